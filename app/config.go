@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-ozzo/ozzo-validation"
 	"github.com/spf13/viper"
 )
@@ -39,6 +40,134 @@ type appConfig struct {
 	Logs map[string]string `mapstructure:"logs"`
 
 	Ethereum map[string]string `mapstructure:"ethereum"`
+
+	// ExchangeAddresses are the exchange contracts accepted in orders besides the primary
+	// ethereum.exchange_address, eg. while migrating to a newly deployed contract
+	ExchangeAddresses []string `mapstructure:"exchange_addresses"`
+
+	// CancelAllWindow is the maximum age in seconds of a signed cancel all orders message.
+	// Defaults to 30
+	CancelAllWindow int64 `mapstructure:"cancel_all_window"`
+
+	// DebugFills makes the engine check that the fills of every order never exceed the order
+	// amounts and sum up exactly to them once the order is filled. Defaults to false
+	DebugFills bool `mapstructure:"debug_fills"`
+
+	// MaxOpenOrdersPerAccount is the default maximum number of open orders of an account over
+	// all pairs. Defaults to 1000, 0 disables the limit
+	MaxOpenOrdersPerAccount int `mapstructure:"max_open_orders_per_account"`
+
+	// MaxOpenOrdersPerAccountPerPair is the default maximum number of open orders of an account
+	// on a single pair. Defaults to 200, 0 disables the limit
+	MaxOpenOrdersPerAccountPerPair int `mapstructure:"max_open_orders_per_account_per_pair"`
+
+	// MaxBatchOrders is the maximum number of orders of a batch of new orders. Defaults to 20
+	MaxBatchOrders int `mapstructure:"max_batch_orders"`
+
+	// OrderSweepInterval is the interval in seconds between two removals of the expired orders
+	// and of the orders that their makers can no longer cover from the orderbooks. Defaults to 10
+	OrderSweepInterval int64 `mapstructure:"order_sweep_interval"`
+
+	// CommandLogDir is the directory where the engine writes the log of its commands, a new log
+	// being created on every start. Defaults to "", which disables the log
+	CommandLogDir string `mapstructure:"command_log_dir"`
+
+	// CircuitBreakerBand is the maximum distance, in basis points, between the price of the trades
+	// of an incoming order and the last trade of its pair, or the mid price before the first trade.
+	// Orders trading further are stopped and their unfilled amount cancelled. Defaults to 0, which
+	// disables the circuit breakers
+	CircuitBreakerBand int64 `mapstructure:"circuit_breaker_band"`
+
+	// CircuitBreakerCooldown is the number of seconds a pair is halted for once its circuit breaker
+	// tripped, only cancels being accepted. Defaults to 0, which only stops the tripping order
+	CircuitBreakerCooldown int64 `mapstructure:"circuit_breaker_cooldown"`
+
+	// OrderDedupSize is the number of order hashes remembered by each orderbook, so that an order
+	// submitted twice is only matched once. Defaults to 100000, 0 disables the dedup cache
+	OrderDedupSize int `mapstructure:"order_dedup_size"`
+
+	// OrderDedupTTL is the number of seconds during which each orderbook remembers an order hash.
+	// Defaults to 3600
+	OrderDedupTTL int64 `mapstructure:"order_dedup_ttl"`
+
+	// OpeningAuctionDuration is the number of seconds of the opening call auction of a pair when
+	// the auction is started without duration. Defaults to 300
+	OpeningAuctionDuration int64 `mapstructure:"opening_auction_duration"`
+
+	// ExecutionPricePolicy is the price at which an incoming order trades with the resting orders it
+	// crosses: MAKER_PRICE, the price of the resting order, or MIDPOINT, halfway between the prices
+	// of the two orders. Defaults to MAKER_PRICE
+	ExecutionPricePolicy string `mapstructure:"execution_price_policy"`
+
+	// SettlementTimeout is the number of seconds after which a trade whose transaction is not mined
+	// is considered failed and its amount given back to its orders. Defaults to 600
+	SettlementTimeout int64 `mapstructure:"settlement_timeout"`
+
+	// CancelOnDisconnectGrace is the number of seconds after which the orders of a lost cancel on
+	// disconnect websocket session are cancelled, unless its address authenticates a new session
+	// in the meantime. Defaults to 5
+	CancelOnDisconnectGrace int64 `mapstructure:"cancel_on_disconnect_grace"`
+
+	// WebsocketPingInterval is the number of seconds between two pings sent to each websocket
+	// connection, connections not answering within two intervals being closed. Defaults to 30,
+	// 0 disables the heartbeat
+	WebsocketPingInterval int64 `mapstructure:"websocket_ping_interval"`
+
+	// OrderQueueCapacity is the number of new orders and other commands that can be queued for
+	// each orderbook, new orders being rejected with ENGINE_BUSY once the queue is full. Defaults
+	// to 1024
+	OrderQueueCapacity int `mapstructure:"order_queue_capacity"`
+
+	// CancelQueueCapacity is the number of cancellations that can be queued for each orderbook.
+	// Cancellations are run before the queued orders. Defaults to 4096
+	CancelQueueCapacity int `mapstructure:"cancel_queue_capacity"`
+
+	// AuditSnapshotInterval is the interval in seconds between two signed audit snapshots of the
+	// orderbooks. Defaults to 3600, 0 disables the periodic snapshots
+	AuditSnapshotInterval int64 `mapstructure:"audit_snapshot_interval"`
+
+	// OrderArchiveAge is the number of seconds after their last update after which the filled,
+	// cancelled and expired orders are moved to the archive collection. Defaults to 2592000 (30
+	// days), 0 disables the archival
+	OrderArchiveAge int64 `mapstructure:"order_archive_age"`
+
+	// OrderArchiveInterval is the interval in seconds between two archivals of orders. Defaults to
+	// 3600
+	OrderArchiveInterval int64 `mapstructure:"order_archive_interval"`
+
+	// OrderRetention is the number of seconds after their last update after which the orders of
+	// each final status (e.g. CANCELLED) are deleted from the orders collection. Statuses without
+	// retention are kept forever. Empty by default
+	OrderRetention map[string]int64 `mapstructure:"order_retention"`
+
+	// OrderRetentionInterval is the interval in seconds between two deletions of the orders past
+	// their retention. Defaults to 3600
+	OrderRetentionInterval int64 `mapstructure:"order_retention_interval"`
+
+	// MongoRetries is the number of times a read or an upsert is run again after the connection to
+	// mongo was lost, the session being refreshed before. Defaults to 3
+	MongoRetries int `mapstructure:"mongo_retries"`
+
+	// MongoRetryBackoff is the delay in milliseconds before the first retry of an operation, doubled
+	// at every retry. Defaults to 100
+	MongoRetryBackoff int64 `mapstructure:"mongo_retry_backoff"`
+
+	// LookupCacheTTL is the number of seconds during which the pairs and tokens looked up by
+	// address are served from memory. Defaults to 60, 0 disables the cache
+	LookupCacheTTL int64 `mapstructure:"lookup_cache_ttl"`
+
+	// OperatorWalletSource is where the operator wallets are loaded from: "db" (the wallets
+	// collection) or "env" (environment variables, see services.EnvWalletSource). Defaults to "db"
+	OperatorWalletSource string `mapstructure:"operator_wallet_source"`
+
+	// ChangeFeed makes the server tail the oplog of the database and deliver to its websocket
+	// clients the order and trade updates it did not receive through RabbitMQ, eg. when the engine
+	// runs in another process. Requires a replica set. Defaults to false
+	ChangeFeed bool `mapstructure:"change_feed"`
+
+	// ChangeFeedID identifies the position of the server in the oplog, which is stored so that the
+	// feed resumes from it after a restart. Defaults to "", which uses the hostname
+	ChangeFeedID string `mapstructure:"change_feed_id"`
 }
 
 func (config appConfig) Validate() error {
@@ -46,9 +175,50 @@ func (config appConfig) Validate() error {
 		validation.Field(&config.DSN, validation.Required),
 		validation.Field(&config.JWTSigningKey, validation.Required),
 		validation.Field(&config.JWTVerificationKey, validation.Required),
+		validation.Field(&config.ExecutionPricePolicy, validation.In("MAKER_PRICE", "MIDPOINT")),
+		validation.Field(&config.OrderQueueCapacity, validation.Min(1)),
+		validation.Field(&config.CancelQueueCapacity, validation.Min(1)),
+		validation.Field(&config.AuditSnapshotInterval, validation.Min(0)),
+		validation.Field(&config.OrderArchiveAge, validation.Min(0)),
+		validation.Field(&config.OrderArchiveInterval, validation.Min(1)),
+		validation.Field(&config.OrderRetentionInterval, validation.Min(1)),
+		validation.Field(&config.MongoRetries, validation.Min(0)),
+		validation.Field(&config.MongoRetryBackoff, validation.Min(1)),
+		validation.Field(&config.LookupCacheTTL, validation.Min(0)),
+		validation.Field(&config.OperatorWalletSource, validation.In("db", "env")),
 	)
 }
 
+// PrimaryExchangeAddress returns the address of the exchange contract used by default
+func (config appConfig) PrimaryExchangeAddress() common.Address {
+	return common.HexToAddress(config.Ethereum["exchange_address"])
+}
+
+// AllowedExchangeAddresses returns the primary exchange address followed by the other
+// exchange addresses accepted in orders
+func (config appConfig) AllowedExchangeAddresses() []common.Address {
+	addresses := []common.Address{config.PrimaryExchangeAddress()}
+	for _, a := range config.ExchangeAddresses {
+		addr := common.HexToAddress(a)
+		if addr != addresses[0] {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	return addresses
+}
+
+// IsAllowedExchangeAddress returns true if orders can be sent to the given exchange contract
+func (config appConfig) IsAllowedExchangeAddress(addr common.Address) bool {
+	for _, a := range config.AllowedExchangeAddresses() {
+		if a == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
 // LoadConfig loads configuration from the given list of paths and populates it into the Config variable.
 // The configuration file(s) should be named as app.yaml.
 // Environment variables with the prefix "RESTFUL_" in their names are also read automatically.
@@ -64,6 +234,33 @@ func LoadConfig(configPath string, env string) error {
 	v.SetDefault("error_file", "config/errors.yaml")
 	v.SetDefault("server_port", 8081)
 	v.SetDefault("jwt_signing_method", "HS256")
+	v.SetDefault("cancel_all_window", 30)
+	v.SetDefault("max_open_orders_per_account", 1000)
+	v.SetDefault("max_open_orders_per_account_per_pair", 200)
+	v.SetDefault("max_batch_orders", 20)
+	v.SetDefault("order_sweep_interval", 10)
+	v.SetDefault("command_log_dir", "")
+	v.SetDefault("circuit_breaker_band", 0)
+	v.SetDefault("circuit_breaker_cooldown", 0)
+	v.SetDefault("order_dedup_size", 100000)
+	v.SetDefault("order_dedup_ttl", 3600)
+	v.SetDefault("opening_auction_duration", 300)
+	v.SetDefault("execution_price_policy", "MAKER_PRICE")
+	v.SetDefault("settlement_timeout", 600)
+	v.SetDefault("cancel_on_disconnect_grace", 5)
+	v.SetDefault("websocket_ping_interval", 30)
+	v.SetDefault("order_queue_capacity", 1024)
+	v.SetDefault("cancel_queue_capacity", 4096)
+	v.SetDefault("audit_snapshot_interval", 3600)
+	v.SetDefault("order_archive_age", 2592000)
+	v.SetDefault("order_archive_interval", 3600)
+	v.SetDefault("order_retention_interval", 3600)
+	v.SetDefault("mongo_retries", 3)
+	v.SetDefault("mongo_retry_backoff", 100)
+	v.SetDefault("lookup_cache_ttl", 60)
+	v.SetDefault("operator_wallet_source", "db")
+	v.SetDefault("change_feed", false)
+	v.SetDefault("change_feed_id", "")
 	v.AddConfigPath(configPath)
 
 	if err := v.ReadInConfig(); err != nil {
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/go-ethereum/log"
+	"github.com/spf13/cobra"
+)
+
+// migrateWalletsCmd represents the migrate-wallets command
+var migrateWalletsCmd = &cobra.Command{
+	Use:   "migrate-wallets",
+	Short: "Encrypt wallet private keys stored in the database",
+	Long:  `Re-encrypts wallet private keys stored in plain hex or with an older key using the current WALLET_ENCRYPTION_KEY`,
+	Run:   migrateWallets,
+}
+
+// migratePricepointsCmd represents the migrate-pricepoints command
+var migratePricepointsCmd = &cobra.Command{
+	Use:   "migrate-pricepoints",
+	Short: "Recompute the pricepoints of pending orders",
+	Long:  `Recomputes the pricepoints of the pending orders of pairs whose tokens have different decimals, which were priced as if both tokens had 18 decimals`,
+	Run:   migratePricepoints,
+}
+
+// findDuplicateOrdersCmd represents the find-duplicate-orders command
+var findDuplicateOrdersCmd = &cobra.Command{
+	Use:   "find-duplicate-orders",
+	Short: "List the orders stored with the same hash",
+	Long:  `Lists the orders stored with the same hash, which must be removed before the unique index on the order hashes can be built. The index is not required, so the command can be run before the first start of a version building it.`,
+	Run:   findDuplicateOrders,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateWalletsCmd)
+	rootCmd.AddCommand(migratePricepointsCmd)
+	rootCmd.AddCommand(findDuplicateOrdersCmd)
+}
+
+func migrateWallets(cmd *cobra.Command, args []string) {
+	// the records encrypted with a previous key are decrypted with the WALLET_ENCRYPTION_KEY_V<n> keys
+	err := types.LoadWalletEncryptionKeys()
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = daos.InitSession(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	n, err := daos.NewWalletDao().EncryptWallets()
+	if err != nil {
+		panic(err)
+	}
+
+	log.Info("wallets migrated", "count", n)
+}
+
+func migratePricepoints(cmd *cobra.Command, args []string) {
+	_, err := daos.InitSession(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	pairs, err := daos.NewPairDao().GetAll()
+	if err != nil {
+		panic(err)
+	}
+
+	orderDao := daos.NewOrderDao()
+	for i := range pairs {
+		p := &pairs[i]
+		if p.BaseTokenDecimal == p.QuoteTokenDecimal {
+			continue
+		}
+
+		n, err := orderDao.RecomputePricePoints(p)
+		if err != nil {
+			panic(err)
+		}
+
+		log.Info("orders migrated", "pair", p.Name(), "count", n)
+	}
+}
+
+func findDuplicateOrders(cmd *cobra.Command, args []string) {
+	_, err := daos.InitSession(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	duplicates, err := daos.FindDuplicateOrderHashes(app.Config.DBName)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, d := range duplicates {
+		log.Warn("duplicate order", "hash", d.Hash.Hex(), "ids", d.IDs, "statuses", d.Statuses)
+	}
+
+	log.Info("orders checked", "duplicates", len(duplicates))
+	if len(duplicates) > 0 {
+		os.Exit(1)
+	}
+}
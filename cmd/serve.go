@@ -3,6 +3,11 @@ package cmd
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/contracts"
@@ -10,13 +15,14 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/daos"
 	"github.com/Proofsuite/amp-matching-engine/endpoints"
 	"github.com/Proofsuite/amp-matching-engine/ethereum"
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/operator"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/redis"
 	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/Proofsuite/go-ethereum/log"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
 
@@ -36,8 +42,17 @@ func init() {
 }
 
 func run(cmd *cobra.Command, args []string) {
+	// the wallets are encrypted before they are stored
+	err := types.LoadWalletEncryptionKeys()
+	if err != nil {
+		panic(err)
+	}
+
 	// connect to the database
-	_, err := daos.InitSession(nil)
+	daos.MongoRetries = app.Config.MongoRetries
+	daos.MongoRetryBackoff = time.Duration(app.Config.MongoRetryBackoff) * time.Millisecond
+	daos.LookupCacheTTL = time.Duration(app.Config.LookupCacheTTL) * time.Second
+	_, err = daos.InitSession(nil)
 	if err != nil {
 		panic(err)
 	}
@@ -69,25 +84,71 @@ func NewRouter(
 	tokenDao := daos.NewTokenDao()
 	pairDao := daos.NewPairDao()
 	tradeDao := daos.NewTradeDao()
+	matchDao := daos.NewMatchDao(orderDao, tradeDao)
+	orderCancelDao := daos.NewOrderCancelDao()
+	balanceChangeDao := daos.NewBalanceChangeDao()
 	accountDao := daos.NewAccountDao()
 	walletDao := daos.NewWalletDao()
+	tokenListingDao := daos.NewTokenListingDao()
+	auditSnapshotDao := daos.NewAuditSnapshotDao()
 
 	// instantiate engine
+	engine.DebugFills = app.Config.DebugFills
+	engine.CircuitBreakerBand = app.Config.CircuitBreakerBand
+	engine.CircuitBreakerCooldown = time.Duration(app.Config.CircuitBreakerCooldown) * time.Second
+	engine.DedupCacheSize = app.Config.OrderDedupSize
+	engine.DedupTTL = time.Duration(app.Config.OrderDedupTTL) * time.Second
+	engine.ExecutionPricePolicy = app.Config.ExecutionPricePolicy
+	engine.OrderQueueCapacity = app.Config.OrderQueueCapacity
+	engine.CancelQueueCapacity = app.Config.CancelQueueCapacity
 	eng := engine.NewEngine(redisConn, rabbitConn, pairDao)
 
+	// log the engine commands so that the orderbooks and their trades can be replayed
+	if app.Config.CommandLogDir != "" {
+		name := fmt.Sprintf("commands-%v.log", time.Now().Unix())
+		f, err := os.Create(filepath.Join(app.Config.CommandLogDir, name))
+		if err != nil {
+			panic(err)
+		}
+
+		eng.LogCommands(f)
+	}
+
+	// complete the matches interrupted by a crash, then reconcile the orderbooks recovered from
+	// redis with the open orders and the pending trades
+	_, err := matchDao.RecoverMatches()
+	if err != nil {
+		panic(err)
+	}
+
+	err = eng.Reconcile(orderDao, tradeDao)
+	if err != nil {
+		panic(err)
+	}
+
 	// get services for injection
-	accountService := services.NewAccountService(accountDao, tokenDao)
-	ohlcvService := services.NewOHLCVService(tradeDao)
-	tokenService := services.NewTokenService(tokenDao)
+	services.CancelAllWindow = time.Duration(app.Config.CancelAllWindow) * time.Second
+	services.MaxOpenOrdersPerAccount = app.Config.MaxOpenOrdersPerAccount
+	services.MaxOpenOrdersPerAccountPerPair = app.Config.MaxOpenOrdersPerAccountPerPair
+	services.MaxBatchOrders = app.Config.MaxBatchOrders
+	services.OpeningAuctionDuration = time.Duration(app.Config.OpeningAuctionDuration) * time.Second
+	accountService := services.NewAccountService(accountDao, tokenDao, balanceChangeDao, orderDao)
+	ohlcvService := services.NewOHLCVService(tradeDao, pairDao)
+	tokenService := services.NewTokenService(tokenDao, provider)
+	tokenListingService := services.NewTokenListingService(tokenListingDao, tokenDao, walletDao, provider)
 	tradeService := services.NewTradeService(tradeDao)
 	pairService := services.NewPairService(pairDao, tokenDao, eng, tradeService)
-	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, eng, provider, rabbitConn)
+	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, matchDao, orderCancelDao, balanceChangeDao, eng, provider, rabbitConn)
 	orderBookService := services.NewOrderBookService(pairDao, tokenDao, orderDao, eng)
+	eng.SubscribeOrderBookDiffs(orderBookService.BroadcastOrderBookDiffs)
+	eng.SubscribePairStatus(pairService.BroadcastPairStatus)
+	eng.SubscribeTickers(pairService.BroadcastTicker)
 	walletService := services.NewWalletService(walletDao)
+	authService := services.NewAuthService(walletDao)
 	cronService := crons.NewCronService(ohlcvService)
 
 	// get exchange contract instance
-	exchangeAddress := common.HexToAddress(app.Config.Ethereum["exchange_address"])
+	exchangeAddress := app.Config.PrimaryExchangeAddress()
 	exchange, err := contracts.NewExchange(
 		walletService,
 		exchangeAddress,
@@ -99,7 +160,8 @@ func NewRouter(
 	}
 
 	// deploy operator
-	op, err := operator.NewOperator(
+	operator.SettlementTimeout = time.Duration(app.Config.SettlementTimeout) * time.Second
+	op, err := newOperator(
 		walletService,
 		tradeService,
 		orderService,
@@ -112,21 +174,156 @@ func NewRouter(
 		panic(err)
 	}
 
+	// orders can also be sent to the other allowed exchange contracts
+	for _, addr := range app.Config.AllowedExchangeAddresses()[1:] {
+		ex, err := contracts.NewExchange(walletService, addr, provider.Client)
+		if err != nil {
+			panic(err)
+		}
+
+		op.AddExchange(ex)
+	}
+
 	// deploy http and ws endpoints
-	endpoints.ServeAccountResource(r, accountService)
+	ws.DisconnectGracePeriod = time.Duration(app.Config.CancelOnDisconnectGrace) * time.Second
+	ws.PingInterval = time.Duration(app.Config.WebsocketPingInterval) * time.Second
+	endpoints.ServeAuthResource(r, authService)
+	endpoints.ServeAccountResource(r, accountService, orderService, authService)
 	endpoints.ServeTokenResource(r, tokenService)
-	endpoints.ServePairResource(r, pairService)
+	endpoints.ServeTokenListingResource(r, tokenListingService, authService)
+	endpoints.ServePairResource(r, pairService, authService)
 	endpoints.ServeOrderBookResource(r, orderBookService)
 	endpoints.ServeOHLCVResource(r, ohlcvService)
 	endpoints.ServeTradeResource(r, tradeService)
 	endpoints.ServeOrderResource(r, orderService, eng)
 
+	// sign the audit snapshots of the orderbooks with the first operator account
+	if len(op.TxQueues) > 0 {
+		auditService := services.NewAuditService(auditSnapshotDao, eng, op.TxQueues[0].Signer)
+		endpoints.ServeAuditResource(r, auditService, authService)
+		if app.Config.AuditSnapshotInterval > 0 {
+			auditService.SnapshotOrderBooks(time.Duration(app.Config.AuditSnapshotInterval) * time.Second)
+		}
+	}
+
 	//initialize rabbitmq subscriptions
 	rabbitConn.SubscribeOrders(eng.HandleOrders)
 	rabbitConn.SubscribeTrades(op.HandleTrades)
 	rabbitConn.SubscribeOperator(orderService.HandleOperatorMessages)
 	rabbitConn.SubscribeEngineResponses(orderService.HandleEngineResponse)
 
+	// deliver the order and trade updates written by other processes to the websocket clients
+	if app.Config.ChangeFeed {
+		id := app.Config.ChangeFeedID
+		if id == "" {
+			id, _ = os.Hostname()
+		}
+
+		services.NewChangeFeedService(daos.NewChangeWatcher(id)).Start()
+	}
+
+	// move the old filled, cancelled and expired orders to the archive collection
+	if app.Config.OrderArchiveAge > 0 {
+		archiveService := services.NewArchiveService(orderDao, time.Duration(app.Config.OrderArchiveAge)*time.Second)
+		endpoints.ServeArchiveResource(r, archiveService, authService)
+		archiveService.ArchiveOrders(time.Duration(app.Config.OrderArchiveInterval) * time.Second)
+	}
+
+	// delete the orders of the final statuses with a retention window once they are past it
+	if len(app.Config.OrderRetention) > 0 {
+		retention := make(map[string]time.Duration)
+		for status, seconds := range app.Config.OrderRetention {
+			retention[status] = time.Duration(seconds) * time.Second
+		}
+
+		retentionService, err := services.NewRetentionService(orderDao, retention)
+		if err != nil {
+			panic(err)
+		}
+
+		retentionService.EnforceRetention(time.Duration(app.Config.OrderRetentionInterval) * time.Second)
+	}
+
+	// remove expired orders and orders that their makers can no longer cover from the orderbooks
+	eng.SweepOrders(time.Duration(app.Config.OrderSweepInterval)*time.Second, accountService)
+
+	// remove the resting orders from the orderbooks within a second of their expiry
+	eng.ExpireOrders(500 * time.Millisecond)
+
+	// resume the pairs halted by their circuit breaker within a second of the end of their cooldown
+	eng.ResumeHaltedPairs(500 * time.Millisecond)
+
+	// close the opening auctions of the pairs within a second of their end
+	eng.CloseAuctions(500 * time.Millisecond)
+
+	// replace the orderbook mutation logs with snapshots
+	eng.SnapshotOrderbooks(time.Minute)
+
 	cronService.InitCrons()
 	return r
 }
+
+// newOperator creates the operator. If the OPERATOR_MNEMONIC environment variable is set, the operator
+// accounts are derived from the mnemonic for each index of OPERATOR_INDEX_RANGE (for example "0-4",
+// defaults to "0-0"). Otherwise the operator wallets are loaded from the environment when the
+// operator_wallet_source configuration is "env" (see services.EnvWalletSource), or from the database.
+func newOperator(
+	walletService interfaces.WalletService,
+	tradeService interfaces.TradeService,
+	orderService interfaces.OrderService,
+	provider interfaces.EthereumProvider,
+	exchange interfaces.Exchange,
+	conn *rabbitmq.Connection,
+) (*operator.Operator, error) {
+	mnemonic := os.Getenv("OPERATOR_MNEMONIC")
+	if mnemonic == "" {
+		var source interfaces.WalletSource = walletService
+		if app.Config.OperatorWalletSource == "env" {
+			source = services.NewEnvWalletSource()
+		}
+
+		return operator.NewOperator(source, walletService, tradeService, orderService, provider, exchange, conn)
+	}
+
+	start, end, err := parseIndexRange(os.Getenv("OPERATOR_INDEX_RANGE"))
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := types.NewWalletsFromMnemonicRange(mnemonic, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	signers := []types.Signer{}
+	for _, w := range wallets {
+		w.Operator = true
+		signers = append(signers, w)
+	}
+
+	return operator.NewOperatorWithSigners(signers, walletService, tradeService, orderService, provider, exchange, conn)
+}
+
+// parseIndexRange parses an index range of the form "start-end" or "index"
+func parseIndexRange(r string) (int, int, error) {
+	if r == "" {
+		return 0, 0, nil
+	}
+
+	bounds := strings.SplitN(r, "-", 2)
+	start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid index range %v: %v", r, err)
+	}
+
+	if len(bounds) == 1 {
+		return start, start, nil
+	}
+
+	end, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid index range %v: %v", r, err)
+	}
+
+	return start, end, nil
+}
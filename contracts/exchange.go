@@ -137,10 +137,7 @@ func (e *Exchange) Operator(a common.Address) (bool, error) {
 // by the Maker and the Taker of the trade. Only the operator account can send a Trade function to the
 // Exchange smart contract.
 func (e *Exchange) Trade(o *types.Order, t *types.Trade, txOpts *bind.TransactOpts) (*eth.Transaction, error) {
-	orderValues := [8]*big.Int{o.BuyAmount, o.SellAmount, o.Expires, o.Nonce, o.MakeFee, o.TakeFee, t.Amount, t.TradeNonce}
-	orderAddresses := [4]common.Address{o.BuyToken, o.SellToken, o.UserAddress, t.Taker}
-	vValues := [2]uint8{o.Signature.V, t.Signature.V}
-	rsValues := [4][32]byte{o.Signature.R, o.Signature.S, t.Signature.R, t.Signature.S}
+	orderValues, orderAddresses, vValues, rsValues := executeTradeArgs(o, t)
 
 	tx, err := e.Interface.ExecuteTrade(txOpts, orderValues, orderAddresses, vValues, rsValues)
 	if err != nil {
@@ -152,10 +149,7 @@ func (e *Exchange) Trade(o *types.Order, t *types.Trade, txOpts *bind.TransactOp
 }
 
 func (e *Exchange) CallTrade(o *types.Order, t *types.Trade, call *ethereum.CallMsg) (uint64, error) {
-	orderValues := [8]*big.Int{o.BuyAmount, o.SellAmount, o.Expires, o.Nonce, o.MakeFee, o.TakeFee, t.Amount, t.TradeNonce}
-	orderAddresses := [4]common.Address{o.BuyToken, o.SellToken, o.UserAddress, t.Taker}
-	vValues := [2]uint8{o.Signature.V, t.Signature.V}
-	rsValues := [4][32]byte{o.Signature.R, o.Signature.S, t.Signature.R, t.Signature.S}
+	orderValues, orderAddresses, vValues, rsValues := executeTradeArgs(o, t)
 
 	exchangeABI, err := abi.JSON(strings.NewReader(contractsinterfaces.ExchangeABI))
 	if err != nil {
@@ -177,6 +171,30 @@ func (e *Exchange) CallTrade(o *types.Order, t *types.Trade, call *ethereum.Call
 	return gasLimit, nil
 }
 
+// executeTradeArgs packs the order and trade into the arguments of the executeTrade function.
+// Trades hashed with the fees (hash version 1 and above) are settled with the fees signed by the
+// taker, while legacy trades are settled with the fees of the order.
+func executeTradeArgs(o *types.Order, t *types.Trade) ([8]*big.Int, [4]common.Address, [2]uint8, [4][32]byte) {
+	makeFee, takeFee := o.MakeFee, o.TakeFee
+	if t.HashVersion >= types.TradeHashV1 {
+		makeFee, takeFee = big.NewInt(0), big.NewInt(0)
+		if t.MakeFee != nil {
+			makeFee = t.MakeFee
+		}
+
+		if t.TakeFee != nil {
+			takeFee = t.TakeFee
+		}
+	}
+
+	orderValues := [8]*big.Int{o.BuyAmount, o.SellAmount, o.Expires, o.Nonce, makeFee, takeFee, t.Amount, t.TradeNonce}
+	orderAddresses := [4]common.Address{o.BuyToken, o.SellToken, o.UserAddress, t.Taker}
+	vValues := [2]uint8{o.Signature.V, t.Signature.V}
+	rsValues := [4][32]byte{o.Signature.R, o.Signature.S, t.Signature.R, t.Signature.S}
+
+	return orderValues, orderAddresses, vValues, rsValues
+}
+
 // ListenToErrorEvents returns a channel that receives errors logs (events) from the exchange smart contract.
 // The error IDs correspond to the following codes:
 // 1. MAKER_INSUFFICIENT_BALANCE,
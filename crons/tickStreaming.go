@@ -35,8 +35,8 @@ func (s *CronService) tickStream(unit string, duration int64) func() {
 		}
 
 		for _, tick := range ticks {
-			baseTokenAddress := tick.ID.BaseToken
-			quoteTokenAddress := tick.ID.QuoteToken
+			baseTokenAddress := tick.Pair.BaseToken
+			quoteTokenAddress := tick.Pair.QuoteToken
 			id := utils.GetTickChannelID(baseTokenAddress, quoteTokenAddress, unit, duration)
 			ws.GetOHLCVSocket().BroadcastOHLCV(id, tick)
 		}
@@ -1,6 +1,7 @@
 package daos
 
 import (
+	"errors"
 	"math/big"
 	"time"
 
@@ -28,7 +29,7 @@ func NewAccountDao() *AccountDao {
 		Unique: true,
 	}
 
-	err := db.Session.DB(dbName).C(collection).EnsureIndex(index)
+	err := db.EnsureIndexes(dbName, collection, index)
 	if err != nil {
 		panic(err)
 	}
@@ -163,6 +164,7 @@ func (dao *AccountDao) UpdateTokenBalance(owner, token common.Address, tokenBala
 			"tokenBalances." + token.Hex() + ".lockedBalance":  tokenBalance.LockedBalance.String(),
 			"tokenBalances." + token.Hex() + ".pendingBalance": tokenBalance.PendingBalance.String(),
 		},
+		"$inc": bson.M{balancesVersion: 1},
 	}
 
 	err := db.Update(dao.dbName, dao.collectionName, q, updateQuery)
@@ -175,6 +177,7 @@ func (dao *AccountDao) UpdateBalance(owner common.Address, token common.Address,
 	}
 	updateQuery := bson.M{
 		"$set": bson.M{"tokenBalances." + token.Hex() + ".balance": balance.String()},
+		"$inc": bson.M{balancesVersion: 1},
 	}
 
 	err := db.Update(dao.dbName, dao.collectionName, q, updateQuery)
@@ -188,6 +191,271 @@ func (dao *AccountDao) UpdateAllowance(owner common.Address, token common.Addres
 
 	updateQuery := bson.M{
 		"$set": bson.M{"tokenBalances." + token.Hex() + ".allowance": allowance.String()},
+		"$inc": bson.M{balancesVersion: 1},
+	}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, updateQuery)
+	return err
+}
+
+// balancesVersion is the field of the account documents incremented by every update of their
+// token balances. The balances are stored as decimal strings, which can not be incremented nor
+// compared by mongo, so they are updated by a compare and swap on this version.
+const balancesVersion = "balancesVersion"
+
+// BalanceUpdateRetries is the number of times the balances of an account are read and updated
+// again after a concurrent update before UpdateTokenBalances gives up
+var BalanceUpdateRetries = 10
+
+// ErrBalanceUpdateConflict is returned when the balances of an account were updated concurrently
+// at every attempt of UpdateTokenBalances
+var ErrBalanceUpdateConflict = errors.New("Token balances were updated concurrently")
+
+// UpdateTokenBalances applies fn to the account of owner and writes the balances of the given
+// tokens in a single update, which only succeeds if the balances of the account were not updated
+// since they were read. Otherwise the account is read and fn is applied again, up to
+// BalanceUpdateRetries times, so that concurrent updates, from this process or another one, are
+// never lost. The balance changes returned by fn are returned, or its error if it fails.
+// mgo.ErrNotFound is returned if there is no account for owner.
+func (dao *AccountDao) UpdateTokenBalances(owner common.Address, fn func(acc *types.Account) ([]*types.BalanceChange, error), tokens ...common.Address) ([]*types.BalanceChange, error) {
+	for i := 0; i <= BalanceUpdateRetries; i++ {
+		docs := []bson.Raw{}
+		err := db.Get(dao.dbName, dao.collectionName, bson.M{"address": owner.Hex()}, 0, 1, &docs)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if len(docs) == 0 {
+			return nil, mgo.ErrNotFound
+		}
+
+		acc := &types.Account{}
+		version := struct {
+			Version int64 `bson:"balancesVersion"`
+		}{}
+
+		err = docs[0].Unmarshal(acc)
+		if err == nil {
+			err = docs[0].Unmarshal(&version)
+		}
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		changes, err := fn(acc)
+		if err != nil {
+			return nil, err
+		}
+
+		set := bson.M{}
+		for _, token := range tokens {
+			tb, ok := acc.TokenBalances[token]
+			if !ok {
+				continue
+			}
+
+			key := "tokenBalances." + token.Hex()
+			set[key+".balance"] = tb.Balance.String()
+			set[key+".allowance"] = tb.Allowance.String()
+			set[key+".lockedBalance"] = tb.LockedBalance.String()
+			set[key+".pendingBalance"] = tb.PendingBalance.String()
+		}
+
+		q := bson.M{"address": owner.Hex(), balancesVersion: version.Version}
+		if version.Version == 0 {
+			q[balancesVersion] = bson.M{"$exists": false}
+		}
+
+		update := bson.M{"$inc": bson.M{balancesVersion: 1}}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+
+		err = db.run(func(sc *mgo.Session) error {
+			return sc.DB(dao.dbName).C(dao.collectionName).Update(q, update)
+		})
+
+		// the balances were updated since they were read, or the account was removed, which is
+		// found when it is read again
+		if err == mgo.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		return changes, nil
+	}
+
+	logger.Error(ErrBalanceUpdateConflict)
+	return nil, ErrBalanceUpdateConflict
+}
+
+// LockBalance locks an amount of token of the account of owner for an order. The amount is
+// checked against the spendable balance and locked atomically, so that concurrent orders can not
+// lock more than the balance. types.ErrInsufficientBalance is returned if the spendable balance is
+// lower than the amount.
+func (dao *AccountDao) LockBalance(owner, token common.Address, amount *big.Int) (*types.BalanceChange, error) {
+	changes, err := dao.UpdateTokenBalances(owner, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		lock, err := acc.Lock(token, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*types.BalanceChange{lock}, nil
+	}, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes[0], nil
+}
+
+// UnlockBalance releases an amount of token of the account of owner locked with LockBalance
+func (dao *AccountDao) UnlockBalance(owner, token common.Address, amount *big.Int) (*types.BalanceChange, error) {
+	changes, err := dao.UpdateTokenBalances(owner, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		unlock, err := acc.Unlock(token, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*types.BalanceChange{unlock}, nil
+	}, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes[0], nil
+}
+
+// TransferLocked settles a fill of an order of the account of owner: the amount spent is taken
+// from the locked balance of the sell token and the amount received is credited to the balance of
+// the buy token, in a single update
+func (dao *AccountDao) TransferLocked(owner, sellToken, buyToken common.Address, spent, received *big.Int) ([]*types.BalanceChange, error) {
+	return dao.UpdateTokenBalances(owner, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		debit, err := acc.SpendLocked(sellToken, spent)
+		if err != nil {
+			return nil, err
+		}
+
+		credit, err := acc.Credit(buyToken, received)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*types.BalanceChange{debit, credit}, nil
+	}, sellToken, buyToken)
+}
+
+// ConsumeOrderNonce records nonce as the last order nonce of an account if it is higher than
+// the previous one. The check and the update are performed in a single atomic update so that
+// concurrent orders can not consume the same nonce. It returns false if the nonce was already consumed.
+func (dao *AccountDao) ConsumeOrderNonce(owner common.Address, nonce *big.Int) (bool, error) {
+	encoded := types.EncodeOrderNonce(nonce)
+
+	q := bson.M{
+		"address": owner.Hex(),
+		"$or": []bson.M{
+			bson.M{"orderNonce": bson.M{"$exists": false}},
+			bson.M{"orderNonce": bson.M{"$lt": encoded}},
+		},
+	}
+
+	update := bson.M{
+		"$set": bson.M{"orderNonce": encoded},
+	}
+
+	// the nonce is consumed by a conditional update, which is not retried after a transient error
+	consume := func() error {
+		return db.run(func(sc *mgo.Session) error {
+			return sc.DB(dao.dbName).C(dao.collectionName).Update(q, update)
+		})
+	}
+
+	err := consume()
+
+	// the account may have no document yet, which is created with the nonce. The unique index on
+	// the addresses rejects the insert if the account exists, in which case its document was
+	// created concurrently or its nonce is not lower, and the nonce is checked again.
+	if err == mgo.ErrNotFound {
+		err = db.Create(dao.dbName, dao.collectionName, &types.Account{
+			ID:            bson.NewObjectId(),
+			Address:       owner,
+			TokenBalances: map[common.Address]*types.TokenBalance{},
+			OrderNonce:    nonce,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		})
+
+		if mgo.IsDup(err) {
+			err = consume()
+		}
+	}
+
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetOrderNonce returns the last order nonce consumed by an account or nil if the account
+// has not placed any order yet
+func (dao *AccountDao) GetOrderNonce(owner common.Address) (*big.Int, error) {
+	a, err := dao.GetByAddress(owner)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if a == nil {
+		return nil, nil
+	}
+
+	return a.OrderNonce, nil
+}
+
+// UpdateOpenOrderLimits sets the open orders limits of an account. The account is reverted to the
+// default limits when limits is nil.
+func (dao *AccountDao) UpdateOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) error {
+	q := bson.M{
+		"address": owner.Hex(),
+	}
+
+	updateQuery := bson.M{
+		"$unset": bson.M{"openOrderLimits": ""},
+	}
+
+	if limits != nil {
+		updateQuery = bson.M{
+			"$set": bson.M{"openOrderLimits": limits},
+		}
+	}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, updateQuery)
+	return err
+}
+
+// UpdateBlocked sets whether an account is blocked from sending orders
+func (dao *AccountDao) UpdateBlocked(owner common.Address, blocked bool) error {
+	q := bson.M{
+		"address": owner.Hex(),
+	}
+
+	updateQuery := bson.M{
+		"$set": bson.M{"isBlocked": blocked},
 	}
 
 	err := db.Update(dao.dbName, dao.collectionName, q, updateQuery)
@@ -9,6 +9,7 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -223,3 +224,149 @@ func TestUpdateAccountBalance(t *testing.T) {
 
 	assert.Equal(t, balance.Balance, big.NewInt(20000))
 }
+
+func TestConsumeOrderNonce(t *testing.T) {
+	dao := NewAccountDao()
+	dao.Drop()
+
+	address := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+	err := dao.Create(&types.Account{Address: address, TokenBalances: map[common.Address]*types.TokenBalance{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce, err := dao.GetOrderNonce(address)
+	assert.Nil(t, err)
+	assert.Nil(t, nonce)
+
+	ok, err := dao.ConsumeOrderNonce(address, big.NewInt(256))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// replayed and lower nonces are rejected
+	ok, err = dao.ConsumeOrderNonce(address, big.NewInt(256))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = dao.ConsumeOrderNonce(address, big.NewInt(15))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	nonce, err = dao.GetOrderNonce(address)
+	assert.Nil(t, err)
+	assert.Equal(t, "256", nonce.String())
+
+	// concurrent orders with the same nonce can only be accepted once
+	results := make(chan bool, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			ok, err := dao.ConsumeOrderNonce(address, big.NewInt(1000))
+			results <- ok && err == nil
+		}()
+	}
+
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		if <-results {
+			accepted++
+		}
+	}
+
+	assert.Equal(t, 1, accepted)
+
+	// the first nonce of an account without document creates its document
+	other := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	ok, err = dao.ConsumeOrderNonce(other, big.NewInt(1))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = dao.ConsumeOrderNonce(other, big.NewInt(1))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	nonce, err = dao.GetOrderNonce(other)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", nonce.String())
+}
+
+func TestLockBalance(t *testing.T) {
+	dao := NewAccountDao()
+	dao.Drop()
+
+	address := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+	sellToken := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
+	buyToken := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+
+	account := &types.Account{
+		Address: address,
+		TokenBalances: map[common.Address]*types.TokenBalance{
+			sellToken: {
+				Address:       sellToken,
+				Balance:       big.NewInt(1000),
+				Allowance:     big.NewInt(1000),
+				LockedBalance: big.NewInt(0),
+			},
+		},
+	}
+
+	err := dao.Create(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent orders can not lock more than the balance
+	results := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			_, err := dao.LockBalance(address, sellToken, big.NewInt(100))
+			results <- err
+		}()
+	}
+
+	locked := 0
+	for i := 0; i < 100; i++ {
+		err := <-results
+		if err == nil {
+			locked++
+		} else {
+			assert.Equal(t, types.ErrInsufficientBalance, err)
+		}
+	}
+
+	assert.Equal(t, 10, locked)
+
+	tb, err := dao.GetTokenBalance(address, sellToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "1000", tb.LockedBalance.String())
+
+	// a fill moves the amount spent out of the locked balance and credits the amount received
+	changes, err := dao.TransferLocked(address, sellToken, buyToken, big.NewInt(300), big.NewInt(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(changes))
+
+	unlock, err := dao.UnlockBalance(address, sellToken, big.NewInt(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, unlock)
+
+	balances, err := dao.GetTokenBalances(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "700", balances[sellToken].Balance.String())
+	assert.Equal(t, "500", balances[sellToken].LockedBalance.String())
+	assert.Equal(t, "30", balances[buyToken].Balance.String())
+
+	// the balances of a missing account are not updated
+	_, err = dao.LockBalance(common.HexToAddress("0x1"), sellToken, big.NewInt(100))
+	assert.Equal(t, mgo.ErrNotFound, err)
+}
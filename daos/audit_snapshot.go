@@ -0,0 +1,90 @@
+package daos
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditSnapshotDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type AuditSnapshotDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewAuditSnapshotDao returns a new instance of AuditSnapshotDao. Audit snapshots are only
+// inserted, never updated.
+func NewAuditSnapshotDao() *AuditSnapshotDao {
+	dbName := app.Config.DBName
+	collection := "audit_snapshots"
+	index := mgo.Index{
+		Key: []string{"baseToken", "quoteToken", "-timestamp"},
+	}
+
+	err := db.EnsureIndexes(dbName, collection, index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &AuditSnapshotDao{collection, dbName}
+}
+
+// Create inserts a snapshot in the audit snapshot collection
+func (dao *AuditSnapshotDao) Create(s *types.AuditSnapshot) error {
+	s.ID = bson.NewObjectId()
+
+	err := db.Create(dao.dbName, dao.collectionName, s)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByID returns the snapshot with the given id, or nil if there is none
+func (dao *AuditSnapshotDao) GetByID(id bson.ObjectId) (*types.AuditSnapshot, error) {
+	res := []*types.AuditSnapshot{}
+
+	err := db.Get(dao.dbName, dao.collectionName, bson.M{"_id": id}, 0, 1, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+// GetByPair returns the snapshots of a pair, the most recent first. At most limit snapshots are
+// returned after skipping the first offset snapshots.
+func (dao *AuditSnapshotDao) GetByPair(bt, qt common.Address, offset, limit int) ([]*types.AuditSnapshot, error) {
+	q := bson.M{"baseToken": bt.Hex(), "quoteToken": qt.Hex()}
+	res := []*types.AuditSnapshot{}
+
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"-timestamp", "-_id"}, offset, limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Drop drops all the audit snapshot documents in the current database
+func (dao *AuditSnapshotDao) Drop() error {
+	err := db.DropCollection(dao.dbName, dao.collectionName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package daos
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// BalanceChangeDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type BalanceChangeDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewBalanceChangeDao returns a new instance of BalanceChangeDao. Balance changes form the
+// ledger of the account balances.
+func NewBalanceChangeDao() *BalanceChangeDao {
+	dbName := app.Config.DBName
+	collection := "balance_changes"
+	index := mgo.Index{
+		Key: []string{"address", "-timestamp"},
+	}
+
+	err := db.EnsureIndexes(dbName, collection, index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &BalanceChangeDao{collection, dbName}
+}
+
+// Create inserts balance changes in the balance change collection
+func (dao *BalanceChangeDao) Create(changes ...*types.BalanceChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	docs := []interface{}{}
+	for _, c := range changes {
+		c.ID = bson.NewObjectId()
+		docs = append(docs, c)
+	}
+
+	err := db.Create(dao.dbName, dao.collectionName, docs...)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByAddress returns the balance changes of an account, the most recent first. At most limit
+// changes are returned after skipping the first offset changes.
+func (dao *BalanceChangeDao) GetByAddress(addr common.Address, offset, limit int) ([]*types.BalanceChange, error) {
+	q := bson.M{"address": addr.Hex()}
+	res := []*types.BalanceChange{}
+
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"-timestamp", "-_id"}, offset, limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Drop drops all the balance change documents in the current database
+func (dao *BalanceChangeDao) Drop() error {
+	err := db.DropCollection(dao.dbName, dao.collectionName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
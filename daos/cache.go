@@ -0,0 +1,73 @@
+package daos
+
+import (
+	"sync"
+	"time"
+)
+
+// LookupCacheTTL is the time during which the pairs and tokens read by PairDao and TokenDao are
+// served from memory. Pairs and tokens updated through the DAOs are read again right away, the
+// TTL bounds the staleness of the records updated by another process. 0 disables the cache.
+var LookupCacheTTL = 60 * time.Second
+
+// lookupCache is a read-through cache of the records returned by the lookups of a DAO. Entries
+// expire after the TTL and are all removed by invalidate, which the DAO calls on every write.
+// It is safe for concurrent use.
+type lookupCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]lookupCacheEntry
+
+	// generation is incremented by every invalidation, so that a record read before an
+	// invalidation is not cached after it
+	generation uint64
+}
+
+type lookupCacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// newLookupCache returns an empty cache whose entries expire after ttl
+func newLookupCache(ttl time.Duration) *lookupCache {
+	return &lookupCache{ttl: ttl, entries: make(map[string]lookupCacheEntry)}
+}
+
+// load returns the cached value of key, or calls fn and caches its value if it is not cached yet
+// or has expired. Errors and nil values are not cached.
+func (c *lookupCache) load(key string, fn func() (interface{}, error)) (interface{}, error) {
+	if c.ttl <= 0 {
+		return fn()
+	}
+
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	generation := c.generation
+	c.mu.RUnlock()
+
+	if ok && time.Now().Before(e.expires) {
+		return e.value, nil
+	}
+
+	v, err := fn()
+	if err != nil || v == nil {
+		return v, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[key] = lookupCacheEntry{v, time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+
+	return v, nil
+}
+
+// invalidate removes all the entries of the cache
+func (c *lookupCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]lookupCacheEntry)
+	c.generation++
+}
@@ -0,0 +1,209 @@
+package daos
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestLookupCache(t *testing.T) {
+	c := newLookupCache(50 * time.Millisecond)
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	// the first lookup is a miss, the next ones are served from the cache until the entry expires
+	v, err := c.load("a", fn)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+
+	v, _ = c.load("a", fn)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(60 * time.Millisecond)
+	v, _ = c.load("a", fn)
+	assert.Equal(t, 2, v)
+
+	// invalidated entries are read again
+	c.invalidate()
+	v, _ = c.load("a", fn)
+	assert.Equal(t, 3, v)
+
+	// errors and nil values are not cached
+	_, err = c.load("b", func() (interface{}, error) { return nil, errors.New("Lookup failed") })
+	assert.EqualError(t, err, "Lookup failed")
+
+	v, _ = c.load("b", func() (interface{}, error) { return nil, nil })
+	assert.Nil(t, v)
+
+	v, _ = c.load("b", fn)
+	assert.Equal(t, 4, v)
+
+	// a value read before an invalidation is not cached after it
+	v, _ = c.load("c", func() (interface{}, error) {
+		c.invalidate()
+		return "stale", nil
+	})
+
+	assert.Equal(t, "stale", v)
+	v, _ = c.load("c", func() (interface{}, error) { return "fresh", nil })
+	assert.Equal(t, "fresh", v)
+
+	// a TTL of 0 disables the cache
+	c = newLookupCache(0)
+	c.load("a", fn)
+	c.load("a", fn)
+	assert.Equal(t, 6, calls)
+}
+
+func TestLookupCacheConcurrentInvalidation(t *testing.T) {
+	c := newLookupCache(time.Minute)
+
+	var mu sync.Mutex
+	version := 0
+	read := func() (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return version, nil
+	}
+
+	// readers never see a version older than the one written before their lookup started
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				mu.Lock()
+				written := version
+				mu.Unlock()
+
+				v, err := c.load("pair", read)
+				assert.Nil(t, err)
+				assert.True(t, v.(int) >= written)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		version++
+		mu.Unlock()
+		c.invalidate()
+	}
+
+	wg.Wait()
+}
+
+func TestPairDaoCache(t *testing.T) {
+	dao := NewPairDao()
+
+	pair := &types.Pair{
+		BaseTokenSymbol:   "CCH",
+		BaseTokenAddress:  common.HexToAddress("0x1b0aa9d7f3d4d5a2be5a2c1e6a26bb1f80cfc2f8"),
+		QuoteTokenSymbol:  "WETH",
+		QuoteTokenAddress: common.HexToAddress("0x2a6b5e1c5ea10b05a3e1c8fd4d1b07e2d4b5b8c1"),
+		Active:            true,
+		MakeFee:           big.NewInt(10000),
+		TakeFee:           big.NewInt(10000),
+	}
+
+	err := dao.Create(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := dao.GetByTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, cached.Paused)
+
+	// the pairs returned are copies of the cached pairs
+	cached.Paused = true
+	cached, _ = dao.GetByTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	assert.False(t, cached.Paused)
+
+	// updates from another process are only seen once the pair expires
+	q := bson.M{"baseTokenAddress": pair.BaseTokenAddress.Hex(), "quoteTokenAddress": pair.QuoteTokenAddress.Hex()}
+	err = db.Update(dao.dbName, dao.collectionName, q, bson.M{"$set": bson.M{"paused": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, _ = dao.GetByBuySellTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	assert.False(t, cached.Paused)
+	cached, _ = dao.GetByTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	assert.False(t, cached.Paused)
+
+	// updates through the DAO are seen right away
+	err = dao.UpdateActive(pair.BaseTokenAddress, pair.QuoteTokenAddress, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, _ = dao.GetByTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	assert.False(t, cached.Active)
+	assert.True(t, cached.Paused)
+
+	cached, _ = dao.GetByBuySellTokenAddress(pair.QuoteTokenAddress, pair.BaseTokenAddress)
+	assert.False(t, cached.Active)
+
+	// unknown pairs are not cached
+	unknown := common.HexToAddress("0x3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f")
+	_, err = dao.GetByTokenAddress(unknown, pair.QuoteTokenAddress)
+	assert.EqualError(t, err, "Pair not found")
+}
+
+func TestTokenDaoCache(t *testing.T) {
+	dao := NewTokenDao()
+	dao.Drop()
+
+	addr := common.HexToAddress("0x1b0aa9d7f3d4d5a2be5a2c1e6a26bb1f80cfc2f8")
+
+	// unknown tokens are not cached, tokens created through the DAO are seen right away
+	token, err := dao.GetByAddress(addr)
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	err = dao.Create(&types.Token{Name: "Cache", Symbol: "CCH", ContractAddress: addr, Decimal: 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = dao.GetByAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CCH", token.Symbol)
+
+	// updates from another process are only seen once the token expires
+	q := bson.M{"contractAddress": addr.Hex()}
+	err = db.Update(dao.dbName, dao.collectionName, q, bson.M{"$set": bson.M{"symbol": "NEW"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _ = dao.GetByAddress(addr)
+	assert.Equal(t, "CCH", token.Symbol)
+
+	dao.cache = newLookupCache(10 * time.Millisecond)
+	dao.GetByAddress(addr)
+	time.Sleep(20 * time.Millisecond)
+
+	token, _ = dao.GetByAddress(addr)
+	assert.Equal(t, "NEW", token.Symbol)
+}
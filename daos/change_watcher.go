@@ -0,0 +1,227 @@
+package daos
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrChangesUnsupported is returned by ChangeWatcher.Watch when the deployment has no oplog, eg. a
+// standalone mongod, in which case the changes of the orders and trades can not be observed
+var ErrChangesUnsupported = errors.New("Changes can not be observed without the oplog of a replica set")
+
+// ChangeTokenSaveInterval is the minimum delay between two saves of the position of a watcher in
+// the oplog. A restarted watcher may observe again the changes of the last interval.
+var ChangeTokenSaveInterval = time.Second
+
+// ChangeRetryDelay is the delay before the oplog is tailed again after the tail failed, eg.
+// during a failover
+var ChangeRetryDelay = time.Second
+
+// oplogTailTimeout is the time a tail of the oplog waits for new entries before the position of
+// the watcher is saved
+const oplogTailTimeout = 5 * time.Second
+
+// oplogEntry is an insert or update of the oplog of a replica set. Inserts hold the inserted
+// document and updates the selector of the updated document.
+type oplogEntry struct {
+	Timestamp bson.MongoTimestamp `bson:"ts"`
+	Namespace string              `bson:"ns"`
+	Operation string              `bson:"op"`
+	Object    bson.Raw            `bson:"o"`
+	Selector  struct {
+		ID bson.ObjectId `bson:"_id"`
+	} `bson:"o2"`
+}
+
+// ChangeWatcher observes the inserts and updates of the orders and trades, whichever process
+// wrote them, by tailing the oplog of the replica set as mgo does not support change streams. Its
+// position in the oplog is stored in the change_tokens collection under its id, so that a
+// restarted watcher resumes after the last change it observed.
+type ChangeWatcher struct {
+	id                  string
+	dbName              string
+	tokenCollectionName string
+	orderCollectionName string
+	tradeCollectionName string
+}
+
+// NewChangeWatcher returns a new instance of ChangeWatcher whose position is stored under the
+// given id, which must be different for every process watching the changes
+func NewChangeWatcher(id string) *ChangeWatcher {
+	return &ChangeWatcher{
+		id:                  id,
+		dbName:              app.Config.DBName,
+		tokenCollectionName: "change_tokens",
+		orderCollectionName: orderCollectionName,
+		tradeCollectionName: "trades",
+	}
+}
+
+// Watch calls fn with each change of the orders and trades, in the order of the oplog, from the
+// stored position of the watcher or from the end of the oplog for a new watcher. It blocks and
+// tails the oplog again after ChangeRetryDelay when the tail fails. ErrChangesUnsupported is
+// returned right away if the deployment has no oplog.
+func (w *ChangeWatcher) Watch(fn func(*types.ChangeEvent)) error {
+	from, err := w.position()
+	if err != nil {
+		return err
+	}
+
+	for {
+		from, err = w.tail(from, fn)
+		if err != nil {
+			logger.Error(err)
+			time.Sleep(ChangeRetryDelay)
+		}
+	}
+}
+
+// position returns the position of the watcher in the oplog: its stored position, or the last
+// entry of the oplog for a new watcher
+func (w *ChangeWatcher) position() (bson.MongoTimestamp, error) {
+	first, last := oplogEntry{}, oplogEntry{}
+	err := db.retry(func(sc *mgo.Session) error {
+		oplog := sc.DB("local").C("oplog.rs")
+		err := oplog.Find(nil).Sort("$natural").One(&first)
+		if err != nil {
+			return err
+		}
+
+		return oplog.Find(nil).Sort("-$natural").One(&last)
+	})
+
+	// standalone servers have no oplog
+	if err == mgo.ErrNotFound {
+		return 0, ErrChangesUnsupported
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	tokens := []bson.M{}
+	err = db.Get(w.dbName, w.tokenCollectionName, bson.M{"_id": w.id}, 0, 1, &tokens)
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	if len(tokens) == 0 {
+		return last.Timestamp, nil
+	}
+
+	from := tokens[0]["ts"].(bson.MongoTimestamp)
+	if from < first.Timestamp {
+		logger.Warning("The oplog rolled over since the last change observed by ", w.id, ", changes were missed")
+	}
+
+	return from, nil
+}
+
+// saveToken stores the position of the watcher in the oplog
+func (w *ChangeWatcher) saveToken(ts bson.MongoTimestamp) error {
+	return db.Upsert(w.dbName, w.tokenCollectionName, bson.M{"_id": w.id}, bson.M{
+		"$set": bson.M{"ts": ts, "updatedAt": time.Now()},
+	})
+}
+
+// tail calls fn with the changes of the oplog after from until the tail fails, and returns the
+// position of the last change observed along with the error
+func (w *ChangeWatcher) tail(from bson.MongoTimestamp, fn func(*types.ChangeEvent)) (bson.MongoTimestamp, error) {
+	sc := db.Session.Copy()
+	defer sc.Close()
+
+	q := bson.M{
+		"ts": bson.M{"$gt": from},
+		"ns": bson.M{"$in": []string{w.namespace(w.orderCollectionName), w.namespace(w.tradeCollectionName)}},
+		"op": bson.M{"$in": []string{"i", "u"}},
+	}
+
+	iter := sc.DB("local").C("oplog.rs").Find(q).LogReplay().Tail(oplogTailTimeout)
+	saved, savedAt := from, time.Now()
+	for {
+		entry := oplogEntry{}
+		for iter.Next(&entry) {
+			from = entry.Timestamp
+			e, err := w.event(&entry)
+			if err != nil {
+				logger.Error(err)
+			} else if e != nil {
+				fn(e)
+			}
+
+			if time.Since(savedAt) >= ChangeTokenSaveInterval {
+				if err := w.saveToken(from); err == nil {
+					saved, savedAt = from, time.Now()
+				}
+			}
+
+			entry = oplogEntry{}
+		}
+
+		if !iter.Timeout() {
+			break
+		}
+
+		if from != saved {
+			if err := w.saveToken(from); err == nil {
+				saved, savedAt = from, time.Now()
+			}
+		}
+	}
+
+	err := iter.Close()
+	if err == nil {
+		err = errors.New("The tail of the oplog was closed")
+	}
+
+	if from != saved {
+		w.saveToken(from)
+	}
+
+	return from, err
+}
+
+// namespace returns the namespace of a collection in the oplog
+func (w *ChangeWatcher) namespace(collection string) string {
+	return w.dbName + "." + collection
+}
+
+// event returns the change of an entry of the oplog. Updated documents are read from their
+// collection, so that the change holds the whole document. nil is returned for a document
+// deleted since it was updated.
+func (w *ChangeWatcher) event(entry *oplogEntry) (*types.ChangeEvent, error) {
+	collection := strings.TrimPrefix(entry.Namespace, w.dbName+".")
+
+	e := &types.ChangeEvent{Operation: types.ChangeInsert}
+	raw := entry.Object
+	if entry.Operation == "u" {
+		e.Operation = types.ChangeUpdate
+		err := db.GetByID(w.dbName, collection, entry.Selector.ID, &raw)
+		if err == mgo.ErrNotFound {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch collection {
+	case w.orderCollectionName:
+		e.Order = &types.Order{}
+		return e, raw.Unmarshal(e.Order)
+	case w.tradeCollectionName:
+		e.Trade = &types.Trade{}
+		return e, raw.Unmarshal(e.Trade)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,102 @@
+package daos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestChangeWatcherUnsupported(t *testing.T) {
+	w := NewChangeWatcher("test")
+
+	// the test server is a standalone server without oplog
+	err := w.Watch(func(e *types.ChangeEvent) {
+		t.Error("Unexpected change", e)
+	})
+
+	assert.Equal(t, ErrChangesUnsupported, err)
+}
+
+func TestChangeWatcherEvent(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	w := NewChangeWatcher("test")
+	trades := ohlcvTrades(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), 2, time.Minute)
+
+	// inserts hold the inserted document
+	data, err := bson.Marshal(trades[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := w.event(&oplogEntry{
+		Namespace: w.namespace("trades"),
+		Operation: "i",
+		Object:    bson.Raw{Kind: 3, Data: data},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.ChangeInsert, e.Operation)
+	assert.Nil(t, e.Order)
+	assert.Equal(t, trades[0].Hash, e.Trade.Hash)
+
+	// updated documents are read from their collection
+	trades[1].Status = types.TradeStatusPending
+	err = dao.Create(trades[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dao.UpdateTradeStatus(trades[1].Hash, types.TradeStatusSuccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &oplogEntry{Namespace: w.namespace("trades"), Operation: "u"}
+	entry.Selector.ID = trades[1].ID
+
+	e, err = w.event(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.ChangeUpdate, e.Operation)
+	assert.Equal(t, trades[1].Hash, e.Trade.Hash)
+	assert.Equal(t, types.TradeStatusSuccess, e.Trade.Status)
+
+	// documents deleted since their update are skipped
+	entry.Selector.ID = bson.NewObjectId()
+	e, err = w.event(entry)
+	assert.Nil(t, err)
+	assert.Nil(t, e)
+}
+
+func TestChangeWatcherSaveToken(t *testing.T) {
+	w := NewChangeWatcher("test")
+	db.Session.DB(w.dbName).C(w.tokenCollectionName).DropCollection()
+
+	ts := bson.MongoTimestamp(6579043473195106305)
+	for _, v := range []bson.MongoTimestamp{ts - 1, ts} {
+		err := w.saveToken(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tokens := []bson.M{}
+	err := db.Get(w.dbName, w.tokenCollectionName, bson.M{"_id": "test"}, 0, 0, &tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a single position is stored by watcher
+	assert.Equal(t, 1, len(tokens))
+	assert.Equal(t, ts, tokens[0]["ts"])
+}
@@ -0,0 +1,146 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MatchDao persists the writes of a match as one unit. mgo does not support multi-document
+// transactions, so a match is recorded in the matches collection before its orders and trades are
+// written, and removed once they all are. A match left in the collection by a crash is completed
+// by RecoverMatches. The writes of a match can be applied again: the orders are updated to the
+// state they have after the match and the trades already stored are not inserted again.
+type MatchDao struct {
+	collectionName string
+	dbName         string
+	orderDao       *OrderDao
+	tradeDao       *TradeDao
+}
+
+// NewMatchDao returns a new instance of MatchDao writing the orders and trades of the matches
+// with the given DAOs
+func NewMatchDao(orderDao *OrderDao, tradeDao *TradeDao) *MatchDao {
+	dbName := app.Config.DBName
+	collection := "matches"
+	// the matches left by a crash are completed in the order in which they were recorded
+	err := db.EnsureIndexes(dbName, collection, mgo.Index{Key: []string{"createdAt"}})
+	if err != nil {
+		panic(err)
+	}
+
+	return &MatchDao{collection, dbName, orderDao, tradeDao}
+}
+
+// PersistMatch records a match, inserts its trades, updates its taker and maker orders and
+// removes the record. If the match can not be recorded nothing is written. If one of its writes
+// fails the record is kept and the match is completed by RecoverMatches. As with
+// TradeDao.CreateBatch, the trades that can not be inserted are returned in a
+// types.TradeBatchErrors, the rest of the match being persisted.
+func (dao *MatchDao) PersistMatch(m *types.MatchResult) error {
+	m.ID = bson.NewObjectId()
+	m.CreatedAt = time.Now()
+
+	err := db.Create(dao.dbName, dao.collectionName, m)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	errs, err := dao.apply(m, false)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	// the writes of the match are applied whether or not the record is removed, in which case
+	// they are applied again harmlessly on the next start
+	err = db.Remove(dao.dbName, dao.collectionName, bson.M{"_id": m.ID})
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return errs.Err()
+}
+
+// RecoverMatches completes the matches left in the matches collection by a crash and returns the
+// number of matches completed. It must run on startup, before the engine reconciles the
+// orderbooks with the database. A match that still can not be completed is logged and kept for
+// the next start.
+func (dao *MatchDao) RecoverMatches() (int, error) {
+	pending := []*types.MatchResult{}
+	err := db.GetAndSort(dao.dbName, dao.collectionName, bson.M{}, []string{"createdAt"}, 0, 0, &pending)
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	n := 0
+	for _, m := range pending {
+		errs, err := dao.apply(m, true)
+		if err != nil {
+			logger.Error("Could not complete match ", m.ID.Hex(), ": ", err)
+			continue
+		}
+
+		// the trades inserted before the crash are found again
+		for _, e := range errs {
+			if e.Err != types.ErrDuplicateTrade {
+				logger.Error("Could not create trade ", e.Trade.Hash.Hex(), " of match ", m.ID.Hex(), ": ", e.Err)
+			}
+		}
+
+		err = db.Remove(dao.dbName, dao.collectionName, bson.M{"_id": m.ID})
+		if err != nil {
+			logger.Error(err)
+			return n, err
+		}
+
+		logger.Infof("Completed match %v of order %v", m.ID.Hex(), m.Taker.Hash.Hex())
+		n++
+	}
+
+	return n, nil
+}
+
+// apply inserts the trades and updates the orders of a match. The trades that could not be
+// inserted are returned apart from the error that interrupted the writes. The orders of a match
+// none of whose trades could be inserted are left untouched, unless the match is recovered, in
+// which case its trades were inserted before the crash.
+func (dao *MatchDao) apply(m *types.MatchResult, recovering bool) (types.TradeBatchErrors, error) {
+	errs := types.TradeBatchErrors{}
+	err := dao.tradeDao.CreateBatch(m.Trades)
+	if batchErrs, ok := err.(types.TradeBatchErrors); ok {
+		errs = batchErrs
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !recovering && len(m.Trades) > 0 && len(errs) == len(m.Trades) {
+		return errs, nil
+	}
+
+	orders := append([]*types.Order{m.Taker}, m.Makers...)
+	for _, o := range orders {
+		err := dao.orderDao.UpdateByHash(o.Hash, o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return errs, nil
+}
+
+// Drop drops the matches collection
+func (dao *MatchDao) Drop() error {
+	err := db.DropCollection(dao.dbName, dao.collectionName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
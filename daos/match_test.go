@@ -0,0 +1,182 @@
+package daos
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// matchOrders stores a taker order and two maker orders and returns them with the amounts filled
+// by their match, along with its trades
+func matchOrders(t *testing.T, orderDao *OrderDao) *types.MatchResult {
+	orders := []*types.Order{}
+	for i := 1; i <= 3; i++ {
+		o := &types.Order{
+			UserAddress:  common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			BaseToken:    common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+			QuoteToken:   common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+			BuyAmount:    big.NewInt(1000),
+			SellAmount:   big.NewInt(100),
+			PricePoint:   big.NewInt(1000),
+			Amount:       big.NewInt(1000),
+			FilledAmount: big.NewInt(0),
+			Side:         "SELL",
+			PairName:     "ZRX/WETH",
+			MakeFee:      big.NewInt(0),
+			TakeFee:      big.NewInt(0),
+			Hash:         common.BigToHash(big.NewInt(int64(i))),
+		}
+
+		err := orderDao.Create(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		orders = append(orders, o)
+	}
+
+	taker, makers := orders[0], orders[1:]
+	taker.Side = "BUY"
+	taker.FilledAmount = big.NewInt(1000)
+	taker.Status = types.OrderStatusFilled
+
+	trades := ohlcvTrades(time.Now(), 2, time.Second)
+	for i, m := range makers {
+		m.FilledAmount = big.NewInt(500)
+		m.Status = types.OrderStatusPartialFilled
+		trades[i].OrderHash = m.Hash
+		trades[i].TakerOrderHash = taker.Hash
+		trades[i].Amount = big.NewInt(500)
+	}
+
+	return &types.MatchResult{Taker: taker, Makers: makers, Trades: trades}
+}
+
+func assertMatchPersisted(t *testing.T, orderDao *OrderDao, tradeDao *TradeDao, m *types.MatchResult) {
+	for _, o := range append([]*types.Order{m.Taker}, m.Makers...) {
+		stored, err := orderDao.GetByHash(o.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, o.Status, stored.Status)
+		assert.Equal(t, o.FilledAmount, stored.FilledAmount)
+	}
+
+	for _, tr := range m.Trades {
+		stored, err := tradeDao.GetByHash(tr.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotNil(t, stored)
+	}
+
+	all, err := tradeDao.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(m.Trades), len(all))
+}
+
+func TestMatchDaoPersistMatch(t *testing.T) {
+	orderDao := NewOrderDao()
+	orderDao.Drop()
+	tradeDao := NewTradeDao()
+	tradeDao.Drop()
+	dao := NewMatchDao(orderDao, tradeDao)
+	dao.Drop()
+
+	m := matchOrders(t, orderDao)
+	err := dao.PersistMatch(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertMatchPersisted(t, orderDao, tradeDao, m)
+
+	// the match is removed once persisted
+	pending := []*types.MatchResult{}
+	err = db.Get(dao.dbName, dao.collectionName, bson.M{}, 0, 0, &pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, len(pending))
+}
+
+func TestMatchDaoPersistMatchDuplicateTrades(t *testing.T) {
+	orderDao := NewOrderDao()
+	orderDao.Drop()
+	tradeDao := NewTradeDao()
+	tradeDao.Drop()
+	dao := NewMatchDao(orderDao, tradeDao)
+	dao.Drop()
+
+	m := matchOrders(t, orderDao)
+	err := tradeDao.CreateBatch(m.Trades)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a match none of whose trades can be inserted leaves its orders untouched
+	err = dao.PersistMatch(m)
+	errs, ok := err.(types.TradeBatchErrors)
+	if !ok {
+		t.Fatalf("Expected trade batch errors, got %v", err)
+	}
+
+	assert.Equal(t, 2, len(errs))
+
+	taker, err := orderDao.GetByHash(m.Taker.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.OrderStatusOpen, taker.Status)
+	assert.Equal(t, "0", taker.FilledAmount.String())
+}
+
+func TestMatchDaoRecoverMatches(t *testing.T) {
+	orderDao := NewOrderDao()
+	orderDao.Drop()
+	tradeDao := NewTradeDao()
+	tradeDao.Drop()
+	dao := NewMatchDao(orderDao, tradeDao)
+	dao.Drop()
+
+	// the process crashed after inserting the first trade of a match
+	m := matchOrders(t, orderDao)
+	m.ID = bson.NewObjectId()
+	m.CreatedAt = time.Now()
+	err := db.Create(dao.dbName, dao.collectionName, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tradeDao.CreateBatch(m.Trades[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := dao.RecoverMatches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+	assertMatchPersisted(t, orderDao, tradeDao, m)
+
+	n, err = dao.RecoverMatches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, n)
+}
@@ -1,7 +1,10 @@
 package daos
 
 import (
+	"errors"
 	"math/big"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
@@ -12,12 +15,72 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
+// OrderArchiveBatchSize is the number of orders moved to the archive collection at once
+var OrderArchiveBatchSize = 1000
+
+// OrderDeleteBatchSize is the number of orders removed at once by DeleteOrdersBefore, so that
+// the collection is not locked for long
+var OrderDeleteBatchSize = 1000
+
+// ErrRetainedOrderStatus is returned when deleting orders that are not in a final status
+var ErrRetainedOrderStatus = errors.New("Only the orders in a final status can be deleted")
+
+// deletableOrderStatuses are the statuses of the orders that are not matched nor settled anymore,
+// and can be deleted by DeleteOrdersBefore
+var deletableOrderStatuses = []string{
+	types.OrderStatusFilled,
+	types.OrderStatusReplaced,
+	types.OrderStatusCancelled,
+	types.OrderStatusExpired,
+	types.OrderStatusInvalid,
+	types.OrderStatusRejected,
+	types.OrderStatusError,
+}
+
+// archivedOrderStatuses are the statuses of the orders that are moved to the archive collection
+// once they are old enough. Orders in these statuses are not matched nor settled anymore.
+var archivedOrderStatuses = []string{
+	types.OrderStatusFilled,
+	types.OrderStatusCancelled,
+	types.OrderStatusExpired,
+}
+
 // OrderDao contains:
 // collectionName: MongoDB collection name
+// archiveCollectionName: MongoDB collection of the archived orders (see ArchiveOrders)
 // dbName: name of mongodb to interact with
 type OrderDao struct {
-	collectionName string
-	dbName         string
+	collectionName        string
+	archiveCollectionName string
+	dbName                string
+	archive               *orderArchive
+}
+
+// orderArchive holds the time of the most recent update of the archived orders, so that the
+// listings of orders skip the archive collection when it can not hold any of their orders. The
+// creation time of an order is never after its update time.
+type orderArchive struct {
+	mu    sync.RWMutex
+	until time.Time
+}
+
+// Until returns the time of the most recent update of the archived orders, or the zero time if
+// no order was archived
+func (a *orderArchive) Until() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.until
+}
+
+// Extend records that orders last updated at t were archived
+func (a *orderArchive) Extend(t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if t.After(a.until) {
+		a.until = t
+	}
 }
 
 type OrderDaoOption = func(*OrderDao) error
@@ -29,10 +92,16 @@ func OrderDaoDBOption(dbName string) func(dao *OrderDao) error {
 	}
 }
 
-// NewOrderDao returns a new instance of OrderDao
+// orderCollectionName is the collection of the orders that are not archived
+const orderCollectionName = "orders"
+
+// NewOrderDao returns a new instance of OrderDao. It panics if the unique index on the order
+// hashes can not be built, after logging the orders stored with the same hash (see
+// FindDuplicateOrderHashes).
 func NewOrderDao(opts ...OrderDaoOption) *OrderDao {
-	dao := &OrderDao{}
-	dao.collectionName = "orders"
+	dao := &OrderDao{archive: &orderArchive{}}
+	dao.collectionName = orderCollectionName
+	dao.archiveCollectionName = "orders_archive"
 	dao.dbName = app.Config.DBName
 
 	for _, op := range opts {
@@ -42,16 +111,51 @@ func NewOrderDao(opts ...OrderDaoOption) *OrderDao {
 		}
 	}
 
-	index := mgo.Index{
-		Key:    []string{"hash"},
-		Unique: true,
+	// the open orders of an account are counted for every new order and the pending orders of a
+	// pair are loaded when its orderbook is reconciled. The orders of an account are listed by
+	// creation or update time, filtered by status or by pair.
+	err := db.EnsureIndexes(dao.dbName, dao.collectionName,
+		mgo.Index{Key: []string{"hash"}, Unique: true},
+		mgo.Index{Key: []string{"userAddress", "status", "createdAt"}},
+		mgo.Index{Key: []string{"userAddress", "pairName", "createdAt"}},
+		mgo.Index{Key: []string{"userAddress", "createdAt"}},
+		mgo.Index{Key: []string{"userAddress", "updatedAt"}},
+		mgo.Index{Key: []string{"baseToken", "quoteToken", "status"}},
+		mgo.Index{Key: []string{"status", "updatedAt"}},
+	)
+
+	if err != nil {
+		duplicates, _ := FindDuplicateOrderHashes(dao.dbName)
+		for _, d := range duplicates {
+			logger.Errorf("Order %v is stored %v times: %v %v", d.Hash.Hex(), len(d.IDs), d.IDs, d.Statuses)
+		}
+
+		panic(err)
+	}
+
+	// the archived orders are only listed
+	err = db.EnsureIndexes(dao.dbName, dao.archiveCollectionName,
+		mgo.Index{Key: []string{"userAddress", "status", "createdAt"}},
+		mgo.Index{Key: []string{"userAddress", "pairName", "createdAt"}},
+		mgo.Index{Key: []string{"userAddress", "createdAt"}},
+		mgo.Index{Key: []string{"userAddress", "updatedAt"}},
+		mgo.Index{Key: []string{"updatedAt"}},
+	)
+
+	if err != nil {
+		panic(err)
 	}
 
-	err := db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	latest := []bson.M{}
+	err = db.GetAndSort(dao.dbName, dao.archiveCollectionName, bson.M{}, []string{"-updatedAt"}, 0, 1, &latest)
 	if err != nil {
 		panic(err)
 	}
 
+	if len(latest) > 0 {
+		dao.archive.Extend(latest[0]["updatedAt"].(time.Time))
+	}
+
 	return dao
 }
 
@@ -62,10 +166,89 @@ func (dao *OrderDao) Create(order *types.Order) error {
 	order.UpdatedAt = time.Now()
 
 	if order.Status == "" {
-		order.Status = "OPEN"
+		order.Status = types.OrderStatusOpen
 	}
 
 	err := db.Create(dao.dbName, dao.collectionName, order)
+	if mgo.IsDup(err) {
+		return types.ErrOrderAlreadyExists
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpsertByHash persists an order that may already be stored, for the paths that write the same
+// order again such as the recovery of matches. The updateable fields of the stored order (see
+// UpdateByHash) are updated if it can move to the status of o, and o is inserted if no order
+// with its hash is stored.
+func (dao *OrderDao) UpsertByHash(o *types.Order) error {
+	now := time.Now()
+	o.UpdatedAt = now
+	if o.Status == "" {
+		o.Status = types.OrderStatusOpen
+	}
+
+	query := bson.M{"hash": o.Hash.Hex()}
+	stored, err := dao.GetByHash(o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if stored != nil {
+		current := stored.Status
+		err = stored.TransitionTo(o.Status)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		query["status"] = current
+		o.ID, o.CreatedAt = stored.ID, stored.CreatedAt
+	} else {
+		o.ID, o.CreatedAt = bson.NewObjectId(), now
+	}
+
+	set := bson.M{
+		"buyAmount":    o.BuyAmount.String(),
+		"sellAmount":   o.SellAmount.String(),
+		"pricepoint":   o.PricePoint.String(),
+		"amount":       o.Amount.String(),
+		"status":       o.Status,
+		"filledAmount": o.FilledAmount.String(),
+		"makeFee":      o.MakeFee.String(),
+		"takeFee":      o.TakeFee.String(),
+		"updatedAt":    o.UpdatedAt,
+	}
+
+	raw, err := bson.Marshal(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	insert := bson.M{}
+	err = bson.Unmarshal(raw, &insert)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for k := range set {
+		delete(insert, k)
+	}
+
+	// an order stored concurrently, or whose status changed since it was read, is not overwritten
+	err = db.Upsert(dao.dbName, dao.collectionName, query, bson.M{"$set": set, "$setOnInsert": insert})
+	if mgo.IsDup(err) {
+		return types.ErrOrderAlreadyExists
+	}
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -74,6 +257,50 @@ func (dao *OrderDao) Create(order *types.Order) error {
 	return nil
 }
 
+// FindDuplicateOrderHashes returns the orders of the orders collection of the given database that
+// are stored with the same hash, by hash. The unique index on the order hashes can only be built
+// once they are removed, so it does not require the indexes of the collection and can be run
+// before the first OrderDao is created.
+func FindDuplicateOrderHashes(dbName string) ([]*types.DuplicateOrderHash, error) {
+	q := []bson.M{
+		{"$group": bson.M{
+			"_id":      "$hash",
+			"ids":      bson.M{"$push": "$_id"},
+			"statuses": bson.M{"$push": "$status"},
+			"count":    bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	records := []struct {
+		Hash     string          `bson:"_id"`
+		IDs      []bson.ObjectId `bson:"ids"`
+		Statuses []string        `bson:"statuses"`
+	}{}
+
+	// the orders are grouped on disk as the collection may not fit in the memory of the pipeline
+	err := db.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(orderCollectionName).Pipe(q).AllowDiskUse().All(&records)
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	duplicates := []*types.DuplicateOrderHash{}
+	for _, r := range records {
+		duplicates = append(duplicates, &types.DuplicateOrderHash{
+			Hash:     common.HexToHash(r.Hash),
+			IDs:      r.IDs,
+			Statuses: r.Statuses,
+		})
+	}
+
+	return duplicates, nil
+}
+
 // Update function performs the DB updations task for Order collection
 // corresponding to a particular order ID
 func (dao *OrderDao) Update(id bson.ObjectId, o *types.Order) error {
@@ -91,7 +318,13 @@ func (dao *OrderDao) Update(id bson.ObjectId, o *types.Order) error {
 func (dao *OrderDao) UpdateAllByHash(hash common.Hash, o *types.Order) error {
 	o.UpdatedAt = time.Now()
 
-	err := db.Update(dao.dbName, dao.collectionName, bson.M{"hash": hash.Hex()}, o)
+	query, err := dao.statusTransitionQuery(hash, o.Status)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, query, o)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -103,7 +336,13 @@ func (dao *OrderDao) UpdateAllByHash(hash common.Hash, o *types.Order) error {
 //UpdateByHash updates fields that are considered updateable for an order.
 func (dao *OrderDao) UpdateByHash(hash common.Hash, o *types.Order) error {
 	o.UpdatedAt = time.Now()
-	query := bson.M{"hash": hash.Hex()}
+
+	query, err := dao.statusTransitionQuery(hash, o.Status)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
 	update := bson.M{"$set": bson.M{
 		"buyAmount":    o.BuyAmount.String(),
 		"sellAmount":   o.SellAmount.String(),
@@ -116,7 +355,7 @@ func (dao *OrderDao) UpdateByHash(hash common.Hash, o *types.Order) error {
 		"updatedAt":    o.UpdatedAt,
 	}}
 
-	err := db.Update(dao.dbName, dao.collectionName, query, update)
+	err = db.Update(dao.dbName, dao.collectionName, query, update)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -125,13 +364,42 @@ func (dao *OrderDao) UpdateByHash(hash common.Hash, o *types.Order) error {
 	return nil
 }
 
+// UpdateOrderStatus updates the status of an order. Illegal status transitions
+// (for example from FILLED to OPEN) are rejected.
 func (dao *OrderDao) UpdateOrderStatus(hash common.Hash, status string) error {
-	query := bson.M{"hash": hash.Hex()}
+	query, err := dao.statusTransitionQuery(hash, status)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
 	update := bson.M{"$set": bson.M{
 		"status": status,
 	}}
 
-	err := db.Update(dao.dbName, dao.collectionName, query, update)
+	err = db.Update(dao.dbName, dao.collectionName, query, update)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateOrderAmount sets the amount of an open or partially filled order, once it was reduced by
+// the engine
+func (dao *OrderDao) UpdateOrderAmount(hash common.Hash, amount *big.Int) error {
+	q := bson.M{
+		"hash":   hash.Hex(),
+		"status": bson.M{"$in": []string{types.OrderStatusOpen, types.OrderStatusPartialFilled}},
+	}
+
+	update := bson.M{"$set": bson.M{
+		"amount":    amount.String(),
+		"updatedAt": time.Now(),
+	}}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, update)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -149,18 +417,29 @@ func (dao *OrderDao) UpdateOrderFilledAmount(hash common.Hash, value *big.Int) e
 		return err
 	}
 
+	if len(res) == 0 {
+		return errors.New("Order not found")
+	}
+
 	o := res[0]
 	status := ""
 	filledAmount := math.Add(o.FilledAmount, value)
 
 	if math.IsEqualOrSmallerThan(filledAmount, big.NewInt(0)) {
 		filledAmount = big.NewInt(0)
-		status = "OPEN"
+		status = types.OrderStatusOpen
 	} else if math.IsEqualOrGreaterThan(filledAmount, o.Amount) {
 		filledAmount = o.Amount
-		status = "FILLED"
+		status = types.OrderStatusFilled
 	} else {
-		status = "PARTIALLY_FILLED"
+		status = types.OrderStatusPartialFilled
+	}
+
+	current := o.Status
+	err = o.TransitionTo(status)
+	if err != nil {
+		logger.Error(err)
+		return err
 	}
 
 	update := bson.M{"$set": bson.M{
@@ -168,6 +447,7 @@ func (dao *OrderDao) UpdateOrderFilledAmount(hash common.Hash, value *big.Int) e
 		"filledAmount": filledAmount.String(),
 	}}
 
+	q["status"] = current
 	err = db.Update(dao.dbName, dao.collectionName, q, update)
 	if err != nil {
 		logger.Error(err)
@@ -177,6 +457,28 @@ func (dao *OrderDao) UpdateOrderFilledAmount(hash common.Hash, value *big.Int) e
 	return nil
 }
 
+// statusTransitionQuery checks that the order corresponding to the given hash can move to the
+// given status. It returns a query matching the order only if its status has not changed since,
+// so that concurrent updates can not bypass the check.
+func (dao *OrderDao) statusTransitionQuery(hash common.Hash, status string) (bson.M, error) {
+	o, err := dao.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if o == nil {
+		return nil, errors.New("Order not found")
+	}
+
+	current := o.Status
+	err = o.TransitionTo(status)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{"hash": hash.Hex(), "status": current}, nil
+}
+
 // GetByID function fetches a single document from order collection based on mongoDB ID.
 // Returns Order type struct
 func (dao *OrderDao) GetByID(id bson.ObjectId) (*types.Order, error) {
@@ -223,46 +525,351 @@ func (dao *OrderDao) GetByHashes(hashes []common.Hash) ([]*types.Order, error) {
 	return res, nil
 }
 
-// GetByUserAddress function fetches list of orders from order collection based on user address.
-// Returns array of Order type struct
-func (dao *OrderDao) GetByUserAddress(addr common.Address) ([]*types.Order, error) {
-	var res []*types.Order
+// getPage returns a page of up to limit orders matching a query sorted by creation time, starting
+// after a cursor (see Database.GetPage). The cursor of the next page is returned along with the
+// orders, and is empty if there is no next page.
+func (dao *OrderDao) getPage(q bson.M, cursor string, ascending bool, limit int) ([]*types.Order, string, error) {
+	return dao.getArchivedPage(q, time.Time{}, cursor, ascending, limit, false)
+}
+
+// getArchivedPage returns a page of orders like getPage. If archived is set, the orders of the
+// archive collection created from the given time are listed as well (see archiveNeeded).
+func (dao *OrderDao) getArchivedPage(q bson.M, from time.Time, cursor string, ascending bool, limit int, archived bool) ([]*types.Order, string, error) {
+	res := []*types.Order{}
+	err := db.GetPage(dao.dbName, dao.collectionName, q, cursor, ascending, limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, "", err
+	}
+
+	if archived && dao.archiveNeeded(res, limit+1, from, types.OrderSortCreatedAt, ascending) {
+		older := []*types.Order{}
+		err := db.GetPage(dao.dbName, dao.archiveCollectionName, q, cursor, ascending, limit, &older)
+		if err != nil {
+			logger.Error(err)
+			return nil, "", err
+		}
+
+		res = mergeOrders(res, older, types.OrderSortCreatedAt, ascending)
+	}
+
+	if len(res) <= limit {
+		return res, "", nil
+	}
+
+	res = res[:limit]
+	last := res[limit-1]
+	c := &types.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	return res, c.Encode(), nil
+}
+
+// GetByUserAddress function fetches a page of the orders of an account matching a query (see
+// types.OrderQuery). Returns the orders and the cursor of the next page, which is empty on the
+// last page and for the queries paginated by offset
+func (dao *OrderDao) GetByUserAddress(addr common.Address, query types.OrderQuery) ([]*types.Order, string, error) {
+	err := query.Validate()
+	if err != nil {
+		return nil, "", err
+	}
+
 	q := bson.M{"userAddress": addr.Hex()}
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
-	return res, err
+	if len(query.Statuses) > 0 {
+		q["status"] = bson.M{"$in": query.Statuses}
+	}
+
+	if query.PairName != "" {
+		q["pairName"] = query.PairName
+	}
+
+	if query.Side != "" {
+		q["side"] = string(query.Side)
+	}
+
+	createdAt := bson.M{}
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+
+	if !query.To.IsZero() {
+		createdAt["$lt"] = query.To
+	}
+
+	if len(createdAt) > 0 {
+		q["createdAt"] = createdAt
+	}
+
+	if query.SortField() == types.OrderSortCreatedAt && query.Offset == 0 {
+		return dao.getArchivedPage(q, query.From, query.Cursor, query.Ascending, query.Limit, true)
+	}
+
+	sorting := []string{"-" + query.SortField(), "-_id"}
+	if query.Ascending {
+		sorting = []string{query.SortField(), "_id"}
+	}
+
+	// the orders before the offset are fetched from both collections to be merged, but only if
+	// the archive may hold some of them
+	res := []*types.Order{}
+	err = db.GetAndSort(dao.dbName, dao.collectionName, q, sorting, 0, query.Offset+query.Limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, "", err
+	}
+
+	if dao.archiveNeeded(res, query.Offset+query.Limit, query.From, query.SortField(), query.Ascending) {
+		older := []*types.Order{}
+		err = db.GetAndSort(dao.dbName, dao.archiveCollectionName, q, sorting, 0, query.Offset+query.Limit, &older)
+		if err != nil {
+			logger.Error(err)
+			return nil, "", err
+		}
+
+		res = mergeOrders(res, older, query.SortField(), query.Ascending)
+	}
+
+	if query.Offset >= len(res) {
+		return []*types.Order{}, "", nil
+	}
+
+	res = res[query.Offset:]
+	if len(res) > query.Limit {
+		res = res[:query.Limit]
+	}
+
+	return res, "", nil
 }
 
-// GetCurrentByUserAddress function fetches list of open/partial orders from order collection based on user address.
-// Returns array of Order type struct
-func (dao *OrderDao) GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error) {
-	var res []*types.Order
+// archiveNeeded returns true if the archive collection may hold some of the first n orders of a
+// listing starting from the given time (zero for no lower bound), given the orders of the orders
+// collection. The archive only holds orders last updated until the time returned by
+// orderArchive.Until, so it is not needed for listings starting after that time, nor for
+// listings from the most recent order whose first n orders were updated or created after it.
+func (dao *OrderDao) archiveNeeded(current []*types.Order, n int, from time.Time, field string, ascending bool) bool {
+	until := dao.archive.Until()
+	if until.IsZero() || from.After(until) {
+		return false
+	}
+
+	return ascending || len(current) < n || !orderSortValue(current[n-1], field).After(until)
+}
+
+// orderSortValue returns the time an order is sorted by in a listing sorted by the given field
+func orderSortValue(o *types.Order, field string) time.Time {
+	if field == types.OrderSortUpdatedAt {
+		return o.UpdatedAt
+	}
+
+	return o.CreatedAt
+}
+
+// mergeOrders merges orders of the orders collection and of the archive collection, sorted by
+// the given field and then by id. Orders found in both collections while they are being archived
+// are listed once, from the orders collection.
+func mergeOrders(current, archived []*types.Order, field string, ascending bool) []*types.Order {
+	res := []*types.Order{}
+	seen := map[bson.ObjectId]bool{}
+	for _, orders := range [][]*types.Order{current, archived} {
+		for _, o := range orders {
+			if !seen[o.ID] {
+				seen[o.ID] = true
+				res = append(res, o)
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := orderSortValue(res[i], field), orderSortValue(res[j], field)
+		if !a.Equal(b) {
+			return a.Before(b) == ascending
+		}
+
+		return (res[i].ID < res[j].ID) == ascending
+	})
+
+	return res
+}
+
+// ArchiveOrders moves the filled, cancelled and expired orders last updated before the given time
+// from the orders collection to the archive collection, in batches of OrderArchiveBatchSize orders
+// and up to the given number of batches, or until none is left if batches is 0. The orders are
+// archived with all their fields. Each batch is upserted by id before being removed, so that an
+// interrupted archival is resumed by the next one without duplicating nor losing orders, and the
+// listings see the orders being archived in either collection. Returns the number of archived
+// orders.
+func (dao *OrderDao) ArchiveOrders(before time.Time, batches int) (int, error) {
+	q := bson.M{
+		"status":    bson.M{"$in": archivedOrderStatuses},
+		"updatedAt": bson.M{"$lt": before},
+	}
+
+	archived := 0
+	for i := 0; batches == 0 || i < batches; i++ {
+		docs := []bson.M{}
+		err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"updatedAt"}, 0, OrderArchiveBatchSize, &docs)
+		if err != nil {
+			logger.Error(err)
+			return archived, err
+		}
+
+		if len(docs) == 0 {
+			break
+		}
+
+		err = db.UpsertAll(dao.dbName, dao.archiveCollectionName, docs...)
+		if err != nil {
+			logger.Error(err)
+			return archived, err
+		}
+
+		ids := []bson.ObjectId{}
+		for _, doc := range docs {
+			ids = append(ids, doc["_id"].(bson.ObjectId))
+			dao.archive.Extend(doc["updatedAt"].(time.Time))
+		}
+
+		// an order updated since it was read is left in the orders collection, the listings
+		// preferring it to its archived copy until it is archived again
+		err = db.RemoveAll(dao.dbName, dao.collectionName, bson.M{
+			"_id":       bson.M{"$in": ids},
+			"status":    q["status"],
+			"updatedAt": q["updatedAt"],
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return archived, err
+		}
+
+		archived += len(docs)
+	}
+
+	return archived, nil
+}
+
+// DeleteOrdersBefore removes the orders of the given final status last updated strictly before the
+// given time from the orders collection, in batches of OrderDeleteBatchSize orders and up to the
+// given number of batches, or until none is left if batches is 0. Archived orders are kept.
+// ErrRetainedOrderStatus is returned for the statuses of the orders that are still active.
+// Returns the number of removed orders.
+func (dao *OrderDao) DeleteOrdersBefore(status string, before time.Time, batches int) (int, error) {
+	deletable := false
+	for _, s := range deletableOrderStatuses {
+		deletable = deletable || s == status
+	}
+
+	if !deletable {
+		return 0, ErrRetainedOrderStatus
+	}
+
+	q := bson.M{
+		"status":    status,
+		"updatedAt": bson.M{"$lt": before},
+	}
+
+	deleted := 0
+	for i := 0; batches == 0 || i < batches; i++ {
+		docs := []bson.M{}
+		err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"updatedAt"}, 0, OrderDeleteBatchSize, &docs)
+		if err != nil {
+			logger.Error(err)
+			return deleted, err
+		}
+
+		if len(docs) == 0 {
+			break
+		}
+
+		ids := []bson.ObjectId{}
+		for _, doc := range docs {
+			ids = append(ids, doc["_id"].(bson.ObjectId))
+		}
+
+		// an order updated since it was read is kept
+		err = db.RemoveAll(dao.dbName, dao.collectionName, bson.M{
+			"_id":       bson.M{"$in": ids},
+			"status":    q["status"],
+			"updatedAt": q["updatedAt"],
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return deleted, err
+		}
+
+		deleted += len(docs)
+	}
+
+	return deleted, nil
+}
+
+// GetCurrentByUserAddress function fetches a page of the open/partial orders of an account, the most recent first.
+// Returns the orders and the cursor of the next page
+func (dao *OrderDao) GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
 	q := bson.M{
 		"userAddress": addr.Hex(),
 		"status": bson.M{"$in": []string{
-			"OPEN",
-			"PARTIALLY_FILLED",
+			types.OrderStatusOpen,
+			types.OrderStatusPartialFilled,
 		},
 		},
 	}
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
-	return res, err
+	return dao.getPage(q, cursor, false, limit)
 }
 
-// GetHistoryByUserAddress function fetches list of orders which are not in open/partial order status
-// from order collection based on user address.
-// Returns array of Order type struct
-func (dao *OrderDao) GetHistoryByUserAddress(addr common.Address) ([]*types.Order, error) {
-	var res []*types.Order
+// GetHistoryByUserAddress function fetches a page of the orders of an account which are not in
+// open/partial order status, the most recent first, archived orders included.
+// Returns the orders and the cursor of the next page
+func (dao *OrderDao) GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
 	q := bson.M{
 		"userAddress": addr.Hex(),
 		"status": bson.M{"$nin": []string{
-			"OPEN",
-			"PARTIALLY_FILLED",
+			types.OrderStatusOpen,
+			types.OrderStatusPartialFilled,
 		},
 		},
 	}
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
-	return res, err
+	return dao.getArchivedPage(q, time.Time{}, cursor, false, limit, true)
+}
+
+// GetOpenOrderCounts returns the number of open orders of an account by pair name. Stop orders
+// that are not triggered yet are counted as open orders.
+func (dao *OrderDao) GetOpenOrderCounts(addr common.Address) (map[string]int, error) {
+	q := []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"userAddress": addr.Hex(),
+				"status": bson.M{"$in": []string{
+					types.OrderStatusStop,
+					types.OrderStatusOpen,
+					types.OrderStatusPartialFilled,
+				}},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id":   "$pairName",
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+
+	res := []struct {
+		PairName string `bson:"_id"`
+		Count    int    `bson:"count"`
+	}{}
+
+	err := db.Aggregate(dao.dbName, dao.collectionName, q, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, r := range res {
+		counts[r.PairName] = r.Count
+	}
+
+	return counts, nil
 }
 
 func (dao *OrderDao) GetUserLockedBalance(account common.Address, token common.Address) (*big.Int, error) {
@@ -270,8 +877,8 @@ func (dao *OrderDao) GetUserLockedBalance(account common.Address, token common.A
 	q := bson.M{
 		"userAddress": account.Hex(),
 		"status": bson.M{"$in": []string{
-			"OPEN",
-			"PARTIALLY_FILLED",
+			types.OrderStatusOpen,
+			types.OrderStatusPartialFilled,
 		},
 		},
 		"sellToken": token.Hex(),
@@ -284,10 +891,9 @@ func (dao *OrderDao) GetUserLockedBalance(account common.Address, token common.A
 	}
 
 	totalLockedBalance := big.NewInt(0)
+	// the balance released by the reduction of an order is no longer locked
 	for _, o := range orders {
-		filledSellAmount := math.Div(math.Mul(o.FilledAmount, o.SellAmount), o.BuyAmount)
-		lockedBalance := math.Sub(o.SellAmount, filledSellAmount)
-		totalLockedBalance = math.Add(totalLockedBalance, lockedBalance)
+		totalLockedBalance = math.Add(totalLockedBalance, o.RemainingSellAmount())
 	}
 
 	return totalLockedBalance, nil
@@ -296,7 +902,7 @@ func (dao *OrderDao) GetUserLockedBalance(account common.Address, token common.A
 func (dao *OrderDao) GetRawOrderBook(p *types.Pair) ([]*types.Order, error) {
 	var orders []*types.Order
 	q := bson.M{
-		"status":     bson.M{"$in": []string{"OPEN", "PARTIALLY_FILLED"}},
+		"status":     bson.M{"$in": []string{types.OrderStatusOpen, types.OrderStatusPartialFilled}},
 		"baseToken":  p.BaseTokenAddress.Hex(),
 		"quoteToken": p.QuoteTokenAddress.Hex(),
 	}
@@ -315,7 +921,7 @@ func (dao *OrderDao) GetOrderBook(p *types.Pair) ([]map[string]string, []map[str
 	bidsQuery := []bson.M{
 		bson.M{
 			"$match": bson.M{
-				"status":     bson.M{"$in": []string{"OPEN", "PARTIALLY_FILLED"}},
+				"status":     bson.M{"$in": []string{types.OrderStatusOpen, types.OrderStatusPartialFilled}},
 				"baseToken":  p.BaseTokenAddress.Hex(),
 				"quoteToken": p.QuoteTokenAddress.Hex(),
 				"side":       "BUY",
@@ -348,7 +954,7 @@ func (dao *OrderDao) GetOrderBook(p *types.Pair) ([]map[string]string, []map[str
 	asksQuery := []bson.M{
 		bson.M{
 			"$match": bson.M{
-				"status":     bson.M{"$in": []string{"OPEN", "PARTIALLY_FILLED"}},
+				"status":     bson.M{"$in": []string{types.OrderStatusOpen, types.OrderStatusPartialFilled}},
 				"baseToken":  p.BaseTokenAddress.Hex(),
 				"quoteToken": p.QuoteTokenAddress.Hex(),
 				"side":       "SELL",
@@ -399,7 +1005,7 @@ func (dao *OrderDao) GetOrderBookPricePoint(p *types.Pair, pp *big.Int) (*big.In
 	q := []bson.M{
 		bson.M{
 			"$match": bson.M{
-				"status":     bson.M{"$in": []string{"OPEN", "PARTIALLY_FILLED"}},
+				"status":     bson.M{"$in": []string{types.OrderStatusOpen, types.OrderStatusPartialFilled}},
 				"baseToken":  p.BaseTokenAddress.Hex(),
 				"quoteToken": p.QuoteTokenAddress.Hex(),
 				"pricepoint": pp.String(),
@@ -432,6 +1038,62 @@ func (dao *OrderDao) GetOrderBookPricePoint(p *types.Pair, pp *big.Int) (*big.In
 	return math.ToBigInt(res[0]["amount"]), nil
 }
 
+// RecomputePricePoints recomputes the pricepoints of the pending orders of a pair from their
+// amounts (see Pair.PricePoint). It migrates the orders priced before the decimals of the pair
+// tokens were taken into account, whose stop pricepoints are converted as well. Orders whose
+// pricepoint is up to date are left unchanged so that the migration can be run again. It returns
+// the number of migrated orders.
+func (dao *OrderDao) RecomputePricePoints(p *types.Pair) (int, error) {
+	q := bson.M{
+		"status": bson.M{"$in": []string{
+			types.OrderStatusNew,
+			types.OrderStatusStop,
+			types.OrderStatusOpen,
+			types.OrderStatusPartialFilled,
+		}},
+		"baseToken":  p.BaseTokenAddress.Hex(),
+		"quoteToken": p.QuoteTokenAddress.Hex(),
+	}
+
+	var orders []*types.Order
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &orders)
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	baseUnit := math.Exp(big.NewInt(10), big.NewInt(int64(p.BaseTokenDecimal)))
+	quoteUnit := math.Exp(big.NewInt(10), big.NewInt(int64(p.QuoteTokenDecimal)))
+
+	migrated := 0
+	for _, o := range orders {
+		pp := p.PricePoint(o.SellAmount, o.BuyAmount)
+		if o.BuyToken == p.BaseTokenAddress {
+			pp = p.PricePoint(o.BuyAmount, o.SellAmount)
+		}
+
+		if o.PricePoint != nil && o.PricePoint.Cmp(pp) == 0 {
+			continue
+		}
+
+		update := bson.M{"pricepoint": pp.String(), "updatedAt": time.Now()}
+		if o.StopPricepoint != nil {
+			stop := math.Div(math.Mul(o.StopPricepoint, baseUnit), quoteUnit)
+			update["stopPricepoint"] = stop.String()
+		}
+
+		err = db.Update(dao.dbName, dao.collectionName, bson.M{"hash": o.Hash.Hex()}, bson.M{"$set": update})
+		if err != nil {
+			logger.Error(err)
+			return migrated, err
+		}
+
+		migrated++
+	}
+
+	return migrated, nil
+}
+
 // Drop drops all the order documents in the current database
 func (dao *OrderDao) Drop() error {
 	err := db.DropCollection(dao.dbName, dao.collectionName)
@@ -440,5 +1102,13 @@ func (dao *OrderDao) Drop() error {
 		return err
 	}
 
+	// the archive collection only exists once orders were archived
+	err = db.DropCollection(dao.dbName, dao.archiveCollectionName)
+	if err != nil && !isNamespaceNotFound(err) {
+		logger.Error(err)
+		return err
+	}
+
+	dao.archive = &orderArchive{}
 	return nil
 }
@@ -0,0 +1,80 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// OrderCancelDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type OrderCancelDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewOrderCancelDao returns a new instance of OrderCancelDao. Processed order cancels are
+// stored to prevent them from being replayed.
+func NewOrderCancelDao() *OrderCancelDao {
+	dbName := app.Config.DBName
+	collection := "order_cancels"
+	index := mgo.Index{
+		Key:    []string{"hash"},
+		Unique: true,
+	}
+
+	err := db.EnsureIndexes(dbName, collection, index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &OrderCancelDao{collection, dbName}
+}
+
+// Create function performs the DB insertion task for the order cancel collection
+func (dao *OrderCancelDao) Create(oc *types.OrderCancel) error {
+	oc.ID = bson.NewObjectId()
+	oc.CreatedAt = time.Now()
+
+	err := db.Create(dao.dbName, dao.collectionName, oc)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash returns the order cancel with the given hash or nil if it has not been processed
+func (dao *OrderCancelDao) GetByHash(hash common.Hash) (*types.OrderCancel, error) {
+	q := bson.M{"hash": hash.Hex()}
+	res := []types.OrderCancel{}
+
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return &res[0], nil
+}
+
+// Drop drops all the order cancel documents in the current database
+func (dao *OrderCancelDao) Drop() error {
+	err := db.DropCollection(dao.dbName, dao.collectionName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -239,7 +239,7 @@ func TestOrderDao1(t *testing.T) {
 
 	testutils.CompareOrder(t, o, o1)
 
-	o2, err := dao.GetByUserAddress(common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"))
+	o2, _, err := dao.GetByUserAddress(common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"), types.OrderQuery{Limit: 10})
 	if err != nil {
 		t.Errorf("Could not get order by user address")
 	}
@@ -331,7 +331,7 @@ func TestGetHistoryByUserAddress(t *testing.T) {
 		BuyAmount:       units.Ethers(10),
 		SellAmount:      units.Ethers(10),
 		FilledAmount:    units.Ethers(5),
-		Status:          "PARTIALLY_FILLED",
+		Status:          "PARTIAL_FILLED",
 		Side:            "BUY",
 		PairName:        "ZRX/WETH",
 		Expires:         big.NewInt(10000),
@@ -453,7 +453,7 @@ func TestGetUserOrderHistory(t *testing.T) {
 		SellAmount:      units.Ethers(10),
 		FilledAmount:    units.Ethers(10),
 		PricePoint:      big.NewInt(1e18),
-		Status:          "PARTIALLY_FILLED",
+		Status:          "PARTIAL_FILLED",
 		Side:            "BUY",
 		PairName:        "ZRX/WETH",
 		Expires:         big.NewInt(10000),
@@ -468,18 +468,87 @@ func TestGetUserOrderHistory(t *testing.T) {
 	dao.Create(o3)
 	dao.Create(o4)
 
-	orders, err := dao.GetHistoryByUserAddress(user)
+	orders, next, err := dao.GetHistoryByUserAddress(user, "", 10)
 	if err != nil {
 		t.Error("Could not get order history", err)
 	}
 
+	// the most recent orders come first
 	assert.Equal(t, 2, len(orders))
-	testutils.CompareOrder(t, orders[0], o1)
-	testutils.CompareOrder(t, orders[1], o3)
+	assert.Equal(t, "", next)
+	testutils.CompareOrder(t, orders[0], o3)
+	testutils.CompareOrder(t, orders[1], o1)
 	assert.NotContains(t, orders, o2)
 	assert.NotContains(t, orders, o4)
 }
 
+func TestOrderDaoGetByUserAddressPages(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	create := func(nonce int64) {
+		o := testutils.GetTestOrder1()
+		o.Nonce = big.NewInt(nonce)
+		o.Hash = common.BigToHash(big.NewInt(nonce))
+		err := dao.Create(&o)
+		if err != nil {
+			t.Error("Could not create order", err)
+		}
+	}
+
+	for i := int64(1); i <= 25; i++ {
+		create(i)
+	}
+
+	// orders are created while the orders are paginated
+	done := make(chan bool)
+	go func() {
+		for i := int64(100); i < 150; i++ {
+			create(i)
+		}
+
+		close(done)
+	}()
+
+	user := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	seen := map[int64]bool{}
+	cursor := ""
+	pages := 0
+	for {
+		orders, next, err := dao.GetByUserAddress(user, types.OrderQuery{Cursor: cursor, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, len(orders) <= 10)
+		for _, o := range orders {
+			assert.False(t, seen[o.Nonce.Int64()], "duplicated order")
+			seen[o.Nonce.Int64()] = true
+		}
+
+		pages++
+		if next == "" {
+			break
+		}
+
+		cursor = next
+	}
+
+	<-done
+
+	// the orders created before the first page are all listed once
+	assert.True(t, pages >= 3)
+	for i := int64(1); i <= 25; i++ {
+		assert.True(t, seen[i], "skipped order")
+	}
+
+	_, _, err = dao.GetByUserAddress(user, types.OrderQuery{Cursor: "invalid", Limit: 10})
+	assert.Equal(t, types.ErrInvalidCursor, err)
+}
+
 func TestUpdateOrderFilledAmount1(t *testing.T) {
 	dao := NewOrderDao()
 	err := dao.Drop()
@@ -528,7 +597,7 @@ func TestUpdateOrderFilledAmount1(t *testing.T) {
 		t.Error("Could not retrieve order", err)
 	}
 
-	assert.Equal(t, "PARTIALLY_FILLED", stored.Status)
+	assert.Equal(t, "PARTIAL_FILLED", stored.Status)
 	assert.Equal(t, big.NewInt(5), stored.FilledAmount)
 }
 
@@ -704,3 +773,710 @@ func ExampleGetRawOrderBook() {
 
 	utils.PrintJSON(orders)
 }
+
+func TestUpdateOrderStatusRejectsIllegalTransition(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	hash := common.HexToHash("0x5")
+	o := &types.Order{
+		ID:              bson.ObjectIdHex("537f700b537461b70c5f0001"),
+		UserAddress:     common.HexToAddress("0x1"),
+		ExchangeAddress: common.HexToAddress("0x2"),
+		BuyToken:        common.HexToAddress("0x3"),
+		SellToken:       common.HexToAddress("0x4"),
+		BuyAmount:       units.Ethers(10),
+		SellAmount:      units.Ethers(10),
+		Amount:          units.Ethers(10),
+		FilledAmount:    units.Ethers(10),
+		Status:          "FILLED",
+		Side:            "BUY",
+		PairName:        "ZRX/WETH",
+		Expires:         big.NewInt(10000),
+		MakeFee:         big.NewInt(50),
+		Nonce:           big.NewInt(1000),
+		TakeFee:         big.NewInt(50),
+		Hash:            hash,
+	}
+
+	err = dao.Create(o)
+	if err != nil {
+		t.Error("Could not create order")
+	}
+
+	// a late engine message can not reopen a filled order
+	err = dao.UpdateOrderStatus(hash, types.OrderStatusOpen)
+	assert.NotNil(t, err)
+
+	err = dao.UpdateOrderFilledAmount(hash, math.Neg(units.Ethers(10)))
+	assert.NotNil(t, err)
+
+	stored, err := dao.GetByHash(hash)
+	if err != nil {
+		t.Error("Could not retrieve order", err)
+	}
+
+	assert.Equal(t, types.OrderStatusFilled, stored.Status)
+	assert.Equal(t, units.Ethers(10), stored.FilledAmount)
+
+	err = dao.UpdateOrderStatus(hash, types.OrderStatusError)
+	assert.Nil(t, err)
+
+	stored, err = dao.GetByHash(hash)
+	if err != nil {
+		t.Error("Could not retrieve order", err)
+	}
+
+	assert.Equal(t, types.OrderStatusError, stored.Status)
+}
+
+func TestGetOpenOrderCounts(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	user := common.HexToAddress("0x1")
+	statuses := []struct {
+		pairName string
+		status   string
+	}{
+		{"ZRX/WETH", types.OrderStatusOpen},
+		{"ZRX/WETH", types.OrderStatusPartialFilled},
+		{"ZRX/WETH", types.OrderStatusFilled},
+		{"DAI/WETH", types.OrderStatusStop},
+		{"DAI/WETH", types.OrderStatusCancelled},
+	}
+
+	for i, s := range statuses {
+		o := &types.Order{
+			UserAddress:     user,
+			ExchangeAddress: common.HexToAddress("0x2"),
+			BuyToken:        common.HexToAddress("0x3"),
+			SellToken:       common.HexToAddress("0x4"),
+			BuyAmount:       units.Ethers(10),
+			SellAmount:      units.Ethers(10),
+			Amount:          units.Ethers(10),
+			FilledAmount:    big.NewInt(0),
+			Status:          s.status,
+			Side:            "BUY",
+			PairName:        s.pairName,
+			Expires:         big.NewInt(10000),
+			MakeFee:         big.NewInt(50),
+			Nonce:           big.NewInt(int64(i)),
+			TakeFee:         big.NewInt(50),
+			Hash:            common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+
+		err = dao.Create(o)
+		if err != nil {
+			t.Fatal("Could not create order", err)
+		}
+	}
+
+	counts, err := dao.GetOpenOrderCounts(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]int{"ZRX/WETH": 2, "DAI/WETH": 1}, counts)
+
+	// cancelled orders are no longer counted
+	err = dao.UpdateOrderStatus(common.BigToHash(big.NewInt(1)), types.OrderStatusCancelled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err = dao.GetOpenOrderCounts(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]int{"ZRX/WETH": 1, "DAI/WETH": 1}, counts)
+
+	counts, err = dao.GetOpenOrderCounts(common.HexToAddress("0x5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]int{}, counts)
+}
+
+func TestRecomputePricePoints(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	// USDC (6 decimals) quoted in WETH (18 decimals) at 0.0005 WETH
+	pair := &types.Pair{
+		BaseTokenAddress:  common.HexToAddress("0x3"),
+		BaseTokenDecimal:  6,
+		QuoteTokenAddress: common.HexToAddress("0x4"),
+		QuoteTokenDecimal: 18,
+		PriceMultiplier:   big.NewInt(1e6),
+	}
+
+	// sell orders of 100 USDC for 0.05 WETH, with the pricepoints computed before the decimals
+	// were taken into account
+	orders := []struct {
+		status string
+		stop   *big.Int
+	}{
+		{types.OrderStatusOpen, nil},
+		{types.OrderStatusStop, math.ToBigInt("400000000000000")},
+		{types.OrderStatusFilled, nil},
+	}
+
+	for i, s := range orders {
+		o := &types.Order{
+			UserAddress:     common.HexToAddress("0x1"),
+			ExchangeAddress: common.HexToAddress("0x2"),
+			BuyToken:        pair.QuoteTokenAddress,
+			SellToken:       pair.BaseTokenAddress,
+			BaseToken:       pair.BaseTokenAddress,
+			QuoteToken:      pair.QuoteTokenAddress,
+			BuyAmount:       math.ToBigInt("50000000000000000"),
+			SellAmount:      big.NewInt(100000000),
+			PricePoint:      math.ToBigInt("500000000000000"),
+			StopPricepoint:  s.stop,
+			Amount:          big.NewInt(100000000),
+			FilledAmount:    big.NewInt(0),
+			Status:          s.status,
+			Side:            "SELL",
+			PairName:        "USDC/WETH",
+			Expires:         big.NewInt(10000),
+			MakeFee:         big.NewInt(50),
+			Nonce:           big.NewInt(int64(i)),
+			TakeFee:         big.NewInt(50),
+			Hash:            common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+
+		err = dao.Create(o)
+		if err != nil {
+			t.Fatal("Could not create order", err)
+		}
+	}
+
+	n, err := dao.RecomputePricePoints(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, n)
+
+	open, err := dao.GetByHash(common.BigToHash(big.NewInt(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, big.NewInt(500), open.PricePoint)
+
+	stop, err := dao.GetByHash(common.BigToHash(big.NewInt(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, big.NewInt(500), stop.PricePoint)
+	assert.Equal(t, big.NewInt(400), stop.StopPricepoint)
+
+	filled, err := dao.GetByHash(common.BigToHash(big.NewInt(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, math.ToBigInt("500000000000000"), filled.PricePoint)
+
+	// migrated orders are not converted again
+	n, err = dao.RecomputePricePoints(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, n)
+}
+
+func TestOrderDaoGetByUserAddressQuery(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	user := common.HexToAddress("0x1")
+	specs := []struct {
+		pairName string
+		side     types.OrderSide
+		status   string
+	}{
+		{"ZRX/WETH", types.OrderSideBuy, types.OrderStatusOpen},
+		{"ZRX/WETH", types.OrderSideSell, types.OrderStatusFilled},
+		{"DAI/WETH", types.OrderSideBuy, types.OrderStatusCancelled},
+		{"DAI/WETH", types.OrderSideSell, types.OrderStatusOpen},
+	}
+
+	hashes := []common.Hash{}
+	for i, s := range specs {
+		o := &types.Order{
+			UserAddress:     user,
+			ExchangeAddress: common.HexToAddress("0x2"),
+			BuyToken:        common.HexToAddress("0x3"),
+			SellToken:       common.HexToAddress("0x4"),
+			BuyAmount:       units.Ethers(10),
+			SellAmount:      units.Ethers(10),
+			Amount:          units.Ethers(10),
+			FilledAmount:    big.NewInt(0),
+			Status:          s.status,
+			Side:            s.side,
+			PairName:        s.pairName,
+			Expires:         big.NewInt(10000),
+			MakeFee:         big.NewInt(50),
+			Nonce:           big.NewInt(int64(i)),
+			TakeFee:         big.NewInt(50),
+			Hash:            common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+
+		err = dao.Create(o)
+		if err != nil {
+			t.Fatal("Could not create order", err)
+		}
+
+		hashes = append(hashes, o.Hash)
+	}
+
+	tests := []struct {
+		query    types.OrderQuery
+		expected []common.Hash
+	}{
+		{types.OrderQuery{Limit: 10}, []common.Hash{hashes[3], hashes[2], hashes[1], hashes[0]}},
+		{types.OrderQuery{Limit: 10, Ascending: true}, hashes},
+		{types.OrderQuery{Limit: 10, Statuses: []string{types.OrderStatusOpen}}, []common.Hash{hashes[3], hashes[0]}},
+		{types.OrderQuery{Limit: 10, PairName: "DAI/WETH"}, []common.Hash{hashes[3], hashes[2]}},
+		{types.OrderQuery{Limit: 10, Side: types.OrderSideSell, Ascending: true}, []common.Hash{hashes[1], hashes[3]}},
+		{types.OrderQuery{Limit: 2, Offset: 1}, []common.Hash{hashes[2], hashes[1]}},
+		{types.OrderQuery{Limit: 10, SortBy: types.OrderSortUpdatedAt, Ascending: true}, hashes},
+		{types.OrderQuery{Limit: 10, To: time.Now().Add(-time.Hour)}, []common.Hash{}},
+	}
+
+	for _, test := range tests {
+		orders, _, err := dao.GetByUserAddress(user, test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res := []common.Hash{}
+		for _, o := range orders {
+			res = append(res, o.Hash)
+		}
+
+		assert.Equal(t, test.expected, res, "%+v", test.query)
+	}
+
+	// the pages of orders sorted by creation time are given by cursor
+	orders, next, err := dao.GetByUserAddress(user, types.OrderQuery{Limit: 3, Ascending: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, len(orders))
+	orders, next, _ = dao.GetByUserAddress(user, types.OrderQuery{Cursor: next, Limit: 3, Ascending: true})
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, hashes[3], orders[0].Hash)
+	assert.Equal(t, "", next)
+
+	_, _, err = dao.GetByUserAddress(user, types.OrderQuery{Limit: 10, Statuses: []string{"UNKNOWN"}})
+	assert.NotNil(t, err)
+}
+
+func TestOrderDaoArchiveOrders(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	user := common.HexToAddress("0x1")
+	t0 := time.Now().Add(-48 * time.Hour).Truncate(time.Millisecond)
+	specs := []struct {
+		status    string
+		createdAt time.Time
+		updatedAt time.Time
+	}{
+		{types.OrderStatusFilled, t0, t0.Add(time.Hour)},
+		{types.OrderStatusCancelled, t0.Add(2 * time.Hour), t0.Add(3 * time.Hour)},
+		{types.OrderStatusOpen, t0.Add(4 * time.Hour), t0.Add(4 * time.Hour)},
+		{types.OrderStatusFilled, time.Time{}, time.Time{}},
+	}
+
+	orders := []*types.Order{}
+	for i, s := range specs {
+		o := &types.Order{
+			UserAddress:     user,
+			ExchangeAddress: common.HexToAddress("0x2"),
+			BuyToken:        common.HexToAddress("0x3"),
+			SellToken:       common.HexToAddress("0x4"),
+			BuyAmount:       units.Ethers(10),
+			SellAmount:      units.Ethers(10),
+			Amount:          units.Ethers(10),
+			FilledAmount:    big.NewInt(0),
+			Status:          s.status,
+			Side:            "BUY",
+			PairName:        "ZRX/WETH",
+			Expires:         big.NewInt(10000),
+			MakeFee:         big.NewInt(50),
+			Nonce:           big.NewInt(int64(i)),
+			TakeFee:         big.NewInt(50),
+			Hash:            common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+
+		err = dao.Create(o)
+		if err != nil {
+			t.Fatal("Could not create order", err)
+		}
+
+		if !s.createdAt.IsZero() {
+			err = db.Update(dao.dbName, dao.collectionName, bson.M{"_id": o.ID}, bson.M{"$set": bson.M{
+				"createdAt": s.createdAt,
+				"updatedAt": s.updatedAt,
+			}})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		orders = append(orders, o)
+	}
+
+	hashes := func(orders []*types.Order) []common.Hash {
+		res := []common.Hash{}
+		for _, o := range orders {
+			res = append(res, o.Hash)
+		}
+
+		return res
+	}
+
+	// the old filled and cancelled orders are archived, the open and recent orders are not
+	n, err := dao.ArchiveOrders(time.Now().Add(-time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, n)
+
+	o, _ := dao.GetByHash(orders[0].Hash)
+	assert.Nil(t, o)
+
+	archived := []*types.Order{}
+	err = db.Get(dao.dbName, dao.archiveCollectionName, bson.M{}, 0, 0, &archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(archived))
+
+	// the listings include the archived orders
+	res, _, err := dao.GetHistoryByUserAddress(user, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.Hash{orders[3].Hash, orders[1].Hash, orders[0].Hash}, hashes(res))
+
+	res, next, err := dao.GetByUserAddress(user, types.OrderQuery{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.Hash{orders[3].Hash, orders[2].Hash}, hashes(res))
+
+	res, _, _ = dao.GetByUserAddress(user, types.OrderQuery{Cursor: next, Limit: 2})
+	assert.Equal(t, []common.Hash{orders[1].Hash, orders[0].Hash}, hashes(res))
+
+	res, _, _ = dao.GetByUserAddress(user, types.OrderQuery{Limit: 2, Offset: 2, SortBy: types.OrderSortUpdatedAt})
+	assert.Equal(t, []common.Hash{orders[1].Hash, orders[0].Hash}, hashes(res))
+
+	res, _, _ = dao.GetByUserAddress(user, types.OrderQuery{Limit: 10, From: time.Now().Add(-time.Hour)})
+	assert.Equal(t, []common.Hash{orders[3].Hash}, hashes(res))
+
+	// an archival interrupted before removing its orders is resumed without duplicates
+	doc := bson.M{}
+	err = db.GetByID(dao.dbName, dao.archiveCollectionName, orders[0].ID, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Create(dao.dbName, dao.collectionName, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, _, _ = dao.GetHistoryByUserAddress(user, "", 10)
+	assert.Equal(t, []common.Hash{orders[3].Hash, orders[1].Hash, orders[0].Hash}, hashes(res))
+
+	n, err = dao.ArchiveOrders(time.Now().Add(-time.Hour), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+
+	archived = []*types.Order{}
+	db.Get(dao.dbName, dao.archiveCollectionName, bson.M{}, 0, 0, &archived)
+	assert.Equal(t, 2, len(archived))
+
+	o, _ = dao.GetByHash(orders[0].Hash)
+	assert.Nil(t, o)
+}
+
+func TestOrderDaoDeleteOrdersBefore(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	user := common.HexToAddress("0x1")
+	cutoff := time.Now().Add(-180 * 24 * time.Hour).Truncate(time.Millisecond)
+	specs := []struct {
+		status    string
+		updatedAt time.Time
+	}{
+		{types.OrderStatusCancelled, cutoff.Add(-time.Hour)},
+		{types.OrderStatusCancelled, cutoff.Add(-time.Millisecond)},
+		{types.OrderStatusCancelled, cutoff},
+		{types.OrderStatusCancelled, cutoff.Add(time.Millisecond)},
+		{types.OrderStatusFilled, cutoff.Add(-time.Hour)},
+		{types.OrderStatusOpen, cutoff.Add(-time.Hour)},
+	}
+
+	orders := []*types.Order{}
+	for i, s := range specs {
+		o := &types.Order{
+			UserAddress:     user,
+			ExchangeAddress: common.HexToAddress("0x2"),
+			BuyToken:        common.HexToAddress("0x3"),
+			SellToken:       common.HexToAddress("0x4"),
+			BuyAmount:       units.Ethers(10),
+			SellAmount:      units.Ethers(10),
+			Amount:          units.Ethers(10),
+			FilledAmount:    big.NewInt(0),
+			Status:          s.status,
+			Side:            "BUY",
+			PairName:        "ZRX/WETH",
+			Expires:         big.NewInt(10000),
+			MakeFee:         big.NewInt(50),
+			Nonce:           big.NewInt(int64(i)),
+			TakeFee:         big.NewInt(50),
+			Hash:            common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+
+		err = dao.Create(o)
+		if err != nil {
+			t.Fatal("Could not create order", err)
+		}
+
+		err = db.Update(dao.dbName, dao.collectionName, bson.M{"_id": o.ID}, bson.M{"$set": bson.M{
+			"createdAt": s.updatedAt,
+			"updatedAt": s.updatedAt,
+		}})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		orders = append(orders, o)
+	}
+
+	// an archived copy of a cancelled order is kept
+	doc := bson.M{}
+	err = db.GetByID(dao.dbName, dao.collectionName, orders[0].ID, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.UpsertAll(dao.dbName, dao.archiveCollectionName, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the orders of the active statuses can not be deleted
+	_, err = dao.DeleteOrdersBefore(types.OrderStatusOpen, cutoff, 0)
+	assert.Equal(t, ErrRetainedOrderStatus, err)
+
+	// the orders are deleted in batches, the oldest first
+	batchSize := OrderDeleteBatchSize
+	OrderDeleteBatchSize = 1
+	defer func() { OrderDeleteBatchSize = batchSize }()
+
+	n, err := dao.DeleteOrdersBefore(types.OrderStatusCancelled, cutoff, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+
+	// the cancelled orders updated strictly before the cutoff are deleted, the ones updated at the
+	// cutoff or after it are kept
+	n, err = dao.DeleteOrdersBefore(types.OrderStatusCancelled, cutoff, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+
+	for i, deleted := range []bool{true, true, false, false, false, false} {
+		o, err := dao.GetByHash(orders[i].Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, deleted, o == nil, "order %v", i)
+	}
+
+	archived := []*types.Order{}
+	err = db.Get(dao.dbName, dao.archiveCollectionName, bson.M{}, 0, 0, &archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(archived))
+	assert.Equal(t, orders[0].Hash, archived[0].Hash)
+}
+
+func TestOrderDaoCreateDuplicate(t *testing.T) {
+	// the collection is dropped with its indexes, which are built again by the next DAO
+	NewOrderDao().Drop()
+	dao := NewOrderDao()
+
+	o := testutils.GetTestOrder1()
+	err := dao.Create(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an order whose hash is already stored is not inserted again
+	duplicate := testutils.GetTestOrder1()
+	err = dao.Create(&duplicate)
+	assert.Equal(t, types.ErrOrderAlreadyExists, err)
+
+	n, err := db.Session.DB(dao.dbName).C(dao.collectionName).Find(bson.M{"hash": o.Hash.Hex()}).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+}
+
+func TestOrderDaoUpsertByHash(t *testing.T) {
+	NewOrderDao().Drop()
+	dao := NewOrderDao()
+
+	// an order that is not stored yet is inserted
+	o := testutils.GetTestOrder1()
+	o.Status = types.OrderStatusOpen
+	err := dao.UpsertByHash(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := dao.GetByHash(o.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.ID, stored.ID)
+	assert.Equal(t, types.OrderStatusOpen, stored.Status)
+	assert.Equal(t, o.UserAddress, stored.UserAddress)
+
+	// the stored order is updated, keeping its id and creation time
+	id, createdAt := stored.ID, stored.CreatedAt
+	again := testutils.GetTestOrder1()
+	again.Status = types.OrderStatusPartialFilled
+	again.FilledAmount = big.NewInt(1)
+	err = dao.UpsertByHash(&again)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err = dao.GetByHash(o.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, id, again.ID)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+	assert.Equal(t, types.OrderStatusPartialFilled, stored.Status)
+	assert.Equal(t, big.NewInt(1), stored.FilledAmount)
+
+	n, err := db.Session.DB(dao.dbName).C(dao.collectionName).Find(bson.M{"hash": o.Hash.Hex()}).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+
+	// illegal status transitions are rejected
+	err = dao.UpdateOrderStatus(o.Hash, types.OrderStatusCancelled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again.Status = types.OrderStatusOpen
+	err = dao.UpsertByHash(&again)
+	assert.Error(t, err)
+
+	stored, _ = dao.GetByHash(o.Hash)
+	assert.Equal(t, types.OrderStatusCancelled, stored.Status)
+}
+
+func TestFindDuplicateOrderHashes(t *testing.T) {
+	// the duplicates are inserted in a database in which the orders index was never built
+	dbName := app.Config.DBName + "_duplicates"
+	db.Session.DB(dbName).DropDatabase()
+	defer db.Session.DB(dbName).DropDatabase()
+
+	a, b := common.HexToHash("0xa"), common.HexToHash("0xb")
+	ids := []bson.ObjectId{bson.NewObjectId(), bson.NewObjectId(), bson.NewObjectId(), bson.NewObjectId()}
+	docs := []interface{}{
+		bson.M{"_id": ids[0], "hash": b.Hex(), "status": types.OrderStatusOpen},
+		bson.M{"_id": ids[1], "hash": a.Hex(), "status": types.OrderStatusFilled},
+		bson.M{"_id": ids[2], "hash": b.Hex(), "status": types.OrderStatusCancelled},
+		bson.M{"_id": ids[3], "hash": common.HexToHash("0xc").Hex(), "status": types.OrderStatusOpen},
+	}
+
+	err := db.Create(dbName, orderCollectionName, docs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates, err := FindDuplicateOrderHashes(dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*types.DuplicateOrderHash{
+		{Hash: b, IDs: []bson.ObjectId{ids[0], ids[2]}, Statuses: []string{types.OrderStatusOpen, types.OrderStatusCancelled}},
+	}, duplicates)
+
+	// the unique index can not be built until the duplicates are removed
+	assert.Panics(t, func() { NewOrderDao(OrderDaoDBOption(dbName)) })
+
+	err = db.Remove(dbName, orderCollectionName, bson.M{"_id": ids[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates, err = FindDuplicateOrderHashes(dbName)
+	assert.Nil(t, err)
+	assert.Equal(t, []*types.DuplicateOrderHash{}, duplicates)
+
+	assert.NotPanics(t, func() { NewOrderDao(OrderDaoDBOption(dbName)) })
+}
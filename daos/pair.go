@@ -2,6 +2,7 @@ package daos
 
 import (
 	"errors"
+	"math/big"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
@@ -14,9 +15,11 @@ import (
 // PairDao contains:
 // collectionName: MongoDB collection name
 // dbName: name of mongodb to interact with
+// cache: the pairs looked up by token addresses (see LookupCacheTTL)
 type PairDao struct {
 	collectionName string
 	dbName         string
+	cache          *lookupCache
 }
 
 type PairDaoOption = func(*PairDao) error
@@ -33,6 +36,7 @@ func NewPairDao(options ...PairDaoOption) *PairDao {
 	dao := &PairDao{}
 	dao.collectionName = "pairs"
 	dao.dbName = app.Config.DBName
+	dao.cache = newLookupCache(LookupCacheTTL)
 
 	for _, op := range options {
 		err := op(dao)
@@ -46,7 +50,7 @@ func NewPairDao(options ...PairDaoOption) *PairDao {
 		Unique: true,
 	}
 
-	err := db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	err := db.EnsureIndexes(dao.dbName, dao.collectionName, index)
 	if err != nil {
 		panic(err)
 	}
@@ -61,6 +65,7 @@ func (dao *PairDao) Create(pair *types.Pair) error {
 	pair.UpdatedAt = time.Now()
 
 	err := db.Create(dao.dbName, dao.collectionName, pair)
+	dao.cache.invalidate()
 	return err
 }
 
@@ -71,6 +76,13 @@ func (dao *PairDao) GetAll() ([]types.Pair, error) {
 	return response, err
 }
 
+// GetActive function fetches the pairs that are currently listed
+func (dao *PairDao) GetActive() ([]types.Pair, error) {
+	var response []types.Pair
+	err := db.Get(dao.dbName, dao.collectionName, bson.M{"active": true}, 0, 0, &response)
+	return response, err
+}
+
 // GetByID function fetches details of a pair using pair's mongo ID.
 func (dao *PairDao) GetByID(id bson.ObjectId) (*types.Pair, error) {
 	var response *types.Pair
@@ -122,50 +134,144 @@ func (dao *PairDao) GetByTokenSymbols(baseTokenSymbol, quoteTokenSymbol string)
 // GetByTokenAddress function fetches pair based on
 // CONTRACT ADDRESS of base token and quote token
 func (dao *PairDao) GetByTokenAddress(baseToken, quoteToken common.Address) (*types.Pair, error) {
-	var res []*types.Pair
+	key := "address:" + baseToken.Hex() + quoteToken.Hex()
+	v, err := dao.cache.load(key, func() (interface{}, error) {
+		var res []*types.Pair
 
-	q := bson.M{
-		"baseTokenAddress":  baseToken.Hex(),
-		"quoteTokenAddress": quoteToken.Hex(),
-	}
+		q := bson.M{
+			"baseTokenAddress":  baseToken.Hex(),
+			"quoteTokenAddress": quoteToken.Hex(),
+		}
+
+		err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(res) == 0 {
+			return nil, errors.New("Pair not found")
+		}
+
+		return res[0], nil
+	})
 
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(res) == 0 {
-		return nil, errors.New("Pair not found")
-	}
-
-	return res[0], nil
+	// callers may modify the pair, the cached pair is copied
+	pair := *v.(*types.Pair)
+	return &pair, nil
 }
 
 // GetByBuySellTokenAddress function fetches pair based on
 // CONTRACT ADDRESS of buy token and sell token
 func (dao *PairDao) GetByBuySellTokenAddress(buyToken, sellToken common.Address) (*types.Pair, error) {
-	var res []*types.Pair
-	q := bson.M{
-		"$or": []bson.M{
-			bson.M{
-				"baseTokenAddress":  buyToken.Hex(),
-				"quoteTokenAddress": sellToken.Hex(),
+	key := "buysell:" + buyToken.Hex() + sellToken.Hex()
+	v, err := dao.cache.load(key, func() (interface{}, error) {
+		var res []*types.Pair
+		q := bson.M{
+			"$or": []bson.M{
+				bson.M{
+					"baseTokenAddress":  buyToken.Hex(),
+					"quoteTokenAddress": sellToken.Hex(),
+				},
+				bson.M{
+					"baseTokenAddress":  sellToken.Hex(),
+					"quoteTokenAddress": buyToken.Hex(),
+				},
 			},
-			bson.M{
-				"baseTokenAddress":  sellToken.Hex(),
-				"quoteTokenAddress": buyToken.Hex(),
-			},
-		},
-	}
+		}
+
+		err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(res) == 0 {
+			return nil, errors.New("Pair not found")
+		}
+
+		return res[0], nil
+	})
 
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(res) == 0 {
-		return nil, errors.New("Pair not found")
+	pair := *v.(*types.Pair)
+	return &pair, nil
+}
+
+// UpdatePaused pauses or resumes the pair corresponding to the base token and quote token addresses
+func (dao *PairDao) UpdatePaused(baseToken, quoteToken common.Address, paused bool) error {
+	q := bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
 	}
 
-	return res[0], nil
+	update := bson.M{"$set": bson.M{
+		"paused":    paused,
+		"updatedAt": time.Now(),
+	}}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, update)
+	dao.cache.invalidate()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateAuction saves the end and the reference pricepoint of the opening auction of the pair
+// corresponding to the base token and quote token addresses
+func (dao *PairDao) UpdateAuction(baseToken, quoteToken common.Address, endsAt *time.Time, reference *big.Int) error {
+	q := bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	}
+
+	ref := ""
+	if reference != nil {
+		ref = reference.String()
+	}
+
+	update := bson.M{"$set": bson.M{
+		"auctionEndsAt":              endsAt,
+		"auctionReferencePricePoint": ref,
+		"updatedAt":                  time.Now(),
+	}}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, update)
+	dao.cache.invalidate()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateActive lists or delists the pair corresponding to the base token and quote token addresses
+func (dao *PairDao) UpdateActive(baseToken, quoteToken common.Address, active bool) error {
+	q := bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	}
+
+	update := bson.M{"$set": bson.M{
+		"active":    active,
+		"updatedAt": time.Now(),
+	}}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, update)
+	dao.cache.invalidate()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
 }
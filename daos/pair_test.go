@@ -59,3 +59,48 @@ func TestPairDao(t *testing.T) {
 
 	testutils.ComparePair(t, pair, byAddress)
 }
+
+func TestPairDaoUpdateActive(t *testing.T) {
+	dao := NewPairDao()
+
+	pair := &types.Pair{
+		ID:                bson.NewObjectId(),
+		BaseTokenSymbol:   "ZRX",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenSymbol:  "WETH",
+		QuoteTokenAddress: common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Active:            true,
+		MakeFee:           big.NewInt(10000),
+		TakeFee:           big.NewInt(10000),
+	}
+
+	err := dao.Create(pair)
+	if err != nil {
+		t.Errorf("Could not create pair object: %+v", err)
+	}
+
+	err = dao.UpdateActive(pair.BaseTokenAddress, pair.QuoteTokenAddress, false)
+	if err != nil {
+		t.Errorf("Could not update pair: %v", err)
+	}
+
+	updated, err := dao.GetByTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	if err != nil {
+		t.Errorf("Could not get pair by address: %v", err)
+	}
+
+	if updated.Active {
+		t.Errorf("Expected pair to be inactive")
+	}
+
+	active, err := dao.GetActive()
+	if err != nil {
+		t.Errorf("Could not get active pairs: %v", err)
+	}
+
+	for _, p := range active {
+		if p.ID == pair.ID {
+			t.Errorf("Inactive pair returned by GetActive")
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package daos
+
+import (
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+)
+
+// MongoRetries is the number of times an idempotent operation is run again after a transient
+// error. It is set from the mongo_retries configuration
+var MongoRetries = 3
+
+// MongoRetryBackoff is the delay before the first retry of an operation, doubled at every retry.
+// It is set from the mongo_retry_backoff configuration
+var MongoRetryBackoff = 100 * time.Millisecond
+
+// notMasterCodes are the codes of the errors returned by a server that stepped down or is
+// shutting down during a failover
+var notMasterCodes = map[int]bool{
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	10107: true, // NotMaster
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotMasterNoSlaveOk
+	13436: true, // NotMasterOrSecondary
+}
+
+// isTransient returns true if the error is returned because the connection to the server was
+// lost or the server is no longer the primary, in which case the session must be refreshed
+func isTransient(err error) bool {
+	if err == nil || err == mgo.ErrNotFound {
+		return false
+	}
+
+	if err == io.EOF {
+		return true
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	switch e := err.(type) {
+	case *mgo.QueryError:
+		if notMasterCodes[e.Code] {
+			return true
+		}
+	case *mgo.LastError:
+		if notMasterCodes[e.Code] {
+			return true
+		}
+	}
+
+	msg := err.Error()
+	for _, s := range []string{"EOF", "no reachable servers", "Closed explicitly", "not master", "connection reset", "broken pipe"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run runs an operation once on a copy of the session. The copies of a session share its
+// connection to the primary, which stays broken after a failover: the session is refreshed after
+// a transient error so that the next operations dial the servers again. The error is returned to
+// the caller, as the operation may have been applied before the connection was lost.
+func (d *Database) run(op func(sc *mgo.Session) error) error {
+	sc := d.Session.Copy()
+	defer sc.Close()
+
+	err := op(sc)
+	if isTransient(err) {
+		logger.Warning("Refreshing the mongo session after a transient error: ", err)
+		d.Session.Refresh()
+	}
+
+	return err
+}
+
+// retry runs an idempotent operation, such as a read or an upsert, like run, and runs it again
+// after a transient error up to MongoRetries times, waiting MongoRetryBackoff before the first
+// retry and twice as long before each next one
+func (d *Database) retry(op func(sc *mgo.Session) error) error {
+	backoff := MongoRetryBackoff
+	for i := 0; ; i++ {
+		err := d.run(op)
+		if !isTransient(err) || i >= MongoRetries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
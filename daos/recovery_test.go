@@ -0,0 +1,144 @@
+package daos
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// failoverDialer dials the test server and breaks all the connections it dialed on demand, as
+// a failover of the primary does
+type failoverDialer struct {
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (f *failoverDialer) dial(addr *mgo.ServerAddr) (net.Conn, error) {
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.conns = append(f.conns, conn)
+	f.mu.Unlock()
+
+	return conn, nil
+}
+
+func (f *failoverDialer) failover() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+
+	f.conns = nil
+}
+
+// withFailoverDatabase runs fn with a database whose connections are dialed by a failoverDialer
+func withFailoverDatabase(t *testing.T, fn func(f *failoverDialer)) {
+	f := &failoverDialer{}
+	session, err := mgo.DialWithInfo(&mgo.DialInfo{
+		Addrs:      db.Session.LiveServers(),
+		Direct:     true,
+		Timeout:    5 * time.Second,
+		DialServer: f.dial,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer session.Close()
+
+	saved := db
+	db = &Database{Session: session}
+	defer func() { db = saved }()
+
+	fn(f)
+}
+
+func TestDatabaseRecovery(t *testing.T) {
+	withFailoverDatabase(t, func(f *failoverDialer) {
+		dao := NewTradeDao()
+		dao.Drop()
+
+		trades := ohlcvTrades(time.Now(), 3, time.Second)
+		err := dao.Create(trades[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// reads are run again on the refreshed session
+		f.failover()
+		stored, err := dao.GetByHash(trades[0].Hash)
+		if err != nil {
+			t.Fatal("Could not retrieve trade after failover", err)
+		}
+
+		assert.Equal(t, trades[0].ID, stored.ID)
+
+		// inserts fail but the session is refreshed for the next operations
+		f.failover()
+		err = dao.Create(trades[1])
+		assert.True(t, isTransient(err))
+
+		err = dao.Create(trades[2])
+		if err != nil {
+			t.Fatal("Could not create trade after failover", err)
+		}
+
+		all, err := dao.GetAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 2, len(all))
+	})
+}
+
+func TestDatabaseRetry(t *testing.T) {
+	retries, backoff := MongoRetries, MongoRetryBackoff
+	MongoRetries, MongoRetryBackoff = 2, time.Millisecond
+	defer func() { MongoRetries, MongoRetryBackoff = retries, backoff }()
+
+	tests := []struct {
+		err   error
+		calls int
+	}{
+		{nil, 1},
+		{mgo.ErrNotFound, 1},
+		{errors.New("Invalid query"), 1},
+		{io.EOF, 3},
+		{errors.New("no reachable servers"), 3},
+		{&mgo.QueryError{Code: 10107, Message: "not master"}, 3},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		err := db.retry(func(sc *mgo.Session) error {
+			calls++
+			return test.err
+		})
+
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.calls, calls, "%v", test.err)
+
+		// non-idempotent operations are run once whatever the error
+		calls = 0
+		err = db.run(func(sc *mgo.Session) error {
+			calls++
+			return test.err
+		})
+
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, 1, calls)
+	}
+}
@@ -1,9 +1,12 @@
 package daos
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
@@ -43,64 +46,168 @@ func (d *Database) InitDatabase(session *mgo.Session) {
 
 // Create is a wrapper for mgo.Insert function.
 // It creates a copy of session initialized, sends query over this session
-// and returns the session to connection pool
+// and returns the session to connection pool. Inserts are not retried after
+// a transient error (see run).
 func (d *Database) Create(dbName, collection string, data ...interface{}) (err error) {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).Insert(data...)
-	return
+	return d.run(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Insert(data...)
+	})
 }
 
 // GetByID is a wrapper for mgo.FindId function.
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) GetByID(dbName, collection string, id bson.ObjectId, response interface{}) (err error) {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).FindId(id).One(response)
-	return
+	return d.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).FindId(id).One(response)
+	})
 }
 
 // Get is a wrapper for mgo.Find function.
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Get(dbName, collection string, query interface{}, offset, limit int, response interface{}) (err error) {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).All(response)
-	return
+	return d.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).All(response)
+	})
 }
 
 func (d *Database) Query(dbName, collection string, query interface{}, selector interface{}, offset, limit int, response interface{}) (err error) {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).Select(selector).All(response)
-	return
+	return d.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).Select(selector).All(response)
+	})
 }
 
 // GetAndSort is a wrapper for mgo.Find function with SORT function in pipeline.
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) GetAndSort(dbName, collection string, query interface{}, sort []string, offset, limit int, response interface{}) (err error) {
+	return d.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Sort(sort...).Skip(offset).Limit(limit).All(response)
+	})
+}
+
+// GetPage is a wrapper for mgo.Find function returning a page of the documents matching a query,
+// sorted by creation time and then by id, the most recent first unless ascending is set. The page
+// starts after the cursor, if not empty (see types.Cursor), and holds up to limit+1 documents so
+// that the caller knows whether another page follows. types.ErrInvalidCursor is returned for
+// invalid cursors.
+func (d *Database) GetPage(dbName, collection string, query bson.M, cursor string, ascending bool, limit int, response interface{}) error {
+	op, sort := "$lt", []string{"-createdAt", "-_id"}
+	if ascending {
+		op, sort = "$gt", []string{"createdAt", "_id"}
+	}
+
+	if cursor != "" {
+		c, err := types.DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+
+		query = bson.M{"$and": []bson.M{query, {"$or": []bson.M{
+			{"createdAt": bson.M{op: c.CreatedAt}},
+			{"createdAt": c.CreatedAt, "_id": bson.M{op: c.ID}},
+		}}}}
+	}
+
+	return d.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Sort(sort...).Limit(limit + 1).All(response)
+	})
+}
+
+// Iter is an iterator over the documents matching a query, read from the server in batches. It
+// holds its own copy of the session, returned to the connection pool by Close.
+type Iter struct {
+	session *mgo.Session
+	iter    *mgo.Iter
+}
+
+// Iter is a wrapper for mgo.Iter returning an iterator over the documents matching a query, in
+// the given sort order. At most batchSize documents are held in memory at once, so that
+// queries matching any number of documents can be walked through. Queries are not retried as
+// part of the documents may already have been read.
+func (d *Database) Iter(dbName, collection string, query interface{}, sort []string, batchSize int) *Iter {
+	sc := d.Session.Copy()
+	iter := sc.DB(dbName).C(collection).Find(query).Sort(sort...).Batch(batchSize).Iter()
+	return &Iter{sc, iter}
+}
+
+// Next decodes the next document into result and returns false once there is no document left
+// or if the iteration failed (see Close)
+func (it *Iter) Next(result interface{}) bool {
+	return it.iter.Next(result)
+}
+
+// Close closes the iterator and its session, and returns the error that stopped the iteration,
+// if any
+func (it *Iter) Close() error {
+	defer it.session.Close()
+
+	err := it.iter.Close()
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return err
+}
+
+// EnsureIndexes builds the indexes of a collection that do not exist yet and logs each index it
+// built. Existing indexes are left untouched, so that the indexes of every collection are ensured
+// at every start. A unique index can not be built while the collection holds duplicate values of
+// its key, in which case the error names the index and the collection.
+func (d *Database) EnsureIndexes(dbName, collection string, indexes ...mgo.Index) error {
 	sc := d.Session.Copy()
 	defer sc.Close()
 
-	err = sc.DB(dbName).C(collection).Find(query).Sort(sort...).Skip(offset).Limit(limit).All(response)
-	return
+	c := sc.DB(dbName).C(collection)
+
+	// the indexes of a collection that was not created yet can not be listed
+	current, err := c.Indexes()
+	if err != nil && !isNamespaceNotFound(err) {
+		logger.Error(err)
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, index := range current {
+		existing[strings.Join(index.Key, ",")] = true
+	}
+
+	for _, index := range indexes {
+		key := strings.Join(index.Key, ",")
+		if existing[key] {
+			continue
+		}
+
+		err := c.EnsureIndex(index)
+		if mgo.IsDup(err) {
+			return fmt.Errorf("Unique index %v of the %v collection can not be built because of duplicate documents: %v", key, collection, err)
+		}
+
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		logger.Infof("Built index %v of the %v collection", key, collection)
+	}
+
+	return nil
+}
+
+// isNamespaceNotFound returns true if the error is returned for a collection that does not exist
+func isNamespaceNotFound(err error) bool {
+	qerr, ok := err.(*mgo.QueryError)
+	return ok && qerr.Code == 26
 }
 
 // Update is a wrapper for mgo.Update function.
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Update(dbName, collection string, query interface{}, update interface{}) error {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
-	err := sc.DB(dbName).C(collection).Update(query, update)
+	err := d.run(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Update(query, update)
+	})
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -114,11 +221,10 @@ func (d *Database) Update(dbName, collection string, query interface{}, update i
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Aggregate(dbName, collection string, query []bson.M, response interface{}) error {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
 	result := reflect.ValueOf(response).Interface()
-	err := sc.DB(dbName).C(collection).Pipe(query).All(result)
+	err := d.retry(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Pipe(query).All(result)
+	})
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -127,12 +233,19 @@ func (d *Database) Aggregate(dbName, collection string, query []bson.M, response
 	return nil
 }
 
-// Remove removes one document matching a certain query
-func (d *Database) Remove(dbName, collection string, query []bson.M) error {
-	sc := d.Session.Copy()
-	defer sc.Close()
+// UpsertAll is a wrapper for mgo.Bulk upserting documents by id. The bulk is unordered, so that
+// every document is written whether or not the others already exist.
+func (d *Database) UpsertAll(dbName, collection string, docs ...bson.M) error {
+	err := d.retry(func(sc *mgo.Session) error {
+		bulk := sc.DB(dbName).C(collection).Bulk()
+		bulk.Unordered()
+		for _, doc := range docs {
+			bulk.Upsert(bson.M{"_id": doc["_id"]}, doc)
+		}
 
-	err := sc.DB(dbName).C(collection).Remove(query)
+		_, err := bulk.Run()
+		return err
+	})
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -141,12 +254,60 @@ func (d *Database) Remove(dbName, collection string, query []bson.M) error {
 	return nil
 }
 
-// RemoveAll removes all the documents from a collection matching a certain query
-func (d *Database) RemoveAll(dbName, collection string, query []bson.M) error {
-	sc := d.Session.Copy()
-	defer sc.Close()
+// Upsert is a wrapper for mgo.Upsert function, updating the first document matching a query or
+// inserting a document built from the query and the update if none matches
+func (d *Database) Upsert(dbName, collection string, query interface{}, update interface{}) error {
+	err := d.retry(func(sc *mgo.Session) error {
+		_, err := sc.DB(dbName).C(collection).Upsert(query, update)
+		return err
+	})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// InsertAll is a wrapper for mgo.Bulk inserting documents. The bulk is unordered, so that a
+// document that can not be inserted does not prevent the others from being inserted. The error
+// of a failed insert is a *mgo.BulkError whose cases give the index of each failed document.
+func (d *Database) InsertAll(dbName, collection string, docs ...interface{}) error {
+	err := d.run(func(sc *mgo.Session) error {
+		bulk := sc.DB(dbName).C(collection).Bulk()
+		bulk.Unordered()
+		bulk.Insert(docs...)
 
-	_, err := sc.DB(dbName).C(collection).RemoveAll(query)
+		_, err := bulk.Run()
+		return err
+	})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Remove removes one document matching a certain query
+func (d *Database) Remove(dbName, collection string, query interface{}) error {
+	err := d.run(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Remove(query)
+	})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveAll removes all the documents from a collection matching a certain query
+func (d *Database) RemoveAll(dbName, collection string, query interface{}) error {
+	err := d.retry(func(sc *mgo.Session) error {
+		_, err := sc.DB(dbName).C(collection).RemoveAll(query)
+		return err
+	})
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -157,10 +318,9 @@ func (d *Database) RemoveAll(dbName, collection string, query []bson.M) error {
 
 // DropCollection drops all the documents in a collection
 func (d *Database) DropCollection(dbName, collection string) error {
-	sc := d.Session.Copy()
-	defer sc.Close()
-
-	err := sc.DB(dbName).C(collection).DropCollection()
+	err := d.run(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).DropCollection()
+	})
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -0,0 +1,37 @@
+package daos
+
+import (
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestEnsureIndexes(t *testing.T) {
+	dbName := app.Config.DBName
+	index := mgo.Index{Key: []string{"hash"}, Unique: true}
+
+	db.DropCollection(dbName, "indexed")
+	db.DropCollection(dbName, "duplicates")
+
+	// indexes can be ensured again, including on collections that do not exist yet
+	err := db.EnsureIndexes(dbName, "indexed", index)
+	assert.Nil(t, err)
+
+	err = db.EnsureIndexes(dbName, "indexed", index)
+	assert.Nil(t, err)
+
+	err = db.Create(dbName, "indexed", bson.M{"hash": "0x1"}, bson.M{"hash": "0x1"})
+	assert.True(t, mgo.IsDup(err))
+
+	// unique indexes can not be built on duplicate documents
+	err = db.Create(dbName, "duplicates", bson.M{"hash": "0x1"}, bson.M{"hash": "0x1"})
+	assert.Nil(t, err)
+
+	err = db.EnsureIndexes(dbName, "duplicates", index)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Unique index hash of the duplicates collection")
+	}
+}
@@ -13,9 +13,11 @@ import (
 // TokenDao contains:
 // collectionName: MongoDB collection name
 // dbName: name of mongodb to interact with
+// cache: the tokens looked up by address (see LookupCacheTTL)
 type TokenDao struct {
 	collectionName string
 	dbName         string
+	cache          *lookupCache
 }
 
 // NewTokenDao returns a new instance of TokenDao.
@@ -27,11 +29,11 @@ func NewTokenDao() *TokenDao {
 		Unique: true,
 	}
 
-	err := db.Session.DB(dbName).C(collection).EnsureIndex(index)
+	err := db.EnsureIndexes(dbName, collection, index)
 	if err != nil {
 		panic(err)
 	}
-	return &TokenDao{collection, dbName}
+	return &TokenDao{collection, dbName, newLookupCache(LookupCacheTTL)}
 }
 
 // Create function performs the DB insertion task for token collection
@@ -46,6 +48,7 @@ func (dao *TokenDao) Create(token *types.Token) error {
 	token.UpdatedAt = time.Now()
 
 	err := db.Create(dao.dbName, dao.collectionName, token)
+	dao.cache.invalidate()
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -103,26 +106,38 @@ func (dao *TokenDao) GetByID(id bson.ObjectId) (*types.Token, error) {
 }
 
 // GetByAddress function fetches details of a token based on its contract address
+// Tokens are cached (see LookupCacheTTL), unknown addresses are not.
 func (dao *TokenDao) GetByAddress(addr common.Address) (*types.Token, error) {
-	q := bson.M{"contractAddress": addr.Hex()}
-	var resp []types.Token
+	v, err := dao.cache.load(addr.Hex(), func() (interface{}, error) {
+		q := bson.M{"contractAddress": addr.Hex()}
+		var resp []types.Token
 
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &resp)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
-	}
+		err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &resp)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
 
-	if len(resp) == 0 {
-		return nil, nil
+		if len(resp) == 0 {
+			return nil, nil
+		}
+
+		return &resp[0], nil
+	})
+
+	if v == nil || err != nil {
+		return nil, err
 	}
 
-	return &resp[0], nil
+	// callers may modify the token, the cached token is copied
+	token := *v.(*types.Token)
+	return &token, nil
 }
 
 // Drop drops all the order documents in the current database
 func (dao *TokenDao) Drop() error {
 	err := db.DropCollection(dao.dbName, dao.collectionName)
+	dao.cache.invalidate()
 	if err != nil {
 		logger.Error(err)
 		return err
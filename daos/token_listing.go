@@ -0,0 +1,112 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// TokenListingDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type TokenListingDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewTokenListingDao returns a new instance of TokenListingDao
+func NewTokenListingDao() *TokenListingDao {
+	dbName := app.Config.DBName
+	collection := "token_listings"
+	index := mgo.Index{
+		Key: []string{"contractAddress", "status"},
+	}
+
+	err := db.EnsureIndexes(dbName, collection, index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &TokenListingDao{collection, dbName}
+}
+
+// Create function performs the DB insertion task for the token listing collection
+func (dao *TokenListingDao) Create(r *types.TokenListingRequest) error {
+	r.ID = bson.NewObjectId()
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+
+	err := db.Create(dao.dbName, dao.collectionName, r)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByID function fetches a token listing request based on its mongo id
+func (dao *TokenListingDao) GetByID(id bson.ObjectId) (*types.TokenListingRequest, error) {
+	var response *types.TokenListingRequest
+
+	err := db.GetByID(dao.dbName, dao.collectionName, id, &response)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetPendingByAddress returns the pending listing request of a token or nil if there is none
+func (dao *TokenListingDao) GetPendingByAddress(addr common.Address) (*types.TokenListingRequest, error) {
+	q := bson.M{"contractAddress": addr.Hex(), "status": types.TokenListingPending}
+	res := []types.TokenListingRequest{}
+
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return &res[0], nil
+}
+
+// UpdateStatus sets the status of a pending listing request along with the reviewer and the
+// reason of the decision. Requests that are not pending anymore are not updated.
+func (dao *TokenListingDao) UpdateStatus(id bson.ObjectId, status, reason string, reviewer common.Address) error {
+	q := bson.M{"_id": id, "status": types.TokenListingPending}
+	update := bson.M{"$set": bson.M{
+		"status":     status,
+		"reason":     reason,
+		"reviewedBy": reviewer.Hex(),
+		"updatedAt":  time.Now(),
+	}}
+
+	err := db.Update(dao.dbName, dao.collectionName, q, update)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Drop drops all the token listing documents in the current database
+func (dao *TokenListingDao) Drop() error {
+	err := db.DropCollection(dao.dbName, dao.collectionName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
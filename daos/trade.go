@@ -1,6 +1,7 @@
 package daos
 
 import (
+	"errors"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
@@ -22,15 +23,24 @@ type TradeDao struct {
 func NewTradeDao() *TradeDao {
 	dbName := app.Config.DBName
 	collection := "trades"
-	index := mgo.Index{
-		Key:    []string{"hash"},
-		Sparse: true,
-	}
+	// the OHLCV aggregation matches the trades of a time range, of all the pairs or of some pairs, and
+	// the trades of an account or of a pair are listed from the most recent. The trades of an order
+	// are found by its maker or taker order hash
+	err := db.EnsureIndexes(dbName, collection,
+		mgo.Index{Key: []string{"hash"}, Sparse: true},
+		mgo.Index{Key: []string{"orderHash"}},
+		mgo.Index{Key: []string{"takerOrderHash"}},
+		mgo.Index{Key: []string{"maker", "createdAt"}},
+		mgo.Index{Key: []string{"taker", "createdAt"}},
+		mgo.Index{Key: []string{"pairName", "createdAt"}},
+		mgo.Index{Key: []string{"baseToken", "quoteToken", "createdAt"}},
+		mgo.Index{Key: []string{"createdAt"}},
+	)
 
-	err := db.Session.DB(dbName).C(collection).EnsureIndex(index)
 	if err != nil {
 		panic(err)
 	}
+
 	return &TradeDao{collection, dbName}
 }
 
@@ -38,7 +48,7 @@ func NewTradeDao() *TradeDao {
 // It accepts 1 or more trades as input.
 // All the trades are inserted in one query itself.
 func (dao *TradeDao) Create(trades ...*types.Trade) error {
-	y := make([]interface{}, len(trades))
+	y := make([]interface{}, 0, len(trades))
 
 	for _, trade := range trades {
 		trade.ID = bson.NewObjectId()
@@ -56,6 +66,70 @@ func (dao *TradeDao) Create(trades ...*types.Trade) error {
 	return nil
 }
 
+// CreateBatch inserts the trades of a match with a single unordered bulk insert, so that a match
+// against many makers costs one round trip. The hash index of the trades is not unique, so the
+// trades whose hash is already stored, or repeated in the batch, are found with a single query
+// beforehand and not inserted. A trade that fails does not prevent the others from being
+// inserted: the returned types.TradeBatchErrors lists each trade that was not inserted.
+func (dao *TradeDao) CreateBatch(trades []*types.Trade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	hashes := []string{}
+	for _, t := range trades {
+		hashes = append(hashes, t.Hash.Hex())
+	}
+
+	existing := []bson.M{}
+	q := bson.M{"hash": bson.M{"$in": hashes}}
+	err := db.Query(dao.dbName, dao.collectionName, q, bson.M{"hash": 1}, 0, 0, &existing)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	stored := map[string]bool{}
+	for _, doc := range existing {
+		if hash, ok := doc["hash"].(string); ok {
+			stored[hash] = true
+		}
+	}
+
+	errs := types.TradeBatchErrors{}
+	inserted := []*types.Trade{}
+	docs := []interface{}{}
+	now := time.Now()
+	for _, t := range trades {
+		if stored[t.Hash.Hex()] {
+			errs.Add(t, types.ErrDuplicateTrade)
+			continue
+		}
+
+		stored[t.Hash.Hex()] = true
+		t.ID = bson.NewObjectId()
+		t.CreatedAt = now
+		t.UpdatedAt = now
+		inserted = append(inserted, t)
+		docs = append(docs, t)
+	}
+
+	if len(docs) > 0 {
+		err = db.InsertAll(dao.dbName, dao.collectionName, docs...)
+		if bulkErr, ok := err.(*mgo.BulkError); ok {
+			for _, c := range bulkErr.Cases() {
+				if c.Index >= 0 && c.Index < len(inserted) {
+					errs.Add(inserted[c.Index], c.Err)
+				}
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return errs.Err()
+}
+
 func (dao *TradeDao) Update(trade *types.Trade) error {
 	trade.UpdatedAt = time.Now()
 	err := db.Update(dao.dbName, dao.collectionName, bson.M{"_id": trade.ID}, trade)
@@ -77,12 +151,8 @@ func (dao *TradeDao) UpdateByHash(hash common.Hash, t *types.Trade) error {
 		"tradeNonce":     t.TradeNonce.String(),
 		"txHash":         t.TxHash.String(),
 		"takerOrderHash": t.TakerOrderHash.String(),
-		"signature": &types.SignatureRecord{
-			V: t.Signature.V,
-			R: t.Signature.R.Hex(),
-			S: t.Signature.S.Hex(),
-		},
-		"updatedAt": t.UpdatedAt,
+		"signature":      t.Signature,
+		"updatedAt":      t.UpdatedAt,
 	}}
 
 	err := db.Update(dao.dbName, dao.collectionName, query, update)
@@ -106,16 +176,46 @@ func (dao *TradeDao) GetAll() ([]types.Trade, error) {
 	return response, nil
 }
 
-// Aggregate function calls the aggregate pipeline of mongodb
-func (dao *TradeDao) Aggregate(q []bson.M) ([]*types.Tick, error) {
-	var response []*types.Tick
-	err := db.Aggregate(dao.dbName, dao.collectionName, q, &response)
+// GetOHLCV computes the OHLCV ticks of the given duration and unit of the trades created between
+// from (inclusive) and to (exclusive), of the given pairs or of all the pairs if none is given.
+// The trades are bucketed by an aggregate pipeline so that they are not loaded in memory. Ticks
+// are sorted by timestamp and then by pair, and their quote volume is the sum of the amounts of
+// the trades multiplied by their pricepoints (see Tick.SetBSON).
+func (dao *TradeDao) GetOHLCV(pairs []types.PairSubDoc, duration int64, unit string, from, to time.Time) ([]*types.Tick, error) {
+	toDecimal := bson.M{"$addFields": bson.M{
+		"pd": bson.M{"$toDecimal": "$pricepoint"},
+		"ad": bson.M{"$toDecimal": "$amount"},
+		"pv": bson.M{"$multiply": []bson.M{{"$toDecimal": "$amount"}, {"$toDecimal": "$pricepoint"}}},
+		"ts": getTickTimestampBson("$createdAt", duration, unit),
+	}}
+
+	// trades created at the same time are sorted by id so that the open and close of the ticks do
+	// not depend on the order in which mongo scans them
+	q := []bson.M{
+		{"$match": getOHLCVMatchQuery(to, from, pairs...)},
+		{"$sort": bson.D{{Name: "createdAt", Value: 1}, {Name: "_id", Value: 1}}},
+		toDecimal,
+		{"$group": getOHLCVGroupBson()},
+		{"$sort": bson.D{
+			{Name: "_id.timestamp", Value: 1},
+			{Name: "_id.baseToken", Value: 1},
+			{Name: "_id.quoteToken", Value: 1},
+		}},
+	}
+
+	ticks := []*types.Tick{}
+	err := db.Aggregate(dao.dbName, dao.collectionName, q, &ticks)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	return response, nil
+	for _, t := range ticks {
+		t.Duration = duration
+		t.Unit = unit
+	}
+
+	return ticks, nil
 }
 
 // GetByPairName fetches all the trades corresponding to a particular pair name.
@@ -146,15 +246,39 @@ func (dao *TradeDao) GetByHash(hash common.Hash) (*types.Trade, error) {
 		return nil, err
 	}
 
+	if len(response) == 0 {
+		return nil, nil
+	}
+
 	return response[0], nil
 }
 
-// GetByOrderHash fetches the first trade record which matches a certain order hash
+// GetByOrderHash fetches the trades of an order, as maker or taker, sorted by creation time. An
+// empty slice is returned for an unknown order.
 func (dao *TradeDao) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
-	q := bson.M{"orderHash": hash.Hex()}
+	return dao.GetByOrderHashes([]common.Hash{hash})
+}
 
+// GetByOrderHashes fetches the trades of the given orders, as maker or taker, sorted by creation
+// time. A trade between two of the orders is returned once. An empty slice is returned if none
+// of the orders has traded.
+func (dao *TradeDao) GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error) {
 	response := []*types.Trade{}
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &response)
+	if len(hashes) == 0 {
+		return response, nil
+	}
+
+	hexes := []string{}
+	for _, h := range hashes {
+		hexes = append(hexes, h.Hex())
+	}
+
+	q := bson.M{"$or": []bson.M{
+		{"orderHash": bson.M{"$in": hexes}},
+		{"takerOrderHash": bson.M{"$in": hexes}},
+	}}
+
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"createdAt", "_id"}, 0, 0, &response)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -163,11 +287,45 @@ func (dao *TradeDao) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
 	return response, nil
 }
 
-// GetByPairAddress fetches all the trades corresponding to a particular pair token address.
-func (dao *TradeDao) GetByPairAddress(baseToken, quoteToken common.Address) ([]*types.Trade, error) {
-	var response []*types.Trade
+// getPage returns a page of up to limit trades matching a query, the most recent first, starting
+// after a cursor (see Database.GetPage). The cursor of the next page is returned along with the
+// trades, and is empty if there is no next page.
+func (dao *TradeDao) getPage(q bson.M, cursor string, limit int) ([]*types.Trade, string, error) {
+	response := []*types.Trade{}
+	err := db.GetPage(dao.dbName, dao.collectionName, q, cursor, false, limit, &response)
+	if err != nil {
+		logger.Error(err)
+		return nil, "", err
+	}
+
+	if len(response) <= limit {
+		return response, "", nil
+	}
+
+	response = response[:limit]
+	last := response[limit-1]
+	c := &types.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	return response, c.Encode(), nil
+}
 
+// GetByPairAddress fetches a page of the trades of a pair, the most recent first, along with the
+// cursor of the next page.
+func (dao *TradeDao) GetByPairAddress(baseToken, quoteToken common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
 	q := bson.M{"baseToken": baseToken.Hex(), "quoteToken": quoteToken.Hex()}
+	return dao.getPage(q, cursor, limit)
+}
+
+// GetPendingByPairAddress fetches the trades of a pair that are still being settled, ie. the trades
+// that were not sent to the exchange contract yet and the trades whose transaction is pending
+func (dao *TradeDao) GetPendingByPairAddress(baseToken, quoteToken common.Address) ([]*types.Trade, error) {
+	var response []*types.Trade
+
+	q := bson.M{
+		"baseToken":  baseToken.Hex(),
+		"quoteToken": quoteToken.Hex(),
+		"status":     bson.M{"$in": types.TradePendingStatuses},
+	}
+
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &response)
 	if err != nil {
 		logger.Error(err)
@@ -177,29 +335,91 @@ func (dao *TradeDao) GetByPairAddress(baseToken, quoteToken common.Address) ([]*
 	return response, nil
 }
 
-// GetByUserAddress fetches all the trades corresponding to a particular user address.
-func (dao *TradeDao) GetByUserAddress(addr common.Address) ([]*types.Trade, error) {
-	var response []*types.Trade
+// GetByUserAddress fetches a page of the trades of an account, as a maker or as a taker, the most
+// recent first, along with the cursor of the next page.
+func (dao *TradeDao) GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
 	q := bson.M{"$or": []bson.M{
 		{"maker": addr.Hex()}, {"taker": addr.Hex()},
 	}}
 
-	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &response)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	return dao.getPage(q, cursor, limit)
+}
+
+// StreamBatchSize is the number of trades read from the database at once by StreamByAddress
+var StreamBatchSize = 1000
+
+// tradeIterator is the types.TradeIterator of the trades of an Iter
+type tradeIterator struct {
+	*Iter
+}
+
+// Next decodes the next trade into t
+func (it *tradeIterator) Next(t *types.Trade) bool {
+	return it.Iter.Next(t)
+}
+
+// StreamByAddress returns an iterator over the trades of an account, as a maker or as a taker,
+// created between from (inclusive) and to (exclusive), in creation time order. A zero from or
+// to leaves the range open on that side. The trades are read in batches of StreamBatchSize, so
+// that accounts with any number of trades are exported with bounded memory. The iterator must be
+// closed.
+func (dao *TradeDao) StreamByAddress(addr common.Address, from, to time.Time) types.TradeIterator {
+	q := bson.M{"$or": []bson.M{
+		{"maker": addr.Hex()}, {"taker": addr.Hex()},
+	}}
+
+	createdAt := bson.M{}
+	if !from.IsZero() {
+		createdAt["$gte"] = from
 	}
 
-	return response, nil
+	if !to.IsZero() {
+		createdAt["$lt"] = to
+	}
+
+	if len(createdAt) > 0 {
+		q["createdAt"] = createdAt
+	}
+
+	// sorting on createdAt alone lets mongo merge the maker and taker indexes instead of sorting
+	// the trades of the account in memory
+	iter := db.Iter(dao.dbName, dao.collectionName, q, []string{"createdAt"}, StreamBatchSize)
+	return &tradeIterator{iter}
 }
 
+// UpdateTradeStatus moves the trade corresponding to the given hash to the given status. An error is
+// returned if the trade can not move to the status (see types.CanTransitionTradeStatus) or if its
+// status changed concurrently, so that a trade is only settled or failed once.
 func (dao *TradeDao) UpdateTradeStatus(hash common.Hash, status string) error {
-	query := bson.M{"hash": hash.Hex()}
+	t, err := dao.GetByHash(hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if t == nil {
+		return errors.New("Trade not found")
+	}
+
+	current := t.Status
+	err = t.TransitionTo(status)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	// the trades created before statuses were set have no status field
+	var currentStatus interface{} = current
+	if current == "" {
+		currentStatus = bson.M{"$in": []interface{}{nil, ""}}
+	}
+
+	query := bson.M{"hash": hash.Hex(), "status": currentStatus}
 	update := bson.M{"$set": bson.M{
 		"status": status,
 	}}
 
-	err := db.Update(dao.dbName, dao.collectionName, query, update)
+	err = db.Update(dao.dbName, dao.collectionName, query, update)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -212,3 +432,91 @@ func (dao *TradeDao) UpdateTradeStatus(hash common.Hash, status string) error {
 func (dao *TradeDao) Drop() {
 	db.DropCollection(dao.dbName, dao.collectionName)
 }
+
+// getOHLCVMatchQuery returns the query matching the trades created in [gt, lt) of the given pairs,
+// or of all the pairs if none is given
+func getOHLCVMatchQuery(lt, gt time.Time, pairs ...types.PairSubDoc) bson.M {
+	match := bson.M{"createdAt": bson.M{"$gte": gt, "$lt": lt}}
+
+	if len(pairs) >= 1 {
+		or := make([]bson.M, 0)
+
+		for _, pair := range pairs {
+			or = append(or, bson.M{
+				"$and": []bson.M{
+					{
+						"baseToken":  pair.BaseToken.Hex(),
+						"quoteToken": pair.QuoteToken.Hex(),
+					},
+				},
+			},
+			)
+		}
+
+		match["$or"] = or
+	}
+	return match
+}
+
+// getTickTimestampBson returns the aggregate expression computing the start of the tick interval
+// containing the date key, in milliseconds. It follows the same bucketing as types.TickStart.
+func getTickTimestampBson(key string, duration int64, unit string) bson.M {
+	epoch := time.Unix(0, 0)
+
+	switch unit {
+	case "month":
+		months := bson.M{"$add": []interface{}{
+			bson.M{"$multiply": []interface{}{bson.M{"$subtract": []interface{}{bson.M{"$year": key}, 1970}}, 12}},
+			bson.M{"$subtract": []interface{}{bson.M{"$month": key}, 1}},
+		}}
+
+		start := bson.M{"$subtract": []interface{}{months, bson.M{"$mod": []interface{}{months, duration}}}}
+		date := bson.M{"$dateFromParts": bson.M{
+			"year":  bson.M{"$add": []interface{}{1970, bson.M{"$floor": bson.M{"$divide": []interface{}{start, 12}}}}},
+			"month": bson.M{"$add": []interface{}{bson.M{"$mod": []interface{}{start, 12}}, 1}},
+		}}
+
+		return bson.M{"$subtract": []interface{}{date, epoch}}
+
+	case "year":
+		years := bson.M{"$subtract": []interface{}{bson.M{"$year": key}, 1970}}
+		start := bson.M{"$subtract": []interface{}{years, bson.M{"$mod": []interface{}{years, duration}}}}
+		date := bson.M{"$dateFromParts": bson.M{
+			"year": bson.M{"$add": []interface{}{1970, start}},
+		}}
+
+		return bson.M{"$subtract": []interface{}{date, epoch}}
+
+	default:
+		// ticks are aligned on the start of the tick interval containing the epoch
+		offset := types.TickStart(epoch, duration, unit).Sub(epoch) / time.Millisecond
+		interval := types.TickLength(duration, unit) / time.Millisecond
+		ms := bson.M{"$subtract": []interface{}{key, epoch}}
+
+		return bson.M{"$subtract": []interface{}{
+			ms,
+			bson.M{"$mod": []interface{}{bson.M{"$subtract": []interface{}{ms, int64(offset)}}, int64(interval)}},
+		}}
+	}
+}
+
+// getOHLCVGroupBson returns the aggregate expression grouping trades by pair and tick interval
+func getOHLCVGroupBson() bson.M {
+	decimal1, _ := bson.ParseDecimal128("1")
+
+	return bson.M{
+		"_id": bson.M{
+			"pairName":   "$pairName",
+			"baseToken":  "$baseToken",
+			"quoteToken": "$quoteToken",
+			"timestamp":  "$ts",
+		},
+		"count":       bson.M{"$sum": decimal1},
+		"high":        bson.M{"$max": "$pd"},
+		"low":         bson.M{"$min": "$pd"},
+		"open":        bson.M{"$first": "$pd"},
+		"close":       bson.M{"$last": "$pd"},
+		"volume":      bson.M{"$sum": "$ad"},
+		"priceVolume": bson.M{"$sum": "$pv"},
+	}
+}
@@ -1,9 +1,13 @@
 package daos
 
 import (
+	"fmt"
 	"io/ioutil"
 	"math/big"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
@@ -121,7 +125,7 @@ func TestTradeDao(t *testing.T) {
 	testutils.CompareTrade(t, trs2[0], trs[0])
 	testutils.CompareTrade(t, trs2[1], trs[1])
 
-	trs3, err := dao.GetByPairAddress(ZRXAddress, DAIAddress)
+	trs3, _, err := dao.GetByPairAddress(ZRXAddress, DAIAddress, "", 10)
 	if err != nil {
 		t.Errorf("Could not retrieve objects")
 	}
@@ -191,3 +195,449 @@ func TestUpdateTrade(t *testing.T) {
 
 	testutils.CompareTrade(t, queried, updated)
 }
+
+func TestUpdateTradeStatus(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	tr := testutils.GetTestTrade1()
+	tr.Status = types.TradeStatusPending
+	err := dao.Create(&tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dao.UpdateTradeStatus(tr.Hash, types.TradeStatusFailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queried, _ := dao.GetByHash(tr.Hash)
+	assert.Equal(t, types.TradeStatusFailed, queried.Status)
+
+	// a failed trade can not be updated anymore
+	err = dao.UpdateTradeStatus(tr.Hash, types.TradeStatusSuccess)
+	assert.NotNil(t, err)
+
+	// the trades created before statuses were set are pending
+	legacy := testutils.GetTestTrade2()
+	err = dao.Create(&legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, bson.M{"hash": legacy.Hash.Hex()}, bson.M{"$unset": bson.M{"status": ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dao.UpdateTradeStatus(legacy.Hash, types.TradeStatusSuccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queried, _ = dao.GetByHash(legacy.Hash)
+	assert.Equal(t, types.TradeStatusSuccess, queried.Status)
+}
+
+// The ticks computed by the aggregate pipeline are the ticks computed in memory from the same trades
+func TestTradeDaoGetOHLCV(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	start := time.Date(2018, 9, 3, 9, 0, 0, 0, time.UTC)
+	trades := ohlcvTrades(start, 500, 7*time.Second)
+
+	// trades created at the same time are sorted by id
+	trades[1].CreatedAt = trades[0].CreatedAt
+	trades[2].CreatedAt = trades[0].CreatedAt
+
+	err := insertTrades(dao, trades)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := start.Add(time.Hour)
+	zrx := types.PairSubDoc{BaseToken: trades[0].BaseToken, QuoteToken: trades[0].QuoteToken}
+	zrxTrades := []*types.Trade{}
+	inRange := []*types.Trade{}
+	for _, tr := range trades {
+		if tr.CreatedAt.Before(end) {
+			inRange = append(inRange, tr)
+			if tr.BaseToken == zrx.BaseToken {
+				zrxTrades = append(zrxTrades, tr)
+			}
+		}
+	}
+
+	for _, unit := range []string{"sec", "min", "hour"} {
+		ticks, err := dao.GetOHLCV(nil, 15, unit, start, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, types.ComputeTicks(inRange, 15, unit), ticks, unit)
+	}
+
+	ticks, err := dao.GetOHLCV([]types.PairSubDoc{zrx}, 5, "min", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.ComputeTicks(zrxTrades, 5, "min"), ticks)
+}
+
+// ohlcvTrades returns n trades alternating between two pairs, created every interval from start,
+// sorted by creation time and then by id
+func TestTradeDaoCreateBatch(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	trades := ohlcvTrades(time.Now(), 4, time.Second)
+	err := dao.Create(trades[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first trade is already stored and the last one repeats the hash of the second one
+	trades[3].Hash = trades[1].Hash
+	err = dao.CreateBatch(trades)
+
+	errs, ok := err.(types.TradeBatchErrors)
+	if !ok {
+		t.Fatalf("Expected trade batch errors, got %v", err)
+	}
+
+	assert.Equal(t, 2, len(errs))
+	assert.True(t, errs.Failed(trades[0]))
+	assert.False(t, errs.Failed(trades[1]))
+	assert.False(t, errs.Failed(trades[2]))
+	assert.True(t, errs.Failed(trades[3]))
+	assert.Equal(t, types.ErrDuplicateTrade, errs[0].Err)
+
+	all, err := dao.GetAll()
+	if err != nil {
+		t.Error("Could not retrieve trades", err)
+	}
+
+	assert.Equal(t, 3, len(all))
+
+	for _, tr := range trades[1:3] {
+		stored, err := dao.GetByHash(tr.Hash)
+		if err != nil {
+			t.Error("Could not retrieve trade", err)
+		}
+
+		assert.Equal(t, tr.ID, stored.ID)
+	}
+
+	assert.Nil(t, dao.CreateBatch(nil))
+}
+
+func TestTradeDaoGetByOrderHashes(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	a, b, c := common.HexToHash("0xa"), common.HexToHash("0xb"), common.HexToHash("0xc")
+	trades := ohlcvTrades(time.Now(), 4, time.Minute)
+	hashes := [][2]common.Hash{
+		{a, common.HexToHash("0x1")},
+		{b, a},
+		{c, common.HexToHash("0x2")},
+		{a, b},
+	}
+
+	for i, h := range hashes {
+		trades[i].OrderHash = h[0]
+		trades[i].TakerOrderHash = h[1]
+	}
+
+	// the trades are inserted in reverse order, they are returned by creation time
+	err := insertTrades(dao, []*types.Trade{trades[3], trades[2], trades[1], trades[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tradeHashes := func(trades []*types.Trade) []common.Hash {
+		res := []common.Hash{}
+		for _, tr := range trades {
+			res = append(res, tr.Hash)
+		}
+
+		return res
+	}
+
+	// the trades of an order as maker or taker
+	res, err := dao.GetByOrderHash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.Hash{trades[0].Hash, trades[1].Hash, trades[3].Hash}, tradeHashes(res))
+
+	// a trade between two of the orders is returned once
+	res, err = dao.GetByOrderHashes([]common.Hash{b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.Hash{trades[1].Hash, trades[2].Hash, trades[3].Hash}, tradeHashes(res))
+
+	// unknown orders have no trades
+	res, err = dao.GetByOrderHash(common.HexToHash("0xd"))
+	assert.Nil(t, err)
+	assert.Equal(t, []*types.Trade{}, res)
+
+	res, err = dao.GetByOrderHashes(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []*types.Trade{}, res)
+}
+
+func TestTradeDaoStreamByAddress(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	maker := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	other := common.HexToAddress("0x3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f")
+
+	// the account is the maker of the first trades and the taker of the last one, the other
+	// account only traded once
+	trades := ohlcvTrades(start, 5, time.Minute)
+	trades[3].Maker = other
+	trades[4].Maker, trades[4].Taker = other, maker
+
+	err := insertTrades(dao, []*types.Trade{trades[4], trades[2], trades[0], trades[3], trades[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := func(addr common.Address, from, to time.Time) []common.Hash {
+		it := dao.StreamByAddress(addr, from, to)
+		res := []common.Hash{}
+		tr := &types.Trade{}
+		for it.Next(tr) {
+			res = append(res, tr.Hash)
+		}
+
+		assert.Nil(t, it.Close())
+		return res
+	}
+
+	// the trades are streamed by creation time
+	res := stream(maker, time.Time{}, time.Time{})
+	assert.Equal(t, []common.Hash{trades[0].Hash, trades[1].Hash, trades[2].Hash, trades[4].Hash}, res)
+
+	res = stream(other, time.Time{}, time.Time{})
+	assert.Equal(t, []common.Hash{trades[3].Hash, trades[4].Hash}, res)
+
+	// from is inclusive and to is exclusive
+	res = stream(maker, start.Add(time.Minute), start.Add(4*time.Minute))
+	assert.Equal(t, []common.Hash{trades[1].Hash, trades[2].Hash}, res)
+
+	res = stream(maker, start.Add(2*time.Minute), time.Time{})
+	assert.Equal(t, []common.Hash{trades[2].Hash, trades[4].Hash}, res)
+
+	res = stream(common.HexToAddress("0x1"), time.Time{}, time.Time{})
+	assert.Equal(t, []common.Hash{}, res)
+}
+
+func ohlcvTrades(start time.Time, n int, interval time.Duration) []*types.Trade {
+	pairs := []types.PairID{
+		{
+			PairName:   "ZRX/WETH",
+			BaseToken:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+			QuoteToken: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		},
+		{
+			PairName:   "DAI/WETH",
+			BaseToken:  common.HexToAddress("0x4dc5790733b997f3db7fc49118ab013182d6ba9b"),
+			QuoteToken: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		},
+	}
+
+	trades := []*types.Trade{}
+	for i := 0; i < n; i++ {
+		p := pairs[i%len(pairs)]
+		trades = append(trades, &types.Trade{
+			ID:         bson.NewObjectId(),
+			Maker:      common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			Taker:      common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+			BaseToken:  p.BaseToken,
+			QuoteToken: p.QuoteToken,
+			PairName:   p.PairName,
+			Hash:       common.BigToHash(big.NewInt(int64(i + 1))),
+			TradeNonce: big.NewInt(int64(i)),
+			Side:       "BUY",
+			PricePoint: big.NewInt(int64(10000 + (i*7919)%1000)),
+			Amount:     big.NewInt(int64(1 + (i*104729)%100)),
+			CreatedAt:  start.Add(time.Duration(i) * interval),
+		})
+	}
+
+	return trades
+}
+
+// insertTrades inserts trades with their creation time, which TradeDao.Create would overwrite
+func insertTrades(dao *TradeDao, trades []*types.Trade) error {
+	batch := []interface{}{}
+	for i, tr := range trades {
+		batch = append(batch, tr)
+		if len(batch) == 1000 || i == len(trades)-1 {
+			err := db.Create(dao.dbName, dao.collectionName, batch...)
+			if err != nil {
+				return err
+			}
+
+			batch = []interface{}{}
+		}
+	}
+
+	return nil
+}
+
+// ohlcvBenchmarkTrades is the number of trades of the fixture of the OHLCV benchmarks, one every
+// second from ohlcvBenchmarkStart
+const ohlcvBenchmarkTrades = 1000000
+
+var ohlcvBenchmarkStart = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var loadOHLCVFixture sync.Once
+
+// ohlcvBenchmarkDao returns a trade DAO holding the fixture of the OHLCV benchmarks, inserted once
+// for all the benchmarks
+func ohlcvBenchmarkDao(b *testing.B) *TradeDao {
+	dao := NewTradeDao()
+	loadOHLCVFixture.Do(func() {
+		dao.Drop()
+		err := insertTrades(dao, ohlcvTrades(ohlcvBenchmarkStart, ohlcvBenchmarkTrades, time.Second))
+		if err != nil {
+			b.Fatal(err)
+		}
+	})
+
+	return dao
+}
+
+// BenchmarkTradeDaoGetOHLCV computes the hourly ticks of the fixture with the aggregate pipeline
+func BenchmarkTradeDaoGetOHLCV(b *testing.B) {
+	dao := ohlcvBenchmarkDao(b)
+	end := ohlcvBenchmarkStart.Add(ohlcvBenchmarkTrades * time.Second)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := dao.GetOHLCV(nil, 1, "hour", ohlcvBenchmarkStart, end)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkTradeDaoGetOHLCVInMemory computes the same ticks by loading the trades and bucketing
+// them in memory
+func BenchmarkTradeDaoGetOHLCVInMemory(b *testing.B) {
+	dao := ohlcvBenchmarkDao(b)
+	end := ohlcvBenchmarkStart.Add(ohlcvBenchmarkTrades * time.Second)
+	q := getOHLCVMatchQuery(end, ohlcvBenchmarkStart)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trades := []*types.Trade{}
+		err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"createdAt", "_id"}, 0, 0, &trades)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		types.ComputeTicks(trades, 1, "hour")
+	}
+}
+
+// BenchmarkTradeDaoStreamByAddress streams the trades of the maker of the fixture, and fails if
+// the heap grows with the number of trades streamed
+func BenchmarkTradeDaoStreamByAddress(b *testing.B) {
+	dao := ohlcvBenchmarkDao(b)
+	maker := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		stats := runtime.MemStats{}
+		runtime.ReadMemStats(&stats)
+		baseline := stats.HeapAlloc
+
+		it := dao.StreamByAddress(maker, time.Time{}, time.Time{})
+		n := 0
+		tr := &types.Trade{}
+		for it.Next(tr) {
+			n++
+			if n%100000 == 0 {
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > baseline+64<<20 {
+					b.Fatalf("Heap grew by %v bytes after %v trades", stats.HeapAlloc-baseline, n)
+				}
+			}
+		}
+
+		err := it.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if n != ohlcvBenchmarkTrades {
+			b.Fatalf("Streamed %v trades, want %v", n, ohlcvBenchmarkTrades)
+		}
+	}
+}
+
+// batchBenchmarkTrades returns the trades of the i-th match of a benchmark, with hashes that
+// are not used by the previous matches
+func batchBenchmarkTrades(i, n int) []*types.Trade {
+	trades := ohlcvTrades(time.Now(), n, time.Millisecond)
+	for j, tr := range trades {
+		tr.Hash = common.BigToHash(big.NewInt(int64(i*n + j + 1)))
+	}
+
+	return trades
+}
+
+// BenchmarkTradeDaoCreate inserts the trades of a match of 1, 10 or 100 makers one at a time,
+// as the trades were inserted before the bulk insert, and with a single bulk insert
+func BenchmarkTradeDaoCreate(b *testing.B) {
+	dao := NewTradeDao()
+	// the benchmarks use their own collection so as not to drop the OHLCV fixture
+	dao.collectionName = "trades_create_benchmark"
+
+	for _, n := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("Sequential/%d", n), func(b *testing.B) {
+			dao.Drop()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				trades := batchBenchmarkTrades(i, n)
+				b.StartTimer()
+
+				for _, tr := range trades {
+					err := dao.Create(tr)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Batch/%d", n), func(b *testing.B) {
+			dao.Drop()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				trades := batchBenchmarkTrades(i, n)
+				b.StartTimer()
+
+				err := dao.CreateBatch(trades)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
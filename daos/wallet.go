@@ -4,6 +4,7 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -15,8 +16,20 @@ type WalletDao struct {
 	dbName         string
 }
 
+// NewWalletDao returns a new instance of WalletDao
 func NewWalletDao() *WalletDao {
-	return &WalletDao{"wallets", app.Config.DBName}
+	dbName := app.Config.DBName
+	collection := "wallets"
+	index := mgo.Index{
+		Key: []string{"address"},
+	}
+
+	err := db.EnsureIndexes(dbName, collection, index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &WalletDao{collection, dbName}
 }
 
 func (dao *WalletDao) Create(wallet *types.Wallet) error {
@@ -110,3 +123,44 @@ func (dao *WalletDao) GetOperatorWallets() ([]*types.Wallet, error) {
 
 	return res, nil
 }
+
+// EncryptWallets re-encrypts in place the private keys of wallet records that were written in plain hex
+// or with an older encryption key. It returns the number of migrated records.
+func (dao *WalletDao) EncryptWallets() (int, error) {
+	version, err := types.CurrentWalletEncryptionKeyVersion()
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	q := bson.M{"$or": []bson.M{
+		bson.M{"encryptedPrivateKey": bson.M{"$exists": false}},
+		bson.M{"keyVersion": bson.M{"$ne": version}},
+	}}
+
+	var wallets []types.Wallet
+	err = db.Get(dao.dbName, dao.collectionName, q, 0, 0, &wallets)
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	migrated := 0
+	for i := range wallets {
+		// watch-only wallets do not hold any key to encrypt
+		w := &wallets[i]
+		if w.IsWatchOnly() {
+			continue
+		}
+
+		err = db.Update(dao.dbName, dao.collectionName, bson.M{"_id": w.ID}, w)
+		if err != nil {
+			logger.Error(err)
+			return migrated, err
+		}
+
+		migrated++
+	}
+
+	return migrated, nil
+}
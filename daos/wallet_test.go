@@ -1,11 +1,13 @@
 package daos
 
 import (
+	"encoding/hex"
 	"io/ioutil"
 	"reflect"
 	"testing"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/dbtest"
 )
 
@@ -17,14 +19,20 @@ func init() {
 
 	session := server.Session()
 	db = &Database{session}
+
+	types.SetWalletEncryptionKey(1, []byte("0123456789abcdef0123456789abcdef"))
 }
 
 func TestWalletDao(t *testing.T) {
 	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
-	w := types.NewWalletFromPrivateKey(key)
+	w, err := types.NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	dao := NewWalletDao()
 
-	err := dao.Create(w)
+	err = dao.Create(w)
 	if err != nil {
 		t.Errorf("Could not create wallet object")
 	}
@@ -59,11 +67,15 @@ func TestWalletDao(t *testing.T) {
 
 func TestDefaultAdminWallet(t *testing.T) {
 	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
-	w := types.NewWalletFromPrivateKey(key)
+	w, err := types.NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	w.Admin = true
 	dao := NewWalletDao()
 
-	err := dao.Create(w)
+	err = dao.Create(w)
 	if err != nil {
 		t.Errorf("Could not create wallet object")
 	}
@@ -77,3 +89,62 @@ func TestDefaultAdminWallet(t *testing.T) {
 		t.Errorf("Could not get correct admin wallet:\n Expected: %v\n, Got: %v\n", w, wallet)
 	}
 }
+
+func TestEncryptWallets(t *testing.T) {
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712661"
+	w, err := types.NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dao := NewWalletDao()
+
+	legacy := bson.M{
+		"_id":        bson.NewObjectId(),
+		"address":    w.Address.Hex(),
+		"privateKey": key,
+		"operator":   true,
+	}
+
+	err = db.Create(dao.dbName, dao.collectionName, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := dao.EncryptWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("Expected 1 migrated wallet but got %v", n)
+	}
+
+	var records []types.WalletRecord
+	err = db.Get(dao.dbName, dao.collectionName, bson.M{"address": w.Address.Hex()}, 0, 0, &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if records[0].PrivateKey != "" || records[0].EncryptedPrivateKey == "" {
+		t.Errorf("Expected private key to be encrypted: %v", records[0])
+	}
+
+	migrated, err := dao.GetByAddress(w.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(migrated.PrivateKey.D.Bytes()) != key || !migrated.Operator {
+		t.Errorf("Could not get correct migrated wallet:\n Expected: %v\n, Got: %v\n", w, migrated)
+	}
+
+	n, err = dao.EncryptWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("Expected no migrated wallet but got %v", n)
+	}
+}
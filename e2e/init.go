@@ -75,21 +75,35 @@ func NewRouter() *mux.Router {
 	tokenDao := daos.NewTokenDao()
 	pairDao := daos.NewPairDao()
 	tradeDao := daos.NewTradeDao()
+	matchDao := daos.NewMatchDao(orderDao, tradeDao)
+	orderCancelDao := daos.NewOrderCancelDao()
+	balanceChangeDao := daos.NewBalanceChangeDao()
 	accountDao := daos.NewAccountDao()
 	walletDao := daos.NewWalletDao()
 
 	// instantiate engine
 	eng := engine.NewEngine(redisConn, rabbitConn, pairDao)
+	_, err := matchDao.RecoverMatches()
+	if err != nil {
+		panic(err)
+	}
+
+	err = eng.Reconcile(orderDao, tradeDao)
+	if err != nil {
+		panic(err)
+	}
 
 	// get services for injection
-	accountService := services.NewAccountService(accountDao, tokenDao)
-	ohlcvService := services.NewOHLCVService(tradeDao)
-	tokenService := services.NewTokenService(tokenDao)
+	accountService := services.NewAccountService(accountDao, tokenDao, balanceChangeDao, orderDao)
+	ohlcvService := services.NewOHLCVService(tradeDao, pairDao)
+	tokenService := services.NewTokenService(tokenDao, provider)
 	tradeService := services.NewTradeService(tradeDao)
 	pairService := services.NewPairService(pairDao, tokenDao, eng, tradeService)
-	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, eng, provider, rabbitConn)
+	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, matchDao, orderCancelDao, balanceChangeDao, eng, provider, rabbitConn)
 	orderBookService := services.NewOrderBookService(pairDao, tokenDao, orderDao, eng)
+	eng.SubscribeOrderBookDiffs(orderBookService.BroadcastOrderBookDiffs)
 	walletService := services.NewWalletService(walletDao)
+	authService := services.NewAuthService(walletDao)
 	cronService := crons.NewCronService(ohlcvService)
 
 	// get exchange contract instance
@@ -106,6 +120,7 @@ func NewRouter() *mux.Router {
 
 	// deploy operator
 	op, err := operator.NewOperator(
+		walletService,
 		walletService,
 		tradeService,
 		orderService,
@@ -119,9 +134,10 @@ func NewRouter() *mux.Router {
 	}
 
 	// deploy http and ws endpoints
-	endpoints.ServeAccountResource(r, accountService)
+	endpoints.ServeAccountResource(r, accountService, orderService, authService)
 	endpoints.ServeTokenResource(r, tokenService)
-	endpoints.ServePairResource(r, pairService)
+	endpoints.ServeAuthResource(r, authService)
+	endpoints.ServePairResource(r, pairService, authService)
 	endpoints.ServeOrderBookResource(r, orderBookService)
 	endpoints.ServeOHLCVResource(r, ohlcvService)
 	endpoints.ServeTradeResource(r, tradeService)
@@ -74,8 +74,16 @@ func SetupTest() (
 
 	ZRX := pair.BaseTokenAddress
 	WETH := pair.QuoteTokenAddress
-	wallet1 := types.NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660")
-	wallet2 := types.NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712661")
+	wallet1, err := types.NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660")
+	if err != nil {
+		panic(err)
+	}
+
+	wallet2, err := types.NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712661")
+	if err != nil {
+		panic(err)
+	}
+
 	NewRouter()
 
 	//setup mock client
@@ -128,7 +136,7 @@ func TestBuyOrder(t *testing.T) {
 	dbo1, _ := orderDao.GetByHash(o1.Hash)
 
 	assert.Equal(t, "1000000", dbo1.PricePoint)
-	assert.Equal(t, "BUY", dbo1.Side)
+	assert.Equal(t, types.OrderSideBuy, dbo1.Side)
 	assert.Equal(t, "OPEN", dbo1.Status)
 	assert.Equal(t, "ZRX/WETH", dbo1.PairName)
 	assert.Equal(t, ZRX, dbo1.BaseToken)
@@ -183,7 +191,7 @@ func TestBuyAndCancelOrder(t *testing.T) {
 
 	dbo1, _ := orderDao.GetByHash(o1.Hash)
 	assert.Equal(t, big.NewInt(1000000), dbo1.PricePoint)
-	assert.Equal(t, "BUY", dbo1.Side)
+	assert.Equal(t, types.OrderSideBuy, dbo1.Side)
 	assert.Equal(t, "CANCELLED", dbo1.Status)
 	assert.Equal(t, big.NewInt(0), dbo1.FilledAmount)
 }
@@ -253,8 +261,8 @@ func TestMatchOrder(t *testing.T) {
 
 	assert.Equal(t, big.NewInt(1000000), dbo1.PricePoint)
 	assert.Equal(t, big.NewInt(1000000), dbo1.PricePoint)
-	assert.Equal(t, "BUY", dbo1.Side)
-	assert.Equal(t, "SELL", dbo2.Side)
+	assert.Equal(t, types.OrderSideBuy, dbo1.Side)
+	assert.Equal(t, types.OrderSideSell, dbo2.Side)
 	assert.Equal(t, "FILLED", dbo1.Status)
 	assert.Equal(t, "FILLED", dbo2.Status)
 	assert.Equal(t, big.NewInt(1e10), dbo1.FilledAmount)
@@ -335,8 +343,8 @@ func TestMatchPartialOrder1(t *testing.T) {
 
 	assert.Equal(t, big.NewInt(1000000), dbo1.PricePoint)
 	assert.Equal(t, big.NewInt(1000000), dbo1.PricePoint)
-	assert.Equal(t, "BUY", dbo1.Side)
-	assert.Equal(t, "SELL", dbo2.Side)
+	assert.Equal(t, types.OrderSideBuy, dbo1.Side)
+	assert.Equal(t, types.OrderSideSell, dbo2.Side)
 	assert.Equal(t, "FILLED", dbo1.Status)
 	assert.Equal(t, "REPLACED", dbo2.Status)
 	assert.Equal(t, big.NewInt(1e10), dbo1.FilledAmount)
@@ -29,7 +29,11 @@ func testWS(t *testing.T, pairs []types.Pair, accounts map[*ecdsa.PrivateKey]typ
 	factories := make([]*testutils.OrderFactory, 0)
 
 	for key := range accounts {
-		w := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(key)))
+		w, err := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(key)))
+		if err != nil {
+			panic(err)
+		}
+
 		c := testutils.NewClient(w, http.HandlerFunc(ws.ConnectionEndpoint))
 		f, err := testutils.NewOrderFactory(pair, w, exchangeAddress)
 		if err != nil {
@@ -163,7 +167,11 @@ func resetLogs(clients ...*testutils.Client) {
 func newObClient(t *testing.T, baseToken, quoteToken common.Address, testData interface{}) *testutils.Client {
 	// orderBook client
 	k, _ := crypto.GenerateKey()
-	w := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(k)))
+	w, err := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(k)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	obClient := testutils.NewClient(w, http.HandlerFunc(ws.ConnectionEndpoint))
 	obClient.Start()
 
@@ -186,7 +194,11 @@ func newObClient(t *testing.T, baseToken, quoteToken common.Address, testData in
 func newTradeClient(t *testing.T, baseToken, quoteToken common.Address, testData interface{}) *testutils.Client {
 	//tradeClient
 	k, _ := crypto.GenerateKey()
-	w := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(k)))
+	w, err := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(k)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	tradeClient := testutils.NewClient(w, http.HandlerFunc(ws.ConnectionEndpoint))
 	tradeClient.Start()
 
@@ -203,7 +215,11 @@ func newTradeClient(t *testing.T, baseToken, quoteToken common.Address, testData
 func newOHLCVClient(t *testing.T, baseToken, quoteToken common.Address, testData interface{}) *testutils.Client {
 	//ohlcvClient
 	k, _ := crypto.GenerateKey()
-	w := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(k)))
+	w, err := types.NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(k)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	ohlcvClient := testutils.NewClient(w, http.HandlerFunc(ws.ConnectionEndpoint))
 	ohlcvClient.Start()
 
@@ -3,26 +3,42 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 )
 
+// ledgerPageSize is the default and maximum number of balance changes returned by the ledger endpoint
+const ledgerPageSize = 100
+
 type accountEndpoint struct {
 	accountService interfaces.AccountService
+	orderService   interfaces.OrderService
 }
 
+// ServeAccountResource sets up the routing of account endpoints and the corresponding handlers.
+// Overriding the open orders limits of an account and freezing an account require an admin wallet.
 func ServeAccountResource(
 	r *mux.Router,
 	accountService interfaces.AccountService,
+	orderService interfaces.OrderService,
+	authService interfaces.AuthService,
 ) {
 
-	e := &accountEndpoint{accountService}
+	e := &accountEndpoint{accountService, orderService}
 	r.HandleFunc("/account", e.handleCreateAccount).Methods("POST")
 	r.HandleFunc("/account/<address>", e.handleGetAccount).Methods("GET")
+	r.HandleFunc("/account/{address}/nonce", e.handleGetNextOrderNonce).Methods("GET")
+	r.HandleFunc("/account/{address}/ledger", e.handleGetLedger).Methods("GET")
+	r.HandleFunc("/account/{address}/limits", e.handleGetOpenOrderCounts).Methods("GET")
+	r.HandleFunc("/account/{address}/limits", RequireAdmin(authService, e.handleSetOpenOrderLimits)).Methods("PUT")
+	r.HandleFunc("/account/{address}/limits", RequireAdmin(authService, e.handleResetOpenOrderLimits)).Methods("DELETE")
+	r.HandleFunc("/account/{address}/freeze", RequireAdmin(authService, e.handleFreezeAccount)).Methods("PUT")
 	r.HandleFunc("/account/{address}/{token}", e.handleGetAccountTokenBalance).Methods("GET")
 }
 
@@ -59,13 +75,12 @@ func (e *accountEndpoint) handleCreateAccount(w http.ResponseWriter, r *http.Req
 func (e *accountEndpoint) handleGetAccount(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	addr := vars["address"]
-	if !common.IsHexAddress(addr) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	address, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	address := common.HexToAddress(addr)
 	a, err := e.accountService.GetByAddress(address)
 	if err != nil {
 		logger.Error(err)
@@ -79,19 +94,18 @@ func (e *accountEndpoint) handleGetAccount(w http.ResponseWriter, r *http.Reques
 func (e *accountEndpoint) handleGetAccountTokenBalance(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	a := vars["address"]
-	if !common.IsHexAddress(a) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	t := vars["token"]
-	if !common.IsHexAddress(a) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Token Address")
+	tokenAddr, err := utils.ParseAddress(vars["token"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	addr := common.HexToAddress(a)
-	tokenAddr := common.HexToAddress(t)
-
 	b, err := e.accountService.GetTokenBalance(addr, tokenAddr)
 	if err != nil {
 		logger.Error(err)
@@ -100,3 +114,165 @@ func (e *accountEndpoint) handleGetAccountTokenBalance(w http.ResponseWriter, r
 
 	httputils.WriteJSON(w, http.StatusOK, b)
 }
+
+func (e *accountEndpoint) handleGetNextOrderNonce(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nonce, err := e.accountService.GetNextOrderNonce(addr)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]string{
+		"address":   addr.Hex(),
+		"nextNonce": nonce.String(),
+	})
+}
+
+func (e *accountEndpoint) handleGetLedger(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		offset, err = strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+	}
+
+	limit := ledgerPageSize
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 || limit > ledgerPageSize {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	changes, err := e.accountService.GetLedger(addr, offset, limit)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, changes)
+}
+
+func (e *accountEndpoint) handleGetOpenOrderCounts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	counts, err := e.accountService.GetOpenOrderCounts(addr)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, counts)
+}
+
+func (e *accountEndpoint) handleSetOpenOrderLimits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limits := &types.OpenOrderLimits{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(limits)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	err = limits.Validate()
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	counts, err := e.accountService.SetOpenOrderLimits(addr, limits)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, counts)
+}
+
+func (e *accountEndpoint) handleResetOpenOrderLimits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	counts, err := e.accountService.SetOpenOrderLimits(addr, nil)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, counts)
+}
+
+// handleFreezeAccount blocks an account and cancels all its resting orders, which are returned
+func (e *accountEndpoint) handleFreezeAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, err := e.orderService.FreezeAccount(addr)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"address":         addr.Hex(),
+		"isBlocked":       true,
+		"cancelledOrders": orders,
+	})
+}
+
+func writeAccountError(w http.ResponseWriter, err error) {
+	if err == services.ErrAccountNotFound {
+		httputils.WriteError(w, http.StatusNotFound, "Account not found")
+		return
+	}
+
+	logger.Error(err)
+	httputils.WriteError(w, http.StatusInternalServerError, "")
+}
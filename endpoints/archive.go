@@ -0,0 +1,42 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
+	"github.com/gorilla/mux"
+)
+
+type archiveEndpoint struct {
+	archiveService interfaces.ArchiveService
+}
+
+// ServeArchiveResource sets up the routing of the order archive endpoints and the corresponding
+// handlers. All of them require an admin wallet.
+func ServeArchiveResource(
+	r *mux.Router,
+	archiveService interfaces.ArchiveService,
+	authService interfaces.AuthService,
+) {
+	e := &archiveEndpoint{archiveService}
+	r.HandleFunc("/orders/archive", RequireAdmin(authService, e.handleBackfill)).Methods("POST")
+}
+
+// handleBackfill starts archiving the backlog of orders old enough to be archived in the background
+func (e *archiveEndpoint) handleBackfill(w http.ResponseWriter, r *http.Request) {
+	err := e.archiveService.StartBackfill()
+	if err == services.ErrArchiveBackfillRunning {
+		httputils.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "STARTED"})
+}
@@ -0,0 +1,144 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
+	"github.com/gorilla/mux"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// auditPageSize is the default and maximum number of snapshots returned by the snapshot list endpoint
+const auditPageSize = 100
+
+type auditEndpoint struct {
+	auditService interfaces.AuditService
+}
+
+// ServeAuditResource sets up the routing of the audit snapshot endpoints and the corresponding
+// handlers. All of them require an admin wallet.
+func ServeAuditResource(
+	r *mux.Router,
+	auditService interfaces.AuditService,
+	authService interfaces.AuthService,
+) {
+	e := &auditEndpoint{auditService}
+	r.HandleFunc("/audit/snapshots", RequireAdmin(authService, e.handleTakeSnapshots)).Methods("POST")
+	r.HandleFunc("/audit/snapshots/{id}", RequireAdmin(authService, e.handleGetSnapshot)).Methods("GET")
+	r.HandleFunc("/audit/snapshots/{id}/verify", RequireAdmin(authService, e.handleVerifySnapshot)).Methods("GET")
+	r.HandleFunc("/audit/{baseToken}/{quoteToken}/snapshots", RequireAdmin(authService, e.handleGetPairSnapshots)).Methods("GET")
+}
+
+// handleTakeSnapshots takes a signed snapshot of every orderbook right away
+func (e *auditEndpoint) handleTakeSnapshots(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := e.auditService.TakeSnapshots()
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusCreated, snapshots)
+}
+
+func (e *auditEndpoint) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !bson.IsObjectIdHex(vars["id"]) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid snapshot id")
+		return
+	}
+
+	snapshot, err := e.auditService.GetByID(bson.ObjectIdHex(vars["id"]))
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if snapshot == nil {
+		httputils.WriteError(w, http.StatusNotFound, services.ErrAuditSnapshotNotFound.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, snapshot)
+}
+
+// handleVerifySnapshot computes the hash of a stored snapshot again and checks its signature. The
+// result is returned as "valid" along with the snapshot.
+func (e *auditEndpoint) handleVerifySnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !bson.IsObjectIdHex(vars["id"]) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid snapshot id")
+		return
+	}
+
+	snapshot, err := e.auditService.Verify(bson.ObjectIdHex(vars["id"]))
+	switch err {
+	case nil, services.ErrInvalidAuditSnapshot:
+	case services.ErrAuditSnapshotNotFound:
+		httputils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	default:
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	res := map[string]interface{}{
+		"valid":    err == nil,
+		"snapshot": snapshot,
+	}
+
+	if err != nil {
+		res["error"] = err.Error()
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *auditEndpoint) handleGetPairSnapshots(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	bt, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	qt, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		offset, err = strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+	}
+
+	limit := auditPageSize
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 || limit > auditPageSize {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	snapshots, err := e.auditService.GetByPair(bt, qt, offset, limit)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, snapshots)
+}
@@ -0,0 +1,95 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
+	"github.com/gorilla/mux"
+)
+
+// Headers holding the signed challenge of authenticated requests
+const (
+	AuthChallengeHeader = "X-Auth-Challenge"
+	AuthSignatureHeader = "X-Auth-Signature"
+)
+
+type authEndpoint struct {
+	authService interfaces.AuthService
+}
+
+// ServeAuthResource sets up the routing of authentication endpoints and the corresponding handlers.
+func ServeAuthResource(
+	r *mux.Router,
+	authService interfaces.AuthService,
+) {
+	e := &authEndpoint{authService}
+	r.HandleFunc("/auth/challenge", e.HandleGetChallenge).Methods("GET")
+}
+
+func (e *authEndpoint) HandleGetChallenge(w http.ResponseWriter, r *http.Request) {
+	challenge, err := e.authService.NewChallenge()
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"challenge": challenge.Hex(),
+		"expiresIn": int64(services.ChallengeTTL.Seconds()),
+	})
+}
+
+// RequireAdmin only serves requests signed by an admin wallet
+func RequireAdmin(authService interfaces.AuthService, h http.HandlerFunc) http.HandlerFunc {
+	return requireRole(authService, (*types.Wallet).IsAdmin, h)
+}
+
+// RequireOperator only serves requests signed by an operator wallet. Admin wallets are accepted as well.
+func RequireOperator(authService interfaces.AuthService, h http.HandlerFunc) http.HandlerFunc {
+	return requireRole(authService, func(w *types.Wallet) bool {
+		return w.IsOperator() || w.IsAdmin()
+	}, h)
+}
+
+// requireRole authenticates the wallet that signed the request challenge. Requests without a
+// valid signature are rejected with a 401 and requests signed by a wallet without the required
+// role with a 403.
+func requireRole(authService interfaces.AuthService, hasRole func(*types.Wallet) bool, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		challenge, err := services.ParseChallenge(r.Header.Get(AuthChallengeHeader))
+		if err != nil {
+			httputils.WriteError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		sig := &types.Signature{}
+		err = sig.UnmarshalText([]byte(r.Header.Get(AuthSignatureHeader)))
+		if err != nil {
+			httputils.WriteError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		wallet, err := authService.Authenticate(challenge, sig)
+		if err != nil {
+			if err == services.ErrInvalidChallenge || err == services.ErrInvalidAuthSignature {
+				httputils.WriteError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusInternalServerError, "")
+			return
+		}
+
+		if !hasRole(wallet) {
+			httputils.WriteError(w, http.StatusForbidden, "Wallet is not allowed to perform this request")
+			return
+		}
+
+		h(w, r)
+	}
+}
@@ -0,0 +1,144 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testAuth holds an authentication service that knows an admin wallet, an operator wallet
+// and a regular user wallet
+type testAuth struct {
+	service  *services.AuthService
+	admin    *types.Wallet
+	operator *types.Wallet
+	user     *types.Wallet
+}
+
+func newTestAuth() *testAuth {
+	walletDao := new(mocks.WalletDao)
+	admin := types.NewWallet()
+	admin.Admin = true
+	operator := types.NewWallet()
+	operator.Operator = true
+
+	walletDao.On("GetByAddress", admin.Address).Return(admin, nil)
+	walletDao.On("GetByAddress", operator.Address).Return(operator, nil)
+	walletDao.On("GetByAddress", mock.Anything).Return(nil, nil)
+
+	return &testAuth{services.NewAuthService(walletDao), admin, operator, types.NewWallet()}
+}
+
+// sign sets the authentication headers of the request with a new challenge signed by the wallet
+func (a *testAuth) sign(t *testing.T, req *http.Request, w *types.Wallet) {
+	challenge, err := a.service.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := w.SignHash(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, _ := sig.MarshalText()
+	req.Header.Set(AuthChallengeHeader, challenge.Hex())
+	req.Header.Set(AuthSignatureHeader, string(text))
+}
+
+func setupAuthTest() (*mux.Router, *testAuth) {
+	r := mux.NewRouter()
+	auth := newTestAuth()
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	ServeAuthResource(r, auth.service)
+	r.HandleFunc("/admin", RequireAdmin(auth.service, ok))
+	r.HandleFunc("/operator", RequireOperator(auth.service, ok))
+
+	return r, auth
+}
+
+func TestHandleGetChallenge(t *testing.T) {
+	router, _ := setupAuthTest()
+
+	req, _ := http.NewRequest("GET", "/auth/challenge", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	res := map[string]interface{}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, common.HashLength*2+2, len(res["challenge"].(string)))
+}
+
+func TestRequireRole(t *testing.T) {
+	router, auth := setupAuthTest()
+
+	testCases := []struct {
+		path   string
+		wallet *types.Wallet
+		code   int
+	}{
+		{"/admin", auth.admin, http.StatusOK},
+		{"/admin", auth.operator, http.StatusForbidden},
+		{"/admin", auth.user, http.StatusForbidden},
+		{"/admin", nil, http.StatusUnauthorized},
+		{"/operator", auth.operator, http.StatusOK},
+		{"/operator", auth.admin, http.StatusOK},
+		{"/operator", auth.user, http.StatusForbidden},
+		{"/operator", nil, http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest("POST", tc.path, nil)
+		if tc.wallet != nil {
+			auth.sign(t, req, tc.wallet)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tc.code, rr.Code, tc.path)
+	}
+}
+
+func TestRequireRoleChallengeReplay(t *testing.T) {
+	router, auth := setupAuthTest()
+
+	req, _ := http.NewRequest("POST", "/admin", nil)
+	auth.sign(t, req, auth.admin)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// challenges can only be used once
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRequireRoleUnknownChallenge(t *testing.T) {
+	router, auth := setupAuthTest()
+
+	challenge := common.HexToHash("0x1234")
+	sig, _ := auth.admin.SignHash(challenge)
+	text, _ := sig.MarshalText()
+
+	req, _ := http.NewRequest("POST", "/admin", nil)
+	req.Header.Set(AuthChallengeHeader, challenge.Hex())
+	req.Header.Set(AuthSignatureHeader, string(text))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -1,5 +1,31 @@
 package endpoints
 
-import "github.com/Proofsuite/amp-matching-engine/utils"
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Proofsuite/amp-matching-engine/utils"
+)
 
 var logger = utils.Logger
+
+var errInvalidLimit = errors.New("Invalid limit")
+
+// pageSize is the default and maximum number of orders or trades returned by the listing endpoints
+const pageSize = 100
+
+// parsePage returns the cursor and the limit of a page of a listing, given by the "cursor" and
+// "limit" query parameters. The cursor is empty for the first page.
+func parsePage(r *http.Request) (string, int, error) {
+	limit := pageSize
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 || limit > pageSize {
+			return "", 0, errInvalidLimit
+		}
+	}
+
+	return r.URL.Query().Get("cursor"), limit, nil
+}
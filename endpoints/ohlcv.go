@@ -2,9 +2,11 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
@@ -30,7 +32,7 @@ func (e *OHLCVEndpoint) handleGetOHLCV(w http.ResponseWriter, r *http.Request) {
 	var model types.TickRequest
 
 	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(model)
+	err := decoder.Decode(&model)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
@@ -38,11 +40,17 @@ func (e *OHLCVEndpoint) handleGetOHLCV(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if model.Units == "" {
-		model.Units = "hour"
+		model.Units = "day"
 	}
 
 	if model.Duration == 0 {
-		model.Duration = 24
+		model.Duration = 1
+	}
+
+	err = validateTickInterval(model.Duration, model.Units)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	if model.To == 0 {
@@ -104,11 +112,17 @@ func (e *OHLCVEndpoint) ohlcvWebSocket(input interface{}, conn *ws.Conn) {
 	}
 
 	if msg.Params.Duration == 0 {
-		msg.Params.Duration = 24
+		msg.Params.Duration = 1
 	}
 
 	if msg.Params.Units == "" {
-		msg.Params.Units = "hour"
+		msg.Params.Units = "day"
+	}
+
+	err = validateTickInterval(msg.Params.Duration, msg.Params.Units)
+	if err != nil {
+		socket.SendErrorMessage(conn, err.Error())
+		return
 	}
 
 	if msg.Event == types.SUBSCRIBE {
@@ -119,3 +133,24 @@ func (e *OHLCVEndpoint) ohlcvWebSocket(input interface{}, conn *ws.Conn) {
 		e.ohlcvService.Unsubscribe(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken, &msg.Params)
 	}
 }
+
+// validateTickInterval checks that the tick interval is valid and that it is part of the
+// intervals allowed by the tick_duration configuration, if any
+func validateTickInterval(duration int64, unit string) error {
+	err := types.ValidateTickInterval(duration, unit)
+	if err != nil {
+		return err
+	}
+
+	if app.Config.TickDuration == nil {
+		return nil
+	}
+
+	for _, d := range app.Config.TickDuration[unit] {
+		if d == duration {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unsupported interval: %v %v", duration, unit)
+}
@@ -2,10 +2,16 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
@@ -26,8 +32,11 @@ func ServeOrderResource(
 	engine interfaces.Engine,
 ) {
 	e := &orderEndpoint{orderService, engine}
+	r.HandleFunc("/orders", e.handleQueryOrders).Methods("GET")
 	r.HandleFunc("/orders/{address}/history", e.handleGetOrderHistory).Methods("GET")
 	r.HandleFunc("/orders/{address}/current", e.handleGetPositions).Methods("GET")
+	r.HandleFunc("/orders/{hash}/position", e.handleGetOrderPosition).Methods("GET")
+	r.HandleFunc("/orders/simulate", e.handleSimulateOrder).Methods("POST")
 	r.HandleFunc("/orders/{address}", e.handleGetOrders).Methods("GET")
 	ws.RegisterChannel(ws.OrderChannel, e.ws)
 }
@@ -35,54 +44,273 @@ func ServeOrderResource(
 func (e *orderEndpoint) handleGetOrders(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	addr := vars["address"]
-	if !common.IsHexAddress(addr) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	address, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	e.writeOrders(w, address, types.OrderQuery{Cursor: cursor, Limit: limit})
+}
+
+func (e *orderEndpoint) handleQueryOrders(w http.ResponseWriter, r *http.Request) {
+	address, err := utils.ParseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	q, err := parseOrderQuery(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	e.writeOrders(w, address, q)
+}
+
+// writeOrders writes the orders of an account matching a query along with the cursor of the next page
+func (e *orderEndpoint) writeOrders(w http.ResponseWriter, address common.Address, q types.OrderQuery) {
+	orders, next, err := e.orderService.GetByUserAddress(address, q)
+	if err == types.ErrInvalidCursor {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	address := common.HexToAddress(addr)
-	orders, err := e.orderService.GetByUserAddress(address)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, orders)
+	res := map[string]interface{}{
+		"orders":     types.OrdersToAPI(orders),
+		"nextCursor": next,
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// parseOrderQuery returns the query of the orders of an account given by the "status" (comma
+// separated), "pair", "side", "from" and "to" (unix timestamps in seconds), "sort" ("createdAt" or
+// "updatedAt"), "order" ("asc" or "desc"), "offset", "cursor" and "limit" query parameters
+func parseOrderQuery(r *http.Request) (types.OrderQuery, error) {
+	v := r.URL.Query()
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		return types.OrderQuery{}, err
+	}
+
+	q := types.OrderQuery{
+		PairName: v.Get("pair"),
+		SortBy:   v.Get("sort"),
+		Cursor:   cursor,
+		Limit:    limit,
+	}
+
+	if s := v.Get("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			parsed, err := types.ParseOrderStatus(status)
+			if err != nil {
+				return types.OrderQuery{}, err
+			}
+
+			q.Statuses = append(q.Statuses, parsed)
+		}
+	}
+
+	if s := v.Get("side"); s != "" {
+		q.Side, err = types.ParseOrderSide(s)
+		if err != nil {
+			return types.OrderQuery{}, err
+		}
+	}
+
+	for param, t := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+		if s := v.Get(param); s != "" {
+			sec, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return types.OrderQuery{}, fmt.Errorf("Invalid %v timestamp: %v", param, s)
+			}
+
+			*t = time.Unix(sec, 0)
+		}
+	}
+
+	switch v.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return types.OrderQuery{}, fmt.Errorf("Invalid sort order: %v", v.Get("order"))
+	}
+
+	if s := v.Get("offset"); s != "" {
+		q.Offset, err = strconv.Atoi(s)
+		if err != nil {
+			return types.OrderQuery{}, fmt.Errorf("Invalid offset: %v", s)
+		}
+	}
+
+	err = q.Validate()
+	if err != nil {
+		return types.OrderQuery{}, err
+	}
+
+	return q, nil
 }
 
 func (e *orderEndpoint) handleGetPositions(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	addr := vars["address"]
-	if !common.IsHexAddress(addr) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	address, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, next, err := e.orderService.GetCurrentByUserAddress(address, cursor, limit)
+	if err == types.ErrInvalidCursor {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	address := common.HexToAddress(addr)
-	orders, err := e.orderService.GetCurrentByUserAddress(address)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	res := map[string]interface{}{
+		"orders":     types.OrdersToAPI(orders),
+		"nextCursor": next,
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, orders)
+	httputils.WriteJSON(w, http.StatusOK, res)
 }
 
 func (e *orderEndpoint) handleGetOrderHistory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	addr := vars["address"]
-	if !common.IsHexAddress(addr) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	address, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, next, err := e.orderService.GetHistoryByUserAddress(address, cursor, limit)
+	if err == types.ErrInvalidCursor {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	res := map[string]interface{}{
+		"orders":     types.OrdersToAPI(orders),
+		"nextCursor": next,
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// handleGetOrderPosition returns the position of a resting order in the queue of its price level
+// and the amount displayed by the orders ahead of it
+func (e *orderEndpoint) handleGetOrderPosition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	hash, err := utils.ParseHash(vars["hash"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	address := common.HexToAddress(addr)
-	orders, err := e.orderService.GetHistoryByUserAddress(address)
+	position, amountAhead, err := e.engine.GetOrderPosition(hash)
 	if err != nil {
+		if err == types.ErrOrderNotResting {
+			httputils.WriteError(w, http.StatusNotFound, "Order is not resting in the orderbook")
+			return
+		}
+
+		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, orders)
+	res := (&types.OrderPosition{Position: position, AmountAhead: amountAhead}).ToAPI()
+	res["hash"] = hash.Hex()
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// handleSimulateOrder previews the fills of an unsigned order against the current orderbook of its
+// pair, without placing the order (see OrderService.SimulateOrder)
+func (e *orderEndpoint) handleSimulateOrder(w http.ResponseWriter, r *http.Request) {
+	o := &types.Order{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+
+	err := decoder.Decode(o)
+	if err != nil {
+		if errs, ok := err.(types.ValidationErrors); ok {
+			httputils.WriteJSON(w, http.StatusBadRequest, errs)
+			return
+		}
+
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	preview, err := e.orderService.SimulateOrder(o)
+	if err != nil {
+		if errs, ok := err.(types.ValidationErrors); ok {
+			httputils.WriteJSON(w, http.StatusBadRequest, errs)
+			return
+		}
+
+		if _, ok := err.(*types.MinNotionalError); ok {
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		switch err {
+		case services.ErrPairNotFound:
+			httputils.WriteError(w, http.StatusNotFound, err.Error())
+		case services.ErrPairInactive, services.ErrPairPaused:
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		case types.ErrAuctionClosing:
+			httputils.WriteError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusInternalServerError, "")
+		}
+
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, preview)
 }
 
 // ws function handles incoming websocket messages on the order channel
@@ -98,10 +326,20 @@ func (e *orderEndpoint) ws(input interface{}, conn *ws.Conn) {
 	switch msg.Type {
 	case "NEW_ORDER":
 		e.handleNewOrder(msg, conn)
+	case "NEW_ORDERS":
+		e.handleNewOrders(msg, conn)
 	case "CANCEL_ORDER":
 		e.handleCancelOrder(msg, conn)
+	case "CANCEL_ALL_ORDERS":
+		e.handleCancelAllOrders(msg, conn)
+	case "REPLACE_ORDER":
+		e.handleReplaceOrder(msg, conn)
+	case "REDUCE_ORDER":
+		e.handleReduceOrder(msg, conn)
 	case "SUBMIT_SIGNATURE":
 		e.handleSubmitSignatures(msg, conn)
+	case "AUTHENTICATE":
+		e.handleAuthenticate(msg, conn)
 	default:
 		log.Print("Response with error")
 	}
@@ -118,6 +356,46 @@ func (e *orderEndpoint) handleSubmitSignatures(p *types.WebSocketPayload, conn *
 	}
 }
 
+// handleAuthenticate handles Authenticate messages, which authenticate the session of the connection
+// as an address. The orders later placed through a cancel on disconnect session are cancelled once
+// the connection is lost, unless the address authenticates a new session within the grace period.
+// A SESSION_AUTHENTICATED message is sent once the session is authenticated.
+func (e *orderEndpoint) handleAuthenticate(p *types.WebSocketPayload, conn *ws.Conn) {
+	bytes, err := json.Marshal(p.Data)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	sa := &types.SessionAuth{}
+	err = sa.UnmarshalJSON(bytes)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	err = e.orderService.AuthenticateSession(sa)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	ws.Authenticate(conn, sa.Address, sa.CancelOnDisconnect, func(addr common.Address, hashes []common.Hash) {
+		_, err := e.orderService.CancelOrdersByHash(addr, hashes)
+		if err != nil {
+			logger.Error(err)
+		}
+	})
+
+	ws.SendMessage(conn, ws.OrderChannel, "SESSION_AUTHENTICATED", map[string]interface{}{
+		"address":            sa.Address.Hex(),
+		"cancelOnDisconnect": sa.CancelOnDisconnect,
+	})
+}
+
 // handleNewOrder handles NewOrder message. New order messages are transmitted to the order service after being unmarshalled
 func (e *orderEndpoint) handleNewOrder(msg *types.WebSocketPayload, conn *ws.Conn) {
 	ch := make(chan *types.WebSocketPayload)
@@ -133,20 +411,269 @@ func (e *orderEndpoint) handleNewOrder(msg *types.WebSocketPayload, conn *ws.Con
 	err = json.Unmarshal(bytes, &o)
 	if err != nil {
 		logger.Error(err)
+		// malformed orders are reported with the list of invalid fields
+		if errs, ok := err.(types.ValidationErrors); ok {
+			ws.SendMessage(conn, ws.OrderChannel, "ERROR", errs)
+			return
+		}
+
 		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
 		return
 	}
 
-	o.Hash = o.ComputeHash()
+	err = o.Validate()
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err)
+		return
+	}
+
+	_, err = o.VerifySignature()
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", "Invalid signature: "+err.Error())
+		return
+	}
+
 	ws.RegisterOrderConnection(o.Hash, &ws.OrderConnection{Conn: conn, ReadChannel: ch})
 	ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(o.Hash))
+	ws.TrackSessionOrder(conn, o.UserAddress, o.Hash)
 
 	err = e.orderService.NewOrder(o)
+	// retried orders are acknowledged with the current state of the order received first
+	if err == services.ErrOrderExists {
+		ws.SendMessage(conn, ws.OrderChannel, "ORDER_EXISTS", o.ToPrivateAPI(), o.Hash)
+		return
+	}
+
+	if err != nil {
+		logger.Error(err)
+		sendOrderError(conn, err)
+		return
+	}
+}
+
+// handleNewOrders handles NewOrders messages, which hold a batch of new orders. The connection is
+// registered for each order of the batch like for a new order. If any order is invalid, the whole
+// batch is rejected with the errors of each invalid order. Otherwise an ORDERS_ADDED message with
+// the engine response status of each order, in the order of the batch, is sent once the orders have
+// been matched, followed by the usual messages of each order. The orders of the pairs whose order
+// queue was full have the ERROR status and the ENGINE_BUSY code.
+func (e *orderEndpoint) handleNewOrders(msg *types.WebSocketPayload, conn *ws.Conn) {
+	bytes, err := json.Marshal(msg.Data)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	batch := struct {
+		Orders []json.RawMessage `json:"orders"`
+	}{}
+
+	err = json.Unmarshal(bytes, &batch)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	orders := []*types.Order{}
+	errs := types.ValidationErrors{}
+	for i, raw := range batch.Orders {
+		o := &types.Order{}
+		err := json.Unmarshal(raw, &o)
+		if err != nil {
+			field := fmt.Sprintf("orders[%v]", i)
+			if fieldErrs, ok := err.(types.ValidationErrors); ok {
+				for _, fe := range fieldErrs {
+					errs.Add(field+"."+fe.Field, fe.Reason)
+				}
+			} else {
+				errs.Add(field, err.Error())
+			}
+
+			continue
+		}
+
+		orders = append(orders, o)
+	}
+
+	if len(errs) > 0 {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", errs)
+		return
+	}
+
+	for _, o := range orders {
+		ws.RegisterOrderConnection(o.Hash, &ws.OrderConnection{Conn: conn, ReadChannel: make(chan *types.WebSocketPayload)})
+		ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(o.Hash))
+		ws.TrackSessionOrder(conn, o.UserAddress, o.Hash)
+	}
+
+	responses, err := e.orderService.NewOrders(orders)
+	if responses == nil {
+		logger.Error(err)
+		sendOrderError(conn, err)
+		return
+	}
+
+	results := []map[string]interface{}{}
+	for i, res := range responses {
+		result := map[string]interface{}{"hash": orders[i].Hash.Hex(), "status": "ERROR"}
+		if res == nil && err == types.ErrEngineBusy {
+			result["code"] = types.RejectReasonEngineBusy
+		}
+
+		if res != nil {
+			result["status"] = res.Status
+			result["order"] = res.Order.ToPrivateAPI()
+		}
+
+		results = append(results, result)
+	}
+
+	ws.SendMessage(conn, ws.OrderChannel, "ORDERS_ADDED", results)
+}
+
+// sendOrderError sends the error returned by the order service for a new order
+func sendOrderError(conn *ws.Conn, err error) {
+	// orders that do not satisfy the pair minimum amount or tick size are reported field by field
+	if errs, ok := err.(types.ValidationErrors); ok {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", errs)
+		return
+	}
+
+	// orders worth less than the pair minimum notional are reported with their notional value
+	if e, ok := err.(*types.MinNotionalError); ok {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", map[string]string{
+			"code":        "MIN_NOTIONAL",
+			"message":     e.Error(),
+			"notional":    e.Notional.String(),
+			"minNotional": e.MinNotional.String(),
+		})
+		return
+	}
+
+	// clients should fetch their next order nonce when receiving this error
+	if err == services.ErrOrderNonceConsumed {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", map[string]string{
+			"code":    "NONCE_CONSUMED",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// delisted pairs only accept order cancellations
+	if err == services.ErrPairInactive {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", map[string]string{
+			"code":    "PAIR_INACTIVE",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// paused pairs only accept order cancellations until they are resumed
+	if err == services.ErrPairPaused {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", map[string]string{
+			"code":    "PAIR_PAUSED",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// overloaded orderbooks shed new orders, which can be submitted again later
+	if err == types.ErrEngineBusy {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", map[string]string{
+			"code":    types.RejectReasonEngineBusy,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// makers have to cancel orders or wait for them to be filled before placing new orders
+	if err == services.ErrOpenOrdersLimit || err == services.ErrPairOpenOrdersLimit {
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", map[string]string{
+			"code":    "OPEN_ORDERS_LIMIT",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+}
+
+// handleReplaceOrder handles ReplaceOrder messages. The replacement order connection is registered
+// like a new order connection and an ORDER_REPLACED message with the hashes of the cancelled and
+// replacement orders is sent once the orders have been swapped.
+func (e *orderEndpoint) handleReplaceOrder(p *types.WebSocketPayload, conn *ws.Conn) {
+	ch := make(chan *types.WebSocketPayload)
+
+	bytes, err := json.Marshal(p.Data)
 	if err != nil {
 		logger.Error(err)
 		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
 		return
 	}
+
+	or := &types.OrderReplace{}
+	err = or.UnmarshalJSON(bytes)
+	if err != nil {
+		logger.Error(err)
+		if errs, ok := err.(types.ValidationErrors); ok {
+			ws.SendMessage(conn, ws.OrderChannel, "ERROR", errs)
+			return
+		}
+
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	ws.RegisterOrderConnection(or.Order.Hash, &ws.OrderConnection{Conn: conn, ReadChannel: ch})
+	ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(or.Order.Hash))
+	ws.TrackSessionOrder(conn, or.Order.UserAddress, or.Order.Hash)
+
+	cancelled, err := e.orderService.ReplaceOrder(or)
+	if err != nil {
+		logger.Error(err)
+		sendOrderError(conn, err)
+		return
+	}
+
+	ws.SendOrderMessage("ORDER_REPLACED", or.Order.Hash, map[string]string{
+		"cancelledHash": cancelled.Hash.Hex(),
+		"orderHash":     or.Order.Hash.Hex(),
+	})
+}
+
+// handleReduceOrder handles ReduceOrder messages. An ORDER_REDUCED message with the reduced order
+// is sent once the order has been reduced in the orderbook.
+func (e *orderEndpoint) handleReduceOrder(p *types.WebSocketPayload, conn *ws.Conn) {
+	bytes, err := json.Marshal(p.Data)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	or := &types.OrderReduce{}
+	err = or.UnmarshalJSON(bytes)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	ws.RegisterOrderConnection(or.Hash, &ws.OrderConnection{Conn: conn, Active: true})
+	ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(or.Hash))
+
+	reduced, err := e.orderService.ReduceOrder(or)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	ws.SendOrderMessage("ORDER_REDUCED", or.Hash, reduced.ToPrivateAPI())
 }
 
 // handleCancelOrder handles CancelOrder message.
@@ -173,3 +700,43 @@ func (e *orderEndpoint) handleCancelOrder(p *types.WebSocketPayload, conn *ws.Co
 		return
 	}
 }
+
+// handleCancelAllOrders handles CancelAllOrders messages. An ORDER_CANCELLED message is sent
+// for each cancelled order, followed by an ALL_ORDERS_CANCELLED message with the hashes of
+// the cancelled orders.
+func (e *orderEndpoint) handleCancelAllOrders(p *types.WebSocketPayload, conn *ws.Conn) {
+	bytes, err := json.Marshal(p.Data)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	ca := &types.CancelAllOrders{}
+	err = ca.UnmarshalJSON(bytes)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	ws.RegisterOrderConnection(ca.Hash, &ws.OrderConnection{Conn: conn, Active: true})
+	ws.RegisterConnectionUnsubscribeHandler(
+		conn,
+		ws.OrderSocketUnsubscribeHandler(ca.Hash),
+	)
+
+	orders, err := e.orderService.CancelAllOrders(ca)
+	if err != nil {
+		logger.Error(err)
+		ws.SendMessage(conn, ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	hashes := []common.Hash{}
+	for _, o := range orders {
+		hashes = append(hashes, o.Hash)
+	}
+
+	ws.SendOrderMessage("ALL_ORDERS_CANCELLED", ca.Hash, hashes)
+}
@@ -0,0 +1,121 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupOrderEndpointTest() (*mux.Router, *mocks.OrderService, *mocks.Engine) {
+	r := mux.NewRouter()
+	orderService := new(mocks.OrderService)
+	eng := new(mocks.Engine)
+
+	ServeOrderResource(r, orderService, eng)
+
+	return r, orderService, eng
+}
+
+func TestHandleGetOrderPosition(t *testing.T) {
+	router, _, eng := SetupOrderEndpointTest()
+
+	resting := common.HexToHash("0x1")
+	filled := common.HexToHash("0x2")
+	eng.On("GetOrderPosition", resting).Return(3, big.NewInt(2000), nil)
+	eng.On("GetOrderPosition", filled).Return(0, nil, types.ErrOrderNotResting)
+
+	req, _ := http.NewRequest("GET", "/orders/"+resting.Hex()+"/position", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	res := map[string]interface{}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, map[string]interface{}{"hash": resting.Hex(), "position": float64(3), "amountAhead": "2000"}, res)
+
+	// orders that are not resting in the orderbook are not found
+	req, _ = http.NewRequest("GET", "/orders/"+filled.Hex()+"/position", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusNotFound)
+	}
+
+	// invalid hashes are rejected
+	req, _ = http.NewRequest("GET", "/orders/0x1234/position", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleSimulateOrder(t *testing.T) {
+	router, orderService, _ := SetupOrderEndpointTest()
+
+	buyToken := common.HexToAddress("0x1")
+	sellToken := common.HexToAddress("0x2")
+	unknownToken := common.HexToAddress("0x3")
+	preview := &types.MatchPreview{
+		PairName:          "ZRX/WETH",
+		Status:            "PARTIAL",
+		Fills:             []*types.PreviewFill{{PricePoint: big.NewInt(1000), Amount: big.NewInt(1e18), Fee: big.NewInt(0)}},
+		FilledAmount:      big.NewInt(1e18),
+		AveragePricePoint: big.NewInt(1000),
+		RemainingAmount:   big.NewInt(1e18),
+		Rests:             true,
+	}
+
+	orderService.On("SimulateOrder", mock.MatchedBy(func(o *types.Order) bool { return o.BuyToken == buyToken })).Return(preview, nil)
+	orderService.On("SimulateOrder", mock.Anything).Return(nil, services.ErrPairNotFound)
+
+	body := `{"buyToken": "` + buyToken.Hex() + `", "sellToken": "` + sellToken.Hex() + `", "buyAmount": "2000000000000000000", "sellAmount": "2000"}`
+	req, _ := http.NewRequest("POST", "/orders/simulate", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	res := map[string]interface{}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, "PARTIAL", res["status"])
+	assert.Equal(t, "1000", res["averagePricePoint"])
+	assert.Equal(t, "1000000000000000000", res["remainingAmount"])
+	assert.Equal(t, true, res["rests"])
+
+	// orders of unknown pairs are not found
+	req, _ = http.NewRequest("POST", "/orders/simulate", strings.NewReader(`{"buyToken": "`+unknownToken.Hex()+`"}`))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusNotFound)
+	}
+
+	// malformed orders are rejected
+	req, _ = http.NewRequest("POST", "/orders/simulate", strings.NewReader(`{"buyAmount": "abc"}`))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
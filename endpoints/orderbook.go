@@ -3,9 +3,12 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
 	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/ethereum/go-ethereum/common"
@@ -23,7 +26,9 @@ func ServeOrderBookResource(
 ) {
 	e := &OrderBookEndpoint{orderBookService}
 	r.HandleFunc("/orderbook/{baseToken}/{quoteToken}/raw", e.handleGetRawOrderBook)
+	r.HandleFunc("/orderbook/{baseToken}/{quoteToken}/checksum", e.handleGetOrderBookChecksum).Methods("GET")
 	r.HandleFunc("/orderbook/{baseToken}/{quoteToken}/", e.handleGetOrderBook)
+	r.HandleFunc("/orderbook/stats", e.handleGetEngineStats).Methods("GET")
 	ws.RegisterChannel(ws.LiteOrderBookChannel, e.orderBookWebSocket)
 	ws.RegisterChannel(ws.RawOrderBookChannel, e.rawOrderBookWebSocket)
 }
@@ -31,50 +36,109 @@ func ServeOrderBookResource(
 // orderBookEndpoint
 func (e *OrderBookEndpoint) handleGetOrderBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	bt := vars["baseToken"]
-	qt := vars["quoteToken"]
 
-	if !common.IsHexAddress(bt) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if !common.IsHexAddress(qt) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	depth := 0
+	if d := r.URL.Query().Get("depth"); d != "" {
+		depth, err = strconv.Atoi(d)
+		if err != nil || depth < 0 {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid depth")
+			return
+		}
+	}
+
+	var ob *types.OrderBook
+	if g := r.URL.Query().Get("groupingDecimals"); g != "" {
+		var decimals int
+		decimals, err = strconv.Atoi(g)
+		if err != nil {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid grouping decimals")
+			return
+		}
+
+		ob, err = e.orderBookService.GetGroupedOrderBook(baseTokenAddress, quoteTokenAddress, decimals)
+		if err == services.ErrInvalidPriceGrouping {
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		ob, err = e.orderBookService.GetOrderBook(baseTokenAddress, quoteTokenAddress)
 	}
 
-	baseTokenAddress := common.HexToAddress(bt)
-	quoteTokenAddress := common.HexToAddress(qt)
-	ob, err := e.orderBookService.GetOrderBook(baseTokenAddress, quoteTokenAddress)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
 	}
 
+	ob.Truncate(depth)
 	httputils.WriteJSON(w, http.StatusOK, ob)
 }
 
+// handleGetOrderBookChecksum returns the checksum of the orderbook of a pair along with its sequence
+func (e *OrderBookEndpoint) handleGetOrderBookChecksum(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	checksum, err := e.orderBookService.GetOrderBookChecksum(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, checksum)
+}
+
+// handleGetEngineStats returns the matching metrics of the orderbooks of all pairs
+func (e *OrderBookEndpoint) handleGetEngineStats(w http.ResponseWriter, r *http.Request) {
+	httputils.WriteJSON(w, http.StatusOK, e.orderBookService.GetEngineStats())
+}
+
 // orderBookEndpoint
 func (e *OrderBookEndpoint) handleGetRawOrderBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	bt := vars["baseToken"]
-	qt := vars["quoteToken"]
 
-	if !common.IsHexAddress(bt) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if !common.IsHexAddress(qt) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	baseTokenAddress := common.HexToAddress(bt)
-	quoteTokenAddress := common.HexToAddress(qt)
 	ob, err := e.orderBookService.GetRawOrderBook(baseTokenAddress, quoteTokenAddress)
 	if err != nil {
 		httputils.WriteError(w, http.StatusInternalServerError, "")
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, ob)
+	httputils.WriteJSON(w, http.StatusOK, types.OrdersToAPI(ob))
 }
 
 // liteOrderBookWebSocket
@@ -163,6 +227,25 @@ func (e *OrderBookEndpoint) orderBookWebSocket(input interface{}, conn *ws.Conn)
 		return
 	}
 
+	// subscriptions with a grouping receive the grouped price levels
+	if msg.Params.GroupingDecimals != nil {
+		decimals := *msg.Params.GroupingDecimals
+		if msg.Event == types.SUBSCRIBE {
+			e.orderBookService.SubscribeGroupedOrderBook(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken, decimals)
+		}
+
+		if msg.Event == types.UNSUBSCRIBE {
+			e.orderBookService.UnSubscribeGroupedOrderBook(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken, decimals)
+		}
+
+		if msg.Event == types.REPLAY {
+			message := map[string]string{"Message": "Grouped orderbook diffs can not be replayed"}
+			socket.SendErrorMessage(conn, message)
+		}
+
+		return
+	}
+
 	if msg.Event == types.SUBSCRIBE {
 		e.orderBookService.SubscribeOrderBook(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
 	}
@@ -170,4 +253,8 @@ func (e *OrderBookEndpoint) orderBookWebSocket(input interface{}, conn *ws.Conn)
 	if msg.Event == types.UNSUBSCRIBE {
 		e.orderBookService.UnSubscribeOrderBook(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
 	}
+
+	if msg.Event == types.REPLAY {
+		e.orderBookService.ReplayOrderBookDiffs(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken, msg.Params.Sequence)
+	}
 }
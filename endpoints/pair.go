@@ -2,14 +2,18 @@ package endpoints
 
 import (
 	"encoding/json"
+	"io"
+	"math/big"
 	"net/http"
-
-	"github.com/ethereum/go-ethereum/common"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 )
 
@@ -18,18 +22,29 @@ type pairEndpoint struct {
 }
 
 // ServePairResource sets up the routing of pair endpoints and the corresponding handlers.
+// Creating pairs and resuming halted pairs requires an admin wallet and listing or delisting
+// pairs an operator wallet.
 func ServePairResource(
 	r *mux.Router,
 	p interfaces.PairService,
+	authService interfaces.AuthService,
 ) {
 	e := &pairEndpoint{p}
-	r.HandleFunc("/pairs", e.HandleCreatePair).Methods("POST")
+	r.HandleFunc("/pairs", RequireAdmin(authService, e.HandleCreatePair)).Methods("POST")
 	r.HandleFunc("/pairs/{baseToken}/{quoteToken}", e.HandleGetPair).Methods("GET")
 	r.HandleFunc("/pairs", e.HandleGetAllPairs).Methods("GET")
+	r.HandleFunc("/pairs/{baseToken}/{quoteToken}/status", e.HandleGetPairStatus).Methods("GET")
+	r.HandleFunc("/pairs/{baseToken}/{quoteToken}/status", RequireOperator(authService, e.HandleUpdatePairStatus)).Methods("PUT")
+	r.HandleFunc("/pairs/{baseToken}/{quoteToken}/pause", RequireAdmin(authService, e.HandlePausePair)).Methods("POST")
+	r.HandleFunc("/pairs/{baseToken}/{quoteToken}/resume", RequireAdmin(authService, e.HandleResumePair)).Methods("POST")
+	r.HandleFunc("/pairs/{baseToken}/{quoteToken}/auction", RequireAdmin(authService, e.HandleStartAuction)).Methods("POST")
+	r.HandleFunc("/pairs/{baseToken}/{quoteToken}/ticker", e.HandleGetTicker).Methods("GET")
+	ws.RegisterChannel(ws.TickerChannel, e.tickerWebSocket)
 }
 
 func (e *pairEndpoint) HandleCreatePair(w http.ResponseWriter, r *http.Request) {
-	p := &types.Pair{}
+	// pairs are listed unless the payload says otherwise
+	p := &types.Pair{Active: true}
 
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(p)
@@ -71,8 +86,18 @@ func (e *pairEndpoint) HandleCreatePair(w http.ResponseWriter, r *http.Request)
 	httputils.WriteJSON(w, http.StatusCreated, p)
 }
 
+// HandleGetAllPairs returns the listed pairs. Delisted pairs are included if the
+// include_inactive query parameter is set to true.
 func (e *pairEndpoint) HandleGetAllPairs(w http.ResponseWriter, r *http.Request) {
-	res, err := e.pairService.GetAll()
+	var res []types.Pair
+	var err error
+
+	if r.URL.Query().Get("include_inactive") == "true" {
+		res, err = e.pairService.GetAll()
+	} else {
+		res, err = e.pairService.GetActive()
+	}
+
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
@@ -85,19 +110,18 @@ func (e *pairEndpoint) HandleGetAllPairs(w http.ResponseWriter, r *http.Request)
 func (e *pairEndpoint) HandleGetPair(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	baseToken := vars["baseToken"]
-	quoteToken := vars["quoteToken"]
-
-	if !common.IsHexAddress(baseToken) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if !common.IsHexAddress(quoteToken) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	baseTokenAddress := common.HexToAddress(baseToken)
-	quoteTokenAddress := common.HexToAddress(quoteToken)
 	res, err := e.pairService.GetByTokenAddress(baseTokenAddress, quoteTokenAddress)
 	if err != nil {
 		logger.Error(err)
@@ -107,3 +131,283 @@ func (e *pairEndpoint) HandleGetPair(w http.ResponseWriter, r *http.Request) {
 
 	httputils.WriteJSON(w, http.StatusOK, res)
 }
+
+// HandleUpdatePairStatus lists or delists a pair. The payload is of the form {"active": false}.
+func (e *pairEndpoint) HandleUpdatePairStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payload := struct {
+		Active *bool `json:"active"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&payload)
+	if err != nil || payload.Active == nil {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	res, err := e.pairService.SetActive(baseTokenAddress, quoteTokenAddress, *payload.Active)
+	if err != nil {
+		if err == services.ErrPairNotFound {
+			httputils.WriteError(w, http.StatusNotFound, "Pair not found")
+			return
+		}
+
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// HandleGetPairStatus returns whether a pair is listed and whether it is halted by its circuit
+// breaker, in which case the end of the halt is returned as well
+func (e *pairEndpoint) HandleGetPairStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := e.pairService.GetStatus(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		if err == services.ErrPairNotFound {
+			httputils.WriteError(w, http.StatusNotFound, "Pair not found")
+			return
+		}
+
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// HandlePausePair pauses a pair until it is resumed
+func (e *pairEndpoint) HandlePausePair(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := e.pairService.Pause(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		if err == services.ErrPairNotFound {
+			httputils.WriteError(w, http.StatusNotFound, "Pair not found")
+			return
+		}
+
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// HandleResumePair ends the pause of a pair, or its halt by its circuit breaker before the end of
+// its cooldown
+func (e *pairEndpoint) HandleResumePair(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := e.pairService.Resume(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		if err == services.ErrPairNotFound {
+			httputils.WriteError(w, http.StatusNotFound, "Pair not found")
+			return
+		}
+
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// HandleStartAuction starts the opening call auction of a pair. The payload, which is optional,
+// holds the duration of the auction in seconds and a reference pricepoint used to break the ties
+// between clearing pricepoints.
+func (e *pairEndpoint) HandleStartAuction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payload := &struct {
+		Duration            int64  `json:"duration"`
+		ReferencePricePoint string `json:"referencePricePoint"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(payload)
+	if err != nil && err != io.EOF {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if payload.Duration < 0 {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid duration")
+		return
+	}
+
+	var reference *big.Int
+	if payload.ReferencePricePoint != "" {
+		var ok bool
+		reference, ok = new(big.Int).SetString(payload.ReferencePricePoint, 10)
+		if !ok || reference.Sign() <= 0 {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid reference pricepoint")
+			return
+		}
+	}
+
+	duration := time.Duration(payload.Duration) * time.Second
+	res, err := e.pairService.StartAuction(baseTokenAddress, quoteTokenAddress, duration, reference)
+	if err != nil {
+		switch err {
+		case services.ErrPairNotFound:
+			httputils.WriteError(w, http.StatusNotFound, "Pair not found")
+		case types.ErrOrderBookNotEmpty:
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusInternalServerError, "")
+		}
+
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// HandleGetTicker returns the best bid and ask, the mid pricepoint, the spread and the last trade of
+// a pair, along with the sequence of its orderbook
+func (e *pairEndpoint) HandleGetTicker(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	baseTokenAddress, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quoteTokenAddress, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := e.pairService.GetTicker(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		if err == services.ErrPairNotFound {
+			httputils.WriteError(w, http.StatusNotFound, "Pair not found")
+			return
+		}
+
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// tickerWebSocket subscribes a connection to the ticker of a pair, or unsubscribes it
+func (e *pairEndpoint) tickerWebSocket(input interface{}, conn *ws.Conn) {
+	bytes, _ := json.Marshal(input)
+	var payload *types.WebSocketPayload
+	if err := json.Unmarshal(bytes, &payload); err != nil {
+		logger.Error(err)
+	}
+
+	socket := ws.GetTickerSocket()
+	if payload.Type != "subscription" {
+		err := map[string]string{"Message": "Invalid payload"}
+		socket.SendErrorMessage(conn, err)
+		return
+	}
+
+	bytes, _ = json.Marshal(payload.Data)
+	var msg *types.WebSocketSubscription
+	err := json.Unmarshal(bytes, &msg)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	if (msg.Pair.BaseToken == common.Address{}) {
+		err := map[string]string{"Message": "Invalid base token"}
+		socket.SendErrorMessage(conn, err)
+		return
+	}
+
+	if (msg.Pair.QuoteToken == common.Address{}) {
+		err := map[string]string{"Message": "Invalid quote token"}
+		socket.SendErrorMessage(conn, err)
+		return
+	}
+
+	if msg.Event == types.SUBSCRIBE {
+		e.pairService.SubscribeTicker(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
+	}
+
+	if msg.Event == types.UNSUBSCRIBE {
+		e.pairService.UnsubscribeTicker(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
+	}
+}
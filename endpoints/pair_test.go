@@ -7,25 +7,30 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
-func SetupPairEndpointTest() (*mux.Router, *mocks.PairService) {
+func SetupPairEndpointTest() (*mux.Router, *mocks.PairService, *testAuth) {
 	r := mux.NewRouter()
 	pairService := new(mocks.PairService)
+	auth := newTestAuth()
 
-	ServePairResource(r, pairService)
+	ServePairResource(r, pairService, auth.service)
 
-	return r, pairService
+	return r, pairService, auth
 }
 
 func TestHandleCreatePair(t *testing.T) {
-	router, pairService := SetupPairEndpointTest()
+	router, pairService, auth := SetupPairEndpointTest()
 
 	pair := types.Pair{
 		BaseTokenSymbol:   "ZRX",
@@ -45,6 +50,8 @@ func TestHandleCreatePair(t *testing.T) {
 		t.Error(err)
 	}
 
+	auth.sign(t, req, auth.admin)
+
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
@@ -60,7 +67,7 @@ func TestHandleCreatePair(t *testing.T) {
 }
 
 func TestHandleCreateInvalidPair(t *testing.T) {
-	router, pairService := SetupPairEndpointTest()
+	router, pairService, auth := SetupPairEndpointTest()
 
 	pair := types.Pair{
 		BaseTokenSymbol:   "ZRX",
@@ -79,6 +86,8 @@ func TestHandleCreateInvalidPair(t *testing.T) {
 		t.Error(err)
 	}
 
+	auth.sign(t, req, auth.admin)
+
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
@@ -87,8 +96,39 @@ func TestHandleCreateInvalidPair(t *testing.T) {
 	}
 }
 
+func TestHandleCreatePairNotAdmin(t *testing.T) {
+	router, pairService, auth := SetupPairEndpointTest()
+
+	pair := types.Pair{
+		BaseTokenSymbol:   "ZRX",
+		BaseTokenAddress:  common.HexToAddress("0x1"),
+		QuoteTokenSymbol:  "WETH",
+		QuoteTokenAddress: common.HexToAddress("0x2"),
+		PriceMultiplier:   big.NewInt(1e6),
+	}
+
+	for _, w := range []*types.Wallet{auth.user, auth.operator} {
+		b, _ := json.Marshal(pair)
+		req, err := http.NewRequest("POST", "/pairs", bytes.NewBuffer(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		auth.sign(t, req, w)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusForbidden)
+		}
+	}
+
+	pairService.AssertNotCalled(t, "Create", mock.Anything)
+}
+
 func TestHandleGetAllPairs(t *testing.T) {
-	router, pairService := SetupPairEndpointTest()
+	router, pairService, _ := SetupPairEndpointTest()
 
 	p1 := types.Pair{
 		BaseTokenSymbol:   "ZRX",
@@ -108,7 +148,7 @@ func TestHandleGetAllPairs(t *testing.T) {
 		TakeFee:           big.NewInt(1e4),
 	}
 
-	pairService.On("GetAll").Return([]types.Pair{p1, p2}, nil)
+	pairService.On("GetActive").Return([]types.Pair{p1, p2}, nil)
 
 	req, err := http.NewRequest("GET", "/pairs", nil)
 	if err != nil {
@@ -125,13 +165,353 @@ func TestHandleGetAllPairs(t *testing.T) {
 	result := []types.Pair{}
 	json.NewDecoder(rr.Body).Decode(&result)
 
-	pairService.AssertCalled(t, "GetAll")
+	pairService.AssertCalled(t, "GetActive")
+	pairService.AssertNotCalled(t, "GetAll")
 	testutils.ComparePair(t, &p1, &result[0])
 	testutils.ComparePair(t, &p2, &result[1])
 }
 
+func TestHandleGetAllPairsIncludeInactive(t *testing.T) {
+	router, pairService, _ := SetupPairEndpointTest()
+
+	p1 := types.Pair{
+		BaseTokenSymbol:   "ZRX",
+		BaseTokenAddress:  common.HexToAddress("0x1"),
+		QuoteTokenAddress: common.HexToAddress("0x2"),
+		PriceMultiplier:   big.NewInt(1e6),
+		MakeFee:           big.NewInt(1e4),
+		TakeFee:           big.NewInt(1e4),
+		Active:            false,
+	}
+
+	pairService.On("GetAll").Return([]types.Pair{p1}, nil)
+
+	req, err := http.NewRequest("GET", "/pairs?include_inactive=true", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := []types.Pair{}
+	json.NewDecoder(rr.Body).Decode(&result)
+
+	pairService.AssertCalled(t, "GetAll")
+	pairService.AssertNotCalled(t, "GetActive")
+	testutils.ComparePair(t, &p1, &result[0])
+}
+
+func TestHandleUpdatePairStatus(t *testing.T) {
+	router, pairService, auth := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+
+	p1 := types.Pair{
+		BaseTokenSymbol:   "ZRX",
+		QuoteTokenSymbol:  "WETH",
+		BaseTokenAddress:  base,
+		QuoteTokenAddress: quote,
+		PriceMultiplier:   big.NewInt(1e6),
+		MakeFee:           big.NewInt(1e4),
+		TakeFee:           big.NewInt(1e4),
+		Active:            false,
+	}
+
+	pairService.On("SetActive", base, quote, false).Return(&p1, nil)
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/status"
+	req, err := http.NewRequest("PUT", url, bytes.NewBufferString(`{"active":false}`))
+	if err != nil {
+		t.Error(err)
+	}
+
+	auth.sign(t, req, auth.operator)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := types.Pair{}
+	json.NewDecoder(rr.Body).Decode(&result)
+
+	pairService.AssertCalled(t, "SetActive", base, quote, false)
+	testutils.ComparePair(t, &p1, &result)
+}
+
+func TestHandleUpdatePairStatusInvalidPayload(t *testing.T) {
+	router, pairService, auth := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/status"
+	req, err := http.NewRequest("PUT", url, bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Error(err)
+	}
+
+	auth.sign(t, req, auth.operator)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	pairService.AssertNotCalled(t, "SetActive", base, quote, false)
+}
+
+func TestHandleGetPairStatus(t *testing.T) {
+	router, pairService, _ := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+
+	until := time.Unix(1500000000, 0).UTC()
+	status := &types.PairStatus{
+		PairName:    "ZRX/WETH",
+		BaseToken:   base.Hex(),
+		QuoteToken:  quote.Hex(),
+		Active:      true,
+		Halted:      true,
+		HaltedUntil: &until,
+	}
+
+	pairService.On("GetStatus", base, quote).Return(status, nil)
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/status"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := &types.PairStatus{}
+	json.NewDecoder(rr.Body).Decode(result)
+	assert.Equal(t, status, result)
+}
+
+func TestHandleGetTicker(t *testing.T) {
+	router, pairService, _ := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+
+	traded := time.Unix(1500000000, 0).UTC()
+	ticker := &types.Ticker{
+		PairName:       "ZRX/WETH",
+		BaseToken:      base,
+		QuoteToken:     quote,
+		BestBid:        big.NewInt(990),
+		BestAsk:        big.NewInt(1010),
+		Mid:            big.NewInt(1000),
+		Spread:         big.NewInt(20),
+		LastTradePrice: big.NewInt(1000),
+		LastTradeTime:  &traded,
+		Sequence:       42,
+	}
+
+	pairService.On("GetTicker", base, quote).Return(ticker, nil)
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/ticker"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := &types.Ticker{}
+	json.NewDecoder(rr.Body).Decode(result)
+	assert.Equal(t, ticker, result)
+
+	// unknown pairs are not found
+	unknown := common.HexToAddress("0x3")
+	pairService.On("GetTicker", unknown, quote).Return(nil, services.ErrPairNotFound)
+
+	url = "/pairs/" + unknown.Hex() + "/" + quote.Hex() + "/ticker"
+	req, err = http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandlePausePair(t *testing.T) {
+	router, pairService, auth := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+
+	status := &types.PairStatus{PairName: "ZRX/WETH", BaseToken: base.Hex(), QuoteToken: quote.Hex(), Active: true, Paused: true}
+	pairService.On("Pause", base, quote).Return(status, nil)
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/pause"
+
+	// only admins can pause a pair
+	req, _ := http.NewRequest("POST", url, nil)
+	auth.sign(t, req, auth.operator)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusForbidden)
+	}
+
+	pairService.AssertNotCalled(t, "Pause", base, quote)
+
+	req, _ = http.NewRequest("POST", url, nil)
+	auth.sign(t, req, auth.admin)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := &types.PairStatus{}
+	json.NewDecoder(rr.Body).Decode(result)
+	assert.Equal(t, status, result)
+	pairService.AssertCalled(t, "Pause", base, quote)
+}
+
+func TestHandleStartAuction(t *testing.T) {
+	router, pairService, auth := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+	listed := common.HexToAddress("0x3")
+
+	end := time.Unix(1500000600, 0).UTC()
+	status := &types.PairStatus{PairName: "ZRX/WETH", BaseToken: base.Hex(), QuoteToken: quote.Hex(), Active: true, Auction: true, AuctionEndsAt: &end}
+	pairService.On("StartAuction", base, quote, 10*time.Minute, big.NewInt(1000)).Return(status, nil)
+	pairService.On("StartAuction", base, listed, time.Duration(0), (*big.Int)(nil)).Return(nil, types.ErrOrderBookNotEmpty)
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/auction"
+	payload := `{"duration": 600, "referencePricePoint": "1000"}`
+
+	// only admins can start an auction
+	req, _ := http.NewRequest("POST", url, bytes.NewBufferString(payload))
+	auth.sign(t, req, auth.operator)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusForbidden)
+	}
+
+	pairService.AssertNotCalled(t, "StartAuction", base, quote, 10*time.Minute, big.NewInt(1000))
+
+	req, _ = http.NewRequest("POST", url, bytes.NewBufferString(payload))
+	auth.sign(t, req, auth.admin)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := &types.PairStatus{}
+	json.NewDecoder(rr.Body).Decode(result)
+	assert.Equal(t, status, result)
+	pairService.AssertCalled(t, "StartAuction", base, quote, 10*time.Minute, big.NewInt(1000))
+
+	// the payload is optional, an auction can not be started once the pair has orders
+	url = "/pairs/" + base.Hex() + "/" + listed.Hex() + "/auction"
+	req, _ = http.NewRequest("POST", url, bytes.NewBufferString(""))
+	auth.sign(t, req, auth.admin)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	// invalid reference pricepoints are refused
+	req, _ = http.NewRequest("POST", url, bytes.NewBufferString(`{"referencePricePoint": "abc"}`))
+	auth.sign(t, req, auth.admin)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	pairService.AssertNumberOfCalls(t, "StartAuction", 2)
+}
+
+func TestHandleResumePair(t *testing.T) {
+	router, pairService, auth := SetupPairEndpointTest()
+
+	base := common.HexToAddress("0x1")
+	quote := common.HexToAddress("0x2")
+
+	status := &types.PairStatus{PairName: "ZRX/WETH", BaseToken: base.Hex(), QuoteToken: quote.Hex(), Active: true}
+	pairService.On("Resume", base, quote).Return(status, nil)
+
+	url := "/pairs/" + base.Hex() + "/" + quote.Hex() + "/resume"
+
+	// only admins can resume a pair
+	req, _ := http.NewRequest("POST", url, nil)
+	auth.sign(t, req, auth.operator)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusForbidden)
+	}
+
+	pairService.AssertNotCalled(t, "Resume", base, quote)
+
+	req, _ = http.NewRequest("POST", url, nil)
+	auth.sign(t, req, auth.admin)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	result := &types.PairStatus{}
+	json.NewDecoder(rr.Body).Decode(result)
+	assert.Equal(t, status, result)
+	pairService.AssertCalled(t, "Resume", base, quote)
+}
+
 func TestHandleGetPair(t *testing.T) {
-	router, pairService := SetupPairEndpointTest()
+	router, pairService, _ := SetupPairEndpointTest()
 
 	base := common.HexToAddress("0x1")
 	quote := common.HexToAddress("0x2")
@@ -7,8 +7,8 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 )
 
@@ -41,11 +41,16 @@ func (e *tokenEndpoint) HandleCreateTokens(w http.ResponseWriter, r *http.Reques
 
 	defer r.Body.Close()
 
-	err = e.tokenService.Create(&t)
+	force := r.URL.Query().Get("force") == "true"
+
+	err = e.tokenService.Create(&t, force)
 	if err != nil {
 		if err == services.ErrTokenExists {
 			httputils.WriteError(w, http.StatusBadRequest, "")
 			return
+		} else if err == services.ErrTokenMetadataMismatch {
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
 		} else {
 			logger.Error(err)
 			httputils.WriteError(w, http.StatusInternalServerError, "")
@@ -91,12 +96,12 @@ func (e *tokenEndpoint) HandleGetBaseTokens(w http.ResponseWriter, r *http.Reque
 func (e *tokenEndpoint) HandleGetToken(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	a := vars["address"]
-	if !common.IsHexAddress(a) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	tokenAddress, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	tokenAddress := common.HexToAddress(a)
 	res, err := e.tokenService.GetByAddress(tokenAddress)
 	if err != nil {
 		logger.Error(err)
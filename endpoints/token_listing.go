@@ -0,0 +1,137 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
+	"github.com/gorilla/mux"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type tokenListingEndpoint struct {
+	tokenListingService interfaces.TokenListingService
+}
+
+// ServeTokenListingResource sets up the routing of token listing endpoints and the corresponding handlers.
+// Reviewing listing requests requires an admin wallet.
+func ServeTokenListingResource(
+	r *mux.Router,
+	tokenListingService interfaces.TokenListingService,
+	authService interfaces.AuthService,
+) {
+	e := &tokenListingEndpoint{tokenListingService}
+	r.HandleFunc("/tokens/listings/{id}/approve", RequireAdmin(authService, e.HandleApproveTokenListing)).Methods("POST")
+	r.HandleFunc("/tokens/listings/{id}/reject", RequireAdmin(authService, e.HandleRejectTokenListing)).Methods("POST")
+	r.HandleFunc("/tokens/listings/{id}", e.HandleGetTokenListing).Methods("GET")
+	r.HandleFunc("/tokens/listings", e.HandleCreateTokenListing).Methods("POST")
+}
+
+func (e *tokenListingEndpoint) HandleCreateTokenListing(w http.ResponseWriter, r *http.Request) {
+	req := &types.TokenListingRequest{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+
+	err := decoder.Decode(req)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	err = e.tokenListingService.Create(req)
+	if err != nil {
+		if errs, ok := err.(types.ValidationErrors); ok {
+			httputils.WriteJSON(w, http.StatusBadRequest, errs)
+			return
+		}
+
+		switch err {
+		case services.ErrTokenExists, services.ErrTokenListingExists, services.ErrNotERC20Token:
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusInternalServerError, "")
+		}
+
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusCreated, req)
+}
+
+func (e *tokenListingEndpoint) HandleGetTokenListing(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !bson.IsObjectIdHex(vars["id"]) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid listing request id")
+		return
+	}
+
+	res, err := e.tokenListingService.GetByID(bson.ObjectIdHex(vars["id"]))
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if res == nil {
+		httputils.WriteError(w, http.StatusNotFound, services.ErrTokenListingNotFound.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *tokenListingEndpoint) HandleApproveTokenListing(w http.ResponseWriter, r *http.Request) {
+	e.handleReviewTokenListing(w, r, types.TokenListingApproved)
+}
+
+func (e *tokenListingEndpoint) HandleRejectTokenListing(w http.ResponseWriter, r *http.Request) {
+	e.handleReviewTokenListing(w, r, types.TokenListingRejected)
+}
+
+// handleReviewTokenListing decodes an admin review sent to the approve or reject endpoint
+// of a listing request and applies it
+func (e *tokenListingEndpoint) handleReviewTokenListing(w http.ResponseWriter, r *http.Request, status string) {
+	vars := mux.Vars(r)
+	if !bson.IsObjectIdHex(vars["id"]) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid listing request id")
+		return
+	}
+
+	review := &types.TokenListingReview{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+
+	err := decoder.Decode(review)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	review.RequestID = bson.ObjectIdHex(vars["id"])
+	review.Status = status
+
+	res, err := e.tokenListingService.Review(review)
+	if err != nil {
+		switch err {
+		case services.ErrNotAdmin:
+			httputils.WriteError(w, http.StatusForbidden, err.Error())
+		case services.ErrTokenListingNotFound:
+			httputils.WriteError(w, http.StatusNotFound, err.Error())
+		case services.ErrTokenListingReviewed, services.ErrTokenListingReasonRequired, services.ErrTokenExists:
+			httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusInternalServerError, "")
+		}
+
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
@@ -34,7 +35,7 @@ func TestHandleCreateTokens(t *testing.T) {
 		ContractAddress: common.HexToAddress("0x1"),
 	}
 
-	tokenService.On("Create", &token).Return(nil)
+	tokenService.On("Create", &token, false).Return(nil)
 
 	b, _ := json.Marshal(token)
 	req, err := http.NewRequest("POST", "/tokens", bytes.NewBuffer(b))
@@ -52,7 +53,50 @@ func TestHandleCreateTokens(t *testing.T) {
 	created := types.Token{}
 	json.NewDecoder(rr.Body).Decode(&created)
 
-	tokenService.AssertCalled(t, "Create", &token)
+	tokenService.AssertCalled(t, "Create", &token, false)
+	testutils.CompareToken(t, &token, &created)
+}
+
+func TestHandleCreateTokensForce(t *testing.T) {
+	router, tokenService := SetupTest()
+
+	// the token contract reports 18 decimals
+	token := types.Token{
+		Name:            "ZRX",
+		Symbol:          "ZRX",
+		Decimal:         8,
+		ContractAddress: common.HexToAddress("0x1"),
+	}
+
+	tokenService.On("Create", &token, false).Return(services.ErrTokenMetadataMismatch)
+	tokenService.On("Create", &token, true).Return(nil)
+
+	create := func(url string) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(token)
+		req, err := http.NewRequest("POST", url, bytes.NewBuffer(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := create("/tokens")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	rr = create("/tokens?force=true")
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusCreated)
+	}
+
+	created := types.Token{}
+	json.NewDecoder(rr.Body).Decode(&created)
+
+	tokenService.AssertCalled(t, "Create", &token, true)
 	testutils.CompareToken(t, &token, &created)
 }
 
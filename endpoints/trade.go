@@ -2,10 +2,14 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/httputils"
 	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/ethereum/go-ethereum/common"
@@ -24,58 +28,170 @@ func ServeTradeResource(
 	e := &tradeEndpoint{tradeService}
 	r.HandleFunc("/trades/history/{baseToken}/{quoteToken}", e.HandleGetTradeHistory)
 	r.HandleFunc("/trades/{address}", e.HandleGetTrades)
+	r.HandleFunc("/orders/{hash}/trades", e.handleGetOrderTrades).Methods("GET")
+	r.HandleFunc("/account/{address}/trades/export", e.handleExportTrades).Methods("GET")
 	ws.RegisterChannel(ws.TradeChannel, e.tradeWebSocket)
 }
 
 // history is reponsible for handling pair's trade history requests
 func (e *tradeEndpoint) HandleGetTradeHistory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	bt := vars["baseToken"]
-	qt := vars["quoteToken"]
 
-	if !common.IsHexAddress(bt) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid base token address")
+	baseToken, err := utils.ParseAddress(vars["baseToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if !common.IsHexAddress(qt) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid quote token address")
+	quoteToken, err := utils.ParseAddress(vars["quoteToken"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trades, next, err := e.tradeService.GetByPairAddress(baseToken, quoteToken, cursor, limit)
+	if err == types.ErrInvalidCursor {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	baseToken := common.HexToAddress(bt)
-	quoteToken := common.HexToAddress(qt)
-	res, err := e.tradeService.GetByPairAddress(baseToken, quoteToken)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
 		return
 	}
 
+	res := map[string]interface{}{
+		"trades":     trades,
+		"nextCursor": next,
+	}
+
 	httputils.WriteJSON(w, http.StatusOK, res)
 }
 
 // get is reponsible for handling user's trade history requests
 func (e *tradeEndpoint) HandleGetTrades(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	addr := vars["address"]
 
-	if !common.IsHexAddress(addr) {
-		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+	address, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trades, next, err := e.tradeService.GetByUserAddress(address, cursor, limit)
+	if err == types.ErrInvalidCursor {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	address := common.HexToAddress(addr)
-	res, err := e.tradeService.GetByUserAddress(address)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
 		return
 	}
 
+	res := map[string]interface{}{
+		"trades":     trades,
+		"nextCursor": next,
+	}
+
 	httputils.WriteJSON(w, http.StatusOK, res)
 }
 
+// handleGetOrderTrades returns all the trades of an order, as maker or taker, by creation time.
+// An order without trades, or unknown, has an empty list of trades.
+func (e *tradeEndpoint) handleGetOrderTrades(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	hash, err := utils.ParseHash(vars["hash"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trades, err := e.tradeService.GetByOrderHash(hash)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, trades)
+}
+
+// handleExportTrades streams the trades of an account as an attachment, in CSV (by default) or
+// JSON lines depending on the format parameter. The optional from and to parameters are unix
+// timestamps in seconds bounding the creation time of the trades. Errors occurring once the
+// export has started can not be reported in the response, which is then cut short.
+func (e *tradeEndpoint) handleExportTrades(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	addr, err := utils.ParseAddress(vars["address"])
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = types.TradeExportCSV
+	}
+
+	var contentType, extension string
+	switch format {
+	case types.TradeExportCSV:
+		contentType, extension = "text/csv", "csv"
+	case types.TradeExportJSON:
+		contentType, extension = "application/x-ndjson", "jsonl"
+	default:
+		httputils.WriteError(w, http.StatusBadRequest, types.ErrInvalidExportFormat.Error())
+		return
+	}
+
+	var from, to time.Time
+	if f := r.URL.Query().Get("from"); f != "" {
+		ts, err := strconv.ParseInt(f, 10, 64)
+		if err != nil || ts < 0 {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid from")
+			return
+		}
+
+		from = time.Unix(ts, 0)
+	}
+
+	if t := r.URL.Query().Get("to"); t != "" {
+		ts, err := strconv.ParseInt(t, 10, 64)
+		if err != nil || ts < 0 {
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid to")
+			return
+		}
+
+		to = time.Unix(ts, 0)
+	}
+
+	filename := fmt.Sprintf("trades-%v.%v", addr.Hex(), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v\"", filename))
+
+	err = e.tradeService.ExportByUserAddress(w, addr, from, to, format)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
 func (e *tradeEndpoint) tradeWebSocket(input interface{}, conn *ws.Conn) {
 	bytes, _ := json.Marshal(input)
 	var payload *types.WebSocketPayload
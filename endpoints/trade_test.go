@@ -0,0 +1,127 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleGetOrderTrades(t *testing.T) {
+	router := mux.NewRouter()
+	tradeService := new(mocks.TradeService)
+	ServeTradeResource(router, tradeService)
+
+	order := common.HexToHash("0x1")
+	unknown := common.HexToHash("0x2")
+	trades := []*types.Trade{
+		{Hash: common.HexToHash("0x3"), OrderHash: order, TakerOrderHash: common.HexToHash("0x4"), Amount: big.NewInt(100)},
+		{Hash: common.HexToHash("0x5"), OrderHash: common.HexToHash("0x6"), TakerOrderHash: order, Amount: big.NewInt(200)},
+	}
+
+	tradeService.On("GetByOrderHash", order).Return(trades, nil)
+	tradeService.On("GetByOrderHash", unknown).Return([]*types.Trade{}, nil)
+
+	req, _ := http.NewRequest("GET", "/orders/"+order.Hex()+"/trades", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	res := []map[string]interface{}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, trades[0].Hash.Hex(), res[0]["hash"])
+	assert.Equal(t, trades[1].Hash.Hex(), res[1]["hash"])
+
+	// unknown orders have no trades
+	req, _ = http.NewRequest("GET", "/orders/"+unknown.Hex()+"/trades", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	assert.Equal(t, "[]", rr.Body.String())
+
+	// invalid hashes are rejected
+	req, _ = http.NewRequest("GET", "/orders/0x1234/trades", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleExportTrades(t *testing.T) {
+	router := mux.NewRouter()
+	tradeService := new(mocks.TradeService)
+	ServeTradeResource(router, tradeService)
+
+	addr := common.HexToAddress("0x1")
+	from, to := time.Unix(1514764800, 0), time.Unix(1514851200, 0)
+	export := func(w io.Writer, addr common.Address, from, to time.Time, format string) error {
+		_, err := w.Write([]byte(format + " export"))
+		return err
+	}
+
+	tradeService.On("ExportByUserAddress", mock.Anything, addr, time.Time{}, time.Time{}, types.TradeExportCSV).Return(export)
+	tradeService.On("ExportByUserAddress", mock.Anything, addr, from, to, types.TradeExportJSON).Return(export)
+
+	// trades are exported as csv attachments by default
+	req, _ := http.NewRequest("GET", "/account/"+addr.Hex()+"/trades/export", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="trades-`+addr.Hex()+`.csv"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "csv export", rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/account/"+addr.Hex()+"/trades/export?format=json&from=1514764800&to=1514851200", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler return wrong status. Got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="trades-`+addr.Hex()+`.jsonl"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "json export", rr.Body.String())
+
+	// invalid addresses, formats and times are rejected
+	for _, url := range []string{
+		"/account/0x1234/trades/export",
+		"/account/" + addr.Hex() + "/trades/export?format=xml",
+		"/account/" + addr.Hex() + "/trades/export?from=yesterday",
+		"/account/" + addr.Hex() + "/trades/export?to=-1",
+	} {
+		req, _ = http.NewRequest("GET", url, nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Handler return wrong status for %v. Got %v want %v", url, rr.Code, http.StatusBadRequest)
+		}
+	}
+
+	tradeService.AssertNumberOfCalls(t, "ExportByUserAddress", 2)
+}
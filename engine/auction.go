@@ -0,0 +1,368 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxPricePoint is the highest pricepoint of the orderbooks
+const maxPricePoint = int64(^uint64(0) >> 1)
+
+// auctionLevel is the total unfilled amount of the orders of a side of the orderbook at a
+// pricepoint
+type auctionLevel struct {
+	PricePoint int64
+	Amount     *big.Int
+}
+
+// clearingPrice returns the pricepoint at which the opening auction of a pair is uncrossed and
+// the amount matched at this pricepoint. The bids are given by decreasing pricepoint and the asks
+// by increasing pricepoint. The clearing pricepoint is the pricepoint of one of the levels that
+// maximizes the matched amount, ie. the smallest of the amount bid at or above the pricepoint and
+// the amount asked at or below it. Ties are broken by the smallest imbalance between these two
+// amounts, then by the distance to the reference pricepoint, if any, then by the lowest
+// pricepoint. A zero amount is returned when the bids and the asks do not cross.
+func clearingPrice(bids, asks []*auctionLevel, reference *big.Int) (int64, *big.Int) {
+	candidates := map[int64]bool{}
+	for _, l := range bids {
+		candidates[l.PricePoint] = true
+	}
+
+	for _, l := range asks {
+		candidates[l.PricePoint] = true
+	}
+
+	var best int64
+	bestVolume := big.NewInt(0)
+	var bestImbalance *big.Int
+	for pp := range candidates {
+		demand := big.NewInt(0)
+		for _, l := range bids {
+			if l.PricePoint >= pp {
+				demand = math.Add(demand, l.Amount)
+			}
+		}
+
+		supply := big.NewInt(0)
+		for _, l := range asks {
+			if l.PricePoint <= pp {
+				supply = math.Add(supply, l.Amount)
+			}
+		}
+
+		volume := math.Min(demand, supply)
+		if volume.Sign() == 0 {
+			continue
+		}
+
+		imbalance := new(big.Int).Abs(math.Sub(demand, supply))
+		if bestImbalance != nil {
+			if c := volume.Cmp(bestVolume); c < 0 {
+				continue
+			} else if c == 0 {
+				if c := imbalance.Cmp(bestImbalance); c > 0 {
+					continue
+				} else if c == 0 && !isCloserPricePoint(pp, best, reference) {
+					continue
+				}
+			}
+		}
+
+		best, bestVolume, bestImbalance = pp, volume, imbalance
+	}
+
+	return best, bestVolume
+}
+
+// isCloserPricePoint returns true if pp is closer to the reference pricepoint than current, or
+// as close but lower. Without reference pricepoint, the lowest pricepoint is the closest.
+func isCloserPricePoint(pp, current int64, reference *big.Int) bool {
+	if reference != nil {
+		ref := reference.Int64()
+		d, dc := abs(pp-ref), abs(current-ref)
+		if d != dc {
+			return d < dc
+		}
+	}
+
+	return pp < current
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+// auctionPending returns true if the opening auction of the pair has not been closed yet, even if
+// its end is past. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) auctionPending() bool {
+	return ob.pair.AuctionEndsAt != nil
+}
+
+// inAuction returns true if the pair is in its opening auction at the time of the command being
+// run: orders are collected without being matched and the orderbook may be crossed. It must be
+// run by the event loop of the orderbook.
+func (ob *OrderBook) inAuction() bool {
+	return ob.auctionPending() && ob.clock().Before(*ob.pair.AuctionEndsAt)
+}
+
+// collectAuctionOrder adds an order received during the opening auction of the pair to the
+// orderbook without matching it. IOC and FOK orders, which can not wait for the end of the
+// auction, are cancelled.
+func (ob *OrderBook) collectAuctionOrder(o *types.Order) (*types.EngineResponse, error) {
+	res := &types.EngineResponse{Order: o, Status: "NOMATCH"}
+	if unfilledCancelReason(o) != "" {
+		return cancelUnfilledAmount(res, types.CancelReasonAuction), nil
+	}
+
+	err := ob.addOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// auctionLevels returns the levels of a side of the orderbook with the total unfilled amount of
+// their orders, including the hidden amount of iceberg orders, best priced first. Expired orders
+// are not counted.
+func (ob *OrderBook) auctionLevels(orders []*types.Order) []*auctionLevel {
+	now := ob.clock()
+	levels := []*auctionLevel{}
+	for _, o := range orders {
+		if o.IsExpired(now) {
+			continue
+		}
+
+		pp := o.PricePoint.Int64()
+		if len(levels) == 0 || levels[len(levels)-1].PricePoint != pp {
+			levels = append(levels, &auctionLevel{PricePoint: pp, Amount: big.NewInt(0)})
+		}
+
+		l := levels[len(levels)-1]
+		l.Amount = math.Add(l.Amount, math.Sub(o.Amount, o.FilledAmount))
+	}
+
+	return levels
+}
+
+// closeAuction ends the opening auction of the pair and switches it to continuous trading. The
+// crossed orders are matched at the clearing pricepoint (see clearingPrice): the bids priced at or
+// above it by decreasing pricepoint, and the asks priced at or below it by increasing pricepoint,
+// the oldest orders of a price level first. Of two matched orders, the order created first is the
+// maker. Orders of different exchange contracts are not matched and expired orders are removed.
+// The orders that are not matched, or only partially, rest in the orderbook. A FULL or PARTIAL
+// engine response is published for each order matched as a taker and the responses are returned.
+// It must be run by the event loop of the orderbook.
+func (ob *OrderBook) closeAuction() ([]*types.EngineResponse, error) {
+	pair := *ob.pair
+	pair.AuctionEndsAt = nil
+	ob.setPair(&pair)
+	defer ob.publishStatus()
+
+	bids, err := ob.crossedOrders(types.OrderSideBuy, 0)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	asks, err := ob.crossedOrders(types.OrderSideSell, maxPricePoint)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	reference := ob.pair.AuctionReferencePricePoint
+	if reference == nil {
+		reference = ob.breaker.LastPricePoint
+	}
+
+	pp, volume := clearingPrice(ob.auctionLevels(bids), ob.auctionLevels(asks), reference)
+	if volume.Sign() == 0 {
+		logger.Infof("The opening auction of %v closed without trades", ob.pair.Name())
+		return nil, nil
+	}
+
+	logger.Infof("The opening auction of %v closed at pricepoint %v, %v matched", ob.pair.Name(), pp, volume)
+
+	clearing := big.NewInt(pp)
+	now := ob.clock()
+	responses := []*types.EngineResponse{}
+	takers := map[common.Hash]*types.EngineResponse{}
+	for _, b := range bids {
+		if b.PricePoint.Int64() < pp {
+			break
+		}
+
+		if ob.book.orders[b.Hash] == nil {
+			continue
+		}
+
+		if b.IsExpired(now) {
+			_, err := ob.expireOrder(b)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			continue
+		}
+
+		for _, a := range asks {
+			if a.PricePoint.Int64() > pp {
+				break
+			}
+
+			// filled or expired asks are no longer in the orderbook
+			if ob.book.orders[a.Hash] == nil {
+				continue
+			}
+
+			if a.IsExpired(now) {
+				_, err := ob.expireOrder(a)
+				if err != nil {
+					logger.Error(err)
+					return nil, err
+				}
+
+				continue
+			}
+
+			if a.ExchangeAddress != b.ExchangeAddress {
+				continue
+			}
+
+			maker, taker := a, b
+			if b.CreatedAt.Before(a.CreatedAt) {
+				maker, taker = b, a
+			}
+
+			res := takers[taker.Hash]
+			if res == nil {
+				res = &types.EngineResponse{HashID: taker.Hash, Order: taker}
+				takers[taker.Hash] = res
+				responses = append(responses, res)
+			}
+
+			// an iceberg maker is matched one displayed slice at a time
+			for ob.book.orders[a.Hash] != nil && ob.book.orders[b.Hash] != nil {
+				trade, err := ob.matchCrossed(taker, maker, clearing)
+				if err != nil {
+					logger.Error(err)
+					return nil, err
+				}
+
+				res.Matches = append(res.Matches, &types.OrderTradePair{Order: maker.Clone(), Trade: trade})
+			}
+
+			if ob.book.orders[b.Hash] == nil {
+				break
+			}
+		}
+	}
+
+	for _, res := range responses {
+		res.Status = "PARTIAL"
+		if res.Order.Status == types.OrderStatusFilled {
+			res.Status = "FULL"
+		}
+
+		err := ob.publishOrderResponse(res, res.HashID)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	return responses, nil
+}
+
+// StartAuction starts the opening auction of a pair, which lasts until the AuctionEndsAt of the
+// given pair. AuctionReferencePricePoint, if set, breaks the ties between clearing pricepoints. The
+// auction can only be started while the orderbook is empty, types.ErrOrderBookNotEmpty being
+// returned otherwise. The auction is not persisted by the engine, it is restarted when the pair
+// record holds an auction that was not closed.
+func (e *Engine) StartAuction(p *types.Pair) (*types.PairStatus, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	if p.AuctionEndsAt == nil {
+		return nil, errors.New("Auction end is missing")
+	}
+
+	var s *types.PairStatus
+	err := ob.do(context.Background(), func() error {
+		if len(ob.book.orders) > 0 || len(ob.book.stops) > 0 {
+			return types.ErrOrderBookNotEmpty
+		}
+
+		pair := *ob.pair
+		pair.AuctionEndsAt = p.AuctionEndsAt
+		pair.AuctionReferencePricePoint = p.AuctionReferencePricePoint
+
+		ob.record(&logEntry{Type: commandUpdatePair, Pair: &pair})
+		ob.setPair(&pair)
+		ob.publishStatus()
+		s = ob.status()
+		return nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// CloseAuctions starts a background routine per orderbook that closes the opening auction of the
+// pair as soon as it is over, at every tick of the given interval. An auction that is over is also
+// closed by the next order received by the pair. The routines are stopped when the engine is
+// closed.
+func (e *Engine) CloseAuctions(interval time.Duration) {
+	for _, ob := range e.orderbooks {
+		ob := ob
+		e.routines.Add(1)
+
+		go func() {
+			defer e.routines.Done()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-e.done:
+					return
+				case <-ticker.C:
+				}
+
+				err := ob.do(context.Background(), func() error {
+					if !ob.auctionPending() || ob.inAuction() {
+						return nil
+					}
+
+					ob.record(&logEntry{Type: commandCloseAuction})
+					_, err := ob.closeAuction()
+					return err
+				})
+
+				if err != nil {
+					logger.Error(err)
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,208 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClearingPrice(t *testing.T) {
+	level := func(pp, amount int64) *auctionLevel {
+		return &auctionLevel{PricePoint: pp, Amount: big.NewInt(amount)}
+	}
+
+	testCases := []struct {
+		name      string
+		bids      []*auctionLevel
+		asks      []*auctionLevel
+		reference *big.Int
+		price     int64
+		volume    int64
+	}{
+		{
+			// 900: 3 bid, 1 asked. 1000: 3 bid, 2 asked. 1100: 1 bid, 4 asked
+			name:   "maximum volume",
+			bids:   []*auctionLevel{level(1100, 1), level(1000, 2)},
+			asks:   []*auctionLevel{level(900, 1), level(1000, 1), level(1100, 2)},
+			price:  1000,
+			volume: 2,
+		},
+		{
+			// 1000: 4 bid, 3 asked. 1100: 3 bid, 3 asked
+			name:   "smallest imbalance",
+			bids:   []*auctionLevel{level(1100, 3), level(1000, 1)},
+			asks:   []*auctionLevel{level(1000, 3)},
+			price:  1100,
+			volume: 3,
+		},
+		{
+			name:      "closest to the reference",
+			bids:      []*auctionLevel{level(1100, 1)},
+			asks:      []*auctionLevel{level(1000, 1)},
+			reference: big.NewInt(1090),
+			price:     1100,
+			volume:    1,
+		},
+		{
+			name:      "lowest as close to the reference",
+			bids:      []*auctionLevel{level(1100, 1)},
+			asks:      []*auctionLevel{level(1000, 1)},
+			reference: big.NewInt(1050),
+			price:     1000,
+			volume:    1,
+		},
+		{
+			name:   "lowest without reference",
+			bids:   []*auctionLevel{level(1100, 1)},
+			asks:   []*auctionLevel{level(1000, 1)},
+			price:  1000,
+			volume: 1,
+		},
+		{
+			name:   "no cross",
+			bids:   []*auctionLevel{level(900, 1)},
+			asks:   []*auctionLevel{level(1000, 1)},
+			volume: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			price, volume := clearingPrice(tc.bids, tc.asks, tc.reference)
+			assert.Equal(t, big.NewInt(tc.volume), volume)
+			if tc.volume > 0 {
+				assert.Equal(t, tc.price, price)
+			}
+		})
+	}
+}
+
+func TestOpeningAuction(t *testing.T) {
+	e, ob, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	statuses := make(chan *types.PairStatus, 10)
+	e.SubscribePairStatus(func(s *types.PairStatus) {
+		statuses <- s
+	})
+
+	responses := []*types.EngineResponse{}
+	ob.do(context.Background(), func() error {
+		ob.publisher = func(res *types.EngineResponse) error {
+			responses = append(responses, res)
+			return nil
+		}
+
+		return nil
+	})
+
+	lastResponse := func() *types.EngineResponse {
+		var res *types.EngineResponse
+		ob.do(context.Background(), func() error {
+			res = responses[len(responses)-1]
+			return nil
+		})
+
+		return res
+	}
+
+	end := time.Now().Add(time.Hour)
+	p := *pair
+	p.AuctionEndsAt = &end
+
+	s, err := e.StartAuction(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, s.Auction)
+	assert.True(t, (<-statuses).Auction)
+
+	// the orders are collected without being matched, the orderbook being crossed
+	sell1, _ := factory1.NewSellOrder(900, 1)
+	sell2, _ := factory1.NewSellOrder(1000, 1)
+	sell3, _ := factory1.NewSellOrder(1100, 2)
+	buy1, _ := factory2.NewBuyOrder(1100, 1)
+	buy2, _ := factory2.NewBuyOrder(1000, 2)
+	for _, o := range []*types.Order{&sell1, &sell2, &sell3, &buy1, &buy2} {
+		e.newOrder(o, o.Hash)
+		assert.Equal(t, "NOMATCH", lastResponse().Status)
+	}
+
+	book, err := e.GetOrderBook(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(book.Bids))
+	assert.Equal(t, 3, len(book.Asks))
+
+	// IOC orders can not wait for the end of the auction
+	buy3, _ := factory2.NewBuyOrder(1100, 1)
+	buy3.TimeInForce = types.TimeInForceIOC
+	e.newOrder(&buy3, buy3.Hash)
+	assert.Equal(t, "CANCELLED", lastResponse().Status)
+	assert.Equal(t, types.CancelReasonAuction, lastResponse().CancelReason)
+
+	// the auction can only be started while the orderbook is empty
+	_, err = e.StartAuction(&p)
+	assert.Equal(t, types.ErrOrderBookNotEmpty, err)
+
+	// 2 are matched at 1000: buy1 against sell1 and buy2 against sell2
+	var closed []*types.EngineResponse
+	err = ob.do(context.Background(), func() error {
+		ob.now = end.Add(time.Second)
+		res, err := ob.closeAuction()
+		closed = res
+		return err
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, (<-statuses).Auction)
+	assert.Equal(t, 2, len(closed))
+	assert.Equal(t, "FULL", closed[0].Status)
+	assert.Equal(t, buy1.Hash, closed[0].Order.Hash)
+	assert.Equal(t, sell1.Hash, closed[0].Matches[0].Order.Hash)
+	assert.Equal(t, "PARTIAL", closed[1].Status)
+	assert.Equal(t, buy2.Hash, closed[1].Order.Hash)
+	assert.Equal(t, sell2.Hash, closed[1].Matches[0].Order.Hash)
+
+	for _, res := range closed {
+		for _, m := range res.Matches {
+			assert.Equal(t, big.NewInt(1000), m.Trade.PricePoint)
+			assert.Equal(t, units.Ethers(1), m.Trade.Amount)
+		}
+	}
+
+	// the unmatched amounts rest in the orderbook
+	book, err = e.GetOrderBook(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(book.Bids))
+	assert.Equal(t, units.Ethers(1), book.Bids[0].Amount)
+	assert.Equal(t, 1, len(book.Asks))
+	assert.Equal(t, units.Ethers(2), book.Asks[0].Amount)
+
+	s, err = e.GetPairStatus(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, s.Auction)
+
+	// the pair then trades continuously
+	sell4, _ := factory1.NewSellOrder(1000, 1)
+	e.newOrder(&sell4, sell4.Hash)
+	assert.Equal(t, "FULL", lastResponse().Status)
+	assert.Equal(t, buy2.Hash, lastResponse().Matches[0].Order.Hash)
+}
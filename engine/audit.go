@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AuditSnapshots returns an unsigned audit snapshot of the resting orders of every orderbook,
+// sorted by pair name (see types.AuditSnapshot). The event loop of each orderbook only copies the
+// state of its book between two commands, so that a snapshot never holds a partly applied
+// command, and the snapshot is built from the copy without holding up the orderbook.
+func (e *Engine) AuditSnapshots() ([]*types.AuditSnapshot, error) {
+	snapshots := []*types.AuditSnapshot{}
+
+	for _, ob := range e.orderbooks {
+		var state *bookSnapshot
+		var timestamp time.Time
+		var pair types.Pair
+
+		err := ob.do(context.Background(), func() error {
+			state = ob.book.snapshot()
+			timestamp = ob.clock()
+			pair = *ob.pair
+			return nil
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		snapshots = append(snapshots, auditSnapshot(&pair, state, timestamp))
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].PairName < snapshots[j].PairName
+	})
+
+	return snapshots, nil
+}
+
+// auditSnapshot returns the audit snapshot of the resting orders of a book state. The orders are
+// listed by side, bids first, then from the best pricepoint and in their order of arrival in their
+// price level. The orders of the state are shared with the book and are only read.
+func auditSnapshot(p *types.Pair, state *bookSnapshot, timestamp time.Time) *types.AuditSnapshot {
+	positions := map[common.Hash]int{}
+	for _, hashes := range state.Levels {
+		for i, h := range hashes {
+			positions[h] = i
+		}
+	}
+
+	orders := make([]*types.Order, len(state.Orders))
+	copy(orders, state.Orders)
+
+	sort.Slice(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		if a.Side != b.Side {
+			return a.Side == types.OrderSideBuy
+		}
+
+		if c := a.PricePoint.Cmp(b.PricePoint); c != 0 {
+			return (c > 0) == (a.Side == types.OrderSideBuy)
+		}
+
+		if positions[a.Hash] != positions[b.Hash] {
+			return positions[a.Hash] < positions[b.Hash]
+		}
+
+		return a.Hash.Hex() < b.Hash.Hex()
+	})
+
+	s := &types.AuditSnapshot{
+		PairName:   p.Name(),
+		BaseToken:  p.BaseTokenAddress,
+		QuoteToken: p.QuoteTokenAddress,
+		Sequence:   state.Sequence,
+		Timestamp:  timestamp.Truncate(time.Millisecond),
+		Orders:     []*types.AuditSnapshotOrder{},
+	}
+
+	for _, o := range orders {
+		s.Orders = append(s.Orders, &types.AuditSnapshotOrder{
+			Hash:            o.Hash,
+			Side:            o.Side,
+			PricePoint:      new(big.Int).Set(o.PricePoint),
+			RemainingAmount: remainingAmount(o),
+		})
+	}
+
+	return s
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditSnapshots(t *testing.T) {
+	e, _, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	snapshots, err := e.AuditSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(snapshots))
+	assert.Equal(t, pair.Name(), snapshots[0].PairName)
+	assert.Equal(t, pair.BaseTokenAddress, snapshots[0].BaseToken)
+	assert.Equal(t, 0, len(snapshots[0].Orders))
+
+	sell1, _ := factory1.NewSellOrder(1100, 1)
+	sell2, _ := factory1.NewSellOrder(1050, 2)
+	sell3, _ := factory1.NewSellOrder(1100, 1)
+	buy1, _ := factory2.NewBuyOrder(900, 1)
+	buy2, _ := factory2.NewBuyOrder(950, 1)
+	for _, o := range []*types.Order{&sell1, &sell2, &sell3, &buy1, &buy2} {
+		e.newOrder(o, o.Hash)
+	}
+
+	// partially fills sell2
+	buy3, _ := factory2.NewBuyOrder(1050, 1)
+	e.newOrder(&buy3, buy3.Hash)
+
+	snapshots, err = e.AuditSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := snapshots[0]
+	hashes := []common.Hash{}
+	for _, o := range s.Orders {
+		hashes = append(hashes, o.Hash)
+	}
+
+	// bids from the best pricepoint, then asks from the best pricepoint in order of arrival
+	assert.Equal(t, []common.Hash{buy2.Hash, buy1.Hash, sell2.Hash, sell1.Hash, sell3.Hash}, hashes)
+	assert.Equal(t, types.OrderSideSell, s.Orders[2].Side)
+	assert.Equal(t, big.NewInt(1050), s.Orders[2].PricePoint)
+	assert.Equal(t, units.Ethers(1), s.Orders[2].RemainingAmount)
+	assert.Equal(t, e.orderbooks[pair.Code()].book.sequence, s.Sequence)
+	assert.Equal(t, s.Timestamp, s.Timestamp.Truncate(time.Millisecond))
+
+	// the snapshot is not changed by the next commands
+	e.CancelOrder(&sell1)
+	assert.Equal(t, 5, len(s.Orders))
+	assert.Equal(t, units.Ethers(1), s.Orders[2].RemainingAmount)
+
+	snapshots, _ = e.AuditSnapshots()
+	assert.Equal(t, 4, len(snapshots[0].Orders))
+	assert.True(t, snapshots[0].Sequence > s.Sequence)
+}
@@ -0,0 +1,298 @@
+package engine
+
+// The benchmarks of this file run against an orderbook held in memory only, without redis nor
+// rabbitmq, so that they measure the matching alone:
+//
+//   go test ./engine -run NONE -bench 'Insert|Match[0-9]|CancelDeep|Depth|Snapshots' -benchmem
+//
+// Baseline, before the pricepoint sets were stored as skip lists (see pricepoints.go), for the
+// books used by the benchmarks:
+// - inserting an order at a new pricepoint shifted the sorted pricepoint slice of its side, up to
+//   10000 pricepoints for the 100k orders book, and every order, even one that crossed nothing,
+//   copied the pricepoints of the opposite side it could have matched
+// - matching a taker copied all the pricepoints it crossed, then cloned every order of each level
+//   it reached, 10 orders per level here, before executing the first trade
+// - the depth snapshot read and sorted all the 2000 price levels before the 50 best levels were
+//   kept by the caller
+// Inserting and matching now read only the pricepoints and orders they reach, in logarithmic time
+// in the number of price levels, and the depth snapshot reads the 50 best levels of each side.
+// The cancellation of an order was already independent of the size of its level and is measured
+// to catch regressions. The pricepoints are never read from redis while matching: the book held in
+// memory is read from the journal of the orderbook on startup, and again only once the redis
+// connection is dialed again after being lost (see OrderBook.refresh). The best pricepoints of
+// each side are kept by the pricepoint sets. The insertion benchmarks from 1k to 100k orders,
+// BenchmarkInsert50k included, show that the cost of an order does not grow with the depth of
+// the book.
+//
+// The snapshots of the orderbook used to be read by the event loop of the orderbook, so that the
+// orders queued behind a large snapshot waited for it. They are now read from the views published
+// by the event loop (see view.go). BenchmarkMatchDuringLoopSnapshots reads the snapshots the
+// previous way and BenchmarkMatchDuringViewSnapshots the current way, both reporting the 99th
+// percentile of the match latency while depth 500 snapshots are read continuously.
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// benchmarkLevelSize is the number of orders of the price levels of the benchmark orderbooks
+const benchmarkLevelSize = 10
+
+// benchmarkOrders creates unsigned copies of a buy and a sell order, which the orderbook does not
+// verify, so that large orderbooks can be built quickly. Each order is given a unique hash.
+type benchmarkOrders struct {
+	buy   *types.Order
+	sell  *types.Order
+	count int64
+}
+
+func newBenchmarkOrders() *benchmarkOrders {
+	pair := testutils.GetZRXWETHTestPair()
+	maker, err := testutils.NewOrderFactory(pair, testutils.GetTestWallet1(), testutils.GetTestAddress1())
+	if err != nil {
+		panic(err)
+	}
+
+	taker, err := testutils.NewOrderFactory(pair, testutils.GetTestWallet2(), testutils.GetTestAddress1())
+	if err != nil {
+		panic(err)
+	}
+
+	buy, _ := taker.NewBuyOrder(1, 1)
+	sell, _ := maker.NewSellOrder(1, 1)
+	return &benchmarkOrders{buy: &buy, sell: &sell}
+}
+
+func (f *benchmarkOrders) newOrder(side types.OrderSide, pricePoint int64, amount int64) *types.Order {
+	o := f.sell.Clone()
+	if side == types.OrderSideBuy {
+		o = f.buy.Clone()
+	}
+
+	f.count++
+	o.Hash = common.BigToHash(big.NewInt(f.count))
+	o.PricePoint = big.NewInt(pricePoint)
+	o.Amount = new(big.Int).Mul(o.Amount, big.NewInt(amount))
+	return o
+}
+
+// newMemoryOrderbook returns a reconciled orderbook that is neither persisted nor published
+func newMemoryOrderbook() *OrderBook {
+	pair := testutils.GetZRXWETHTestPair()
+	return &OrderBook{
+		book:       newBook(),
+		diffs:      newDiffRing(diffRingSize),
+		pair:       pair,
+		stats:      newOrderBookStats(pair.Name()),
+		breaker:    newCircuitBreaker(),
+		reconciled: true,
+		publisher:  func(res *types.EngineResponse) error { return nil },
+	}
+}
+
+// fillSide adds size orders to a side of the orderbook, benchmarkLevelSize orders per pricepoint.
+// The pricepoints are even and spaced by 2 from start, upwards for asks and downwards for bids.
+func fillSide(ob *OrderBook, f *benchmarkOrders, side types.OrderSide, start int64, size int) {
+	step := int64(2)
+	if side == types.OrderSideBuy {
+		step = -2
+	}
+
+	for i := 0; i < size; i++ {
+		ob.addOrder(f.newOrder(side, start+step*int64(i/benchmarkLevelSize), 1))
+	}
+}
+
+func benchmarkInsert(b *testing.B, size int) {
+	ob := newMemoryOrderbook()
+	f := newBenchmarkOrders()
+	fillSide(ob, f, types.OrderSideSell, 1e6, size)
+
+	// the asks are inserted between the resting asks, each at a new pricepoint until all the odd
+	// pricepoints are used, and the orderbook has no bids to match them
+	levels := size / benchmarkLevelSize
+	orders := []*types.Order{}
+	for i := 0; i < b.N; i++ {
+		orders = append(orders, f.newOrder(types.OrderSideSell, 1e6+2*int64(i*7919%levels)+1, 1))
+	}
+
+	b.ResetTimer()
+	for _, o := range orders {
+		_, err := ob.matchOrder(o)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsert1k(b *testing.B)   { benchmarkInsert(b, 1e3) }
+func BenchmarkInsert10k(b *testing.B)  { benchmarkInsert(b, 1e4) }
+func BenchmarkInsert50k(b *testing.B)  { benchmarkInsert(b, 5e4) }
+func BenchmarkInsert100k(b *testing.B) { benchmarkInsert(b, 1e5) }
+
+func benchmarkMatch(b *testing.B, levels int) {
+	ob := newMemoryOrderbook()
+	f := newBenchmarkOrders()
+	fillSide(ob, f, types.OrderSideSell, 1e6, 1e4)
+
+	// each buy order fills the orders of the best levels, which are then booked again
+	top := int64(1e6 + 2*(levels-1))
+	amount := int64(levels * benchmarkLevelSize)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, err := ob.matchOrder(f.newOrder(types.OrderSideBuy, top, amount))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if res.Status != "FULL" || len(res.Matches) != levels*benchmarkLevelSize {
+			b.Fatalf("unexpected %v response with %v matches", res.Status, len(res.Matches))
+		}
+
+		b.StopTimer()
+		fillSide(ob, f, types.OrderSideSell, 1e6, levels*benchmarkLevelSize)
+		b.StartTimer()
+	}
+}
+
+func BenchmarkMatch1Level(b *testing.B)    { benchmarkMatch(b, 1) }
+func BenchmarkMatch10Levels(b *testing.B)  { benchmarkMatch(b, 10) }
+func BenchmarkMatch100Levels(b *testing.B) { benchmarkMatch(b, 100) }
+
+func BenchmarkCancelDeepLevel(b *testing.B) {
+	ob := newMemoryOrderbook()
+	f := newBenchmarkOrders()
+
+	// a single price level of 10000 orders, the cancelled order being booked again at the back of
+	// the level so that the next cancelled order is still in the middle of the level
+	orders := []*types.Order{}
+	for i := 0; i < 1e4; i++ {
+		o := f.newOrder(types.OrderSideSell, 1e6, 1)
+		ob.addOrder(o)
+		orders = append(orders, o)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j := (len(orders)/2 + i) % len(orders)
+		_, err := ob.CancelOrder(orders[j])
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		ob.addOrder(orders[j])
+		b.StartTimer()
+	}
+}
+
+func BenchmarkDepthSnapshot(b *testing.B) {
+	ob := newMemoryOrderbook()
+	f := newBenchmarkOrders()
+	fillSide(ob, f, types.OrderSideSell, 1e6, 1e4)
+	fillSide(ob, f, types.OrderSideBuy, 1e6-2, 1e4)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		book := ob.getOrderBook(50)
+		if len(book.Bids) != 50 || len(book.Asks) != 50 {
+			b.Fatal("unexpected depth")
+		}
+	}
+}
+
+// benchmarkMatchDuringSnapshots matches orders through the event loop of an orderbook of 2000
+// price levels per side while another goroutine reads depth 500 snapshots with snapshot, without
+// pause, and reports the 99th percentile of the time from the submission of an order to the end
+// of its matching
+func benchmarkMatchDuringSnapshots(b *testing.B, snapshot func(ob *OrderBook) *types.OrderBook) {
+	ob := newMemoryOrderbook()
+	f := newBenchmarkOrders()
+	fillSide(ob, f, types.OrderSideSell, 1e6, 2e4)
+	fillSide(ob, f, types.OrderSideBuy, 1e6-2, 2e4)
+
+	ob.commands = make(chan *command, OrderQueueCapacity)
+	ob.cancels = make(chan *command, CancelQueueCapacity)
+	ob.stopped = make(chan struct{})
+	ob.publishView()
+	go ob.run()
+	defer ob.stop()
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			book := snapshot(ob)
+			if len(book.Bids) != 500 || len(book.Asks) != 500 {
+				b.Error("unexpected depth")
+				return
+			}
+		}
+	}()
+
+	latencies := make([]time.Duration, 0, b.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// each buy order fills the orders of the best ask level, which are then booked again
+		o := f.newOrder(types.OrderSideBuy, 1e6, benchmarkLevelSize)
+		start := time.Now()
+		err := ob.do(context.Background(), func() error {
+			_, err := ob.matchOrder(o)
+			return err
+		})
+
+		latencies = append(latencies, time.Since(start))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		ob.do(context.Background(), func() error {
+			fillSide(ob, f, types.OrderSideSell, 1e6, benchmarkLevelSize)
+			return nil
+		})
+		b.StartTimer()
+	}
+
+	b.StopTimer()
+	close(done)
+	wg.Wait()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns/match")
+}
+
+func BenchmarkMatchDuringLoopSnapshots(b *testing.B) {
+	benchmarkMatchDuringSnapshots(b, func(ob *OrderBook) *types.OrderBook {
+		var book *types.OrderBook
+		ob.do(context.Background(), func() error {
+			book = ob.getOrderBook(500)
+			return nil
+		})
+
+		return book
+	})
+}
+
+func BenchmarkMatchDuringViewSnapshots(b *testing.B) {
+	benchmarkMatchDuringSnapshots(b, func(ob *OrderBook) *types.OrderBook {
+		return ob.loadView().orderBook(500)
+	})
+}
@@ -0,0 +1,381 @@
+package engine
+
+import (
+	"container/list"
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// book is the in-memory state of an orderbook. It holds the same structures that were
+// previously stored in redis, under the same keys:
+// - the pricepoint sets, sorted pricepoints keyed by pair prefix and side (see pricePointSet)
+// - the price levels, order hashes in arrival order keyed by pricepoint set key and pricepoint
+// - the orders resting in the orderbook keyed by hash
+// - the dormant stop orders keyed by hash, also indexed by stop pricepoint (see stopIndex)
+// The resting orders that expire are also kept in an expiry wheel (see expiryWheel).
+// The book also keeps the total displayed amount of each price level and the sequence of the
+// last change of a price level amount, as well as the total displayed amount and the number of
+// price levels of each side. The amounts of the price levels of each side are also kept in a
+// persistent treap (see levelNode), which the views of the orderbook share (see bookView). The displayed amount of an order is its unfilled amount, or only the
+// unfilled part of its current slice for an iceberg order (see types.Order.DisplayedAmount), so
+// that the hidden amount of iceberg orders never appears in the price levels nor in their diffs.
+// Orders are cloned when they are stored and when they are returned, so that the matching can
+// update the orders it holds without changing the book. The book is not safe for concurrent use,
+// it is only used by the event loop of the orderbook.
+type book struct {
+	pricePoints map[string]*pricePointSet
+	levels      map[string]*list.List
+	elements    map[common.Hash]*list.Element
+	orders      map[common.Hash]*types.Order
+	stops       map[common.Hash]*types.Order
+	stopIndexes map[string]*stopIndex
+	expiries    *expiryWheel
+	volumes     map[string]*big.Int
+	levelTrees  map[types.OrderSide]*levelNode
+	depths      map[types.OrderSide]*big.Int
+	levelCounts map[types.OrderSide]int
+	sequence    uint64
+}
+
+// mutation operations recorded in the orderbook mutation log
+const (
+	opAddPricePoint    = "ADD_PRICEPOINT"
+	opRemovePricePoint = "REMOVE_PRICEPOINT"
+	opPushHash         = "PUSH_HASH"
+	opRemoveHash       = "REMOVE_HASH"
+	opPutOrder         = "PUT_ORDER"
+	opRemoveOrder      = "REMOVE_ORDER"
+	opPutStop          = "PUT_STOP"
+	opRemoveStop       = "REMOVE_STOP"
+)
+
+// mutation is a change of the book. Mutations are applied to the book synchronously and
+// appended to the mutation log of the orderbook so that the book can be rebuilt on restart.
+type mutation struct {
+	Op         string       `json:"op"`
+	Key        string       `json:"key,omitempty"`
+	PricePoint int64        `json:"pricepoint,omitempty"`
+	Hash       common.Hash  `json:"hash"`
+	Order      *types.Order `json:"order,omitempty"`
+	Sequence   uint64       `json:"sequence,omitempty"`
+}
+
+// bookSnapshot is the serialized state of a book
+type bookSnapshot struct {
+	PricePoints map[string][]int64       `json:"pricepoints"`
+	Levels      map[string][]common.Hash `json:"levels"`
+	Orders      []*types.Order           `json:"orders"`
+	Stops       []*types.Order           `json:"stops"`
+	Sequence    uint64                   `json:"sequence"`
+}
+
+func newBook() *book {
+	return &book{
+		pricePoints: map[string]*pricePointSet{},
+		levels:      map[string]*list.List{},
+		elements:    map[common.Hash]*list.Element{},
+		orders:      map[common.Hash]*types.Order{},
+		stops:       map[common.Hash]*types.Order{},
+		stopIndexes: map[string]*stopIndex{},
+		expiries:    newExpiryWheel(),
+		volumes:     map[string]*big.Int{},
+		levelTrees:  map[types.OrderSide]*levelNode{},
+		depths:      map[types.OrderSide]*big.Int{},
+		levelCounts: map[types.OrderSide]int{},
+	}
+}
+
+// apply applies a mutation to the book. Applying a mutation twice has the same effect as
+// applying it once, so that log entries already included in a snapshot can be replayed. If the
+// mutation changes the amount of a price level, the diff of the level is returned.
+func (b *book) apply(m *mutation) *types.OrderBookDiff {
+	switch m.Op {
+	case opAddPricePoint:
+		pps := b.pricePoints[m.Key]
+		if pps == nil {
+			pps = newPricePointSet()
+			b.pricePoints[m.Key] = pps
+		}
+
+		pps.add(m.PricePoint)
+
+	case opRemovePricePoint:
+		pps := b.pricePoints[m.Key]
+		if pps == nil {
+			return nil
+		}
+
+		pps.remove(m.PricePoint)
+		if pps.len() == 0 {
+			delete(b.pricePoints, m.Key)
+		}
+
+	case opPushHash:
+		// like a redis sorted set member, an order keeps its place in the level when it is
+		// added again, for example after a partial fill
+		if b.elements[m.Hash] != nil {
+			return nil
+		}
+
+		level := b.levels[m.Key]
+		if level == nil {
+			level = list.New()
+			b.levels[m.Key] = level
+		}
+
+		b.elements[m.Hash] = level.PushBack(m.Hash)
+
+	case opRemoveHash:
+		level := b.levels[m.Key]
+		e := b.elements[m.Hash]
+		if level == nil || e == nil {
+			return nil
+		}
+
+		level.Remove(e)
+		delete(b.elements, m.Hash)
+		if level.Len() == 0 {
+			delete(b.levels, m.Key)
+		}
+
+	case opPutOrder:
+		old := b.orders[m.Order.Hash]
+		b.orders[m.Order.Hash] = m.Order
+		b.addExpiry(m.Order)
+		return b.updateVolume(m, m.Order, math.Sub(displayedAmount(m.Order), displayedAmount(old)))
+
+	case opRemoveOrder:
+		old := b.orders[m.Hash]
+		if old == nil {
+			return nil
+		}
+
+		delete(b.orders, m.Hash)
+		b.expiries.remove(m.Hash)
+		return b.updateVolume(m, old, math.Neg(displayedAmount(old)))
+
+	case opPutStop:
+		// trailing stops are stored again when their stop pricepoint moves
+		if old := b.stops[m.Order.Hash]; old != nil {
+			b.unindexStop(old)
+		}
+
+		b.stops[m.Order.Hash] = m.Order
+		b.indexStop(m.Order)
+
+	case opRemoveStop:
+		old := b.stops[m.Hash]
+		if old == nil {
+			return nil
+		}
+
+		b.unindexStop(old)
+		delete(b.stops, m.Hash)
+	}
+
+	return nil
+}
+
+// updateVolume adds delta to the amount of the price level of the order. The mutation is given
+// the next sequence of the book, or the book is set to the sequence of the mutation when the
+// mutation is replayed.
+func (b *book) updateVolume(m *mutation, o *types.Order, delta *big.Int) *types.OrderBookDiff {
+	if delta.Sign() == 0 {
+		return nil
+	}
+
+	_, key := o.GetOBKeys()
+	volume := math.Add(b.volume(key), delta)
+	b.setVolume(o, key, volume)
+
+	if m.Sequence == 0 {
+		b.sequence++
+		m.Sequence = b.sequence
+	} else {
+		b.sequence = m.Sequence
+	}
+
+	return &types.OrderBookDiff{
+		Sequence:   m.Sequence,
+		Side:       o.Side,
+		PricePoint: new(big.Int).Set(o.PricePoint),
+		Amount:     new(big.Int).Set(volume),
+	}
+}
+
+// setVolume sets the total displayed amount of the price level of the order, along with the
+// level treap and the depth of its side
+func (b *book) setVolume(o *types.Order, key string, volume *big.Int) {
+	previous := b.volume(key)
+	if volume.Sign() == 0 {
+		delete(b.volumes, key)
+	} else {
+		b.volumes[key] = volume
+	}
+
+	b.levelTrees[o.Side] = putLevel(b.levelTrees[o.Side], o.PricePoint.Int64(), volume)
+	b.updateDepth(o.Side, previous, volume)
+}
+
+// volume returns the total displayed amount of the orders of a price level
+func (b *book) volume(key string) *big.Int {
+	if b.volumes[key] == nil {
+		return big.NewInt(0)
+	}
+
+	return b.volumes[key]
+}
+
+// updateDepth updates the depth and the level count of a side after the amount of one of its
+// price levels changed from previous to volume
+func (b *book) updateDepth(side types.OrderSide, previous, volume *big.Int) {
+	b.depths[side] = math.Add(b.depth(side), math.Sub(volume, previous))
+
+	if previous.Sign() == 0 && volume.Sign() != 0 {
+		b.levelCounts[side]++
+	} else if previous.Sign() != 0 && volume.Sign() == 0 {
+		b.levelCounts[side]--
+	}
+}
+
+// depth returns the total displayed amount of the orders of a side
+func (b *book) depth(side types.OrderSide) *big.Int {
+	if b.depths[side] == nil {
+		return big.NewInt(0)
+	}
+
+	return b.depths[side]
+}
+
+// remainingAmount returns the unfilled amount of an order, or zero for a nil order
+func remainingAmount(o *types.Order) *big.Int {
+	if o == nil {
+		return big.NewInt(0)
+	}
+
+	if o.FilledAmount == nil {
+		return new(big.Int).Set(o.Amount)
+	}
+
+	return math.Sub(o.Amount, o.FilledAmount)
+}
+
+// displayedAmount returns the amount of an order shown in its price level, or zero for a nil order
+func displayedAmount(o *types.Order) *big.Int {
+	if o == nil {
+		return big.NewInt(0)
+	}
+
+	return o.DisplayedAmount()
+}
+
+// addExpiry adds an order to the expiry wheel if it expires
+func (b *book) addExpiry(o *types.Order) {
+	if o.Expires != nil && o.Expires.Sign() > 0 {
+		b.expiries.add(o.Hash, o.Expires.Int64())
+	}
+}
+
+// position returns the position of a resting order in its price level, 1 for the first order of
+// the level, and the total displayed amount of the orders ahead of it. Only the orders ahead are
+// read. ok is false if the order is not resting in the book.
+func (b *book) position(h common.Hash) (position int, amountAhead *big.Int, ok bool) {
+	e := b.elements[h]
+	if e == nil || b.orders[h] == nil {
+		return 0, nil, false
+	}
+
+	position, amountAhead = 1, big.NewInt(0)
+	for prev := e.Prev(); prev != nil; prev = prev.Prev() {
+		position++
+		amountAhead.Add(amountAhead, displayedAmount(b.orders[prev.Value.(common.Hash)]))
+	}
+
+	return position, amountAhead, true
+}
+
+// isLast returns true if no order is behind the order with the given hash in its price level
+func (b *book) isLast(h common.Hash) bool {
+	e := b.elements[h]
+	return e == nil || e.Next() == nil
+}
+
+// levelHashes returns the hashes of the orders of a price level in arrival order
+func (b *book) levelHashes(key string) []common.Hash {
+	hashes := []common.Hash{}
+	level := b.levels[key]
+	if level == nil {
+		return hashes
+	}
+
+	for e := level.Front(); e != nil; e = e.Next() {
+		hashes = append(hashes, e.Value.(common.Hash))
+	}
+
+	return hashes
+}
+
+// snapshot returns a copy of the book state. Stored orders are never modified, so the snapshot
+// shares them with the book.
+func (b *book) snapshot() *bookSnapshot {
+	s := &bookSnapshot{
+		PricePoints: map[string][]int64{},
+		Levels:      map[string][]common.Hash{},
+		Orders:      []*types.Order{},
+		Stops:       []*types.Order{},
+		Sequence:    b.sequence,
+	}
+
+	for k, pps := range b.pricePoints {
+		s.PricePoints[k] = pps.values()
+	}
+
+	for k := range b.levels {
+		s.Levels[k] = b.levelHashes(k)
+	}
+
+	for _, o := range b.orders {
+		s.Orders = append(s.Orders, o)
+	}
+
+	for _, o := range b.stops {
+		s.Stops = append(s.Stops, o)
+	}
+
+	return s
+}
+
+// restore replaces the book state with the state of a snapshot
+func (b *book) restore(s *bookSnapshot) {
+	*b = *newBook()
+
+	for k, pps := range s.PricePoints {
+		for _, pp := range pps {
+			b.apply(&mutation{Op: opAddPricePoint, Key: k, PricePoint: pp})
+		}
+	}
+
+	for k, hashes := range s.Levels {
+		for _, h := range hashes {
+			b.apply(&mutation{Op: opPushHash, Key: k, Hash: h})
+		}
+	}
+
+	for _, o := range s.Orders {
+		b.orders[o.Hash] = o
+		b.addExpiry(o)
+
+		_, key := o.GetOBKeys()
+		b.setVolume(o, key, math.Add(b.volume(key), displayedAmount(o)))
+	}
+
+	for _, o := range s.Stops {
+		b.stops[o.Hash] = o
+		b.indexStop(o)
+	}
+
+	b.sequence = s.Sequence
+}
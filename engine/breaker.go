@@ -0,0 +1,300 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// CircuitBreakerBand is the maximum distance, in basis points, between the pricepoint of the trades
+// of an incoming order and the reference pricepoint of its pair: the pricepoint of the last trade,
+// or the mid pricepoint of the orderbook before the first trade. 0 disables the circuit breakers.
+var CircuitBreakerBand int64
+
+// CircuitBreakerCooldown is the time during which a pair is halted once its circuit breaker
+// tripped. 0 only stops the matching of the order that tripped it.
+var CircuitBreakerCooldown time.Duration
+
+// circuitBreakerBase is the denominator of the circuit breaker band, which is in basis points
+var circuitBreakerBase = big.NewInt(10000)
+
+// circuitBreaker is the circuit breaker of an orderbook. When an incoming order would trade beyond
+// the band around the reference pricepoint of the pair, its matching is stopped, its unfilled
+// amount is cancelled and the pair is halted until HaltedUntil: new orders are refused but open
+// orders can still be cancelled. The settings of the breaker are copied when the orderbook is
+// created, so that they are logged with its state and replayed along with it.
+type circuitBreaker struct {
+	Band           int64         `json:"band"`
+	Cooldown       time.Duration `json:"cooldown"`
+	LastPricePoint *big.Int      `json:"lastPricePoint,omitempty"`
+	HaltedUntil    time.Time     `json:"haltedUntil"`
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{Band: CircuitBreakerBand, Cooldown: CircuitBreakerCooldown}
+}
+
+// clone returns a copy of the circuit breaker
+func (b *circuitBreaker) clone() *circuitBreaker {
+	c := *b
+	if b.LastPricePoint != nil {
+		c.LastPricePoint = new(big.Int).Set(b.LastPricePoint)
+	}
+
+	return &c
+}
+
+// referencePricePoint returns the pricepoint the trades of an incoming order are compared to: the
+// pricepoint of the last trade, or the mid pricepoint of the orderbook if the pair has not traded
+// since the engine started. nil is returned if neither is known.
+func (ob *OrderBook) referencePricePoint() *big.Int {
+	if ob.breaker.LastPricePoint != nil {
+		return ob.breaker.LastPricePoint
+	}
+
+	bid, ask, crossed := ob.crossedPricePoints()
+	if bid == 0 || ask == 0 || crossed {
+		return nil
+	}
+
+	return big.NewInt((bid + ask) / 2)
+}
+
+// isBeyondBand returns true if a trade of an order of the given side at the given pricepoint would
+// move the price beyond the circuit breaker band around the reference pricepoint
+func (ob *OrderBook) isBeyondBand(side types.OrderSide, reference, pricePoint *big.Int) bool {
+	if ob.breaker.Band <= 0 || reference == nil {
+		return false
+	}
+
+	offset := math.Div(math.Mul(reference, big.NewInt(ob.breaker.Band)), circuitBreakerBase)
+	if side == types.OrderSideBuy {
+		return pricePoint.Cmp(math.Add(reference, offset)) > 0
+	}
+
+	return pricePoint.Cmp(math.Sub(reference, offset)) < 0
+}
+
+// trip halts the pair for the cooldown of the circuit breaker and publishes the new status of the
+// pair. Previewed orders do not halt the pair. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) trip(o *types.Order) {
+	if ob.previewing {
+		return
+	}
+
+	logger.Warningf("The circuit breaker of the %v orderbook stopped the matching of order %v", ob.pair.Name(), o.Hash.Hex())
+
+	if ob.breaker.Cooldown <= 0 {
+		return
+	}
+
+	ob.breaker.HaltedUntil = ob.clock().Add(ob.breaker.Cooldown)
+	ob.publishStatus()
+}
+
+// halted returns true if the pair is halted by its circuit breaker at the time of the command
+// being run. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) halted() bool {
+	return ob.clock().Before(ob.breaker.HaltedUntil)
+}
+
+// resume ends the halt of the pair, if any, and publishes the new status of the pair. It must be
+// run by the event loop of the orderbook.
+func (ob *OrderBook) resume() {
+	if ob.breaker.HaltedUntil.IsZero() {
+		return
+	}
+
+	ob.breaker.HaltedUntil = time.Time{}
+	ob.publishStatus()
+}
+
+// pausePair pauses the pair until it is resumed by an admin and publishes the new status of the
+// pair. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) pausePair() {
+	if ob.pair.Paused {
+		return
+	}
+
+	pair := *ob.pair
+	pair.Paused = true
+	ob.setPair(&pair)
+	ob.publishStatus()
+}
+
+// unpausePair ends the pause of the pair, if any, and publishes the new status of the pair. It must be
+// run by the event loop of the orderbook.
+func (ob *OrderBook) unpausePair() {
+	if !ob.pair.Paused {
+		return
+	}
+
+	pair := *ob.pair
+	pair.Paused = false
+	ob.setPair(&pair)
+	ob.publishStatus()
+}
+
+// status returns the trading status of the pair. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) status() *types.PairStatus {
+	s := ob.pair.Status()
+	if ob.halted() {
+		until := ob.breaker.HaltedUntil
+		s.Halted = true
+		s.HaltedUntil = &until
+	}
+
+	if ob.inAuction() {
+		end := *ob.pair.AuctionEndsAt
+		s.Auction = true
+		s.AuctionEndsAt = &end
+	}
+
+	return s
+}
+
+// publishStatus sends the trading status of the pair to the status subscriber, if any
+func (ob *OrderBook) publishStatus() {
+	if ob.statuses != nil {
+		ob.statuses <- ob.status()
+	}
+}
+
+// ResumeHaltedPairs starts a background routine per orderbook that resumes the pair as soon as
+// the cooldown of its circuit breaker is over, at every tick of the given interval, so that the
+// resumption is published. Halted pairs accept new orders again once their cooldown is over even
+// if the routines are not started. The routines are stopped when the engine is closed.
+func (e *Engine) ResumeHaltedPairs(interval time.Duration) {
+	for _, ob := range e.orderbooks {
+		ob := ob
+		e.routines.Add(1)
+
+		go func() {
+			defer e.routines.Done()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-e.done:
+					return
+				case <-ticker.C:
+				}
+
+				err := ob.do(context.Background(), func() error {
+					if ob.breaker.HaltedUntil.IsZero() || ob.halted() {
+						return nil
+					}
+
+					ob.record(&logEntry{Type: commandResumePair})
+					ob.resume()
+					return nil
+				})
+
+				if err != nil {
+					logger.Error(err)
+				}
+			}
+		}()
+	}
+}
+
+// PausePair pauses a pair until it is resumed (see ResumePair): new orders are refused but open
+// orders can still be cancelled and the orderbook can still be read. The command being run by the
+// event loop of the orderbook, if any, completes before the pair is paused. The pause is not
+// persisted by the engine, pairs are paused when their pair record is.
+func (e *Engine) PausePair(p *types.Pair) (*types.PairStatus, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	var s *types.PairStatus
+	err := ob.do(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandPausePair})
+		ob.pausePair()
+		s = ob.status()
+		return nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ResumePair ends the pause of a pair, as well as its halt by its circuit breaker before the
+// cooldown is over
+func (e *Engine) ResumePair(p *types.Pair) (*types.PairStatus, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	var s *types.PairStatus
+	err := ob.do(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandResumePair})
+		ob.resume()
+
+		if ob.pair.Paused {
+			ob.record(&logEntry{Type: commandUnpausePair})
+			ob.unpausePair()
+		}
+
+		s = ob.status()
+		return nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetPairStatus returns the trading status of a pair
+func (e *Engine) GetPairStatus(p *types.Pair) (*types.PairStatus, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	var s *types.PairStatus
+	err := ob.do(context.Background(), func() error {
+		s = ob.status()
+		return nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SubscribePairStatus starts publishing the trading status of the pairs to the given function
+// whenever a pair is halted, paused or resumed. The function is called from a single background routine.
+func (e *Engine) SubscribePairStatus(fn func(s *types.PairStatus)) {
+	statuses := make(chan *types.PairStatus, commandBufferSize)
+	for _, ob := range e.orderbooks {
+		ob.do(context.Background(), func() error {
+			ob.statuses = statuses
+			return nil
+		})
+	}
+
+	go func() {
+		for s := range statuses {
+			fn(s)
+		}
+	}()
+}
@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	e, ob, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	statuses := make(chan *types.PairStatus, 2)
+	e.SubscribePairStatus(func(s *types.PairStatus) {
+		statuses <- s
+	})
+
+	responses := []*types.EngineResponse{}
+	ob.do(context.Background(), func() error {
+		ob.breaker.Band = 1000
+		ob.breaker.Cooldown = time.Minute
+		ob.publisher = func(res *types.EngineResponse) error {
+			responses = append(responses, res)
+			return nil
+		}
+
+		return nil
+	})
+
+	lastResponse := func() *types.EngineResponse {
+		var res *types.EngineResponse
+		ob.do(context.Background(), func() error {
+			res = responses[len(responses)-1]
+			return nil
+		})
+
+		return res
+	}
+
+	sell1, _ := factory1.NewSellOrder(1000, 1e8)
+	sell2, _ := factory1.NewSellOrder(1050, 1e8)
+	sell3, _ := factory1.NewSellOrder(1200, 1e8)
+	for _, o := range []*types.Order{&sell1, &sell2, &sell3} {
+		e.addOrder(o)
+	}
+
+	// trades within 10% of the last trade are matched
+	buy1, _ := factory2.NewBuyOrder(1000, 1e8)
+	e.newOrder(&buy1, buy1.Hash)
+	assert.Equal(t, "FULL", lastResponse().Status)
+
+	buy2, _ := factory2.NewBuyOrder(1050, 1e8)
+	e.newOrder(&buy2, buy2.Hash)
+	assert.Equal(t, "FULL", lastResponse().Status)
+
+	// an order trading more than 10% away from the last trade is cancelled and halts the pair
+	buy3, _ := factory2.NewBuyOrder(1200, 1e8)
+	e.newOrder(&buy3, buy3.Hash)
+
+	res := lastResponse()
+	assert.Equal(t, "CANCELLED", res.Status)
+	assert.Equal(t, types.CancelReasonCircuitBreaker, res.CancelReason)
+	assert.Equal(t, 0, len(res.Matches))
+
+	s := <-statuses
+	assert.True(t, s.Halted)
+	assert.True(t, s.HaltedUntil.After(time.Now().Add(59*time.Second)))
+
+	s, _ = e.GetPairStatus(pair)
+	assert.True(t, s.Halted)
+
+	// new orders are refused while the pair is halted but orders can still be cancelled
+	buy4, _ := factory2.NewBuyOrder(1200, 1e8)
+	e.newOrder(&buy4, buy4.Hash)
+	assert.Equal(t, "ERROR", lastResponse().Status)
+
+	res, err := e.CancelOrder(&sell3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CANCELLED", res.Status)
+
+	// the pair can be resumed before the end of the cooldown
+	s, err = e.ResumePair(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, s.Halted)
+	assert.Nil(t, s.HaltedUntil)
+	assert.False(t, (<-statuses).Halted)
+
+	sell4, _ := factory1.NewSellOrder(1100, 1e8)
+	e.newOrder(&sell4, sell4.Hash)
+	assert.Equal(t, "NOMATCH", lastResponse().Status)
+}
+
+func TestCircuitBreakerBand(t *testing.T) {
+	ob := &OrderBook{breaker: &circuitBreaker{Band: 500}}
+	reference := big.NewInt(1000)
+
+	assert.False(t, ob.isBeyondBand(types.OrderSideBuy, reference, big.NewInt(1050)))
+	assert.True(t, ob.isBeyondBand(types.OrderSideBuy, reference, big.NewInt(1051)))
+	assert.False(t, ob.isBeyondBand(types.OrderSideSell, reference, big.NewInt(950)))
+	assert.True(t, ob.isBeyondBand(types.OrderSideSell, reference, big.NewInt(949)))
+
+	// the circuit breaker is disabled without band or reference pricepoint
+	assert.False(t, ob.isBeyondBand(types.OrderSideBuy, nil, big.NewInt(2000)))
+
+	ob.breaker.Band = 0
+	assert.False(t, ob.isBeyondBand(types.OrderSideBuy, reference, big.NewInt(2000)))
+}
+
+func TestPausePair(t *testing.T) {
+	e, ob, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	statuses := make(chan *types.PairStatus, 2)
+	e.SubscribePairStatus(func(s *types.PairStatus) {
+		statuses <- s
+	})
+
+	responses := []*types.EngineResponse{}
+	ob.do(context.Background(), func() error {
+		ob.publisher = func(res *types.EngineResponse) error {
+			responses = append(responses, res)
+			return nil
+		}
+
+		return nil
+	})
+
+	lastResponse := func() *types.EngineResponse {
+		var res *types.EngineResponse
+		ob.do(context.Background(), func() error {
+			res = responses[len(responses)-1]
+			return nil
+		})
+
+		return res
+	}
+
+	sell1, _ := factory1.NewSellOrder(1000, 1e8)
+	sell2, _ := factory1.NewSellOrder(1100, 1e8)
+	e.addOrder(&sell1)
+	e.addOrder(&sell2)
+
+	// the command being run when the pause is received completes before the pair is paused
+	release := make(chan struct{})
+	running := make(chan struct{})
+	buy1, _ := factory2.NewBuyOrder(1000, 1e8)
+	go ob.do(context.Background(), func() error {
+		close(running)
+		<-release
+		_, err := ob.newOrder(&buy1, buy1.Hash, time.Now())
+		return err
+	})
+
+	<-running
+	paused := make(chan *types.PairStatus)
+	go func() {
+		s, err := e.PausePair(pair)
+		if err != nil {
+			t.Error(err)
+		}
+
+		paused <- s
+	}()
+
+	select {
+	case <-paused:
+		t.Fatal("The pair was paused before the running command completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	s := <-paused
+	assert.True(t, s.Paused)
+	assert.True(t, (<-statuses).Paused)
+	assert.Equal(t, "FULL", responses[0].Status)
+
+	// new orders are refused while the pair is paused
+	buy2, _ := factory2.NewBuyOrder(1100, 1e8)
+	e.newOrder(&buy2, buy2.Hash)
+	assert.Equal(t, "ERROR", lastResponse().Status)
+
+	// open orders can still be cancelled and the orderbook read
+	book, err := e.GetOrderBook(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(book.Asks))
+
+	res, err := e.CancelOrder(&sell2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CANCELLED", res.Status)
+
+	// the pair accepts new orders once resumed
+	s, err = e.ResumePair(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, s.Paused)
+	assert.False(t, (<-statuses).Paused)
+
+	sell3, _ := factory1.NewSellOrder(1200, 1e8)
+	e.newOrder(&sell3, sell3.Hash)
+	assert.Equal(t, "NOMATCH", lastResponse().Status)
+}
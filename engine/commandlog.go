@@ -0,0 +1,434 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// commandLogBufferSize is the number of commands that can be queued before the matching waits
+// for the command log to catch up
+const commandLogBufferSize = 1 << 16
+
+// command log entry types
+const (
+	commandSnapshot          = "SNAPSHOT"
+	commandNewOrder          = "NEW_ORDER"
+	commandAddOrder          = "ADD_ORDER"
+	commandCancelOrder       = "CANCEL_ORDER"
+	commandReplaceOrder      = "REPLACE_ORDER"
+	commandReduceOrder       = "REDUCE_ORDER"
+	commandRemoveMakerOrders = "REMOVE_MAKER_ORDERS"
+	commandDeleteOrders      = "DELETE_ORDERS"
+	commandRecoverOrders     = "RECOVER_ORDERS"
+	commandCancelTrades      = "CANCEL_TRADES"
+	commandRestoreFills      = "RESTORE_FILLS"
+	commandPruneOrders       = "PRUNE_ORDERS"
+	commandExpireOrders      = "EXPIRE_ORDERS"
+	commandReconcile         = "RECONCILE"
+	commandUpdatePair        = "UPDATE_PAIR"
+	commandResumePair        = "RESUME_PAIR"
+	commandPausePair         = "PAUSE_PAIR"
+	commandUnpausePair       = "UNPAUSE_PAIR"
+	commandCloseAuction      = "CLOSE_AUCTION"
+	commandStopTriggered     = "STOP_TRIGGERED"
+)
+
+var errUnknownCommand = errors.New("Unknown command")
+
+// commandLog writes the commands run by the event loops of the orderbooks to an append-only log,
+// one json entry per line, so that the orderbooks and their trades can be rebuilt from the log
+// alone (see Replay). Each entry is given the next sequence of the log and the time at which it
+// was run, which is also the time at which the command expired orders. Entries are written in
+// sequence order by a single goroutine, the event loops only wait for it if it falls more than
+// commandLogBufferSize entries behind.
+type commandLog struct {
+	w        io.Writer
+	entries  chan *logEntry
+	done     chan struct{}
+	mutex    sync.Mutex
+	sequence uint64
+}
+
+// logEntry is a command of an orderbook. The log of an orderbook starts with a SNAPSHOT entry
+// holding its pair, book and circuit breaker, the following entries only hold the arguments of
+// their command.
+// AmountsByHash holds the committed amounts of a RECONCILE entry or the filled amounts of the
+// uncovered orders of a PRUNE_ORDERS entry.
+type logEntry struct {
+	Sequence   uint64          `json:"sequence"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Type       string          `json:"type"`
+	PairCode   string          `json:"pairCode"`
+	HashID     common.Hash     `json:"hashID"`
+	Orders     []*types.Order  `json:"orders,omitempty"`
+	Amounts    []*big.Int      `json:"amounts,omitempty"`
+	Maker      *common.Address `json:"maker,omitempty"`
+	Hashes     []common.Hash   `json:"hashes,omitempty"`
+	Pair       *types.Pair     `json:"pair,omitempty"`
+	Book       *bookSnapshot   `json:"book,omitempty"`
+	Breaker    *circuitBreaker `json:"breaker,omitempty"`
+	Reconciled bool            `json:"reconciled,omitempty"`
+
+	AmountsByHash map[common.Hash]*big.Int `json:"amountsByHash,omitempty"`
+}
+
+// StateSnapshot is the state of the orderbooks rebuilt by Replay. The orderbooks and their resting
+// and stop orders, sorted by hash, are keyed by pair code. The trades are listed in the order in
+// which they were made.
+type StateSnapshot struct {
+	Sequence   uint64                      `json:"sequence"`
+	OrderBooks map[string]*types.OrderBook `json:"orderBooks"`
+	Orders     map[string][]*types.Order   `json:"orders"`
+	Trades     []*types.Trade              `json:"trades"`
+}
+
+func newCommandLog(w io.Writer) *commandLog {
+	return &commandLog{
+		w:       w,
+		entries: make(chan *logEntry, commandLogBufferSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// start starts writing the queued entries. The writes are buffered and flushed whenever the
+// queue is empty.
+func (l *commandLog) start() {
+	go func() {
+		defer close(l.done)
+
+		w := bufio.NewWriter(l.w)
+		enc := json.NewEncoder(w)
+		for e := range l.entries {
+			err := enc.Encode(e)
+			if err != nil {
+				logger.Error(err)
+			}
+
+			if len(l.entries) > 0 {
+				continue
+			}
+
+			err = w.Flush()
+			if err != nil {
+				logger.Error(err)
+			}
+		}
+	}()
+}
+
+// close waits until all the queued entries have been written and stops the log. The writer of
+// the log is closed if it is a closer.
+func (l *commandLog) close() {
+	close(l.entries)
+	<-l.done
+
+	if c, ok := l.w.(io.Closer); ok {
+		err := c.Close()
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+}
+
+// append gives an entry the next sequence of the log and queues it
+func (l *commandLog) append(e *logEntry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sequence++
+	e.Sequence = l.sequence
+	l.entries <- e
+}
+
+// lastSequence returns the sequence of the last entry appended to the log
+func (l *commandLog) lastSequence() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.sequence
+}
+
+// LogCommands starts writing the commands run by the orderbooks to w (see commandLog). The log
+// starts with a snapshot of every orderbook. Commands that change several orderbooks, like the
+// cancellation of all the orders of a maker, are logged once per orderbook.
+func (e *Engine) LogCommands(w io.Writer) {
+	l := newCommandLog(w)
+	l.start()
+
+	for _, ob := range e.orderbooks {
+		ob.do(context.Background(), func() error {
+			ob.commandLog = l
+			ob.record(&logEntry{
+				Type:       commandSnapshot,
+				Pair:       ob.pair,
+				Book:       ob.book.snapshot(),
+				Breaker:    ob.breaker.clone(),
+				Reconciled: ob.reconciled,
+			})
+
+			return nil
+		})
+	}
+
+	e.commandLog = l
+}
+
+// State returns the state of the orderbooks in the form rebuilt by Replay, with the sequence of
+// the last logged command, so that the engine can be compared with the replay of its log. The
+// engine does not keep its trades, which are left empty.
+func (e *Engine) State() (*StateSnapshot, error) {
+	s := &StateSnapshot{
+		OrderBooks: map[string]*types.OrderBook{},
+		Orders:     map[string][]*types.Order{},
+		Trades:     []*types.Trade{},
+	}
+
+	for code, ob := range e.orderbooks {
+		err := ob.do(context.Background(), func() error {
+			s.OrderBooks[code], s.Orders[code] = ob.state()
+			return nil
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	if e.commandLog != nil {
+		s.Sequence = e.commandLog.lastSequence()
+	}
+
+	return s, nil
+}
+
+// state returns the price levels and a copy of the resting and stop orders of the orderbook,
+// sorted by hash. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) state() (*types.OrderBook, []*types.Order) {
+	orders := []*types.Order{}
+	for _, o := range ob.book.orders {
+		orders = append(orders, o.Clone())
+	}
+
+	for _, o := range ob.book.stops {
+		orders = append(orders, o.Clone())
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Hash.Hex() < orders[j].Hash.Hex()
+	})
+
+	return ob.getOrderBook(0), orders
+}
+
+// record sets the time of the command being run by the event loop, which is the time of the
+// entry if it is set, and appends the command to the command log if the commands of the
+// orderbook are logged. The orders, amounts and pair of the entry are copied since they can be
+// changed before they are written. It must be run by the event loop of the orderbook before
+// the command changes the orderbook.
+func (ob *OrderBook) record(e *logEntry) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = ob.clock()
+	}
+
+	ob.now = e.Timestamp
+	if ob.commandLog == nil {
+		return
+	}
+
+	e.PairCode = ob.pair.Code()
+
+	orders := make([]*types.Order, len(e.Orders))
+	for i, o := range e.Orders {
+		orders[i] = o.Clone()
+	}
+
+	amounts := make([]*big.Int, len(e.Amounts))
+	for i, a := range e.Amounts {
+		amounts[i] = new(big.Int).Set(a)
+	}
+
+	e.Orders, e.Amounts = orders, amounts
+	if e.Pair != nil {
+		pair := *e.Pair
+		e.Pair = &pair
+	}
+
+	ob.commandLog.append(e)
+}
+
+// Replay rebuilds the orderbooks and the trades of an engine from its command log (see
+// LogCommands). Each orderbook is restored from its snapshot and runs its logged commands in
+// sequence order, at the time at which they were logged and without being persisted or
+// publishing any engine response, which makes the replay deterministic: replaying a log always
+// results in the state of the engine that wrote it.
+// Commands that failed when they were logged fail again and are skipped in the same way.
+func Replay(r io.Reader) (*StateSnapshot, error) {
+	s := &StateSnapshot{
+		OrderBooks: map[string]*types.OrderBook{},
+		Orders:     map[string][]*types.Order{},
+		Trades:     []*types.Trade{},
+	}
+
+	obs := map[string]*OrderBook{}
+	dec := json.NewDecoder(r)
+	for {
+		e := &logEntry{}
+		err := dec.Decode(e)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if e.Sequence <= s.Sequence {
+			return nil, errors.New("Command log is not in sequence order")
+		}
+
+		s.Sequence = e.Sequence
+
+		if e.Type == commandSnapshot {
+			if e.Pair == nil || e.Book == nil {
+				return nil, errors.New("Invalid orderbook snapshot")
+			}
+
+			obs[e.PairCode] = newReplayOrderBook(e, s)
+			continue
+		}
+
+		ob := obs[e.PairCode]
+		if ob == nil {
+			return nil, errors.New("Command log does not start with a snapshot of the orderbook")
+		}
+
+		ob.now = e.Timestamp
+		err = ob.replay(e)
+		if err == errUnknownCommand {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if ob.reconciled {
+			ob.checkCrossed()
+		}
+
+		ob.now = time.Time{}
+	}
+
+	for code, ob := range obs {
+		s.OrderBooks[code], s.Orders[code] = ob.state()
+	}
+
+	return s, nil
+}
+
+// newReplayOrderBook restores an orderbook from a SNAPSHOT entry. The trades matched by the
+// orderbook are added to the state. The circuit breaker is disabled for logs written before it
+// was introduced.
+func newReplayOrderBook(e *logEntry, s *StateSnapshot) *OrderBook {
+	ob := &OrderBook{
+		book:       newBook(),
+		diffs:      newDiffRing(diffRingSize),
+		dedup:      newDedupCache(DedupCacheSize, DedupTTL),
+		pair:       e.Pair,
+		stats:      newOrderBookStats(e.Pair.Name()),
+		breaker:    &circuitBreaker{},
+		reconciled: e.Reconciled,
+	}
+
+	if e.Breaker != nil {
+		ob.breaker = e.Breaker
+	}
+
+	ob.book.restore(e.Book)
+	ob.publisher = func(res *types.EngineResponse) error {
+		for _, m := range res.Matches {
+			s.Trades = append(s.Trades, m.Trade)
+		}
+
+		return nil
+	}
+
+	return ob
+}
+
+// replay runs a logged command. It returns errUnknownCommand for entries of unknown type.
+func (ob *OrderBook) replay(e *logEntry) error {
+	var err error
+	switch e.Type {
+	case commandNewOrder:
+		_, err = ob.newOrder(e.Orders[0], e.HashID, e.Timestamp)
+	case commandAddOrder:
+		err = ob.addOrder(e.Orders[0])
+	case commandCancelOrder:
+		_, err = ob.CancelOrder(e.Orders[0])
+	case commandReplaceOrder:
+		_, err = ob.replaceOrder(e.Orders[0], e.Orders[1], e.HashID)
+	case commandReduceOrder:
+		_, err = ob.reduceOrder(e.Orders[0], e.Amounts[0])
+	case commandRemoveMakerOrders:
+		_, err = ob.removeMakerOrders(*e.Maker, e.Hashes)
+	case commandDeleteOrders:
+		orders := make([]types.Order, len(e.Orders))
+		for i, o := range e.Orders {
+			orders[i] = *o
+		}
+
+		err = ob.deleteOrders(orders...)
+	case commandRecoverOrders:
+		matches := []*types.OrderTradePair{}
+		for i, o := range e.Orders {
+			matches = append(matches, &types.OrderTradePair{Order: o, Trade: &types.Trade{Amount: e.Amounts[i]}})
+		}
+
+		err = ob.RecoverOrders(matches)
+	case commandCancelTrades:
+		err = ob.CancelTrades(e.Orders, e.Amounts)
+	case commandRestoreFills:
+		for i, o := range e.Orders {
+			_, err = ob.restoreFill(o, e.Amounts[i])
+			if err != nil {
+				break
+			}
+		}
+	case commandPruneOrders:
+		_, err = ob.pruneOrders(e.Timestamp, e.AmountsByHash)
+	case commandExpireOrders:
+		_, err = ob.expireOrders(e.Timestamp, ob.book.expiries.expired(e.Timestamp.Unix()))
+	case commandReconcile:
+		_, err = ob.reconcile(e.Orders, e.AmountsByHash)
+	case commandUpdatePair:
+		ob.setPair(e.Pair)
+	case commandResumePair:
+		ob.resume()
+	case commandPausePair:
+		ob.pausePair()
+	case commandUnpausePair:
+		ob.unpausePair()
+	case commandCloseAuction:
+		_, err = ob.closeAuction()
+	case commandStopTriggered:
+		// the stop orders are triggered again by the replayed trades
+	default:
+		return errUnknownCommand
+	}
+
+	if err != nil {
+		logger.Warningf("Replayed command %v failed: %v", e.Sequence, err)
+	}
+
+	return nil
+}
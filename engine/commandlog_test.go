@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer e.redisConn.FlushAll()
+
+	log := &bytes.Buffer{}
+	e.LogCommands(log)
+
+	trades := []*types.Trade{}
+	ob.do(context.Background(), func() error {
+		ob.publisher = func(res *types.EngineResponse) error {
+			for _, m := range res.Matches {
+				trades = append(trades, m.Trade)
+			}
+
+			return nil
+		}
+
+		return nil
+	})
+
+	// random orders, cancellations and replacements around the same price, the failed
+	// cancellations and replacements of filled orders being logged as well
+	r := rand.New(rand.NewSource(1))
+	newOrder := func() *types.Order {
+		factory := []*testutils.OrderFactory{factory1, factory2}[r.Intn(2)]
+		pricePoint := int64(990 + r.Intn(20))
+		amount := float64(1 + r.Intn(5))
+
+		o, _ := factory.NewSellOrder(pricePoint, amount)
+		if r.Intn(2) == 0 {
+			o, _ = factory.NewBuyOrder(pricePoint, amount)
+		}
+
+		return &o
+	}
+
+	orders := []*types.Order{}
+	for i := 0; i < 500; i++ {
+		n := r.Intn(10)
+		switch {
+		case n < 7 || len(orders) == 0:
+			o := newOrder()
+			e.newOrder(o, o.Hash)
+			orders = append(orders, o)
+		case n < 9:
+			e.CancelOrder(orders[r.Intn(len(orders))])
+		default:
+			o := newOrder()
+			e.ReplaceOrder(orders[r.Intn(len(orders))], o, o.Hash)
+			orders = append(orders, o)
+		}
+	}
+
+	state, err := e.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Close()
+
+	replayed, err := Replay(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEqual(t, 0, len(trades))
+	assert.Equal(t, state.Sequence, replayed.Sequence)
+	assertJSONEqual(t, state.OrderBooks, replayed.OrderBooks)
+	assertJSONEqual(t, state.Orders, replayed.Orders)
+	assertJSONEqual(t, trades, replayed.Trades)
+}
+
+// assertJSONEqual asserts that two values have the same json encoding, which ignores the
+// internal representation of big integers and times
+func assertJSONEqual(t *testing.T, expected, actual interface{}) {
+	e, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := json.Marshal(actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, string(e), string(a))
+}
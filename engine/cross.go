@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// crossedPricePoints returns the best bid and best ask pricepoints of the orderbook and whether
+// the best bid is at or above the best ask. It only reads the ends of the pricepoint sets, so that
+// it can be run after every command.
+func (ob *OrderBook) crossedPricePoints() (bid, ask int64, crossed bool) {
+	bids := ob.book.pricePoints[ob.pair.GetKVPrefix()+"::"+string(types.OrderSideBuy)]
+	asks := ob.book.pricePoints[ob.pair.GetKVPrefix()+"::"+string(types.OrderSideSell)]
+	if bids == nil || asks == nil {
+		return 0, 0, false
+	}
+
+	bid, _ = bids.max()
+	ask, _ = asks.min()
+	return bid, ask, bid >= ask
+}
+
+// checkCrossed repairs the orderbook if its best bid is at or above its best ask (see uncross).
+// The orders of different exchange contracts can not be matched and may leave the orderbook
+// crossed, such a cross is only logged and checked again once the best bid or ask changes. It must
+// be run by the event loop of the orderbook.
+func (ob *OrderBook) checkCrossed() {
+	// the orderbook is crossed during the opening auction of the pair until it is closed
+	if ob.auctionPending() {
+		return
+	}
+
+	bid, ask, crossed := ob.crossedPricePoints()
+	if !crossed || ob.lastCross == [2]int64{bid, ask} {
+		return
+	}
+
+	responses, err := ob.uncross()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	if len(responses) > 0 {
+		logger.Errorf("ALERT: the %v orderbook was crossed (best bid %v, best ask %v), %v crossed orders were matched", ob.pair.Name(), bid, ask, len(responses))
+	}
+
+	bid, ask, crossed = ob.crossedPricePoints()
+	if crossed {
+		logger.Warningf("The %v orderbook is crossed by orders that can not be matched (best bid %v, best ask %v)", ob.pair.Name(), bid, ask)
+		ob.lastCross = [2]int64{bid, ask}
+	}
+}
+
+// uncross matches the crossed orders of the orderbook: the bids priced at or above the best ask
+// with the asks priced at or below the best bid. Bids are taken by decreasing pricepoint and asks
+// by increasing pricepoint, the oldest orders of a price level first. Of two crossed orders, the
+// order created first is the maker and the trade is made at its pricepoint, as if the other order
+// had been matched when it was received. Expired orders are removed instead of being matched and
+// orders of different exchange contracts are not matched.
+// A FULL or PARTIAL engine response is published for each order matched as a taker, the orders
+// left partially filled staying in the orderbook. The responses are also returned. It must be run
+// by the event loop of the orderbook.
+func (ob *OrderBook) uncross() ([]*types.EngineResponse, error) {
+	bid, ask, crossed := ob.crossedPricePoints()
+	if !crossed {
+		return nil, nil
+	}
+
+	bids, err := ob.crossedOrders(types.OrderSideBuy, ask)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	asks, err := ob.crossedOrders(types.OrderSideSell, bid)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	now := ob.clock()
+	responses := []*types.EngineResponse{}
+	takers := map[common.Hash]*types.EngineResponse{}
+	for _, b := range bids {
+		if ob.book.orders[b.Hash] == nil {
+			continue
+		}
+
+		if b.IsExpired(now) {
+			_, err := ob.expireOrder(b)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			continue
+		}
+
+		for _, a := range asks {
+			if a.PricePoint.Cmp(b.PricePoint) > 0 {
+				break
+			}
+
+			// filled or expired asks are no longer in the orderbook
+			if ob.book.orders[a.Hash] == nil {
+				continue
+			}
+
+			if a.IsExpired(now) {
+				_, err := ob.expireOrder(a)
+				if err != nil {
+					logger.Error(err)
+					return nil, err
+				}
+
+				continue
+			}
+
+			if a.ExchangeAddress != b.ExchangeAddress {
+				continue
+			}
+
+			maker, taker := a, b
+			if b.CreatedAt.Before(a.CreatedAt) {
+				maker, taker = b, a
+			}
+
+			res := takers[taker.Hash]
+			if res == nil {
+				res = &types.EngineResponse{HashID: taker.Hash, Order: taker}
+				takers[taker.Hash] = res
+				responses = append(responses, res)
+			}
+
+			// an iceberg maker is matched one displayed slice at a time
+			for ob.book.orders[a.Hash] != nil && ob.book.orders[b.Hash] != nil {
+				trade, err := ob.matchCrossed(taker, maker, executionPricePoint(taker, maker))
+				if err != nil {
+					logger.Error(err)
+					return nil, err
+				}
+
+				res.Matches = append(res.Matches, &types.OrderTradePair{Order: maker.Clone(), Trade: trade})
+			}
+
+			if ob.book.orders[b.Hash] == nil {
+				break
+			}
+		}
+	}
+
+	for _, res := range responses {
+		res.Status = "PARTIAL"
+		if res.Order.Status == types.OrderStatusFilled {
+			res.Status = "FULL"
+		}
+
+		err := ob.publishOrderResponse(res, res.HashID)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	return responses, nil
+}
+
+// crossedOrders returns the orders of a side of the orderbook that cross the given pricepoint,
+// best priced first and the oldest visible orders of a price level first (see GetMatchingOrders)
+func (ob *OrderBook) crossedOrders(side types.OrderSide, pricePoint int64) ([]*types.Order, error) {
+	key := ob.pair.GetKVPrefix() + "::" + string(side)
+
+	var pps []int64
+	var err error
+	if side == types.OrderSideBuy {
+		pps, err = ob.GetMatchingSellPricePoints(key, pricePoint)
+	} else {
+		pps, err = ob.GetMatchingBuyPricePoints(key, pricePoint)
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	orders := []*types.Order{}
+	for _, pp := range pps {
+		entries, err := ob.GetMatchingOrders(key, pp)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		orders = append(orders, entries...)
+	}
+
+	return orders, nil
+}
+
+// matchCrossed executes a trade between two crossed orders at the given pricepoint, the execution
+// pricepoint of the orders (see executionPricePoint) or the clearing pricepoint of an auction. Unlike the orders matched when they are
+// received, the taker is resting in the orderbook and is updated or removed like the maker.
+func (ob *OrderBook) matchCrossed(taker, maker *types.Order, pricePoint *big.Int) (*types.Trade, error) {
+	trade, err := ob.execute(taker, maker)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	trade.PricePoint = pricePoint
+	ob.recordTrade(trade)
+
+	remaining := math.Sub(taker.Amount, taker.FilledAmount)
+	if remaining.Sign() == 0 || ob.isDust(remaining, taker.PricePoint) {
+		err = ob.deleteOrder(taker)
+		taker.Status = types.OrderStatusFilled
+	} else {
+		err = ob.updateOrder(taker, trade.Amount)
+		taker.Status = types.OrderStatusPartialFilled
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if taker.Status == types.OrderStatusPartialFilled {
+		err = ob.revealNextSlice(taker)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	return trade, nil
+}
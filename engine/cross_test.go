@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCrossed(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	now := time.Now()
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	o1.CreatedAt = now
+	o2, _ := factory2.NewBuyOrder(1e3+2, 2)
+	o2.CreatedAt = now.Add(time.Second)
+	o3, _ := factory1.NewSellOrder(1e3+1, 3)
+	o3.CreatedAt = now.Add(2 * time.Second)
+
+	// the orders are booked without being matched, as after a bad deploy
+	err := ob.do(context.Background(), func() error {
+		for _, o := range []*types.Order{&o1, &o2, &o3} {
+			err := ob.addOrder(o)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, _, crossed := ob.crossedPricePoints()
+		assert.True(t, crossed)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cross has been repaired once the command was run
+	var responses []*types.EngineResponse
+	err = ob.do(context.Background(), func() (err error) {
+		_, _, crossed := ob.crossedPricePoints()
+		assert.False(t, crossed)
+
+		responses, err = ob.uncross()
+		return err
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, len(responses))
+
+	orders, err := ob.GetAllOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o3.Hash, orders[0].Hash)
+	assert.Equal(t, units.Ethers(1), orders[0].FilledAmount)
+	assert.Equal(t, types.OrderStatusPartialFilled, orders[0].Status)
+}
+
+func TestUncrossTrades(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	now := time.Now()
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	o1.CreatedAt = now
+	o2, _ := factory2.NewBuyOrder(1e3+2, 2)
+	o2.CreatedAt = now.Add(time.Second)
+	o3, _ := factory1.NewSellOrder(1e3+1, 3)
+	o3.CreatedAt = now.Add(2 * time.Second)
+
+	// the event loop is paused so that the crossed orderbook is only repaired by uncross
+	resume, err := ob.pause(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resume()
+
+	for _, o := range []*types.Order{&o1, &o2, &o3} {
+		err := ob.addOrder(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	responses, err := ob.uncross()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(responses))
+
+	// o2 is matched by the older o1 at the price of o1
+	assert.Equal(t, o2.Hash, responses[0].HashID)
+	assert.Equal(t, "FULL", responses[0].Status)
+	assert.Equal(t, 1, len(responses[0].Matches))
+	assert.Equal(t, o1.Hash, responses[0].Matches[0].Order.Hash)
+	assert.Equal(t, types.OrderStatusFilled, responses[0].Matches[0].Order.Status)
+	assert.Equal(t, units.Ethers(1), responses[0].Matches[0].Trade.Amount)
+	assert.Equal(t, o1.PricePoint, responses[0].Matches[0].Trade.PricePoint)
+
+	// o3 is then matched by the rest of the older o2 at the price of o2
+	assert.Equal(t, o3.Hash, responses[1].HashID)
+	assert.Equal(t, "PARTIAL", responses[1].Status)
+	assert.Equal(t, 1, len(responses[1].Matches))
+	assert.Equal(t, o2.Hash, responses[1].Matches[0].Order.Hash)
+	assert.Equal(t, units.Ethers(1), responses[1].Matches[0].Trade.Amount)
+	assert.Equal(t, o2.PricePoint, responses[1].Matches[0].Trade.PricePoint)
+
+	_, _, crossed := ob.crossedPricePoints()
+	assert.False(t, crossed)
+
+	orders, err := ob.GetAllOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o3.Hash, orders[0].Hash)
+	assert.Equal(t, units.Ethers(1), orders[0].FilledAmount)
+}
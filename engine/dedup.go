@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DedupCacheSize is the number of order hashes remembered by the dedup cache of each orderbook
+var DedupCacheSize = 100000
+
+// DedupTTL is the time during which the dedup cache of each orderbook remembers an order hash
+var DedupTTL = time.Hour
+
+// dedupCache is a bounded set of the hashes of the orders recently received by an orderbook, so
+// that an order submitted twice is only matched once, eg. when a client retries an order that was
+// accepted by another API node. Hashes are forgotten after the TTL or when the cache is full,
+// oldest first. The unique index on the order hashes of the database is the durable backstop for
+// the hashes that were forgotten. The cache is not safe for concurrent use, it is only used by the
+// event loop of the orderbook.
+type dedupCache struct {
+	size     int
+	ttl      time.Duration
+	entries  *list.List
+	elements map[common.Hash]*list.Element
+}
+
+type dedupEntry struct {
+	hash common.Hash
+	seen time.Time
+}
+
+func newDedupCache(size int, ttl time.Duration) *dedupCache {
+	return &dedupCache{
+		size:     size,
+		ttl:      ttl,
+		entries:  list.New(),
+		elements: map[common.Hash]*list.Element{},
+	}
+}
+
+// add adds the hash of an order received at the given time to the cache. It returns false if the
+// hash was already in the cache, in which case the time it was first seen is kept.
+func (c *dedupCache) add(h common.Hash, now time.Time) bool {
+	if c == nil || c.size <= 0 {
+		return true
+	}
+
+	c.expire(now)
+	if _, ok := c.elements[h]; ok {
+		return false
+	}
+
+	c.elements[h] = c.entries.PushBack(&dedupEntry{hash: h, seen: now})
+	for c.entries.Len() > c.size {
+		c.remove(c.entries.Front())
+	}
+
+	return true
+}
+
+// expire removes the hashes seen more than the TTL before now
+func (c *dedupCache) expire(now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	for e := c.entries.Front(); e != nil; e = c.entries.Front() {
+		if now.Sub(e.Value.(*dedupEntry).seen) < c.ttl {
+			return
+		}
+
+		c.remove(e)
+	}
+}
+
+func (c *dedupCache) remove(e *list.Element) {
+	delete(c.elements, e.Value.(*dedupEntry).hash)
+	c.entries.Remove(e)
+}
+
+// len returns the number of hashes in the cache
+func (c *dedupCache) len() int {
+	return c.entries.Len()
+}
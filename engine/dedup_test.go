@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderDuplicates(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
+	defer teardown(e)
+
+	responses := []*types.EngineResponse{}
+	ob.do(context.Background(), func() error {
+		ob.publisher = func(res *types.EngineResponse) error {
+			responses = append(responses, res)
+			return nil
+		}
+
+		return nil
+	})
+
+	o, _ := factory1.NewSellOrder(1e3, 1e8)
+
+	// the same order is submitted concurrently, eg. by a client retrying through several nodes
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(o *types.Order) {
+			defer wg.Done()
+			e.newOrder(o, o.Hash)
+		}(o.Clone())
+	}
+
+	wg.Wait()
+
+	statuses := map[string]int{}
+	var booked *types.OrderBook
+	ob.do(context.Background(), func() error {
+		for _, res := range responses {
+			statuses[res.Status]++
+		}
+
+		booked = ob.getOrderBook(0)
+		return nil
+	})
+
+	// the order is booked exactly once, the duplicates get the current state of the order
+	assert.Equal(t, map[string]int{"NOMATCH": 1, "DUPLICATE": 49}, statuses)
+	assert.Equal(t, 1, len(booked.Asks))
+	assert.Equal(t, units.Ethers(1e8), booked.Asks[0].Amount)
+
+	for _, res := range responses {
+		assert.Equal(t, o.Hash, res.Order.Hash)
+	}
+}
+
+func TestDedupCache(t *testing.T) {
+	now := time.Now()
+	c := newDedupCache(2, time.Minute)
+	h1, h2, h3 := common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x3")
+
+	assert.True(t, c.add(h1, now))
+	assert.False(t, c.add(h1, now.Add(time.Second)))
+	assert.True(t, c.add(h2, now.Add(time.Second)))
+
+	// the oldest hash is forgotten when the cache is full
+	assert.True(t, c.add(h3, now.Add(2*time.Second)))
+	assert.Equal(t, 2, c.len())
+	assert.True(t, c.add(h1, now.Add(3*time.Second)))
+	assert.False(t, c.add(h3, now.Add(3*time.Second)))
+
+	// hashes are forgotten after the TTL
+	assert.True(t, c.add(h3, now.Add(3*time.Minute)))
+	assert.Equal(t, 1, c.len())
+
+	// a nil or empty cache never finds duplicates
+	var disabled *dedupCache
+	assert.True(t, disabled.add(h1, now))
+	assert.True(t, disabled.add(h1, now))
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// diffRingSize is the number of orderbook diffs kept in memory for each pair
+const diffRingSize = 1000
+
+// diffRing holds the last diffs of an orderbook so that clients that missed diffs can request
+// them instead of fetching a new snapshot. It is not safe for concurrent use, it is only used by
+// the event loop of the orderbook.
+type diffRing struct {
+	diffs []*types.OrderBookDiff
+	next  int
+}
+
+func newDiffRing(size int) *diffRing {
+	return &diffRing{diffs: make([]*types.OrderBookDiff, 0, size)}
+}
+
+// push adds a diff to the ring, replacing the oldest diff when the ring is full
+func (r *diffRing) push(d *types.OrderBookDiff) {
+	if len(r.diffs) < cap(r.diffs) {
+		r.diffs = append(r.diffs, d)
+		return
+	}
+
+	r.diffs[r.next] = d
+	r.next = (r.next + 1) % len(r.diffs)
+}
+
+// since returns the diffs following the given sequence in increasing sequence order. current is
+// the sequence of the orderbook. An error is returned when some of the diffs following the
+// sequence are not in the ring anymore, or when the sequence is ahead of the orderbook.
+func (r *diffRing) since(from, current uint64) ([]*types.OrderBookDiff, error) {
+	if from > current {
+		return nil, fmt.Errorf("Sequence %v is ahead of the orderbook sequence %v", from, current)
+	}
+
+	diffs := []*types.OrderBookDiff{}
+	if from == current {
+		return diffs, nil
+	}
+
+	// the diffs are ordered oldest first starting at index next
+	for i := 0; i < len(r.diffs); i++ {
+		d := r.diffs[(r.next+i)%len(r.diffs)]
+		if d.Sequence > from {
+			diffs = append(diffs, d)
+		}
+	}
+
+	if len(diffs) == 0 || diffs[0].Sequence != from+1 {
+		return nil, fmt.Errorf("Diffs following sequence %v are not available anymore", from)
+	}
+
+	return diffs, nil
+}
+
+// orderBookDiffs are the diffs produced by a change of the orderbook of a pair
+type orderBookDiffs struct {
+	pair  *types.Pair
+	diffs []*types.OrderBookDiff
+}
@@ -1,16 +1,21 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"math/big"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/redis"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -19,11 +24,19 @@ type Engine struct {
 	orderbooks   map[string]*OrderBook
 	redisConn    *redis.RedisConnection
 	rabbitMQConn *rabbitmq.Connection
+	journal      *journal
+	diffs        chan *orderBookDiffs
+	commandLog   *commandLog
+
+	// done is closed when the engine is closed, to stop its background routines
+	done     chan struct{}
+	routines sync.WaitGroup
 }
 
 var logger = utils.EngineLogger
 
-// NewEngine initializes the engine singleton instance
+// NewEngine initializes the engine singleton instance. The orderbooks are rebuilt in memory
+// from the snapshots and mutation logs stored in redis.
 func NewEngine(
 	redisConn *redis.RedisConnection,
 	rabbitMQConn *rabbitmq.Connection,
@@ -35,22 +48,302 @@ func NewEngine(
 		panic(err)
 	}
 
+	journal := newJournal(redisConn)
 	obs := map[string]*OrderBook{}
-	for _, p := range pairs {
+	for i := range pairs {
+		p := pairs[i]
 		ob := &OrderBook{
-			redisConn:    redisConn,
+			book:         newBook(),
+			journal:      journal,
+			diffs:        newDiffRing(diffRingSize),
+			dedup:        newDedupCache(DedupCacheSize, DedupTTL),
 			rabbitMQConn: rabbitMQConn,
 			pair:         &p,
-			mutex:        &sync.Mutex{},
+			commands:     make(chan *command, OrderQueueCapacity),
+			cancels:      make(chan *command, CancelQueueCapacity),
+			stopped:      make(chan struct{}),
+			stats:        newOrderBookStats(p.Name()),
+			breaker:      newCircuitBreaker(),
 		}
 
+		ob.generation = journal.generation()
+		err := journal.load(p.GetKVPrefix(), ob.book)
+		if err != nil {
+			panic(err)
+		}
+
+		ob.publishView()
+		ob.publishTicker()
 		obs[p.Code()] = ob
 	}
 
-	engine := &Engine{obs, redisConn, rabbitMQConn}
+	journal.start()
+	for _, ob := range obs {
+		go ob.run()
+	}
+
+	engine := &Engine{
+		orderbooks:   obs,
+		redisConn:    redisConn,
+		rabbitMQConn: rabbitMQConn,
+		journal:      journal,
+		done:         make(chan struct{}),
+	}
+
 	return engine
 }
 
+// Reconcile compares the orderbooks recovered from redis with the open orders and the pending
+// trades stored in the database, and corrects the orderbooks (see OrderBook.reconcile). The
+// orderbooks refuse new orders until they are reconciled, so Reconcile is meant to be called on
+// startup before orders are received. A summary of the discrepancies is logged for each pair.
+func (e *Engine) Reconcile(orderDao interfaces.OrderDao, tradeDao interfaces.TradeDao) error {
+	for _, ob := range e.orderbooks {
+		p := ob.pair
+		open, err := orderDao.GetRawOrderBook(p)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		pending, err := tradeDao.GetPendingByPairAddress(p.BaseTokenAddress, p.QuoteTokenAddress)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		committed, err := committedAmounts(tradeDao, pending)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		var r *reconciliation
+		err = ob.do(context.Background(), func() (err error) {
+			ob.record(&logEntry{Type: commandReconcile, Orders: open, AmountsByHash: committed})
+			r, err = ob.reconcile(open, committed)
+			return err
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		logger.Infof(
+			"Reconciled %v orderbook: %v pending trades (%v orders adjusted), %v orphaned orders removed, %v missing orders booked, %v orders updated, %v crossed orders matched",
+			p.Name(), len(pending), r.pending, r.orphaned, r.missing, r.updated, r.crossed,
+		)
+	}
+
+	return nil
+}
+
+// committedAmounts returns, for each maker order of the pending trades, the total amount of its
+// trades, as maker or taker, that were not rejected by the exchange contract nor failed to settle
+func committedAmounts(tradeDao interfaces.TradeDao, pending []*types.Trade) (map[common.Hash]*big.Int, error) {
+	committed := map[common.Hash]*big.Int{}
+	for _, t := range pending {
+		if committed[t.OrderHash] != nil {
+			continue
+		}
+
+		trades, err := tradeDao.GetByOrderHash(t.OrderHash)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		amount := big.NewInt(0)
+		for _, trade := range trades {
+			if trade.Status == types.TradeStatusError || trade.Status == types.TradeStatusInvalid || trade.Status == types.TradeStatusFailed {
+				continue
+			}
+
+			amount = math.Add(amount, trade.Amount)
+		}
+
+		committed[t.OrderHash] = amount
+	}
+
+	return committed, nil
+}
+
+// SnapshotOrderbooks starts a background routine that writes a snapshot of every orderbook to
+// redis at every tick of the given interval. Each snapshot replaces the mutation log of its
+// orderbook, which keeps the log short and the recovery fast.
+func (e *Engine) SnapshotOrderbooks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			e.Snapshot()
+		}
+	}()
+}
+
+// Snapshot queues a snapshot of every orderbook
+func (e *Engine) Snapshot() {
+	for _, ob := range e.orderbooks {
+		ob.do(context.Background(), func() error {
+			ob.snapshot()
+			return nil
+		})
+	}
+}
+
+// Close stops the expiry routines and the event loops of the orderbooks once their queued
+// commands have been run, then waits until the orderbook changes have been written to redis and
+// the commands to the command log, and stops writing them. The engine must not be used once
+// closed.
+func (e *Engine) Close() {
+	close(e.done)
+	e.routines.Wait()
+
+	for _, ob := range e.orderbooks {
+		ob.stop()
+	}
+
+	e.journal.close()
+	if e.commandLog != nil {
+		e.commandLog.close()
+	}
+}
+
+// SubscribeOrderBookDiffs starts publishing the diffs of the orderbooks to the given function.
+// The function is called from a single background routine, with the diffs of a pair in
+// sequence order. Diffs that are produced while the function runs are batched by pair.
+func (e *Engine) SubscribeOrderBookDiffs(fn func(p *types.Pair, diffs []*types.OrderBookDiff)) {
+	e.diffs = make(chan *orderBookDiffs, journalBufferSize)
+	for _, ob := range e.orderbooks {
+		ob.do(context.Background(), func() error {
+			ob.subscriber = e.diffs
+			return nil
+		})
+	}
+
+	go func() {
+		for d := range e.diffs {
+			batches := []*orderBookDiffs{d}
+			batched := map[string]*orderBookDiffs{d.pair.Code(): d}
+
+		drain:
+			for {
+				select {
+				case d := <-e.diffs:
+					b := batched[d.pair.Code()]
+					if b == nil {
+						batched[d.pair.Code()] = d
+						batches = append(batches, d)
+						continue
+					}
+
+					b.diffs = append(b.diffs, d.diffs...)
+				default:
+					break drain
+				}
+			}
+
+			for _, b := range batches {
+				fn(b.pair, b.diffs)
+			}
+		}
+	}()
+}
+
+// GetOrderBook returns the price levels of the orderbook of a pair along with the sequence of
+// the last diff they include. The levels are read from the last view published by the event loop
+// of the orderbook (see publishView), without waiting for the command being run, so that large
+// snapshots never delay the matching. They include the commands completed before GetOrderBook is
+// called.
+func (e *Engine) GetOrderBook(p *types.Pair) (*types.OrderBook, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	return ob.loadView().orderBook(0), nil
+}
+
+// getOrderBook returns the price levels of the orderbook, best priced first, and at most depth
+// levels on each side if depth is positive, along with the checksum of the orderbook (see
+// bookView.orderBook). It must be run by the event loop of the orderbook.
+func (ob *OrderBook) getOrderBook(depth int) *types.OrderBook {
+	return ob.currentView().orderBook(depth)
+}
+
+// checksum returns the checksum of the orderbook (see types.OrderBook.ComputeChecksum). It must
+// be run by the event loop of the orderbook.
+func (ob *OrderBook) checksum() uint32 {
+	return ob.currentView().checksum()
+}
+
+// GetOrderBookChecksum returns the checksum of the orderbook of a pair along with the sequence of
+// the last diff it includes. Like GetOrderBook, it reads the last view published by the event
+// loop of the orderbook.
+func (e *Engine) GetOrderBookChecksum(p *types.Pair) (uint64, uint32, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return 0, 0, errors.New("Orderbook error")
+	}
+
+	v := ob.loadView()
+	return v.sequence, v.checksum(), nil
+}
+
+// Stats returns the metrics of the orderbooks. The metrics are read without waiting for the
+// event loops of the orderbooks, so that they can be collected while the orderbooks are matched.
+func (e *Engine) Stats() *types.EngineStats {
+	stats := &types.EngineStats{Pairs: map[string]*types.PairStats{}}
+	for _, ob := range e.orderbooks {
+		s := ob.stats.stats(len(ob.commands), len(ob.cancels))
+		stats.Pairs[s.PairName] = s
+	}
+
+	return stats
+}
+
+// GetOrderBookDiffs returns the diffs of the orderbook of a pair that follow the given sequence.
+// An error is returned if some of these diffs are not kept in memory anymore, in which case
+// the client has to fetch the orderbook again.
+func (e *Engine) GetOrderBookDiffs(p *types.Pair, from uint64) ([]*types.OrderBookDiff, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	var diffs []*types.OrderBookDiff
+	err := ob.do(context.Background(), func() (err error) {
+		diffs, err = ob.diffs.since(from, ob.book.sequence)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return diffs, nil
+}
+
+// PruneExpiredOrders removes the orders that have expired at the given time from all
+// the orderbooks. An ORDER_EXPIRED engine response is published for each removed order.
+func (e *Engine) PruneExpiredOrders(now time.Time) error {
+	for _, ob := range e.orderbooks {
+		err := ob.do(context.Background(), func() error {
+			ob.record(&logEntry{Type: commandPruneOrders, Timestamp: now})
+			_, err := ob.pruneOrders(now, nil)
+			return err
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
 // HandleOrders parses incoming rabbitmq order messages and redirects them to the appropriate
 // engine function
 func (e *Engine) HandleOrders(msg *rabbitmq.Message) error {
@@ -90,7 +383,11 @@ func (e *Engine) addOrder(o *types.Order) error {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.addOrder(o)
+	err = ob.do(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandAddOrder, Orders: []*types.Order{o}})
+		return ob.addOrder(o)
+	})
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -111,7 +408,20 @@ func (e *Engine) newOrder(o *types.Order, hashID common.Hash) error {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.newOrder(o, hashID)
+	// queued orders are always matched, even if they wait for the orders queued before them, but
+	// orders submitted while the queue is full are rejected right away
+	received := time.Now()
+	err = ob.submit(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandNewOrder, Orders: []*types.Order{o}, HashID: hashID})
+		_, err := ob.newOrder(o, hashID, received)
+		return err
+	})
+
+	if err == types.ErrEngineBusy {
+		res := rejectOrder(&types.EngineResponse{HashID: hashID, Order: o}, types.RejectReasonEngineBusy)
+		err = ob.publish(res)
+	}
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -120,6 +430,66 @@ func (e *Engine) newOrder(o *types.Order, hashID common.Hash) error {
 	return nil
 }
 
+// NewOrders matches a batch of new orders. The orders of each pair are run by a single command of
+// the event loop of their orderbook, in the order of the batch, so that no other order of the pair
+// is matched in between. The pairs are run one after the other, a batch spanning several pairs
+// being only atomic per pair. The engine responses are returned in the order of the batch, the
+// responses of the orders of the pairs that could not be run being nil, eg. when the order queue
+// of their orderbook is full, in which case types.ErrEngineBusy is returned.
+func (e *Engine) NewOrders(orders []*types.Order) ([]*types.EngineResponse, error) {
+	codes := []string{}
+	batches := map[string][]int{}
+	for i, o := range orders {
+		code, err := o.PairCode()
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if batches[code] == nil {
+			codes = append(codes, code)
+		}
+
+		batches[code] = append(batches[code], i)
+	}
+
+	responses := make([]*types.EngineResponse, len(orders))
+	received := time.Now()
+
+	var batchErr error
+	for _, code := range codes {
+		ob := e.orderbooks[code]
+		if ob == nil {
+			batchErr = errors.New("Orderbook error")
+			logger.Error(batchErr)
+			continue
+		}
+
+		// each order is logged as a new order, the orders being replayed back-to-back as well
+		err := ob.submit(context.Background(), func() error {
+			for _, i := range batches[code] {
+				o := orders[i]
+				ob.record(&logEntry{Type: commandNewOrder, Orders: []*types.Order{o}, HashID: o.Hash})
+				res, err := ob.newOrder(o, o.Hash, received)
+				if err != nil {
+					return err
+				}
+
+				responses[i] = res.Clone()
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			logger.Error(err)
+			batchErr = err
+		}
+	}
+
+	return responses, batchErr
+}
+
 func (e *Engine) RecoverOrders(matches []*types.OrderTradePair) error {
 	//TODO for now we assume all order/trades have the same token pair
 	o := matches[0].Order
@@ -134,7 +504,17 @@ func (e *Engine) RecoverOrders(matches []*types.OrderTradePair) error {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.RecoverOrders(matches)
+	err = ob.do(context.Background(), func() error {
+		entry := &logEntry{Type: commandRecoverOrders}
+		for _, m := range matches {
+			entry.Orders = append(entry.Orders, m.Order)
+			entry.Amounts = append(entry.Amounts, m.Trade.Amount)
+		}
+
+		ob.record(entry)
+		return ob.RecoverOrders(matches)
+	})
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -143,7 +523,103 @@ func (e *Engine) RecoverOrders(matches []*types.OrderTradePair) error {
 	return nil
 }
 
-//Cancel order is currently not sent through a queue. Not sure i agree with this mechanism
+// RestoreFailedTrade gives back the amount of a trade that failed to settle to its maker and taker
+// orders, as recorded by the order service before the amount is given back (see restoreFill). The
+// orders added back to the orderbook or still resting in it are returned with their updated filled
+// amount. Restored orders crossing the orderbook are matched like crossed orders (see uncross).
+func (e *Engine) RestoreFailedTrade(orders []*types.Order, amount *big.Int) ([]*types.Order, error) {
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	code, err := orders[0].PairCode()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob := e.orderbooks[code]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	restored := []*types.Order{}
+	err = ob.do(context.Background(), func() error {
+		entry := &logEntry{Type: commandRestoreFills}
+		for _, o := range orders {
+			entry.Orders = append(entry.Orders, o)
+			entry.Amounts = append(entry.Amounts, amount)
+		}
+
+		ob.record(entry)
+		for _, o := range orders {
+			r, err := ob.restoreFill(o, amount)
+			if err != nil {
+				return err
+			}
+
+			if r != nil {
+				restored = append(restored, r)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// GetOrderPosition returns the position of a resting order in its price level, 1 for the first
+// order of the level, and the amount displayed by the orders ahead of it (see book.position).
+// The position is read by the event loop of the orderbook of the order, so that it is consistent
+// with the orderbook at the time it is read. types.ErrOrderNotResting is returned if the order is
+// not resting in any orderbook.
+func (e *Engine) GetOrderPosition(h common.Hash) (int, *big.Int, error) {
+	for _, ob := range e.orderbooks {
+		var position int
+		var amountAhead *big.Int
+		var found bool
+
+		err := ob.do(context.Background(), func() error {
+			position, amountAhead, found = ob.book.position(h)
+			return nil
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return 0, nil, err
+		}
+
+		if found {
+			return position, amountAhead, nil
+		}
+	}
+
+	return 0, nil, types.ErrOrderNotResting
+}
+
+// UpdatePair replaces the pair settings used by the orderbook of the pair, for example
+// after the pair has been listed or delisted
+func (e *Engine) UpdatePair(p *types.Pair) error {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return errors.New("Orderbook error")
+	}
+
+	return ob.do(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandUpdatePair, Pair: p})
+		ob.setPair(p)
+		return nil
+	})
+}
+
+// CancelOrder removes an order from its orderbook and returns its CANCELLED engine response.
+// Cancellations are run before the orders queued for the orderbook (see doPriority).
 func (e *Engine) CancelOrder(o *types.Order) (*types.EngineResponse, error) {
 	code, err := o.PairCode()
 	if err != nil {
@@ -156,7 +632,97 @@ func (e *Engine) CancelOrder(o *types.Order) (*types.EngineResponse, error) {
 		return nil, errors.New("Orderbook error")
 	}
 
-	res, err := ob.CancelOrder(o)
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	var res *types.EngineResponse
+	err = ob.doPriority(ctx, func() (err error) {
+		ob.record(&logEntry{Type: commandCancelOrder, Orders: []*types.Order{o}})
+		res, err = ob.CancelOrder(o)
+		return err
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ReplaceOrder atomically cancels an order resting in the orderbook and matches its replacement.
+// The replacement is published like a new order under hashID and the CANCELLED engine response
+// of the replaced order is returned. Both orders must belong to the same pair. The replacement is
+// a new order: types.ErrEngineBusy is returned, the old order being left in place, if the order
+// queue of the orderbook is full.
+func (e *Engine) ReplaceOrder(old, o *types.Order, hashID common.Hash) (*types.EngineResponse, error) {
+	code, err := o.PairCode()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	oldCode, err := old.PairCode()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if code != oldCode {
+		return nil, errors.New("Replacement order pair does not match")
+	}
+
+	ob := e.orderbooks[code]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	// the replaced order response is returned along with the error if the replacement was matched
+	// but its response could not be published
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	var res *types.EngineResponse
+	err = ob.submit(ctx, func() (err error) {
+		ob.record(&logEntry{Type: commandReplaceOrder, Orders: []*types.Order{old, o}, HashID: hashID})
+		res, err = ob.replaceOrder(old, o, hashID)
+		return err
+	})
+
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return res, err
+}
+
+// ReduceOrder reduces the unfilled amount of an order resting in its orderbook to the given amount,
+// without changing its position in its price level (see OrderBook.reduceOrder). Like
+// cancellations, reductions are run before the orders queued for the orderbook.
+// types.ErrOrderNotResting is returned if the order is not resting in the orderbook and
+// types.ErrReduceAmountNotLower if the amount is not lower than its unfilled amount.
+func (e *Engine) ReduceOrder(o *types.Order, amount *big.Int) (*types.EngineResponse, error) {
+	code, err := o.PairCode()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob := e.orderbooks[code]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	var res *types.EngineResponse
+	err = ob.doPriority(ctx, func() (err error) {
+		ob.record(&logEntry{Type: commandReduceOrder, Orders: []*types.Order{o}, Amounts: []*big.Int{amount}})
+		res, err = ob.reduceOrder(o, amount)
+		return err
+	})
+
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -165,6 +731,118 @@ func (e *Engine) CancelOrder(o *types.Order) (*types.EngineResponse, error) {
 	return res, nil
 }
 
+// CancelAllOrders removes all the orders of the maker from the orderbooks, or only from the
+// orderbook of the given pair if pairName is not empty. The event loops of all the affected
+// orderbooks are paused until every order has been removed so that none of the maker orders can
+// be matched in the meantime. A CANCELLED engine response is returned for each removed order.
+func (e *Engine) CancelAllOrders(maker common.Address, pairName string) ([]*types.EngineResponse, error) {
+	// orderbooks are keyed by pair code, which starts with the pair name
+	codes := []string{}
+	for code := range e.orderbooks {
+		if pairName == "" || strings.HasPrefix(code, pairName+"::") {
+			codes = append(codes, code)
+		}
+	}
+
+	if len(codes) == 0 {
+		return nil, errors.New("Orderbook error")
+	}
+
+	orders, err := e.removeMakerOrders(maker, codes, nil)
+
+	responses := []*types.EngineResponse{}
+	for _, o := range orders {
+		status := "CANCELLED"
+		if o.Status == types.OrderStatusStop {
+			status = "STOP_CANCELLED"
+		}
+
+		o.Status = "CANCELLED"
+		responses = append(responses, &types.EngineResponse{
+			HashID: o.Hash,
+			Status: status,
+			Order:  o,
+		})
+	}
+
+	return responses, err
+}
+
+// CancelOrdersForAddress removes all the resting orders of an address from the orderbooks, or
+// only from the orderbook of the given pair if p is not nil, in a single pass per orderbook (see
+// CancelAllOrders). The removed orders are returned as they were in the orderbooks: their filled
+// amount includes all the matches made before the orderbooks were paused and their status is
+// still OPEN, PARTIAL_FILLED or STOP, dormant stop orders having no locked balance. Orders filled
+// before the orderbooks were paused are no longer resting and are never returned, their fills
+// being reported by their own engine responses.
+func (e *Engine) CancelOrdersForAddress(addr common.Address, p *types.Pair) ([]*types.Order, error) {
+	codes := []string{}
+	if p == nil {
+		for code := range e.orderbooks {
+			codes = append(codes, code)
+		}
+	} else if e.orderbooks[p.Code()] != nil {
+		codes = append(codes, p.Code())
+	}
+
+	if len(codes) == 0 {
+		return nil, errors.New("Orderbook error")
+	}
+
+	return e.removeMakerOrders(addr, codes, nil)
+}
+
+// CancelOrdersByHash removes the resting and stop orders of an address with the given hashes from
+// the orderbooks, in a single pass per orderbook like CancelOrdersForAddress. The orders of other
+// addresses and the hashes of orders that are no longer resting are ignored.
+func (e *Engine) CancelOrdersByHash(addr common.Address, hashes []common.Hash) ([]*types.Order, error) {
+	if len(hashes) == 0 {
+		return []*types.Order{}, nil
+	}
+
+	codes := []string{}
+	for code := range e.orderbooks {
+		codes = append(codes, code)
+	}
+
+	return e.removeMakerOrders(addr, codes, hashes)
+}
+
+// removeMakerOrders pauses the orderbooks with the given codes and removes all the orders of the
+// maker from them, or only the orders with the given hashes if hashes is not nil. The orders
+// removed before an error occurred are returned with it.
+func (e *Engine) removeMakerOrders(maker common.Address, codes []string, hashes []common.Hash) ([]*types.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	// orderbooks are always paused in the same order to avoid deadlocks between
+	// concurrent cancellations
+	sort.Strings(codes)
+	for _, code := range codes {
+		resume, err := e.orderbooks[code].pause(ctx)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		defer resume()
+	}
+
+	removed := []*types.Order{}
+	for _, code := range codes {
+		ob := e.orderbooks[code]
+		ob.record(&logEntry{Type: commandRemoveMakerOrders, Maker: &maker, Hashes: hashes})
+		orders, err := ob.removeMakerOrders(maker, hashes)
+		removed = append(removed, orders...)
+		if err != nil {
+			logger.Error(err)
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
 func (e *Engine) DeleteOrders(orders ...types.Order) error {
 	//we assume all the orders correspond to the same pair
 	code, err := orders[0].PairCode()
@@ -178,7 +856,16 @@ func (e *Engine) DeleteOrders(orders ...types.Order) error {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.deleteOrders(orders...)
+	err = ob.do(context.Background(), func() error {
+		entry := &logEntry{Type: commandDeleteOrders}
+		for i := range orders {
+			entry.Orders = append(entry.Orders, &orders[i])
+		}
+
+		ob.record(entry)
+		return ob.deleteOrders(orders...)
+	})
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -200,7 +887,11 @@ func (e *Engine) DeleteOrder(o *types.Order) error {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.deleteOrder(o)
+	err = ob.do(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandDeleteOrders, Orders: []*types.Order{o}})
+		return ob.deleteOrder(o)
+	})
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -222,7 +913,11 @@ func (e *Engine) CancelTrades(orders []*types.Order, amounts []*big.Int) error {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.CancelTrades(orders, amounts)
+	err = ob.do(context.Background(), func() error {
+		ob.record(&logEntry{Type: commandCancelTrades, Orders: orders, Amounts: amounts})
+		return ob.CancelTrades(orders, amounts)
+	})
+
 	if err != nil {
 		logger.Error(err)
 		return err
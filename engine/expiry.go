@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// expiryWheelSize is the number of one second slots of the expiry wheels, orders expiring later
+// than one turn of the wheel wait in their slot for the following turns
+const expiryWheelSize = 3600
+
+// expiryWheel is a timer wheel holding the expiry of the orders resting in an orderbook, so
+// that the orders that have expired are found without reading the other orders. Each slot holds
+// the orders expiring in a second, modulo the size of the wheel. The wheel is part of the book
+// and is rebuilt with it on restart.
+type expiryWheel struct {
+	slots  []map[common.Hash]int64
+	slotOf map[common.Hash]int
+
+	// next is the first second whose slot has not been checked yet
+	next int64
+}
+
+func newExpiryWheel() *expiryWheel {
+	w := &expiryWheel{
+		slots:  make([]map[common.Hash]int64, expiryWheelSize),
+		slotOf: map[common.Hash]int{},
+	}
+
+	for i := range w.slots {
+		w.slots[i] = map[common.Hash]int64{}
+	}
+
+	return w
+}
+
+// add adds an order expiring at the given unix time to the wheel. Orders whose expiry second has
+// already been checked are added to the next slot to check.
+func (w *expiryWheel) add(h common.Hash, expires int64) {
+	if _, ok := w.slotOf[h]; ok {
+		return
+	}
+
+	second := expires
+	if second < w.next {
+		second = w.next
+	}
+
+	i := expirySlot(second)
+	w.slots[i][h] = expires
+	w.slotOf[h] = i
+}
+
+// remove removes an order from the wheel
+func (w *expiryWheel) remove(h common.Hash) {
+	i, ok := w.slotOf[h]
+	if !ok {
+		return
+	}
+
+	delete(w.slots[i], h)
+	delete(w.slotOf, h)
+}
+
+// expired returns the hashes of the orders of the wheel that have expired at the given unix time,
+// sorted by hash, and advances the wheel to that time. Only the slots of the seconds elapsed since
+// the last call are read. The expired orders stay in the wheel until they are removed.
+func (w *expiryWheel) expired(now int64) []common.Hash {
+	from := w.next
+	if now-from > expiryWheelSize {
+		from = now - expiryWheelSize
+	}
+
+	hashes := []common.Hash{}
+	for s := from; s < now; s++ {
+		for h, expires := range w.slots[expirySlot(s)] {
+			if expires < now {
+				hashes = append(hashes, h)
+			}
+		}
+	}
+
+	if now > w.next {
+		w.next = now
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].Hex() < hashes[j].Hex()
+	})
+
+	return hashes
+}
+
+// expirySlot returns the slot of the expiry wheels holding the orders expiring in a second
+func expirySlot(second int64) int {
+	return int((second%expiryWheelSize + expiryWheelSize) % expiryWheelSize)
+}
+
+// ExpireOrders starts a background routine per orderbook that removes the orders of the
+// orderbook as soon as they expire, at every tick of the given interval, even if no other
+// command is run by the orderbook (see OrderBook.expireOrders). The routines are stopped when
+// the engine is closed.
+func (e *Engine) ExpireOrders(interval time.Duration) {
+	for _, ob := range e.orderbooks {
+		ob := ob
+		e.routines.Add(1)
+
+		go func() {
+			defer e.routines.Done()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-e.done:
+					return
+				case <-ticker.C:
+				}
+
+				err := ob.do(context.Background(), func() error {
+					now := time.Now()
+					hashes := ob.book.expiries.expired(now.Unix())
+					if len(hashes) == 0 {
+						return nil
+					}
+
+					ob.record(&logEntry{Type: commandExpireOrders, Timestamp: now})
+					_, err := ob.expireOrders(now, hashes)
+					return err
+				})
+
+				if err != nil {
+					logger.Error(err)
+				}
+			}
+		}()
+	}
+}
+
+// expireOrders removes the orders with the given hashes that have expired at the given time from
+// the orderbook, and publishes an ORDER_EXPIRED engine response for each of them. The engine
+// responses are returned. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) expireOrders(now time.Time, hashes []common.Hash) ([]*types.EngineResponse, error) {
+	responses := []*types.EngineResponse{}
+	for _, h := range hashes {
+		o := ob.book.orders[h]
+		if o == nil || !o.IsExpired(now) {
+			continue
+		}
+
+		res, err := ob.expireOrder(o.Clone())
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		responses = append(responses, res)
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiryWheel(t *testing.T) {
+	h1 := common.BigToHash(big.NewInt(1))
+	h2 := common.BigToHash(big.NewInt(2))
+	h3 := common.BigToHash(big.NewInt(3))
+	h4 := common.BigToHash(big.NewInt(4))
+
+	w := newExpiryWheel()
+	w.add(h1, 1000)
+	w.add(h2, 1000+expiryWheelSize)
+	w.add(h3, 1005)
+	w.add(h4, 1002)
+
+	// orders expire once their expiry second is over
+	assert.Equal(t, []common.Hash{}, w.expired(1000))
+	assert.Equal(t, []common.Hash{h1}, w.expired(1001))
+
+	w.remove(h1)
+	w.remove(h4)
+	assert.Equal(t, []common.Hash{}, w.expired(1003))
+
+	// orders added after their expiry second was checked are found by the next check
+	w.add(h4, 1001)
+	assert.Equal(t, []common.Hash{h3, h4}, w.expired(1006))
+
+	// orders expiring after a turn of the wheel wait for their turn
+	w.remove(h3)
+	w.remove(h4)
+	assert.Equal(t, []common.Hash{}, w.expired(1000+expiryWheelSize))
+	assert.Equal(t, []common.Hash{h2}, w.expired(1001+expiryWheelSize))
+}
+
+func TestExpireIdleOrders(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
+	defer teardown(e)
+
+	expired := make(chan *types.EngineResponse, 1)
+	ob.do(context.Background(), func() error {
+		ob.publisher = func(res *types.EngineResponse) error {
+			if res.Status == "ORDER_EXPIRED" {
+				expired <- res
+			}
+
+			return nil
+		}
+
+		return nil
+	})
+
+	expires := time.Now().Unix() + 2
+	factory1.Params.Expires = big.NewInt(expires)
+	o, _ := factory1.NewSellOrder(1e3, 1)
+	e.addOrder(&o)
+
+	// the expiry wheel is rebuilt with the orderbook on restart
+	b := newBook()
+	b.restore(ob.book.snapshot())
+	assert.Equal(t, []common.Hash{o.Hash}, b.expiries.expired(expires+1))
+
+	// nothing else happens on the pair until the order expires
+	e.ExpireOrders(100 * time.Millisecond)
+
+	select {
+	case res := <-expired:
+		assert.Equal(t, o.Hash, res.HashID)
+		assert.Equal(t, types.OrderStatusExpired, res.Order.Status)
+		assert.True(t, time.Now().Unix() > expires)
+		assert.True(t, time.Now().Before(time.Unix(expires+2, 0)))
+	case <-time.After(5 * time.Second):
+		t.Fatal("The order was not expired")
+	}
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+}
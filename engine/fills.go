@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DebugFills enables the check of the fills of every matched order: the amounts and token amounts
+// of the fills of an order must never exceed the order amounts and must be equal to them once the
+// order is entirely filled. A fill failing the check makes the matching of the order fail.
+var DebugFills = false
+
+// fillTotals are the sums of the amounts and token amounts of the fills of an order
+type fillTotals struct {
+	amount *big.Int
+	sell   *big.Int
+	buy    *big.Int
+}
+
+// checkFill adds a fill to the fill totals of an order and checks them against the order
+// amounts. The fills matched before the order was first checked, for example before a restart,
+// are accounted at once from the filled amount of the order.
+func (ob *OrderBook) checkFill(o *types.Order, filledBefore, amount *big.Int) error {
+	if ob.fills == nil {
+		ob.fills = map[common.Hash]*fillTotals{}
+	}
+
+	// the totals are reset when fills were cancelled since the last check
+	t := ob.fills[o.Hash]
+	if t == nil || t.amount.Cmp(filledBefore) != 0 {
+		sell, buy := o.FillAmounts(big.NewInt(0), filledBefore)
+		t = &fillTotals{filledBefore, sell, buy}
+	}
+
+	sell, buy := o.FillAmounts(filledBefore, amount)
+	t = &fillTotals{math.Add(t.amount, amount), math.Add(t.sell, sell), math.Add(t.buy, buy)}
+
+	if t.amount.Cmp(o.Amount) > 0 || t.sell.Cmp(o.SellAmount) > 0 || t.buy.Cmp(o.BuyAmount) > 0 {
+		return fmt.Errorf("Fills of order %v exceed the order amounts", o.Hash.Hex())
+	}
+
+	if t.amount.Cmp(o.Amount) == 0 {
+		delete(ob.fills, o.Hash)
+		if t.sell.Cmp(o.SellAmount) != 0 || t.buy.Cmp(o.BuyAmount) != 0 {
+			return fmt.Errorf("Fills of filled order %v do not sum up to the order amounts", o.Hash.Hex())
+		}
+
+		return nil
+	}
+
+	ob.fills[o.Hash] = t
+	return nil
+}
+
+// filledAmount returns a copy of the filled amount of an order, or zero if it is not set
+func filledAmount(o *types.Order) *big.Int {
+	if o.FilledAmount == nil {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Set(o.FilledAmount)
+}
@@ -0,0 +1,241 @@
+package engine
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/redis"
+)
+
+// journalBufferSize is the number of writes that can be queued before the matching waits for
+// the journal to catch up
+const journalBufferSize = 1 << 16
+
+// ReconnectInterval is the time waited between two attempts to dial redis again once the
+// connection of the journal is lost
+var ReconnectInterval = time.Second
+
+// journal persists the orderbooks to redis in the background so that a command never waits for
+// its own writes. Each orderbook is stored as its last snapshot and an append-only log of the
+// mutations applied since the snapshot. Writes are applied in the order in which they are queued
+// by a single goroutine, which is the only user of the redis connection once started. When the
+// connection is lost, the journal dials redis again and retries the write. Other processes may
+// have changed the journal while the connection was lost, so the books loaded before the
+// connection was dialed again are stale. The next command of an orderbook waits for the writes
+// of the previous one and its book is loaded again if the connection was dialed again meanwhile
+// (see OrderBook.refresh).
+type journal struct {
+	redisConn *redis.RedisConnection
+	writes    chan *journalWrite
+	done      chan struct{}
+
+	// pending is the number of mutations and snapshots queued and not written yet
+	pending int64
+}
+
+// journalWrite is either a mutation appended to the log of an orderbook, a snapshot that
+// replaces the snapshot and the log of an orderbook, a request to load the book of an orderbook
+// again once the previous writes are written (see reload), or a barrier released once the
+// previous writes are written (see flush)
+type journalWrite struct {
+	prefix   string
+	mutation *mutation
+	snapshot *bookSnapshot
+	reload   chan<- *journalLoad
+	flushed  chan<- uint64
+}
+
+// journalLoad is a book loaded again by the journal, at the given connection generation
+type journalLoad struct {
+	book       *book
+	generation uint64
+	err        error
+}
+
+func newJournal(redisConn *redis.RedisConnection) *journal {
+	return &journal{
+		redisConn: redisConn,
+		writes:    make(chan *journalWrite, journalBufferSize),
+		done:      make(chan struct{}),
+	}
+}
+
+// snapshotKey returns the redis key of the snapshot of the orderbook with the given prefix
+func snapshotKey(prefix string) string {
+	return prefix + "::snapshot"
+}
+
+// logKey returns the redis key of the mutation log of the orderbook with the given prefix
+func logKey(prefix string) string {
+	return prefix + "::log"
+}
+
+// start starts writing the queued writes to redis
+func (j *journal) start() {
+	go func() {
+		defer close(j.done)
+
+		for w := range j.writes {
+			if w.reload != nil {
+				w.reload <- j.loadAgain(w.prefix)
+				continue
+			}
+
+			if w.flushed != nil {
+				w.flushed <- j.generation()
+				continue
+			}
+
+			err := j.write(w)
+			for err != nil && j.redisConn.Err() != nil {
+				logger.Error(err)
+				j.reconnect()
+				err = j.write(w)
+			}
+
+			if err != nil {
+				logger.Error(err)
+			}
+
+			atomic.AddInt64(&j.pending, -1)
+		}
+	}()
+}
+
+// loadAgain loads the book of the orderbook with the given prefix in a new book, dialing redis
+// again if the connection is lost while the book is read
+func (j *journal) loadAgain(prefix string) *journalLoad {
+	for {
+		l := &journalLoad{book: newBook(), generation: j.generation()}
+		l.err = j.load(prefix, l.book)
+		if l.err == nil || j.redisConn.Err() == nil {
+			return l
+		}
+
+		logger.Error(l.err)
+		j.reconnect()
+	}
+}
+
+// reconnect dials redis again until it succeeds
+func (j *journal) reconnect() {
+	for {
+		err := j.redisConn.Reconnect()
+		if err == nil {
+			logger.Warning("The redis connection of the journal was lost and dialed again, the orderbooks are loaded again")
+			return
+		}
+
+		logger.Error(err)
+		time.Sleep(ReconnectInterval)
+	}
+}
+
+// generation returns the generation of the redis connection of the journal, which changes every
+// time the connection is dialed again
+func (j *journal) generation() uint64 {
+	return j.redisConn.Generation()
+}
+
+// close waits until all the queued writes have been written and stops the journal
+func (j *journal) close() {
+	close(j.writes)
+	<-j.done
+}
+
+func (j *journal) append(prefix string, m *mutation) {
+	atomic.AddInt64(&j.pending, 1)
+	j.writes <- &journalWrite{prefix: prefix, mutation: m}
+}
+
+func (j *journal) snapshot(prefix string, s *bookSnapshot) {
+	atomic.AddInt64(&j.pending, 1)
+	j.writes <- &journalWrite{prefix: prefix, snapshot: s}
+}
+
+// flush waits until the writes queued before have been written and returns the generation of the
+// redis connection they were written with, so that a connection lost while writing them is seen
+// by the caller. It returns right away if no write is pending.
+func (j *journal) flush() uint64 {
+	if atomic.LoadInt64(&j.pending) == 0 {
+		return j.generation()
+	}
+
+	flushed := make(chan uint64, 1)
+	j.writes <- &journalWrite{flushed: flushed}
+	return <-flushed
+}
+
+// reload loads the book of the orderbook with the given prefix again, from its last snapshot and
+// its log, once the writes queued before have been written. The generation of the connection the
+// book was read with is returned along with the book.
+func (j *journal) reload(prefix string) (*book, uint64, error) {
+	loaded := make(chan *journalLoad, 1)
+	j.writes <- &journalWrite{prefix: prefix, reload: loaded}
+
+	l := <-loaded
+	return l.book, l.generation, l.err
+}
+
+func (j *journal) write(w *journalWrite) error {
+	if w.snapshot != nil {
+		bytes, err := json.Marshal(w.snapshot)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		// the mutations queued before the snapshot are included in it
+		return j.redisConn.SetAndDel(snapshotKey(w.prefix), string(bytes), logKey(w.prefix))
+	}
+
+	bytes, err := json.Marshal(w.mutation)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return j.redisConn.RPush(logKey(w.prefix), string(bytes))
+}
+
+// load reads the last snapshot and the mutation log of the orderbook with the given prefix and
+// rebuilds its book. It must be called before the journal is started, or by the journal itself
+// (see reload).
+func (j *journal) load(prefix string, b *book) error {
+	if j.redisConn.Exists(snapshotKey(prefix)) {
+		serialized, err := j.redisConn.GetValue(snapshotKey(prefix))
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		s := &bookSnapshot{}
+		err = json.Unmarshal([]byte(serialized), s)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		b.restore(s)
+	}
+
+	entries, err := j.redisConn.LRange(logKey(prefix), 0, -1)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for _, e := range entries {
+		m := &mutation{}
+		err := json.Unmarshal([]byte(e), m)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		b.apply(m)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/redis"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/alicebob/miniredis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshAfterReconnect(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn := redis.NewRedisConnection("redis://" + s.Addr())
+	j := newJournal(conn)
+	j.start()
+	defer j.close()
+
+	f := newBenchmarkOrders()
+	ob := newMemoryOrderbook()
+	ob.journal = j
+	ob.generation = j.generation()
+	prefix := ob.pair.GetKVPrefix()
+
+	first := f.newOrder(types.OrderSideSell, 100, 1)
+	ob.addOrder(first)
+
+	// the book is not loaded again as long as the connection is not lost
+	ob.refresh()
+	assert.NotNil(t, ob.book.orders[first.Hash])
+
+	// the first order is written before the connection is lost
+	_, _, err = j.reload(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another process replaces the journal of the orderbook while the connection is lost
+	s.Close()
+	err = s.Restart()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := newMemoryOrderbook()
+	second := f.newOrder(types.OrderSideSell, 101, 1)
+	other.addOrder(second)
+
+	bytes, err := json.Marshal(other.book.snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = redis.NewRedisConnection("redis://"+s.Addr()).SetAndDel(snapshotKey(prefix), string(bytes), logKey(prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the write that finds the connection lost is written once redis is dialed again
+	third := f.newOrder(types.OrderSideSell, 102, 1)
+	ob.addOrder(third)
+
+	ob.refresh()
+	assert.Equal(t, uint64(1), ob.generation)
+	assert.Nil(t, ob.book.orders[first.Hash])
+	assert.NotNil(t, ob.book.orders[second.Hash])
+	assert.NotNil(t, ob.book.orders[third.Hash])
+
+	// the best pricepoints are read from the loaded book
+	key, _ := third.GetOBKeys()
+	best, _ := ob.book.pricePoints[key].min()
+	worst, _ := ob.book.pricePoints[key].max()
+	assert.Equal(t, int64(101), best)
+	assert.Equal(t, int64(102), worst)
+}
@@ -0,0 +1,141 @@
+package engine
+
+import "math/big"
+
+// levelNode is a node of a persistent treap of the price levels of a side of the orderbook,
+// keyed by pricepoint and holding the total displayed amount of the level. Nodes are never
+// modified once they are reachable from a root: a change copies the nodes on the path from the
+// root to the changed level and returns a new root, in logarithmic time, while the previous root
+// still holds the levels as they were. A root can then be read from any goroutine while the event
+// loop of the orderbook keeps changing the levels (see bookView). The priority of a node is drawn
+// from its pricepoint, which makes the shape of a treap depend only on the levels it holds.
+type levelNode struct {
+	pricePoint int64
+	amount     *big.Int
+	priority   uint64
+	left       *levelNode
+	right      *levelNode
+}
+
+// levelPriority returns the treap priority of a pricepoint (splitmix64)
+func levelPriority(pp int64) uint64 {
+	z := uint64(pp) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// putLevel returns the root of a treap where the level of the pricepoint holds amount, the level
+// being removed if amount is zero. The amount is stored as is and must never be modified.
+func putLevel(n *levelNode, pp int64, amount *big.Int) *levelNode {
+	if amount.Sign() == 0 {
+		return removeLevel(n, pp)
+	}
+
+	if n == nil {
+		return &levelNode{pricePoint: pp, amount: amount, priority: levelPriority(pp)}
+	}
+
+	c := *n
+	switch {
+	case pp < n.pricePoint:
+		c.left = putLevel(n.left, pp, amount)
+		// the children returned by putLevel are new nodes, which can still be rotated in place
+		if c.left.priority > c.priority {
+			l := c.left
+			c.left, l.right = l.right, &c
+			return l
+		}
+	case pp > n.pricePoint:
+		c.right = putLevel(n.right, pp, amount)
+		if c.right.priority > c.priority {
+			r := c.right
+			c.right, r.left = r.left, &c
+			return r
+		}
+	default:
+		c.amount = amount
+	}
+
+	return &c
+}
+
+// removeLevel returns the root of a treap without the level of the pricepoint
+func removeLevel(n *levelNode, pp int64) *levelNode {
+	if n == nil {
+		return nil
+	}
+
+	switch {
+	case pp < n.pricePoint:
+		left := removeLevel(n.left, pp)
+		if left == n.left {
+			return n
+		}
+
+		c := *n
+		c.left = left
+		return &c
+	case pp > n.pricePoint:
+		right := removeLevel(n.right, pp)
+		if right == n.right {
+			return n
+		}
+
+		c := *n
+		c.right = right
+		return &c
+	default:
+		return mergeLevels(n.left, n.right)
+	}
+}
+
+// mergeLevels returns the root of a treap holding the levels of two treaps, the pricepoints of
+// the first being all lower than the pricepoints of the second
+func mergeLevels(a, b *levelNode) *levelNode {
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	if a.priority > b.priority {
+		c := *a
+		c.right = mergeLevels(a.right, b)
+		return &c
+	}
+
+	c := *b
+	c.left = mergeLevels(a, b.left)
+	return &c
+}
+
+// walkLevels calls fn with the levels of a treap by increasing pricepoint, or by decreasing
+// pricepoint if descending is set, until fn returns false. Only the levels reached are read.
+func walkLevels(n *levelNode, descending bool, fn func(pp int64, amount *big.Int) bool) {
+	stack := []*levelNode{}
+	for n != nil || len(stack) > 0 {
+		for n != nil {
+			stack = append(stack, n)
+			if descending {
+				n = n.right
+			} else {
+				n = n.left
+			}
+		}
+
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(n.pricePoint, n.amount) {
+			return
+		}
+
+		if descending {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// treeLevels returns the pricepoints and amounts of a level treap in increasing order
+func treeLevels(root *levelNode) ([]int64, []int64) {
+	pps, amounts := []int64{}, []int64{}
+	walkLevels(root, false, func(pp int64, amount *big.Int) bool {
+		pps = append(pps, pp)
+		amounts = append(amounts, amount.Int64())
+		return true
+	})
+
+	return pps, amounts
+}
+
+func TestLevelTreap(t *testing.T) {
+	var root *levelNode
+	root = putLevel(root, 1000, big.NewInt(1))
+	root = putLevel(root, 900, big.NewInt(2))
+	root = putLevel(root, 1100, big.NewInt(3))
+	before := root
+
+	// the previous roots keep the levels as they were
+	root = putLevel(root, 1000, big.NewInt(4))
+	root = putLevel(root, 900, big.NewInt(0))
+	root = removeLevel(root, 1200)
+
+	pps, amounts := treeLevels(before)
+	assert.Equal(t, []int64{900, 1000, 1100}, pps)
+	assert.Equal(t, []int64{2, 1, 3}, amounts)
+
+	pps, amounts = treeLevels(root)
+	assert.Equal(t, []int64{1000, 1100}, pps)
+	assert.Equal(t, []int64{4, 3}, amounts)
+
+	// the levels are read best priced first until fn returns false
+	read := []int64{}
+	walkLevels(before, true, func(pp int64, amount *big.Int) bool {
+		read = append(read, pp)
+		return len(read) < 2
+	})
+
+	assert.Equal(t, []int64{1100, 1000}, read)
+}
+
+func TestLevelTreapRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	model := map[int64]int64{}
+	var root *levelNode
+	for i := 0; i < 10000; i++ {
+		pp := int64(r.Intn(500))
+		amount := int64(r.Intn(4))
+		if amount == 0 {
+			delete(model, pp)
+		} else {
+			model[pp] = amount
+		}
+
+		root = putLevel(root, pp, big.NewInt(amount))
+	}
+
+	expected := []int64{}
+	for pp := range model {
+		expected = append(expected, pp)
+	}
+
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+	pps, amounts := treeLevels(root)
+	assert.Equal(t, expected, pps)
+	for i, pp := range pps {
+		assert.Equal(t, model[pp], amounts[i])
+	}
+}
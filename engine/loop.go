@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// commandBufferSize is the number of updates buffered for the subscribers of the orderbooks
+// before the event loops wait for them to catch up
+const commandBufferSize = 1024
+
+// OrderQueueCapacity is the number of new orders and other commands that can be queued for an
+// orderbook. New orders are rejected with types.ErrEngineBusy once the queue is full (see
+// submit), while the other commands wait for the event loop to catch up.
+var OrderQueueCapacity = 1024
+
+// CancelQueueCapacity is the number of cancellations that can be queued for an orderbook before
+// their callers wait for the event loop to catch up (see doPriority)
+var CancelQueueCapacity = 4096
+
+// CommandTimeout is the time after which the cancellation and replacement of an order are given
+// up if the event loop of the orderbook has not started them
+var CommandTimeout = 10 * time.Second
+
+// command states, a command is either started by the event loop or abandoned by its caller
+const (
+	commandQueued int32 = iota
+	commandStarted
+	commandAbandoned
+)
+
+// command is a function run by the event loop of an orderbook. The error returned by the
+// function is sent on done, which is buffered so that the event loop never waits for a caller
+// that gave up.
+type command struct {
+	ctx   context.Context
+	fn    func() error
+	done  chan error
+	state int32
+}
+
+// run is the event loop of the orderbook. The commands of the orderbook are run one after the
+// other, cancellations first, while the orderbooks of the other pairs run in parallel. Commands
+// abandoned by their caller before they start are skipped. Before a command, the book is loaded
+// again if the redis connection was dialed again (see refresh). After a command, a reconciled
+// orderbook is checked for crossed orders (see checkCrossed), then its depth metrics are recorded
+// and its view and ticker are published before the caller is released.
+func (ob *OrderBook) run() {
+	defer close(ob.stopped)
+
+	ob.stats.recordDepth(ob.book)
+	ob.publishView()
+	ob.publishTicker()
+	commands, cancels := ob.commands, ob.cancels
+	for commands != nil || cancels != nil {
+		c := next(&commands, &cancels)
+		if c == nil {
+			continue
+		}
+
+		if c.ctx.Err() != nil || !atomic.CompareAndSwapInt32(&c.state, commandQueued, commandStarted) {
+			c.done <- c.ctx.Err()
+			continue
+		}
+
+		ob.refresh()
+		err := c.fn()
+		if ob.reconciled {
+			ob.checkCrossed()
+		}
+
+		ob.now = time.Time{}
+
+		ob.stats.recordDepth(ob.book)
+		ob.publishView()
+		ob.publishTicker()
+		c.done <- err
+	}
+}
+
+// next receives the next command from the queues of the orderbook, the cancellations first. A
+// queue is set to nil once it is closed and drained, in which case nil is returned.
+func next(commands, cancels *chan *command) *command {
+	select {
+	case c, ok := <-*cancels:
+		if !ok {
+			*cancels = nil
+		}
+
+		return c
+	default:
+	}
+
+	select {
+	case c, ok := <-*cancels:
+		if !ok {
+			*cancels = nil
+		}
+
+		return c
+	case c, ok := <-*commands:
+		if !ok {
+			*commands = nil
+		}
+
+		return c
+	}
+}
+
+// do runs fn on the event loop of the orderbook and returns its error. If ctx is done before fn
+// is started, fn is never run and the error of the context is returned. Once started, fn always
+// completes and do waits for it, so that the caller never misses the result of a command that
+// changed the orderbook.
+func (ob *OrderBook) do(ctx context.Context, fn func() error) error {
+	return queue(ctx, ob.commands, fn)
+}
+
+// doPriority runs a cancellation like do, through the cancellation queue of the orderbook, which
+// is run before the other commands
+func (ob *OrderBook) doPriority(ctx context.Context, fn func() error) error {
+	return queue(ctx, ob.cancels, fn)
+}
+
+// submit runs a new order like do, except that types.ErrEngineBusy is returned right away,
+// without running fn, if the order queue of the orderbook is full. The overloaded orderbook sheds
+// the new orders instead of building a backlog that would delay all of them.
+func (ob *OrderBook) submit(ctx context.Context, fn func() error) error {
+	c := &command{ctx: ctx, fn: fn, done: make(chan error, 1)}
+
+	select {
+	case ob.commands <- c:
+	default:
+		ob.stats.reject(types.RejectReasonEngineBusy)
+		return types.ErrEngineBusy
+	}
+
+	return c.wait()
+}
+
+// queue sends a command running fn to the given queue and waits for its result (see do)
+func queue(ctx context.Context, q chan *command, fn func() error) error {
+	c := &command{ctx: ctx, fn: fn, done: make(chan error, 1)}
+
+	select {
+	case q <- c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return c.wait()
+}
+
+// wait waits for the result of a queued command. If the context of the command is done before
+// the command is started, the command is abandoned and the error of the context is returned.
+func (c *command) wait() error {
+	select {
+	case err := <-c.done:
+		return err
+	case <-c.ctx.Done():
+		if atomic.CompareAndSwapInt32(&c.state, commandQueued, commandAbandoned) {
+			return c.ctx.Err()
+		}
+
+		return <-c.done
+	}
+}
+
+// pause stops the event loop of the orderbook until resume is called, so that the caller can
+// use the orderbook along with other paused orderbooks as a single step. The orderbooks are
+// paused to cancel orders, so the pause goes through the cancellation queue. The caller must call
+// resume once done.
+func (ob *OrderBook) pause(ctx context.Context) (resume func(), err error) {
+	paused := make(chan struct{})
+	resumed := make(chan struct{})
+
+	go ob.doPriority(ctx, func() error {
+		close(paused)
+		<-resumed
+		return nil
+	})
+
+	select {
+	case <-paused:
+		return func() { close(resumed) }, nil
+	case <-ctx.Done():
+		// the pause command may still be started once the context is done, it is then resumed
+		// right away
+		close(resumed)
+		return nil, ctx.Err()
+	}
+}
+
+// stop stops the event loop of the orderbook once the queued commands have been run
+func (ob *OrderBook) stop() {
+	close(ob.cancels)
+	close(ob.commands)
+	<-ob.stopped
+}
@@ -1,451 +1,1356 @@
 package engine
 
-// The orderbook currently uses the four following data structures to store engine
-// state in redis
+// The orderbook is kept in memory (see book.go) in the four following data structures
 // 1. Pricepoints set
-// 2. Pricepoints volume set
-// 3. Pricepoints hashes set
-// 4. Orders map
+// 2. Pricepoints hashes list
+// 3. Orders map
+// 4. Stop index
 
-// 1. The pricepoints set is an ordered set that store all pricepoints.
+// 1. The pricepoints set stores the sorted pricepoints of one side of the orderbook.
 // Keys: ~ pair addresses + side (BUY or SELL)
-// Values: pricepoints set (sorted set but all ranks are actually 0)
+// Values: sorted pricepoints
 
-// 2. The pricepoints volume set is an order set that store the volume for a given pricepoint
-// Keys: pair addresses + side + pricepoint
-// Values: volume for corresponding (pair, pricepoint)
-
-// 3. The pricepoints hashes set is an ordered set that stores a set of hashes ranked by creation time for a given pricepoint
+// 2. The pricepoints hashes list stores the hashes of the orders of a price level in arrival order
 // Keys: pair addresses + side + pricepoint
 // Values: hashes of orders with corresponding pricepoint
 
-// 4. The orders hashmap is a mapping that stores serialized orders
+// 3. The orders map stores the orders resting in the orderbook
+// Keys: hash
+// Values: order
+
+// 4. Dormant stop orders are kept out of the orderbook in the stop index
 // Keys: hash
-// Values: serialized order
+// Values: stop order, the stops are also indexed by side and by stop pricepoint, and trailing stops
+// by side and trailing offset (see stops.go)
+
+// Every change of these structures is appended asynchronously to the mutation log of the
+// orderbook in redis, and a snapshot of the orderbook periodically replaces the log (see
+// journal.go). On restart the orderbook is rebuilt from its last snapshot and its log.
+
+// Every change of the total amount of a price level is given the next sequence of the orderbook
+// and published as a diff (see diffs.go). The last diffs are kept in memory so that clients that
+// missed some diffs can request them again.
+
+// Each orderbook is used by a single goroutine, its event loop (see loop.go). The engine submits
+// the orders, cancellations and other operations of a pair as commands to the event loop of the
+// pair, which runs them one after the other, so that the orderbooks of different pairs are
+// matched in parallel without any lock. The snapshots of the price levels are read from an
+// immutable view published by the event loop after every command (see view.go), so that they
+// never wait for, nor delay, the matching.
+
+// On startup the orderbook is reconciled with the open orders stored in mongo and the trades that
+// are still being settled (see reconcile). New orders are refused until the reconciliation is over.
 
 import (
-	"encoding/json"
+	"errors"
 	"math/big"
-	"sync"
+	"sort"
+	"sync/atomic"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
-	"github.com/Proofsuite/amp-matching-engine/redis"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 )
 
 type OrderBook struct {
-	redisConn    *redis.RedisConnection
+	book         *book
+	journal      *journal
+	diffs        *diffRing
+	subscriber   chan<- *orderBookDiffs
 	rabbitMQConn *rabbitmq.Connection
 	pair         *types.Pair
-	mutex        *sync.Mutex
+	commands     chan *command
+	cancels      chan *command
+	stopped      chan struct{}
+	reconciled   bool
+
+	// generation is the generation of the redis connection of the journal the book was loaded
+	// with (see refresh)
+	generation uint64
+
+	// dedup holds the hashes of the orders recently received by the orderbook
+	dedup *dedupCache
+
+	// fills are the fill totals of the partially filled orders, only kept when DebugFills is set
+	fills map[common.Hash]*fillTotals
+
+	stats *orderBookStats
+
+	// view holds the last view of the price levels published by the event loop (see publishView)
+	view atomic.Value
+
+	// ticker holds the last ticker published by the event loop (see publishTicker), which is sent
+	// to tickers, if set. lastTradeTime is the time of the last trade since the engine started.
+	ticker        atomic.Value
+	tickers       chan<- *types.Ticker
+	lastTradeTime time.Time
+
+	// lastCross holds the best bid and ask of the last cross that could not be repaired
+	lastCross [2]int64
+
+	// breaker is the circuit breaker of the pair (see circuitBreaker). The trading status of the
+	// pair is sent to statuses, if set, whenever the pair is halted or resumed.
+	breaker  *circuitBreaker
+	statuses chan<- *types.PairStatus
+
+	// commandLog is the log the commands of the orderbook are appended to, if they are logged.
+	// now is the time of the command being run by the event loop (see record).
+	commandLog *commandLog
+	now        time.Time
+
+	// publisher replaces rabbitmq as the destination of the engine responses when it is set,
+	// eg. while replaying a command log
+	publisher func(res *types.EngineResponse) error
+
+	// previewing is set while an order is matched only to preview its execution (see
+	// simulateOrder), in which case the orderbook is not changed by the matching
+	previewing bool
+}
+
+// publish publishes an engine response to rabbitmq or to the publisher of the orderbook
+func (ob *OrderBook) publish(res *types.EngineResponse) error {
+	if ob.publisher != nil {
+		return ob.publisher(res)
+	}
+
+	return ob.rabbitMQConn.PublishEngineResponse(res)
+}
+
+// clock returns the time of the command being run by the event loop, at which orders are expired,
+// or the current time outside of a recorded command
+func (ob *OrderBook) clock() time.Time {
+	if ob.now.IsZero() {
+		return time.Now()
+	}
+
+	return ob.now
 }
 
 // newOrder calls buyOrder/sellOrder based on type of order recieved and
-// publishes the response back to rabbitmq
-func (ob *OrderBook) newOrder(o *types.Order, hashID common.Hash) (err error) {
-	// Attain lock on engineResource, so that recovery or cancel order function doesn't interfere
-	ob.mutex.Lock()
-	defer ob.mutex.Unlock()
+// publishes the response back to rabbitmq. The time from the reception of the order to the
+// publication of its response is recorded in the match latency metrics. The published response
+// is returned.
+func (ob *OrderBook) newOrder(o *types.Order, hashID common.Hash, received time.Time) (*types.EngineResponse, error) {
+	// orders that were already received are not matched again, the current state of the order
+	// being sent back instead (see dedupCache)
+	if ob.isDuplicate(o) {
+		ob.stats.reject(rejectReasonDuplicate)
+
+		current := o
+		if stored := ob.book.orders[o.Hash]; stored != nil {
+			current = stored.Clone()
+		} else if stored := ob.book.stops[o.Hash]; stored != nil {
+			current = stored.Clone()
+		}
+
+		resp := &types.EngineResponse{HashID: hashID, Status: "DUPLICATE", Order: current}
+		err := ob.publish(resp.Clone())
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		return resp, nil
+	}
+
+	// orders that were queued before the pair was delisted, paused or halted, or before the
+	// orderbook was reconciled, are rejected
+	if reason := ob.refusalReason(); reason != "" {
+		ob.stats.reject(reason)
+
+		resp := &types.EngineResponse{HashID: hashID, Status: "ERROR", Order: o}
+		err := ob.publish(resp)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		return resp, nil
+	}
+
+	// stop orders stay dormant in the stop index until a trade crosses their stop pricepoint
+	if o.Status == types.OrderStatusStop {
+		err := ob.AddToStopIndex(o)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		resp := &types.EngineResponse{HashID: hashID, Status: "STOP_ADDED", Order: o}
+		err = ob.publish(resp.Clone())
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		return resp, nil
+	}
+
+	resp, err := ob.matchOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	err = ob.publishOrderResponse(resp, hashID)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob.stats.matchLatency.observe(time.Since(received))
+	return resp, nil
+}
+
+// refusalReason returns the reason why new orders are refused by the orderbook, ie. when the
+// pair is delisted, paused or halted, or before the orderbook is reconciled, or an empty string
+// if new orders are matched. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) refusalReason() string {
+	switch {
+	case !ob.pair.Active:
+		return rejectReasonPairInactive
+	case !ob.reconciled:
+		return rejectReasonNotReconciled
+	case ob.pair.Paused:
+		return rejectReasonPairPaused
+	case ob.halted():
+		return rejectReasonPairHalted
+	default:
+		return ""
+	}
+}
+
+// isDuplicate returns true if an order was already received by the orderbook or rests in it. The
+// hash of the order is added to the dedup cache.
+func (ob *OrderBook) isDuplicate(o *types.Order) bool {
+	if !ob.dedup.add(o.Hash, ob.clock()) {
+		return true
+	}
+
+	return ob.book.orders[o.Hash] != nil || ob.book.stops[o.Hash] != nil
+}
+
+// matchOrder calls buyOrder/sellOrder based on the side of the order. It must be run by the
+// event loop of the orderbook.
+func (ob *OrderBook) matchOrder(o *types.Order) (*types.EngineResponse, error) {
+	// orders are collected without being matched during the opening auction of the pair, which is
+	// closed by the first order received after its end if it was not closed yet
+	if ob.auctionPending() {
+		if ob.inAuction() {
+			return ob.collectAuctionOrder(o)
+		}
+
+		_, err := ob.closeAuction()
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
 
 	resp := &types.EngineResponse{}
-	if o.Side == "SELL" {
+	var err error
+	if o.Side == types.OrderSideSell {
 		resp, err = ob.sellOrder(o)
 		if err != nil {
 			logger.Error(err)
-			return err
+			return nil, err
 		}
 
-	} else if o.Side == "BUY" {
+	} else if o.Side == types.OrderSideBuy {
 		resp, err = ob.buyOrder(o)
 		if err != nil {
 			logger.Error(err)
-			return err
+			return nil, err
 		}
 	}
 
+	return resp, nil
+}
+
+// publishOrderResponse publishes the engine response of a matched order, moves the trailing
+// stops that follow the highest and lowest prices of its trades and triggers the stop orders
+// crossed by its last trade. The trades of an order print at increasing prices for a buy order and
+// decreasing prices for a sell order, so trailing stops are moved before the last trade can cross
+// them. Stop orders are not triggered while the pair is halted, since the orders they trigger
+// would be refused. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) publishOrderResponse(resp *types.EngineResponse, hashID common.Hash) error {
 	// Note: Plug the option for orders like FOC, Limit here (if needed)
+	// The response is cloned since the orders it holds can still be updated by the engine
+	// while it is being published
 	resp.HashID = hashID
-	err = ob.rabbitMQConn.PublishEngineResponse(resp)
+	if resp.Order != nil {
+		if position, amountAhead, ok := ob.book.position(resp.Order.Hash); ok {
+			resp.Position = &types.OrderPosition{Position: position, AmountAhead: amountAhead}
+		}
+	}
+	err := ob.publish(resp.Clone())
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
+	if resp.RejectReason != "" {
+		ob.stats.reject(resp.RejectReason)
+	}
+
+	if len(resp.Matches) > 0 {
+		high, low := resp.Matches[0].Trade.PricePoint, resp.Matches[0].Trade.PricePoint
+		for _, m := range resp.Matches {
+			high = math.Max(high, m.Trade.PricePoint)
+			low = math.Min(low, m.Trade.PricePoint)
+		}
+
+		err = ob.trailStopOrders(high, low)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		if ob.halted() {
+			return nil
+		}
+
+		last := resp.Matches[len(resp.Matches)-1].Trade
+		err = ob.triggerStopOrders(last.PricePoint)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
 	return nil
 }
 
-// buyOrder is triggered when a buy order comes in, it fetches the ask list
-// from orderbook. First it checks ths price point list to check whether the order can be matched
-// or not, if there are pricepoints that can satisfy the order then corresponding list of orders
-// are fetched and trade is executed
-func (ob *OrderBook) buyOrder(o *types.Order) (*types.EngineResponse, error) {
-	res := &types.EngineResponse{
-		Order:  o,
-		Status: "NOMATCH",
+// replaceOrder removes an order from the orderbook and matches its replacement as a single step.
+// The replacement order is published like a new order and goes to the back of its price level.
+// If the replacement can not be matched, the replaced order is put back in the orderbook. A
+// CANCELLED engine response is returned for the replaced order.
+func (ob *OrderBook) replaceOrder(old, o *types.Order, hashID common.Hash) (*types.EngineResponse, error) {
+	if !ob.pair.Active {
+		return nil, errors.New("Pair is not active")
 	}
 
-	remainingOrder := *o
-	res.RemainingOrder = &remainingOrder
-	oskv := o.GetOBMatchKey()
+	if !ob.reconciled {
+		return nil, errors.New("Orderbook is not reconciled")
+	}
 
-	// GET Range of sellOrder between minimum Sell order and o.Price
-	pps, err := ob.GetMatchingBuyPricePoints(oskv, o.PricePoint.Int64())
+	if ob.pair.Paused {
+		return nil, errors.New("Pair is paused")
+	}
+
+	if ob.halted() {
+		return nil, errors.New("Pair is halted")
+	}
+
+	stored, err := ob.GetFromOrderMap(old.Hash)
+	if err != nil {
+		logger.Error(err)
+		return nil, errors.New("Replaced order is not in the orderbook")
+	}
+
+	err = ob.deleteOrder(stored)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	if len(pps) == 0 {
-		o.Status = "OPEN"
-		res.Status = "NOMATCH"
-		res.RemainingOrder = nil
-		ob.addOrder(o)
-		return res, nil
+	resp, err := ob.matchOrder(o)
+	if err != nil {
+		logger.Error(err)
+		if err := ob.addOrder(stored); err != nil {
+			logger.Error(err)
+		}
+
+		return nil, err
 	}
 
-	for _, pp := range pps {
-		entries, err := ob.GetMatchingOrders(oskv, pp)
+	stored.Status = types.OrderStatusCancelled
+	cancelled := &types.EngineResponse{
+		HashID: old.Hash,
+		Status: "CANCELLED",
+		Order:  stored,
+	}
+
+	err = ob.publishOrderResponse(resp, hashID)
+	if err != nil {
+		logger.Error(err)
+		return cancelled, err
+	}
+
+	return cancelled, nil
+}
+
+// triggerStopOrders removes the stop orders triggered by a trade at the given pricepoint from
+// the stop index and publishes a STOP_TRIGGERED engine response for each of them. The triggered
+// orders are not matched here: their balance is locked by the order service which then sends
+// them back to the engine as regular limit orders. It must be run by the event loop of the
+// orderbook, so that each stop order is triggered only once even if several trades print in quick
+// succession.
+func (ob *OrderBook) triggerStopOrders(pricePoint *big.Int) error {
+	for _, side := range []types.OrderSide{types.OrderSideBuy, types.OrderSideSell} {
+		stops, err := ob.GetTriggeredStopOrders(side, pricePoint.Int64())
 		if err != nil {
 			logger.Error(err)
-			return nil, err
+			return err
 		}
 
-		for _, bookEntry := range entries {
-			entry := &types.Order{}
-			err = json.Unmarshal(bookEntry, &entry)
+		for _, o := range stops {
+			err := ob.RemoveFromStopIndex(o)
 			if err != nil {
-				return nil, err
+				logger.Error(err)
+				return err
 			}
 
-			trade, err := ob.execute(o, entry)
+			// triggers are logged for auditing, replays trigger the stop orders again
+			ob.record(&logEntry{Type: commandStopTriggered, Orders: []*types.Order{o}})
+
+			res := &types.EngineResponse{HashID: o.Hash, Status: "STOP_TRIGGERED", Order: o}
+			err = ob.publish(res)
 			if err != nil {
 				logger.Error(err)
-				return nil, err
+				return err
 			}
+		}
+	}
 
-			match := &types.OrderTradePair{entry, trade}
-			res.Matches = append(res.Matches, match)
-			res.RemainingOrder.Amount = math.Sub(res.RemainingOrder.Amount, trade.Amount)
+	return nil
+}
 
-			if math.IsZero(res.RemainingOrder.Amount) {
-				res.Status = "FULL"
-				res.Order.Status = "FILLED"
-				res.RemainingOrder = nil
-				return res, nil
-			}
+// trailStopOrders moves the stop pricepoint of the trailing stops that follow trades between the
+// given low and high pricepoints (see GetTrailedStopOrders). It must be run by the event loop of
+// the orderbook.
+func (ob *OrderBook) trailStopOrders(high, low *big.Int) error {
+	stops, err := ob.GetTrailedStopOrders(high, low)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for _, o := range stops {
+		err := ob.AddToStopIndex(o)
+		if err != nil {
+			logger.Error(err)
+			return err
 		}
 	}
 
-	//TODO refactor this in a different function (make above function more clear in general)
-	res.Order.Status = "REPLACED"
-	res.Status = "PARTIAL"
-	res.RemainingOrder.Signature = nil
-	res.RemainingOrder.Nonce = nil
-	res.RemainingOrder.Hash = common.HexToHash("")
-	res.RemainingOrder.BuyAmount = res.RemainingOrder.Amount
-	res.RemainingOrder.SellAmount = math.Div(
-		math.Mul(res.RemainingOrder.Amount, res.Order.SellAmount),
-		res.Order.BuyAmount,
-	)
+	return nil
+}
 
-	return res, nil
+// setPair replaces the pair settings of the orderbook
+func (ob *OrderBook) setPair(p *types.Pair) {
+	pair := *p
+	ob.pair = &pair
 }
 
-// sellOrder is triggered when a sell order comes in, it fetches the bid list
+// buyOrder is triggered when a buy order comes in, it fetches the ask list
 // from orderbook. First it checks ths price point list to check whether the order can be matched
 // or not, if there are pricepoints that can satisfy the order then corresponding list of orders
 // are fetched and trade is executed
-func (ob *OrderBook) sellOrder(o *types.Order) (*types.EngineResponse, error) {
+func (ob *OrderBook) buyOrder(o *types.Order) (*types.EngineResponse, error) {
 	res := &types.EngineResponse{
-		Status: "NOMATCH",
 		Order:  o,
+		Status: "NOMATCH",
 	}
 
-	remainingOrder := *o
-	res.RemainingOrder = &remainingOrder
-	obkv := o.GetOBMatchKey()
+	res.RemainingOrder = o.Clone()
+
+	// post-only orders are rejected if any order of the orderbook would match them. The orderbook
+	// can not change between the check and the moment the order is added to the orderbook.
+	if o.PostOnly {
+		fillable, err := ob.fillableAmount(o)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if fillable.Sign() > 0 {
+			return rejectOrder(res, types.RejectReasonPostOnly), nil
+		}
+	}
+
+	// FOK orders are cancelled without being matched if the orderbook can not fill them entirely.
+	// The orderbook can not change between the depth check and the matching.
+	if o.TimeInForce == types.TimeInForceFOK {
+		fillable, err := ob.fillableAmount(o)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if fillable.Cmp(math.Sub(o.Amount, o.FilledAmount)) < 0 {
+			return cancelUnfilledAmount(res, types.CancelReasonFOK), nil
+		}
+	}
 
-	pps, err := ob.GetMatchingSellPricePoints(obkv, o.PricePoint.Int64())
+	filled, tripped, err := ob.fill(o, res)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	if len(pps) == 0 {
+	if tripped {
+		ob.trip(o)
+		return cancelUnfilledAmount(res, types.CancelReasonCircuitBreaker), nil
+	}
+
+	if filled {
+		res.Status = "FULL"
+		res.Order.Status = "FILLED"
+		res.RemainingOrder = nil
+		return res, nil
+	}
+
+	// all the matching orders had expired or belong to another exchange contract
+	if len(res.Matches) == 0 {
+		if reason := unfilledCancelReason(o); reason != "" {
+			return cancelUnfilledAmount(res, reason), nil
+		}
+
 		res.Status = "NOMATCH"
 		res.RemainingOrder = nil
 		ob.addOrder(o)
-		o.Status = "OPEN"
 		return res, nil
 	}
 
-	for _, pp := range pps {
-		entries, err := ob.GetMatchingOrders(obkv, pp)
+	if reason := unfilledCancelReason(o); reason != "" {
+		return cancelUnfilledAmount(res, reason), nil
+	}
+
+	// a remaining amount below the pair minimum amount or notional could never be filled and is cancelled
+	if ob.isDust(res.RemainingOrder.Amount, res.RemainingOrder.PricePoint) {
+		res.Status = "FULL"
+		res.Order.Status = "FILLED"
+		res.RemainingOrder = nil
+		return res, nil
+	}
+
+	err = ob.restOrder(res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// sellOrder is triggered when a sell order comes in, it fetches the bid list
+// from orderbook. First it checks ths price point list to check whether the order can be matched
+// or not, if there are pricepoints that can satisfy the order then corresponding list of orders
+// are fetched and trade is executed
+func (ob *OrderBook) sellOrder(o *types.Order) (*types.EngineResponse, error) {
+	res := &types.EngineResponse{
+		Status: "NOMATCH",
+		Order:  o,
+	}
+
+	res.RemainingOrder = o.Clone()
+
+	// post-only orders are rejected if any order of the orderbook would match them. The orderbook
+	// can not change between the check and the moment the order is added to the orderbook.
+	if o.PostOnly {
+		fillable, err := ob.fillableAmount(o)
 		if err != nil {
 			logger.Error(err)
 			return nil, err
 		}
 
-		for _, b := range entries {
-			entry := &types.Order{}
-			err = json.Unmarshal(b, &entry)
+		if fillable.Sign() > 0 {
+			return rejectOrder(res, types.RejectReasonPostOnly), nil
+		}
+	}
 
-			if err != nil {
-				logger.Error(err)
-				return nil, err
-			}
+	// FOK orders are cancelled without being matched if the orderbook can not fill them entirely.
+	// The orderbook can not change between the depth check and the matching.
+	if o.TimeInForce == types.TimeInForceFOK {
+		fillable, err := ob.fillableAmount(o)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
 
-			trade, err := ob.execute(o, entry)
-			if err != nil {
-				logger.Error(err)
-				return nil, err
-			}
+		if fillable.Cmp(math.Sub(o.Amount, o.FilledAmount)) < 0 {
+			return cancelUnfilledAmount(res, types.CancelReasonFOK), nil
+		}
+	}
 
-			match := &types.OrderTradePair{entry, trade}
-			res.Matches = append(res.Matches, match)
-			res.RemainingOrder.Amount = math.Sub(res.RemainingOrder.Amount, trade.Amount)
+	filled, tripped, err := ob.fill(o, res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 
-			if math.IsZero(res.RemainingOrder.Amount) {
-				res.Status = "FULL"
-				res.Order.Status = "FILLED"
-				res.RemainingOrder = nil
-				return res, nil
-			}
+	if tripped {
+		ob.trip(o)
+		return cancelUnfilledAmount(res, types.CancelReasonCircuitBreaker), nil
+	}
+
+	if filled {
+		res.Status = "FULL"
+		res.Order.Status = "FILLED"
+		res.RemainingOrder = nil
+		return res, nil
+	}
+
+	// all the matching orders had expired or belong to another exchange contract
+	if len(res.Matches) == 0 {
+		if reason := unfilledCancelReason(o); reason != "" {
+			return cancelUnfilledAmount(res, reason), nil
 		}
+
+		res.Status = "NOMATCH"
+		res.RemainingOrder = nil
+		ob.addOrder(o)
+		return res, nil
 	}
 
-	//TODO refactor this in a different function (make above function more clear in general)
-	res.Order.Status = "REPLACED"
-	res.Status = "PARTIAL"
-	res.RemainingOrder.Signature = nil
-	res.RemainingOrder.Nonce = nil
-	res.RemainingOrder.Hash = common.HexToHash("")
-	res.RemainingOrder.BuyAmount = res.RemainingOrder.Amount
-	res.RemainingOrder.SellAmount = math.Div(
-		math.Mul(res.RemainingOrder.Amount, res.Order.SellAmount),
-		res.Order.BuyAmount,
-	)
+	if reason := unfilledCancelReason(o); reason != "" {
+		return cancelUnfilledAmount(res, reason), nil
+	}
+
+	// a remaining amount below the pair minimum amount or notional could never be filled and is cancelled
+	if ob.isDust(res.RemainingOrder.Amount, res.RemainingOrder.PricePoint) {
+		res.Status = "FULL"
+		res.Order.Status = "FILLED"
+		res.RemainingOrder = nil
+		return res, nil
+	}
+
+	err = ob.restOrder(res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 
 	return res, nil
 }
 
-// addOrder adds an order to redis
+// fill matches an order with the orders of the orderbook that it crosses, best priced first, until
+// it is filled (see walkMatchingOrders). The matches are added to the engine response of the
+// order and true is returned if the order was filled. Expired orders are removed from the
+// orderbook instead of being matched and orders signed for different exchange contracts, which
+// can not be settled against each other, are skipped.
+// The matching stops, and tripped is true, if the trades would print beyond the circuit breaker
+// band around the reference pricepoint of the pair, or around the best price of the orderbook if
+// the pair has no reference pricepoint.
+// While an order is previewed, expired orders are skipped instead of being removed and hidden
+// orders are skipped so that previews do not reveal them (see also matchableAmount).
+func (ob *OrderBook) fill(o *types.Order, res *types.EngineResponse) (filled, tripped bool, err error) {
+	reference := ob.referencePricePoint()
+	err = ob.walkMatchingOrders(o, func(entry *types.Order) (bool, error) {
+		if ob.previewing && (entry.IsExpired(ob.clock()) || entry.Hidden) {
+			return true, nil
+		}
+
+		if entry.IsExpired(ob.clock()) {
+			_, err := ob.expireOrder(entry)
+			return err == nil, err
+		}
+
+		if entry.ExchangeAddress != o.ExchangeAddress {
+			return true, nil
+		}
+
+		if reference == nil {
+			reference = entry.PricePoint
+		}
+
+		if ob.isBeyondBand(o.Side, reference, executionPricePoint(o, entry)) {
+			tripped = true
+			return false, nil
+		}
+
+		trade, err := ob.execute(o, entry)
+		if err != nil {
+			return false, err
+		}
+
+		if !ob.previewing {
+			ob.recordTrade(trade)
+		}
+
+		res.Matches = append(res.Matches, &types.OrderTradePair{Order: entry, Trade: trade})
+		res.RemainingOrder.Amount = math.Sub(res.RemainingOrder.Amount, trade.Amount)
+		filled = math.IsZero(res.RemainingOrder.Amount)
+		return !filled, nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return false, false, err
+	}
+
+	return filled, tripped, nil
+}
+
+// restOrder adds the unfilled amount of a partially matched order to the orderbook. The order
+// keeps its hash and signature and is matched like any other partially filled order of the
+// orderbook, so the taker does not have to sign a new order for the remaining amount.
+func (ob *OrderBook) restOrder(res *types.EngineResponse) error {
+	err := ob.addOrder(res.Order)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	res.Order.Status = "PARTIAL_FILLED"
+	res.Status = "PARTIAL"
+	res.RemainingOrder = nil
+
+	return ob.AddToOrderMap(res.Order)
+}
+
+// addOrder adds an order to the orderbook
 func (ob *OrderBook) addOrder(o *types.Order) error {
 	o.Status = "OPEN"
 	pricePointSetKey, orderHashListKey := o.GetOBKeys()
 
-	// ob.redisConn.StartTx()
+	err := ob.AddToPricePointSet(pricePointSetKey, o.PricePoint.Int64())
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = ob.AddToOrderMap(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = ob.AddToPricePointHashesSet(orderHashListKey, o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// updateOrder updates the order in the orderbook
+func (ob *OrderBook) updateOrder(o *types.Order, tradeAmount *big.Int) error {
+	stored := &types.Order{}
+
+	stored, err := ob.GetFromOrderMap(o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	filledAmount := math.Add(stored.FilledAmount, tradeAmount)
+	if math.IsEqualOrSmallerThan(filledAmount, big.NewInt(0)) {
+		stored.Status = "OPEN"
+		stored.FilledAmount = big.NewInt(0)
+	} else if math.IsEqualOrGreaterThan(filledAmount, stored.Amount) {
+		stored.Status = "FILLED"
+		stored.FilledAmount = stored.Amount
+	} else {
+		stored.Status = "PARTIAL_FILLED"
+		stored.FilledAmount = filledAmount
+	}
+
+	err = ob.AddToOrderMap(stored)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// deleteOrder deletes the order from the orderbook
+func (ob *OrderBook) deleteOrder(o *types.Order) (err error) {
+	pricePointSetKey, orderHashListKey := o.GetOBKeys()
+	pp := o.PricePoint.Int64()
+
+	err = ob.RemoveFromPricePointHashesSet(orderHashListKey, o.Hash)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	pplen, err := ob.GetPricePointHashesSetLength(orderHashListKey)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	if pplen == 0 {
+		err = ob.RemoveFromPricePointSet(pricePointSetKey, pp)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	err = ob.RemoveFromOrderMap(o.Hash)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return err
+}
+
+func (ob *OrderBook) deleteOrders(orders ...types.Order) error {
+	for _, o := range orders {
+		err := ob.deleteOrder(&o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecoverOrders is responsible for recovering the orders that failed to execute after matching
+// Orders are updated or added to orderbook based on whether that order exists in orderbook or not.
+func (ob *OrderBook) RecoverOrders(matches []*types.OrderTradePair) error {
+	for _, m := range matches {
+		t := m.Trade
+		o := m.Order
+
+		o.Status = "PARTIAL_FILLED"
+		filledAmount := math.Sub(o.FilledAmount, t.Amount)
+		if math.IsEqualOrSmallerThan(filledAmount, big.NewInt(0)) {
+			o.Status = "OPEN"
+			o.FilledAmount = big.NewInt(0)
+		}
+
+		if ob.book.orders[o.Hash] == nil {
+			if err := ob.addOrder(o); err != nil {
+				logger.Error(err)
+				return err
+			}
+		} else {
+			if err := ob.updateOrder(o, math.Neg(t.Amount)); err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreFill gives back the amount of a trade that failed to settle to one of its orders, o being
+// the order as recorded by the order service before the amount is given back. An order still resting
+// in the orderbook keeps its place in its price level and only the failed amount is deducted from
+// its filled amount, its other fills being kept. An order that left the orderbook filled is added
+// back at the back of its price level, behind the orders received since it left. IOC and FOK orders,
+// expired orders and orders whose unfilled amount would be dust are not added back. The order
+// resting in the orderbook is returned, or nil if the order was not added back. It must be run by
+// the event loop of the orderbook.
+func (ob *OrderBook) restoreFill(o *types.Order, amount *big.Int) (*types.Order, error) {
+	if stored := ob.book.orders[o.Hash]; stored != nil {
+		err := ob.updateOrder(stored, math.Neg(amount))
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		return ob.book.orders[o.Hash].Clone(), nil
+	}
+
+	restored := o.Clone()
+	restored.FilledAmount = math.Max(math.Sub(filledAmount(o), amount), big.NewInt(0))
+	remaining := math.Sub(restored.Amount, restored.FilledAmount)
+	if unfilledCancelReason(restored) != "" || restored.IsExpired(ob.clock()) || remaining.Sign() <= 0 || ob.isDust(remaining, restored.PricePoint) {
+		return nil, nil
+	}
+
+	err := ob.addOrder(restored)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if restored.FilledAmount.Sign() > 0 {
+		restored.Status = types.OrderStatusPartialFilled
+	}
+
+	return restored.Clone(), nil
+}
+
+func (ob *OrderBook) CancelTrades(orders []*types.Order, amounts []*big.Int) error {
+	for i, o := range orders {
+		o.Status = "PARTIAL_FILLED"
+		o.FilledAmount = math.Sub(o.FilledAmount, amounts[i])
+		if math.IsZero(o.FilledAmount) {
+			o.Status = "OPEN"
+		}
+
+		if ob.book.orders[o.Hash] == nil {
+			if err := ob.addOrder(o); err != nil {
+				logger.Error(err)
+				return err
+			}
+		} else {
+			err := ob.updateOrder(o, math.Neg(o.Amount))
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CancelOrder is used to cancel the order from orderbook
+func (ob *OrderBook) CancelOrder(o *types.Order) (*types.EngineResponse, error) {
+	if o.Status == types.OrderStatusStop {
+		return ob.cancelStopOrder(o)
+	}
+
+	stored, err := ob.GetFromOrderMap(o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if err := ob.deleteOrder(o); err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	stored.Status = "CANCELLED"
+	res := &types.EngineResponse{
+		HashID:         o.Hash,
+		Status:         "CANCELLED",
+		Order:          stored,
+		RemainingOrder: nil,
+		Matches:        nil,
+	}
+
+	return res, nil
+}
+
+// cancelStopOrder removes a dormant stop order from the stop index and returns a STOP_CANCELLED
+// engine response. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) cancelStopOrder(o *types.Order) (*types.EngineResponse, error) {
+	stored, err := ob.GetFromStopIndex(o.Side, o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	err = ob.RemoveFromStopIndex(stored)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	stored.Status = "CANCELLED"
+	res := &types.EngineResponse{
+		HashID: o.Hash,
+		Status: "STOP_CANCELLED",
+		Order:  stored,
+	}
+
+	return res, nil
+}
+
+// reduceOrder reduces the unfilled amount of an order resting in the orderbook to the given amount.
+// The order is updated in place, so that it keeps its position in its price level, and the diff of
+// its level is published. The REDUCED engine response holds the reduced order and the amount
+// removed from it as CancelledAmount. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) reduceOrder(o *types.Order, amount *big.Int) (*types.EngineResponse, error) {
+	stored, err := ob.GetFromOrderMap(o.Hash)
+	if err != nil {
+		return nil, types.ErrOrderNotResting
+	}
+
+	remaining := remainingAmount(stored)
+	if amount.Sign() <= 0 || amount.Cmp(remaining) >= 0 {
+		return nil, types.ErrReduceAmountNotLower
+	}
+
+	stored.Amount = math.Add(math.Sub(stored.Amount, remaining), amount)
+	err = ob.AddToOrderMap(stored)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	res := &types.EngineResponse{
+		HashID:          o.Hash,
+		Status:          "REDUCED",
+		Order:           stored,
+		CancelledAmount: math.Sub(remaining, amount),
+	}
+
+	return res, nil
+}
+
+// removeMakerOrders removes all the orders of the maker from the orderbook and the stop index,
+// or only the orders with the given hashes if hashes is not nil, and returns them as they were
+// stored, dormant stop orders having the STOP status. The event loop of the orderbook must be
+// paused or running it.
+func (ob *OrderBook) removeMakerOrders(maker common.Address, hashes []common.Hash) ([]*types.Order, error) {
+	orders, err := ob.GetAllOrders()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	stops, err := ob.GetAllStopOrders()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	var only map[common.Hash]bool
+	if hashes != nil {
+		only = map[common.Hash]bool{}
+		for _, h := range hashes {
+			only[h] = true
+		}
+	}
+
+	selected := func(o *types.Order) bool {
+		return o.UserAddress == maker && (only == nil || only[o.Hash])
+	}
+
+	removed := []*types.Order{}
+	for _, o := range stops {
+		if !selected(o) {
+			continue
+		}
+
+		stored, err := ob.GetFromStopIndex(o.Side, o.Hash)
+		if err != nil {
+			logger.Error(err)
+			return removed, err
+		}
+
+		err = ob.RemoveFromStopIndex(stored)
+		if err != nil {
+			logger.Error(err)
+			return removed, err
+		}
+
+		removed = append(removed, stored)
+	}
+
+	for _, o := range orders {
+		if !selected(o) {
+			continue
+		}
+
+		err := ob.deleteOrder(o)
+		if err != nil {
+			logger.Error(err)
+			return removed, err
+		}
+
+		removed = append(removed, o)
+	}
 
-	err := ob.AddToPricePointSet(pricePointSetKey, o.PricePoint.Int64())
+	return removed, nil
+}
+
+// expireOrder removes an expired order from the orderbook and publishes an ORDER_EXPIRED
+// engine response, which is returned
+func (ob *OrderBook) expireOrder(o *types.Order) (*types.EngineResponse, error) {
+	err := ob.deleteOrder(o)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	err = ob.AddToOrderMap(o)
-	if err != nil {
-		logger.Error(err)
-		return err
+	o.Status = "EXPIRED"
+	res := &types.EngineResponse{
+		HashID: o.Hash,
+		Status: "ORDER_EXPIRED",
+		Order:  o,
 	}
 
-	err = ob.AddToPricePointHashesSet(orderHashListKey, o.CreatedAt, o.Hash)
+	err = ob.publish(res)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	// ob.redisConn.ExecuteTx()
-
-	return nil
+	return res, nil
 }
 
-// updateOrder updates the order in redis
-func (ob *OrderBook) updateOrder(o *types.Order, tradeAmount *big.Int) error {
-	stored := &types.Order{}
-
-	stored, err := ob.GetFromOrderMap(o.Hash)
+// cancelUncoveredOrder removes an order that its maker can no longer cover from the orderbook
+// and publishes a CANCELLED engine response for its unfilled amount, which is returned
+func (ob *OrderBook) cancelUncoveredOrder(o *types.Order) (*types.EngineResponse, error) {
+	err := ob.deleteOrder(o)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	filledAmount := math.Add(stored.FilledAmount, tradeAmount)
-	if math.IsEqualOrSmallerThan(filledAmount, big.NewInt(0)) {
-		stored.Status = "OPEN"
-		stored.FilledAmount = big.NewInt(0)
-	} else if math.IsEqualOrGreaterThan(filledAmount, stored.Amount) {
-		stored.Status = "FILLED"
-		stored.FilledAmount = stored.Amount
-	} else {
-		stored.Status = "PARTIAL_FILLED"
-		stored.FilledAmount = filledAmount
+	o.Status = types.OrderStatusCancelled
+	res := &types.EngineResponse{
+		HashID:          o.Hash,
+		Status:          "CANCELLED",
+		Order:           o,
+		CancelledAmount: remainingAmount(o),
+		CancelReason:    types.CancelReasonInsufficientFunds,
 	}
 
-	err = ob.AddToOrderMap(stored)
+	err = ob.publish(res.Clone())
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return res, nil
 }
 
-// deleteOrder deletes the order in redis
-func (ob *OrderBook) deleteOrder(o *types.Order) (err error) {
-	//TODO decide to put the mutex on deleteOrder or on cancelOrder
-	// ob.mutex.Lock()
-	// defer ob.mutex.Unlock()
-
-	pricePointSetKey, orderHashListKey := o.GetOBKeys()
-	pp := o.PricePoint.Int64()
-
-	err = ob.RemoveFromPricePointHashesSet(orderHashListKey, o.Hash)
+// pruneOrders removes all the orders resting in the orderbook that have expired at the given
+// time, as well as the uncovered orders (see uncoveredOrders) whose filled amount did not change
+// since they were found uncovered. The engine responses of the removed orders are returned. It
+// must be run by the event loop of the orderbook.
+func (ob *OrderBook) pruneOrders(now time.Time, uncovered map[common.Hash]*big.Int) ([]*types.EngineResponse, error) {
+	orders, err := ob.GetAllOrders()
 	if err != nil {
 		logger.Error(err)
+		return nil, err
 	}
 
-	pplen, err := ob.GetPricePointHashesSetLength(orderHashListKey)
-	if err != nil {
-		logger.Error(err)
-	}
+	responses := []*types.EngineResponse{}
+	for _, o := range orders {
+		var res *types.EngineResponse
+		if o.IsExpired(now) {
+			res, err = ob.expireOrder(o)
+		} else if filled := uncovered[o.Hash]; filled != nil && filled.Cmp(filledAmount(o)) == 0 {
+			res, err = ob.cancelUncoveredOrder(o)
+		} else {
+			continue
+		}
 
-	if pplen == 0 {
-		err = ob.RemoveFromPricePointSet(pricePointSetKey, pp)
 		if err != nil {
 			logger.Error(err)
+			return nil, err
 		}
+
+		responses = append(responses, res)
 	}
 
-	err = ob.RemoveFromOrderMap(o.Hash)
+	return responses, nil
+}
+
+// snapshot queues a snapshot of the orderbook, which replaces its mutation log in redis
+func (ob *OrderBook) snapshot() {
+	ob.journal.snapshot(ob.pair.GetKVPrefix(), ob.book.snapshot())
+}
+
+// refresh loads the book of the orderbook again from redis if the redis connection of the journal
+// was dialed again since the book was loaded, since other processes may have changed the journal
+// of the orderbook while the connection was lost. The pending writes of the journal are flushed
+// first, so that a connection lost while writing the previous commands is dialed again before
+// the generations are compared. The diffs kept for the previous book are dropped so that clients
+// fetch a new snapshot. The book is kept, and loaded again before the next command, if it can not
+// be read. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) refresh() {
+	if ob.journal == nil || ob.journal.flush() == ob.generation {
+		return
+	}
+
+	b, generation, err := ob.journal.reload(ob.pair.GetKVPrefix())
 	if err != nil {
 		logger.Error(err)
+		return
 	}
 
-	return err
+	ob.book = b
+	ob.generation = generation
+	ob.diffs = newDiffRing(diffRingSize)
+	ob.view.Store(ob.currentView())
 }
 
-func (ob *OrderBook) deleteOrders(orders ...types.Order) error {
-	for _, o := range orders {
-		err := ob.deleteOrder(&o)
-		if err != nil {
-			logger.Error(err)
-			return err
-		}
-	}
-
-	return nil
+// reconciliation counts the discrepancies between an orderbook and the database found by reconcile
+type reconciliation struct {
+	orphaned int
+	missing  int
+	updated  int
+	pending  int
+	crossed  int
 }
 
-// RecoverOrders is responsible for recovering the orders that failed to execute after matching
-// Orders are updated or added to orderbook based on whether that order exists in orderbook or not.
-func (ob *OrderBook) RecoverOrders(matches []*types.OrderTradePair) error {
-	ob.mutex.Lock()
-	defer ob.mutex.Unlock()
+// reconcile compares the orderbook with the open orders of the pair stored in the database and
+// corrects it, the database being the reference:
+// - orders resting in the orderbook that are not open anymore are removed
+// - open orders missing from the orderbook are booked, by increasing pricepoint then creation time
+// - orders whose filled amount differs from the database are updated and keep their place
+// committed holds, for each order, the total amount of its trades that are settled or still being
+// settled. An order whose filled amount does not account yet for all of its trades is given the
+// committed amount as filled amount so that the amount of its pending trades can not be matched
+// again, and orders that are entirely committed are removed from the orderbook.
+// The crossed orders of the reconciled orderbook are then matched (see uncross).
+// Once reconciled the orderbook accepts new orders.
+func (ob *OrderBook) reconcile(open []*types.Order, committed map[common.Hash]*big.Int) (*reconciliation, error) {
+	r := &reconciliation{}
+	expected := map[common.Hash]*types.Order{}
+	for _, o := range open {
+		o = o.Clone()
+		if o.FilledAmount == nil {
+			o.FilledAmount = big.NewInt(0)
+		}
 
-	for _, m := range matches {
-		t := m.Trade
-		o := m.Order
+		if c := committed[o.Hash]; c != nil && c.Cmp(o.FilledAmount) > 0 {
+			logger.Warningf("Order %v has pending trades that are not accounted for in its filled amount", o.Hash.Hex())
+			o.FilledAmount = math.Min(c, o.Amount)
+			r.pending++
+		}
 
-		o.Status = "PARTIAL_FILLED"
-		filledAmount := math.Sub(o.FilledAmount, t.Amount)
-		if math.IsEqualOrSmallerThan(filledAmount, big.NewInt(0)) {
-			o.Status = "OPEN"
-			o.FilledAmount = big.NewInt(0)
+		if o.FilledAmount.Cmp(o.Amount) >= 0 {
+			continue
 		}
 
-		_, obListKey := o.GetOBKeys()
-		if !ob.redisConn.Exists(obListKey + "::orders::" + o.Hash.Hex()) {
-			if err := ob.addOrder(o); err != nil {
-				logger.Error(err)
-				return err
-			}
-		} else {
-			if err := ob.updateOrder(o, math.Neg(t.Amount)); err != nil {
-				logger.Error(err)
-				return err
-			}
+		o.Status = types.OrderStatusOpen
+		if o.FilledAmount.Sign() > 0 {
+			o.Status = types.OrderStatusPartialFilled
 		}
-	}
 
-	return nil
-}
+		expected[o.Hash] = o
+	}
 
-func (ob *OrderBook) CancelTrades(orders []*types.Order, amounts []*big.Int) error {
-	ob.mutex.Lock()
-	defer ob.mutex.Unlock()
+	orders, err := ob.GetAllOrders()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 
-	for i, o := range orders {
-		o.Status = "PARTIAL_FILLED"
-		o.FilledAmount = math.Sub(o.FilledAmount, amounts[i])
-		if math.IsZero(o.FilledAmount) {
-			o.Status = "OPEN"
-		}
-		_, obListKey := o.GetOBKeys()
-		if !ob.redisConn.Exists(obListKey + "::orders::" + o.Hash.Hex()) {
-			if err := ob.addOrder(o); err != nil {
+	for _, o := range orders {
+		e := expected[o.Hash]
+		if e == nil {
+			logger.Warningf("Removing order %v which is not open from the orderbook", o.Hash.Hex())
+			err := ob.deleteOrder(o)
+			if err != nil {
 				logger.Error(err)
-				return err
+				return nil, err
 			}
-		} else {
-			err := ob.updateOrder(o, math.Neg(o.Amount))
+
+			r.orphaned++
+			continue
+		}
+
+		if e.FilledAmount.Cmp(o.FilledAmount) != 0 {
+			logger.Warningf("Updating the filled amount of order %v from %v to %v", o.Hash.Hex(), o.FilledAmount, e.FilledAmount)
+			o.FilledAmount = e.FilledAmount
+			o.Status = e.Status
+			err := ob.AddToOrderMap(o)
 			if err != nil {
 				logger.Error(err)
-				return err
+				return nil, err
 			}
+
+			r.updated++
 		}
 	}
 
-	return nil
-}
+	missing := []*types.Order{}
+	for _, o := range expected {
+		if ob.book.orders[o.Hash] == nil {
+			missing = append(missing, o)
+		}
+	}
 
-// CancelOrder is used to cancel the order from orderbook
-func (ob *OrderBook) CancelOrder(o *types.Order) (*types.EngineResponse, error) {
-	ob.mutex.Lock()
-	defer ob.mutex.Unlock()
+	// the missing orders are booked in a deterministic order, the earliest orders of a price
+	// level first
+	sort.Slice(missing, func(i, j int) bool {
+		if c := missing[i].PricePoint.Cmp(missing[j].PricePoint); c != 0 {
+			return c < 0
+		}
 
-	stored, err := ob.GetFromOrderMap(o.Hash)
+		if !missing[i].CreatedAt.Equal(missing[j].CreatedAt) {
+			return missing[i].CreatedAt.Before(missing[j].CreatedAt)
+		}
+
+		return missing[i].Hash.Hex() < missing[j].Hash.Hex()
+	})
+
+	for _, o := range missing {
+		logger.Warningf("Booking open order %v which is missing from the orderbook", o.Hash.Hex())
+		err := ob.addOrder(o)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		r.missing++
+	}
+
+	// the orders booked again may cross the orderbook
+	crossed, err := ob.uncross()
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	if err := ob.deleteOrder(o); err != nil {
-		logger.Error(err)
-		return nil, err
+	r.crossed = len(crossed)
+	ob.reconciled = true
+	return r, nil
+}
+
+// ExecutionPricePolicy is the pricepoint at which an incoming order trades with a resting order
+// it crosses, types.ExecutionPriceMaker or types.ExecutionPriceMidpoint. It is set from the
+// execution_price_policy configuration.
+var ExecutionPricePolicy = types.ExecutionPriceMaker
+
+// executionPricePoint returns the pricepoint of a trade between a taker and a maker order (see
+// ExecutionPricePolicy). The taker always trades at its pricepoint or better: the midpoint is
+// rounded down for a buy taker and up for a sell taker.
+func executionPricePoint(taker, maker *types.Order) *big.Int {
+	if ExecutionPricePolicy != types.ExecutionPriceMidpoint {
+		return new(big.Int).Set(maker.PricePoint)
 	}
 
-	stored.Status = "CANCELLED"
-	res := &types.EngineResponse{
-		HashID:         o.Hash,
-		Status:         "CANCELLED",
-		Order:          stored,
-		RemainingOrder: nil,
-		Matches:        nil,
+	sum := math.Add(taker.PricePoint, maker.PricePoint)
+	if taker.Side == types.OrderSideSell {
+		return math.DivideRoundUp(sum, big.NewInt(2))
 	}
 
-	return res, nil
+	return math.Div(sum, big.NewInt(2))
 }
 
 // execute function is responsible for executing of matched orders
 // i.e it deletes/updates orders in case of order matching and responds
 // with trade instance and fillOrder
+// The trade prints at the execution pricepoint of the orders (see executionPricePoint).
+// An iceberg book entry is only matched up to its displayed slice while other orders wait behind
+// it in its price level (see matchableAmount). Once its slice is filled, it goes to the back of its
+// price level with its next slice.
 func (ob *OrderBook) execute(o *types.Order, bookEntry *types.Order) (*types.Trade, error) {
 	trade := &types.Trade{}
 	tradeAmount := big.NewInt(0)
 	bookEntryAvailableAmount := math.Sub(bookEntry.Amount, bookEntry.FilledAmount)
 	orderAvailableAmount := math.Sub(o.Amount, o.FilledAmount)
+	bookEntryFilledBefore := filledAmount(bookEntry)
+	orderFilledBefore := filledAmount(o)
 
-	if math.IsGreaterThan(bookEntryAvailableAmount, orderAvailableAmount) {
-		tradeAmount = orderAvailableAmount
-		bookEntry.FilledAmount = math.Add(bookEntry.FilledAmount, orderAvailableAmount)
+	if math.IsGreaterThan(bookEntryAvailableAmount, math.Min(ob.matchableAmount(bookEntry), orderAvailableAmount)) {
+		tradeAmount = math.Min(ob.matchableAmount(bookEntry), orderAvailableAmount)
+		bookEntry.FilledAmount = math.Add(bookEntry.FilledAmount, tradeAmount)
 		bookEntry.Status = "PARTIAL_FILLED"
 
-		err := ob.updateOrder(bookEntry, tradeAmount)
-		if err != nil {
-			logger.Error(err)
-			return nil, err
+		// book entries left with less than the pair minimum amount or notional could never be
+		// filled, their unfilled amount is cancelled and they are removed from the orderbook as
+		// filled orders
+		if ob.isDust(math.Sub(bookEntry.Amount, bookEntry.FilledAmount), bookEntry.PricePoint) {
+			err := ob.deleteOrder(bookEntry)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			bookEntry.Status = "FILLED"
+		} else {
+			err := ob.updateOrder(bookEntry, tradeAmount)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			err = ob.revealNextSlice(bookEntry)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
 		}
 
 	} else {
@@ -463,7 +1368,7 @@ func (ob *OrderBook) execute(o *types.Order, bookEntry *types.Order) (*types.Tra
 	o.FilledAmount = math.Add(o.FilledAmount, tradeAmount)
 	trade = &types.Trade{
 		Amount:         tradeAmount,
-		PricePoint:     o.PricePoint,
+		PricePoint:     executionPricePoint(o, bookEntry),
 		BaseToken:      o.BaseToken,
 		QuoteToken:     o.QuoteToken,
 		OrderHash:      bookEntry.Hash,
@@ -472,11 +1377,193 @@ func (ob *OrderBook) execute(o *types.Order, bookEntry *types.Order) (*types.Tra
 		Taker:          o.UserAddress,
 		PairName:       o.PairName,
 		Maker:          bookEntry.UserAddress,
+		HashVersion:    types.CurrentTradeHashVersion,
+
+		MakerFilledBefore: bookEntryFilledBefore,
+		TakerFilledBefore: orderFilledBefore,
+	}
+
+	trade.MakeFee, trade.TakeFee = types.ComputeFees(bookEntry, trade, ob.pair.FeeSchedule())
+
+	if DebugFills && !ob.previewing {
+		err := ob.checkFill(bookEntry, bookEntryFilledBefore, tradeAmount)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		err = ob.checkFill(o, orderFilledBefore, tradeAmount)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
 	}
 
 	return trade, nil
 }
 
+// matchableAmount returns the amount of a book entry that can be matched at once. An iceberg
+// order is matched up to its displayed slice while other orders wait behind it in its price level,
+// and up to its whole unfilled amount otherwise. Previews only match the displayed slice, so that
+// they do not reveal the hidden amount.
+func (ob *OrderBook) matchableAmount(bookEntry *types.Order) *big.Int {
+	if bookEntry.IsIceberg() && (ob.previewing || !ob.book.isLast(bookEntry.Hash)) {
+		return bookEntry.DisplayedAmount()
+	}
+
+	return math.Sub(bookEntry.Amount, bookEntry.FilledAmount)
+}
+
+// revealNextSlice moves an iceberg order whose displayed slice has been filled to the back of its
+// price level, where its next slice waits behind the orders that were already in the level
+func (ob *OrderBook) revealNextSlice(o *types.Order) error {
+	if !o.IsIceberg() || new(big.Int).Mod(o.FilledAmount, o.DisplayAmount).Sign() != 0 {
+		return nil
+	}
+
+	_, orderHashListKey := o.GetOBKeys()
+	err := ob.RemoveFromPricePointHashesSet(orderHashListKey, o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = ob.AddToPricePointHashesSet(orderHashListKey, o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// isDust returns true if a non-zero unfilled amount is below the pair minimum amount, or if its
+// value at the pricepoint of its order is below the pair minimum notional
+func (ob *OrderBook) isDust(amount, pricePoint *big.Int) bool {
+	if amount.Sign() <= 0 {
+		return false
+	}
+
+	minAmount := ob.pair.MinAmount
+	if minAmount != nil && amount.Cmp(minAmount) < 0 {
+		return true
+	}
+
+	return ob.pair.IsBelowMinNotional(amount, pricePoint)
+}
+
+// splitCounterAmount splits the counter amount of an order (the sell amount of buy orders and
+// the buy amount of sell orders) between its fills and its remaining amount in proportion to
+// their amounts. The rounding remainder goes to the remaining amount, or to the last fill if the
+// order is filled, so that the counter amounts always sum up to the counter amount of the order.
+func splitCounterAmount(o *types.Order, fills []*big.Int, remaining *big.Int) ([]*big.Int, *big.Int) {
+	total := o.SellAmount
+	if o.Side == types.OrderSideSell {
+		total = o.BuyAmount
+	}
+
+	weights := append([]*big.Int{}, fills...)
+	if !math.IsZero(remaining) || len(fills) == 0 {
+		weights = append(weights, remaining)
+	}
+
+	shares := math.SplitProportionally(total, weights)
+	if len(shares) == len(fills) {
+		return shares, big.NewInt(0)
+	}
+
+	return shares[:len(fills)], shares[len(fills)]
+}
+
+// setRemainingAmounts sets the buy and sell amounts of the remaining order of a partial match.
+// The remaining order holds exactly the part of the counter amount that was not used by the fills.
+func setRemainingAmounts(res *types.EngineResponse) {
+	fills := []*big.Int{}
+	for _, m := range res.Matches {
+		fills = append(fills, m.Trade.Amount)
+	}
+
+	_, counterAmount := splitCounterAmount(res.Order, fills, res.RemainingOrder.Amount)
+	if res.Order.Side == types.OrderSideSell {
+		res.RemainingOrder.SellAmount = res.RemainingOrder.Amount
+		res.RemainingOrder.BuyAmount = counterAmount
+		return
+	}
+
+	res.RemainingOrder.BuyAmount = res.RemainingOrder.Amount
+	res.RemainingOrder.SellAmount = counterAmount
+}
+
+// fillableAmount returns the amount of an order that can be filled by the orderbook, up to the
+// unfilled amount of the order. Expired orders and orders signed for another exchange contract
+// are not counted since they are skipped by the matching. It must be run by the event loop of the
+// orderbook.
+func (ob *OrderBook) fillableAmount(o *types.Order) (*big.Int, error) {
+	unfilled := math.Sub(o.Amount, o.FilledAmount)
+	fillable := big.NewInt(0)
+	now := ob.clock()
+
+	err := ob.walkMatchingOrders(o, func(entry *types.Order) (bool, error) {
+		if entry.IsExpired(now) || entry.ExchangeAddress != o.ExchangeAddress {
+			return true, nil
+		}
+
+		fillable = math.Add(fillable, math.Sub(entry.Amount, entry.FilledAmount))
+		return fillable.Cmp(unfilled) < 0, nil
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if fillable.Cmp(unfilled) >= 0 {
+		return unfilled, nil
+	}
+
+	return fillable, nil
+}
+
+// unfilledCancelReason returns the reason why the unfilled amount of an order is cancelled instead
+// of being added to the orderbook, or an empty string for good till cancelled orders
+func unfilledCancelReason(o *types.Order) string {
+	switch o.TimeInForce {
+	case types.TimeInForceIOC:
+		return types.CancelReasonIOC
+	case types.TimeInForceFOK:
+		return types.CancelReasonFOK
+	default:
+		return ""
+	}
+}
+
+// cancelUnfilledAmount cancels the unfilled amount of the order of an engine response instead of
+// adding it to the orderbook. Orders without any match get a CANCELLED response while partially
+// matched orders keep their matches in a PARTIAL response without remaining order.
+func cancelUnfilledAmount(res *types.EngineResponse, reason string) *types.EngineResponse {
+	res.CancelledAmount = math.Sub(res.Order.Amount, res.Order.FilledAmount)
+	res.CancelReason = reason
+	res.RemainingOrder = nil
+	res.Order.Status = types.OrderStatusCancelled
+
+	res.Status = "PARTIAL"
+	if len(res.Matches) == 0 {
+		res.Status = "CANCELLED"
+	}
+
+	return res
+}
+
+// rejectOrder rejects the order of an engine response without matching it nor adding it to the
+// orderbook
+func rejectOrder(res *types.EngineResponse, reason string) *types.EngineResponse {
+	res.RejectReason = reason
+	res.RemainingOrder = nil
+	res.Order.Status = types.OrderStatusRejected
+	res.Status = "REJECTED"
+	return res
+}
+
 // buyOrder is triggered when a buy order comes in, it fetches the ask list
 // from orderbook. First it checks ths price point list to check whether the order can be matched
 // or not, if there are pricepoints that can satisfy the order then corresponding list of orders
@@ -513,21 +1600,14 @@ func (ob *OrderBook) buyOrderBis(o *types.Order) (*types.EngineResponse, error)
 			return nil, err
 		}
 
-		//TODO refactor to make the redis transaction atomic
-		for _, bookEntry := range entries {
-			entry := &types.Order{}
-			err = json.Unmarshal(bookEntry, &entry)
-			if err != nil {
-				return nil, err
-			}
-
+		for _, entry := range entries {
 			trade, err := ob.execute(o, entry)
 			if err != nil {
 				logger.Error(err)
 				return nil, err
 			}
 
-			match := &types.OrderTradePair{entry, trade}
+			match := &types.OrderTradePair{Order: entry, Trade: trade}
 			res.Matches = append(res.Matches, match)
 			res.RemainingOrder.Amount = math.Sub(res.RemainingOrder.Amount, trade.Amount)
 
@@ -546,11 +1626,7 @@ func (ob *OrderBook) buyOrderBis(o *types.Order) (*types.EngineResponse, error)
 	res.RemainingOrder.Signature = nil
 	res.RemainingOrder.Nonce = nil
 	res.RemainingOrder.Hash = common.HexToHash("")
-	res.RemainingOrder.BuyAmount = res.RemainingOrder.Amount
-	res.RemainingOrder.SellAmount = math.Div(
-		math.Mul(res.RemainingOrder.Amount, res.Order.SellAmount),
-		res.Order.BuyAmount,
-	)
+	setRemainingAmounts(res)
 
 	return res, nil
 }
@@ -2,17 +2,23 @@ package engine
 
 import (
 	"log"
+	"math/big"
+	"math/rand"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/redis"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
 	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
 	"github.com/Proofsuite/amp-matching-engine/utils/units"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func setupTest() (
@@ -39,6 +45,8 @@ func setupTest() (
 	pairDao.On("GetAll").Return([]types.Pair{*pair}, nil)
 
 	eng := NewEngine(redisConn, rabbitConn, pairDao)
+	reconcile(eng, []*types.Order{})
+
 	ex := testutils.GetTestAddress1()
 	maker := testutils.GetTestWallet1()
 	taker := testutils.GetTestWallet2()
@@ -64,9 +72,57 @@ func setupTest() (
 	return eng, ob, ex, maker, taker, pair, zrx, weth, factory1, factory2
 }
 
+// zeroFees sets the fees of the expected trades to those of the test pair, which charges no fees
+func zeroFees(trades ...*types.Trade) {
+	for _, t := range trades {
+		t.MakeFee = big.NewInt(0)
+		t.TakeFee = big.NewInt(0)
+	}
+}
+
+// takerFills sets the amounts filled before the expected trades of a taker order matching
+// unfilled maker orders in turn, in the order of the trades
+func takerFills(trades ...*types.Trade) {
+	filled := big.NewInt(0)
+	for _, t := range trades {
+		t.MakerFilledBefore = big.NewInt(0)
+		t.TakerFilledBefore = filled
+		filled = math.Add(filled, t.Amount)
+	}
+}
+
+// reconcile reconciles the orderbooks of the engine with the given open orders and pending trades.
+// The trades of the orders are the pending trades.
+func reconcile(e *Engine, open []*types.Order, pending ...*types.Trade) error {
+	orderDao := new(mocks.OrderDao)
+	orderDao.On("GetRawOrderBook", mock.Anything).Return(open, nil)
+
+	tradeDao := new(mocks.TradeDao)
+	tradeDao.On("GetPendingByPairAddress", mock.Anything, mock.Anything).Return(pending, nil)
+	for _, t := range pending {
+		trades := []*types.Trade{}
+		for _, pt := range pending {
+			if pt.OrderHash == t.OrderHash {
+				trades = append(trades, pt)
+			}
+		}
+
+		tradeDao.On("GetByOrderHash", t.OrderHash).Return(trades, nil)
+	}
+
+	return e.Reconcile(orderDao, tradeDao)
+}
+
+// teardown waits until the orderbook changes of the engine have been written to redis, so that
+// they can not be recovered by the engine of the next test, and flushes redis
+func teardown(e *Engine) {
+	e.Close()
+	e.redisConn.FlushAll()
+}
+
 func TestAddOrder(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8)
 	o2, _ := factory2.NewSellOrder(1e3, 1e8)
@@ -74,70 +130,38 @@ func TestAddOrder(t *testing.T) {
 	e.addOrder(&o1)
 
 	pricePointSetKey, orderHashListKey := o1.GetOBKeys()
-
-	pricepoints, err := e.redisConn.GetSortedSet(pricePointSetKey)
-	if err != nil {
-		t.Error("Error getting sorted set")
-	}
-
-	pricePointHashes, err := e.redisConn.GetSortedSet(orderHashListKey)
-	if err != nil {
-		t.Error("Error getting pricepoints order hashes set")
-	}
+	pricepoints := ob.GetPricePointSet(pricePointSetKey)
+	pricePointHashes := ob.GetPricePointHashes(orderHashListKey)
 
 	stored, err := ob.GetFromOrderMap(o1.Hash)
 	if err != nil {
 		t.Error("Error getting sorted set", err)
 	}
 
-	// volume, err := ob.GetPricePointVolume(pricePointSetKey, o1.PricePoint.Int64())
-	// if err != nil {
-	// 	t.Error("Error getting volume set", err)
-	// }
-
-	assert.Equal(t, 1, len(pricepoints))
-	assert.Contains(t, pricepoints, utils.UintToPaddedString(o1.PricePoint.Int64()))
-	assert.Equal(t, 1, len(pricePointHashes))
-	assert.Contains(t, pricePointHashes, o1.Hash.Hex())
-	assert.Equal(t, int64(pricePointHashes[o1.Hash.Hex()]), o1.CreatedAt.Unix())
-	// assert.Equal(t, int64(1e8), volume)
+	assert.Equal(t, []int64{o1.PricePoint.Int64()}, pricepoints)
+	assert.Equal(t, []common.Hash{o1.Hash}, pricePointHashes)
 	testutils.CompareOrder(t, &o1, stored)
 
 	e.addOrder(&o2)
 
 	pricePointSetKey, orderHashListKey = o2.GetOBKeys()
-	pricepoints, err = e.redisConn.GetSortedSet(pricePointSetKey)
-	if err != nil {
-		t.Error(err)
-	}
-
-	pricePointHashes, err = e.redisConn.GetSortedSet(orderHashListKey)
-	if err != nil {
-		t.Error("Error getting pricepoints order hashes set")
-	}
+	pricepoints = ob.GetPricePointSet(pricePointSetKey)
+	pricePointHashes = ob.GetPricePointHashes(orderHashListKey)
 
 	stored, err = ob.GetFromOrderMap(o2.Hash)
 	if err != nil {
 		t.Error("Error getting order from map", err)
 	}
 
-	// volume, err = ob.GetPricePointVolume(pricePointSetKey, o2.PricePoint.Int64())
-	// if err != nil {
-	// 	t.Error("Error getting volume set", err)
-	// }
-
-	assert.Equal(t, 1, len(pricepoints))
-	assert.Contains(t, pricepoints, utils.UintToPaddedString(o2.PricePoint.Int64()))
-	assert.Equal(t, 2, len(pricePointHashes))
-	assert.Contains(t, pricePointHashes, o2.Hash.Hex())
-	assert.Equal(t, int64(pricePointHashes[o2.Hash.Hex()]), o2.CreatedAt.Unix())
-	// assert.Equal(t, int64(2e8), volume)
+	// orders of the same price level are kept in arrival order
+	assert.Equal(t, []int64{o2.PricePoint.Int64()}, pricepoints)
+	assert.Equal(t, []common.Hash{o1.Hash, o2.Hash}, pricePointHashes)
 	testutils.CompareOrder(t, &o2, stored)
 }
 
 func TestUpdateOrder(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8)
 
@@ -156,103 +180,57 @@ func TestUpdateOrder(t *testing.T) {
 	}
 
 	pricePointSetKey, orderHashListKey := o1.GetOBKeys()
-
-	pricepoints, err := ob.redisConn.GetSortedSet(pricePointSetKey)
-	if err != nil {
-		t.Error("Error getting pricepoint set", err)
-	}
-
-	pricePointHashes, err := ob.redisConn.GetSortedSet(orderHashListKey)
-	if err != nil {
-		t.Error("Error getting pricepoint hash set", err)
-	}
-
-	// volume, err := ob.GetPricePointVolume(pricePointSetKey, o1.PricePoint.Int64())
-	// if err != nil {
-	// 	t.Error("Error getting pricepoint volume", err)
-	// }
+	pricepoints := ob.GetPricePointSet(pricePointSetKey)
+	pricePointHashes := ob.GetPricePointHashes(orderHashListKey)
 
 	stored, err := ob.GetFromOrderMap(o1.Hash)
 	if err != nil {
 		t.Error(err)
 	}
 
-	assert.Equal(t, 1, len(pricepoints))
-	assert.Contains(t, pricepoints, utils.UintToPaddedString(o1.PricePoint.Int64()))
+	assert.Equal(t, []int64{o1.PricePoint.Int64()}, pricepoints)
 	testutils.Compare(t, &exp1, stored)
-
-	assert.Equal(t, 1, len(pricePointHashes))
-	assert.Contains(t, pricePointHashes, o1.Hash.Hex())
-	assert.Equal(t, int64(pricePointHashes[o1.Hash.Hex()]), o1.CreatedAt.Unix())
-	// assert.Equal(t, int64(99999000), volume)
+	assert.Equal(t, []common.Hash{o1.Hash}, pricePointHashes)
 }
 
 func TestDeleteOrder(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8)
 
 	e.addOrder(&o1)
 
 	pricePointSetKey, orderHashListKey := o1.GetOBKeys()
-
-	pricepoints, err := e.redisConn.GetSortedSet(pricePointSetKey)
-	if err != nil {
-		t.Error(err)
-	}
-
-	pricePointHashes, err := e.redisConn.GetSortedSet(orderHashListKey)
-	if err != nil {
-		t.Error(err)
-	}
-
-	// volume, err := ob.GetPricePointVolume(pricePointSetKey, o1.PricePoint.Int64())
-	// if err != nil {
-	// 	t.Error(err)
-	// }
+	pricepoints := ob.GetPricePointSet(pricePointSetKey)
+	pricePointHashes := ob.GetPricePointHashes(orderHashListKey)
 
 	stored, err := ob.GetFromOrderMap(o1.Hash)
 	if err != nil {
 		t.Error(err)
 	}
 
-	assert.Equal(t, 1, len(pricepoints))
-	assert.Contains(t, pricepoints, utils.UintToPaddedString(o1.PricePoint.Int64()))
+	assert.Equal(t, []int64{o1.PricePoint.Int64()}, pricepoints)
 	testutils.CompareOrder(t, &o1, stored)
-
-	assert.Equal(t, 1, len(pricePointHashes))
-	assert.Contains(t, pricePointHashes, o1.Hash.Hex())
-	assert.Equal(t, int64(pricePointHashes[o1.Hash.Hex()]), o1.CreatedAt.Unix())
-	// assert.Equal(t, int64(100000000), volume)
+	assert.Equal(t, []common.Hash{o1.Hash}, pricePointHashes)
 
 	err = ob.deleteOrder(&o1)
 	if err != nil {
 		t.Error(err)
 	}
 
-	pricePointSetKey, orderHashListKey = o1.GetOBKeys()
+	assert.Equal(t, 0, len(ob.GetPricePointSet(pricePointSetKey)))
+	assert.Equal(t, 0, len(ob.GetPricePointHashes(orderHashListKey)))
 
-	if e.redisConn.Exists(pricePointSetKey) {
-		t.Errorf("Key: %v expected to be deleted but exists", pricePointSetKey)
-	}
-
-	if e.redisConn.Exists(orderHashListKey) {
-		t.Errorf("Key: %v expected to be deleted but key exists", pricePointSetKey)
-	}
-
-	if e.redisConn.Exists(orderHashListKey + "::" + o1.Hash.Hex()) {
-		t.Errorf("Key: %v expected to be deleted but key exists", pricePointSetKey)
-	}
-
-	if e.redisConn.Exists(pricePointSetKey + "::book::" + utils.UintToPaddedString(o1.PricePoint.Int64())) {
-		t.Errorf("Key: %v expected to be deleted but key exists", pricePointSetKey)
+	_, err = ob.GetFromOrderMap(o1.Hash)
+	if err == nil {
+		t.Errorf("Order %v expected to be deleted but exists", o1.Hash.Hex())
 	}
 }
 
 func TestCancelOrder(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8)
 	o2, _ := factory1.NewSellOrder(1e3, 1e8)
@@ -264,6 +242,7 @@ func TestCancelOrder(t *testing.T) {
 	expectedOrder.Status = "CANCELLED"
 	expected := &types.EngineResponse{
 		Status:         "CANCELLED",
+		HashID:         o2.Hash,
 		Order:          &expectedOrder,
 		RemainingOrder: nil,
 		Matches:        nil,
@@ -271,20 +250,13 @@ func TestCancelOrder(t *testing.T) {
 
 	pricePointSetKey, orderHashListKey := o1.GetOBKeys()
 
-	pricepoints, _ := e.redisConn.GetSortedSet(pricePointSetKey)
-	pricePointHashes, _ := e.redisConn.GetSortedSet(orderHashListKey)
-	// volume, _ := ob.GetPricePointVolume(pricePointSetKey, o2.PricePoint.Int64())
+	pricepoints := ob.GetPricePointSet(pricePointSetKey)
+	pricePointHashes := ob.GetPricePointHashes(orderHashListKey)
 	stored1, _ := ob.GetFromOrderMap(o1.Hash)
 	stored2, _ := ob.GetFromOrderMap(o2.Hash)
 
-	assert.Equal(t, 1, len(pricepoints))
-	assert.Contains(t, pricepoints, utils.UintToPaddedString(o1.PricePoint.Int64()))
-	assert.Equal(t, 2, len(pricePointHashes))
-	assert.Contains(t, pricePointHashes, o1.Hash.Hex())
-	assert.Contains(t, pricePointHashes, o2.Hash.Hex())
-	assert.Equal(t, int64(pricePointHashes[o1.Hash.Hex()]), o1.CreatedAt.Unix())
-	assert.Equal(t, int64(pricePointHashes[o2.Hash.Hex()]), o2.CreatedAt.Unix())
-	// assert.Equal(t, int64(200000000), volume)
+	assert.Equal(t, []int64{o1.PricePoint.Int64()}, pricepoints)
+	assert.Equal(t, []common.Hash{o1.Hash, o2.Hash}, pricePointHashes)
 	testutils.Compare(t, &o1, stored1)
 	testutils.Compare(t, &o2, stored2)
 
@@ -295,28 +267,20 @@ func TestCancelOrder(t *testing.T) {
 
 	testutils.Compare(t, expected, res)
 
-	pricePointSetKey, orderHashListKey = o1.GetOBKeys()
-
-	pricepoints, _ = e.redisConn.GetSortedSet(pricePointSetKey)
-	pricePointHashes, _ = e.redisConn.GetSortedSet(orderHashListKey)
-	// volume, _ = ob.GetPricePointVolume(pricePointSetKey, o2.PricePoint.Int64())
+	pricepoints = ob.GetPricePointSet(pricePointSetKey)
+	pricePointHashes = ob.GetPricePointHashes(orderHashListKey)
 	stored1, _ = ob.GetFromOrderMap(o1.Hash)
 	stored2, _ = ob.GetFromOrderMap(o2.Hash)
 
-	assert.Equal(t, 1, len(pricepoints))
-	assert.Contains(t, pricepoints, utils.UintToPaddedString(o1.PricePoint.Int64()))
-	assert.Equal(t, 1, len(pricePointHashes))
-	assert.Contains(t, pricePointHashes, o1.Hash.Hex())
-	assert.NotContains(t, pricePointHashes, o2.Hash.Hex())
-	assert.Equal(t, int64(pricePointHashes[o1.Hash.Hex()]), o1.CreatedAt.Unix())
-	// assert.Equal(t, int64(100000000), volume)
+	assert.Equal(t, []int64{o1.PricePoint.Int64()}, pricepoints)
+	assert.Equal(t, []common.Hash{o1.Hash}, pricePointHashes)
 	testutils.Compare(t, &o1, stored1)
 	testutils.Compare(t, nil, stored2)
 }
 
 func TestSellOrder(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8, 0)
 
@@ -339,7 +303,7 @@ func TestSellOrder(t *testing.T) {
 
 func TestBuyOrder(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewBuyOrder(1e3, 1e8, 0)
 
@@ -362,11 +326,13 @@ func TestBuyOrder(t *testing.T) {
 
 func TestFillOrder1(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8)
 	o2, _ := factory2.NewBuyOrder(1e3, 1e8)
 	expectedTrade, _ := types.NewUnsignedTrade1(&o1, &o2, units.Ethers(1e8))
+	zeroFees(&expectedTrade)
+	takerFills(&expectedTrade)
 
 	exp1 := o1
 	exp1.Status = "OPEN"
@@ -386,7 +352,7 @@ func TestFillOrder1(t *testing.T) {
 	expectedBuyOrderResponse := &types.EngineResponse{
 		Status:  "FULL",
 		Order:   &exp2,
-		Matches: []*types.OrderTradePair{{&ex3, &expectedTrade}},
+		Matches: []*types.OrderTradePair{{Order: &ex3, Trade: &expectedTrade}},
 	}
 
 	sellOrderResponse, err := ob.sellOrder(&o1)
@@ -405,11 +371,13 @@ func TestFillOrder1(t *testing.T) {
 
 func TestFillOrder2(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	o1, _ := factory1.NewBuyOrder(1e3, 1e8)
 	o2, _ := factory2.NewSellOrder(1e3, 1e8)
 	expectedTrade, _ := types.NewUnsignedTrade1(&o1, &o2, utils.Ethers(1e8))
+	zeroFees(&expectedTrade)
+	takerFills(&expectedTrade)
 
 	exp1 := o1
 	exp1.Status = "OPEN"
@@ -428,7 +396,7 @@ func TestFillOrder2(t *testing.T) {
 	expectedSellOrderResponse := &types.EngineResponse{
 		Status:  "FULL",
 		Order:   &exp2,
-		Matches: []*types.OrderTradePair{{&ex3, &expectedTrade}},
+		Matches: []*types.OrderTradePair{{Order: &ex3, Trade: &expectedTrade}},
 	}
 
 	res1, err := ob.buyOrder(&o1)
@@ -445,9 +413,83 @@ func TestFillOrder2(t *testing.T) {
 	testutils.Compare(t, expectedSellOrderResponse, res2)
 }
 
+func TestMatchExpiredOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// the sell order expires after being placed in the orderbook but before being matched
+	factory1.Params.Expires = big.NewInt(time.Now().Add(time.Second).Unix())
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	o2, _ := factory2.NewBuyOrder(1e3, 1e8)
+
+	_, err := ob.sellOrder(&o1)
+	if err != nil {
+		t.Errorf("Error when calling sell order")
+	}
+
+	time.Sleep(2 * time.Second)
+
+	res, err := ob.buyOrder(&o2)
+	if err != nil {
+		t.Errorf("Error when calling buy order")
+	}
+
+	assert.Equal(t, "NOMATCH", res.Status)
+	assert.Equal(t, 0, len(res.Matches))
+
+	_, err = ob.GetFromOrderMap(o1.Hash)
+	assert.NotNil(t, err)
+
+	stored, err := ob.GetFromOrderMap(o2.Hash)
+	if err != nil {
+		t.Errorf("Error getting order from map: %v", err)
+	}
+
+	assert.Equal(t, "OPEN", stored.Status)
+}
+
+func TestPruneExpiredOrders(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	factory1.Params.Expires = big.NewInt(time.Now().Add(time.Hour).Unix())
+	factory2.Params.Expires = big.NewInt(0)
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	o2, _ := factory2.NewSellOrder(1e3, 1e8)
+
+	e.addOrder(&o1)
+	e.addOrder(&o2)
+
+	err := e.PruneExpiredOrders(time.Now())
+	if err != nil {
+		t.Error(err)
+	}
+
+	orders, err := ob.GetAllOrders()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 2, len(orders))
+
+	err = e.PruneExpiredOrders(time.Now().Add(2 * time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+
+	orders, err = ob.GetAllOrders()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o2.Hash, orders[0].Hash)
+}
+
 func TestMultiMatchOrder1(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	so1, _ := factory1.NewSellOrder(1e3+1, 1e8)
 	so2, _ := factory1.NewSellOrder(1e3+2, 1e8)
@@ -474,12 +516,17 @@ func TestMultiMatchOrder1(t *testing.T) {
 	trade1, _ := types.NewUnsignedTrade1(&so1, &bo1, utils.Ethers(1e8))
 	trade2, _ := types.NewUnsignedTrade1(&so2, &bo1, utils.Ethers(1e8))
 	trade3, _ := types.NewUnsignedTrade1(&so3, &bo1, utils.Ethers(1e8))
+	zeroFees(&trade1, &trade2, &trade3)
+	takerFills(&trade1, &trade2, &trade3)
 
 	expectedResponse := &types.EngineResponse{
-		"FULL",
-		&expbo1,
-		nil,
-		[]*types.OrderTradePair{{&expso1, &trade1}, {&expso2, &trade2}, {&expso3, &trade3}},
+		Status: "FULL",
+		Order:  &expbo1,
+		Matches: []*types.OrderTradePair{
+			{Order: &expso1, Trade: &trade1},
+			{Order: &expso2, Trade: &trade2},
+			{Order: &expso3, Trade: &trade3},
+		},
 	}
 
 	response, err := ob.buyOrder(&bo1)
@@ -492,7 +539,7 @@ func TestMultiMatchOrder1(t *testing.T) {
 
 func TestMultiMatchOrder2(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	bo1, _ := factory1.NewBuyOrder(1e3+1, 1e8)
 	bo2, _ := factory1.NewBuyOrder(1e3+2, 1e8)
@@ -519,12 +566,17 @@ func TestMultiMatchOrder2(t *testing.T) {
 	trade1, _ := types.NewUnsignedTrade1(&bo1, &so1, units.Ethers(1e8))
 	trade2, _ := types.NewUnsignedTrade1(&bo2, &so1, units.Ethers(1e8))
 	trade3, _ := types.NewUnsignedTrade1(&bo3, &so1, units.Ethers(1e8))
+	zeroFees(&trade1, &trade2, &trade3)
+	takerFills(&trade3, &trade2, &trade1)
 
 	expectedResponse := &types.EngineResponse{
-		"FULL",
-		&expso1,
-		nil,
-		[]*types.OrderTradePair{{&expbo3, &trade3}, {&expbo2, &trade2}, {&expbo1, &trade1}},
+		Status: "FULL",
+		Order:  &expso1,
+		Matches: []*types.OrderTradePair{
+			{Order: &expbo3, Trade: &trade3},
+			{Order: &expbo2, Trade: &trade2},
+			{Order: &expbo1, Trade: &trade1},
+		},
 	}
 
 	res, err := ob.sellOrder(&so1)
@@ -537,7 +589,7 @@ func TestMultiMatchOrder2(t *testing.T) {
 
 func TestPartialMatchOrder1(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	so1, _ := factory1.NewSellOrder(1e3+1, 1e8)
 	so2, _ := factory1.NewSellOrder(1e3+2, 1e8)
@@ -565,6 +617,8 @@ func TestPartialMatchOrder1(t *testing.T) {
 	trade2, _ := types.NewUnsignedTrade1(&so2, &bo1, units.Ethers(1e8))
 	trade3, _ := types.NewUnsignedTrade1(&so3, &bo1, units.Ethers(1e8))
 	trade4, _ := types.NewUnsignedTrade1(&so4, &bo1, units.Ethers(1e8))
+	zeroFees(&trade1, &trade2, &trade3, &trade4)
+	takerFills(&trade1, &trade2, &trade3, &trade4)
 
 	ob.sellOrder(&so1)
 	ob.sellOrder(&so2)
@@ -577,10 +631,14 @@ func TestPartialMatchOrder1(t *testing.T) {
 	}
 
 	expectedResponse := &types.EngineResponse{
-		"FULL",
-		&expbo1,
-		nil,
-		[]*types.OrderTradePair{{&expso1, &trade1}, {&expso2, &trade2}, {&expso3, &trade3}, {&expso4, &trade4}},
+		Status: "FULL",
+		Order:  &expbo1,
+		Matches: []*types.OrderTradePair{
+			{Order: &expso1, Trade: &trade1},
+			{Order: &expso2, Trade: &trade2},
+			{Order: &expso3, Trade: &trade3},
+			{Order: &expso4, Trade: &trade4},
+		},
 	}
 
 	testutils.Compare(t, expectedResponse, res)
@@ -588,7 +646,7 @@ func TestPartialMatchOrder1(t *testing.T) {
 
 func TestPartialMatchOrder2(t *testing.T) {
 	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
-	defer e.redisConn.FlushAll()
+	defer teardown(e)
 
 	bo1, _ := factory1.NewBuyOrder(1e3+5, 1e8)
 	bo2, _ := factory1.NewBuyOrder(1e3+4, 1e8)
@@ -617,6 +675,8 @@ func TestPartialMatchOrder2(t *testing.T) {
 	trade2, _ := types.NewUnsignedTrade1(&bo2, &so1, utils.Ethers(1e8))
 	trade3, _ := types.NewUnsignedTrade1(&bo3, &so1, utils.Ethers(1e8))
 	trade4, _ := types.NewUnsignedTrade1(&bo4, &so1, utils.Ethers(1e8))
+	zeroFees(&trade1, &trade2, &trade3, &trade4)
+	takerFills(&trade1, &trade2, &trade3, &trade4)
 
 	ob.buyOrder(&bo1)
 	ob.buyOrder(&bo2)
@@ -629,15 +689,82 @@ func TestPartialMatchOrder2(t *testing.T) {
 	}
 
 	expectedResponse := &types.EngineResponse{
-		"FULL",
-		&expso1,
-		nil,
-		[]*types.OrderTradePair{{&expbo1, &trade1}, {&expbo2, &trade2}, {&expbo3, &trade3}, {&expbo4, &trade4}},
+		Status: "FULL",
+		Order:  &expso1,
+		Matches: []*types.OrderTradePair{
+			{Order: &expbo1, Trade: &trade1},
+			{Order: &expbo2, Trade: &trade2},
+			{Order: &expbo3, Trade: &trade3},
+			{Order: &expbo4, Trade: &trade4},
+		},
 	}
 
 	testutils.Compare(t, expectedResponse, res)
 }
 
+func TestRestPartiallyMatchedOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	so1, _ := factory1.NewSellOrder(1e3+1, 1e8)
+	so2, _ := factory1.NewSellOrder(1e3+2, 1e8)
+	so3, _ := factory1.NewSellOrder(1e3+3, 1e8)
+	bo1, _ := factory2.NewBuyOrder(1e3+2, 3e8)
+
+	ob.sellOrder(&so1)
+	ob.sellOrder(&so2)
+	ob.sellOrder(&so3)
+
+	// the buy order walks the price levels it crosses, one trade per maker order
+	res, err := ob.buyOrder(&bo1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "PARTIAL", res.Status)
+	assert.Equal(t, "PARTIAL_FILLED", res.Order.Status)
+	assert.Equal(t, units.Ethers(2e8), res.Order.FilledAmount)
+	assert.Nil(t, res.RemainingOrder)
+	assert.Equal(t, 2, len(res.Matches))
+	assert.Equal(t, so1.Hash, res.Matches[0].Trade.OrderHash)
+	assert.Equal(t, so2.Hash, res.Matches[1].Trade.OrderHash)
+
+	// the unfilled amount rests in the orderbook under the hash of the buy order
+	stored, err := ob.GetFromOrderMap(bo1.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "PARTIAL_FILLED", stored.Status)
+	assert.Equal(t, units.Ethers(2e8), stored.FilledAmount)
+
+	// the orders are matched outside of the event loop, which would publish the view read by
+	// GetOrderBook
+	ob.publishView()
+	book, err := e.GetOrderBook(ob.pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(book.Bids))
+	assert.Equal(t, units.Ethers(1e8), book.Bids[0].Amount)
+	assert.Equal(t, 1, len(book.Asks))
+
+	// the resting amount is matched like any other order of the orderbook, the last maker of
+	// the walk is partially consumed
+	so4, _ := factory1.NewSellOrder(1e3+2, 5e7)
+	res, err = ob.sellOrder(&so4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 1, len(res.Matches))
+	assert.Equal(t, bo1.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, "PARTIAL_FILLED", res.Matches[0].Order.Status)
+	assert.Equal(t, units.Ethers(25e7), res.Matches[0].Order.FilledAmount)
+}
+
 // func TestRecoverOrders(t *testing.T) {
 // 	e, _, _, _, _, _, _, factory1, factory2 := setupTest()
 // 	defer e.redisConn.FlushAll()
@@ -841,3 +968,1040 @@ func TestPartialMatchOrder2(t *testing.T) {
 // 	assert.JSONEq(t, string(etb), string(tb))
 // 	assert.JSONEq(t, string(efob), string(fob))
 // }
+
+func TestMatchOrdersFromDifferentExchanges(t *testing.T) {
+	e, ob, _, maker, taker, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// factory3 and factory4 create orders for a newly deployed exchange contract
+	factory3, err := testutils.NewOrderFactory(pair, maker, testutils.GetTestAddress2())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factory4, err := testutils.NewOrderFactory(pair, taker, testutils.GetTestAddress2())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	so1, _ := factory1.NewSellOrder(1e3, 1e8)
+	so2, _ := factory3.NewSellOrder(1e3, 1e8)
+	bo1, _ := factory2.NewBuyOrder(1e3, 2e8)
+	bo2, _ := factory4.NewBuyOrder(1e3, 1e8)
+
+	ob.sellOrder(&so1)
+	ob.sellOrder(&so2)
+
+	res, err := ob.buyOrder(&bo1)
+	if err != nil {
+		t.Errorf("Error when calling buy order: %v", err)
+	}
+
+	assert.Equal(t, "PARTIAL", res.Status)
+	assert.Equal(t, 1, len(res.Matches))
+	assert.Equal(t, so1.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, units.Ethers(1e8), res.Matches[0].Trade.Amount)
+
+	// the sell order of the second exchange is still in the orderbook
+	stored, err := ob.GetFromOrderMap(so2.Hash)
+	if err != nil {
+		t.Errorf("Error getting order from map: %v", err)
+	}
+
+	assert.Equal(t, "OPEN", stored.Status)
+
+	res, err = ob.buyOrder(&bo2)
+	if err != nil {
+		t.Errorf("Error when calling buy order: %v", err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 1, len(res.Matches))
+	assert.Equal(t, so2.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, testutils.GetTestAddress2(), res.Matches[0].Order.ExchangeAddress)
+}
+
+func TestSplitCounterAmount(t *testing.T) {
+	o := &types.Order{Side: types.OrderSideBuy, BuyAmount: big.NewInt(3), SellAmount: big.NewInt(100)}
+
+	counter, remaining := splitCounterAmount(o, []*big.Int{big.NewInt(1), big.NewInt(1)}, big.NewInt(1))
+	assert.Equal(t, []*big.Int{big.NewInt(33), big.NewInt(33)}, counter)
+	assert.Equal(t, big.NewInt(34), remaining)
+
+	// the last fill absorbs the remainder when the order is filled
+	counter, remaining = splitCounterAmount(o, []*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1)}, big.NewInt(0))
+	assert.Equal(t, []*big.Int{big.NewInt(33), big.NewInt(33), big.NewInt(34)}, counter)
+	assert.Equal(t, 0, remaining.Sign())
+
+	o = &types.Order{Side: types.OrderSideSell, BuyAmount: big.NewInt(100), SellAmount: big.NewInt(3)}
+	counter, remaining = splitCounterAmount(o, []*big.Int{big.NewInt(2)}, big.NewInt(1))
+	assert.Equal(t, []*big.Int{big.NewInt(66)}, counter)
+	assert.Equal(t, big.NewInt(34), remaining)
+}
+
+// TestFillConservation fills random orders in random chunks over successive partial matches
+// and checks that the fills and their counter amounts sum up to the order amounts
+func TestFillConservation(t *testing.T) {
+	conservation := func(seed int64, sell bool) bool {
+		r := rand.New(rand.NewSource(seed))
+		side := types.OrderSideBuy
+		if sell {
+			side = types.OrderSideSell
+		}
+
+		o := &types.Order{
+			Side:       side,
+			BuyAmount:  new(big.Int).Rand(r, units.Ethers(1e3)),
+			SellAmount: new(big.Int).Rand(r, units.Ethers(1e3)),
+		}
+
+		o.BuyAmount.Add(o.BuyAmount, big.NewInt(1))
+		o.Amount = o.BuyAmount
+		counterTotal := o.SellAmount
+		if sell {
+			o.Amount = o.SellAmount
+			counterTotal = o.BuyAmount
+		}
+
+		amount := o.Amount
+		filled := big.NewInt(0)
+		counterFilled := big.NewInt(0)
+
+		for o != nil {
+			res := &types.EngineResponse{Order: o, RemainingOrder: o.Clone()}
+			for n := r.Intn(5) + 1; n > 0 && res.RemainingOrder.Amount.Sign() > 0; n-- {
+				fill := new(big.Int).Rand(r, res.RemainingOrder.Amount)
+				fill.Add(fill, big.NewInt(1))
+				res.RemainingOrder.Amount = math.Sub(res.RemainingOrder.Amount, fill)
+				res.Matches = append(res.Matches, &types.OrderTradePair{Trade: &types.Trade{Amount: fill}})
+				filled = math.Add(filled, fill)
+			}
+
+			fills := []*big.Int{}
+			for _, m := range res.Matches {
+				fills = append(fills, m.Trade.Amount)
+			}
+
+			counter, _ := splitCounterAmount(o, fills, res.RemainingOrder.Amount)
+			for _, c := range counter {
+				counterFilled = math.Add(counterFilled, c)
+			}
+
+			if res.RemainingOrder.Amount.Sign() == 0 {
+				break
+			}
+
+			setRemainingAmounts(res)
+			o = res.RemainingOrder
+		}
+
+		return filled.Cmp(amount) == 0 && counterFilled.Cmp(counterTotal) == 0
+	}
+
+	config := &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(conservation, config); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCheckFill checks random fill sequences of random orders with the debug fill check
+func TestCheckFill(t *testing.T) {
+	ob := &OrderBook{}
+
+	check := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		o := &types.Order{
+			Hash:       common.BigToHash(big.NewInt(seed)),
+			Amount:     new(big.Int).Rand(r, units.Ethers(1e3)),
+			SellAmount: new(big.Int).Rand(r, units.Ethers(1e3)),
+			BuyAmount:  new(big.Int).Rand(r, units.Ethers(1e3)),
+		}
+
+		o.Amount.Add(o.Amount, big.NewInt(1))
+
+		filled := big.NewInt(0)
+		for filled.Cmp(o.Amount) < 0 {
+			amount := new(big.Int).Rand(r, math.Sub(o.Amount, filled))
+			amount.Add(amount, big.NewInt(1))
+
+			if err := ob.checkFill(o, filled, amount); err != nil {
+				return false
+			}
+
+			filled = math.Add(filled, amount)
+		}
+
+		// the totals of filled orders are not kept
+		return ob.fills[o.Hash] == nil
+	}
+
+	config := &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(check, config); err != nil {
+		t.Error(err)
+	}
+
+	// fills exceeding the order amount are reported
+	o := &types.Order{Amount: big.NewInt(10), SellAmount: big.NewInt(7), BuyAmount: big.NewInt(3)}
+	assert.Nil(t, ob.checkFill(o, big.NewInt(0), big.NewInt(6)))
+	assert.Error(t, ob.checkFill(o, big.NewInt(6), big.NewInt(5)))
+}
+
+func TestStopOrders(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	newStop := func(f *testutils.OrderFactory, side types.OrderSide, stop int64) *types.Order {
+		o, _ := f.NewBuyOrder(1e3, 1e8)
+		if side == types.OrderSideSell {
+			o, _ = f.NewSellOrder(1e3, 1e8)
+		}
+
+		o.Status = types.OrderStatusStop
+		o.StopPricepoint = big.NewInt(stop)
+		return &o
+	}
+
+	buy1 := newStop(factory1, types.OrderSideBuy, 1100)
+	buy2 := newStop(factory2, types.OrderSideBuy, 1200)
+	sell1 := newStop(factory1, types.OrderSideSell, 900)
+	sell2 := newStop(factory2, types.OrderSideSell, 800)
+
+	for _, o := range []*types.Order{buy2, buy1, sell2, sell1} {
+		err := ob.AddToStopIndex(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// buy stops are triggered when the price rises to their stop pricepoint
+	triggered, err := ob.GetTriggeredStopOrders(types.OrderSideBuy, 1199)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(triggered))
+	assert.Equal(t, buy1.Hash, triggered[0].Hash)
+
+	triggered, _ = ob.GetTriggeredStopOrders(types.OrderSideBuy, 1200)
+	assert.Equal(t, 2, len(triggered))
+	assert.Equal(t, buy1.Hash, triggered[0].Hash)
+	assert.Equal(t, buy2.Hash, triggered[1].Hash)
+
+	// sell stops are triggered when the price falls to their stop pricepoint, the highest first
+	triggered, _ = ob.GetTriggeredStopOrders(types.OrderSideSell, 901)
+	assert.Equal(t, 0, len(triggered))
+
+	triggered, _ = ob.GetTriggeredStopOrders(types.OrderSideSell, 800)
+	assert.Equal(t, 2, len(triggered))
+	assert.Equal(t, sell1.Hash, triggered[0].Hash)
+	assert.Equal(t, sell2.Hash, triggered[1].Hash)
+
+	// triggered stops leave the stop index so that they are only triggered once
+	err = ob.triggerStopOrders(big.NewInt(1150))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ob.triggerStopOrders(big.NewInt(1150))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stops, err := ob.GetAllStopOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, len(stops))
+	for _, o := range stops {
+		assert.NotEqual(t, buy1.Hash, o.Hash)
+	}
+
+	// dormant stops are not part of the orderbook
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+
+	res, err := ob.CancelOrder(buy2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "STOP_CANCELLED", res.Status)
+	assert.Equal(t, "CANCELLED", res.Order.Status)
+
+	stops, _ = ob.GetAllStopOrders()
+	assert.Equal(t, 2, len(stops))
+}
+
+func TestTrailingStopOrders(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	newTrailingStop := func(f *testutils.OrderFactory, side types.OrderSide, stop int64, trailingType types.TrailingType, offset int64) *types.Order {
+		o, _ := f.NewBuyOrder(1e3, 1e8)
+		if side == types.OrderSideSell {
+			o, _ = f.NewSellOrder(1e3, 1e8)
+		}
+
+		o.Status = types.OrderStatusStop
+		o.StopPricepoint = big.NewInt(stop)
+		o.TrailingType = trailingType
+		o.TrailingOffset = big.NewInt(offset)
+		return &o
+	}
+
+	sell1 := newTrailingStop(factory1, types.OrderSideSell, 900, types.TrailingAbsolute, 50)
+	sell2 := newTrailingStop(factory2, types.OrderSideSell, 960, types.TrailingAbsolute, 50)
+	sell3 := newTrailingStop(factory1, types.OrderSideSell, 850, types.TrailingPercent, 1000)
+	buy1 := newTrailingStop(factory2, types.OrderSideBuy, 1100, "", 50)
+
+	for _, o := range []*types.Order{sell1, sell2, sell3, buy1} {
+		err := ob.AddToStopIndex(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stopPricepoints := func() map[common.Hash]int64 {
+		stops := map[common.Hash]int64{}
+		for h, o := range ob.book.stops {
+			stops[h] = o.StopPricepoint.Int64()
+		}
+
+		return stops
+	}
+
+	// sell stops trail the high and buy stops the low, unless their stop pricepoint is closer
+	err := ob.trailStopOrders(big.NewInt(1000), big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[common.Hash]int64{sell1.Hash: 950, sell2.Hash: 960, sell3.Hash: 900, buy1.Hash: 1050}, stopPricepoints())
+	assert.Equal(t, "1000", ob.book.stops[sell1.Hash].Watermark.String())
+	assert.Nil(t, ob.book.stops[sell2.Hash].Watermark)
+
+	// stop pricepoints never move back
+	err = ob.trailStopOrders(big.NewInt(1020), big.NewInt(1010))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[common.Hash]int64{sell1.Hash: 970, sell2.Hash: 970, sell3.Hash: 918, buy1.Hash: 1050}, stopPricepoints())
+
+	// trailing stops are triggered like fixed stops once the price falls back through them
+	triggered, _ := ob.GetTriggeredStopOrders(types.OrderSideSell, 971)
+	assert.Equal(t, 0, len(triggered))
+
+	err = ob.triggerStopOrders(big.NewInt(970))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stops, _ := ob.GetAllStopOrders()
+	assert.Equal(t, 2, len(stops))
+
+	// the stop index is rebuilt with the orderbook
+	b := newBook()
+	b.restore(ob.book.snapshot())
+	assert.Equal(t, ob.book.stopIndexes[string(types.OrderSideSell)].levelHashes(918), b.stopIndexes[string(types.OrderSideSell)].levelHashes(918))
+	assert.Equal(t, []string{"BUY::ABSOLUTE::50", "SELL::PERCENT::1000"}, b.trailingIndexKeys())
+}
+
+func TestImmediateOrCancelOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// IOC orders are not added to the orderbook when they can not be matched
+	o1, _ := factory1.NewBuyOrder(1e3, 1e8)
+	o1.TimeInForce = types.TimeInForceIOC
+
+	res, err := ob.buyOrder(&o1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CANCELLED", res.Status)
+	assert.Equal(t, types.OrderStatusCancelled, res.Order.Status)
+	assert.Equal(t, types.CancelReasonIOC, res.CancelReason)
+	assert.Equal(t, o1.Amount, res.CancelledAmount)
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+
+	// the unfilled amount of partially matched IOC orders is cancelled
+	o2, _ := factory1.NewSellOrder(1e3, 1e8)
+	o3, _ := factory2.NewBuyOrder(1e3, 3e8)
+	o3.TimeInForce = types.TimeInForceIOC
+
+	ob.sellOrder(&o2)
+	res, err = ob.buyOrder(&o3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "PARTIAL", res.Status)
+	assert.Equal(t, types.OrderStatusCancelled, res.Order.Status)
+	assert.Nil(t, res.RemainingOrder)
+	assert.Equal(t, 1, len(res.Matches))
+	assert.Equal(t, o2.Amount, res.Order.FilledAmount)
+	assert.Equal(t, math.Sub(o3.Amount, o2.Amount), res.CancelledAmount)
+
+	orders, _ = ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+}
+
+func TestFillOrKillOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	o2, _ := factory1.NewSellOrder(1e3, 1e8)
+	ob.sellOrder(&o1)
+	ob.sellOrder(&o2)
+
+	// FOK orders are cancelled without any fill when the orderbook depth is insufficient
+	o3, _ := factory2.NewBuyOrder(1e3, 3e8)
+	o3.TimeInForce = types.TimeInForceFOK
+
+	res, err := ob.buyOrder(&o3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CANCELLED", res.Status)
+	assert.Equal(t, types.CancelReasonFOK, res.CancelReason)
+	assert.Equal(t, 0, len(res.Matches))
+	assert.Equal(t, o3.Amount, res.CancelledAmount)
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 2, len(orders))
+	for _, o := range orders {
+		assert.Equal(t, int64(0), o.FilledAmount.Int64())
+	}
+
+	// FOK orders that can be filled entirely are matched
+	o4, _ := factory2.NewBuyOrder(1e3, 2e8)
+	o4.TimeInForce = types.TimeInForceFOK
+
+	res, err = ob.buyOrder(&o4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 2, len(res.Matches))
+	assert.Nil(t, res.CancelledAmount)
+
+	orders, _ = ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+}
+
+func TestPostOnlyOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	ob.sellOrder(&o1)
+
+	// post-only orders crossing the best ask are rejected without being matched
+	o2, _ := factory2.NewBuyOrder(1e3, 1e8)
+	o2.PostOnly = true
+
+	res, err := ob.buyOrder(&o2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "REJECTED", res.Status)
+	assert.Equal(t, types.RejectReasonPostOnly, res.RejectReason)
+	assert.Equal(t, types.OrderStatusRejected, res.Order.Status)
+	assert.Equal(t, 0, len(res.Matches))
+	assert.Nil(t, res.RemainingOrder)
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, int64(0), orders[0].FilledAmount.Int64())
+
+	// post-only orders that do not cross the orderbook are added to it
+	o3, _ := factory2.NewBuyOrder(999, 1e8)
+	o3.PostOnly = true
+
+	res, err = ob.buyOrder(&o3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "NOMATCH", res.Status)
+	assert.Equal(t, "", res.RejectReason)
+
+	orders, _ = ob.GetAllOrders()
+	assert.Equal(t, 2, len(orders))
+
+	// the same applies to sell orders crossing the best bid
+	o4, _ := factory1.NewSellOrder(999, 1e8)
+	o4.PostOnly = true
+
+	res, err = ob.sellOrder(&o4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "REJECTED", res.Status)
+	assert.Equal(t, types.RejectReasonPostOnly, res.RejectReason)
+}
+
+func TestReplaceOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, _ := setupTest()
+	defer teardown(e)
+
+	now := time.Now()
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	o2, _ := factory1.NewSellOrder(1e3, 1e8)
+	o1.CreatedAt = now
+	o2.CreatedAt = now.Add(time.Second)
+	ob.sellOrder(&o1)
+	ob.sellOrder(&o2)
+
+	// the replacement of o1 goes to the back of the price level
+	o3, _ := factory1.NewSellOrder(1e3, 2e8)
+	o3.CreatedAt = now.Add(2 * time.Second)
+	res, err := e.ReplaceOrder(&o1, &o3, o3.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "CANCELLED", res.Status)
+	assert.Equal(t, o1.Hash, res.Order.Hash)
+	assert.Equal(t, types.OrderStatusCancelled, res.Order.Status)
+
+	_, err = ob.GetFromOrderMap(o1.Hash)
+	assert.NotNil(t, err)
+
+	key, _ := o2.GetOBKeys()
+	entries, _ := ob.GetMatchingOrders(key, 1e3)
+	assert.Equal(t, 2, len(entries))
+
+	assert.Equal(t, o2.Hash, entries[0].Hash)
+	assert.Equal(t, o3.Hash, entries[1].Hash)
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 2, len(orders))
+
+	// orders that are not in the orderbook can not be replaced and the replacement is not booked
+	o4, _ := factory1.NewSellOrder(1100, 1e8)
+	res, err = e.ReplaceOrder(&o1, &o4, o4.Hash)
+	assert.NotNil(t, err)
+	assert.Nil(t, res)
+
+	_, err = ob.GetFromOrderMap(o4.Hash)
+	assert.NotNil(t, err)
+
+	orders, _ = ob.GetAllOrders()
+	assert.Equal(t, 2, len(orders))
+}
+
+func TestPriceLevelPriority(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// the orders are created in the same second so that their creation times tie
+	now := time.Now()
+	submitted := []common.Hash{}
+	for i := 0; i < 100; i++ {
+		o, _ := factory1.NewSellOrder(1e3, 2)
+		o.CreatedAt = now
+		ob.sellOrder(&o)
+		submitted = append(submitted, o.Hash)
+	}
+
+	// the first taker fills 50 orders and the first unit of the 51st order
+	taker1, _ := factory2.NewBuyOrder(1e3, 101)
+	res, err := ob.buyOrder(&taker1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 51, len(res.Matches))
+	assert.Equal(t, types.OrderStatusPartialFilled, res.Matches[50].Order.Status)
+
+	// the partially filled order keeps its place at the front of the price level
+	_, key := res.Matches[50].Order.GetOBKeys()
+	assert.Equal(t, submitted[50:], ob.GetPricePointHashes(key))
+
+	taker2, _ := factory2.NewBuyOrder(1e3, 200)
+	res2, err := ob.buyOrder(&taker2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 50, len(res2.Matches))
+
+	filled := []common.Hash{}
+	for _, m := range append(res.Matches, res2.Matches...) {
+		filled = append(filled, m.Order.Hash)
+	}
+
+	// the second taker first fills the rest of the partially filled order
+	assert.Equal(t, submitted[50], filled[51])
+	assert.Equal(t, submitted, append(filled[:51], filled[52:]...))
+}
+
+func TestReplaceOrderPriority(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	now := time.Now()
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	o2, _ := factory1.NewSellOrder(1e3, 1)
+	o3, _ := factory1.NewSellOrder(1e3, 1)
+	for _, o := range []*types.Order{&o1, &o2, &o3} {
+		o.CreatedAt = now
+		ob.sellOrder(o)
+	}
+
+	// the amended order loses its priority even though nothing but its amount changed
+	amended, _ := factory1.NewSellOrder(1e3, 2)
+	amended.CreatedAt = now
+	_, err := e.ReplaceOrder(&o1, &amended, amended.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taker, _ := factory2.NewBuyOrder(1e3, 4)
+	res, err := ob.buyOrder(&taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 3, len(res.Matches))
+	assert.Equal(t, o2.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, o3.Hash, res.Matches[1].Order.Hash)
+	assert.Equal(t, amended.Hash, res.Matches[2].Order.Hash)
+}
+
+func TestDustRemainder(t *testing.T) {
+	e, ob, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	p := *pair
+	p.MinAmount = big.NewInt(6e17)
+	ob.setPair(&p)
+
+	// book entries left with less than the minimum amount are removed from the orderbook
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	ob.sellOrder(&o1)
+
+	o2, _ := factory2.NewBuyOrder(1e3, 0.5)
+	res, err := ob.buyOrder(&o2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 1, len(res.Matches))
+	assert.Equal(t, types.OrderStatusFilled, res.Matches[0].Order.Status)
+	assert.Equal(t, "500000000000000000", res.Matches[0].Order.DustAmount().String())
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+
+	// the remaining amount of incoming orders below the minimum amount is cancelled
+	o3, _ := factory1.NewSellOrder(1e3, 0.5)
+	ob.sellOrder(&o3)
+
+	o4, _ := factory2.NewBuyOrder(1e3, 1)
+	res, err = ob.buyOrder(&o4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, types.OrderStatusFilled, res.Order.Status)
+	assert.Nil(t, res.RemainingOrder)
+	assert.Equal(t, "500000000000000000", res.Order.DustAmount().String())
+
+	orders, _ = ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+}
+
+func TestMinNotionalRemainder(t *testing.T) {
+	e, ob, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// 0.6 ZRX at a pricepoint of 1e3
+	p := *pair
+	p.MinNotional = big.NewInt(6e14)
+	ob.setPair(&p)
+
+	// book entries left with less than the minimum notional are removed from the orderbook
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	ob.sellOrder(&o1)
+
+	o2, _ := factory2.NewBuyOrder(1e3, 0.5)
+	res, err := ob.buyOrder(&o2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, types.OrderStatusFilled, res.Matches[0].Order.Status)
+	assert.Equal(t, "500000000000000000", res.Matches[0].Order.DustAmount().String())
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+
+	// the remaining amount of incoming orders is rested while it is worth the minimum notional
+	o3, _ := factory1.NewSellOrder(1e3, 0.5)
+	ob.sellOrder(&o3)
+
+	o4, _ := factory2.NewBuyOrder(2e3, 1)
+	res, err = ob.buyOrder(&o4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "PARTIAL", res.Status)
+	assert.Nil(t, res.RemainingOrder)
+
+	// the remaining amount rests in the orderbook under the hash of the incoming order
+	orders, _ = ob.GetAllOrders()
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o4.Hash, orders[0].Hash)
+	assert.Equal(t, types.OrderStatusPartialFilled, orders[0].Status)
+	assert.Equal(t, o3.Amount, orders[0].FilledAmount)
+}
+
+func TestRestoreFill(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	o2, _ := factory1.NewSellOrder(1e3, 1)
+	ob.sellOrder(&o1)
+	ob.sellOrder(&o2)
+
+	// o1 is filled and leaves the orderbook, o2 is filled by two trades
+	taker1, _ := factory2.NewBuyOrder(1e3, 1.5)
+	res, err := ob.buyOrder(&taker1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filled1 := res.Matches[0].Order.Clone()
+	taker2, _ := factory2.NewBuyOrder(1e3, 0.25)
+	ob.buyOrder(&taker2)
+
+	// the first trade of o2 fails: o2 keeps its place and its second fill
+	restored, err := ob.restoreFill(&o2, big.NewInt(5e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, big.NewInt(25e16), restored.FilledAmount)
+	assert.Equal(t, types.OrderStatusPartialFilled, restored.Status)
+
+	// the trade of o1 fails: o1 goes to the back of its price level
+	restored, err = ob.restoreFill(filled1, units.Ethers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, big.NewInt(0), restored.FilledAmount)
+	assert.Equal(t, types.OrderStatusOpen, restored.Status)
+
+	_, key := o1.GetOBKeys()
+	assert.Equal(t, []common.Hash{o2.Hash, o1.Hash}, ob.GetPricePointHashes(key))
+
+	// IOC orders can not rest in the orderbook
+	ioc, _ := factory2.NewBuyOrder(900, 1)
+	ioc.TimeInForce = types.TimeInForceIOC
+	ioc.FilledAmount = units.Ethers(1)
+	restored, err = ob.restoreFill(&ioc, units.Ethers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, restored)
+}
+
+func TestIcebergOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// an iceberg order of 10 showing slices of 3, followed by a regular order of 1
+	now := time.Now()
+	iceberg, _ := factory1.NewSellOrder(1e3, 10)
+	iceberg.DisplayAmount = big.NewInt(3e18)
+	iceberg.CreatedAt = now
+	ob.sellOrder(&iceberg)
+
+	o2, _ := factory1.NewSellOrder(1e3, 1)
+	o2.CreatedAt = now
+	ob.sellOrder(&o2)
+
+	book := ob.getOrderBook(0)
+	assert.Equal(t, 1, len(book.Asks))
+	assert.Equal(t, "4000000000000000000", book.Asks[0].Amount.String())
+
+	// the taker fills the displayed slice then the regular order, which was ahead of the next slice
+	taker1, _ := factory2.NewBuyOrder(1e3, 4)
+	res, err := ob.buyOrder(&taker1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 2, len(res.Matches))
+	assert.Equal(t, iceberg.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, "3000000000000000000", res.Matches[0].Trade.Amount.String())
+	assert.Equal(t, o2.Hash, res.Matches[1].Order.Hash)
+
+	book = ob.getOrderBook(0)
+	assert.Equal(t, "3000000000000000000", book.Asks[0].Amount.String())
+
+	// the next slice waits behind the orders added before it was revealed
+	o3, _ := factory1.NewSellOrder(1e3, 1)
+	o3.CreatedAt = now
+	ob.sellOrder(&o3)
+
+	_, key := iceberg.GetOBKeys()
+	assert.Equal(t, []common.Hash{iceberg.Hash, o3.Hash}, ob.GetPricePointHashes(key))
+
+	taker2, _ := factory2.NewBuyOrder(1e3, 5)
+	res, err = ob.buyOrder(&taker2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, len(res.Matches))
+	assert.Equal(t, iceberg.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, o3.Hash, res.Matches[1].Order.Hash)
+	assert.Equal(t, iceberg.Hash, res.Matches[2].Order.Hash)
+	assert.Equal(t, "1000000000000000000", res.Matches[2].Trade.Amount.String())
+
+	// alone in its price level, the iceberg order is filled across its slices
+	taker3, _ := factory2.NewBuyOrder(1e3, 3)
+	res, err = ob.buyOrder(&taker3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 1, len(res.Matches))
+	assert.Equal(t, types.OrderStatusFilled, res.Matches[0].Order.Status)
+
+	orders, _ := ob.GetAllOrders()
+	assert.Equal(t, 0, len(orders))
+}
+
+func TestHiddenOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	// a hidden order of 2 followed by a visible order of 1 at the same price
+	hidden, _ := factory1.NewSellOrder(1e3, 2)
+	hidden.Hidden = true
+	ob.sellOrder(&hidden)
+
+	visible, _ := factory1.NewSellOrder(1e3, 1)
+	ob.sellOrder(&visible)
+
+	// only the visible order shows in the price levels, the diffs and the ticker
+	book := ob.getOrderBook(0)
+	assert.Equal(t, 1, len(book.Asks))
+	assert.Equal(t, "1000000000000000000", book.Asks[0].Amount.String())
+
+	diffs, err := ob.diffs.since(0, ob.book.sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(diffs))
+	assert.Equal(t, "1000000000000000000", diffs[0].Amount.String())
+
+	// the hidden order, although older, is matched once the visible size of the level is filled
+	taker, _ := factory2.NewBuyOrder(1e3, 2)
+	res, err := ob.buyOrder(&taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", res.Status)
+	assert.Equal(t, 2, len(res.Matches))
+	assert.Equal(t, visible.Hash, res.Matches[0].Order.Hash)
+	assert.Equal(t, "1000000000000000000", res.Matches[0].Trade.Amount.String())
+	assert.Equal(t, hidden.Hash, res.Matches[1].Order.Hash)
+	assert.Equal(t, "1000000000000000000", res.Matches[1].Trade.Amount.String())
+
+	// the rest of the hidden order is matchable but the level looks empty
+	book = ob.getOrderBook(0)
+	assert.Equal(t, 0, len(book.Asks))
+	assert.Nil(t, ob.currentTicker().BestAsk)
+
+	stored, err := ob.GetFromOrderMap(hidden.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "1000000000000000000", stored.FilledAmount.String())
+}
+
+func TestReduceOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	o1, _ := factory1.NewSellOrder(1e3, 3)
+	o2, _ := factory1.NewSellOrder(1e3, 1)
+	ob.sellOrder(&o1)
+	ob.sellOrder(&o2)
+
+	sequence := ob.book.sequence
+	res, err := ob.reduceOrder(&o1, units.Ethers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "REDUCED", res.Status)
+	assert.Equal(t, units.Ethers(1), res.Order.Amount)
+	assert.Equal(t, units.Ethers(2), res.CancelledAmount)
+
+	// the reduced order keeps its position and the diff of its level is published
+	position, _, _ := ob.book.position(o1.Hash)
+	assert.Equal(t, 1, position)
+
+	diffs, err := ob.diffs.since(sequence, ob.book.sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(diffs))
+	assert.Equal(t, units.Ethers(2), diffs[0].Amount)
+
+	// the amount of an order can not be increased nor kept
+	_, err = ob.reduceOrder(&o1, units.Ethers(1))
+	assert.Equal(t, types.ErrReduceAmountNotLower, err)
+
+	_, err = ob.reduceOrder(&o1, units.Ethers(2))
+	assert.Equal(t, types.ErrReduceAmountNotLower, err)
+
+	// the reduced order is still matched first, for its reduced amount only
+	taker, _ := factory2.NewBuyOrder(1e3, 1.5)
+	match, err := ob.buyOrder(&taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(match.Matches))
+	assert.Equal(t, o1.Hash, match.Matches[0].Order.Hash)
+	assert.Equal(t, units.Ethers(1), match.Matches[0].Trade.Amount)
+	assert.Equal(t, types.OrderStatusFilled, match.Matches[0].Order.Status)
+
+	// filled orders are no longer resting
+	_, err = ob.reduceOrder(&o1, big.NewInt(1))
+	assert.Equal(t, types.ErrOrderNotResting, err)
+}
+
+func TestExecutionPricePolicy(t *testing.T) {
+	defer func(policy string) { ExecutionPricePolicy = policy }(ExecutionPricePolicy)
+
+	testCases := []struct {
+		policy     string
+		side       types.OrderSide
+		makerPrice int64
+		takerPrice int64
+		tradePrice int64
+	}{
+		{types.ExecutionPriceMaker, types.OrderSideBuy, 1000, 1100, 1000},
+		{types.ExecutionPriceMaker, types.OrderSideSell, 1001, 900, 1001},
+		// the midpoint is rounded in favor of the taker
+		{types.ExecutionPriceMidpoint, types.OrderSideBuy, 1000, 1101, 1050},
+		{types.ExecutionPriceMidpoint, types.OrderSideSell, 1001, 900, 951},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy+" "+string(tc.side), func(t *testing.T) {
+			e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+			defer teardown(e)
+
+			ExecutionPricePolicy = tc.policy
+
+			var res *types.EngineResponse
+			var err error
+			if tc.side == types.OrderSideBuy {
+				maker, _ := factory1.NewSellOrder(tc.makerPrice, 1)
+				ob.sellOrder(&maker)
+				taker, _ := factory2.NewBuyOrder(tc.takerPrice, 1)
+				res, err = ob.buyOrder(&taker)
+			} else {
+				maker, _ := factory1.NewBuyOrder(tc.makerPrice, 1)
+				ob.buyOrder(&maker)
+				taker, _ := factory2.NewSellOrder(tc.takerPrice, 1)
+				res, err = ob.sellOrder(&taker)
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "FULL", res.Status)
+			assert.Equal(t, 1, len(res.Matches))
+			assert.Equal(t, big.NewInt(tc.tradePrice), res.Matches[0].Trade.PricePoint)
+			assert.Equal(t, big.NewInt(tc.tradePrice), ob.breaker.LastPricePoint)
+		})
+	}
+}
+
+// newBenchmarkOrderbook returns an orderbook holding 10000 sell orders spread over 100 pricepoints
+func newBenchmarkOrderbook() (*Engine, *OrderBook, *testutils.OrderFactory, *testutils.OrderFactory) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	for i := 0; i < 1e4; i++ {
+		o, _ := factory1.NewSellOrder(int64(1e3+i%100), 1e8)
+		ob.addOrder(&o)
+	}
+
+	return e, ob, factory1, factory2
+}
+
+func BenchmarkAddOrder(b *testing.B) {
+	e, ob, factory1, _ := newBenchmarkOrderbook()
+	defer teardown(e)
+
+	orders := []types.Order{}
+	for i := 0; i < b.N; i++ {
+		o, _ := factory1.NewSellOrder(int64(1e3+i%100), 1e8)
+		orders = append(orders, o)
+	}
+
+	b.ResetTimer()
+	for i := range orders {
+		ob.addOrder(&orders[i])
+	}
+}
+
+func BenchmarkMatchOrder(b *testing.B) {
+	e, ob, factory1, factory2 := newBenchmarkOrderbook()
+	defer teardown(e)
+
+	// each buy order fills the oldest order of the best price level, which is replaced by a new
+	// order so that the orderbook size stays the same
+	buys := []types.Order{}
+	sells := []types.Order{}
+	for i := 0; i < b.N; i++ {
+		bo, _ := factory2.NewBuyOrder(1099, 1e8)
+		so, _ := factory1.NewSellOrder(1e3, 1e8)
+		buys = append(buys, bo)
+		sells = append(sells, so)
+	}
+
+	b.ResetTimer()
+	for i := range buys {
+		_, err := ob.buyOrder(&buys[i])
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		ob.addOrder(&sells[i])
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// SimulateOrder previews the execution of an order against the current state of the orderbook of
+// its pair, without the order being signed nor anything being changed (see simulateOrder). The
+// order is matched by the event loop of the orderbook like a new order, between two commands.
+func (e *Engine) SimulateOrder(o *types.Order) (*types.MatchPreview, error) {
+	code, err := o.PairCode()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob := e.orderbooks[code]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	var preview *types.MatchPreview
+	err = ob.do(ctx, func() (err error) {
+		preview, err = ob.simulateOrder(o)
+		return err
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// simulateOrder matches a copy of an order with the same code as new orders (see matchOrder)
+// while the orderbook is previewing, so that the previewed fills are the fills the order would get
+// if it were received now. The orderbook is not changed, no trade is recorded and the circuit
+// breaker is not tripped. Expired orders are skipped, and neither hidden orders nor the hidden
+// amount of iceberg orders are matched so that previews do not reveal them.
+// Orders can not be previewed between the end of the opening auction of the pair and its closing,
+// which is done by the next new order. It must be run by the event loop of the orderbook.
+func (ob *OrderBook) simulateOrder(o *types.Order) (*types.MatchPreview, error) {
+	o = o.Clone()
+	o.FilledAmount = filledAmount(o)
+	preview := &types.MatchPreview{
+		PairName:        ob.pair.Name(),
+		Status:          "NOMATCH",
+		Fills:           []*types.PreviewFill{},
+		FilledAmount:    big.NewInt(0),
+		RemainingAmount: math.Sub(o.Amount, filledAmount(o)),
+		Sequence:        ob.book.sequence,
+	}
+
+	if reason := ob.refusalReason(); reason != "" {
+		preview.Status = "ERROR"
+		preview.RejectReason = reason
+		return preview, nil
+	}
+
+	// stop orders stay dormant until they are triggered
+	if o.Status == types.OrderStatusStop {
+		preview.Status = "STOP_ADDED"
+		return preview, nil
+	}
+
+	if ob.auctionPending() && !ob.inAuction() {
+		return nil, types.ErrAuctionClosing
+	}
+
+	ob.previewing = true
+	defer func() { ob.previewing = false }()
+
+	res, err := ob.matchOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	preview.Status = res.Status
+	preview.CancelReason = res.CancelReason
+	preview.RejectReason = res.RejectReason
+
+	value := big.NewInt(0)
+	for _, m := range res.Matches {
+		t := m.Trade
+		preview.Fills = append(preview.Fills, &types.PreviewFill{PricePoint: t.PricePoint, Amount: t.Amount, Fee: t.TakeFee})
+		preview.FilledAmount = math.Add(preview.FilledAmount, t.Amount)
+		value = math.Add(value, math.Mul(t.PricePoint, t.Amount))
+	}
+
+	if preview.FilledAmount.Sign() > 0 {
+		preview.AveragePricePoint = math.Div(value, preview.FilledAmount)
+	}
+
+	// the unfilled amount of filled orders is dust, which is cancelled
+	if res.Order.Status != types.OrderStatusFilled {
+		preview.RemainingAmount = math.Sub(res.Order.Amount, filledAmount(res.Order))
+	} else {
+		preview.RemainingAmount = big.NewInt(0)
+	}
+
+	preview.Rests = res.Order.Status == types.OrderStatusOpen || res.Order.Status == types.OrderStatusPartialFilled
+	return preview, nil
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateOrder(t *testing.T) {
+	e, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	sell1, _ := factory1.NewSellOrder(1000, 1)
+	sell2, _ := factory1.NewSellOrder(1100, 2)
+	e.newOrder(&sell1, sell1.Hash)
+	e.newOrder(&sell2, sell2.Hash)
+
+	sequence := ob.book.sequence
+	checksum := ob.checksum()
+
+	buy, _ := factory2.NewBuyOrder(1100, 2)
+	status := buy.Status
+	preview, err := e.SimulateOrder(&buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "FULL", preview.Status)
+	assert.Equal(t, 2, len(preview.Fills))
+	assert.Equal(t, big.NewInt(1000), preview.Fills[0].PricePoint)
+	assert.Equal(t, units.Ethers(1), preview.Fills[0].Amount)
+	assert.Equal(t, big.NewInt(1100), preview.Fills[1].PricePoint)
+	assert.Equal(t, units.Ethers(1), preview.Fills[1].Amount)
+	assert.Equal(t, units.Ethers(2), preview.FilledAmount)
+	assert.Equal(t, big.NewInt(1050), preview.AveragePricePoint)
+	assert.Equal(t, big.NewInt(0), preview.RemainingAmount)
+	assert.False(t, preview.Rests)
+	assert.Equal(t, sequence, preview.Sequence)
+
+	// the orderbook and the previewed order are not changed
+	assert.Equal(t, sequence, ob.book.sequence)
+	assert.Equal(t, checksum, ob.checksum())
+	assert.Equal(t, big.NewInt(0), ob.book.orders[sell1.Hash].FilledAmount)
+	assert.Equal(t, status, buy.Status)
+
+	// the unfilled amount rests in the orderbook
+	large, _ := factory2.NewBuyOrder(1100, 5)
+	preview, _ = e.SimulateOrder(&large)
+	assert.Equal(t, "PARTIAL", preview.Status)
+	assert.Equal(t, units.Ethers(3), preview.FilledAmount)
+	assert.Equal(t, units.Ethers(2), preview.RemainingAmount)
+	assert.True(t, preview.Rests)
+
+	// IOC orders do not rest
+	large.TimeInForce = types.TimeInForceIOC
+	preview, _ = e.SimulateOrder(&large)
+	assert.Equal(t, "PARTIAL", preview.Status)
+	assert.Equal(t, types.CancelReasonIOC, preview.CancelReason)
+	assert.False(t, preview.Rests)
+
+	low, _ := factory2.NewBuyOrder(900, 1)
+	preview, _ = e.SimulateOrder(&low)
+	assert.Equal(t, "NOMATCH", preview.Status)
+	assert.Equal(t, 0, len(preview.Fills))
+	assert.Nil(t, preview.AveragePricePoint)
+	assert.True(t, preview.Rests)
+
+	// the order gets the previewed fills once it is placed
+	preview, _ = e.SimulateOrder(&buy)
+	res, err := ob.newOrder(&buy, buy.Hash, ob.clock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, preview.Status, res.Status)
+	assert.Equal(t, len(preview.Fills), len(res.Matches))
+	for i, m := range res.Matches {
+		assert.Equal(t, preview.Fills[i].PricePoint, m.Trade.PricePoint)
+		assert.Equal(t, preview.Fills[i].Amount, m.Trade.Amount)
+		assert.Equal(t, preview.Fills[i].Fee, m.Trade.TakeFee)
+	}
+}
+
+func TestSimulateOrderHiddenOrders(t *testing.T) {
+	e, _, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	hidden, _ := factory1.NewSellOrder(1000, 2)
+	hidden.Hidden = true
+	e.newOrder(&hidden, hidden.Hash)
+
+	// hidden orders are not revealed by previews
+	buy, _ := factory2.NewBuyOrder(1000, 1)
+	preview, err := e.SimulateOrder(&buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "NOMATCH", preview.Status)
+	assert.Equal(t, 0, len(preview.Fills))
+
+	// previews are refused with the reason of the rejection of new orders
+	e.PausePair(pair)
+	preview, _ = e.SimulateOrder(&buy)
+	assert.Equal(t, "ERROR", preview.Status)
+	assert.Equal(t, rejectReasonPairPaused, preview.RejectReason)
+}
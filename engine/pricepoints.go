@@ -0,0 +1,185 @@
+package engine
+
+// maxPricePointLevel is the number of levels of the pricepoint skip lists, which keeps their
+// operations logarithmic up to 4^maxPricePointLevel pricepoints
+const maxPricePointLevel = 16
+
+// pricePointSet is a sorted set of pricepoints stored as a skip list, so that pricepoints are
+// added and removed in logarithmic time whatever the number of price levels of the orderbook and
+// the best pricepoints are read without copying the set. The levels of the nodes are drawn from
+// a generator seeded with a constant, which makes the shape of a set depend only on the
+// operations applied to it. The node of the highest pricepoint is kept along with the head, so
+// that both best pricepoints are read in constant time.
+type pricePointSet struct {
+	head   *pricePointNode
+	last   *pricePointNode
+	level  int
+	length int
+	seed   uint64
+}
+
+type pricePointNode struct {
+	pricePoint int64
+	next       []*pricePointNode
+}
+
+func newPricePointSet() *pricePointSet {
+	return &pricePointSet{
+		head:  &pricePointNode{next: make([]*pricePointNode, maxPricePointLevel)},
+		level: 1,
+		seed:  0x9e3779b97f4a7c15,
+	}
+}
+
+// randomLevel returns the level of a new node, each level being kept with a probability of 1/4
+func (s *pricePointSet) randomLevel() int {
+	// xorshift64
+	s.seed ^= s.seed << 13
+	s.seed ^= s.seed >> 7
+	s.seed ^= s.seed << 17
+
+	level := 1
+	for r := s.seed; level < maxPricePointLevel && r&3 == 0; r >>= 2 {
+		level++
+	}
+
+	return level
+}
+
+// predecessors returns, for each level, the last node whose pricepoint is lower than pp
+func (s *pricePointSet) predecessors(pp int64) (update [maxPricePointLevel]*pricePointNode) {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].pricePoint < pp {
+			x = x.next[i]
+		}
+
+		update[i] = x
+	}
+
+	return update
+}
+
+// add adds a pricepoint to the set and returns false if it was already in the set
+func (s *pricePointSet) add(pp int64) bool {
+	update := s.predecessors(pp)
+	if n := update[0].next[0]; n != nil && n.pricePoint == pp {
+		return false
+	}
+
+	level := s.randomLevel()
+	for i := s.level; i < level; i++ {
+		update[i] = s.head
+	}
+
+	if level > s.level {
+		s.level = level
+	}
+
+	n := &pricePointNode{pricePoint: pp, next: make([]*pricePointNode, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+
+	if n.next[0] == nil {
+		s.last = n
+	}
+
+	s.length++
+	return true
+}
+
+// remove removes a pricepoint from the set and returns false if it was not in the set
+func (s *pricePointSet) remove(pp int64) bool {
+	update := s.predecessors(pp)
+	n := update[0].next[0]
+	if n == nil || n.pricePoint != pp {
+		return false
+	}
+
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+
+	if s.last == n {
+		s.last = update[0]
+		if s.last == s.head {
+			s.last = nil
+		}
+	}
+
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+
+	s.length--
+	return true
+}
+
+// len returns the number of pricepoints of the set
+func (s *pricePointSet) len() int {
+	return s.length
+}
+
+// ceiling returns the lowest pricepoint of the set that is greater or equal to pp
+func (s *pricePointSet) ceiling(pp int64) (int64, bool) {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].pricePoint < pp {
+			x = x.next[i]
+		}
+	}
+
+	n := x.next[0]
+	if n == nil {
+		return 0, false
+	}
+
+	return n.pricePoint, true
+}
+
+// floor returns the highest pricepoint of the set that is lower or equal to pp
+func (s *pricePointSet) floor(pp int64) (int64, bool) {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].pricePoint <= pp {
+			x = x.next[i]
+		}
+	}
+
+	if x == s.head {
+		return 0, false
+	}
+
+	return x.pricePoint, true
+}
+
+// min returns the lowest pricepoint of the set
+func (s *pricePointSet) min() (int64, bool) {
+	n := s.head.next[0]
+	if n == nil {
+		return 0, false
+	}
+
+	return n.pricePoint, true
+}
+
+// max returns the highest pricepoint of the set
+func (s *pricePointSet) max() (int64, bool) {
+	if s.last == nil {
+		return 0, false
+	}
+
+	return s.last.pricePoint, true
+}
+
+// values returns the pricepoints of the set in increasing order
+func (s *pricePointSet) values() []int64 {
+	pps := make([]int64, 0, s.length)
+	for n := s.head.next[0]; n != nil; n = n.next[0] {
+		pps = append(pps, n.pricePoint)
+	}
+
+	return pps
+}
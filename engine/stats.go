@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// Reasons of the rejection of orders by the engine that are only reported in the metrics, the
+// orders are sent back with an ERROR engine response
+const (
+	rejectReasonPairInactive  = "PAIR_INACTIVE"
+	rejectReasonNotReconciled = "NOT_RECONCILED"
+	rejectReasonPairHalted    = "PAIR_HALTED"
+	rejectReasonPairPaused    = "PAIR_PAUSED"
+	rejectReasonDuplicate     = "DUPLICATE"
+)
+
+// rejectReasons are the reasons counted in the reject metrics of an orderbook
+var rejectReasons = []string{
+	types.RejectReasonPostOnly,
+	types.RejectReasonEngineBusy,
+	rejectReasonPairInactive,
+	rejectReasonNotReconciled,
+	rejectReasonPairHalted,
+	rejectReasonPairPaused,
+	rejectReasonDuplicate,
+}
+
+// matchLatencyBuckets are the upper bounds of the buckets of the match latency histograms
+var matchLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// orderBookStats holds the metrics of an orderbook. The metrics are written by the event loop of
+// the orderbook and read by Stats from any goroutine: counters are only accessed atomically and
+// the depth is replaced as a whole, so that reading the metrics never waits for the event loop.
+type orderBookStats struct {
+	pairName     string
+	matchLatency *histogram
+	rejects      map[string]*uint64
+	depth        atomic.Value
+	sequence     uint64
+}
+
+// depth is the depth of an orderbook at the time it was recorded. It is never modified.
+type depth struct {
+	bids      *big.Int
+	asks      *big.Int
+	bidLevels int
+	askLevels int
+}
+
+func newOrderBookStats(pairName string) *orderBookStats {
+	s := &orderBookStats{
+		pairName:     pairName,
+		matchLatency: newHistogram(matchLatencyBuckets),
+		rejects:      map[string]*uint64{},
+	}
+
+	for _, r := range rejectReasons {
+		s.rejects[r] = new(uint64)
+	}
+
+	s.depth.Store(&depth{big.NewInt(0), big.NewInt(0), 0, 0})
+	return s
+}
+
+// reject counts an order rejected by the engine
+func (s *orderBookStats) reject(reason string) {
+	if c := s.rejects[reason]; c != nil {
+		atomic.AddUint64(c, 1)
+	}
+}
+
+// recordDepth records the depth of the book if it changed since the last record. It must be run
+// by the event loop of the orderbook.
+func (s *orderBookStats) recordDepth(b *book) {
+	if b.sequence == s.sequence {
+		return
+	}
+
+	s.sequence = b.sequence
+	s.depth.Store(&depth{
+		bids:      new(big.Int).Set(b.depth(types.OrderSideBuy)),
+		asks:      new(big.Int).Set(b.depth(types.OrderSideSell)),
+		bidLevels: b.levelCounts[types.OrderSideBuy],
+		askLevels: b.levelCounts[types.OrderSideSell],
+	})
+}
+
+// stats returns the metrics of the orderbook along with the number of commands and cancellations
+// queued for it
+func (s *orderBookStats) stats(queueLength, cancelQueueLength int) *types.PairStats {
+	d := s.depth.Load().(*depth)
+	rejects := map[string]uint64{}
+	for r, c := range s.rejects {
+		rejects[r] = atomic.LoadUint64(c)
+	}
+
+	return &types.PairStats{
+		PairName:          s.pairName,
+		BidDepth:          d.bids,
+		AskDepth:          d.asks,
+		BidLevels:         d.bidLevels,
+		AskLevels:         d.askLevels,
+		QueueLength:       queueLength,
+		CancelQueueLength: cancelQueueLength,
+		MatchLatency:      s.matchLatency.snapshot(),
+		Rejects:           rejects,
+	}
+}
+
+// histogram is a latency histogram that can be updated and read concurrently. counts holds the
+// number of durations of each bucket, the last count being the durations above the last bound.
+type histogram struct {
+	count  uint64
+	sum    int64
+	bounds []time.Duration
+	counts []uint64
+}
+
+func newHistogram(bounds []time.Duration) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// observe adds a duration to the histogram
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+// snapshot returns the cumulative counts of the histogram. The counters are read one after the
+// other, so that the total count can be slightly off the bucket counts while durations are
+// observed.
+func (h *histogram) snapshot() *types.LatencyHistogram {
+	res := &types.LatencyHistogram{
+		Buckets: make([]types.LatencyBucket, len(h.bounds)),
+		Count:   atomic.LoadUint64(&h.count),
+		Sum:     time.Duration(atomic.LoadInt64(&h.sum)),
+	}
+
+	var cumulative uint64
+	for i, b := range h.bounds {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		res.Buckets[i] = types.LatencyBucket{UpperBound: b, Count: cumulative}
+	}
+
+	return res
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]time.Duration{100 * time.Microsecond, time.Millisecond, time.Second})
+	h.observe(50 * time.Microsecond)
+	h.observe(time.Millisecond)
+	h.observe(2 * time.Second)
+
+	s := h.snapshot()
+	assert.Equal(t, uint64(3), s.Count)
+	assert.Equal(t, 2*time.Second+time.Millisecond+50*time.Microsecond, s.Sum)
+	assert.Equal(t, []types.LatencyBucket{
+		{UpperBound: 100 * time.Microsecond, Count: 1},
+		{UpperBound: time.Millisecond, Count: 2},
+		{UpperBound: time.Second, Count: 2},
+	}, s.Buckets)
+}
+
+func TestStats(t *testing.T) {
+	e, _, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	o2, _ := factory1.NewSellOrder(1e3+1, 2)
+	o3, _ := factory2.NewBuyOrder(999, 3)
+
+	// post-only orders crossing the best ask are rejected
+	o4, _ := factory2.NewBuyOrder(1e3, 2)
+	o4.PostOnly = true
+
+	for _, o := range []*types.Order{&o1, &o2, &o3, &o4} {
+		err := e.newOrder(o, o.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := e.Stats().Pairs[pair.Name()]
+	assert.Equal(t, pair.Name(), s.PairName)
+	assert.Equal(t, units.Ethers(3), s.AskDepth)
+	assert.Equal(t, units.Ethers(3), s.BidDepth)
+	assert.Equal(t, 2, s.AskLevels)
+	assert.Equal(t, 1, s.BidLevels)
+	assert.Equal(t, 0, s.QueueLength)
+	assert.Equal(t, 0, s.CancelQueueLength)
+	assert.Equal(t, uint64(4), s.MatchLatency.Count)
+	assert.Equal(t, uint64(1), s.Rejects[types.RejectReasonPostOnly])
+	assert.Equal(t, uint64(0), s.Rejects[rejectReasonPairInactive])
+
+	// the depth is updated once the orders are matched
+	o5, _ := factory2.NewBuyOrder(1e3+1, 3)
+	err := e.newOrder(&o5, o5.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s = e.Stats().Pairs[pair.Name()]
+	assert.Equal(t, 0, s.AskDepth.Sign())
+	assert.Equal(t, 0, s.AskLevels)
+	assert.Equal(t, units.Ethers(3), s.BidDepth)
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// stopIndex is a set of stop orders sorted by stop pricepoint, so that the stop orders crossed by
+// a trade, or trailing a price, are read without scanning the stop orders that are not. The
+// pricepoints are kept in a skip list (see pricePointSet) and the hashes of the orders sharing a
+// stop pricepoint in a set.
+type stopIndex struct {
+	pricePoints *pricePointSet
+	hashes      map[int64]map[common.Hash]bool
+}
+
+func newStopIndex() *stopIndex {
+	return &stopIndex{
+		pricePoints: newPricePointSet(),
+		hashes:      map[int64]map[common.Hash]bool{},
+	}
+}
+
+// add adds the hash of a stop order at the given stop pricepoint
+func (s *stopIndex) add(pp int64, h common.Hash) {
+	if s.hashes[pp] == nil {
+		s.hashes[pp] = map[common.Hash]bool{}
+		s.pricePoints.add(pp)
+	}
+
+	s.hashes[pp][h] = true
+}
+
+// remove removes the hash of a stop order from the given stop pricepoint
+func (s *stopIndex) remove(pp int64, h common.Hash) {
+	if s.hashes[pp] == nil {
+		return
+	}
+
+	delete(s.hashes[pp], h)
+	if len(s.hashes[pp]) == 0 {
+		delete(s.hashes, pp)
+		s.pricePoints.remove(pp)
+	}
+}
+
+// len returns the number of stop pricepoints of the index
+func (s *stopIndex) len() int {
+	return s.pricePoints.len()
+}
+
+// levelHashes returns the hashes of the stop orders at the given stop pricepoint sorted by hash
+func (s *stopIndex) levelHashes(pp int64) []common.Hash {
+	hashes := []common.Hash{}
+	for h := range s.hashes[pp] {
+		hashes = append(hashes, h)
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].Hex() < hashes[j].Hex()
+	})
+
+	return hashes
+}
+
+// stopIndexKeys returns the keys of the stop indexes of a stop order. Every stop order is indexed
+// by side, and trailing stop orders are also indexed with the trailing stops of the same side and
+// offset, which all trail the price to the same stop pricepoint.
+func stopIndexKeys(o *types.Order) []string {
+	keys := []string{string(o.Side)}
+	if o.IsTrailingStop() {
+		keys = append(keys, trailingIndexKey(o))
+	}
+
+	return keys
+}
+
+// trailingIndexKey returns the key of the index of the trailing stops of the side and offset of o
+func trailingIndexKey(o *types.Order) string {
+	trailingType := o.TrailingType
+	if trailingType == "" {
+		trailingType = types.TrailingAbsolute
+	}
+
+	return string(o.Side) + "::" + string(trailingType) + "::" + o.TrailingOffset.String()
+}
+
+// indexStop adds a stop order to its stop indexes
+func (b *book) indexStop(o *types.Order) {
+	pp := o.StopPricepoint.Int64()
+	for _, key := range stopIndexKeys(o) {
+		if b.stopIndexes[key] == nil {
+			b.stopIndexes[key] = newStopIndex()
+		}
+
+		b.stopIndexes[key].add(pp, o.Hash)
+	}
+}
+
+// unindexStop removes a stop order from its stop indexes
+func (b *book) unindexStop(o *types.Order) {
+	pp := o.StopPricepoint.Int64()
+	for _, key := range stopIndexKeys(o) {
+		idx := b.stopIndexes[key]
+		if idx == nil {
+			continue
+		}
+
+		idx.remove(pp, o.Hash)
+		if idx.len() == 0 {
+			delete(b.stopIndexes, key)
+		}
+	}
+}
+
+// trailingIndexKeys returns the sorted keys of the trailing stop indexes
+func (b *book) trailingIndexKeys() []string {
+	keys := []string{}
+	for key := range b.stopIndexes {
+		if key != string(types.OrderSideBuy) && key != string(types.OrderSideSell) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
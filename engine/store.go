@@ -1,164 +1,384 @@
 package engine
 
 import (
-	"encoding/json"
 	"fmt"
-	"time"
+	"math"
+	"math/big"
+	"sort"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/ethereum/go-ethereum/common"
 )
 
-// GetPricePoints returns the pricepoints matching a certain (pair, pricepoint)
+// mutate applies a mutation to the in-memory book and appends it to the mutation log of the
+// orderbook. The diff of the price level changed by the mutation, if any, is given the checksum of
+// the book, kept in the diff ring and sent to the diff subscriber. It must be run by the event
+// loop of the orderbook. Mutations are discarded while an order is previewed.
+func (ob *OrderBook) mutate(m *mutation) {
+	if ob.previewing {
+		return
+	}
+
+	d := ob.book.apply(m)
+
+	// replayed orderbooks are not persisted
+	if ob.journal != nil {
+		ob.journal.append(ob.pair.GetKVPrefix(), m)
+	}
+
+	if d == nil {
+		return
+	}
+
+	// the checksum is computed from the book the diff was applied to, before any other change
+	checksum := ob.checksum()
+	d.Checksum = &checksum
+
+	ob.diffs.push(d)
+	if ob.subscriber != nil {
+		ob.subscriber <- &orderBookDiffs{ob.pair, []*types.OrderBookDiff{d}}
+	}
+}
+
+// GetMatchingBuyPricePoints returns the pricepoints of the set that are lower or equal to the
+// given pricepoint, in increasing order
 func (ob *OrderBook) GetMatchingBuyPricePoints(obKey string, pricePoint int64) ([]int64, error) {
-	pps, err := ob.redisConn.ZRangeByLexInt(obKey, "-", "["+utils.UintToPaddedString(pricePoint))
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	matching := []int64{}
+	pps := ob.book.pricePoints[obKey]
+	if pps == nil {
+		return matching, nil
+	}
+
+	for pp, ok := pps.min(); ok && pp <= pricePoint; pp, ok = pps.ceiling(pp + 1) {
+		matching = append(matching, pp)
 	}
 
-	return pps, nil
+	return matching, nil
 }
 
+// GetMatchingSellPricePoints returns the pricepoints of the set that are greater or equal to the
+// given pricepoint, in decreasing order
 func (ob *OrderBook) GetMatchingSellPricePoints(obkv string, pricePoint int64) ([]int64, error) {
-	pps, err := ob.redisConn.ZRevRangeByLexInt(obkv, "+", "["+utils.UintToPaddedString(pricePoint))
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	matching := []int64{}
+	pps := ob.book.pricePoints[obkv]
+	if pps == nil {
+		return matching, nil
+	}
+
+	for pp, ok := pps.max(); ok && pp >= pricePoint; pp, ok = pps.floor(pp - 1) {
+		matching = append(matching, pp)
 	}
 
-	return pps, nil
+	return matching, nil
 }
 
-func (ob *OrderBook) GetFromOrderMap(hash common.Hash) (*types.Order, error) {
-	o := &types.Order{}
-	keys, err := ob.redisConn.Keys("*::" + hash.Hex())
-	if err != nil {
-		return nil, err
+// walkMatchingOrders calls fn with a copy of each order of the orderbook that crosses the
+// pricepoint of o, the best priced orders first and the orders of a price level in arrival order,
+// the visible orders of a price level before its hidden orders, until fn returns false or an error. The pricepoints and the orders are read one at a time
+// instead of being copied beforehand, so that the matching only reads the orders it reaches. fn
+// can remove or update the order it is given, or move it to the back of its price level like an
+// iceberg order revealing its next slice, but no other order of the orderbook.
+func (ob *OrderBook) walkMatchingOrders(o *types.Order, fn func(entry *types.Order) (bool, error)) error {
+	key := o.GetOBMatchKey()
+	limit := o.PricePoint.Int64()
+	pps := ob.book.pricePoints[key]
+	if pps == nil {
+		return nil
 	}
 
-	if len(keys) == 0 {
-		return nil, fmt.Errorf("Key doesn't exists")
+	// buy orders match the asks by increasing pricepoint and sell orders the bids by decreasing
+	// pricepoint
+	buy := o.Side == types.OrderSideBuy
+	next := func(pp int64) (int64, bool) {
+		if buy {
+			pp, ok := pps.ceiling(pp)
+			return pp, ok && pp <= limit
+		}
+
+		pp, ok := pps.floor(pp)
+		return pp, ok && pp >= limit
 	}
 
-	serialized, err := ob.redisConn.GetValue(keys[0])
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	start := int64(math.MinInt64)
+	if !buy {
+		start = math.MaxInt64
 	}
 
-	err = json.Unmarshal([]byte(serialized), &o)
-	if err != nil {
-		return nil, err
+	for pp, ok := next(start); ok; {
+		for _, hidden := range []bool{false, true} {
+			level := ob.book.levels[key+"::"+utils.UintToPaddedString(pp)]
+			if level == nil {
+				break
+			}
+
+			for e := level.Front(); e != nil; {
+				// fn may remove the element of the order from the level
+				following := e.Next()
+
+				h := e.Value.(common.Hash)
+				entry := ob.book.orders[h]
+				if entry == nil {
+					return fmt.Errorf("Order %v is missing from the orders map", h.Hex())
+				}
+
+				if entry.Hidden == hidden {
+					more, err := fn(entry.Clone())
+					if err != nil || !more {
+						return err
+					}
+				}
+
+				e = following
+			}
+		}
+
+		if buy {
+			pp, ok = next(pp + 1)
+		} else {
+			pp, ok = next(pp - 1)
+		}
 	}
 
-	return o, nil
+	return nil
 }
 
-// GetPricePointOrders returns the orders hashes for a (pair, pricepoint)
-func (ob *OrderBook) GetMatchingOrders(obKey string, pricePoint int64) ([][]byte, error) {
-	k := obKey + "::" + utils.UintToPaddedString(pricePoint)
-	orders, err := ob.redisConn.Sort(k, "", true, false, k+"::orders::*")
-	if err != nil {
-		return nil, err
+func (ob *OrderBook) GetFromOrderMap(hash common.Hash) (*types.Order, error) {
+	o := ob.book.orders[hash]
+	if o == nil {
+		return nil, fmt.Errorf("Key doesn't exists")
+	}
+
+	return o.Clone(), nil
+}
+
+// GetAllOrders returns all the orders stored in the orderbook
+func (ob *OrderBook) GetAllOrders() ([]*types.Order, error) {
+	orders := []*types.Order{}
+	for _, o := range ob.book.orders {
+		orders = append(orders, o.Clone())
 	}
 
 	return orders, nil
 }
 
-// AddPricePointToSet
-func (ob *OrderBook) AddToPricePointSet(pricePointSetKey string, pricePoint int64) error {
-	err := ob.redisConn.ZAdd(pricePointSetKey, 0, utils.UintToPaddedString(pricePoint))
-	if err != nil {
-		logger.Error(err)
-		return err
+// GetMatchingOrders returns the orders of a (pair, pricepoint) in matching order: the visible
+// orders in arrival order, then the hidden orders in arrival order
+func (ob *OrderBook) GetMatchingOrders(obKey string, pricePoint int64) ([]*types.Order, error) {
+	k := obKey + "::" + utils.UintToPaddedString(pricePoint)
+
+	orders, hidden := []*types.Order{}, []*types.Order{}
+	for _, h := range ob.book.levelHashes(k) {
+		o := ob.book.orders[h]
+		if o == nil {
+			return nil, fmt.Errorf("Order %v is missing from the orders map", h.Hex())
+		}
+
+		if o.Hidden {
+			hidden = append(hidden, o.Clone())
+		} else {
+			orders = append(orders, o.Clone())
+		}
 	}
 
+	return append(orders, hidden...), nil
+}
+
+// GetPricePointSet returns the pricepoints of a pricepoint set in increasing order
+func (ob *OrderBook) GetPricePointSet(pricePointSetKey string) []int64 {
+	pps := ob.book.pricePoints[pricePointSetKey]
+	if pps == nil {
+		return []int64{}
+	}
+
+	return pps.values()
+}
+
+// GetPricePointHashes returns the hashes of the orders of a price level in arrival order
+func (ob *OrderBook) GetPricePointHashes(orderHashListKey string) []common.Hash {
+	return ob.book.levelHashes(orderHashListKey)
+}
+
+// AddPricePointToSet
+func (ob *OrderBook) AddToPricePointSet(pricePointSetKey string, pricePoint int64) error {
+	ob.mutate(&mutation{Op: opAddPricePoint, Key: pricePointSetKey, PricePoint: pricePoint})
 	return nil
 }
 
 // RemoveFromPricePointSet
 func (ob *OrderBook) RemoveFromPricePointSet(pricePointSetKey string, pricePoint int64) error {
-	err := ob.redisConn.ZRem(pricePointSetKey, utils.UintToPaddedString(pricePoint))
-	if err != nil {
-		logger.Error(err)
-		return err
-	}
-
+	ob.mutate(&mutation{Op: opRemovePricePoint, Key: pricePointSetKey, PricePoint: pricePoint})
 	return nil
 }
 
 func (ob *OrderBook) GetPricePointSetLength(pricePointSetKey string) (int64, error) {
-	count, err := ob.redisConn.ZCount(pricePointSetKey)
-	if err != nil {
-		logger.Error(err)
-		return 0, err
+	pps := ob.book.pricePoints[pricePointSetKey]
+	if pps == nil {
+		return 0, nil
 	}
 
-	return count, nil
+	return int64(pps.len()), nil
 }
 
-// AddPricePointHashesSet
-func (ob *OrderBook) AddToPricePointHashesSet(orderHashListKey string, createdAt time.Time, hash common.Hash) error {
-	err := ob.redisConn.ZAdd(orderHashListKey, createdAt.Unix(), hash.Hex())
-	if err != nil {
-		logger.Error(err)
-		return err
-	}
-
+// AddToPricePointHashesSet adds an order hash at the back of its price level. Orders of a price
+// level are matched strictly in arrival order. The creation time of the orders is not used since
+// orders created in the same second would tie, and an order that is already in the level (for
+// example after a partial fill) keeps its place.
+func (ob *OrderBook) AddToPricePointHashesSet(orderHashListKey string, hash common.Hash) error {
+	ob.mutate(&mutation{Op: opPushHash, Key: orderHashListKey, Hash: hash})
 	return nil
 }
 
 // RemoveFromPricePointHashesSet
 func (ob *OrderBook) RemoveFromPricePointHashesSet(orderHashListKey string, hash common.Hash) error {
-	err := ob.redisConn.ZRem(orderHashListKey, hash.Hex())
-	if err != nil {
-		logger.Error(err)
-		return err
-	}
-
+	ob.mutate(&mutation{Op: opRemoveHash, Key: orderHashListKey, Hash: hash})
 	return nil
 }
 
 func (ob *OrderBook) GetPricePointHashesSetLength(orderHashListKey string) (int64, error) {
-	count, err := ob.redisConn.ZCount(orderHashListKey)
-	if err != nil {
-		logger.Error(err)
-		return 0, err
+	level := ob.book.levels[orderHashListKey]
+	if level == nil {
+		return 0, nil
 	}
 
-	return count, nil
+	return int64(level.Len()), nil
 }
 
 // AddToOrderMap
 func (ob *OrderBook) AddToOrderMap(o *types.Order) error {
-	bytes, err := json.Marshal(o)
-	if err != nil {
-		logger.Error(err)
-		return err
+	ob.mutate(&mutation{Op: opPutOrder, Hash: o.Hash, Order: o.Clone()})
+	return nil
+}
+
+// RemoveFromOrderMap
+func (ob *OrderBook) RemoveFromOrderMap(hash common.Hash) error {
+	ob.mutate(&mutation{Op: opRemoveOrder, Hash: hash})
+	return nil
+}
+
+// AddToStopIndex stores a dormant stop order in the stop index of the orderbook
+func (ob *OrderBook) AddToStopIndex(o *types.Order) error {
+	ob.mutate(&mutation{Op: opPutStop, Hash: o.Hash, Order: o.Clone()})
+	return nil
+}
+
+// RemoveFromStopIndex removes a stop order from the stop index
+func (ob *OrderBook) RemoveFromStopIndex(o *types.Order) error {
+	ob.mutate(&mutation{Op: opRemoveStop, Hash: o.Hash})
+	return nil
+}
+
+// GetFromStopIndex returns the dormant stop order with the given side and hash
+func (ob *OrderBook) GetFromStopIndex(side types.OrderSide, hash common.Hash) (*types.Order, error) {
+	o := ob.book.stops[hash]
+	if o == nil || o.Side != side {
+		return nil, fmt.Errorf("Key doesn't exists")
 	}
 
-	decoded := &types.Order{}
-	json.Unmarshal(bytes, decoded)
-	_, orderHashListKey := o.GetOBKeys()
-	err = ob.redisConn.Set(orderHashListKey+"::orders::"+o.Hash.Hex(), string(bytes))
-	if err != nil {
-		logger.Error(err)
-		return err
+	return o.Clone(), nil
+}
+
+// GetTriggeredStopOrders returns the dormant stop orders of the given side that are triggered
+// by a trade at the given pricepoint, in the order in which the price crossed their stop
+// pricepoint: buy stops by increasing stop pricepoint and sell stops by decreasing stop pricepoint.
+// Only the stop pricepoints crossed by the trade are read from the stop index.
+func (ob *OrderBook) GetTriggeredStopOrders(side types.OrderSide, pricePoint int64) ([]*types.Order, error) {
+	triggered := []*types.Order{}
+	idx := ob.book.stopIndexes[string(side)]
+	if idx == nil {
+		return triggered, nil
 	}
 
-	return nil
+	if side == types.OrderSideBuy {
+		for pp, ok := idx.pricePoints.min(); ok && pp <= pricePoint; pp, ok = idx.pricePoints.ceiling(pp + 1) {
+			for _, h := range idx.levelHashes(pp) {
+				triggered = append(triggered, ob.book.stops[h].Clone())
+			}
+		}
+
+		return triggered, nil
+	}
+
+	for pp, ok := idx.pricePoints.max(); ok && pp >= pricePoint; pp, ok = idx.pricePoints.floor(pp - 1) {
+		hashes := idx.levelHashes(pp)
+		for i := len(hashes) - 1; i >= 0; i-- {
+			triggered = append(triggered, ob.book.stops[hashes[i]].Clone())
+		}
+	}
+
+	return triggered, nil
 }
 
-// RemoveFromOrderMap
-func (ob *OrderBook) RemoveFromOrderMap(hash common.Hash) error {
-	keys, _ := ob.redisConn.Keys("*::" + hash.Hex())
-	err := ob.redisConn.Del(keys[0])
-	if err != nil {
-		logger.Error(err)
-		return err
+// GetTrailedStopOrders returns the trailing stop orders whose stop pricepoint is moved by trades
+// between the given low and high pricepoints, with their new stop pricepoint and watermark. Sell
+// trailing stops are raised to trail the high and buy trailing stops are lowered to trail the
+// low, their stop pricepoints never moving back. The trailing stops of a side and offset all trail
+// the price to the same stop pricepoint, so only the stop pricepoints passed by the new stop
+// pricepoint are read from their index.
+func (ob *OrderBook) GetTrailedStopOrders(high, low *big.Int) ([]*types.Order, error) {
+	trailed := []*types.Order{}
+	for _, key := range ob.book.trailingIndexKeys() {
+		idx := ob.book.stopIndexes[key]
+		pp, _ := idx.pricePoints.min()
+		first := ob.book.stops[idx.levelHashes(pp)[0]]
+
+		watermark := high
+		if first.Side == types.OrderSideBuy {
+			watermark = low
+		}
+
+		stop := first.TrailingStopPricepoint(watermark)
+		trail := func(pp int64) {
+			for _, h := range idx.levelHashes(pp) {
+				o := ob.book.stops[h].Clone()
+				o.StopPricepoint = new(big.Int).Set(stop)
+				o.Watermark = new(big.Int).Set(watermark)
+				trailed = append(trailed, o)
+			}
+		}
+
+		if first.Side == types.OrderSideBuy {
+			for pp, ok := idx.pricePoints.max(); ok && pp > stop.Int64(); pp, ok = idx.pricePoints.floor(pp - 1) {
+				trail(pp)
+			}
+		} else {
+			for pp, ok := idx.pricePoints.min(); ok && pp < stop.Int64(); pp, ok = idx.pricePoints.ceiling(pp + 1) {
+				trail(pp)
+			}
+		}
 	}
 
-	return nil
+	return trailed, nil
+}
+
+// GetAllStopOrders returns all the dormant stop orders of the orderbook
+func (ob *OrderBook) GetAllStopOrders() ([]*types.Order, error) {
+	orders := []*types.Order{}
+	for _, side := range []types.OrderSide{types.OrderSideBuy, types.OrderSideSell} {
+		orders = append(orders, ob.getStopOrders(side)...)
+	}
+
+	return orders, nil
+}
+
+// getStopOrders returns the stop orders of the given side sorted by stop pricepoint and hash
+func (ob *OrderBook) getStopOrders(side types.OrderSide) []*types.Order {
+	orders := []*types.Order{}
+	for _, o := range ob.book.stops {
+		if o.Side == side {
+			orders = append(orders, o.Clone())
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return stopIndexMember(orders[i]) < stopIndexMember(orders[j])
+	})
+
+	return orders
+}
+
+// stopIndexMember returns the sort key of a stop order, its padded stop pricepoint followed by
+// its hash
+func stopIndexMember(o *types.Order) string {
+	return utils.UintToPaddedString(o.StopPricepoint.Int64()) + "::" + o.Hash.Hex()
 }
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SweepOrders starts a background routine that periodically removes from the orderbooks the
+// orders that have expired and the orders that their maker can no longer cover (see PruneOrders)
+func (e *Engine) SweepOrders(interval time.Duration, accountService interfaces.AccountService) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			_, err := e.PruneOrders(accountService)
+			if err != nil {
+				logger.Error(err)
+			}
+		}
+	}()
+}
+
+// PruneOrders removes from the orderbooks the resting orders that have expired and the orders
+// whose remaining sell amount is no longer covered by the balance and allowance of their maker.
+// The resting orders of all the pairs are read first, so that the funds of a maker are compared
+// with their orders on every pair. Each orderbook then removes its orders in a single command of
+// its event loop, like a cancellation, so that the orders can not be matched while they are
+// removed. The expiry of the orders is checked when they are removed and the orders matched since
+// they were read are left for the next sweep.
+// An ORDER_EXPIRED engine response, or a CANCELLED engine response with the INSUFFICIENT_FUNDS
+// cancel reason, is published for each removed order. The responses are also returned.
+func (e *Engine) PruneOrders(accountService interfaces.AccountService) ([]*types.EngineResponse, error) {
+	orders := []*types.Order{}
+	for _, ob := range e.orderbooks {
+		var resting []*types.Order
+		err := ob.do(context.Background(), func() (err error) {
+			resting, err = ob.GetAllOrders()
+			return err
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		orders = append(orders, resting...)
+	}
+
+	uncovered := uncoveredOrders(accountService, orders)
+
+	responses := []*types.EngineResponse{}
+	for _, ob := range e.orderbooks {
+		var res []*types.EngineResponse
+		err := ob.do(context.Background(), func() (err error) {
+			now := time.Now()
+			ob.record(&logEntry{Type: commandPruneOrders, Timestamp: now, AmountsByHash: uncovered})
+			res, err = ob.pruneOrders(now, uncovered)
+			return err
+		})
+
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		responses = append(responses, res...)
+	}
+
+	return responses, nil
+}
+
+// fundsKey identifies the funds of a maker in a token
+type fundsKey struct {
+	maker common.Address
+	token common.Address
+}
+
+// uncoveredOrders returns the filled amounts of the orders whose remaining sell amount is not
+// covered by the balance and allowance of their maker, keyed by order hash. The funds of a maker
+// are allotted to their orders in nonce order, so that the most recent orders are the first to
+// be uncovered. The orders of the makers whose balances can not be read are never uncovered.
+func uncoveredOrders(accountService interfaces.AccountService, orders []*types.Order) map[common.Hash]*big.Int {
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Nonce.Cmp(orders[j].Nonce) < 0
+	})
+
+	balances := map[common.Address]map[common.Address]*types.TokenBalance{}
+	committed := map[fundsKey]*big.Int{}
+	uncovered := map[common.Hash]*big.Int{}
+	for _, o := range orders {
+		tbs, ok := balances[o.UserAddress]
+		if !ok {
+			var err error
+			tbs, err = accountService.GetTokenBalances(o.UserAddress)
+			if err != nil {
+				logger.Error(err)
+			}
+
+			balances[o.UserAddress] = tbs
+		}
+
+		tb := tbs[o.SellToken]
+		if tb == nil || tb.Balance == nil || tb.Allowance == nil {
+			continue
+		}
+
+		available := tb.Balance
+		if tb.Allowance.Cmp(available) < 0 {
+			available = tb.Allowance
+		}
+
+		k := fundsKey{o.UserAddress, o.SellToken}
+		if committed[k] == nil {
+			committed[k] = big.NewInt(0)
+		}
+
+		total := math.Add(committed[k], o.RemainingSellAmount())
+		if total.Cmp(available) > 0 {
+			uncovered[o.Hash] = filledAmount(o)
+			continue
+		}
+
+		committed[k] = total
+	}
+
+	return uncovered
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/Proofsuite/amp-matching-engine/utils/units"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUncoveredOrders(t *testing.T) {
+	maker := common.HexToAddress("0x1")
+	token := common.HexToAddress("0x2")
+	unknown := common.HexToAddress("0x3")
+
+	newOrder := func(nonce int64, sellToken common.Address, sellAmount int64) *types.Order {
+		return &types.Order{
+			Hash:         common.BigToHash(big.NewInt(nonce)),
+			UserAddress:  maker,
+			SellToken:    sellToken,
+			SellAmount:   big.NewInt(sellAmount),
+			Amount:       big.NewInt(sellAmount),
+			FilledAmount: big.NewInt(0),
+			Nonce:        big.NewInt(nonce),
+		}
+	}
+
+	o1 := newOrder(1, token, 40)
+	o2 := newOrder(2, token, 40)
+	o3 := newOrder(3, token, 20)
+	o4 := newOrder(4, unknown, 1000)
+
+	accountService := new(mocks.AccountService)
+	accountService.On("GetTokenBalances", maker).Return(map[common.Address]*types.TokenBalance{
+		token: {Balance: big.NewInt(100), Allowance: big.NewInt(70)},
+	}, nil)
+
+	// the funds are allotted in nonce order, whatever the order of the orders, and the orders
+	// of tokens without balance are left alone
+	uncovered := uncoveredOrders(accountService, []*types.Order{o3, o4, o2, o1})
+
+	assert.Equal(t, map[common.Hash]*big.Int{o2.Hash: big.NewInt(0)}, uncovered)
+	accountService.AssertNumberOfCalls(t, "GetTokenBalances", 1)
+}
+
+func TestPruneOrders(t *testing.T) {
+	e, ob, _, maker, taker, _, zrx, weth, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	o1, _ := factory1.NewSellOrder(1e3, 1)
+	o2, _ := factory1.NewSellOrder(1e3, 2)
+
+	// the buy order expires while the balances of the makers are read
+	expires := time.Now().Unix() + 1
+	factory2.Params.Expires = big.NewInt(expires)
+	o3, _ := factory2.NewBuyOrder(999, 1)
+
+	e.addOrder(&o1)
+	e.addOrder(&o2)
+	e.addOrder(&o3)
+
+	accountService := new(mocks.AccountService)
+	accountService.On("GetTokenBalances", maker.Address).Return(map[common.Address]*types.TokenBalance{
+		zrx: {Balance: o1.SellAmount, Allowance: units.Ethers(1e6)},
+	}, nil)
+
+	accountService.On("GetTokenBalances", taker.Address).Run(func(args mock.Arguments) {
+		time.Sleep(time.Until(time.Unix(expires+1, 0)))
+	}).Return(map[common.Address]*types.TokenBalance{
+		weth: {Balance: units.Ethers(1e6), Allowance: units.Ethers(1e6)},
+	}, nil)
+
+	responses, err := e.PruneOrders(accountService)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(responses))
+
+	statuses := map[common.Hash]*types.EngineResponse{}
+	for _, res := range responses {
+		statuses[res.HashID] = res
+	}
+
+	assert.Equal(t, "CANCELLED", statuses[o2.Hash].Status)
+	assert.Equal(t, types.CancelReasonInsufficientFunds, statuses[o2.Hash].CancelReason)
+	assert.Equal(t, o2.Amount, statuses[o2.Hash].CancelledAmount)
+	assert.Equal(t, types.OrderStatusCancelled, statuses[o2.Hash].Order.Status)
+
+	assert.Equal(t, "ORDER_EXPIRED", statuses[o3.Hash].Status)
+	assert.Equal(t, types.OrderStatusExpired, statuses[o3.Hash].Order.Status)
+
+	orders, err := ob.GetAllOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o1.Hash, orders[0].Hash)
+}
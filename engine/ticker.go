@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// recordTrade records the pricepoint of a trade as the last pricepoint of the pair, which is the
+// reference of its circuit breaker and the last trade of its ticker. It must be run by the event
+// loop of the orderbook.
+func (ob *OrderBook) recordTrade(trade *types.Trade) {
+	ob.breaker.LastPricePoint = trade.PricePoint
+	ob.lastTradeTime = ob.clock()
+}
+
+// currentTicker returns the ticker of the orderbook. It must be run by the event loop of the
+// orderbook.
+func (ob *OrderBook) currentTicker() *types.Ticker {
+	t := &types.Ticker{
+		PairName:   ob.pair.Name(),
+		BaseToken:  ob.pair.BaseTokenAddress,
+		QuoteToken: ob.pair.QuoteTokenAddress,
+		Sequence:   ob.book.sequence,
+	}
+
+	// the best pricepoints are read from the displayed price levels, which hidden orders are not
+	// part of
+	walkLevels(ob.book.levelTrees[types.OrderSideBuy], true, func(pp int64, amount *big.Int) bool {
+		t.BestBid = big.NewInt(pp)
+		return false
+	})
+
+	walkLevels(ob.book.levelTrees[types.OrderSideSell], false, func(pp int64, amount *big.Int) bool {
+		t.BestAsk = big.NewInt(pp)
+		return false
+	})
+
+	if t.BestBid != nil && t.BestAsk != nil {
+		t.Mid = math.Div(math.Add(t.BestBid, t.BestAsk), big.NewInt(2))
+		t.Spread = math.Sub(t.BestAsk, t.BestBid)
+	}
+
+	if !ob.lastTradeTime.IsZero() && ob.breaker.LastPricePoint != nil {
+		last := ob.lastTradeTime
+		t.LastTradePrice = new(big.Int).Set(ob.breaker.LastPricePoint)
+		t.LastTradeTime = &last
+	}
+
+	return t
+}
+
+// publishTicker publishes the ticker of the orderbook if the price levels changed or the pair
+// traded since the last published ticker, so that it is read without waiting for the event loop
+// (see loadTicker). The ticker is also sent to the ticker subscriber, if any. It must be run by
+// the event loop of the orderbook.
+func (ob *OrderBook) publishTicker() {
+	if t, ok := ob.ticker.Load().(*types.Ticker); ok && t.Sequence == ob.book.sequence && !ob.tradedSince(t) {
+		return
+	}
+
+	t := ob.currentTicker()
+	ob.ticker.Store(t)
+	if ob.tickers != nil {
+		ob.tickers <- t
+	}
+}
+
+// tradedSince returns true if the pair traded since the given ticker
+func (ob *OrderBook) tradedSince(t *types.Ticker) bool {
+	if ob.lastTradeTime.IsZero() {
+		return false
+	}
+
+	return t.LastTradeTime == nil || !t.LastTradeTime.Equal(ob.lastTradeTime)
+}
+
+// loadTicker returns the last ticker published by the event loop of the orderbook. It includes the
+// commands completed before loadTicker is called and can be run from any goroutine.
+func (ob *OrderBook) loadTicker() *types.Ticker {
+	t, ok := ob.ticker.Load().(*types.Ticker)
+	if !ok {
+		return &types.Ticker{
+			PairName:   ob.pair.Name(),
+			BaseToken:  ob.pair.BaseTokenAddress,
+			QuoteToken: ob.pair.QuoteTokenAddress,
+		}
+	}
+
+	return t
+}
+
+// Ticker returns the best bid and ask, the mid pricepoint, the spread and the last trade of a
+// pair. The ticker is the last one published by the event loop of the orderbook (see
+// publishTicker) and is returned without waiting for the command being run. It is shared and must
+// not be modified.
+func (e *Engine) Ticker(p *types.Pair) (*types.Ticker, error) {
+	ob := e.orderbooks[p.Code()]
+	if ob == nil {
+		return nil, errors.New("Orderbook error")
+	}
+
+	return ob.loadTicker(), nil
+}
+
+// SubscribeTickers starts publishing the tickers of the pairs to the given function whenever the
+// best pricepoints of a pair change or the pair trades. The tickers of a pair are published in
+// sequence order. The function is called from a single background routine.
+func (e *Engine) SubscribeTickers(fn func(t *types.Ticker)) {
+	tickers := make(chan *types.Ticker, commandBufferSize)
+	for _, ob := range e.orderbooks {
+		ob.do(context.Background(), func() error {
+			ob.tickers = tickers
+			return nil
+		})
+	}
+
+	go func() {
+		for t := range tickers {
+			fn(t)
+		}
+	}()
+}
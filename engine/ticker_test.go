@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicker(t *testing.T) {
+	e, _, _, _, _, pair, _, _, factory1, factory2 := setupTest()
+	defer teardown(e)
+
+	tickers := make(chan *types.Ticker, 10)
+	e.SubscribeTickers(func(t *types.Ticker) {
+		tickers <- t
+	})
+
+	ticker, err := e.Ticker(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, pair.Name(), ticker.PairName)
+	assert.Nil(t, ticker.BestBid)
+	assert.Nil(t, ticker.BestAsk)
+	assert.Nil(t, ticker.LastTradePrice)
+
+	// the mid and spread are only set once both sides have orders
+	sell, _ := factory1.NewSellOrder(1100, 1)
+	e.newOrder(&sell, sell.Hash)
+
+	ticker, _ = e.Ticker(pair)
+	assert.Nil(t, ticker.BestBid)
+	assert.Equal(t, big.NewInt(1100), ticker.BestAsk)
+	assert.Nil(t, ticker.Mid)
+	assert.Nil(t, ticker.Spread)
+
+	buy1, _ := factory2.NewBuyOrder(1000, 1)
+	e.newOrder(&buy1, buy1.Hash)
+
+	ticker, _ = e.Ticker(pair)
+	assert.Equal(t, big.NewInt(1000), ticker.BestBid)
+	assert.Equal(t, big.NewInt(1100), ticker.BestAsk)
+	assert.Equal(t, big.NewInt(1050), ticker.Mid)
+	assert.Equal(t, big.NewInt(100), ticker.Spread)
+	assert.Nil(t, ticker.LastTradePrice)
+
+	// the trade empties the ask side
+	buy2, _ := factory2.NewBuyOrder(1200, 1)
+	e.newOrder(&buy2, buy2.Hash)
+
+	ticker, _ = e.Ticker(pair)
+	assert.Equal(t, big.NewInt(1000), ticker.BestBid)
+	assert.Nil(t, ticker.BestAsk)
+	assert.Nil(t, ticker.Mid)
+	assert.Equal(t, big.NewInt(1100), ticker.LastTradePrice)
+	assert.NotNil(t, ticker.LastTradeTime)
+
+	// every change is published, in sequence order
+	published := []*types.Ticker{<-tickers, <-tickers, <-tickers}
+	assert.Equal(t, big.NewInt(1100), published[0].BestAsk)
+	assert.Equal(t, big.NewInt(1000), published[1].BestBid)
+	assert.Equal(t, ticker, published[2])
+	assert.True(t, published[0].Sequence < published[1].Sequence)
+	assert.True(t, published[1].Sequence < published[2].Sequence)
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// bookView is an immutable view of the price levels of an orderbook at a sequence. It shares the
+// persistent level treaps of the book (see levelNode), so that it is taken in constant time by the
+// event loop of the orderbook and read from any goroutine without waiting for the event loop.
+type bookView struct {
+	pairName string
+	sequence uint64
+	bids     *levelNode
+	asks     *levelNode
+}
+
+// currentView returns the view of the price levels of the orderbook. It must be run by the event
+// loop of the orderbook.
+func (ob *OrderBook) currentView() *bookView {
+	return &bookView{
+		pairName: ob.pair.Name(),
+		sequence: ob.book.sequence,
+		bids:     ob.book.levelTrees[types.OrderSideBuy],
+		asks:     ob.book.levelTrees[types.OrderSideSell],
+	}
+}
+
+// publishView publishes the view of the price levels of the orderbook if they changed since the
+// last published view, so that the snapshots of the orderbook are read without waiting for the
+// event loop (see loadView). It must be run by the event loop of the orderbook.
+func (ob *OrderBook) publishView() {
+	if v, ok := ob.view.Load().(*bookView); ok && v.sequence == ob.book.sequence {
+		return
+	}
+
+	ob.view.Store(ob.currentView())
+}
+
+// loadView returns the last view published by the event loop of the orderbook. It includes the
+// commands completed before loadView is called and can be run from any goroutine.
+func (ob *OrderBook) loadView() *bookView {
+	v, ok := ob.view.Load().(*bookView)
+	if !ok {
+		return &bookView{pairName: ob.pair.Name()}
+	}
+
+	return v
+}
+
+// orderBook returns the price levels of the view, best priced first, and at most depth levels on
+// each side if depth is positive, along with the checksum of the view. Only the levels returned
+// and the levels of the checksum are read.
+func (v *bookView) orderBook(depth int) *types.OrderBook {
+	book := &types.OrderBook{
+		PairName: v.pairName,
+		Sequence: v.sequence,
+		Bids:     v.levels(v.bids, true, depth),
+		Asks:     v.levels(v.asks, false, depth),
+	}
+
+	// the checksum covers the best levels of the orderbook even if fewer levels are read
+	top := book
+	if depth > 0 && depth < types.OrderBookChecksumDepth {
+		top = v.orderBook(types.OrderBookChecksumDepth)
+	}
+
+	checksum := top.ComputeChecksum()
+	book.Checksum = &checksum
+	return book
+}
+
+// checksum returns the checksum of the view (see types.OrderBook.ComputeChecksum)
+func (v *bookView) checksum() uint32 {
+	return *v.orderBook(types.OrderBookChecksumDepth).Checksum
+}
+
+// levels returns at most depth levels of a side, or all its levels if depth is not positive. Bids
+// are read by decreasing pricepoint and asks by increasing pricepoint.
+func (v *bookView) levels(root *levelNode, descending bool, depth int) []*types.PriceLevel {
+	levels := []*types.PriceLevel{}
+	walkLevels(root, descending, func(pp int64, amount *big.Int) bool {
+		if depth > 0 && len(levels) >= depth {
+			return false
+		}
+
+		levels = append(levels, &types.PriceLevel{PricePoint: big.NewInt(pp), Amount: new(big.Int).Set(amount)})
+		return true
+	})
+
+	return levels
+}
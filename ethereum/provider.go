@@ -2,12 +2,16 @@ package ethereum
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/contracts/contractsinterfaces"
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	eth "github.com/ethereum/go-ethereum/core/types"
@@ -15,6 +19,10 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// erc20MetadataABI describes the optional ERC20 symbol() and decimals() functions. Some tokens (e.g. MKR)
+// return their symbol as a bytes32 instead of a string which is handled separately.
+const erc20MetadataABI = `[{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
 type EthereumProvider struct {
 	Client interfaces.EthereumClient
 	Config interfaces.EthereumConfig
@@ -181,6 +189,77 @@ func (e *EthereumProvider) ExchangeAllowance(owner, token common.Address) (*big.
 	return a, nil
 }
 
+// TokenMetadata returns the symbol and decimals of an ERC20 token by calling the symbol() and decimals()
+// functions of the token contract
+func (e *EthereumProvider) TokenMetadata(token common.Address) (string, uint8, error) {
+	parsed, err := abi.JSON(strings.NewReader(erc20MetadataABI))
+	if err != nil {
+		logger.Error(err)
+		return "", 0, err
+	}
+
+	out, err := e.callToken(parsed, token, "symbol")
+	if err != nil {
+		logger.Error(err)
+		return "", 0, err
+	}
+
+	symbol, err := decodeTokenSymbol(parsed, out)
+	if err != nil {
+		logger.Error(err)
+		return "", 0, err
+	}
+
+	out, err = e.callToken(parsed, token, "decimals")
+	if err != nil {
+		logger.Error(err)
+		return "", 0, err
+	}
+
+	var decimals uint8
+	err = parsed.Unpack(&decimals, "decimals", out)
+	if err != nil {
+		logger.Error(err)
+		return "", 0, err
+	}
+
+	return symbol, decimals, nil
+}
+
+func (e *EthereumProvider) callToken(parsed abi.ABI, token common.Address, method string) ([]byte, error) {
+	data, err := parsed.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := e.Client.CallContract(context.Background(), ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("Token contract does not implement " + method + "()")
+	}
+
+	return out, nil
+}
+
+// decodeTokenSymbol decodes the output of the symbol() function. A single 32 bytes word can not
+// be a valid abi encoded string and corresponds to a bytes32 symbol padded with zeros.
+func decodeTokenSymbol(parsed abi.ABI, out []byte) (string, error) {
+	if len(out) == 32 {
+		return strings.TrimRight(string(out), "\x00"), nil
+	}
+
+	var symbol string
+	err := parsed.Unpack(&symbol, "symbol", out)
+	if err != nil {
+		return "", err
+	}
+
+	return symbol, nil
+}
+
 // func (e *EthereumProvider) NewTokenInstance(
 // 	w interfaces.WalletService,
 // 	tx interfaces.TxService,
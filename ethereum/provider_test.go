@@ -0,0 +1,82 @@
+package ethereum
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	symbolSelector   = hexutil.MustDecode("0x95d89b41")
+	decimalsSelector = hexutil.MustDecode("0x313ce567")
+)
+
+func callTo(selector []byte) interface{} {
+	return mock.MatchedBy(func(msg eth.CallMsg) bool {
+		return bytes.Equal(msg.Data, selector)
+	})
+}
+
+func TestTokenMetadata(t *testing.T) {
+	token := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+
+	// abi encoded string "ZRX"
+	symbol := hexutil.MustDecode("0x" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"5a52580000000000000000000000000000000000000000000000000000000000")
+
+	decimals := common.BigToHash(big.NewInt(18)).Bytes()
+
+	client := new(mocks.EthereumClient)
+	client.On("CallContract", mock.Anything, callTo(symbolSelector), (*big.Int)(nil)).Return(symbol, nil)
+	client.On("CallContract", mock.Anything, callTo(decimalsSelector), (*big.Int)(nil)).Return(decimals, nil)
+
+	provider := &EthereumProvider{Client: client}
+	s, d, err := provider.TokenMetadata(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "ZRX", s)
+	assert.Equal(t, uint8(18), d)
+}
+
+func TestTokenMetadataBytes32Symbol(t *testing.T) {
+	token := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+
+	// bytes32 "MKR"
+	symbol := common.RightPadBytes([]byte("MKR"), 32)
+	decimals := common.BigToHash(big.NewInt(18)).Bytes()
+
+	client := new(mocks.EthereumClient)
+	client.On("CallContract", mock.Anything, callTo(symbolSelector), (*big.Int)(nil)).Return(symbol, nil)
+	client.On("CallContract", mock.Anything, callTo(decimalsSelector), (*big.Int)(nil)).Return(decimals, nil)
+
+	provider := &EthereumProvider{Client: client}
+	s, d, err := provider.TokenMetadata(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "MKR", s)
+	assert.Equal(t, uint8(18), d)
+}
+
+func TestTokenMetadataNotImplemented(t *testing.T) {
+	token := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+
+	client := new(mocks.EthereumClient)
+	client.On("CallContract", mock.Anything, mock.Anything, (*big.Int)(nil)).Return([]byte{}, nil)
+
+	provider := &EthereumProvider{Client: client}
+	_, _, err := provider.TokenMetadata(token)
+	assert.NotNil(t, err)
+}
@@ -2,7 +2,9 @@ package interfaces
 
 import (
 	"context"
+	"io"
 	"math/big"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/contracts/contractsinterfaces"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
@@ -23,15 +25,32 @@ type OrderDao interface {
 	GetByID(id bson.ObjectId) (*types.Order, error)
 	GetByHash(hash common.Hash) (*types.Order, error)
 	GetByHashes(hashes []common.Hash) ([]*types.Order, error)
-	GetByUserAddress(addr common.Address) ([]*types.Order, error)
-	GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error)
-	GetHistoryByUserAddress(addr common.Address) ([]*types.Order, error)
+	GetByUserAddress(addr common.Address, q types.OrderQuery) ([]*types.Order, string, error)
+	GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error)
+	GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error)
+	GetOpenOrderCounts(addr common.Address) (map[string]int, error)
 	UpdateOrderFilledAmount(hash common.Hash, value *big.Int) error
+	UpdateOrderAmount(hash common.Hash, amount *big.Int) error
 	GetUserLockedBalance(account common.Address, token common.Address) (*big.Int, error)
 	UpdateOrderStatus(hash common.Hash, status string) error
+	UpsertByHash(o *types.Order) error
 	GetRawOrderBook(*types.Pair) ([]*types.Order, error)
 	GetOrderBook(*types.Pair) ([]map[string]string, []map[string]string, error)
 	GetOrderBookPricePoint(p *types.Pair, pp *big.Int) (*big.Int, error)
+	ArchiveOrders(before time.Time, batches int) (int, error)
+	DeleteOrdersBefore(status string, before time.Time, batches int) (int, error)
+	Drop() error
+}
+
+type OrderCancelDao interface {
+	Create(oc *types.OrderCancel) error
+	GetByHash(hash common.Hash) (*types.OrderCancel, error)
+	Drop() error
+}
+
+type BalanceChangeDao interface {
+	Create(changes ...*types.BalanceChange) error
+	GetByAddress(addr common.Address, offset, limit int) ([]*types.BalanceChange, error)
 	Drop() error
 }
 
@@ -43,8 +62,16 @@ type AccountDao interface {
 	GetTokenBalances(owner common.Address) (map[common.Address]*types.TokenBalance, error)
 	GetTokenBalance(owner common.Address, token common.Address) (*types.TokenBalance, error)
 	UpdateTokenBalance(owner common.Address, token common.Address, tokenBalance *types.TokenBalance) (err error)
+	UpdateTokenBalances(owner common.Address, fn func(acc *types.Account) ([]*types.BalanceChange, error), tokens ...common.Address) ([]*types.BalanceChange, error)
+	LockBalance(owner, token common.Address, amount *big.Int) (*types.BalanceChange, error)
+	UnlockBalance(owner, token common.Address, amount *big.Int) (*types.BalanceChange, error)
+	TransferLocked(owner, sellToken, buyToken common.Address, spent, received *big.Int) ([]*types.BalanceChange, error)
 	UpdateBalance(owner common.Address, token common.Address, balance *big.Int) (err error)
 	UpdateAllowance(owner common.Address, token common.Address, allowance *big.Int) (err error)
+	ConsumeOrderNonce(owner common.Address, nonce *big.Int) (bool, error)
+	GetOrderNonce(owner common.Address) (*big.Int, error)
+	UpdateOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) error
+	UpdateBlocked(owner common.Address, blocked bool) error
 	Drop()
 }
 
@@ -65,19 +92,33 @@ type PairDao interface {
 	GetByTokenSymbols(baseTokenSymbol, quoteTokenSymbol string) (*types.Pair, error)
 	GetByTokenAddress(baseToken, quoteToken common.Address) (*types.Pair, error)
 	GetByBuySellTokenAddress(buyToken, sellToken common.Address) (*types.Pair, error)
+	GetActive() ([]types.Pair, error)
+	UpdateActive(baseToken, quoteToken common.Address, active bool) error
+	UpdatePaused(baseToken, quoteToken common.Address, paused bool) error
+	UpdateAuction(baseToken, quoteToken common.Address, endsAt *time.Time, reference *big.Int) error
+}
+
+// ChangeWatcher observes the inserts and updates of the orders and trades, whichever process
+// wrote them (see daos.ChangeWatcher)
+type ChangeWatcher interface {
+	Watch(fn func(*types.ChangeEvent)) error
 }
 
 type TradeDao interface {
 	Create(o ...*types.Trade) error
+	CreateBatch(trades []*types.Trade) error
 	Update(t *types.Trade) error
 	UpdateByHash(hash common.Hash, t *types.Trade) error
 	GetAll() ([]types.Trade, error)
-	Aggregate(q []bson.M) ([]*types.Tick, error)
 	GetByPairName(name string) ([]*types.Trade, error)
 	GetByHash(hash common.Hash) (*types.Trade, error)
 	GetByOrderHash(hash common.Hash) ([]*types.Trade, error)
-	GetByPairAddress(baseToken, quoteToken common.Address) ([]*types.Trade, error)
-	GetByUserAddress(addr common.Address) ([]*types.Trade, error)
+	GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error)
+	GetByPairAddress(baseToken, quoteToken common.Address, cursor string, limit int) ([]*types.Trade, string, error)
+	GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error)
+	GetOHLCV(pairs []types.PairSubDoc, duration int64, unit string, from, to time.Time) ([]*types.Tick, error)
+	GetPendingByPairAddress(baseToken, quoteToken common.Address) ([]*types.Trade, error)
+	StreamByAddress(addr common.Address, from, to time.Time) types.TradeIterator
 	UpdateTradeStatus(hash common.Hash, status string) error
 	Drop()
 }
@@ -92,6 +133,27 @@ type TokenDao interface {
 	Drop() error
 }
 
+type TokenListingDao interface {
+	Create(r *types.TokenListingRequest) error
+	GetByID(id bson.ObjectId) (*types.TokenListingRequest, error)
+	GetPendingByAddress(addr common.Address) (*types.TokenListingRequest, error)
+	UpdateStatus(id bson.ObjectId, status, reason string, reviewer common.Address) error
+	Drop() error
+}
+
+type MatchDao interface {
+	PersistMatch(m *types.MatchResult) error
+	RecoverMatches() (int, error)
+	Drop() error
+}
+
+type AuditSnapshotDao interface {
+	Create(s *types.AuditSnapshot) error
+	GetByID(id bson.ObjectId) (*types.AuditSnapshot, error)
+	GetByPair(bt, qt common.Address, offset, limit int) ([]*types.AuditSnapshot, error)
+	Drop() error
+}
+
 type Exchange interface {
 	GetAddress() common.Address
 	GetTxCallOptions() *bind.CallOpts
@@ -111,11 +173,37 @@ type Exchange interface {
 
 type Engine interface {
 	HandleOrders(msg *rabbitmq.Message) error
+	NewOrders(orders []*types.Order) ([]*types.EngineResponse, error)
 	RecoverOrders(orders []*types.OrderTradePair) error
+	RestoreFailedTrade(orders []*types.Order, amount *big.Int) ([]*types.Order, error)
 	CancelOrder(order *types.Order) (*types.EngineResponse, error)
+	CancelAllOrders(maker common.Address, pairName string) ([]*types.EngineResponse, error)
+	CancelOrdersForAddress(addr common.Address, p *types.Pair) ([]*types.Order, error)
+	CancelOrdersByHash(addr common.Address, hashes []common.Hash) ([]*types.Order, error)
+	ReplaceOrder(old, o *types.Order, hashID common.Hash) (*types.EngineResponse, error)
+	ReduceOrder(o *types.Order, amount *big.Int) (*types.EngineResponse, error)
+	SimulateOrder(o *types.Order) (*types.MatchPreview, error)
 	CancelTrades(orders []*types.Order, amount []*big.Int) error
 	DeleteOrder(o *types.Order) error
 	DeleteOrders(orders ...types.Order) error
+	UpdatePair(p *types.Pair) error
+	GetPairStatus(p *types.Pair) (*types.PairStatus, error)
+	PausePair(p *types.Pair) (*types.PairStatus, error)
+	ResumePair(p *types.Pair) (*types.PairStatus, error)
+	StartAuction(p *types.Pair) (*types.PairStatus, error)
+	GetOrderBook(p *types.Pair) (*types.OrderBook, error)
+	GetOrderBookDiffs(p *types.Pair, from uint64) ([]*types.OrderBookDiff, error)
+	GetOrderBookChecksum(p *types.Pair) (uint64, uint32, error)
+	Ticker(p *types.Pair) (*types.Ticker, error)
+	GetOrderPosition(h common.Hash) (int, *big.Int, error)
+	Stats() *types.EngineStats
+	AuditSnapshots() ([]*types.AuditSnapshot, error)
+}
+
+// WalletSource provides the signing wallets of the operator, from the database (WalletService) or
+// from the environment (services.EnvWalletSource)
+type WalletSource interface {
+	GetOperatorWallets() ([]*types.Wallet, error)
 }
 
 type WalletService interface {
@@ -141,25 +229,40 @@ type EthereumService interface {
 type OrderService interface {
 	GetByID(id bson.ObjectId) (*types.Order, error)
 	GetByHash(hash common.Hash) (*types.Order, error)
-	GetByUserAddress(addr common.Address) ([]*types.Order, error)
+	GetByUserAddress(addr common.Address, q types.OrderQuery) ([]*types.Order, string, error)
 	NewOrder(o *types.Order) error
+	NewOrders(orders []*types.Order) ([]*types.EngineResponse, error)
+	SimulateOrder(o *types.Order) (*types.MatchPreview, error)
 	CancelOrder(oc *types.OrderCancel) error
+	CancelAllOrders(ca *types.CancelAllOrders) ([]*types.Order, error)
+	CancelOrdersForAddress(addr common.Address, p *types.Pair) ([]*types.Order, error)
+	CancelOrdersByHash(addr common.Address, hashes []common.Hash) ([]*types.Order, error)
+	AuthenticateSession(sa *types.SessionAuth) error
+	FreezeAccount(addr common.Address) ([]*types.Order, error)
+	ReplaceOrder(or *types.OrderReplace) (*types.Order, error)
+	ReduceOrder(or *types.OrderReduce) (*types.Order, error)
 	CancelTrades(trades []*types.Trade) error
 	HandleEngineResponse(res *types.EngineResponse) error
-	GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error)
-	GetHistoryByUserAddress(addr common.Address) ([]*types.Order, error)
+	GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error)
+	GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error)
 	Rollback(res *types.EngineResponse) *types.EngineResponse
 	RollbackOrder(o *types.Order) error
 	RollbackTrade(o *types.Order, t *types.Trade) error
 }
 
 type OrderBookService interface {
-	GetOrderBook(bt, qt common.Address) (map[string]interface{}, error)
+	GetOrderBook(bt, qt common.Address) (*types.OrderBook, error)
+	GetGroupedOrderBook(bt, qt common.Address, decimals int) (*types.OrderBook, error)
+	GetOrderBookChecksum(bt, qt common.Address) (*types.OrderBookChecksum, error)
 	GetRawOrderBook(bt, qt common.Address) ([]*types.Order, error)
 	SubscribeOrderBook(conn *ws.Conn, bt, qt common.Address)
 	UnSubscribeOrderBook(conn *ws.Conn, bt, qt common.Address)
+	SubscribeGroupedOrderBook(conn *ws.Conn, bt, qt common.Address, decimals int)
+	UnSubscribeGroupedOrderBook(conn *ws.Conn, bt, qt common.Address, decimals int)
 	SubscribeRawOrderBook(conn *ws.Conn, bt, qt common.Address)
 	UnSubscribeRawOrderBook(conn *ws.Conn, bt, qt common.Address)
+	ReplayOrderBookDiffs(conn *ws.Conn, bt, qt common.Address, from uint64)
+	GetEngineStats() *types.EngineStats
 }
 
 type PairService interface {
@@ -167,10 +270,22 @@ type PairService interface {
 	GetByID(id bson.ObjectId) (*types.Pair, error)
 	GetByTokenAddress(bt, qt common.Address) (*types.Pair, error)
 	GetAll() ([]types.Pair, error)
+	GetActive() ([]types.Pair, error)
+	SetActive(bt, qt common.Address, active bool) (*types.Pair, error)
+	GetStatus(bt, qt common.Address) (*types.PairStatus, error)
+	Pause(bt, qt common.Address) (*types.PairStatus, error)
+	Resume(bt, qt common.Address) (*types.PairStatus, error)
+	StartAuction(bt, qt common.Address, duration time.Duration, reference *big.Int) (*types.PairStatus, error)
+	BroadcastPairStatus(s *types.PairStatus)
+	GetTicker(bt, qt common.Address) (*types.Ticker, error)
+	SubscribeTicker(conn *ws.Conn, bt, qt common.Address)
+	UnsubscribeTicker(conn *ws.Conn, bt, qt common.Address)
+	BroadcastTicker(t *types.Ticker)
 }
 
 type TokenService interface {
-	Create(token *types.Token) error
+	Create(token *types.Token, force bool) error
+	ResolveTokenMetadata(addr common.Address) (string, uint8, error)
 	GetByID(id bson.ObjectId) (*types.Token, error)
 	GetByAddress(addr common.Address) (*types.Token, error)
 	GetAll() ([]types.Token, error)
@@ -178,13 +293,37 @@ type TokenService interface {
 	GetBaseTokens() ([]types.Token, error)
 }
 
+type AuthService interface {
+	NewChallenge() (common.Hash, error)
+	Authenticate(challenge common.Hash, sig *types.Signature) (*types.Wallet, error)
+}
+
+type TokenListingService interface {
+	Create(r *types.TokenListingRequest) error
+	GetByID(id bson.ObjectId) (*types.TokenListingRequest, error)
+	Review(review *types.TokenListingReview) (*types.TokenListingRequest, error)
+}
+
+type ArchiveService interface {
+	StartBackfill() error
+}
+
+type AuditService interface {
+	TakeSnapshots() ([]*types.AuditSnapshot, error)
+	GetByID(id bson.ObjectId) (*types.AuditSnapshot, error)
+	GetByPair(bt, qt common.Address, offset, limit int) ([]*types.AuditSnapshot, error)
+	Verify(id bson.ObjectId) (*types.AuditSnapshot, error)
+}
+
 type TradeService interface {
 	GetByPairName(p string) ([]*types.Trade, error)
 	GetTrades(bt, qt common.Address) ([]types.Trade, error)
-	GetByPairAddress(bt, qt common.Address) ([]*types.Trade, error)
-	GetByUserAddress(addr common.Address) ([]*types.Trade, error)
+	GetByPairAddress(bt, qt common.Address, cursor string, limit int) ([]*types.Trade, string, error)
+	GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error)
 	GetByHash(hash common.Hash) (*types.Trade, error)
 	GetByOrderHash(hash common.Hash) ([]*types.Trade, error)
+	GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error)
+	ExportByUserAddress(w io.Writer, addr common.Address, from, to time.Time, format string) error
 	UpdateTradeTxHash(tr *types.Trade, txHash common.Hash) error
 	Subscribe(conn *ws.Conn, bt, qt common.Address)
 	Unsubscribe(conn *ws.Conn, bt, qt common.Address)
@@ -205,6 +344,10 @@ type AccountService interface {
 	GetByAddress(a common.Address) (*types.Account, error)
 	GetTokenBalance(owner common.Address, token common.Address) (*types.TokenBalance, error)
 	GetTokenBalances(owner common.Address) (map[common.Address]*types.TokenBalance, error)
+	GetNextOrderNonce(owner common.Address) (*big.Int, error)
+	GetLedger(owner common.Address, offset, limit int) ([]*types.BalanceChange, error)
+	GetOpenOrderCounts(owner common.Address) (*types.OpenOrderCounts, error)
+	SetOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) (*types.OpenOrderCounts, error)
 }
 
 type EthereumConfig interface {
@@ -234,4 +377,5 @@ type EthereumProvider interface {
 	BalanceOf(owner common.Address, token common.Address) (*big.Int, error)
 	Allowance(owner, spender, token common.Address) (*big.Int, error)
 	ExchangeAllowance(owner, token common.Address) (*big.Int, error)
+	TokenMetadata(token common.Address) (string, uint8, error)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
@@ -18,6 +19,13 @@ import (
 
 var logger = utils.OperatorLogger
 
+// SettlementTimeout is the time after which a trade whose transaction is not mined is considered
+// failed. It is set from the settlement_timeout configuration.
+var SettlementTimeout = 10 * time.Minute
+
+// ErrSettlementTimeout is returned when a trade transaction is not mined within SettlementTimeout
+var ErrSettlementTimeout = errors.New("Trade transaction was not mined in time")
+
 // Operator manages the transaction queue that will eventually be
 // sent to the exchange contract. The Operator Wallet must be equal to the
 // account that initially deployed the exchange contract or an address with operator rights
@@ -38,6 +46,7 @@ type Operator struct {
 type OperatorInterface interface {
 	SubscribeOperatorMessages(fn func(*types.OperatorMessage) error) error
 	QueueTrade(o *types.Order, t *types.Trade) error
+	QueueTrades(matches []*types.OrderTradePair) error
 	GetShortestQueue() (*TxQueue, int, error)
 	SetFeeAccount(account common.Address) (*eth.Transaction, error)
 	SetOperator(account common.Address, isOperator bool) (*eth.Transaction, error)
@@ -50,7 +59,9 @@ type OperatorInterface interface {
 // Upon receiving errors and trades in their respective channels, event payloads are sent to the
 // associated order maker and taker sockets through the through the event channel on the Order and Trade struct.
 // In addition, an error event cancels the trade in the trading engine and makes the order available again.
+// The operator accounts are the wallets of the wallet source.
 func NewOperator(
+	walletSource interfaces.WalletSource,
 	walletService interfaces.WalletService,
 	tradeService interfaces.TradeService,
 	orderService interfaces.OrderService,
@@ -58,16 +69,36 @@ func NewOperator(
 	exchange interfaces.Exchange,
 	conn *rabbitmq.Connection,
 ) (*Operator, error) {
-	txqueues := []*TxQueue{}
-	addressIndex := make(map[common.Address]*TxQueue)
-
-	wallets, err := walletService.GetOperatorWallets()
+	wallets, err := walletSource.GetOperatorWallets()
 	if err != nil {
-		panic(err)
+		logger.Error(err)
+		return nil, err
 	}
 
-	for i, w := range wallets {
-		name := strconv.Itoa(i) + w.Address.Hex()
+	signers := []types.Signer{}
+	for _, w := range wallets {
+		signers = append(signers, w)
+	}
+
+	return NewOperatorWithSigners(signers, walletService, tradeService, orderService, provider, exchange, conn)
+}
+
+// NewOperatorWithSigners creates a new operator struct with one transaction queue per signer. This allows
+// the operator accounts to be held by remote signers instead of wallets stored in the database.
+func NewOperatorWithSigners(
+	signers []types.Signer,
+	walletService interfaces.WalletService,
+	tradeService interfaces.TradeService,
+	orderService interfaces.OrderService,
+	provider interfaces.EthereumProvider,
+	exchange interfaces.Exchange,
+	conn *rabbitmq.Connection,
+) (*Operator, error) {
+	txqueues := []*TxQueue{}
+	addressIndex := make(map[common.Address]*TxQueue)
+
+	for i, s := range signers {
+		name := strconv.Itoa(i) + s.SignerAddress().Hex()
 		ch := conn.GetChannel("TX_QUEUES:" + name)
 		err := conn.DeclareQueue(ch, "TX_QUEUES:"+name)
 		if err != nil {
@@ -79,7 +110,7 @@ func NewOperator(
 			tradeService,
 			provider,
 			orderService,
-			w,
+			s,
 			exchange,
 			conn,
 		)
@@ -151,13 +182,33 @@ func (op *Operator) SubscribeOperatorMessages(fn func(*types.OperatorMessage) er
 // order hash in the ordertrade mapping. I suspect this is because the event listener catches events from previous
 // tests. It might be helpful to see how to listen to events from up to a certain block.
 func (op *Operator) HandleEvents() error {
-	tradeEvents, err := op.Exchange.ListenToTrades()
+	return op.handleExchangeEvents(op.Exchange)
+}
+
+// AddExchange registers an exchange contract in addition to the primary exchange contract, eg.
+// while migrating to a newly deployed contract. Trades are executed on the exchange contract
+// of their order and the trade and error events of the new contract are handled like the events
+// of the primary contract.
+func (op *Operator) AddExchange(ex interfaces.Exchange) {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	for _, txq := range op.TxQueues {
+		txq.AddExchange(ex)
+	}
+
+	go op.handleExchangeEvents(ex)
+}
+
+// handleExchangeEvents listens to the trade and error events of the given exchange contract
+func (op *Operator) handleExchangeEvents(ex interfaces.Exchange) error {
+	tradeEvents, err := ex.ListenToTrades()
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
-	errorEvents, err := op.Exchange.ListenToErrors()
+	errorEvents, err := ex.ListenToErrors()
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -216,28 +267,34 @@ func (op *Operator) HandleEvents() error {
 	}
 }
 
+// HandleTrades queues the trades of an operator message. A message holds either a single trade or
+// the batch of trades of a taker order, whose trades are queued in the order of the matches.
 func (op *Operator) HandleTrades(msg *types.OperatorMessage) error {
-	o := msg.Order
-	// t := msg.Trade
-
-	//TODO move this to the order service
-	err := o.Validate()
-	if err != nil {
-		logger.Error(err)
-		return err
+	matches := msg.Matches
+	if len(matches) == 0 {
+		matches = []*types.OrderTradePair{{Order: msg.Order, Trade: msg.Trade}}
 	}
 
-	//TODO move this to the order service
-	ok, err := o.VerifySignature()
-	if err != nil {
-		return err
-	}
+	for _, m := range matches {
+		//TODO move this to the order service
+		err := m.Order.Validate()
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
 
-	if !ok {
-		return errors.New("Invalid signature")
+		//TODO move this to the order service
+		ok, err := m.Order.VerifySignature()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("Invalid signature")
+		}
 	}
 
-	err = op.QueueTrade(msg.Order, msg.Trade)
+	err := op.QueueTrades(matches)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -248,6 +305,13 @@ func (op *Operator) HandleTrades(msg *types.OperatorMessage) error {
 
 // QueueTrade
 func (op *Operator) QueueTrade(o *types.Order, t *types.Trade) error {
+	return op.QueueTrades([]*types.OrderTradePair{{Order: o, Trade: t}})
+}
+
+// QueueTrades queues a batch of trades on a single transaction queue, so that they are executed
+// one after the other in the order of the batch. The trades following an invalid trade are not
+// queued.
+func (op *Operator) QueueTrades(matches []*types.OrderTradePair) error {
 	op.mutex.Lock()
 	defer op.mutex.Unlock()
 
@@ -262,11 +326,13 @@ func (op *Operator) QueueTrade(o *types.Order, t *types.Trade) error {
 		return errors.New("Transaction queue is full")
 	}
 
-	logger.Info("QUEING TRADE", len)
-	err = txq.QueueTrade(o, t)
-	if err != nil {
-		logger.Warning("INVALID TRADE")
-		return err
+	for _, m := range matches {
+		logger.Info("QUEING TRADE", len)
+		err = txq.QueueTrade(m.Order, m.Trade)
+		if err != nil {
+			logger.Warning("INVALID TRADE")
+			return err
+		}
 	}
 
 	return nil
@@ -370,7 +436,7 @@ func (op *Operator) GetTxSendOptions() (*bind.TransactOpts, error) {
 		return nil, err
 	}
 
-	return bind.NewKeyedTransactor(wallet.PrivateKey), nil
+	return types.NewTransactor(wallet), nil
 }
 
 // func (op *Operator) ValidateTrade(o *types.Order, t *types.Trade) error {
@@ -155,6 +155,7 @@ func SetupTest(t *testing.T) (
 	}
 
 	op, err := operator.NewOperator(
+		walletService,
 		walletService,
 		tradeService,
 		orderService,
@@ -339,6 +340,59 @@ func TestExecuteTrade(t *testing.T) {
 
 }
 
+func TestExecuteTradeOnOrderExchange(t *testing.T) {
+	_, _, wallets, zrx, weth, factory1, _, simulator, _, orderService, rabbitConn := SetupTest(t)
+
+	// the order is signed for the second exchange contract
+	factory1.SetExchangeAddress(testutils.GetTestAddress2())
+	o1, _ := factory1.NewOrder(zrx, 1, weth, 1)
+	t1, _ := factory1.NewTrade(o1, 1)
+
+	provider := ethereum.NewEthereumProvider(simulator)
+	tradeService := new(mocks.TradeService)
+	exchange1 := new(mocks.Exchange)
+	exchange2 := new(mocks.Exchange)
+	mockTx := &eth.Transaction{}
+	tradeService.On("UpdateTradeTxHash", mock.Anything, mock.Anything).Return(nil)
+	exchange1.On("GetAddress").Return(testutils.GetTestAddress1())
+	exchange2.On("GetAddress").Return(testutils.GetTestAddress2())
+	exchange2.On("CallTrade", o1, &t1, mock.Anything).Return(uint64(200000), nil)
+	exchange2.On("Trade", o1, &t1, mock.Anything).Return(mockTx, nil)
+
+	txq, err := operator.NewTxQueue(
+		"queue1",
+		tradeService,
+		provider,
+		orderService,
+		wallets[0],
+		exchange1,
+		rabbitConn,
+	)
+	if err != nil {
+		t.Errorf("Could not create new queue")
+	}
+	defer txq.PurgePendingTrades()
+
+	txq.AddExchange(exchange2)
+
+	tx, err := txq.ExecuteTrade(o1, &t1)
+	if err != nil {
+		t.Errorf("Could not execute trade: %v", err)
+	}
+
+	exchange2.AssertCalled(t, "Trade", o1, &t1, mock.Anything)
+	exchange1.AssertNotCalled(t, "Trade", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, mockTx, tx)
+
+	// orders of unknown exchange contracts are not executed
+	factory1.SetExchangeAddress(testutils.GetTestAddress3())
+	o2, _ := factory1.NewOrder(zrx, 1, weth, 1)
+	t2, _ := factory1.NewTrade(o2, 1)
+
+	_, err = txq.ExecuteTrade(o2, &t2)
+	assert.Error(t, err)
+}
+
 func TestQueueTrade(t *testing.T) {
 	_, _, wallets, zrx, weth, factory1, _, simulator, _, orderService, rabbitConn := SetupTest(t)
 
@@ -3,23 +3,29 @@ package operator
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 type TxQueue struct {
 	Name             string
-	Wallet           *types.Wallet
+	Signer           types.Signer
 	TradeService     interfaces.TradeService
 	OrderService     interfaces.OrderService
 	EthereumProvider interfaces.EthereumProvider
 	Exchange         interfaces.Exchange
+	Exchanges        map[common.Address]interfaces.Exchange
 	RabbitMQConn     *rabbitmq.Connection
 }
 
@@ -29,7 +35,7 @@ func NewTxQueue(
 	tr interfaces.TradeService,
 	p interfaces.EthereumProvider,
 	o interfaces.OrderService,
-	w *types.Wallet,
+	s types.Signer,
 	ex interfaces.Exchange,
 	rabbitConn *rabbitmq.Connection,
 ) (*TxQueue, error) {
@@ -39,8 +45,9 @@ func NewTxQueue(
 		TradeService:     tr,
 		OrderService:     o,
 		EthereumProvider: p,
-		Wallet:           w,
+		Signer:           s,
 		Exchange:         ex,
+		Exchanges:        make(map[common.Address]interfaces.Exchange),
 		RabbitMQConn:     rabbitConn,
 	}
 
@@ -54,12 +61,31 @@ func NewTxQueue(
 }
 
 func (txq *TxQueue) GetTxSendOptions() *bind.TransactOpts {
-	return bind.NewKeyedTransactor(txq.Wallet.PrivateKey)
+	return types.NewTransactor(txq.Signer)
 }
 
-func (txq *TxQueue) GetTxCallOptions() *ethereum.CallMsg {
-	address := txq.Exchange.GetAddress()
-	return &ethereum.CallMsg{From: txq.Wallet.Address, To: &address}
+func (txq *TxQueue) GetTxCallOptions(ex interfaces.Exchange) *ethereum.CallMsg {
+	address := ex.GetAddress()
+	return &ethereum.CallMsg{From: txq.Signer.SignerAddress(), To: &address}
+}
+
+// AddExchange registers an exchange contract other than the primary exchange. Trades are
+// sent to the contract named in the exchange address of their order.
+func (txq *TxQueue) AddExchange(ex interfaces.Exchange) {
+	txq.Exchanges[ex.GetAddress()] = ex
+}
+
+// GetExchange returns the exchange contract with the given address
+func (txq *TxQueue) GetExchange(addr common.Address) (interfaces.Exchange, error) {
+	if ex, ok := txq.Exchanges[addr]; ok {
+		return ex, nil
+	}
+
+	if txq.Exchange.GetAddress() == addr {
+		return txq.Exchange, nil
+	}
+
+	return nil, fmt.Errorf("Unknown exchange address: %v", addr.Hex())
 }
 
 // Length
@@ -99,14 +125,26 @@ func (txq *TxQueue) QueueTrade(o *types.Order, t *types.Trade) error {
 	return nil
 }
 
-// ExecuteTrade send a trade execution order to the smart contract interface. After sending the
+// ExecuteTrade send a trade execution order to the exchange contract of the order. After sending the
 // trade message, the trade is updated on the database and is published to the operator subscribers
 // (order service)
 func (txq *TxQueue) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transaction, error) {
 	logger.Info("EXECUTE_TRADE: ", tr.Hash.Hex())
 
-	callOpts := txq.GetTxCallOptions()
-	gasLimit, err := txq.Exchange.CallTrade(o, tr, callOpts)
+	err := validateEncoding(o, tr)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ex, err := txq.GetExchange(o.ExchangeAddress)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	callOpts := txq.GetTxCallOptions(ex)
+	gasLimit, err := ex.CallTrade(o, tr, callOpts)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -124,7 +162,7 @@ func (txq *TxQueue) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transact
 		return nil, errors.New("Invalid Trade")
 	}
 
-	nonce, err := txq.EthereumProvider.GetPendingNonceAt(txq.Wallet.Address)
+	nonce, err := txq.EthereumProvider.GetPendingNonceAt(txq.Signer.SignerAddress())
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -132,7 +170,7 @@ func (txq *TxQueue) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transact
 
 	txOpts := txq.GetTxSendOptions()
 	txOpts.Nonce = big.NewInt(int64(nonce))
-	tx, err := txq.Exchange.Trade(o, tr, txOpts)
+	tx, err := ex.Trade(o, tr, txOpts)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -151,9 +189,20 @@ func (txq *TxQueue) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transact
 	}
 
 	go func() {
-		_, err := txq.EthereumProvider.WaitMined(tx.Hash())
-		if err != nil {
-			logger.Error(err)
+		// trades whose transaction reverts or is not mined in time are failed, the successful
+		// trades being reported by the trade events of the exchange contract
+		receipt, err := txq.waitSettlement(tx.Hash())
+		if err != nil || receipt.Status == eth.ReceiptStatusFailed {
+			errID := types.TxErrorReverted
+			if err != nil {
+				logger.Error(err)
+				errID = types.TxErrorTimeout
+			}
+
+			err = txq.RabbitMQConn.PublishTxErrorMessage(tr, errID)
+			if err != nil {
+				logger.Error(err)
+			}
 		}
 
 		logger.Info("TRADE_MINED IN EXECUTE TRADE: ", tr.Hash.Hex())
@@ -187,6 +236,48 @@ func (txq *TxQueue) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transact
 	return tx, nil
 }
 
+// waitSettlement waits for the receipt of a trade transaction. ErrSettlementTimeout is returned if
+// the transaction is not mined within SettlementTimeout.
+func (txq *TxQueue) waitSettlement(h common.Hash) (*eth.Receipt, error) {
+	type result struct {
+		receipt *eth.Receipt
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		receipt, err := txq.EthereumProvider.WaitMined(h)
+		done <- result{receipt, err}
+	}()
+
+	t := time.NewTimer(SettlementTimeout)
+	defer t.Stop()
+
+	select {
+	case r := <-done:
+		return r.receipt, r.err
+	case <-t.C:
+		return nil, ErrSettlementTimeout
+	}
+}
+
+// validateEncoding checks that the order and trade fields packed the same way as in the exchange
+// smart contract hash to the signed hashes. Sending a mismatching trade would only be reverted
+// with an invalid signature error.
+func validateEncoding(o *types.Order, tr *types.Trade) error {
+	if crypto.Keccak256Hash(o.EncodedBytes()) != o.Hash {
+		logger.Warning("ENCODED ORDER: ", hexutil.Encode(o.EncodedBytes()))
+		return errors.New("Order hash does not match the encoded order")
+	}
+
+	if crypto.Keccak256Hash(tr.EncodedBytes()) != tr.Hash {
+		logger.Warning("ENCODED TRADE: ", hexutil.Encode(tr.EncodedBytes()))
+		return errors.New("Trade hash does not match the encoded trade")
+	}
+
+	return nil
+}
+
 func (txq *TxQueue) ExecuteNextTrade(tr *types.Trade) error {
 	len := txq.Length()
 	logger.Info("LENGTH of the queue is ", len)
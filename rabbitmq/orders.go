@@ -93,6 +93,32 @@ func (c *Connection) PublishTrade(o *types.Order, t *types.Trade) error {
 	return nil
 }
 
+// PublishTrades publishes the trades of a taker order as a single batch. The operator executes the
+// trades of a batch one after the other, in the order of the matches.
+func (c *Connection) PublishTrades(matches []*types.OrderTradePair) error {
+	ch := c.GetChannel("tradePublish")
+	q := c.GetQueue(ch, "trades")
+
+	msg := &types.OperatorMessage{
+		MessageType: "NEW_TRADES",
+		Matches:     matches,
+	}
+
+	bytes, err := json.Marshal(msg)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = c.Publish(ch, q, bytes)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
 func (c *Connection) PublishOrder(order *Message) error {
 	ch := c.GetChannel("orderPublish")
 	q := c.GetQueue(ch, "order")
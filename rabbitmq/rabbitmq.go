@@ -2,6 +2,7 @@ package rabbitmq
 
 import (
 	"log"
+	"sync"
 
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/ethereum/go-ethereum/common"
@@ -13,6 +14,9 @@ var conn *Connection
 var channels = make(map[string]*amqp.Channel)
 var queues = make(map[string]*amqp.Queue)
 
+// mu guards the channels and queues maps, which are used by the event loops of all the pairs
+var mu sync.Mutex
+
 var logger = utils.RabbitLogger
 
 type Connection struct {
@@ -46,6 +50,9 @@ func (c *Connection) NewConnection(address string) *amqp.Connection {
 }
 
 func (c *Connection) GetQueue(ch *amqp.Channel, queue string) *amqp.Queue {
+	mu.Lock()
+	defer mu.Unlock()
+
 	if queues[queue] == nil {
 		q, err := ch.QueueDeclare(queue, false, false, false, false, nil)
 		if err != nil {
@@ -59,6 +66,9 @@ func (c *Connection) GetQueue(ch *amqp.Channel, queue string) *amqp.Queue {
 }
 
 func (c *Connection) DeclareQueue(ch *amqp.Channel, name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
 	if queues[name] == nil {
 		q, err := ch.QueueDeclare(name, false, false, false, false, nil)
 		if err != nil {
@@ -73,6 +83,9 @@ func (c *Connection) DeclareQueue(ch *amqp.Channel, name string) error {
 }
 
 func (c *Connection) GetChannel(id string) *amqp.Channel {
+	mu.Lock()
+	defer mu.Unlock()
+
 	if channels[id] == nil {
 		ch, err := c.Conn.Channel()
 		if err != nil {
@@ -3,6 +3,7 @@ package redis
 import (
 	"fmt"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/alicebob/miniredis"
@@ -11,8 +12,12 @@ import (
 
 var logger = utils.Logger
 
+// RedisConnection is a connection to redis that can be dialed again once lost (see Reconnect).
+// generation is the number of times the connection was dialed again.
 type RedisConnection struct {
 	redis.Conn
+	uri        string
+	generation uint64
 }
 
 // InitConnection returns a new connection to redis
@@ -30,7 +35,7 @@ func NewRedisConnection(uri string) *RedisConnection {
 		panic(err)
 	}
 
-	return &RedisConnection{c}
+	return &RedisConnection{Conn: c, uri: uri}
 }
 
 func NewMiniRedisConnection() *RedisConnection {
@@ -44,7 +49,27 @@ func NewMiniRedisConnection() *RedisConnection {
 		panic(err)
 	}
 
-	return &RedisConnection{c}
+	return &RedisConnection{Conn: c, uri: "redis://" + s.Addr()}
+}
+
+// Reconnect closes the connection and dials redis again. It must not be called while the
+// connection is used by another goroutine.
+func (c *RedisConnection) Reconnect() error {
+	conn, err := redis.DialURL(c.uri)
+	if err != nil {
+		return err
+	}
+
+	c.Conn.Close()
+	c.Conn = conn
+	atomic.AddUint64(&c.generation, 1)
+	return nil
+}
+
+// Generation returns the number of times the connection was dialed again. It can be called from
+// any goroutine.
+func (c *RedisConnection) Generation() uint64 {
+	return atomic.LoadUint64(&c.generation)
 }
 
 // FlushAll flushes all the key in the redis db
@@ -201,3 +226,23 @@ func (c *RedisConnection) MGet(keys ...string) (res []string, err error) {
 	}
 	return redis.Strings(c.Do("MGET", args...))
 }
+
+// RPush appends a value to the list stored at key
+func (c *RedisConnection) RPush(key string, value string) error {
+	_, err := redis.Int64(c.Do("RPUSH", key, value))
+	return err
+}
+
+// LRange returns the values of the list stored at key between the start and stop indexes
+func (c *RedisConnection) LRange(key string, start, stop int) ([]string, error) {
+	return redis.Strings(c.Do("LRANGE", key, start, stop))
+}
+
+// SetAndDel sets the value of a key and deletes another key in a single transaction
+func (c *RedisConnection) SetAndDel(key string, value string, delKey string) error {
+	c.Send("MULTI")
+	c.Send("SET", key, value)
+	c.Send("DEL", delKey)
+	_, err := c.Do("EXEC")
+	return err
+}
@@ -5,21 +5,33 @@ import (
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/mgo.v2/bson"
 )
 
 type AccountService struct {
-	AccountDao interfaces.AccountDao
-	TokenDao   interfaces.TokenDao
+	AccountDao       interfaces.AccountDao
+	TokenDao         interfaces.TokenDao
+	BalanceChangeDao interfaces.BalanceChangeDao
+	OrderDao         interfaces.OrderDao
 }
 
+// MaxOpenOrdersPerAccount is the default maximum number of open orders of an account over all
+// pairs and MaxOpenOrdersPerAccountPerPair the default maximum on a single pair. They are set from
+// the max_open_orders_per_account and max_open_orders_per_account_per_pair configuration and can
+// be overridden for an account. A zero limit disables the check.
+var MaxOpenOrdersPerAccount = 0
+var MaxOpenOrdersPerAccountPerPair = 0
+
 // NewAddressService returns a new instance of accountService
 func NewAccountService(
 	AccountDao interfaces.AccountDao,
 	TokenDao interfaces.TokenDao,
+	BalanceChangeDao interfaces.BalanceChangeDao,
+	OrderDao interfaces.OrderDao,
 ) *AccountService {
-	return &AccountService{AccountDao, TokenDao}
+	return &AccountService{AccountDao, TokenDao, BalanceChangeDao, OrderDao}
 }
 
 func (s *AccountService) Create(a *types.Account) error {
@@ -84,3 +96,102 @@ func (s *AccountService) GetTokenBalance(owner common.Address, token common.Addr
 func (s *AccountService) GetTokenBalances(owner common.Address) (map[common.Address]*types.TokenBalance, error) {
 	return s.AccountDao.GetTokenBalances(owner)
 }
+
+// GetNextOrderNonce returns the lowest nonce that can be used for the next order of an account
+func (s *AccountService) GetNextOrderNonce(owner common.Address) (*big.Int, error) {
+	nonce, err := s.AccountDao.GetOrderNonce(owner)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if nonce == nil {
+		return big.NewInt(0), nil
+	}
+
+	return math.Add(nonce, big.NewInt(1)), nil
+}
+
+// GetLedger returns the balance changes of an account, the most recent first
+func (s *AccountService) GetLedger(owner common.Address, offset, limit int) ([]*types.BalanceChange, error) {
+	return s.BalanceChangeDao.GetByAddress(owner, offset, limit)
+}
+
+// GetOpenOrderCounts returns the numbers of open orders of an account and the limits that apply to it
+func (s *AccountService) GetOpenOrderCounts(owner common.Address) (*types.OpenOrderCounts, error) {
+	acc, err := s.AccountDao.GetByAddress(owner)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if acc == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return countOpenOrders(s.OrderDao, acc)
+}
+
+// SetOpenOrderLimits overrides the default open orders limits of an account. The account is
+// reverted to the default limits when limits is nil.
+func (s *AccountService) SetOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) (*types.OpenOrderCounts, error) {
+	if limits != nil {
+		err := limits.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	acc, err := s.AccountDao.GetByAddress(owner)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if acc == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	err = s.AccountDao.UpdateOpenOrderLimits(owner, limits)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	acc.OpenOrderLimits = limits
+	return countOpenOrders(s.OrderDao, acc)
+}
+
+// openOrderLimits returns the open orders limits that apply to an account
+func openOrderLimits(acc *types.Account) types.OpenOrderLimits {
+	if acc != nil && acc.OpenOrderLimits != nil {
+		return *acc.OpenOrderLimits
+	}
+
+	return types.OpenOrderLimits{
+		Total:   MaxOpenOrdersPerAccount,
+		PerPair: MaxOpenOrdersPerAccountPerPair,
+	}
+}
+
+// countOpenOrders returns the numbers of open orders of an account. The counts are computed from
+// the order statuses so that cancelled and filled orders are no longer counted.
+func countOpenOrders(orderDao interfaces.OrderDao, acc *types.Account) (*types.OpenOrderCounts, error) {
+	pairs, err := orderDao.GetOpenOrderCounts(acc.Address)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	counts := &types.OpenOrderCounts{
+		Address: acc.Address,
+		Pairs:   pairs,
+		Limits:  openOrderLimits(acc),
+	}
+
+	for _, c := range pairs {
+		counts.Total += c
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/memdaos"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountServiceCreate(t *testing.T) {
+	accountDao := memdaos.NewAccountDao()
+	tokenDao := memdaos.NewTokenDao()
+	accountService := NewAccountService(accountDao, tokenDao, nil, memdaos.NewOrderDao())
+
+	zrx := testutils.GetTestZRXToken()
+	weth := testutils.GetTestWETHToken()
+	tokenDao.Create(&zrx)
+	tokenDao.Create(&weth)
+
+	addr := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	err := accountService.Create(&types.Account{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = accountService.Create(&types.Account{Address: addr})
+	assert.Equal(t, ErrAccountExists, err)
+
+	// the accounts are given a zero balance of every token
+	balances, err := accountService.GetTokenBalances(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(balances))
+	assert.Equal(t, "ZRX", balances[zrx.ContractAddress].Symbol)
+	assert.Equal(t, big.NewInt(0), balances[zrx.ContractAddress].Balance)
+	assert.Equal(t, big.NewInt(0), balances[weth.ContractAddress].Allowance)
+
+	nonce, _ := accountService.GetNextOrderNonce(addr)
+	assert.Equal(t, big.NewInt(0), nonce)
+
+	consumed, _ := accountDao.ConsumeOrderNonce(addr, big.NewInt(5))
+	assert.True(t, consumed)
+	consumed, _ = accountDao.ConsumeOrderNonce(addr, big.NewInt(5))
+	assert.False(t, consumed)
+
+	nonce, _ = accountService.GetNextOrderNonce(addr)
+	assert.Equal(t, big.NewInt(6), nonce)
+}
+
+func TestAccountServiceOpenOrderCounts(t *testing.T) {
+	orderDao := memdaos.NewOrderDao()
+	accountService := NewAccountService(memdaos.NewAccountDao(), memdaos.NewTokenDao(), nil, orderDao)
+
+	addr := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	_, err := accountService.GetOpenOrderCounts(addr)
+	assert.Equal(t, ErrAccountNotFound, err)
+
+	accountService.Create(&types.Account{Address: addr})
+
+	o1 := testutils.GetTestOrder1()
+	o2 := testutils.GetTestOrder2()
+	o3 := testutils.GetTestOrder3()
+	o3.Status = types.OrderStatusFilled
+	orderDao.Create(&o1)
+	orderDao.Create(&o2)
+	orderDao.Create(&o3)
+
+	// filled orders are not counted
+	counts, err := accountService.GetOpenOrderCounts(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, counts.Total)
+	assert.Equal(t, 2, counts.Pairs["ZRX/WETH"])
+
+	limits := &types.OpenOrderLimits{Total: 5, PerPair: 2}
+	counts, err = accountService.SetOpenOrderLimits(addr, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, *limits, counts.Limits)
+
+	acc, _ := accountService.GetByAddress(addr)
+	assert.Equal(t, limits, acc.OpenOrderLimits)
+
+	accountService.SetOpenOrderLimits(addr, nil)
+	acc, _ = accountService.GetByAddress(addr)
+	assert.Nil(t, acc.OpenOrderLimits)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+)
+
+// archiveBatchesPerRun is the number of batches of orders moved by each periodic archival, so that
+// a large backlog is spread over several runs or archived by a backfill
+const archiveBatchesPerRun = 10
+
+// ArchiveService moves the old filled, cancelled and expired orders from the orders collection to
+// the archive collection (see OrderDao.ArchiveOrders), so that the orders collection mostly holds
+// the orders that are still active. The listings of orders include the archived orders.
+type ArchiveService struct {
+	orderDao interfaces.OrderDao
+	age      time.Duration
+
+	mu          sync.Mutex
+	backfilling bool
+}
+
+// NewArchiveService returns a new instance of ArchiveService archiving the orders last updated
+// more than age ago
+func NewArchiveService(orderDao interfaces.OrderDao, age time.Duration) *ArchiveService {
+	return &ArchiveService{orderDao: orderDao, age: age}
+}
+
+// ArchiveOrders starts a background routine that archives up to archiveBatchesPerRun batches of
+// orders at every tick of the given interval
+func (s *ArchiveService) ArchiveOrders(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			n, err := s.orderDao.ArchiveOrders(time.Now().Add(-s.age), archiveBatchesPerRun)
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+
+			if n > 0 {
+				logger.Infof("Archived %v orders", n)
+			}
+		}
+	}()
+}
+
+// StartBackfill starts archiving all the orders old enough to be archived in the background, for
+// the backlog of orders which the periodic archival would take long to go through.
+// ErrArchiveBackfillRunning is returned if a backfill is already running.
+func (s *ArchiveService) StartBackfill() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.backfilling {
+		return ErrArchiveBackfillRunning
+	}
+
+	s.backfilling = true
+	go func() {
+		n, err := s.backfill()
+		if err != nil {
+			logger.Error(err)
+		}
+
+		logger.Infof("Archived %v orders by backfill", n)
+	}()
+
+	return nil
+}
+
+// backfill archives all the orders old enough to be archived. An interrupted backfill is resumed
+// by the next archival.
+func (s *ArchiveService) backfill() (int, error) {
+	defer func() {
+		s.mu.Lock()
+		s.backfilling = false
+		s.mu.Unlock()
+	}()
+
+	return s.orderDao.ArchiveOrders(time.Now().Add(-s.age), 0)
+}
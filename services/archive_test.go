@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestArchiveServiceBackfill(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	s := NewArchiveService(orderDao, 24*time.Hour)
+
+	// the backfill archives every order older than the archive age
+	orderDao.On("ArchiveOrders", mock.MatchedBy(func(before time.Time) bool {
+		return before.Before(time.Now().Add(-23*time.Hour)) && before.After(time.Now().Add(-25*time.Hour))
+	}), 0).Return(42, nil)
+
+	n, err := s.backfill()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 42, n)
+	assert.False(t, s.backfilling)
+	orderDao.AssertExpectations(t)
+
+	// a single backfill runs at a time
+	s.backfilling = true
+	assert.Equal(t, ErrArchiveBackfillRunning, s.StartBackfill())
+}
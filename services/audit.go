@@ -0,0 +1,104 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditService takes signed snapshots of the orderbooks, so that the state of the orderbook of a
+// pair at a point in time can be proven later on. The snapshots are signed by an operator
+// account and stored in the database.
+type AuditService struct {
+	snapshotDao interfaces.AuditSnapshotDao
+	engine      interfaces.Engine
+	signer      types.Signer
+}
+
+// NewAuditService returns a new instance of AuditService signing the snapshots with the given
+// operator account
+func NewAuditService(
+	snapshotDao interfaces.AuditSnapshotDao,
+	engine interfaces.Engine,
+	signer types.Signer,
+) *AuditService {
+	return &AuditService{snapshotDao, engine, signer}
+}
+
+// SnapshotOrderBooks starts a background routine that takes a snapshot of every orderbook at every
+// tick of the given interval (see TakeSnapshots)
+func (s *AuditService) SnapshotOrderBooks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			_, err := s.TakeSnapshots()
+			if err != nil {
+				logger.Error(err)
+			}
+		}
+	}()
+}
+
+// TakeSnapshots takes a snapshot of the resting orders of every orderbook, signs it with the
+// operator account and stores it. The stored snapshots are returned.
+func (s *AuditService) TakeSnapshots() ([]*types.AuditSnapshot, error) {
+	snapshots, err := s.engine.AuditSnapshots()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	for _, snapshot := range snapshots {
+		err := snapshot.Sign(s.signer)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		err = s.snapshotDao.Create(snapshot)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	return snapshots, nil
+}
+
+// GetByID returns the snapshot with the given id, or nil if there is none
+func (s *AuditService) GetByID(id bson.ObjectId) (*types.AuditSnapshot, error) {
+	return s.snapshotDao.GetByID(id)
+}
+
+// GetByPair returns the snapshots of a pair, the most recent first
+func (s *AuditService) GetByPair(bt, qt common.Address, offset, limit int) ([]*types.AuditSnapshot, error) {
+	return s.snapshotDao.GetByPair(bt, qt, offset, limit)
+}
+
+// Verify computes the hash of a stored snapshot again and checks that it was signed by the
+// signer of the snapshot (see types.AuditSnapshot.Verify). ErrAuditSnapshotNotFound is returned
+// if there is no snapshot with the given id and ErrInvalidAuditSnapshot if the snapshot does
+// not verify. The snapshot is returned in both other cases.
+func (s *AuditService) Verify(id bson.ObjectId) (*types.AuditSnapshot, error) {
+	snapshot, err := s.snapshotDao.GetByID(id)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if snapshot == nil {
+		return nil, ErrAuditSnapshotNotFound
+	}
+
+	err = snapshot.Verify()
+	if err != nil {
+		logger.Warning("Invalid audit snapshot", id.Hex(), err)
+		return snapshot, ErrInvalidAuditSnapshot
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func newTestAuditSnapshot() *types.AuditSnapshot {
+	return &types.AuditSnapshot{
+		PairName:   "ZRX/WETH",
+		BaseToken:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken: common.HexToAddress("0xc778417e063141139fce010982780140aa0cd5ab"),
+		Sequence:   7,
+		Timestamp:  time.Unix(1405544146, 0),
+		Orders: []*types.AuditSnapshotOrder{
+			{
+				Hash:            common.HexToHash("0xa9a89346cc62330626c5853b74493a1f8e933db582c444bf2288bd6a211586ee"),
+				Side:            types.OrderSideBuy,
+				PricePoint:      big.NewInt(100),
+				RemainingAmount: big.NewInt(1e18),
+			},
+		},
+	}
+}
+
+func TestTakeAuditSnapshots(t *testing.T) {
+	snapshotDao := new(mocks.AuditSnapshotDao)
+	engine := new(mocks.Engine)
+	operator := types.NewWallet()
+	s := NewAuditService(snapshotDao, engine, operator)
+
+	snapshot := newTestAuditSnapshot()
+	engine.On("AuditSnapshots").Return([]*types.AuditSnapshot{snapshot}, nil)
+	snapshotDao.On("Create", snapshot).Return(nil)
+
+	snapshots, err := s.TakeSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*types.AuditSnapshot{snapshot}, snapshots)
+	assert.Equal(t, operator.Address, snapshot.Signer)
+	assert.Equal(t, snapshot.ComputeHash(), snapshot.Hash)
+	assert.Nil(t, snapshot.Verify())
+	snapshotDao.AssertCalled(t, "Create", snapshot)
+}
+
+func TestVerifyAuditSnapshot(t *testing.T) {
+	snapshotDao := new(mocks.AuditSnapshotDao)
+	s := NewAuditService(snapshotDao, new(mocks.Engine), types.NewWallet())
+
+	valid := newTestAuditSnapshot()
+	valid.ID = bson.NewObjectId()
+	valid.Sign(types.NewWallet())
+
+	tampered := newTestAuditSnapshot()
+	tampered.ID = bson.NewObjectId()
+	tampered.Sign(types.NewWallet())
+	tampered.Orders[0].RemainingAmount = big.NewInt(2e18)
+
+	missing := bson.NewObjectId()
+
+	snapshotDao.On("GetByID", valid.ID).Return(valid, nil)
+	snapshotDao.On("GetByID", tampered.ID).Return(tampered, nil)
+	snapshotDao.On("GetByID", missing).Return(nil, nil)
+
+	res, err := s.Verify(valid.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, valid, res)
+
+	res, err = s.Verify(tampered.ID)
+	assert.Equal(t, ErrInvalidAuditSnapshot, err)
+	assert.Equal(t, tampered, res)
+
+	_, err = s.Verify(missing)
+	assert.Equal(t, ErrAuditSnapshotNotFound, err)
+	snapshotDao.AssertNotCalled(t, "Create", mock.Anything)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ChallengeTTL is the duration during which an authentication challenge can be signed and used
+var ChallengeTTL = 5 * time.Minute
+
+// AuthService authenticates the wallet sending a request. The client requests a challenge,
+// signs it with its wallet (with the "Ethereum Signed Message" prefix) and sends the challenge
+// along with the signature. Challenges expire after ChallengeTTL and can only be used once.
+type AuthService struct {
+	walletDao  interfaces.WalletDao
+	challenges map[common.Hash]time.Time
+	mu         sync.Mutex
+}
+
+// NewAuthService returns a new instance of AuthService
+func NewAuthService(walletDao interfaces.WalletDao) *AuthService {
+	return &AuthService{
+		walletDao:  walletDao,
+		challenges: make(map[common.Hash]time.Time),
+	}
+}
+
+// NewChallenge returns a new random challenge to be signed by the client
+func (s *AuthService) NewChallenge() (common.Hash, error) {
+	b := make([]byte, common.HashLength)
+	_, err := rand.Read(b)
+	if err != nil {
+		logger.Error(err)
+		return common.Hash{}, err
+	}
+
+	challenge := common.BytesToHash(b)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c, expires := range s.challenges {
+		if now.After(expires) {
+			delete(s.challenges, c)
+		}
+	}
+
+	s.challenges[challenge] = now.Add(ChallengeTTL)
+	return challenge, nil
+}
+
+// Authenticate consumes the challenge and returns the wallet that signed it. Addresses that
+// are not registered are returned as watch-only wallets without any role.
+func (s *AuthService) Authenticate(challenge common.Hash, sig *types.Signature) (*types.Wallet, error) {
+	s.mu.Lock()
+	expires, ok := s.challenges[challenge]
+	delete(s.challenges, challenge)
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(expires) {
+		return nil, ErrInvalidChallenge
+	}
+
+	addr, err := sig.Recover(challenge)
+	if err != nil {
+		logger.Error(err)
+		return nil, ErrInvalidAuthSignature
+	}
+
+	w, err := s.walletDao.GetByAddress(addr)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if w == nil {
+		return types.NewWatchOnlyWallet(addr), nil
+	}
+
+	return w, nil
+}
+
+// ParseChallenge decodes a 0x prefixed hex challenge
+func ParseChallenge(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, ErrInvalidChallenge
+	}
+
+	return common.BytesToHash(b), nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ChangeFeedDelay is the time the change feed waits before delivering a change, so that the
+// changes whose update reaches this node through RabbitMQ are delivered by the RabbitMQ path first
+// and not delivered twice
+var ChangeFeedDelay = 500 * time.Millisecond
+
+// deliveryLogSize is the number of order and trade updates remembered by deliveries
+const deliveryLogSize = 100000
+
+// changeFeedCapacity is the number of changes waiting for their delivery
+const changeFeedCapacity = 10000
+
+// deliveries records the order and trade updates delivered to the websocket clients of this node,
+// by RabbitMQ or by the change feed
+var deliveries = newDeliveryLog(deliveryLogSize)
+
+// deliveryLog remembers the last updates delivered by hash and status, the oldest being forgotten
+// once size updates are remembered. It is safe for concurrent use.
+type deliveryLog struct {
+	mu   sync.Mutex
+	keys []string
+	next int
+	seen map[string]bool
+}
+
+// newDeliveryLog returns an empty log remembering up to size updates
+func newDeliveryLog(size int) *deliveryLog {
+	return &deliveryLog{keys: make([]string, size), seen: make(map[string]bool)}
+}
+
+// add records the delivery of the update of an order or trade to the given status, and returns
+// false if it was already recorded
+func (l *deliveryLog) add(hash common.Hash, status string) bool {
+	key := hash.Hex() + "/" + status
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.seen[key] {
+		return false
+	}
+
+	if old := l.keys[l.next]; old != "" {
+		delete(l.seen, old)
+	}
+
+	l.keys[l.next] = key
+	l.next = (l.next + 1) % len(l.keys)
+	l.seen[key] = true
+	return true
+}
+
+// orderChangeMessages are the messages of the order updates delivered by the change feed, by
+// status. The updates of the other statuses are delivered as ORDER_UPDATED messages.
+var orderChangeMessages = map[string]string{
+	types.OrderStatusOpen:      "ORDER_ADDED",
+	types.OrderStatusCancelled: "ORDER_CANCELLED",
+	types.OrderStatusExpired:   "ORDER_EXPIRED",
+}
+
+// tradeChangeMessages are the messages sent to the maker and taker of the trades updated to each
+// status, as in HandleOperatorMessages
+var tradeChangeMessages = map[string]string{
+	types.TradeStatusPending: "ORDER_PENDING",
+	types.TradeStatusSuccess: "ORDER_SUCCESS",
+	types.TradeStatusFailed:  "ORDER_ERROR",
+}
+
+// pendingChange is a change waiting for its delivery
+type pendingChange struct {
+	event *types.ChangeEvent
+	due   time.Time
+}
+
+// ChangeFeedService delivers to the websocket clients of this node the order and trade updates
+// written by any process, as observed in the database, when the engine and the API run as
+// separate processes. Each update is delivered once by hash and status, whether by the change
+// feed or by the RabbitMQ path (see OrderService.HandleEngineResponse).
+type ChangeFeedService struct {
+	watcher interfaces.ChangeWatcher
+	pending chan pendingChange
+}
+
+// NewChangeFeedService returns a new instance of ChangeFeedService delivering the changes
+// observed by watcher
+func NewChangeFeedService(watcher interfaces.ChangeWatcher) *ChangeFeedService {
+	return &ChangeFeedService{watcher, make(chan pendingChange, changeFeedCapacity)}
+}
+
+// Start starts background routines watching the changes and delivering them ChangeFeedDelay
+// after they were observed, in the order in which they were observed. The change feed is
+// disabled, which is logged, if the changes can not be observed, eg. because the deployment
+// does not support it.
+func (s *ChangeFeedService) Start() {
+	go func() {
+		for p := range s.pending {
+			time.Sleep(time.Until(p.due))
+			s.deliver(p.event)
+		}
+	}()
+
+	go func() {
+		err := s.watcher.Watch(s.HandleChange)
+		if err != nil {
+			logger.Warning("Change feed disabled: ", err)
+		}
+	}()
+}
+
+// HandleChange queues a change for its delivery
+func (s *ChangeFeedService) HandleChange(e *types.ChangeEvent) {
+	s.pending <- pendingChange{e, time.Now().Add(ChangeFeedDelay)}
+}
+
+// deliver sends a change to the websocket clients of this node, unless the update was already
+// delivered. Order updates are sent on the connection of the order, trades are broadcast on the
+// trade channel of their pair when they are inserted and sent on the connections of their orders
+// when their settlement status changes. Returns true if the change was delivered.
+func (s *ChangeFeedService) deliver(e *types.ChangeEvent) bool {
+	switch {
+	case e.Order != nil:
+		o := e.Order
+		if !deliveries.add(o.Hash, o.Status) {
+			return false
+		}
+
+		msgType, ok := orderChangeMessages[o.Status]
+		if !ok {
+			msgType = "ORDER_UPDATED"
+		}
+
+		ws.SendOrderMessage(msgType, o.Hash, o.ToPrivateAPI())
+		return true
+	case e.Trade != nil:
+		t := e.Trade
+		if !deliveries.add(t.Hash, t.Status) {
+			return false
+		}
+
+		if e.Operation == types.ChangeInsert {
+			id := utils.GetTradeChannelID(t.BaseToken, t.QuoteToken)
+			ws.GetTradeSocket().BroadcastMessage(id, []*types.Trade{t})
+			return true
+		}
+
+		if msgType, ok := tradeChangeMessages[t.Status]; ok {
+			ws.SendOrderMessage(msgType, t.OrderHash, t)
+			ws.SendOrderMessage(msgType, t.TakerOrderHash, t)
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+// markEngineResponseDelivered records the delivery of the orders and trades of an engine response
+// received through RabbitMQ
+func markEngineResponseDelivered(res *types.EngineResponse) {
+	if res.Order != nil {
+		deliveries.add(res.Order.Hash, res.Order.Status)
+	}
+
+	for _, m := range res.Matches {
+		if m.Order != nil {
+			deliveries.add(m.Order.Hash, m.Order.Status)
+		}
+
+		if m.Trade != nil {
+			deliveries.add(m.Trade.Hash, m.Trade.Status)
+		}
+	}
+}
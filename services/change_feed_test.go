@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeliveryLog(t *testing.T) {
+	l := newDeliveryLog(2)
+	a, b, c := common.HexToHash("0xa"), common.HexToHash("0xb"), common.HexToHash("0xc")
+
+	assert.True(t, l.add(a, "OPEN"))
+	assert.False(t, l.add(a, "OPEN"))
+
+	// the statuses of a hash are delivered separately
+	assert.True(t, l.add(a, "FILLED"))
+
+	// the oldest updates are forgotten
+	assert.True(t, l.add(b, "OPEN"))
+	assert.True(t, l.add(a, "OPEN"))
+	assert.False(t, l.add(b, "OPEN"))
+	assert.True(t, l.add(c, "OPEN"))
+	assert.True(t, l.add(b, "OPEN"))
+}
+
+func TestChangeFeedDeliver(t *testing.T) {
+	deliveries = newDeliveryLog(100)
+	s := NewChangeFeedService(new(mocks.ChangeWatcher))
+
+	o := &types.Order{Hash: common.HexToHash("0x1"), Status: types.OrderStatusOpen}
+	tr := &types.Trade{
+		Hash:           common.HexToHash("0x2"),
+		OrderHash:      common.HexToHash("0x3"),
+		TakerOrderHash: o.Hash,
+		Status:         types.TradeStatusPending,
+	}
+
+	// the updates received through RabbitMQ are not delivered again
+	markEngineResponseDelivered(&types.EngineResponse{
+		Status:  "FULL",
+		Order:   o,
+		Matches: []*types.OrderTradePair{{Order: &types.Order{Hash: tr.OrderHash, Status: types.OrderStatusFilled}, Trade: tr}},
+	})
+
+	assert.False(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeInsert, Order: o}))
+	assert.False(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeInsert, Trade: tr}))
+
+	// the updates written by other processes are delivered once
+	filled := &types.Order{Hash: o.Hash, Status: types.OrderStatusFilled}
+	assert.True(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeUpdate, Order: filled}))
+	assert.False(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeUpdate, Order: filled}))
+
+	settled := &types.Trade{Hash: tr.Hash, OrderHash: tr.OrderHash, TakerOrderHash: tr.TakerOrderHash, Status: types.TradeStatusSuccess}
+	assert.True(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeUpdate, Trade: settled}))
+	assert.False(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeUpdate, Trade: settled}))
+
+	assert.False(t, s.deliver(&types.ChangeEvent{Operation: types.ChangeUpdate}))
+}
+
+func TestChangeFeedStart(t *testing.T) {
+	deliveries = newDeliveryLog(100)
+	delay := ChangeFeedDelay
+	ChangeFeedDelay = 0
+	defer func() { ChangeFeedDelay = delay }()
+
+	o := &types.Order{Hash: common.HexToHash("0x1"), Status: types.OrderStatusCancelled}
+	done := make(chan struct{})
+
+	// the feed is disabled once the changes can not be observed anymore
+	watcher := new(mocks.ChangeWatcher)
+	watcher.On("Watch", mock.Anything).Return(errors.New("Changes can not be observed")).Run(func(args mock.Arguments) {
+		args.Get(0).(func(*types.ChangeEvent))(&types.ChangeEvent{Operation: types.ChangeUpdate, Order: o})
+		close(done)
+	})
+
+	NewChangeFeedService(watcher).Start()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The changes were not watched")
+	}
+
+	// the observed changes are delivered in the background
+	deadline := time.Now().Add(time.Second)
+	for !delivered(o.Hash, o.Status) {
+		if time.Now().After(deadline) {
+			t.Fatal("The change was not delivered")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	watcher.AssertExpectations(t)
+}
+
+// delivered returns true if the update of a hash to a status was recorded by deliveries
+func delivered(hash common.Hash, status string) bool {
+	deliveries.mu.Lock()
+	defer deliveries.mu.Unlock()
+	return deliveries.seen[hash.Hex()+"/"+status]
+}
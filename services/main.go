@@ -10,10 +10,46 @@ var logger = utils.Logger
 
 var ErrPairExists = errors.New("Pairs already exists")
 var ErrPairNotFound = errors.New("Pair not found")
+var ErrPairInactive = errors.New("Pair is not active")
+var ErrPairPaused = errors.New("Pair is paused")
+var ErrInvalidPriceGrouping = errors.New("Price grouping is finer than the pair price precision")
 var ErrBaseTokenNotFound = errors.New("BaseToken not found")
 var ErrQuoteTokenNotFound = errors.New("QuoteToken not found")
 var ErrQuoteTokenInvalid = errors.New("Quote Token Invalid (not a quote)")
 var ErrTokenExists = errors.New("Token already exists")
+var ErrTokenMetadataMismatch = errors.New("Token symbol or decimals do not match the token contract")
+var ErrNotERC20Token = errors.New("Token contract does not implement ERC-20")
+
+var ErrTokenListingExists = errors.New("A listing request for this token is already pending")
+var ErrTokenListingNotFound = errors.New("Token listing request not found")
+var ErrTokenListingReviewed = errors.New("Token listing request has already been reviewed")
+var ErrTokenListingReasonRequired = errors.New("A reason is required to reject a token listing request")
+var ErrInvalidTokenListingStatus = errors.New("Invalid token listing status")
+var ErrNotAdmin = errors.New("Review is not signed by an admin wallet")
+
+var ErrAuditSnapshotNotFound = errors.New("Audit snapshot not found")
+var ErrInvalidAuditSnapshot = errors.New("Audit snapshot hash or signature is invalid")
+
+var ErrArchiveBackfillRunning = errors.New("Order archive backfill is already running")
+
+var ErrInvalidChallenge = errors.New("Authentication challenge is invalid or has expired")
+var ErrInvalidAuthSignature = errors.New("Authentication signature is invalid")
+
+var ErrOrderNonceConsumed = errors.New("Order nonce has already been used")
+var ErrOrderExists = errors.New("Order has already been received")
+var ErrOpenOrdersLimit = errors.New("Maximum number of open orders reached")
+var ErrPairOpenOrdersLimit = errors.New("Maximum number of open orders on this pair reached")
+
+var ErrReduceBelowMinAmount = errors.New("New amount is below the pair minimum amount")
+
+var ErrBatchEmpty = errors.New("Batch has no orders")
+var ErrBatchTooLarge = errors.New("Batch has too many orders")
+
+var ErrCancelAllExpired = errors.New("Cancel all orders message timestamp is too old")
+var ErrCancelAllProcessed = errors.New("Cancel all orders message has already been processed")
+
+var ErrSessionAuthExpired = errors.New("Session authentication message timestamp is too old")
+var ErrSessionAuthProcessed = errors.New("Session authentication message has already been processed")
 
 var ErrAccountNotFound = errors.New("Account not found")
 var ErrAccountExists = errors.New("Account already Exists")
@@ -3,16 +3,16 @@ package services
 import (
 	"encoding/json"
 	"fmt"
-	"math"
 	"math/big"
 	"sort"
-	"strconv"
 	"testing"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/daos"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/memdaos"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
@@ -24,16 +24,16 @@ type TickSorter []*types.Tick
 
 func (a TickSorter) Len() int           { return len(a) }
 func (a TickSorter) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a TickSorter) Less(i, j int) bool { return a[i].Ts < a[j].Ts }
+func (a TickSorter) Less(i, j int) bool { return a[i].Timestamp < a[j].Timestamp }
 
 var durations = map[string][]int64{
 	"year":  {1},
 	"month": {1, 3, 6, 9},
-	"week":  {1},
-	"day":   {1},
-	"hour":  {1, 6, 12},
-	"min":   {1, 5, 15, 30},
-	"sec":   {15, 30},
+	"week":  {1, 2},
+	"day":   {1, 3},
+	"hour":  {1, 6, 12, 5},
+	"min":   {1, 5, 15, 30, 7, 90},
+	"sec":   {15, 30, 45},
 }
 var testTimes = []string{
 	"Dec 17 2017 00:00:00",
@@ -79,6 +79,8 @@ var testTimes = []string{
 var durationMap = make(map[string]map[int64]*types.Tick)
 
 func TestOHLCV(t *testing.T) {
+	setupMongo()
+
 	pair := types.PairSubDoc{
 		Name:       "HPC/AUT",
 		BaseToken:  common.HexToAddress("0x2034842261b82651885751fc293bba7ba5398156"),
@@ -101,7 +103,7 @@ func TestOHLCV(t *testing.T) {
 	}
 	app.Config.DBName = "proofdex"
 	tradeDao := daos.NewTradeDao()
-	ohlcvService := NewOHLCVService(tradeDao)
+	ohlcvService := NewOHLCVService(tradeDao, daos.NewPairDao())
 
 	for _, t := range testTimes {
 		tTime, err := time.Parse(timeLayoutString, t)
@@ -143,45 +145,10 @@ func TestOHLCV(t *testing.T) {
 }
 
 func updateExpectedResponse(trade *types.Trade) error {
-
-	tradeTs := trade.CreatedAt.Unix()
 	for unit, durationSlice := range durations {
 		for _, duration := range durationSlice {
-			var ts int64
-			switch unit {
-			case "sec":
-				intervalSeconds := duration
-				ts = tradeTs - int64(math.Mod(float64(tradeTs), float64(intervalSeconds)))
-
-			case "hour":
-				intervalSeconds := duration * 60 * 60
-				ts = tradeTs - int64(math.Mod(float64(tradeTs), float64(intervalSeconds)))
-
-			case "day":
-				intervalSeconds := duration * 24 * 60 * 60
-				ts = tradeTs - int64(math.Mod(float64(tradeTs), float64(intervalSeconds)))
-
-			case "week":
-				yr, wk := trade.CreatedAt.ISOWeek()
-				ts = firstDayOfISOWeek(yr, wk, time.UTC).Unix()
-
-			case "month":
-				mnth, _ := strconv.ParseInt(fmt.Sprintf("%d", trade.CreatedAt.Month()), 10, 64)
-				ts = time.Date(trade.CreatedAt.Year(), time.Month((math.Ceil(float64(mnth)/float64(duration))*float64(duration))-float64(duration-1)), 1, 0, 0, 0, 0, time.UTC).Unix()
-
-			case "year":
-				ts = time.Date(trade.CreatedAt.Year(), 1, 1, 0, 0, 0, 0, time.UTC).Unix()
-
-			case "min":
-				intervalSeconds := duration * 60
-				ts = tradeTs - int64(math.Mod(float64(tradeTs), float64(intervalSeconds)))
-
-			default:
-				panic("Invalid unit: " + unit)
-			}
-
+			ts := types.TickStart(trade.CreatedAt, duration, unit).Unix()
 			addTick(unit, duration, ts, trade)
-
 		}
 	}
 	return nil
@@ -192,37 +159,39 @@ func addTick(unit string, duration int64, ts int64, trade *types.Trade) {
 	if durationMap[key] == nil {
 		durationMap[key] = make(map[int64]*types.Tick)
 	}
-	durationMap[key][ts] = tradeToTick(trade, durationMap[key][ts], ts)
+	durationMap[key][ts] = tradeToTick(trade, durationMap[key][ts], ts, duration, unit)
 }
 
-func tradeToTick(trade *types.Trade, tick *types.Tick, ts int64) *types.Tick {
+func tradeToTick(trade *types.Trade, tick *types.Tick, ts, duration int64, unit string) *types.Tick {
 	if tick == nil {
 		tick = &types.Tick{
-			ID: types.TickID{
-				Pair:       trade.PairName,
+			Pair: types.PairID{
+				PairName:   trade.PairName,
 				BaseToken:  trade.BaseToken,
 				QuoteToken: trade.QuoteToken,
 			},
-			O:     trade.PricePoint,
-			H:     trade.PricePoint,
-			L:     trade.PricePoint,
-			C:     trade.PricePoint,
-			V:     trade.Amount,
-			Count: big.NewInt(1),
-			Ts:    ts * 1000,
+			Open:      trade.PricePoint,
+			High:      trade.PricePoint,
+			Low:       trade.PricePoint,
+			Close:     trade.PricePoint,
+			Volume:    trade.Amount,
+			Count:     big.NewInt(1),
+			Timestamp: ts * 1000,
+			Duration:  duration,
+			Unit:      unit,
 		}
 	} else {
-		tick.C = trade.PricePoint
+		tick.Close = trade.PricePoint
 		tv := new(big.Int)
-		tv.Add(tick.V, trade.Amount)
-		tick.V = tv
+		tv.Add(tick.Volume, trade.Amount)
+		tick.Volume = tv
 
 		tick.Count.Add(tick.Count, big.NewInt(1))
-		if trade.PricePoint.Cmp(tick.H) == 1 {
-			tick.H = trade.PricePoint
+		if trade.PricePoint.Cmp(tick.High) == 1 {
+			tick.High = trade.PricePoint
 		}
-		if trade.PricePoint.Cmp(tick.L) == -1 {
-			tick.L = trade.PricePoint
+		if trade.PricePoint.Cmp(tick.Low) == -1 {
+			tick.Low = trade.PricePoint
 		}
 	}
 	return tick
@@ -242,20 +211,145 @@ func getExpectedResponse(unit string, duration int64) (ticks []*types.Tick) {
 	return
 }
 
-func firstDayOfISOWeek(year int, week int, timezone *time.Location) time.Time {
-	date := time.Date(year, 0, 0, 0, 0, 0, 0, timezone)
-	isoYear, isoWeek := date.ISOWeek()
-	for date.Weekday() != time.Monday { // iterate back to Monday
-		date = date.AddDate(0, 0, -1)
-		isoYear, isoWeek = date.ISOWeek()
+// Intervals without trades are omitted rather than returned as flat ticks
+func TestOHLCVOmitsEmptyIntervals(t *testing.T) {
+	setupMongo()
+
+	pair := types.PairSubDoc{
+		Name:       "ZRX/WETH",
+		BaseToken:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken: common.HexToAddress("0x1888a8db0b7db59413ce07150b3373972bf818d3"),
+	}
+
+	app.Config.DBName = "proofdex"
+	tradeDao := daos.NewTradeDao()
+	ohlcvService := NewOHLCVService(tradeDao, daos.NewPairDao())
+
+	times := []string{"Sep 3 2018 09:15:00", "Sep 3 2018 09:45:00", "Sep 3 2018 13:05:00"}
+	for i, ts := range times {
+		createdAt, err := time.Parse(timeLayoutString, ts)
+		if err != nil {
+			panic("invalid date: " + err.Error())
+		}
+
+		trade := types.Trade{
+			ID:         bson.NewObjectId(),
+			Taker:      common.HexToAddress("0xefD7eB287CeeFCE8256Dd46e25F398acEA7C4b63"),
+			Maker:      common.HexToAddress("0xefD7eB287CeeFCE8256Dd46e25F398acEA7C4b58"),
+			BaseToken:  pair.BaseToken,
+			QuoteToken: pair.QuoteToken,
+			PairName:   pair.Name,
+			TradeNonce: big.NewInt(int64(i)),
+			Signature:  &types.Signature{},
+			Side:       "BUY",
+			PricePoint: big.NewInt(int64(100 + i)),
+			Amount:     big.NewInt(10),
+			CreatedAt:  createdAt,
+		}
+
+		trade.Hash = trade.ComputeHash()
+		if err := db.DB(app.Config.DBName).C("trades").Insert(&trade); err != nil {
+			panic(err)
+		}
+	}
+
+	from, _ := time.Parse(timeLayoutString, "Sep 3 2018 09:00:00")
+	to, _ := time.Parse(timeLayoutString, "Sep 3 2018 14:00:00")
+
+	ticks, err := ohlcvService.GetOHLCV([]types.PairSubDoc{pair}, 1, "hour", from.Unix(), to.Unix())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if assert.Equal(t, 2, len(ticks)) {
+		assert.Equal(t, from.Unix()*1000, ticks[0].Timestamp)
+		assert.Equal(t, big.NewInt(2), ticks[0].Count)
+		assert.Equal(t, big.NewInt(100), ticks[0].Open)
+		assert.Equal(t, big.NewInt(101), ticks[0].Close)
+		assert.Equal(t, from.Add(4*time.Hour).Unix()*1000, ticks[1].Timestamp)
+		assert.Equal(t, big.NewInt(1), ticks[1].Count)
+	}
+}
+
+// The quote volume is converted with the decimals of the pair, here WETH (18 decimals) quoted in
+// USDC (6 decimals)
+func TestOHLCVQuoteVolume(t *testing.T) {
+	setupMongo()
+
+	app.Config.DBName = "proofdex"
+
+	pair := &types.Pair{
+		BaseTokenSymbol:   "WETH",
+		BaseTokenAddress:  common.HexToAddress("0x2956356cd2a2bf3202f771f50d3d14a367b48070"),
+		BaseTokenDecimal:  18,
+		QuoteTokenSymbol:  "USDC",
+		QuoteTokenAddress: common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+		QuoteTokenDecimal: 6,
+		PriceMultiplier:   big.NewInt(1e6),
+		PriceDecimals:     2,
+		MakeFee:           big.NewInt(0),
+		TakeFee:           big.NewInt(0),
+		Active:            true,
+	}
+
+	err := daos.NewPairDao().Create(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createdAt, _ := time.Parse(timeLayoutString, "Oct 1 2018 10:00:00")
+
+	// 1.5 WETH at 2000 USDC and 0.5 WETH at 2001 USDC
+	trades := []struct{ amount, pricePoint string }{
+		{"1500000000000000000", "2000000000"},
+		{"500000000000000000", "2001000000"},
+	}
+
+	for i, tr := range trades {
+		trade := types.Trade{
+			ID:         bson.NewObjectId(),
+			Taker:      common.HexToAddress("0xefD7eB287CeeFCE8256Dd46e25F398acEA7C4b63"),
+			Maker:      common.HexToAddress("0xefD7eB287CeeFCE8256Dd46e25F398acEA7C4b58"),
+			BaseToken:  pair.BaseTokenAddress,
+			QuoteToken: pair.QuoteTokenAddress,
+			PairName:   pair.Name(),
+			TradeNonce: big.NewInt(int64(i)),
+			Signature:  &types.Signature{},
+			Side:       "BUY",
+			PricePoint: math.ToBigInt(tr.pricePoint),
+			Amount:     math.ToBigInt(tr.amount),
+			CreatedAt:  createdAt.Add(time.Duration(i) * time.Minute),
+		}
+
+		trade.Hash = trade.ComputeHash()
+		if err := db.DB(app.Config.DBName).C("trades").Insert(&trade); err != nil {
+			t.Fatal(err)
+		}
 	}
-	for isoYear < year { // iterate forward to the first day of the first week
-		date = date.AddDate(0, 0, 1)
-		isoYear, isoWeek = date.ISOWeek()
+
+	ohlcvService := NewOHLCVService(daos.NewTradeDao(), daos.NewPairDao())
+	sub := types.PairSubDoc{Name: pair.Name(), BaseToken: pair.BaseTokenAddress, QuoteToken: pair.QuoteTokenAddress}
+
+	ticks, err := ohlcvService.GetOHLCV([]types.PairSubDoc{sub}, 1, "hour", createdAt.Unix(), createdAt.Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatal(err)
 	}
-	for isoWeek < week { // iterate forward to the first day of the given week
-		date = date.AddDate(0, 0, 1)
-		isoYear, isoWeek = date.ISOWeek()
+
+	if assert.Equal(t, 1, len(ticks)) {
+		assert.Equal(t, math.ToBigInt("2000000000000000000"), ticks[0].Volume)
+		assert.Equal(t, big.NewInt(4000500000), ticks[0].QuoteVolume)
+		assert.Equal(t, big.NewInt(2000000000), ticks[0].Open)
+		assert.Equal(t, big.NewInt(2001000000), ticks[0].High)
 	}
-	return date
+}
+
+func TestGetOHLCVInvalidInterval(t *testing.T) {
+	ohlcvService := NewOHLCVService(memdaos.NewTradeDao(), memdaos.NewPairDao())
+
+	_, err := ohlcvService.GetOHLCV([]types.PairSubDoc{}, 0, "hour")
+	assert.Error(t, err)
+
+	_, err = ohlcvService.GetOHLCV([]types.PairSubDoc{}, 1, "yr")
+	assert.Error(t, err)
 }
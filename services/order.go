@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
@@ -28,9 +29,31 @@ type OrderService struct {
 	pairDao          interfaces.PairDao
 	accountDao       interfaces.AccountDao
 	tradeDao         interfaces.TradeDao
+	matchDao         interfaces.MatchDao
+	orderCancelDao   interfaces.OrderCancelDao
+	balanceChangeDao interfaces.BalanceChangeDao
 	engine           interfaces.Engine
 	ethereumProvider interfaces.EthereumProvider
 	broker           *rabbitmq.Connection
+
+	// cancelAllHashes holds the hashes of the processed cancel all orders messages
+	// until they are too old to be accepted
+	cancelAllHashes map[common.Hash]time.Time
+	cancelAllMutex  sync.Mutex
+}
+
+// CancelAllWindow is the maximum difference between the timestamp of a cancel all orders
+// message and the server time. It is set from the cancel_all_window configuration.
+var CancelAllWindow = 30 * time.Second
+
+// MaxBatchOrders is the maximum number of orders of a batch (see NewOrders). It is set from the
+// max_batch_orders configuration.
+var MaxBatchOrders = 20
+
+// rejectReasonMessages are the human readable messages sent along with the engine rejection reasons
+var rejectReasonMessages = map[string]string{
+	types.RejectReasonPostOnly:   "Post-only order would be matched by the orderbook",
+	types.RejectReasonEngineBusy: "Engine is busy, the order can be submitted again later",
 }
 
 // NewOrderService returns a new instance of orderservice
@@ -39,239 +62,1005 @@ func NewOrderService(
 	pairDao interfaces.PairDao,
 	accountDao interfaces.AccountDao,
 	tradeDao interfaces.TradeDao,
+	matchDao interfaces.MatchDao,
+	orderCancelDao interfaces.OrderCancelDao,
+	balanceChangeDao interfaces.BalanceChangeDao,
 	engine interfaces.Engine,
 	ethereumProvider interfaces.EthereumProvider,
 	broker *rabbitmq.Connection,
 ) *OrderService {
 	return &OrderService{
-		orderDao,
-		pairDao,
-		accountDao,
-		tradeDao,
-		engine,
-		ethereumProvider,
-		broker,
+		orderDao:         orderDao,
+		pairDao:          pairDao,
+		accountDao:       accountDao,
+		tradeDao:         tradeDao,
+		matchDao:         matchDao,
+		orderCancelDao:   orderCancelDao,
+		balanceChangeDao: balanceChangeDao,
+		engine:           engine,
+		ethereumProvider: ethereumProvider,
+		broker:           broker,
+		cancelAllHashes:  make(map[common.Hash]time.Time),
+	}
+}
+
+// GetByID fetches the details of an order using order's mongo ID
+func (s *OrderService) GetByID(id bson.ObjectId) (*types.Order, error) {
+	return s.orderDao.GetByID(id)
+}
+
+// GetByUserAddress fetches a page of the orders placed by passed user address matching a query,
+// along with the cursor of the next page
+func (s *OrderService) GetByUserAddress(addr common.Address, q types.OrderQuery) ([]*types.Order, string, error) {
+	return s.orderDao.GetByUserAddress(addr, q)
+}
+
+// GetByHash fetches all trades corresponding to a trade hash
+func (s *OrderService) GetByHash(hash common.Hash) (*types.Order, error) {
+	return s.orderDao.GetByHash(hash)
+}
+
+// GetCurrentByUserAddress function fetches a page of the open/partial orders of an account.
+// Returns the orders and the cursor of the next page
+func (s *OrderService) GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	return s.orderDao.GetCurrentByUserAddress(addr, cursor, limit)
+}
+
+// GetHistoryByUserAddress function fetches a page of the orders of an account which are not in
+// open/partial order status.
+// Returns the orders and the cursor of the next page
+func (s *OrderService) GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	return s.orderDao.GetHistoryByUserAddress(addr, cursor, limit)
+}
+
+// NewOrder validates if the passed order is valid or not based on user's available
+// funds and order data.
+// If valid: Order is inserted in DB with order status as new and order is publiched
+// on rabbitmq queue for matching engine to process the order
+// If the order was already received, it is replaced with its current state and ErrOrderExists
+// is returned
+func (s *OrderService) NewOrder(o *types.Order) error {
+	err := s.validateOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	// orders are only placed once so that clients can retry them, the order being replaced with its
+	// current state when it was already received
+	err = s.loadExistingOrder(o)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkOpenOrderLimits(o)
+	if err != nil {
+		return err
+	}
+
+	// the balance of stop orders is only locked once they are triggered
+	if o.IsStop() {
+		o.Status = types.OrderStatusStop
+	} else {
+		err = s.lockOrderBalance(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	// orders with a nonce lower or equal to the last nonce of the maker are rejected to prevent
+	// replays. The nonce is only consumed once the balance of the order is locked, so that the
+	// orders rejected for their balance can be sent again.
+	ok, err := s.accountDao.ConsumeOrderNonce(o.UserAddress, o.Nonce)
+	if err != nil || !ok {
+		if !o.IsStop() {
+			s.unlockOrderBalance(o)
+		}
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if !ok {
+		// the nonce may have been consumed by the same order received by another node
+		err = s.loadExistingOrder(o)
+		if err != nil {
+			return err
+		}
+
+		return ErrOrderNonceConsumed
+	}
+
+	if err = s.orderDao.Create(o); err != nil {
+		logger.Error(err)
+		if !o.IsStop() {
+			s.unlockOrderBalance(o)
+		}
+
+		// the unique index on the order hashes rejects the orders that were received concurrently,
+		// which are acknowledged like retried orders
+		if err == types.ErrOrderAlreadyExists {
+			if err := s.loadExistingOrder(o); err != nil {
+				return err
+			}
+		}
+
+		return err
+	}
+
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	s.broker.PublishOrder(&rabbitmq.Message{Type: "NEW_ORDER", HashID: o.Hash, Data: bytes})
+	return nil
+}
+
+// loadExistingOrder replaces a new order with the order of the same hash that was already
+// received, if any, in which case ErrOrderExists is returned
+func (s *OrderService) loadExistingOrder(o *types.Order) error {
+	existing, err := s.orderDao.GetByHash(o.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if existing == nil {
+		return nil
+	}
+
+	*o = *existing
+	return ErrOrderExists
+}
+
+// NewOrders handles batches of new orders, eg. the quotes of a ladder. All the orders are checked
+// before any of them is sent to the engine: the whole batch is rejected with the errors of each
+// invalid order, the balances locked for the batch being released. The nonces of the orders of a
+// maker must increase in the order of the batch. The orders of each pair are then matched
+// back-to-back by the engine (see Engine.NewOrders) and their engine responses returned in the
+// order of the batch, the usual messages of each order being sent on its connection.
+func (s *OrderService) NewOrders(orders []*types.Order) ([]*types.EngineResponse, error) {
+	if len(orders) == 0 {
+		return nil, ErrBatchEmpty
+	}
+
+	if len(orders) > MaxBatchOrders {
+		return nil, ErrBatchTooLarge
+	}
+
+	errs := types.ValidationErrors{}
+	addError := func(i int, err error) {
+		field := fmt.Sprintf("orders[%v]", i)
+		if fieldErrs, ok := err.(types.ValidationErrors); ok {
+			for _, e := range fieldErrs {
+				errs.Add(field+"."+e.Field, e.Reason)
+			}
+
+			return
+		}
+
+		errs.Add(field, err.Error())
+	}
+
+	hashes := map[common.Hash]bool{}
+	for i, o := range orders {
+		if hashes[o.Hash] {
+			addError(i, errors.New("Duplicate order"))
+			continue
+		}
+
+		hashes[o.Hash] = true
+		err := s.validateOrder(o)
+		if err != nil {
+			logger.Error(err)
+			addError(i, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// the limits and nonces are checked against the orders of the batch preceding each order
+	counts := map[common.Address]*types.OpenOrderCounts{}
+	nonces := map[common.Address]*big.Int{}
+	for i, o := range orders {
+		c := counts[o.UserAddress]
+		if c == nil {
+			acc, err := s.accountDao.GetByAddress(o.UserAddress)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			if acc == nil {
+				return nil, ErrAccountNotFound
+			}
+
+			c, err = countOpenOrders(s.orderDao, acc)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			if c.Pairs == nil {
+				c.Pairs = map[string]int{}
+			}
+
+			nonces[o.UserAddress], err = s.accountDao.GetOrderNonce(o.UserAddress)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			counts[o.UserAddress] = c
+		}
+
+		err := checkOpenOrderCounts(c.Limits, c, o.PairName)
+		if err != nil {
+			addError(i, err)
+		}
+
+		c.Total++
+		c.Pairs[o.PairName]++
+
+		last := nonces[o.UserAddress]
+		if last != nil && o.Nonce.Cmp(last) <= 0 {
+			addError(i, ErrOrderNonceConsumed)
+		}
+
+		nonces[o.UserAddress] = o.Nonce
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// the balances of the whole batch are locked before the orders are sent to the engine
+	locked := []*types.Order{}
+	release := func() {
+		for _, o := range locked {
+			s.unlockOrderBalance(o)
+		}
+	}
+
+	for i, o := range orders {
+		// the balance of stop orders is only locked once they are triggered
+		if o.IsStop() {
+			o.Status = types.OrderStatusStop
+			continue
+		}
+
+		err := s.lockOrderBalance(o)
+		if err != nil {
+			logger.Error(err)
+			release()
+			addError(i, err)
+			return nil, errs
+		}
+
+		locked = append(locked, o)
+	}
+
+	// the nonces of the batch are increasing, consuming the last nonce of each maker consumes them
+	// all unless another order of the maker was accepted since they were checked
+	for addr, nonce := range nonces {
+		ok, err := s.accountDao.ConsumeOrderNonce(addr, nonce)
+		if err != nil {
+			logger.Error(err)
+			release()
+			return nil, err
+		}
+
+		if !ok {
+			release()
+			return nil, ErrOrderNonceConsumed
+		}
+	}
+
+	for i, o := range orders {
+		err := s.orderDao.Create(o)
+		if err != nil {
+			logger.Error(err)
+			release()
+			for _, created := range orders[:i] {
+				s.cancelBatchOrder(created)
+			}
+
+			return nil, err
+		}
+	}
+
+	responses, err := s.engine.NewOrders(orders)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	// the orders of the pairs that could not be run by the engine are cancelled, their balances
+	// being released
+	for i, o := range orders {
+		if responses == nil || responses[i] == nil {
+			if !o.IsStop() {
+				s.unlockOrderBalance(o)
+			}
+
+			s.cancelBatchOrder(o)
+		}
+	}
+
+	return responses, err
+}
+
+// SimulateOrder previews the execution of an order against the current orderbook of its pair,
+// without the order being signed, stored or sent to the engine (see Engine.SimulateOrder). The
+// order is described like a new order by its tokens and amounts while its maker, nonce, expiry and
+// signature are optional. Orders without exchange address are previewed for the primary exchange
+// contract and orders without fees with the pair minimum fees.
+func (s *OrderService) SimulateOrder(o *types.Order) (*types.MatchPreview, error) {
+	errs := types.ValidationErrors{}
+	if o.ExchangeAddress == (common.Address{}) {
+		o.ExchangeAddress = app.Config.PrimaryExchangeAddress()
+	} else if !app.Config.IsAllowedExchangeAddress(o.ExchangeAddress) {
+		errs.Add("exchangeAddress", "Incorrect exchange address")
+	}
+
+	if o.BuyToken == (common.Address{}) {
+		errs.Add("buyToken", "Buy token address is missing")
+	}
+
+	if o.SellToken == (common.Address{}) {
+		errs.Add("sellToken", "Sell token address is missing")
+	} else if o.SellToken == o.BuyToken {
+		errs.Add("sellToken", "Buy and sell tokens should be different")
+	}
+
+	if o.BuyAmount == nil || o.BuyAmount.Sign() <= 0 {
+		errs.Add("buyAmount", "Buy amount should be positive")
+	}
+
+	if o.SellAmount == nil || o.SellAmount.Sign() <= 0 {
+		errs.Add("sellAmount", "Sell amount should be positive")
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	p, err := s.pairDao.GetByBuySellTokenAddress(o.BuyToken, o.SellToken)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	if !p.Active {
+		return nil, ErrPairInactive
+	}
+
+	if p.Paused {
+		return nil, ErrPairPaused
+	}
+
+	err = o.Process(p)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	fees := p.FeeSchedule()
+	if o.MakeFee == nil {
+		o.MakeFee = big.NewInt(0)
+		if fees.MinMakeFee != nil {
+			o.MakeFee = new(big.Int).Set(fees.MinMakeFee)
+		}
+	}
+
+	if o.TakeFee == nil {
+		o.TakeFee = big.NewInt(0)
+		if fees.MinTakeFee != nil {
+			o.TakeFee = new(big.Int).Set(fees.MinTakeFee)
+		}
+	}
+
+	err = p.ValidateOrder(o)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.ValidateNotional(o)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.IsStop() {
+		o.Status = types.OrderStatusStop
+	}
+
+	return s.engine.SimulateOrder(o)
+}
+
+// cancelBatchOrder cancels an order of a batch that was created but not sent to the engine
+func (s *OrderService) cancelBatchOrder(o *types.Order) {
+	err := s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusCancelled)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	o.Status = types.OrderStatusCancelled
+}
+
+// validateOrder checks that a new order is valid, signed by its maker and that its pair accepts
+// orders. The token and pair data of the order are filled in.
+func (s *OrderService) validateOrder(o *types.Order) error {
+	// Validate if the address is not blacklisted
+	acc, err := s.accountDao.GetByAddress(o.UserAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	// accounts without document are not blocked
+	if acc != nil && acc.IsBlocked {
+		return fmt.Errorf("Address: %+v isBlocked", acc)
+	}
+
+	if err := o.Validate(); err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	ok, err := o.VerifySignature()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if !ok {
+		return errors.New("Invalid signature")
+	}
+
+	p, err := s.pairDao.GetByBuySellTokenAddress(o.BuyToken, o.SellToken)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if p == nil {
+		return errors.New("Pair not found")
+	}
+
+	if !p.Active {
+		return ErrPairInactive
+	}
+
+	if p.Paused {
+		return ErrPairPaused
+	}
+
+	// Fill token and pair data
+	err = o.Process(p)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = p.ValidateOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = p.ValidateNotional(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// checkOpenOrderLimits returns an error if the maker of an order already has the maximum number of
+// open orders, over all pairs or on the pair of the order. Concurrent orders of an account are not
+// serialized, so that a burst of orders can exceed the limits by the number of orders in flight.
+func (s *OrderService) checkOpenOrderLimits(o *types.Order) error {
+	acc, err := s.accountDao.GetByAddress(o.UserAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	limits := openOrderLimits(acc)
+	if limits.Total == 0 && limits.PerPair == 0 {
+		return nil
+	}
+
+	if acc == nil {
+		return ErrAccountNotFound
+	}
+
+	counts, err := countOpenOrders(s.orderDao, acc)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return checkOpenOrderCounts(limits, counts, o.PairName)
+}
+
+// checkOpenOrderCounts returns an error if the open order counts of an account reach its limits,
+// over all pairs or on the given pair
+func checkOpenOrderCounts(limits types.OpenOrderLimits, counts *types.OpenOrderCounts, pairName string) error {
+	if limits.Total > 0 && counts.Total >= limits.Total {
+		return ErrOpenOrdersLimit
+	}
+
+	if limits.PerPair > 0 && counts.Pairs[pairName] >= limits.PerPair {
+		return ErrPairOpenOrdersLimit
+	}
+
+	return nil
+}
+
+// CancelOrder handles the cancellation order requests.
+// Only Orders which are OPEN or NEW i.e. Not yet filled/partially filled
+// can be cancelled
+func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
+	err := oc.Validate()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	prev, err := s.orderCancelDao.GetByHash(oc.Hash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if prev != nil {
+		return errors.New("Order cancel has already been processed")
+	}
+
+	dbOrder, err := s.orderDao.GetByHash(oc.OrderHash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if dbOrder == nil {
+		return fmt.Errorf("No order with this hash present")
+	}
+
+	_, err = oc.VerifySignature(dbOrder)
+	if err != nil {
+		logger.Error(err)
+		return errors.New("Invalid signature")
+	}
+
+	_, err = json.Marshal(dbOrder)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if dbOrder.Status == types.OrderStatusOpen || dbOrder.Status == types.OrderStatusPartialFilled || dbOrder.Status == types.OrderStatusStop {
+		res, err := s.engine.CancelOrder(dbOrder)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		err = s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusCancelled)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		err = s.orderCancelDao.Create(oc)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		// dormant stop orders have no locked balance
+		if res.Status != "STOP_CANCELLED" {
+			s.unlockOrderBalance(res.Order)
+		}
+
+		ws.SendOrderMessage("ORDER_CANCELLED", res.HashID, res.Order.ToPrivateAPI())
+		s.BroadcastUpdate(res)
+		return nil
 	}
-}
 
-// GetByID fetches the details of an order using order's mongo ID
-func (s *OrderService) GetByID(id bson.ObjectId) (*types.Order, error) {
-	return s.orderDao.GetByID(id)
+	return fmt.Errorf("Cannot cancel the order")
 }
 
-// GetByUserAddress fetches all the orders placed by passed user address
-func (s *OrderService) GetByUserAddress(addr common.Address) ([]*types.Order, error) {
-	return s.orderDao.GetByUserAddress(addr)
-}
+// ReplaceOrder handles the signed requests to atomically cancel an order and replace it with a
+// new order. The replacement goes through the same checks as new orders and must have the same
+// maker and pair as the replaced order. The balance of the replaced order is released and the
+// balance of the replacement locked in a single account update, then the engine swaps the orders
+// in the orderbook. If either step fails, the replaced order is left untouched. The cancelled
+// order is returned.
+func (s *OrderService) ReplaceOrder(or *types.OrderReplace) (*types.Order, error) {
+	err := or.Validate()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 
-// GetByHash fetches all trades corresponding to a trade hash
-func (s *OrderService) GetByHash(hash common.Hash) (*types.Order, error) {
-	return s.orderDao.GetByHash(hash)
-}
+	old, err := s.orderDao.GetByHash(or.OrderHash)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 
-// GetCurrentByUserAddress function fetches list of open/partial orders from order collection based on user address.
-// Returns array of Order type struct
-func (s *OrderService) GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error) {
-	return s.orderDao.GetCurrentByUserAddress(addr)
-}
+	if old == nil {
+		return nil, fmt.Errorf("No order with this hash present")
+	}
 
-// GetHistoryByUserAddress function fetches list of orders which are not in open/partial order status
-// from order collection based on user address.
-// Returns array of Order type struct
-func (s *OrderService) GetHistoryByUserAddress(addr common.Address) ([]*types.Order, error) {
-	return s.orderDao.GetHistoryByUserAddress(addr)
-}
+	err = or.VerifySignature(old)
+	if err != nil {
+		logger.Error(err)
+		return nil, errors.New("Invalid signature")
+	}
 
-// NewOrder validates if the passed order is valid or not based on user's available
-// funds and order data.
-// If valid: Order is inserted in DB with order status as new and order is publiched
-// on rabbitmq queue for matching engine to process the order
-func (s *OrderService) NewOrder(o *types.Order) error {
-	// Validate if the address is not blacklisted
-	acc, err := s.accountDao.GetByAddress(o.UserAddress)
+	if old.Status != types.OrderStatusOpen && old.Status != types.OrderStatusPartialFilled {
+		return nil, errors.New("Cannot replace the order")
+	}
+
+	o := or.Order
+	err = s.validateOrder(o)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	if acc.IsBlocked {
-		return fmt.Errorf("Address: %+v isBlocked", acc)
+	if o.UserAddress != old.UserAddress {
+		return nil, errors.New("Replacement order maker does not match")
 	}
 
-	if err := o.Validate(); err != nil {
+	if o.PairName != old.PairName {
+		return nil, errors.New("Replacement order pair does not match")
+	}
+
+	if o.IsStop() {
+		return nil, errors.New("Stop orders can not replace an order")
+	}
+
+	err = s.swapOrderBalance(old, o)
+	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
+	}
+
+	// the nonce is only consumed once the balance of the replacement is locked
+	ok, err := s.accountDao.ConsumeOrderNonce(o.UserAddress, o.Nonce)
+	if err != nil || !ok {
+		s.restoreOrderBalance(o, old)
 	}
 
-	ok, err := o.VerifySignature()
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
 	if !ok {
-		return errors.New("Invalid signature")
+		return nil, ErrOrderNonceConsumed
 	}
 
-	p, err := s.pairDao.GetByBuySellTokenAddress(o.BuyToken, o.SellToken)
+	err = s.orderDao.Create(o)
 	if err != nil {
 		logger.Error(err)
-		return err
+		s.restoreOrderBalance(o, old)
+		return nil, err
 	}
 
-	if p == nil {
-		return errors.New("Pair not found")
+	res, err := s.engine.ReplaceOrder(old, o, o.Hash)
+	if res == nil {
+		logger.Error(err)
+		s.restoreOrderBalance(o, old)
+		if err := s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusCancelled); err != nil {
+			logger.Error(err)
+		}
+
+		return nil, err
 	}
 
-	// Fill token and pair data
-	err = o.Process(p)
+	// the replaced order is removed from the orderbook even if the engine response of the
+	// replacement could not be published
 	if err != nil {
 		logger.Error(err)
-		return err
 	}
 
-	// fee balance validation
-	wethAddress := common.HexToAddress(app.Config.Ethereum["weth_address"])
-	exchangeAddress := common.HexToAddress(app.Config.Ethereum["exchange_address"])
-	balanceRecord, err := s.accountDao.GetTokenBalances(o.UserAddress)
+	err = s.orderDao.UpdateOrderStatus(old.Hash, types.OrderStatusCancelled)
 	if err != nil {
 		logger.Error(err)
-		return err
 	}
 
-	wethBalance, err := s.ethereumProvider.BalanceOf(o.UserAddress, wethAddress)
+	ws.SendOrderMessage("ORDER_CANCELLED", old.Hash, res.Order.ToPrivateAPI())
+	s.BroadcastUpdate(res)
+	return res.Order, nil
+}
+
+// ReduceOrder handles the signed requests to reduce the unfilled amount of an order without losing
+// its position in the orderbook. The new amount must be lower than the unfilled amount of the
+// order and at least the pair minimum amount. The engine reduces the order in place, then the
+// reduced amount is stored and the balance it locked is released. The reduced order is returned.
+func (s *OrderService) ReduceOrder(or *types.OrderReduce) (*types.Order, error) {
+	err := or.Validate()
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	wethAllowance, err := s.ethereumProvider.Allowance(o.UserAddress, exchangeAddress, wethAddress)
+	o, err := s.orderDao.GetByHash(or.OrderHash)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	wethLockedBalance, err := s.orderDao.GetUserLockedBalance(o.UserAddress, wethAddress)
+	if o == nil {
+		return nil, fmt.Errorf("No order with this hash present")
+	}
+
+	err = or.VerifySignature(o)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, errors.New("Invalid signature")
 	}
 
-	sellTokenBalance, err := s.ethereumProvider.BalanceOf(o.UserAddress, o.SellToken)
+	if o.Status != types.OrderStatusOpen && o.Status != types.OrderStatusPartialFilled {
+		return nil, errors.New("Cannot reduce the order")
+	}
+
+	p, err := s.pairDao.GetByBuySellTokenAddress(o.BuyToken, o.SellToken)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	sellTokenAllowance, err := s.ethereumProvider.Allowance(o.UserAddress, exchangeAddress, o.SellToken)
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	if p.MinAmount != nil && or.NewAmount.Cmp(p.MinAmount) < 0 {
+		return nil, ErrReduceBelowMinAmount
+	}
+
+	res, err := s.engine.ReduceOrder(o, or.NewAmount)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	sellTokenLockedBalance, err := s.orderDao.GetUserLockedBalance(o.UserAddress, o.SellToken)
+	reduced := res.Order
+	err = s.orderDao.UpdateOrderAmount(reduced.Hash, reduced.Amount)
 	if err != nil {
 		logger.Error(err)
-		return err
 	}
 
-	fee := math.Max(o.MakeFee, o.TakeFee)
-	availableWethBalance := math.Sub(wethBalance, wethLockedBalance)
-	availableSellTokenBalance := math.Sub(sellTokenBalance, sellTokenLockedBalance)
+	// the order locked the sell amount of its unfilled amount before it was reduced
+	before := reduced.Clone()
+	before.Amount = math.Add(reduced.Amount, res.CancelledAmount)
+	s.unlockAmount(reduced, math.Sub(before.RemainingSellAmount(), reduced.RemainingSellAmount()))
 
-	if availableWethBalance.Cmp(fee) == -1 {
-		return errors.New("Insufficient WETH Balance")
+	s.BroadcastUpdate(res)
+	return reduced, nil
+}
+
+// restoreOrderBalance reverts the balance swap of a failed order replacement
+func (s *OrderService) restoreOrderBalance(o, old *types.Order) {
+	err := s.updateAccount(o.UserAddress, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		unlock, err := acc.Unlock(o.SellToken, o.RemainingSellAmount())
+		if err != nil {
+			return nil, err
+		}
+
+		lock, err := acc.Lock(old.SellToken, old.RemainingSellAmount())
+		if err != nil {
+			return nil, err
+		}
+
+		unlock.OrderHash = o.Hash
+		lock.OrderHash = old.Hash
+		return []*types.BalanceChange{unlock, lock}, nil
+	}, o.SellToken, old.SellToken)
+
+	if err != nil {
+		logger.Error(err)
 	}
+}
 
-	if wethAllowance.Cmp(fee) == -1 {
-		return errors.New("Insufficient WETH Balance")
+// CancelAllOrders handles the signed requests to cancel all the open orders of a maker,
+// optionally restricted to a single pair. The orders are removed from the orderbooks at
+// once by the engine and an ORDER_CANCELLED message is sent for each cancelled order on
+// the connection of the request.
+func (s *OrderService) CancelAllOrders(ca *types.CancelAllOrders) ([]*types.Order, error) {
+	err := ca.Validate()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
 	}
 
-	if availableSellTokenBalance.Cmp(o.SellAmount) != 1 {
-		return errors.New("Insufficient Balance")
+	now := time.Now()
+	if !ca.IsRecent(now, CancelAllWindow) {
+		return nil, ErrCancelAllExpired
 	}
 
-	if sellTokenAllowance.Cmp(o.SellAmount) != 1 {
-		return errors.New("Insufficient Allowance")
+	err = ca.VerifySignature()
+	if err != nil {
+		logger.Error(err)
+		return nil, errors.New("Invalid signature")
 	}
 
-	sellTokenBalanceRecord := balanceRecord[o.SellToken]
-	sellTokenBalanceRecord.Balance.Set(sellTokenBalance)
-	sellTokenBalanceRecord.Allowance.Set(sellTokenAllowance)
-	wethTokenBalanceRecord := balanceRecord[wethAddress]
-	wethTokenBalanceRecord.Balance.Set(wethBalance)
-	wethTokenBalanceRecord.Allowance.Set(wethAllowance)
+	err = s.consumeCancelAllHash(ca.Hash, now)
+	if err != nil {
+		return nil, err
+	}
 
-	err = s.accountDao.UpdateTokenBalance(o.UserAddress, wethAddress, wethTokenBalanceRecord)
+	responses, err := s.engine.CancelAllOrders(ca.Maker, ca.PairName)
 	if err != nil {
 		logger.Error(err)
-		return err
 	}
 
-	err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.SellToken, sellTokenBalanceRecord)
+	// the orders removed from the orderbooks are cancelled even if the engine could not remove
+	// all of them
+	orders := []*types.Order{}
+	for _, res := range responses {
+		err := s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusCancelled)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		if res.Status != "STOP_CANCELLED" {
+			s.unlockOrderBalance(res.Order)
+		}
+
+		ws.SendOrderMessage("ORDER_CANCELLED", ca.Hash, res.Order.ToPrivateAPI())
+		s.BroadcastUpdate(res)
+		orders = append(orders, res.Order)
+	}
+
+	return orders, err
+}
+
+// CancelOrdersForAddress removes all the resting orders of an address from the orderbooks, or
+// only the orders of the given pair if p is not nil, and cancels them. The balance locked by each
+// removed order is released once, dormant stop orders having no locked balance, and an
+// ORDER_CANCELLED message is sent on the connection of each order. The cancelled orders are
+// returned.
+func (s *OrderService) CancelOrdersForAddress(addr common.Address, p *types.Pair) ([]*types.Order, error) {
+	removed, err := s.engine.CancelOrdersForAddress(addr, p)
 	if err != nil {
 		logger.Error(err)
-		return err
 	}
 
-	if err = s.orderDao.Create(o); err != nil {
+	// the orders removed from the orderbooks are cancelled even if the engine could not remove
+	// all of them
+	return s.cancelRemovedOrders(removed), err
+}
+
+// CancelOrdersByHash removes the resting orders of an address with the given hashes from the
+// orderbooks and cancels them like CancelOrdersForAddress. The cancelled orders are returned.
+func (s *OrderService) CancelOrdersByHash(addr common.Address, hashes []common.Hash) ([]*types.Order, error) {
+	removed, err := s.engine.CancelOrdersByHash(addr, hashes)
+	if err != nil {
 		logger.Error(err)
-		return err
 	}
 
-	bytes, err := json.Marshal(o)
+	return s.cancelRemovedOrders(removed), err
+}
+
+// cancelRemovedOrders cancels the orders removed from the orderbooks by the engine and returns them
+func (s *OrderService) cancelRemovedOrders(removed []*types.Order) []*types.Order {
+	orders := []*types.Order{}
+	for _, o := range removed {
+		locked := o.Status != types.OrderStatusStop
+
+		err := s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusCancelled)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		o.Status = types.OrderStatusCancelled
+		if locked {
+			s.unlockOrderBalance(o)
+		}
+
+		ws.SendOrderMessage("ORDER_CANCELLED", o.Hash, o.ToPrivateAPI())
+		s.BroadcastUpdate(&types.EngineResponse{HashID: o.Hash, Status: "CANCELLED", Order: o})
+		orders = append(orders, o)
+	}
+
+	return orders
+}
+
+// AuthenticateSession checks the signed message authenticating a websocket session as an address.
+// Like cancel all orders messages, the message must be recent and can only be processed once, so
+// that it cannot be replayed to take over the session of the address.
+func (s *OrderService) AuthenticateSession(sa *types.SessionAuth) error {
+	err := sa.Validate()
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
-	s.broker.PublishOrder(&rabbitmq.Message{Type: "NEW_ORDER", HashID: o.Hash, Data: bytes})
+	now := time.Now()
+	if !sa.IsRecent(now, CancelAllWindow) {
+		return ErrSessionAuthExpired
+	}
+
+	err = sa.VerifySignature()
+	if err != nil {
+		logger.Error(err)
+		return errors.New("Invalid signature")
+	}
+
+	// the hashes of both messages are kept together, they commit to different content
+	if s.consumeCancelAllHash(sa.Hash, now) != nil {
+		return ErrSessionAuthProcessed
+	}
+
 	return nil
 }
 
-// CancelOrder handles the cancellation order requests.
-// Only Orders which are OPEN or NEW i.e. Not yet filled/partially filled
-// can be cancelled
-func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
-	dbOrder, err := s.orderDao.GetByHash(oc.OrderHash)
+// FreezeAccount blocks an account, so that it can no longer send orders, and cancels all its
+// resting orders. The cancelled orders are returned.
+func (s *OrderService) FreezeAccount(addr common.Address) ([]*types.Order, error) {
+	acc, err := s.accountDao.GetByAddress(addr)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	if dbOrder == nil {
-		return fmt.Errorf("No order with this hash present")
+	if acc == nil {
+		return nil, ErrAccountNotFound
 	}
 
-	_, err = json.Marshal(dbOrder)
+	// the account is blocked first so that no order can be added once its orders are cancelled
+	err = s.accountDao.UpdateBlocked(addr, true)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	if dbOrder.Status == "OPEN" || dbOrder.Status == "OPEN" {
-		res, err := s.engine.CancelOrder(dbOrder)
-		if err != nil {
-			logger.Error(err)
-			return err
-		}
+	return s.CancelOrdersForAddress(addr, nil)
+}
 
-		err = s.orderDao.UpdateOrderStatus(res.Order.Hash, "CANCELLED")
-		if err != nil {
-			logger.Error(err)
+// consumeCancelAllHash records a cancel all orders message hash and returns an error if the
+// message has already been processed. Hashes are forgotten once their message can no longer
+// be accepted.
+func (s *OrderService) consumeCancelAllHash(h common.Hash, now time.Time) error {
+	s.cancelAllMutex.Lock()
+	defer s.cancelAllMutex.Unlock()
+
+	for hash, expires := range s.cancelAllHashes {
+		if now.After(expires) {
+			delete(s.cancelAllHashes, hash)
 		}
+	}
 
-		ws.SendOrderMessage("ORDER_CANCELLED", res.HashID, res.Order)
-		s.BroadcastUpdate(res)
-		return nil
+	if _, ok := s.cancelAllHashes[h]; ok {
+		return ErrCancelAllProcessed
 	}
 
-	return fmt.Errorf("Cannot cancel the order")
+	// a message is accepted until its timestamp is CancelAllWindow in the past, the hash is
+	// kept for the longest possible window
+	s.cancelAllHashes[h] = now.Add(2 * CancelAllWindow)
+	return nil
 }
 
 // HandleEngineResponse listens to messages incoming from the engine and handles websocket
 // responses and database updates accordingly
 func (s *OrderService) HandleEngineResponse(res *types.EngineResponse) error {
+	markEngineResponseDelivered(res)
+
 	switch res.Status {
 	case "ERROR":
 		s.handleEngineError(res)
@@ -281,6 +1070,25 @@ func (s *OrderService) HandleEngineResponse(res *types.EngineResponse) error {
 		s.handleEngineOrderMatched(res)
 	case "PARTIAL":
 		s.handleEngineOrderMatched(res)
+	case "CANCELLED":
+		s.handleEngineOrderCancelled(res)
+	case "ORDER_EXPIRED":
+		s.handleEngineOrderExpired(res)
+	case "STOP_ADDED":
+		// dormant stop orders are not part of the orderbook and are not broadcast
+		s.handleEngineStopAdded(res)
+		return nil
+	case "STOP_TRIGGERED":
+		s.handleEngineStopTriggered(res)
+		return nil
+	case "DUPLICATE":
+		// orders received twice are only matched once and are not broadcast again
+		s.handleEngineOrderDuplicate(res)
+		return nil
+	case "REJECTED":
+		// rejected orders never reach the orderbook and are not broadcast
+		s.handleEngineOrderRejected(res)
+		return nil
 	default:
 		s.handleEngineUnknownMessage(res)
 	}
@@ -297,9 +1105,9 @@ func (s *OrderService) HandleOperatorMessages(msg *types.OperatorMessage) error
 	case "TRADE_SUCCESS":
 		s.handleOperatorTradeSuccess(msg)
 	case "TRADE_ERROR":
-		s.handleOperatorTradeError(msg)
+		s.handleOperatorTradeFailed(msg)
 	case "TRADE_INVALID":
-		s.handleOperatorTradeError(msg)
+		s.handleOperatorTradeFailed(msg)
 	default:
 		s.handleOperatorUnknownMessage(msg)
 	}
@@ -310,7 +1118,7 @@ func (s *OrderService) HandleOperatorMessages(msg *types.OperatorMessage) error
 // handleEngineError returns an websocket error message to the client and recovers orders on the
 // redis key/value store
 func (s *OrderService) handleEngineError(res *types.EngineResponse) {
-	err := s.orderDao.UpdateOrderStatus(res.Order.Hash, "ERROR")
+	err := s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusError)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -327,26 +1135,139 @@ func (s *OrderService) handleEngineError(res *types.EngineResponse) {
 // to the orderbook (but currently not matched)
 func (s *OrderService) handleEngineOrderAdded(res *types.EngineResponse) {
 	logger.Warning("ADDING ORDER", res.HashID.Hex())
-	ws.SendOrderMessage("ORDER_ADDED", res.HashID, res.Order)
+	spec := res.Order.ToPrivateAPI()
+	spec.Position = res.Position
+	ws.SendOrderMessage("ORDER_ADDED", res.HashID, spec)
 }
 
-// handleEngineOrderMatched returns a websocket message informing the client that his order has been added.
-// The request signature message also signals the client to sign trades.
-func (s *OrderService) handleEngineOrderMatched(res *types.EngineResponse) {
-	err := s.orderDao.UpdateByHash(res.Order.Hash, res.Order)
+// handleEngineOrderDuplicate sends the current state of an order that was received twice by the
+// engine back to its owner
+func (s *OrderService) handleEngineOrderDuplicate(res *types.EngineResponse) {
+	ws.SendOrderMessage("ORDER_EXISTS", res.HashID, res.Order.ToPrivateAPI())
+}
+
+// handleEngineOrderExpired marks an order removed from the orderbook after its expiry as expired,
+// releases its locked balance and notifies the order owner
+func (s *OrderService) handleEngineOrderExpired(res *types.EngineResponse) {
+	err := s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusExpired)
 	if err != nil {
 		logger.Error(err)
 	}
 
-	for _, m := range res.Matches {
-		err := s.orderDao.UpdateByHash(m.Order.Hash, m.Order)
+	s.unlockOrderBalance(res.Order)
+	ws.SendOrderMessage("ORDER_EXPIRED", res.HashID, res.Order.ToPrivateAPI())
+}
+
+// handleEngineStopAdded informs the client that its stop order is waiting for its stop pricepoint
+// to be crossed
+func (s *OrderService) handleEngineStopAdded(res *types.EngineResponse) {
+	ws.SendOrderMessage("STOP_ORDER_ADDED", res.HashID, res.Order.ToPrivateAPI())
+}
+
+// handleEngineStopTriggered locks the balance of a triggered stop order and sends it back to the
+// engine as a regular limit order. Stop orders that expired or whose balance can not be locked
+// anymore are not activated.
+func (s *OrderService) handleEngineStopTriggered(res *types.EngineResponse) {
+	o := res.Order
+
+	if o.IsExpired(time.Now()) {
+		err := s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusExpired)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		o.Status = types.OrderStatusExpired
+		ws.SendOrderMessage("ORDER_EXPIRED", o.Hash, o.ToPrivateAPI())
+		return
+	}
+
+	err := s.lockOrderBalance(o)
+	if err != nil {
+		logger.Error(err)
+
+		err = s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusCancelled)
 		if err != nil {
 			logger.Error(err)
 		}
+
+		o.Status = types.OrderStatusCancelled
+		ws.SendOrderMessage("ORDER_CANCELLED", o.Hash, o.ToPrivateAPI())
+		return
+	}
+
+	err = s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusOpen)
+	if err != nil {
+		logger.Error(err)
+		s.unlockOrderBalance(o)
+		return
+	}
+
+	o.Status = types.OrderStatusOpen
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		logger.Error(err)
+		s.unlockOrderBalance(o)
+		return
+	}
+
+	ws.SendOrderMessage("STOP_TRIGGERED", o.Hash, o.ToPrivateAPI())
+	s.broker.PublishOrder(&rabbitmq.Message{Type: "NEW_ORDER", HashID: o.Hash, Data: bytes})
+}
+
+// handleEngineOrderMatched returns a websocket message informing the client that his order has been added.
+// The request signature message also signals the client to sign trades. The orders of the match
+// are persisted along with its trades once the taker has signed them (see handleSubmitSignatures).
+func (s *OrderService) handleEngineOrderMatched(res *types.EngineResponse) {
+	// the dust left by the orders filled below the pair minimum amount is released
+	orders := []*types.Order{res.Order}
+	for _, m := range res.Matches {
+		orders = append(orders, m.Order)
+	}
+
+	for _, o := range orders {
+		if o.DustAmount().Sign() > 0 {
+			s.unlockOrderBalance(o)
+			ws.SendOrderMessage("DUST_CANCELLED", o.Hash, o.ToPrivateAPI())
+		}
+	}
+
+	// the unfilled amount of IOC and FOK orders is cancelled by the engine
+	if res.CancelledAmount != nil {
+		s.unlockOrderBalance(res.Order)
+		ws.SendOrderMessage("UNFILLED_AMOUNT_CANCELLED", res.HashID, types.NewUnfilledCancelPayload(res))
 	}
 
 	go s.handleSubmitSignatures(res)
-	ws.SendOrderMessage("REQUEST_SIGNATURE", res.HashID, types.SignaturePayload{res.RemainingOrder, res.Matches})
+	ws.SendOrderMessage("REQUEST_SIGNATURE", res.HashID, types.SignaturePayload{Order: res.RemainingOrder, Matches: res.Matches})
+}
+
+// handleEngineOrderCancelled handles the IOC and FOK orders cancelled by the engine without being
+// matched and the resting orders that their maker can no longer cover. The order balance is
+// released and the client is told why the order was cancelled.
+func (s *OrderService) handleEngineOrderCancelled(res *types.EngineResponse) {
+	err := s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusCancelled)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	s.unlockOrderBalance(res.Order)
+	ws.SendOrderMessage("UNFILLED_AMOUNT_CANCELLED", res.HashID, types.NewUnfilledCancelPayload(res))
+}
+
+// handleEngineOrderRejected is called when the engine rejects an order without matching it.
+// The balance locked for the order is released and the maker is notified of the rejection reason.
+func (s *OrderService) handleEngineOrderRejected(res *types.EngineResponse) {
+	err := s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusRejected)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	s.unlockOrderBalance(res.Order)
+	ws.SendOrderMessage("ORDER_REJECTED", res.HashID, map[string]interface{}{
+		"order":   res.Order.ToPrivateAPI(),
+		"code":    res.RejectReason,
+		"message": rejectReasonMessages[res.RejectReason],
+	})
 }
 
 // handleSubmitSignatures wait for a submit signature message that provides the matching engine with orders
@@ -373,9 +1294,21 @@ func (s *OrderService) handleSubmitSignatures(res *types.EngineResponse) {
 				ws.SendOrderMessage("ERROR", res.HashID, err)
 			}
 
-			// remaining order
+			// the trades are checked against the matches of the engine before the remaining order is
+			// sent back to the engine to be matched
+			if data.Matches != nil {
+				err = validateSubmittedTrades(res, data.Matches)
+				if err != nil {
+					logger.Error(err)
+					s.Rollback(res)
+					ws.SendOrderMessage("ERROR", res.HashID, err)
+					return
+				}
+			}
+
+			// remaining order, which may already be stored
 			if data.Order != nil {
-				err := s.orderDao.Create(data.Order)
+				err := s.orderDao.UpsertByHash(data.Order)
 				if err != nil {
 					//TODO consider if we should going on with execution or not
 					logger.Error(err)
@@ -397,13 +1330,44 @@ func (s *OrderService) handleSubmitSignatures(res *types.EngineResponse) {
 			if data.Matches != nil {
 				trades := []*types.Trade{}
 				for _, m := range data.Matches {
+					m.Trade.Status = types.TradeStatusPending
 					trades = append(trades, m.Trade)
 				}
 
-				//TODO include this in the handleOrderMatched step
-				err := s.tradeDao.Create(trades...)
-				if err != nil {
-					logger.Error(err)
+				// the orders and trades of a match are persisted as one unit; a trade that could not
+				// be inserted, e.g. because it already exists, is not executed while the others are
+				err = s.matchDao.PersistMatch(types.NewMatchResult(res, trades))
+				if errs, ok := err.(types.TradeBatchErrors); ok {
+					matches := []*types.OrderTradePair{}
+					for _, m := range data.Matches {
+						if !errs.Failed(m.Trade) {
+							matches = append(matches, m)
+						}
+					}
+
+					for _, e := range errs {
+						logger.Error("Could not create trade ", e.Trade.Hash.Hex(), ": ", e.Err)
+					}
+
+					data.Matches = matches
+				} else if err != nil {
+					logger.Error(err)
+				}
+
+				// the trades of the engine response take the hashes of the persisted trades, so that
+				// a rollback reverts their fills
+				for _, em := range res.Matches {
+					for _, m := range data.Matches {
+						if em.Trade.OrderHash == m.Trade.OrderHash {
+							em.Trade.Hash = m.Trade.Hash
+						}
+					}
+				}
+
+				if err != nil && len(data.Matches) == 0 {
+					s.Rollback(res)
+					ws.SendOrderMessage("ERROR", res.HashID, err)
+					return
 				}
 
 				_, err = json.Marshal(res.Order)
@@ -413,14 +1377,16 @@ func (s *OrderService) handleSubmitSignatures(res *types.EngineResponse) {
 					ws.SendOrderMessage("ERROR", res.HashID, err)
 				}
 
-				for _, m := range data.Matches {
-					err := s.broker.PublishTrade(m.Order, m.Trade)
-					if err != nil {
-						logger.Error(err)
-						s.Rollback(res)
-						ws.SendOrderMessage("ERROR", res.HashID, err)
-					}
+				// the trades are executed by the operator in the order in which they were matched
+				err = s.broker.PublishTrades(data.Matches)
+				if err != nil {
+					logger.Error(err)
+					s.Rollback(res)
+					ws.SendOrderMessage("ERROR", res.HashID, err)
+					return
 				}
+
+				ws.SendOrderMessage("ORDER_MATCHED", res.HashID, types.NewFillReportPayload(res.Order, data.Matches))
 			}
 		}
 	case <-t.C:
@@ -430,6 +1396,73 @@ func (s *OrderService) handleSubmitSignatures(res *types.EngineResponse) {
 	}
 }
 
+// validateSubmittedTrades checks the trades signed by the taker against the matches computed by the engine.
+// Each trade must be signed by the taker, have the amount and pricepoint of the engine trade of its maker
+// order and must not fill more than what was available in the matched order. The filled amounts of the
+// orders before each trade are copied from the engine trades.
+func validateSubmittedTrades(res *types.EngineResponse, submitted []*types.OrderTradePair) error {
+	errs := types.ValidationErrors{}
+
+	for i, m := range submitted {
+		prefix := fmt.Sprintf("matches[%d].", i)
+		if m == nil || m.Trade == nil {
+			errs.Add(prefix+"trade", "Trade is missing")
+			continue
+		}
+
+		err := m.Trade.Validate()
+		if err != nil {
+			for _, e := range err.(types.ValidationErrors) {
+				errs.Add(prefix+e.Field, e.Reason)
+			}
+			continue
+		}
+
+		var match *types.OrderTradePair
+		for _, em := range res.Matches {
+			if em.Trade.OrderHash == m.Trade.OrderHash {
+				match = em
+			}
+		}
+
+		if match == nil {
+			errs.Add(prefix+"orderHash", "Trade does not correspond to a matched order")
+			continue
+		}
+
+		n := len(errs)
+		if m.Trade.Amount.Cmp(match.Trade.Amount) != 0 {
+			errs.Add(prefix+"amount", "Trade amount does not match the matched amount")
+		}
+
+		if m.Trade.PricePoint == nil || m.Trade.PricePoint.Cmp(match.Trade.PricePoint) != 0 {
+			errs.Add(prefix+"pricepoint", "Trade pricepoint does not match the matched pricepoint")
+		}
+
+		if len(errs) > n {
+			continue
+		}
+
+		// the engine has already applied the match to the order so the fillable amount
+		// is computed from the order state before the match
+		o := *match.Order
+		o.FilledAmount = math.Sub(match.Order.FilledAmount, match.Trade.Amount)
+
+		err = m.Trade.ValidateFill(&o)
+		if err != nil {
+			for _, e := range err.(types.ValidationErrors) {
+				errs.Add(prefix+e.Field, e.Reason)
+			}
+		}
+
+		// the place of the trade among the fills of the orders is set by the engine
+		m.Trade.MakerFilledBefore = match.Trade.MakerFilledBefore
+		m.Trade.TakerFilledBefore = match.Trade.TakerFilledBefore
+	}
+
+	return errs.Err()
+}
+
 // handleEngineUnknownMessage returns a websocket messsage in case the engine resonse is not recognized
 func (s *OrderService) handleEngineUnknownMessage(res *types.EngineResponse) {
 	s.Rollback(res)
@@ -444,11 +1477,12 @@ func (s *OrderService) handleOperatorUnknownMessage(msg *types.OperatorMessage)
 func (s *OrderService) handleOperatorTradePending(msg *types.OperatorMessage) {
 	t := msg.Trade
 
-	err := s.tradeDao.UpdateTradeStatus(t.Hash, "ORDER_PENDING")
+	err := s.tradeDao.UpdateTradeStatus(t.Hash, types.TradeStatusPending)
 	if err != nil {
 		logger.Error(err)
 	}
 
+	deliveries.add(t.Hash, types.TradeStatusPending)
 	ws.SendOrderMessage("ORDER_PENDING", t.OrderHash, t)
 	ws.SendOrderMessage("ORDER_PENDING", t.TakerOrderHash, t)
 }
@@ -485,7 +1519,7 @@ func (s *OrderService) handleTradeMakerInvalid(msg *types.OperatorMessage) {
 		logger.Error(err)
 	}
 
-	op := &types.OrderTradePair{takerOrder, t}
+	op := &types.OrderTradePair{Order: takerOrder, Trade: t}
 	err = s.engine.RecoverOrders([]*types.OrderTradePair{op})
 	if err != nil {
 		logger.Error(err)
@@ -506,7 +1540,7 @@ func (s *OrderService) handleTradeTakerInvalid(msg *types.OperatorMessage) {
 		logger.Error(err)
 	}
 
-	err = s.orderDao.UpdateOrderStatus(t.TakerOrderHash, "INVALID")
+	err = s.orderDao.UpdateOrderStatus(t.TakerOrderHash, types.OrderStatusInvalid)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -524,7 +1558,7 @@ func (s *OrderService) handleTradeTakerInvalid(msg *types.OperatorMessage) {
 
 	// we recover and include the maker order in the redis orderbook again
 	//TODO only the trade amount should be needed and not the full trade
-	op := &types.OrderTradePair{makerOrder, t}
+	op := &types.OrderTradePair{Order: makerOrder, Trade: t}
 	err = s.engine.RecoverOrders([]*types.OrderTradePair{op})
 	if err != nil {
 		logger.Error(err)
@@ -536,52 +1570,422 @@ func (s *OrderService) handleTradeTakerInvalid(msg *types.OperatorMessage) {
 	//TODO often and he will likely not know
 }
 
-// handleOperatorTradeSuccess handles successfull trade messages from the orderbook. It updates
-// the trade status in the database and
+// handleOperatorTradeSuccess handles successfull trade messages from the orderbook. It moves the
+// trade from PENDING to SUCCESS and settles the balances of its orders. Trades that are not pending
+// anymore, eg. trades considered failed before their transaction was mined, are not settled.
 func (s *OrderService) handleOperatorTradeSuccess(msg *types.OperatorMessage) {
 	t := msg.Trade
-	err := s.tradeDao.UpdateTradeStatus(t.Hash, "SUCCESS")
+	err := s.tradeDao.UpdateTradeStatus(t.Hash, types.TradeStatusSuccess)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	err = s.settleTrade(t)
 	if err != nil {
 		logger.Error(err)
 	}
 
+	deliveries.add(t.Hash, types.TradeStatusSuccess)
 	ws.SendOrderMessage("ORDER_SUCCESS", t.OrderHash, t)
 	ws.SendOrderMessage("ORDER_SUCCESS", t.TakerOrderHash, t)
 }
 
-// handleOperatorTradeError handles error messages from the operator (case where the blockchain tx was made
-// but ended up failing. It updates the trade status in the db. None of the orders are reincluded in the redis
-// orderbook.
-func (s *OrderService) handleOperatorTradeError(msg *types.OperatorMessage) {
+// handleOperatorTradeFailed handles the trades that failed to settle: their transaction reverted,
+// the exchange contract reported an error, the transaction was not mined in time (TRADE_ERROR) or
+// it could not be sent (TRADE_INVALID). The trade moves from PENDING to FAILED, which happens at
+// most once per trade, and its amount is given back to its orders (see restoreFailedTrade). Both
+// parties receive an ORDER_ERROR message holding the trade.
+func (s *OrderService) handleOperatorTradeFailed(msg *types.OperatorMessage) {
 	t := msg.Trade
+	if t == nil {
+		return
+	}
+
+	err := s.tradeDao.UpdateTradeStatus(t.Hash, types.TradeStatusFailed)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	deliveries.add(t.Hash, types.TradeStatusFailed)
 	ws.SendOrderMessage("ORDER_ERROR", t.OrderHash, t)
 	ws.SendOrderMessage("ORDER_ERROR", t.TakerOrderHash, t)
 
-	err := s.tradeDao.UpdateTradeStatus(t.Hash, "ERROR")
+	err = s.restoreFailedTrade(t)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// restoreFailedTrade gives back the amount of a failed trade to its maker and taker orders. The
+// amount is deducted from the filled amount of the open, partially filled and filled orders, whose
+// other fills are kept, and the orders are restored in the orderbook (see Engine.RestoreFailedTrade):
+// an ORDER_RESTORED message holding the order is sent to their maker. The balance locked for the
+// trade stays locked for the restored orders, the dust amount of filled orders being locked again.
+// The orders that can not be restored, because they were cancelled, expired or replaced or they
+// could not rest in the orderbook, release the balance locked for the trade. The orders that were
+// still open are then cancelled with an ORDER_CANCELLED message.
+func (s *OrderService) restoreFailedTrade(t *types.Trade) error {
+	orders, err := s.orderDao.GetByHashes([]common.Hash{t.OrderHash, t.TakerOrderHash})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	restorable := []*types.Order{}
+	for _, o := range orders {
+		switch o.Status {
+		case types.OrderStatusOpen, types.OrderStatusPartialFilled, types.OrderStatusFilled:
+		default:
+			s.releaseTradeBalance(o, t)
+			continue
+		}
+
+		// the dust amount of filled orders was released when they were filled
+		if dust := o.DustAmount(); dust.Sign() > 0 {
+			err := s.relockDustBalance(o)
+			if err != nil {
+				logger.Error(err)
+				s.releaseTradeBalance(o, t)
+				continue
+			}
+		}
+
+		restorable = append(restorable, o)
+	}
+
+	if len(restorable) == 0 {
+		return nil
+	}
+
+	restored, err := s.engine.RestoreFailedTrade(restorable, t.Amount)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	restoredByHash := map[common.Hash]*types.Order{}
+	for _, o := range restored {
+		restoredByHash[o.Hash] = o
+	}
+
+	for _, o := range restorable {
+		err := s.orderDao.UpdateOrderFilledAmount(o.Hash, math.Neg(t.Amount))
+		if err != nil {
+			logger.Error(err)
+		}
+
+		if r := restoredByHash[o.Hash]; r != nil {
+			ws.SendOrderMessage("ORDER_RESTORED", o.Hash, r.ToPrivateAPI())
+			continue
+		}
+
+		// the order could not rest in the orderbook anymore, the dust amount locked again being
+		// released with the amount of the trade
+		s.releaseTradeBalance(o, t)
+		if o.DustAmount().Sign() > 0 {
+			s.unlockOrderBalance(o)
+		}
+
+		err = s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusCancelled)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+
+		o.Status = types.OrderStatusCancelled
+		ws.SendOrderMessage("ORDER_CANCELLED", o.Hash, o.ToPrivateAPI())
+	}
+
+	return nil
+}
+
+// releaseTradeBalance unlocks the amount of sell token locked by an order for a trade that failed
+func (s *OrderService) releaseTradeBalance(o *types.Order, t *types.Trade) {
+	filledBefore := t.MakerFilledBefore
+	if o.Hash == t.TakerOrderHash {
+		filledBefore = t.TakerFilledBefore
+	}
+
+	sold := o.SellAmountFor(t.Amount)
+	if filledBefore != nil {
+		sold, _ = o.FillAmounts(filledBefore, t.Amount)
+	}
+
+	s.unlockAmount(o, sold)
+}
+
+// relockDustBalance locks again the amount of sell token of the dust amount of a filled order,
+// which was released when the order was filled
+func (s *OrderService) relockDustBalance(o *types.Order) error {
+	lock, err := s.accountDao.LockBalance(o.UserAddress, o.SellToken, o.RemainingSellAmount())
+	if err != nil {
+		return err
+	}
+
+	lock.OrderHash = o.Hash
+	s.recordBalanceChanges([]*types.BalanceChange{lock})
+	return nil
+}
+
+// updateAccount applies fn to the account of the given address and persists the balances of the
+// given tokens along with the balance changes returned by fn. The balances are updated atomically
+// by the account DAO (see AccountDao.UpdateTokenBalances), so fn may be applied more than once when
+// the account is updated concurrently.
+func (s *OrderService) updateAccount(addr common.Address, fn func(acc *types.Account) ([]*types.BalanceChange, error), tokens ...common.Address) error {
+	changes, err := s.accountDao.UpdateTokenBalances(addr, fn, tokens...)
+	if err != nil {
+		return err
+	}
+
+	s.recordBalanceChanges(changes)
+	return nil
+}
+
+// recordBalanceChanges persists the balance changes in the account ledger and pushes them to the
+// owner of the account. Changes are sent on the connection of the order that caused them.
+// Nil changes (e.g. balance syncs that did not modify the balance) are skipped.
+func (s *OrderService) recordBalanceChanges(changes []*types.BalanceChange) {
+	recorded := []*types.BalanceChange{}
+	for _, c := range changes {
+		if c != nil {
+			recorded = append(recorded, c)
+		}
+	}
+
+	err := s.balanceChangeDao.Create(recorded...)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	for _, c := range recorded {
+		if c.OrderHash != (common.Hash{}) {
+			ws.SendOrderMessage("BALANCE_CHANGED", c.OrderHash, c)
+		}
+	}
+}
+
+// lockOrderBalance checks that the maker of an order can pay its fees and locks its sell amount.
+// The balances are synced from the token contracts first.
+func (s *OrderService) lockOrderBalance(o *types.Order) error {
+	return s.swapOrderBalance(nil, o)
+}
+
+// swapOrderBalance releases the unfilled sell amount of the released order, if any, and locks the
+// unfilled sell amount of the order o in a single account update, so that the maker balance can
+// be used by the replacement of an order. Nothing is released if the order o can not be locked.
+func (s *OrderService) swapOrderBalance(released, o *types.Order) error {
+	// fee balance validation
+	wethAddress := common.HexToAddress(app.Config.Ethereum["weth_address"])
+	exchangeAddress := o.ExchangeAddress
+	wethBalance, err := s.ethereumProvider.BalanceOf(o.UserAddress, wethAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	wethAllowance, err := s.ethereumProvider.Allowance(o.UserAddress, exchangeAddress, wethAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	wethLockedBalance, err := s.orderDao.GetUserLockedBalance(o.UserAddress, wethAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	sellTokenBalance, err := s.ethereumProvider.BalanceOf(o.UserAddress, o.SellToken)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	sellTokenAllowance, err := s.ethereumProvider.Allowance(o.UserAddress, exchangeAddress, o.SellToken)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	fee := math.Max(o.MakeFee, o.TakeFee)
+	availableWethBalance := math.Sub(wethBalance, wethLockedBalance)
+
+	if availableWethBalance.Cmp(fee) == -1 {
+		return errors.New("Insufficient WETH Balance")
+	}
+
+	if wethAllowance.Cmp(fee) == -1 {
+		return errors.New("Insufficient WETH Balance")
+	}
+
+	tokens := []common.Address{wethAddress, o.SellToken}
+	if released != nil {
+		tokens = append(tokens, released.SellToken)
+	}
+
+	// the sell amount is locked until the order is filled or cancelled
+	err = s.updateAccount(o.UserAddress, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		changes := []*types.BalanceChange{
+			acc.SetBalanceAndAllowance(wethAddress, wethBalance, wethAllowance),
+			acc.SetBalanceAndAllowance(o.SellToken, sellTokenBalance, sellTokenAllowance),
+		}
+
+		if released != nil {
+			unlock, err := acc.Unlock(released.SellToken, released.RemainingSellAmount())
+			if err != nil {
+				return nil, err
+			}
+
+			unlock.OrderHash = released.Hash
+			changes = append(changes, unlock)
+		}
+
+		lock, err := acc.Lock(o.SellToken, o.RemainingSellAmount())
+		if err != nil {
+			return nil, errors.New("Insufficient Balance")
+		}
+
+		lock.OrderHash = o.Hash
+		return append(changes, lock), nil
+	}, tokens...)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// unlockOrderBalance releases the unfilled sell amount of an order
+func (s *OrderService) unlockOrderBalance(o *types.Order) {
+	s.unlockAmount(o, o.RemainingSellAmount())
+}
+
+// unlockAmount unlocks an amount of the sell token of an order
+func (s *OrderService) unlockAmount(o *types.Order, amount *big.Int) {
+	unlock, err := s.accountDao.UnlockBalance(o.UserAddress, o.SellToken, amount)
 	if err != nil {
 		logger.Error(err)
+		return
 	}
+
+	unlock.OrderHash = o.Hash
+	s.recordBalanceChanges([]*types.BalanceChange{unlock})
+}
+
+// settleTrade moves the funds locked by the maker and taker orders of a successful trade
+func (s *OrderService) settleTrade(t *types.Trade) error {
+	orders, err := s.orderDao.GetByHashes([]common.Hash{t.OrderHash, t.TakerOrderHash})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	var pair *types.Pair
+	for _, o := range orders {
+		// trades matched before the engine recorded the filled amounts of their orders are
+		// settled in proportion to their amount
+		filledBefore := t.MakerFilledBefore
+		if o.Hash == t.TakerOrderHash {
+			filledBefore = t.TakerFilledBefore
+		}
+
+		sold, bought := o.SellAmountFor(t.Amount), o.BuyAmountFor(t.Amount)
+		if filledBefore != nil {
+			sold, bought = o.FillAmounts(filledBefore, t.Amount)
+		}
+
+		// an order trading at a better price than its own (see engine.ExecutionPricePolicy) pays
+		// less quote token when buying, the difference being unlocked, or receives more when selling
+		unlocked := big.NewInt(0)
+		if t.PricePoint != nil && o.PricePoint != nil && t.PricePoint.Cmp(o.PricePoint) != 0 {
+			if pair == nil {
+				pair, err = s.pairDao.GetByTokenAddress(t.BaseToken, t.QuoteToken)
+				if err != nil {
+					logger.Error(err)
+					return err
+				}
+
+				if pair == nil {
+					err = errors.New("Pair not found")
+					logger.Error(err)
+					return err
+				}
+			}
+
+			quote := pair.QuoteAmount(t.Amount, t.PricePoint)
+			if o.Side == types.OrderSideBuy {
+				if quote.Cmp(sold) < 0 {
+					unlocked = math.Sub(sold, quote)
+					sold = quote
+				}
+			} else {
+				bought = quote
+			}
+		}
+
+		changes, err := s.accountDao.TransferLocked(o.UserAddress, o.SellToken, o.BuyToken, sold, bought)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		if unlocked.Sign() > 0 {
+			released, err := s.accountDao.UnlockBalance(o.UserAddress, o.SellToken, unlocked)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+
+			changes = append(changes, released)
+		}
+
+		for _, c := range changes {
+			c.OrderHash = o.Hash
+			c.TradeHash = t.Hash
+		}
+
+		s.recordBalanceChanges(changes)
+	}
+
+	return nil
 }
 
 func (s *OrderService) Rollback(res *types.EngineResponse) *types.EngineResponse {
 	if res.RemainingOrder != nil {
-		err := s.orderDao.UpdateOrderStatus(res.RemainingOrder.Hash, "ERROR")
+		err := s.orderDao.UpdateOrderStatus(res.RemainingOrder.Hash, types.OrderStatusError)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	// the unfilled amount of partially matched orders rests in the orderbook
+	if res.Status == "PARTIAL" && res.CancelledAmount == nil {
+		err := s.engine.DeleteOrder(res.Order)
 		if err != nil {
 			logger.Error(err)
 		}
 	}
 
-	//TODO what do we do with remaining order ?
 	if len(res.Matches) > 0 {
+		err := s.orderDao.UpdateOrderStatus(res.Order.Hash, types.OrderStatusError)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		// the fills of a match are persisted with its trades once the taker signed them, the
+		// trades of the engine response then taking the hashes of the persisted trades
 		for _, ot := range res.Matches {
 			t := ot.Trade
-
-			err := s.orderDao.UpdateOrderFilledAmount(t.OrderHash, math.Neg(t.Amount))
-			if err != nil {
-				logger.Error(err)
+			if t.Hash == (common.Hash{}) {
+				continue
 			}
 
-			err = s.orderDao.UpdateOrderStatus(t.TakerOrderHash, "ERROR")
+			err = s.orderDao.UpdateOrderFilledAmount(t.OrderHash, math.Neg(t.Amount))
 			if err != nil {
 				logger.Error(err)
 			}
@@ -593,7 +1997,7 @@ func (s *OrderService) Rollback(res *types.EngineResponse) *types.EngineResponse
 		}
 
 		//TODO should we simply delete the orders from the orderbook
-		err := s.engine.RecoverOrders(res.Matches)
+		err = s.engine.RecoverOrders(res.Matches)
 		if err != nil {
 			logger.Error(err)
 		}
@@ -607,7 +2011,7 @@ func (s *OrderService) Rollback(res *types.EngineResponse) *types.EngineResponse
 }
 
 func (s *OrderService) RollbackOrder(o *types.Order) (err error) {
-	err = s.orderDao.UpdateOrderStatus(o.Hash, "ERROR")
+	err = s.orderDao.UpdateOrderStatus(o.Hash, types.OrderStatusError)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -632,7 +2036,7 @@ func (s *OrderService) RollbackTrade(o *types.Order, t *types.Trade) (err error)
 		logger.Error(err)
 	}
 
-	err = s.engine.RecoverOrders([]*types.OrderTradePair{{o, t}})
+	err = s.engine.RecoverOrders([]*types.OrderTradePair{{Order: o, Trade: t}})
 	if err != nil {
 		logger.Error(err)
 	}
@@ -668,43 +2072,26 @@ func (s *OrderService) BroadcastUpdate(res *types.EngineResponse) {
 	p, err := s.pairDao.GetByBuySellTokenAddress(res.Order.BuyToken, res.Order.SellToken)
 	if err != nil {
 		logger.Error(err)
+		return
 	}
 
-	orders := []map[string]string{}
-
+	// the broadcasts are serialized concurrently with the handling of the engine response
+	// so they are given copies of the orders and trades. Hidden orders are left out of the raw
+	// orderbook but their trades are broadcast like any other trade.
+	rawOrders := []*types.Order{res.Order.Clone()}
 	for _, m := range res.Matches {
-		pp := m.Order.PricePoint
-		amount, err := s.orderDao.GetOrderBookPricePoint(p, pp)
-		if err != nil {
-			logger.Error(err)
-		}
-
-		update := map[string]string{
-			"pricepoint": pp.String(),
-			"amount":     amount.String(),
-		}
-
-		orders = append(orders, update)
+		rawOrders = append(rawOrders, m.Order.Clone())
 	}
 
-	rawOrders := []*types.Order{res.Order}
-	for _, m := range res.Matches {
-		rawOrders = append(rawOrders, m.Order)
-	}
+	rawOrders = visibleOrders(rawOrders)
 
 	trades := []*types.Trade{}
 	for _, m := range res.Matches {
-		trades = append(trades, m.Trade)
+		trades = append(trades, m.Trade.Clone())
 	}
 
 	go s.broadcastTradeUpdate(p, trades)
 	go s.broadcastRawOrderUpdate(p, rawOrders)
-	go s.broadcastOrderUpdate(p, orders)
-}
-
-func (s *OrderService) broadcastOrderUpdate(p *types.Pair, data interface{}) {
-	id := utils.GetOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress)
-	ws.GetOrderBookSocket().BroadcastMessage(id, data)
 }
 
 func (s *OrderService) broadcastTradeUpdate(p *types.Pair, trades []*types.Trade) {
@@ -714,5 +2101,5 @@ func (s *OrderService) broadcastTradeUpdate(p *types.Pair, trades []*types.Trade
 
 func (s *OrderService) broadcastRawOrderUpdate(p *types.Pair, orders []*types.Order) {
 	id := utils.GetOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress)
-	ws.GetRawOrderBookSocket().BroadcastMessage(id, orders)
+	ws.GetRawOrderBookSocket().BroadcastMessage(id, types.OrdersToAPI(orders))
 }
@@ -2,10 +2,13 @@ package services
 
 import (
 	"errors"
+	"math/big"
+	"sync"
 
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/Proofsuite/amp-matching-engine/ws"
@@ -18,6 +21,10 @@ type OrderBookService struct {
 	tokenDao interfaces.TokenDao
 	orderDao interfaces.OrderDao
 	eng      interfaces.Engine
+
+	// mirrors hold the price levels of the orderbooks with grouped subscriptions, keyed by pair code
+	mirrors      map[string]*orderBookMirror
+	mirrorsMutex sync.Mutex
 }
 
 // NewPairService returns a new instance of balance service
@@ -27,11 +34,18 @@ func NewOrderBookService(
 	orderDao interfaces.OrderDao,
 	eng interfaces.Engine,
 ) *OrderBookService {
-	return &OrderBookService{pairDao, tokenDao, orderDao, eng}
+	return &OrderBookService{
+		pairDao:  pairDao,
+		tokenDao: tokenDao,
+		orderDao: orderDao,
+		eng:      eng,
+		mirrors:  map[string]*orderBookMirror{},
+	}
 }
 
-// GetOrderBook fetches orderbook from engine/redis and returns it as an map[string]interface
-func (s *OrderBookService) GetOrderBook(bt, qt common.Address) (map[string]interface{}, error) {
+// GetOrderBook returns the price levels of the orderbook of a pair, as kept by the engine, with
+// the sequence of the last diff they include
+func (s *OrderBookService) GetOrderBook(bt, qt common.Address) (*types.OrderBook, error) {
 	pair, err := s.pairDao.GetByTokenAddress(bt, qt)
 	if err != nil {
 		logger.Error(err)
@@ -42,20 +56,73 @@ func (s *OrderBookService) GetOrderBook(bt, qt common.Address) (map[string]inter
 		return nil, errors.New("Pair not found")
 	}
 
-	bids, asks, err := s.orderDao.GetOrderBook(pair)
+	ob, err := s.eng.GetOrderBook(pair)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	ob := map[string]interface{}{
-		"asks": asks,
-		"bids": bids,
+	// prices are displayed with the precision of the pair
+	ob.FormatPrices(pair)
+	return ob, nil
+}
+
+// GetGroupedOrderBook returns the orderbook of a pair with its price levels aggregated to the
+// given number of price decimals, which can not be more than the price decimals of the pair
+func (s *OrderBookService) GetGroupedOrderBook(bt, qt common.Address, decimals int) (*types.OrderBook, error) {
+	pair, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
 	}
 
+	if pair == nil {
+		return nil, errors.New("Pair not found")
+	}
+
+	tick := pair.GroupingTick(decimals)
+	if tick == nil {
+		return nil, ErrInvalidPriceGrouping
+	}
+
+	ob, err := s.eng.GetOrderBook(pair)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob.Group(tick)
+	ob.FormatPrices(pair)
 	return ob, nil
 }
 
+// GetOrderBookChecksum returns the checksum of the orderbook of a pair, so that clients can check
+// the orderbook they keep from the diffs
+func (s *OrderBookService) GetOrderBookChecksum(bt, qt common.Address) (*types.OrderBookChecksum, error) {
+	pair, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if pair == nil {
+		return nil, errors.New("Pair not found")
+	}
+
+	sequence, checksum, err := s.eng.GetOrderBookChecksum(pair)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &types.OrderBookChecksum{PairName: pair.Name(), Sequence: sequence, Checksum: checksum}, nil
+}
+
+// GetEngineStats returns the metrics of the orderbooks of the engine
+func (s *OrderBookService) GetEngineStats() *types.EngineStats {
+	return s.eng.Stats()
+}
+
 // SubscribeOrderBook is responsible for handling incoming orderbook subscription messages
 // It makes an entry of connection in pairSocket corresponding to pair,unit and duration
 func (s *OrderBookService) SubscribeOrderBook(conn *ws.Conn, bt, qt common.Address) {
@@ -90,7 +157,248 @@ func (s *OrderBookService) UnSubscribeOrderBook(conn *ws.Conn, bt, qt common.Add
 	socket.Unsubscribe(id, conn)
 }
 
-// GetRawOrderBook fetches complete orderbook from engine/redis
+// SubscribeGroupedOrderBook subscribes a connection to the orderbook of a pair with its price
+// levels aggregated to the given number of price decimals. The diffs of grouped subscriptions
+// hold the new amount of the grouped price levels, with the sequence of the orderbook diff that
+// changed them.
+func (s *OrderBookService) SubscribeGroupedOrderBook(conn *ws.Conn, bt, qt common.Address, decimals int) {
+	socket := ws.GetOrderBookSocket()
+
+	pair, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		socket.SendErrorMessage(conn, err.Error())
+		return
+	}
+
+	if pair == nil {
+		socket.SendErrorMessage(conn, "Pair not found")
+		return
+	}
+
+	tick := pair.GroupingTick(decimals)
+	if tick == nil {
+		socket.SendErrorMessage(conn, ErrInvalidPriceGrouping.Error())
+		return
+	}
+
+	// the snapshot is only used if the orderbook is not mirrored yet, it is read before locking
+	// the mirrors as the diffs are applied to the mirrors while they are locked
+	snapshot, err := s.eng.GetOrderBook(pair)
+	if err != nil {
+		logger.Error(err)
+		socket.SendErrorMessage(conn, err.Error())
+		return
+	}
+
+	s.mirrorsMutex.Lock()
+	defer s.mirrorsMutex.Unlock()
+
+	m := s.mirrors[pair.Code()]
+	if m == nil {
+		m = newOrderBookMirror(snapshot)
+		s.mirrors[pair.Code()] = m
+	}
+
+	id := utils.GetGroupedOrderBookChannelID(bt, qt, decimals)
+	err = socket.Subscribe(id, conn)
+	if err != nil {
+		message := map[string]string{
+			"Code":    "Internal Server Error",
+			"Message": err.Error(),
+		}
+
+		socket.SendErrorMessage(conn, message)
+		return
+	}
+
+	m.groupings[decimals] = tick
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(id))
+
+	// the grouped snapshot is sent while the mirrors are locked so that the following diffs
+	// are the diffs that were not applied to it
+	ob := m.orderBook()
+	ob.PairName = pair.Name()
+	ob.Group(tick)
+	ob.FormatPrices(pair)
+	socket.SendInitMessage(conn, ob)
+}
+
+// UnSubscribeGroupedOrderBook is responsible for handling incoming grouped orderbook unsubscription messages
+func (s *OrderBookService) UnSubscribeGroupedOrderBook(conn *ws.Conn, bt, qt common.Address, decimals int) {
+	socket := ws.GetOrderBookSocket()
+	id := utils.GetGroupedOrderBookChannelID(bt, qt, decimals)
+	socket.Unsubscribe(id, conn)
+}
+
+// ReplayOrderBookDiffs sends the orderbook diffs following the given sequence to a client that
+// detected a gap in the sequence of the diffs it received. An error message is sent if the
+// diffs are not available anymore, the client then has to subscribe again.
+func (s *OrderBookService) ReplayOrderBookDiffs(conn *ws.Conn, bt, qt common.Address, from uint64) {
+	socket := ws.GetOrderBookSocket()
+
+	pair, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		socket.SendErrorMessage(conn, err.Error())
+		return
+	}
+
+	if pair == nil {
+		socket.SendErrorMessage(conn, "Pair not found")
+		return
+	}
+
+	diffs, err := s.eng.GetOrderBookDiffs(pair, from)
+	if err != nil {
+		socket.SendErrorMessage(conn, err.Error())
+		return
+	}
+
+	socket.SendDiffMessage(conn, formatOrderBookDiffs(pair, diffs))
+}
+
+// BroadcastOrderBookDiffs sends the diffs of the orderbook of a pair to the subscribers of the
+// orderbook channel of the pair
+func (s *OrderBookService) BroadcastOrderBookDiffs(p *types.Pair, diffs []*types.OrderBookDiff) {
+	id := utils.GetOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress)
+	ws.GetOrderBookSocket().BroadcastDiffMessage(id, formatOrderBookDiffs(p, diffs))
+	s.broadcastGroupedDiffs(p, diffs)
+}
+
+// broadcastGroupedDiffs applies the diffs of the orderbook of a pair to its mirror and sends the
+// resulting changes of the grouped price levels to the grouped subscriptions of the pair. Diffs
+// missed by the mirror, which can happen while it is created, are replayed from the engine. If
+// they are not available anymore the mirror is dropped and the grouped subscribers have to
+// subscribe again.
+func (s *OrderBookService) broadcastGroupedDiffs(p *types.Pair, diffs []*types.OrderBookDiff) {
+	s.mirrorsMutex.Lock()
+	defer s.mirrorsMutex.Unlock()
+
+	m := s.mirrors[p.Code()]
+	if m == nil || len(diffs) == 0 {
+		return
+	}
+
+	socket := ws.GetOrderBookSocket()
+	if diffs[0].Sequence > m.sequence+1 {
+		missed, err := s.eng.GetOrderBookDiffs(p, m.sequence)
+		if err != nil {
+			logger.Error(err)
+			delete(s.mirrors, p.Code())
+			for decimals := range m.groupings {
+				id := utils.GetGroupedOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress, decimals)
+				socket.BroadcastErrorMessage(id, "Orderbook diffs are not available anymore, subscribe again")
+			}
+
+			return
+		}
+
+		diffs = append(missed, diffs...)
+	}
+
+	grouped := map[int][]*types.OrderBookDiff{}
+	for _, d := range diffs {
+		if d.Sequence <= m.sequence {
+			continue
+		}
+
+		m.apply(d)
+		for decimals, tick := range m.groupings {
+			grouped[decimals] = append(grouped[decimals], m.groupedDiff(d, tick))
+		}
+	}
+
+	for decimals, gd := range grouped {
+		id := utils.GetGroupedOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress, decimals)
+		socket.BroadcastDiffMessage(id, formatOrderBookDiffs(p, gd))
+	}
+}
+
+// orderBookMirror holds the price levels of an orderbook, kept up to date by applying the diffs
+// of the orderbook, and the groupings of the subscriptions to the orderbook
+type orderBookMirror struct {
+	sequence  uint64
+	levels    map[types.OrderSide]map[string]*types.PriceLevel
+	groupings map[int]*big.Int
+}
+
+func newOrderBookMirror(ob *types.OrderBook) *orderBookMirror {
+	m := &orderBookMirror{
+		sequence: ob.Sequence,
+		levels: map[types.OrderSide]map[string]*types.PriceLevel{
+			types.OrderSideBuy:  {},
+			types.OrderSideSell: {},
+		},
+		groupings: map[int]*big.Int{},
+	}
+
+	for _, l := range ob.Bids {
+		m.levels[types.OrderSideBuy][l.PricePoint.String()] = l
+	}
+
+	for _, l := range ob.Asks {
+		m.levels[types.OrderSideSell][l.PricePoint.String()] = l
+	}
+
+	return m
+}
+
+// apply sets the amount of the price level changed by a diff
+func (m *orderBookMirror) apply(d *types.OrderBookDiff) {
+	m.sequence = d.Sequence
+
+	key := d.PricePoint.String()
+	if d.Amount.Sign() == 0 {
+		delete(m.levels[d.Side], key)
+		return
+	}
+
+	m.levels[d.Side][key] = &types.PriceLevel{PricePoint: d.PricePoint, Amount: d.Amount}
+}
+
+// groupedDiff returns the new amount of the grouped price level changed by a diff
+func (m *orderBookMirror) groupedDiff(d *types.OrderBookDiff, tick *big.Int) *types.OrderBookDiff {
+	pp := types.GroupPricePoint(d.PricePoint, tick, d.Side)
+	amount := big.NewInt(0)
+	for _, l := range m.levels[d.Side] {
+		if types.GroupPricePoint(l.PricePoint, tick, d.Side).Cmp(pp) == 0 {
+			amount = math.Add(amount, l.Amount)
+		}
+	}
+
+	return &types.OrderBookDiff{Sequence: d.Sequence, Side: d.Side, PricePoint: pp, Amount: amount}
+}
+
+// orderBook returns the sorted price levels of the mirror
+func (m *orderBookMirror) orderBook() *types.OrderBook {
+	ob := &types.OrderBook{Sequence: m.sequence, Bids: []*types.PriceLevel{}, Asks: []*types.PriceLevel{}}
+	for _, l := range m.levels[types.OrderSideBuy] {
+		ob.Bids = append(ob.Bids, l)
+	}
+
+	for _, l := range m.levels[types.OrderSideSell] {
+		ob.Asks = append(ob.Asks, l)
+	}
+
+	ob.Sort()
+	return ob
+}
+
+// formatOrderBookDiffs returns copies of the diffs with their price formatted with the precision
+// of the pair. The diffs of the engine are shared and must not be modified.
+func formatOrderBookDiffs(p *types.Pair, diffs []*types.OrderBookDiff) []*types.OrderBookDiff {
+	formatted := []*types.OrderBookDiff{}
+	for _, d := range diffs {
+		f := *d
+		f.Price = p.FormatPricePoint(d.PricePoint)
+		formatted = append(formatted, &f)
+	}
+
+	return formatted
+}
+
+// GetRawOrderBook fetches complete orderbook from engine/redis. Hidden orders are left out.
 func (s *OrderBookService) GetRawOrderBook(bt, qt common.Address) ([]*types.Order, error) {
 	pair, err := s.pairDao.GetByTokenAddress(bt, qt)
 	if err != nil {
@@ -104,7 +412,20 @@ func (s *OrderBookService) GetRawOrderBook(bt, qt common.Address) ([]*types.Orde
 		return nil, err
 	}
 
-	return ob, nil
+	return visibleOrders(ob), nil
+}
+
+// visibleOrders returns the orders that are not hidden, which are the only orders shown in the
+// raw orderbook
+func visibleOrders(orders []*types.Order) []*types.Order {
+	visible := []*types.Order{}
+	for _, o := range orders {
+		if !o.Hidden {
+			visible = append(visible, o)
+		}
+	}
+
+	return visible
 }
 
 // SubscribeRawOrderBook is responsible for handling incoming orderbook subscription messages
@@ -131,7 +452,7 @@ func (s *OrderBookService) SubscribeRawOrderBook(conn *ws.Conn, bt, qt common.Ad
 	}
 
 	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(id))
-	socket.SendInitMessage(conn, ob)
+	socket.SendInitMessage(conn, types.OrdersToAPI(ob))
 }
 
 // UnSubscribeRawOrderBook is responsible for handling incoming orderbook unsubscription messages
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBookMirror(t *testing.T) {
+	m := newOrderBookMirror(&types.OrderBook{
+		Sequence: 10,
+		Bids: []*types.PriceLevel{
+			{PricePoint: big.NewInt(1050), Amount: big.NewInt(2)},
+			{PricePoint: big.NewInt(1000), Amount: big.NewInt(4)},
+		},
+		Asks: []*types.PriceLevel{
+			{PricePoint: big.NewInt(1150), Amount: big.NewInt(1)},
+		},
+	})
+
+	tick := big.NewInt(100)
+
+	// a new bid in the same group adds up to the group amount
+	d := &types.OrderBookDiff{Sequence: 11, Side: types.OrderSideBuy, PricePoint: big.NewInt(1099), Amount: big.NewInt(1)}
+	m.apply(d)
+	assert.Equal(t, &types.OrderBookDiff{
+		Sequence:   11,
+		Side:       types.OrderSideBuy,
+		PricePoint: big.NewInt(1000),
+		Amount:     big.NewInt(7),
+	}, m.groupedDiff(d, tick))
+
+	// asks are grouped to the next tick and removed levels are subtracted from their group
+	d = &types.OrderBookDiff{Sequence: 12, Side: types.OrderSideSell, PricePoint: big.NewInt(1150), Amount: big.NewInt(0)}
+	m.apply(d)
+	assert.Equal(t, &types.OrderBookDiff{
+		Sequence:   12,
+		Side:       types.OrderSideSell,
+		PricePoint: big.NewInt(1200),
+		Amount:     big.NewInt(0),
+	}, m.groupedDiff(d, tick))
+
+	ob := m.orderBook()
+	ob.Group(tick)
+
+	assert.Equal(t, uint64(12), ob.Sequence)
+	assert.Equal(t, []*types.PriceLevel{{PricePoint: big.NewInt(1000), Amount: big.NewInt(7)}}, ob.Bids)
+	assert.Equal(t, []*types.PriceLevel{}, ob.Asks)
+}
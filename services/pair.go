@@ -1,7 +1,12 @@
 package services
 
 import (
+	"math/big"
+	"time"
+
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/ethereum/go-ethereum/common"
 
 	"gopkg.in/mgo.v2/bson"
@@ -9,6 +14,10 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/types"
 )
 
+// OpeningAuctionDuration is the duration of the opening auction of a pair started without duration.
+// It is set from the opening_auction_duration configuration.
+var OpeningAuctionDuration = 5 * time.Minute
+
 // PairService struct with daos required, responsible for communicating with daos.
 // PairService functions are responsible for interacting with daos and implements business logics.
 type PairService struct {
@@ -69,6 +78,8 @@ func (s *PairService) Create(pair *types.Pair) error {
 	pair.BaseTokenSymbol = bt.Symbol
 	pair.BaseTokenAddress = bt.ContractAddress
 	pair.BaseTokenDecimal = bt.Decimal
+	pair.ApplyDefaults()
+
 	err = s.pairDao.Create(pair)
 	if err != nil {
 		return err
@@ -92,3 +103,253 @@ func (s *PairService) GetByTokenAddress(bt, qt common.Address) (*types.Pair, err
 func (s *PairService) GetAll() ([]types.Pair, error) {
 	return s.pairDao.GetAll()
 }
+
+// GetActive fetches the pairs that are currently listed
+func (s *PairService) GetActive() ([]types.Pair, error) {
+	return s.pairDao.GetActive()
+}
+
+// SetActive lists or delists a pair. Delisted pairs do not accept new orders anymore but
+// their open orders can still be cancelled. Clients subscribed to the orderbook of the pair
+// are notified with a MARKET_STATUS message (see BroadcastPairStatus).
+func (s *PairService) SetActive(bt, qt common.Address, active bool) (*types.Pair, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	err = s.pairDao.UpdateActive(bt, qt, active)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	p.Active = active
+
+	// pairs created after the engine was started have no orderbook yet
+	err = s.eng.UpdatePair(p)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	status, err := s.eng.GetPairStatus(p)
+	if err != nil {
+		status = p.Status()
+	}
+
+	s.BroadcastPairStatus(status)
+	return p, nil
+}
+
+// GetStatus returns the trading status of a pair: whether it is listed, whether it is paused and
+// whether it is halted by its circuit breaker
+func (s *PairService) GetStatus(bt, qt common.Address) (*types.PairStatus, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	// pairs created after the engine was started have no orderbook yet and are never halted
+	status, err := s.eng.GetPairStatus(p)
+	if err != nil {
+		return p.Status(), nil
+	}
+
+	return status, nil
+}
+
+// Pause pauses a pair until it is resumed, eg. when its token contract or price feed misbehaves.
+// New orders are refused but open orders can still be cancelled. The pause is saved with the pair so
+// that it lasts across restarts. Clients subscribed to the orderbook of the pair are notified with a
+// MARKET_STATUS message.
+func (s *PairService) Pause(bt, qt common.Address) (*types.PairStatus, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	err = s.pairDao.UpdatePaused(bt, qt, true)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	p.Paused = true
+
+	// pairs created after the engine was started have no orderbook yet, their status is broadcast
+	// here instead of being published by the engine
+	status, err := s.eng.PausePair(p)
+	if err != nil {
+		logger.Error(err)
+		status = p.Status()
+		s.BroadcastPairStatus(status)
+	}
+
+	return status, nil
+}
+
+// Resume ends the pause of a pair, as well as its halt by its circuit breaker before the cooldown
+// is over. Clients subscribed to the orderbook of the pair are notified with a MARKET_STATUS
+// message.
+func (s *PairService) Resume(bt, qt common.Address) (*types.PairStatus, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	if p.Paused {
+		err = s.pairDao.UpdatePaused(bt, qt, false)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		p.Paused = false
+	}
+
+	status, err := s.eng.ResumePair(p)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// StartAuction starts the opening call auction of a newly listed pair for the given duration, or
+// OpeningAuctionDuration if it is zero. During the auction orders are collected without being
+// matched, then the crossed orders are matched at a single clearing pricepoint and the pair switches
+// to continuous trading. The reference pricepoint, if any, breaks the ties between clearing
+// pricepoints. The auction can only be started while the orderbook of the pair is empty,
+// types.ErrOrderBookNotEmpty being returned otherwise. Clients subscribed to the orderbook of the
+// pair are notified with a MARKET_STATUS message.
+func (s *PairService) StartAuction(bt, qt common.Address, duration time.Duration, reference *big.Int) (*types.PairStatus, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	if duration == 0 {
+		duration = OpeningAuctionDuration
+	}
+
+	end := time.Now().Add(duration)
+	p.AuctionEndsAt = &end
+	p.AuctionReferencePricePoint = reference
+
+	status, err := s.eng.StartAuction(p)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	// the auction is saved with the pair so that it is restarted by the engine after a restart
+	err = s.pairDao.UpdateAuction(bt, qt, p.AuctionEndsAt, reference)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// BroadcastPairStatus sends the status of a pair in a MARKET_STATUS message to the clients
+// subscribed to the orderbook of the pair, when the pair is listed, delisted, paused, halted or
+// resumed
+func (s *PairService) BroadcastPairStatus(status *types.PairStatus) {
+	bt := common.HexToAddress(status.BaseToken)
+	qt := common.HexToAddress(status.QuoteToken)
+
+	id := utils.GetOrderBookChannelID(bt, qt)
+	ws.GetOrderBookSocket().BroadcastMarketStatus(id, status)
+	ws.GetRawOrderBookSocket().BroadcastMarketStatus(id, status)
+}
+
+// GetTicker returns the best bid and ask, the mid pricepoint, the spread and the last trade of a
+// pair, as maintained by the engine
+func (s *PairService) GetTicker(bt, qt common.Address) (*types.Ticker, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrPairNotFound
+	}
+
+	t, err := s.eng.Ticker(p)
+	if err != nil {
+		// pairs created after the engine was started have no orderbook yet
+		return &types.Ticker{PairName: p.Name(), BaseToken: bt, QuoteToken: qt}, nil
+	}
+
+	return t, nil
+}
+
+// SubscribeTicker sends the current ticker of a pair to the connection and subscribes it to the
+// ticker updates of the pair (see BroadcastTicker)
+func (s *PairService) SubscribeTicker(conn *ws.Conn, bt, qt common.Address) {
+	socket := ws.GetTickerSocket()
+
+	t, err := s.GetTicker(bt, qt)
+	if err != nil {
+		socket.SendErrorMessage(conn, err.Error())
+		return
+	}
+
+	id := utils.GetTickerChannelID(bt, qt)
+	err = socket.Subscribe(id, conn)
+	if err != nil {
+		message := map[string]string{
+			"Code":    "UNABLE_TO_REGISTER",
+			"Message": "UNABLE_TO_REGISTER " + err.Error(),
+		}
+
+		socket.SendErrorMessage(conn, message)
+		return
+	}
+
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(id))
+	socket.SendInitMessage(conn, t)
+}
+
+// UnsubscribeTicker unsubscribes the connection from the ticker updates of a pair
+func (s *PairService) UnsubscribeTicker(conn *ws.Conn, bt, qt common.Address) {
+	socket := ws.GetTickerSocket()
+
+	id := utils.GetTickerChannelID(bt, qt)
+	socket.Unsubscribe(id, conn)
+}
+
+// BroadcastTicker sends the ticker of a pair to the clients subscribed to the ticker of the pair,
+// whenever its best pricepoints change or it trades. Clients order the updates by sequence.
+func (s *PairService) BroadcastTicker(t *types.Ticker) {
+	id := utils.GetTickerChannelID(t.BaseToken, t.QuoteToken)
+	ws.GetTickerSocket().BroadcastMessage(id, t)
+}
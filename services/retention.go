@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// retentionBatchesPerRun is the number of batches of orders of each status deleted by each
+// periodic run, so that a large backlog is spread over several runs
+const retentionBatchesPerRun = 10
+
+// RetentionService deletes from the orders collection the orders that stayed in a final status
+// for longer than the retention window of their status (see OrderDao.DeleteOrdersBefore). The
+// orders moved to the archive collection are never deleted.
+type RetentionService struct {
+	orderDao  interfaces.OrderDao
+	retention map[string]time.Duration
+}
+
+// NewRetentionService returns a new instance of RetentionService deleting the orders of each status
+// of retention last updated more than the duration of the status ago. Statuses are case
+// insensitive. It fails if a status is not a final order status or if a duration is not positive.
+func NewRetentionService(orderDao interfaces.OrderDao, retention map[string]time.Duration) (*RetentionService, error) {
+	windows := make(map[string]time.Duration)
+	for status, d := range retention {
+		status = strings.ToUpper(status)
+		switch status {
+		case types.OrderStatusFilled, types.OrderStatusReplaced, types.OrderStatusCancelled,
+			types.OrderStatusExpired, types.OrderStatusInvalid, types.OrderStatusRejected, types.OrderStatusError:
+		default:
+			return nil, fmt.Errorf("Orders in status %v can not be deleted", status)
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("Invalid retention of %v orders: %v", status, d)
+		}
+
+		windows[status] = d
+	}
+
+	return &RetentionService{orderDao: orderDao, retention: windows}, nil
+}
+
+// EnforceRetention starts a background routine that deletes up to retentionBatchesPerRun batches
+// of orders of each status at every tick of the given interval
+func (s *RetentionService) EnforceRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			s.DeleteExpiredOrders(time.Now(), retentionBatchesPerRun)
+		}
+	}()
+}
+
+// DeleteExpiredOrders deletes the orders of each status last updated strictly before now minus the
+// retention window of the status, in up to the given number of batches per status or until none is
+// left if batches is 0. Orders updated exactly at the start of the window are kept. Returns the
+// number of deleted orders by status. A failed status is logged and the next ones are still run.
+func (s *RetentionService) DeleteExpiredOrders(now time.Time, batches int) map[string]int {
+	statuses := []string{}
+	for status := range s.retention {
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	deleted := make(map[string]int)
+	for _, status := range statuses {
+		n, err := s.orderDao.DeleteOrdersBefore(status, now.Add(-s.retention[status]), batches)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		if n > 0 {
+			logger.Infof("Deleted %v %v orders", n, status)
+		}
+
+		deleted[status] = n
+	}
+
+	return deleted
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRetentionService(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+
+	// statuses are case insensitive, like the keys of the configuration
+	s, err := NewRetentionService(orderDao, map[string]time.Duration{"cancelled": time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]time.Duration{types.OrderStatusCancelled: time.Hour}, s.retention)
+
+	_, err = NewRetentionService(orderDao, map[string]time.Duration{types.OrderStatusOpen: time.Hour})
+	assert.EqualError(t, err, "Orders in status OPEN can not be deleted")
+
+	_, err = NewRetentionService(orderDao, map[string]time.Duration{types.OrderStatusFilled: 0})
+	assert.Error(t, err)
+}
+
+func TestRetentionServiceDeleteExpiredOrders(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	s, err := NewRetentionService(orderDao, map[string]time.Duration{
+		types.OrderStatusCancelled: 180 * 24 * time.Hour,
+		types.OrderStatusExpired:   24 * time.Hour,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cutoff of each status is the start of its retention window
+	now := time.Now()
+	orderDao.On("DeleteOrdersBefore", types.OrderStatusCancelled, now.Add(-180*24*time.Hour), 10).Return(3, nil)
+	orderDao.On("DeleteOrdersBefore", types.OrderStatusExpired, now.Add(-24*time.Hour), 10).Return(0, errors.New("Connection lost"))
+
+	// a failed status does not prevent the others from being deleted
+	deleted := s.DeleteExpiredOrders(now, retentionBatchesPerRun)
+	assert.Equal(t, map[string]int{types.OrderStatusCancelled: 3, types.OrderStatusExpired: 0}, deleted)
+	orderDao.AssertExpectations(t)
+}
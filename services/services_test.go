@@ -1,22 +1,29 @@
 package services
 
 import (
+	"io/ioutil"
+	"sync"
+
 	"github.com/Proofsuite/amp-matching-engine/daos"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/dbtest"
-	"io/ioutil"
 )
 
 var server dbtest.DBServer
 var db *mgo.Session
+var mongoOnce sync.Once
 
-func init() {
-	temp, _ := ioutil.TempDir("", "test")
-	server.SetPath(temp)
+// setupMongo starts a mongod for the tests that aggregate stored trades. The other tests of the
+// package use the in-memory DAOs and do not need it.
+func setupMongo() {
+	mongoOnce.Do(func() {
+		temp, _ := ioutil.TempDir("", "test")
+		server.SetPath(temp)
 
-	session := server.Session()
-	if _, err := daos.InitSession(session); err != nil {
-		panic(err)
-	}
-	db = session
+		session := server.Session()
+		if _, err := daos.InitSession(session); err != nil {
+			panic(err)
+		}
+		db = session
+	})
 }
@@ -11,16 +11,19 @@ import (
 // TokenService struct with daos required, responsible for communicating with daos.
 // TokenService functions are responsible for interacting with daos and implements business logics.
 type TokenService struct {
-	tokenDao interfaces.TokenDao
+	tokenDao         interfaces.TokenDao
+	ethereumProvider interfaces.EthereumProvider
 }
 
 // NewTokenService returns a new instance of TokenService
-func NewTokenService(tokenDao interfaces.TokenDao) *TokenService {
-	return &TokenService{tokenDao}
+func NewTokenService(tokenDao interfaces.TokenDao, ethereumProvider interfaces.EthereumProvider) *TokenService {
+	return &TokenService{tokenDao, ethereumProvider}
 }
 
-// Create inserts a new token into the database
-func (s *TokenService) Create(token *types.Token) error {
+// Create inserts a new token into the database. The token symbol and decimals are populated from
+// the token contract if they are not set. If they are set but differ from the values returned by the
+// token contract, the token is only created if force is true.
+func (s *TokenService) Create(token *types.Token, force bool) error {
 	t, err := s.tokenDao.GetByAddress(token.ContractAddress)
 	if err != nil {
 		logger.Error(err)
@@ -31,6 +34,29 @@ func (s *TokenService) Create(token *types.Token) error {
 		return ErrTokenExists
 	}
 
+	// forced tokens are created with the given metadata when the token contract can not be queried
+	symbol, decimals, err := s.ResolveTokenMetadata(token.ContractAddress)
+	if err != nil && !force {
+		logger.Error(err)
+		return err
+	}
+
+	if err != nil {
+		logger.Warning("Could not resolve the metadata of token ", token.ContractAddress.Hex(), ": ", err)
+	} else {
+		if token.Symbol == "" {
+			token.Symbol = symbol
+		}
+
+		if token.Decimal == 0 {
+			token.Decimal = int(decimals)
+		}
+
+		if !force && (token.Symbol != symbol || token.Decimal != int(decimals)) {
+			return ErrTokenMetadataMismatch
+		}
+	}
+
 	err = s.tokenDao.Create(token)
 	if err != nil {
 		logger.Error(err)
@@ -40,6 +66,11 @@ func (s *TokenService) Create(token *types.Token) error {
 	return nil
 }
 
+// ResolveTokenMetadata returns the symbol and decimals of a token as returned by the token contract
+func (s *TokenService) ResolveTokenMetadata(addr common.Address) (string, uint8, error) {
+	return s.ethereumProvider.TokenMetadata(addr)
+}
+
 // GetByID fetches the detailed document of a token using its mongo ID
 func (s *TokenService) GetByID(id bson.ObjectId) (*types.Token, error) {
 	return s.tokenDao.GetByID(id)
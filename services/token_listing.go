@@ -0,0 +1,170 @@
+package services
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// TokenListingService handles the requests of users to list new tokens. Requests are only
+// accepted for contracts implementing ERC-20 and are reviewed by admin wallets.
+type TokenListingService struct {
+	listingDao       interfaces.TokenListingDao
+	tokenDao         interfaces.TokenDao
+	walletDao        interfaces.WalletDao
+	ethereumProvider interfaces.EthereumProvider
+}
+
+// NewTokenListingService returns a new instance of TokenListingService
+func NewTokenListingService(
+	listingDao interfaces.TokenListingDao,
+	tokenDao interfaces.TokenDao,
+	walletDao interfaces.WalletDao,
+	ethereumProvider interfaces.EthereumProvider,
+) *TokenListingService {
+	return &TokenListingService{listingDao, tokenDao, walletDao, ethereumProvider}
+}
+
+// Create stores a new pending listing request. The request is rejected if the token is already
+// listed, if another request for the same token is pending or if the token contract does not
+// respond to ERC-20 calls. The decimals of the request are read from the token contract.
+func (s *TokenListingService) Create(r *types.TokenListingRequest) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	t, err := s.tokenDao.GetByAddress(r.ContractAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if t != nil {
+		return ErrTokenExists
+	}
+
+	pending, err := s.listingDao.GetPendingByAddress(r.ContractAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if pending != nil {
+		return ErrTokenListingExists
+	}
+
+	_, decimals, err := s.ethereumProvider.TokenMetadata(r.ContractAddress)
+	if err != nil {
+		logger.Error(err)
+		return ErrNotERC20Token
+	}
+
+	_, err = s.ethereumProvider.BalanceOf(common.Address{}, r.ContractAddress)
+	if err != nil {
+		logger.Error(err)
+		return ErrNotERC20Token
+	}
+
+	r.Decimal = int(decimals)
+	r.Status = types.TokenListingPending
+	r.Reason = ""
+	r.ReviewedBy = common.Address{}
+
+	err = s.listingDao.Create(r)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByID returns the listing request with the given id. Requesters can follow the status of
+// their request and the reason of a rejection with it.
+func (s *TokenListingService) GetByID(id bson.ObjectId) (*types.TokenListingRequest, error) {
+	return s.listingDao.GetByID(id)
+}
+
+// Review approves or rejects a pending listing request. The review must be signed by an admin
+// wallet. Approving a request creates the corresponding token while rejecting it requires a reason.
+func (s *TokenListingService) Review(review *types.TokenListingReview) (*types.TokenListingRequest, error) {
+	if review.Status != types.TokenListingApproved && review.Status != types.TokenListingRejected {
+		return nil, ErrInvalidTokenListingStatus
+	}
+
+	if review.Status == types.TokenListingRejected && review.Reason == "" {
+		return nil, ErrTokenListingReasonRequired
+	}
+
+	err := review.Verify()
+	if err != nil {
+		logger.Error(err)
+		return nil, ErrNotAdmin
+	}
+
+	admin, err := s.walletDao.GetByAddress(review.Admin)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if admin == nil || !admin.Admin {
+		return nil, ErrNotAdmin
+	}
+
+	r, err := s.listingDao.GetByID(review.RequestID)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if r == nil {
+		return nil, ErrTokenListingNotFound
+	}
+
+	if r.Status != types.TokenListingPending {
+		return nil, ErrTokenListingReviewed
+	}
+
+	if review.Status == types.TokenListingApproved {
+		t, err := s.tokenDao.GetByAddress(r.ContractAddress)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if t != nil {
+			return nil, ErrTokenExists
+		}
+	}
+
+	err = s.listingDao.UpdateStatus(r.ID, review.Status, review.Reason, review.Admin)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	r.Status = review.Status
+	r.Reason = review.Reason
+	r.ReviewedBy = review.Admin
+
+	if r.Status == types.TokenListingApproved {
+		token := &types.Token{
+			Name:            r.Symbol,
+			Symbol:          r.Symbol,
+			ContractAddress: r.ContractAddress,
+			Decimal:         r.Decimal,
+			Active:          true,
+		}
+
+		err = s.tokenDao.Create(token)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
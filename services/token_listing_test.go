@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func setupTokenListingService() (*TokenListingService, *mocks.TokenListingDao, *mocks.TokenDao, *mocks.WalletDao, *mocks.EthereumProvider) {
+	listingDao := new(mocks.TokenListingDao)
+	tokenDao := new(mocks.TokenDao)
+	walletDao := new(mocks.WalletDao)
+	provider := new(mocks.EthereumProvider)
+
+	return NewTokenListingService(listingDao, tokenDao, walletDao, provider), listingDao, tokenDao, walletDao, provider
+}
+
+func TestCreateTokenListing(t *testing.T) {
+	s, listingDao, tokenDao, _, provider := setupTokenListingService()
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	r := &types.TokenListingRequest{ContractAddress: addr, Symbol: "ZRX"}
+
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	listingDao.On("GetPendingByAddress", addr).Return(nil, nil)
+	listingDao.On("Create", r).Return(nil)
+	provider.On("TokenMetadata", addr).Return("ZRX", uint8(18), nil)
+	provider.On("BalanceOf", common.Address{}, addr).Return(big.NewInt(0), nil)
+
+	err := s.Create(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.TokenListingPending, r.Status)
+	assert.Equal(t, 18, r.Decimal)
+	listingDao.AssertCalled(t, "Create", r)
+}
+
+func TestCreateTokenListingDuplicate(t *testing.T) {
+	s, listingDao, tokenDao, _, provider := setupTokenListingService()
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	pending := &types.TokenListingRequest{ID: bson.NewObjectId(), ContractAddress: addr, Symbol: "ZRX", Status: types.TokenListingPending}
+	r := &types.TokenListingRequest{ContractAddress: addr, Symbol: "ZRX"}
+
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	listingDao.On("GetPendingByAddress", addr).Return(pending, nil)
+
+	err := s.Create(r)
+	assert.Equal(t, ErrTokenListingExists, err)
+	listingDao.AssertNotCalled(t, "Create", mock.Anything)
+	provider.AssertNotCalled(t, "TokenMetadata", mock.Anything)
+}
+
+func TestCreateTokenListingNotERC20(t *testing.T) {
+	s, listingDao, tokenDao, _, provider := setupTokenListingService()
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	r := &types.TokenListingRequest{ContractAddress: addr, Symbol: "ZRX"}
+
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	listingDao.On("GetPendingByAddress", addr).Return(nil, nil)
+	provider.On("TokenMetadata", addr).Return("", uint8(0), errors.New("no contract code at given address"))
+
+	err := s.Create(r)
+	assert.Equal(t, ErrNotERC20Token, err)
+	listingDao.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestReviewTokenListingApprove(t *testing.T) {
+	s, listingDao, tokenDao, walletDao, _ := setupTokenListingService()
+
+	admin := types.NewWallet()
+	admin.Admin = true
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	r := &types.TokenListingRequest{ID: bson.NewObjectId(), ContractAddress: addr, Symbol: "ZRX", Decimal: 18, Status: types.TokenListingPending}
+
+	review := &types.TokenListingReview{RequestID: r.ID, Status: types.TokenListingApproved}
+	err := review.Sign(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walletDao.On("GetByAddress", admin.Address).Return(admin, nil)
+	listingDao.On("GetByID", r.ID).Return(r, nil)
+	listingDao.On("UpdateStatus", r.ID, types.TokenListingApproved, "", admin.Address).Return(nil)
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	tokenDao.On("Create", mock.AnythingOfType("*types.Token")).Return(nil)
+
+	res, err := s.Review(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.TokenListingApproved, res.Status)
+	assert.Equal(t, admin.Address, res.ReviewedBy)
+
+	token := tokenDao.Calls[len(tokenDao.Calls)-1].Arguments.Get(0).(*types.Token)
+	assert.Equal(t, addr, token.ContractAddress)
+	assert.Equal(t, "ZRX", token.Symbol)
+	assert.Equal(t, 18, token.Decimal)
+}
+
+func TestReviewTokenListingReject(t *testing.T) {
+	s, listingDao, tokenDao, walletDao, _ := setupTokenListingService()
+
+	admin := types.NewWallet()
+	admin.Admin = true
+
+	r := &types.TokenListingRequest{ID: bson.NewObjectId(), Symbol: "ZRX", Status: types.TokenListingPending}
+
+	review := &types.TokenListingReview{RequestID: r.ID, Status: types.TokenListingRejected}
+	review.Sign(admin)
+
+	_, err := s.Review(review)
+	assert.Equal(t, ErrTokenListingReasonRequired, err)
+
+	review.Reason = "Insufficient liquidity"
+	review.Sign(admin)
+
+	walletDao.On("GetByAddress", admin.Address).Return(admin, nil)
+	listingDao.On("GetByID", r.ID).Return(r, nil)
+	listingDao.On("UpdateStatus", r.ID, types.TokenListingRejected, "Insufficient liquidity", admin.Address).Return(nil)
+
+	res, err := s.Review(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.TokenListingRejected, res.Status)
+	assert.Equal(t, "Insufficient liquidity", res.Reason)
+	tokenDao.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestReviewTokenListingNotAdmin(t *testing.T) {
+	s, listingDao, tokenDao, walletDao, _ := setupTokenListingService()
+
+	user := types.NewWallet()
+	admin := types.NewWallet()
+	admin.Admin = true
+
+	r := &types.TokenListingRequest{ID: bson.NewObjectId(), Symbol: "ZRX", Status: types.TokenListingPending}
+	walletDao.On("GetByAddress", user.Address).Return(user, nil)
+	listingDao.On("GetByID", r.ID).Return(r, nil)
+
+	// the wallet is known but is not an admin
+	review := &types.TokenListingReview{RequestID: r.ID, Status: types.TokenListingApproved}
+	review.Sign(user)
+
+	_, err := s.Review(review)
+	assert.Equal(t, ErrNotAdmin, err)
+
+	// the review claims to come from an admin but is signed by another wallet
+	review.Sign(user)
+	review.Admin = admin.Address
+
+	_, err = s.Review(review)
+	assert.Equal(t, ErrNotAdmin, err)
+
+	listingDao.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	tokenDao.AssertNotCalled(t, "Create", mock.Anything)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTokenResolvesMetadata(t *testing.T) {
+	tokenDao := new(mocks.TokenDao)
+	provider := new(mocks.EthereumProvider)
+	tokenService := NewTokenService(tokenDao, provider)
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	token := &types.Token{Name: "ZRX", ContractAddress: addr}
+
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	tokenDao.On("Create", token).Return(nil)
+	provider.On("TokenMetadata", addr).Return("ZRX", uint8(18), nil)
+
+	err := tokenService.Create(token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "ZRX", token.Symbol)
+	assert.Equal(t, 18, token.Decimal)
+	tokenDao.AssertCalled(t, "Create", token)
+}
+
+func TestCreateTokenMetadataMismatch(t *testing.T) {
+	tokenDao := new(mocks.TokenDao)
+	provider := new(mocks.EthereumProvider)
+	tokenService := NewTokenService(tokenDao, provider)
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	token := &types.Token{Name: "ZRX", Symbol: "ZRX", Decimal: 8, ContractAddress: addr}
+
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	tokenDao.On("Create", token).Return(nil)
+	provider.On("TokenMetadata", addr).Return("ZRX", uint8(18), nil)
+
+	err := tokenService.Create(token, false)
+	assert.Equal(t, ErrTokenMetadataMismatch, err)
+	tokenDao.AssertNotCalled(t, "Create", token)
+
+	err = tokenService.Create(token, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, token.Decimal)
+	tokenDao.AssertCalled(t, "Create", token)
+}
+
+func TestCreateTokenForceUnresolvedMetadata(t *testing.T) {
+	tokenDao := new(mocks.TokenDao)
+	provider := new(mocks.EthereumProvider)
+	tokenService := NewTokenService(tokenDao, provider)
+
+	addr := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	token := &types.Token{Name: "ZRX", Symbol: "ZRX", Decimal: 18, ContractAddress: addr}
+
+	tokenDao.On("GetByAddress", addr).Return(nil, nil)
+	tokenDao.On("Create", token).Return(nil)
+	provider.On("TokenMetadata", addr).Return("", uint8(0), errors.New("no contract code at given address"))
+
+	err := tokenService.Create(token, false)
+	assert.Error(t, err)
+	tokenDao.AssertNotCalled(t, "Create", token)
+
+	// forced tokens are created with the given metadata
+	err = tokenService.Create(token, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "ZRX", token.Symbol)
+	assert.Equal(t, 18, token.Decimal)
+	tokenDao.AssertCalled(t, "Create", token)
+}
@@ -1,6 +1,12 @@
 package services
 
 import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
 	"github.com/Proofsuite/amp-matching-engine/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
@@ -64,14 +70,16 @@ func (s *TradeService) GetTrades(bt, qt common.Address) ([]types.Trade, error) {
 	return s.tradeDao.GetAll()
 }
 
-// GetByPairAddress fetches all the trades corresponding to a pair using pair's token address
-func (s *TradeService) GetByPairAddress(bt, qt common.Address) ([]*types.Trade, error) {
-	return s.tradeDao.GetByPairAddress(bt, qt)
+// GetByPairAddress fetches a page of the trades of a pair using pair's token address, along with
+// the cursor of the next page
+func (s *TradeService) GetByPairAddress(bt, qt common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	return s.tradeDao.GetByPairAddress(bt, qt, cursor, limit)
 }
 
-// GetByUserAddress fetches all the trades corresponding to a user address
-func (s *TradeService) GetByUserAddress(addr common.Address) ([]*types.Trade, error) {
-	return s.tradeDao.GetByUserAddress(addr)
+// GetByUserAddress fetches a page of the trades of a user address, along with the cursor of the
+// next page
+func (s *TradeService) GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	return s.tradeDao.GetByUserAddress(addr, cursor, limit)
 }
 
 // GetByHash fetches all trades corresponding to a trade hash
@@ -79,11 +87,76 @@ func (s *TradeService) GetByHash(hash common.Hash) (*types.Trade, error) {
 	return s.tradeDao.GetByHash(hash)
 }
 
-// GetByOrderHash fetches all trades corresponding to an order hash
+// GetByOrderHash fetches all trades of an order, as maker or taker, by creation time
 func (s *TradeService) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
 	return s.tradeDao.GetByOrderHash(hash)
 }
 
+// GetByOrderHashes fetches all trades of the given orders, as maker or taker, by creation time
+func (s *TradeService) GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error) {
+	return s.tradeDao.GetByOrderHashes(hashes)
+}
+
+// ExportByUserAddress writes the trades of an account created between from (inclusive) and to
+// (exclusive) to w, in creation time order, as CSV rows or JSON lines depending on format (see
+// types.TradeExportHeader and Trade.ExportRecord). The trades are streamed from the database
+// and written as they are read, so that the memory used does not depend on the number of trades.
+// types.ErrInvalidExportFormat is returned before anything is written for unknown formats.
+func (s *TradeService) ExportByUserAddress(w io.Writer, addr common.Address, from, to time.Time, format string) error {
+	var write func(record []string) error
+	var flush func() error
+
+	switch format {
+	case types.TradeExportCSV:
+		cw := csv.NewWriter(w)
+		write = cw.Write
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+
+		err := write(types.TradeExportHeader)
+		if err != nil {
+			return err
+		}
+	case types.TradeExportJSON:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		write = func(record []string) error {
+			line := make(map[string]string, len(record))
+			for i, column := range types.TradeExportHeader {
+				line[column] = record[i]
+			}
+
+			return enc.Encode(line)
+		}
+
+		flush = bw.Flush
+	default:
+		return types.ErrInvalidExportFormat
+	}
+
+	it := s.tradeDao.StreamByAddress(addr, from, to)
+	t := &types.Trade{}
+	for it.Next(t) {
+		err := write(t.ExportRecord(addr))
+		if err != nil {
+			it.Close()
+			return err
+		}
+
+		// trades are decoded in place, fields missing from the next trade must not be kept
+		*t = types.Trade{}
+	}
+
+	err := it.Close()
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
 func (s *TradeService) UpdateTradeTxHash(tr *types.Trade, txHash common.Hash) error {
 	tr.TxHash = txHash
 
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/memdaos"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// generatedTrades is a types.TradeIterator generating n trades of an account on the fly
+type generatedTrades struct {
+	addr common.Address
+	n, i int
+}
+
+func (it *generatedTrades) Next(t *types.Trade) bool {
+	if it.i == it.n {
+		return false
+	}
+
+	*t = types.Trade{
+		Maker:      it.addr,
+		Taker:      common.HexToAddress("0x2"),
+		PairName:   "ZRX/WETH",
+		Side:       types.OrderSideBuy,
+		PricePoint: big.NewInt(int64(10000 + it.i%1000)),
+		Amount:     big.NewInt(int64(1 + it.i%100)),
+		MakeFee:    big.NewInt(1),
+		TakeFee:    big.NewInt(2),
+		TxHash:     common.BigToHash(big.NewInt(int64(it.i + 1))),
+		CreatedAt:  time.Unix(int64(1514764800+it.i), 0),
+	}
+
+	it.i++
+	return true
+}
+
+func (it *generatedTrades) Close() error {
+	return nil
+}
+
+// countingWriter counts the bytes written to it and checks that the heap does not grow with the
+// number of bytes written
+type countingWriter struct {
+	t        *testing.T
+	baseline uint64
+	written  int
+	writes   int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.written += len(p)
+	w.writes++
+	if w.writes%1000 == 0 {
+		stats := runtime.MemStats{}
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > w.baseline+64<<20 {
+			w.t.Fatalf("Heap grew by %v bytes after writing %v bytes", stats.HeapAlloc-w.baseline, w.written)
+		}
+	}
+
+	return len(p), nil
+}
+
+func TestExportByUserAddress(t *testing.T) {
+	tradeDao := memdaos.NewTradeDao()
+	s := NewTradeService(tradeDao)
+
+	maker := common.HexToAddress("0x1")
+	taker := common.HexToAddress("0x2")
+	from := time.Unix(1514764800, 0)
+	to := time.Unix(1514851200, 0)
+
+	trades := []*types.Trade{
+		{
+			Maker:      maker,
+			Taker:      taker,
+			PairName:   `A,"B"/WETH`,
+			Side:       types.OrderSideBuy,
+			PricePoint: big.NewInt(10000),
+			Amount:     big.NewInt(100),
+			MakeFee:    big.NewInt(1),
+			TakeFee:    big.NewInt(2),
+			TxHash:     common.HexToHash("0x3"),
+			TradeNonce: big.NewInt(1),
+			CreatedAt:  time.Date(2018, 1, 1, 10, 0, 0, 0, time.FixedZone("UTC+2", 7200)),
+		},
+		{
+			Maker:      taker,
+			Taker:      maker,
+			PairName:   "ZRX/WETH",
+			Side:       types.OrderSideSell,
+			PricePoint: big.NewInt(20000),
+			Amount:     big.NewInt(50),
+			MakeFee:    big.NewInt(3),
+			TakeFee:    big.NewInt(4),
+			TradeNonce: big.NewInt(2),
+			CreatedAt:  time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	// the stored trades are signed by their taker
+	for _, tr := range trades {
+		err := tr.Sign(types.NewWallet())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an account without trades only has the header row
+	buf := &bytes.Buffer{}
+	err := s.ExportByUserAddress(buf, maker, from, to, types.TradeExportCSV)
+	assert.Nil(t, err)
+	assert.Equal(t, "pair,side,price,amount,fee,txHash,timestamp\n", buf.String())
+
+	// the trades are given back their creation time once stored
+	for _, tr := range trades {
+		createdAt := tr.CreatedAt
+		tradeDao.Create(tr)
+		tr.CreatedAt = createdAt
+		tradeDao.Update(tr)
+	}
+
+	// the side and fee are the ones of the account, fields are escaped and times are in UTC
+	buf.Reset()
+	err = s.ExportByUserAddress(buf, maker, from, to, types.TradeExportCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, [][]string{
+		types.TradeExportHeader,
+		{`A,"B"/WETH`, "SELL", "10000", "100", "1", common.HexToHash("0x3").Hex(), "2018-01-01T08:00:00Z"},
+		{"ZRX/WETH", "SELL", "20000", "50", "4", "", "2018-01-01T12:00:00Z"},
+	}, records)
+
+	// json lines hold one object per trade
+	buf.Reset()
+	err = s.ExportByUserAddress(buf, maker, from, to, types.TradeExportJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []map[string]string{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		line := map[string]string{}
+		err := dec.Decode(&line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, `A,"B"/WETH`, lines[0]["pair"])
+	assert.Equal(t, "SELL", lines[0]["side"])
+	assert.Equal(t, "4", lines[1]["fee"])
+	assert.Equal(t, "2018-01-01T12:00:00Z", lines[1]["timestamp"])
+
+	// unknown formats are rejected before anything is written
+	buf.Reset()
+	err = s.ExportByUserAddress(buf, maker, from, to, "xml")
+	assert.Equal(t, types.ErrInvalidExportFormat, err)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestExportByUserAddressBoundedMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Exports 1M trades")
+	}
+
+	// the trades are not stored in the in-memory DAO, which would hold all of them, but generated
+	// by a mock as they are exported
+	tradeDao := new(mocks.TradeDao)
+	s := NewTradeService(tradeDao)
+	addr := common.HexToAddress("0x1")
+
+	// the trades of an account with 1M trades are generated as they are exported
+	for _, format := range []string{types.TradeExportCSV, types.TradeExportJSON} {
+		tradeDao.On("StreamByAddress", addr, time.Time{}, time.Time{}).Return(&generatedTrades{addr: addr, n: 1000000}).Once()
+
+		runtime.GC()
+		stats := runtime.MemStats{}
+		runtime.ReadMemStats(&stats)
+
+		w := &countingWriter{t: t, baseline: stats.HeapAlloc}
+		err := s.ExportByUserAddress(w, addr, time.Time{}, time.Time{}, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, w.written > 1000000*50)
+	}
+}
+
+// tradeSlice is a types.TradeIterator over a slice of trades
+type tradeSlice struct {
+	trades []*types.Trade
+}
+
+func (it *tradeSlice) Next(t *types.Trade) bool {
+	if len(it.trades) == 0 {
+		return false
+	}
+
+	*t, it.trades = *it.trades[0], it.trades[1:]
+	return true
+}
+
+func (it *tradeSlice) Close() error {
+	return nil
+}
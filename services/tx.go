@@ -26,10 +26,18 @@ func (s *TxService) GetTxDefaultSendOptions() (*bind.TransactOpts, error) {
 		return nil, err
 	}
 
+	if wallet.IsWatchOnly() {
+		return nil, types.ErrNoPrivateKey
+	}
+
 	return bind.NewKeyedTransactor(wallet.PrivateKey), nil
 }
 
 func (s *TxService) GetTxSendOptions() (*bind.TransactOpts, error) {
+	if s.Wallet.IsWatchOnly() {
+		return nil, types.ErrNoPrivateKey
+	}
+
 	return bind.NewKeyedTransactor(s.Wallet.PrivateKey), nil
 }
 
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// OperatorPrivateKeysEnv is the environment variable holding the hex encoded private keys of the
+// operator wallets, separated by commas
+const OperatorPrivateKeysEnv = "OPERATOR_PRIVATE_KEYS"
+
+// OperatorKeystoreFilesEnv is the environment variable holding the paths of the keystore (V3)
+// files of the operator wallets, separated by commas
+const OperatorKeystoreFilesEnv = "OPERATOR_KEYSTORE_FILES"
+
+// OperatorKeystorePassphraseEnv is the environment variable holding the passphrase of the
+// keystore files of OperatorKeystoreFilesEnv
+const OperatorKeystorePassphraseEnv = "OPERATOR_KEYSTORE_PASSPHRASE"
+
+// EnvWalletSource loads the operator wallets from the environment instead of the wallets
+// collection, so that the operator private keys are never stored in the database. It implements
+// interfaces.WalletSource like WalletService.
+type EnvWalletSource struct{}
+
+// NewEnvWalletSource returns a wallet source reading the operator wallets from the
+// OPERATOR_PRIVATE_KEYS and OPERATOR_KEYSTORE_FILES environment variables
+func NewEnvWalletSource() *EnvWalletSource {
+	return &EnvWalletSource{}
+}
+
+// GetOperatorWallets returns the wallets of the private keys of OPERATOR_PRIVATE_KEYS followed by
+// the wallets of the keystore files of OPERATOR_KEYSTORE_FILES, decrypted with
+// OPERATOR_KEYSTORE_PASSPHRASE. It fails if a key is invalid or if there is no wallet at all.
+func (s *EnvWalletSource) GetOperatorWallets() ([]*types.Wallet, error) {
+	wallets := []*types.Wallet{}
+	for _, key := range splitEnvList(os.Getenv(OperatorPrivateKeysEnv)) {
+		w, err := types.NewWalletFromPrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid key in %v: %v", OperatorPrivateKeysEnv, err)
+		}
+
+		wallets = append(wallets, w)
+	}
+
+	passphrase := os.Getenv(OperatorKeystorePassphraseEnv)
+	for _, path := range splitEnvList(os.Getenv(OperatorKeystoreFilesEnv)) {
+		keyjson, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read keystore %v: %v", path, err)
+		}
+
+		w, err := types.NewWalletFromKeystore(keyjson, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid keystore %v: %v", path, err)
+		}
+
+		wallets = append(wallets, w)
+	}
+
+	if len(wallets) == 0 {
+		err := errors.New("No operator wallet in " + OperatorPrivateKeysEnv + " or " + OperatorKeystoreFilesEnv)
+		logger.Error(err)
+		return nil, err
+	}
+
+	for _, w := range wallets {
+		w.Operator = true
+	}
+
+	return wallets, nil
+}
+
+// splitEnvList returns the non-empty trimmed values of a comma separated list
+func splitEnvList(list string) []string {
+	values := []string{}
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvWalletSource(t *testing.T) {
+	w1 := testutils.GetTestWallet1()
+	w2 := testutils.GetTestWallet2()
+	w3 := testutils.GetTestWallet3()
+
+	keyjson, err := w3.ExportKeystore("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, _ := ioutil.TempDir("", "keystore")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "operator.json")
+	err = ioutil.WriteFile(path, keyjson, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(OperatorPrivateKeysEnv, w1.GetPrivateKey()+", 0x"+w2.GetPrivateKey())
+	os.Setenv(OperatorKeystoreFilesEnv, path)
+	os.Setenv(OperatorKeystorePassphraseEnv, "passphrase")
+	defer os.Unsetenv(OperatorPrivateKeysEnv)
+	defer os.Unsetenv(OperatorKeystoreFilesEnv)
+	defer os.Unsetenv(OperatorKeystorePassphraseEnv)
+
+	// the wallets are loaded from the environment without any wallet DAO
+	var source interfaces.WalletSource = NewEnvWalletSource()
+	wallets, err := source.GetOperatorWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, len(wallets))
+	for i, w := range []*types.Wallet{w1, w2, w3} {
+		assert.Equal(t, w.Address, wallets[i].Address)
+		assert.Equal(t, w.GetPrivateKey(), wallets[i].GetPrivateKey())
+		assert.True(t, wallets[i].Operator)
+	}
+
+	// invalid keys and passphrases are rejected
+	os.Setenv(OperatorKeystorePassphraseEnv, "wrong")
+	_, err = source.GetOperatorWallets()
+	assert.Error(t, err)
+
+	os.Setenv(OperatorPrivateKeysEnv, "0x1234")
+	_, err = source.GetOperatorWallets()
+	assert.Error(t, err)
+
+	os.Unsetenv(OperatorPrivateKeysEnv)
+	os.Unsetenv(OperatorKeystoreFilesEnv)
+	_, err = source.GetOperatorWallets()
+	assert.EqualError(t, err, "No operator wallet in OPERATOR_PRIVATE_KEYS or OPERATOR_KEYSTORE_FILES")
+}
+
+func TestDatabaseWalletSource(t *testing.T) {
+	w1 := testutils.GetTestWallet1()
+	walletDao := new(mocks.WalletDao)
+	walletDao.On("GetOperatorWallets").Return([]*types.Wallet{w1}, nil)
+
+	var source interfaces.WalletSource = NewWalletService(walletDao)
+	wallets, err := source.GetOperatorWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*types.Wallet{w1}, wallets)
+}
@@ -1,24 +1,33 @@
 package types
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
 	"time"
 
 	"encoding/json"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-ozzo/ozzo-validation"
 	"gopkg.in/mgo.v2/bson"
 )
 
-// Account corresponds to a single Ethereum address. It contains a list of token balances for that address
+// Account corresponds to a single Ethereum address. It contains a list of token balances for that address.
+// Its methods are not safe for concurrent use: the balances of an account are updated on a copy read by
+// AccountDao.UpdateTokenBalances, which serializes the concurrent updates.
 type Account struct {
 	ID            bson.ObjectId                    `json:"-" bson:"_id"`
 	Address       common.Address                   `json:"address" bson:"address"`
 	TokenBalances map[common.Address]*TokenBalance `json:"tokenBalances" bson:"tokenBalances"`
 	IsBlocked     bool                             `json:"isBlocked" bson:"isBlocked"`
+	OrderNonce    *big.Int                         `json:"orderNonce" bson:"orderNonce"`
 	CreatedAt     time.Time                        `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time                        `json:"updatedAt" bson:"updatedAt"`
+
+	// OpenOrderLimits overrides the default open orders limits for this account when set
+	OpenOrderLimits *OpenOrderLimits `json:"openOrderLimits,omitempty" bson:"openOrderLimits,omitempty"`
 }
 
 // TokenBalance holds the Balance, Allowance and the Locked balance values for a single Ethereum token
@@ -32,14 +41,43 @@ type TokenBalance struct {
 	LockedBalance  *big.Int       `json:"lockedBalance" bson:"lockedBalance"`
 }
 
-// AccountRecord corresponds to what is stored in the DB. big.Ints are encoded as strings
+// AccountRecord corresponds to what is stored in the DB. big.Ints are encoded as strings. The order nonce
+// is encoded as a 32 bytes hex string so that nonces can be compared in database queries.
 type AccountRecord struct {
 	ID            bson.ObjectId                 `json:"id" bson:"_id"`
 	Address       string                        `json:"address" bson:"address"`
 	TokenBalances map[string]TokenBalanceRecord `json:"tokenBalances" bson:"tokenBalances"`
 	IsBlocked     bool                          `json:"isBlocked" bson:"isBlocked"`
+	OrderNonce    string                        `json:"orderNonce,omitempty" bson:"orderNonce,omitempty"`
 	CreatedAt     time.Time                     `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time                     `json:"updatedAt" bson:"updatedAt"`
+
+	OpenOrderLimits *OpenOrderLimits `json:"openOrderLimits,omitempty" bson:"openOrderLimits,omitempty"`
+}
+
+// OpenOrderLimits are the maximum numbers of open orders of an account, over all pairs and on each
+// pair. A zero limit means that the number of orders is not limited.
+type OpenOrderLimits struct {
+	Total   int `json:"total" bson:"total"`
+	PerPair int `json:"perPair" bson:"perPair"`
+}
+
+// OpenOrderCounts holds the numbers of open orders of an account, over all pairs and by pair
+// name, along with the limits that apply to the account
+type OpenOrderCounts struct {
+	Address common.Address  `json:"address"`
+	Total   int             `json:"total"`
+	Pairs   map[string]int  `json:"pairs"`
+	Limits  OpenOrderLimits `json:"limits"`
+}
+
+// Validate checks that the limits are not negative
+func (l OpenOrderLimits) Validate() error {
+	if l.Total < 0 || l.PerPair < 0 {
+		return errors.New("Open order limits must not be negative")
+	}
+
+	return nil
 }
 
 // TokenBalanceRecord corresponds to a TokenBalance struct that is stored in the DB. big.Ints are encoded as strings
@@ -48,7 +86,7 @@ type TokenBalanceRecord struct {
 	Symbol         string `json:"symbol" bson:"symbol"`
 	Balance        string `json:"balance" bson:"balance"`
 	Allowance      string `json:"allowance" bson:"allowance"`
-	PendingBalance string `json:"pendingBalance" base:"pendingBalance"`
+	PendingBalance string `json:"pendingBalance" bson:"pendingBalance"`
 	LockedBalance  string `json:"lockedBalance" bson:"lockedBalance"`
 }
 
@@ -67,11 +105,18 @@ func (a *Account) GetBSON() (interface{}, error) {
 		}
 	}
 
-	return AccountRecord{
-		ID:            a.ID,
-		Address:       a.Address.Hex(),
-		TokenBalances: tokenBalances,
-	}, nil
+	ar := AccountRecord{
+		ID:              a.ID,
+		Address:         a.Address.Hex(),
+		TokenBalances:   tokenBalances,
+		OpenOrderLimits: a.OpenOrderLimits,
+	}
+
+	if a.OrderNonce != nil {
+		ar.OrderNonce = EncodeOrderNonce(a.OrderNonce)
+	}
+
+	return ar, nil
 }
 
 // SetBSON implemenets bson.Setter
@@ -108,7 +153,12 @@ func (a *Account) SetBSON(raw bson.Raw) error {
 	a.Address = common.HexToAddress(decoded.Address)
 	a.ID = decoded.ID
 	a.IsBlocked = decoded.IsBlocked
+	a.OpenOrderLimits = decoded.OpenOrderLimits
 	a.CreatedAt = decoded.CreatedAt
+
+	if decoded.OrderNonce != "" {
+		a.OrderNonce = common.HexToHash(decoded.OrderNonce).Big()
+	}
 	a.UpdatedAt = decoded.UpdatedAt
 
 	return nil
@@ -120,7 +170,7 @@ func (a *Account) SetBSON(raw bson.Raw) error {
 func (a *Account) MarshalJSON() ([]byte, error) {
 	account := map[string]interface{}{
 		"id":        a.ID,
-		"address":   a.Address,
+		"address":   a.Address.Hex(),
 		"isBlocked": a.IsBlocked,
 		"createdAt": a.CreatedAt.String(),
 		"updatedAt": a.UpdatedAt.String(),
@@ -139,6 +189,14 @@ func (a *Account) MarshalJSON() ([]byte, error) {
 		}
 	}
 
+	if a.OrderNonce != nil {
+		account["orderNonce"] = a.OrderNonce.String()
+	}
+
+	if a.OpenOrderLimits != nil {
+		account["openOrderLimits"] = a.OpenOrderLimits
+	}
+
 	account["tokenBalances"] = tokenBalance
 	return json.Marshal(account)
 }
@@ -155,7 +213,14 @@ func (a *Account) UnmarshalJSON(b []byte) error {
 	}
 
 	if account["address"] != nil {
-		a.Address = common.HexToAddress(account["address"].(string))
+		a.Address, err = parseAddress(account["address"])
+		if err != nil {
+			return fmt.Errorf("address: %v", err)
+		}
+	}
+
+	if account["orderNonce"] != nil {
+		a.OrderNonce = math.ToBigInt(account["orderNonce"].(string))
 	}
 
 	if account["tokenBalances"] != nil {
@@ -205,9 +270,156 @@ func (a *Account) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// EncodeOrderNonce encodes an order nonce as stored in the database. Encoded nonces are fixed
+// length hex strings which sort in the same order as the nonces.
+func EncodeOrderNonce(nonce *big.Int) string {
+	return common.BigToHash(nonce).Hex()
+}
+
+// Spendable returns the amount of a token that can still be committed to new orders. It corresponds
+// to the minimum of the balance and allowance minus the locked balance and is never negative.
+func (a *Account) Spendable(token common.Address) *big.Int {
+	return a.spendable(token)
+}
+
+// SetBalanceAndAllowance updates the balance and allowance of a token. The locked balance is left unchanged.
+// It returns a SYNC balance change if the balance moved and nil otherwise.
+func (a *Account) SetBalanceAndAllowance(token common.Address, balance, allowance *big.Int) *BalanceChange {
+	tb := a.tokenBalance(token)
+	delta := math.Sub(balance, tb.Balance)
+	tb.Balance = new(big.Int).Set(balance)
+	tb.Allowance = new(big.Int).Set(allowance)
+
+	if delta.Sign() == 0 {
+		return nil
+	}
+
+	return newBalanceChange(a, token, delta, big.NewInt(0), BalanceChangeSync)
+}
+
+// ErrInsufficientBalance is returned when an amount to lock exceeds the spendable balance
+var ErrInsufficientBalance = errors.New("Insufficient spendable balance")
+
+// Lock reserves an amount of token for an order. It fails with ErrInsufficientBalance if the
+// spendable balance is insufficient.
+func (a *Account) Lock(token common.Address, amount *big.Int) (*BalanceChange, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return nil, errors.New("Invalid amount")
+	}
+
+	if a.spendable(token).Cmp(amount) < 0 {
+		return nil, ErrInsufficientBalance
+	}
+
+	tb := a.tokenBalance(token)
+	tb.LockedBalance = math.Add(tb.LockedBalance, amount)
+	return newBalanceChange(a, token, big.NewInt(0), new(big.Int).Set(amount), BalanceChangeLock), nil
+}
+
+// Unlock releases an amount of token previously reserved with Lock
+func (a *Account) Unlock(token common.Address, amount *big.Int) (*BalanceChange, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return nil, errors.New("Invalid amount")
+	}
+
+	tb := a.tokenBalance(token)
+	if tb.LockedBalance.Cmp(amount) < 0 {
+		return nil, errors.New("Unlock amount exceeds locked balance")
+	}
+
+	tb.LockedBalance = math.Sub(tb.LockedBalance, amount)
+	return newBalanceChange(a, token, big.NewInt(0), math.Neg(amount), BalanceChangeUnlock), nil
+}
+
+// SpendLocked removes an amount of token that was locked for an order that got filled. The amount
+// is deducted from the locked balance, the balance and the allowance.
+func (a *Account) SpendLocked(token common.Address, amount *big.Int) (*BalanceChange, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return nil, errors.New("Invalid amount")
+	}
+
+	tb := a.tokenBalance(token)
+	if tb.LockedBalance.Cmp(amount) < 0 {
+		return nil, errors.New("Spent amount exceeds locked balance")
+	}
+
+	if tb.Balance.Cmp(amount) < 0 || tb.Allowance.Cmp(amount) < 0 {
+		return nil, errors.New("Spent amount exceeds balance or allowance")
+	}
+
+	tb.LockedBalance = math.Sub(tb.LockedBalance, amount)
+	tb.Balance = math.Sub(tb.Balance, amount)
+	tb.Allowance = math.Sub(tb.Allowance, amount)
+	return newBalanceChange(a, token, math.Neg(amount), math.Neg(amount), BalanceChangeFill), nil
+}
+
+// Credit adds an amount of token received from a filled order to the account balance
+func (a *Account) Credit(token common.Address, amount *big.Int) (*BalanceChange, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return nil, errors.New("Invalid amount")
+	}
+
+	tb := a.tokenBalance(token)
+	tb.Balance = math.Add(tb.Balance, amount)
+	return newBalanceChange(a, token, new(big.Int).Set(amount), big.NewInt(0), BalanceChangeFill), nil
+}
+
+func (a *Account) spendable(token common.Address) *big.Int {
+	tb, ok := a.TokenBalances[token]
+	if !ok || tb.Balance == nil || tb.Allowance == nil {
+		return big.NewInt(0)
+	}
+
+	available := tb.Balance
+	if tb.Allowance.Cmp(available) < 0 {
+		available = tb.Allowance
+	}
+
+	if tb.LockedBalance != nil {
+		available = math.Sub(available, tb.LockedBalance)
+	}
+
+	if available.Sign() < 0 {
+		return big.NewInt(0)
+	}
+
+	return available
+}
+
+// tokenBalance returns the balance of token, creating it if needed. It must be called with mu held.
+func (a *Account) tokenBalance(token common.Address) *TokenBalance {
+	if a.TokenBalances == nil {
+		a.TokenBalances = make(map[common.Address]*TokenBalance)
+	}
+
+	tb, ok := a.TokenBalances[token]
+	if !ok {
+		tb = &TokenBalance{Address: token}
+		a.TokenBalances[token] = tb
+	}
+
+	if tb.Balance == nil {
+		tb.Balance = big.NewInt(0)
+	}
+
+	if tb.Allowance == nil {
+		tb.Allowance = big.NewInt(0)
+	}
+
+	if tb.LockedBalance == nil {
+		tb.LockedBalance = big.NewInt(0)
+	}
+
+	if tb.PendingBalance == nil {
+		tb.PendingBalance = big.NewInt(0)
+	}
+
+	return tb
+}
+
 // Validate enforces the account model
-func (a Account) Validate() error {
-	return validation.ValidateStruct(&a,
+func (a *Account) Validate() error {
+	return validation.ValidateStruct(a,
 		validation.Field(&a.Address, validation.Required),
 	)
 }
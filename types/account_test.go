@@ -1,9 +1,11 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
@@ -17,7 +19,6 @@ func TestAccountBSON(t *testing.T) {
 	tokenAddress2 := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
 
 	tokenBalance1 := &TokenBalance{
-		ID:            bson.NewObjectId(),
 		Address:       tokenAddress1,
 		Symbol:        "EOS",
 		Balance:       big.NewInt(10000),
@@ -26,7 +27,6 @@ func TestAccountBSON(t *testing.T) {
 	}
 
 	tokenBalance2 := &TokenBalance{
-		ID:            bson.NewObjectId(),
 		Address:       tokenAddress2,
 		Symbol:        "ZRX",
 		Balance:       big.NewInt(10000),
@@ -56,3 +56,85 @@ func TestAccountBSON(t *testing.T) {
 
 	assert.Equal(decoded, account)
 }
+
+func TestAccountJSON(t *testing.T) {
+	token := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
+
+	account := &Account{
+		Address: common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47"),
+		TokenBalances: map[common.Address]*TokenBalance{
+			token: &TokenBalance{
+				Address:        token,
+				Symbol:         "EOS",
+				Balance:        math.ToBigInt("100000000000000000000000"),
+				Allowance:      big.NewInt(10000),
+				LockedBalance:  big.NewInt(5000),
+				PendingBalance: big.NewInt(0),
+			},
+		},
+	}
+
+	encoded, err := json.Marshal(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Account{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tb := decoded.TokenBalances[token]
+	assert.Equal(t, "EOS", tb.Symbol)
+	assert.Equal(t, "100000000000000000000000", tb.Balance.String())
+	assert.Equal(t, "10000", tb.Allowance.String())
+	assert.Equal(t, "5000", tb.LockedBalance.String())
+	assert.Equal(t, "0", tb.PendingBalance.String())
+}
+
+func TestAccountLock(t *testing.T) {
+	token := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
+	account := &Account{
+		TokenBalances: map[common.Address]*TokenBalance{
+			token: &TokenBalance{
+				Address:       token,
+				Balance:       big.NewInt(1000),
+				Allowance:     big.NewInt(800),
+				LockedBalance: big.NewInt(0),
+			},
+		},
+	}
+
+	assert.Equal(t, "800", account.Spendable(token).String())
+
+	_, err := account.Lock(token, big.NewInt(500))
+	assert.Nil(t, err)
+	assert.Equal(t, "300", account.Spendable(token).String())
+
+	_, err = account.Lock(token, big.NewInt(301))
+	assert.NotNil(t, err)
+	assert.Equal(t, "500", account.TokenBalances[token].LockedBalance.String())
+
+	_, err = account.Unlock(token, big.NewInt(600))
+	assert.NotNil(t, err)
+
+	_, err = account.Unlock(token, big.NewInt(200))
+	assert.Nil(t, err)
+	assert.Equal(t, "500", account.Spendable(token).String())
+
+	_, err = account.SpendLocked(token, big.NewInt(300))
+	assert.Nil(t, err)
+	assert.Equal(t, "700", account.TokenBalances[token].Balance.String())
+	assert.Equal(t, "500", account.TokenBalances[token].Allowance.String())
+	assert.Equal(t, "0", account.TokenBalances[token].LockedBalance.String())
+
+	other := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	assert.Equal(t, "0", account.Spendable(other).String())
+	_, err = account.Lock(other, big.NewInt(1))
+	assert.NotNil(t, err)
+
+	_, err = account.Credit(other, big.NewInt(100))
+	assert.Nil(t, err)
+	assert.Equal(t, "100", account.TokenBalances[other].Balance.String())
+}
@@ -0,0 +1,19 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// parseAddress parses a json value holding a hex encoded address. Mixed-case
+// addresses must carry a valid EIP-55 checksum.
+func parseAddress(v interface{}) (common.Address, error) {
+	s, ok := v.(string)
+	if !ok {
+		return common.Address{}, fmt.Errorf("Invalid address: %v", v)
+	}
+
+	return utils.ParseAddress(s)
+}
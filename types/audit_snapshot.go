@@ -0,0 +1,210 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditSnapshotOrder is an order resting in the orderbook at the time of an audit snapshot
+type AuditSnapshotOrder struct {
+	Hash            common.Hash `json:"hash"`
+	Side            OrderSide   `json:"side"`
+	PricePoint      *big.Int    `json:"pricepoint"`
+	RemainingAmount *big.Int    `json:"remainingAmount"`
+}
+
+// AuditSnapshot records every order resting in the orderbook of a pair at a sequence of the
+// orderbook, hidden orders and the hidden amount of iceberg orders included. The orders are listed
+// by side, bids first, then from the best pricepoint and in the order in which they are matched
+// within their price level. The hash of the snapshot is signed by an operator wallet so that the
+// state of the orderbook at a point in time can be proven later on (see Verify).
+// The timestamp is truncated to the millisecond, which is the precision of the database, so that
+// the hash of a stored snapshot can be computed again.
+type AuditSnapshot struct {
+	ID         bson.ObjectId         `json:"id" bson:"_id"`
+	PairName   string                `json:"pairName" bson:"pairName"`
+	BaseToken  common.Address        `json:"baseToken" bson:"baseToken"`
+	QuoteToken common.Address        `json:"quoteToken" bson:"quoteToken"`
+	Sequence   uint64                `json:"sequence" bson:"sequence"`
+	Timestamp  time.Time             `json:"timestamp" bson:"timestamp"`
+	Orders     []*AuditSnapshotOrder `json:"orders" bson:"orders"`
+	Hash       common.Hash           `json:"hash" bson:"hash"`
+	Signer     common.Address        `json:"signer" bson:"signer"`
+	Signature  *Signature            `json:"signature" bson:"signature"`
+}
+
+// AuditSnapshotOrderRecord is the database representation of an AuditSnapshotOrder
+type AuditSnapshotOrderRecord struct {
+	Hash            string `bson:"hash"`
+	Side            string `bson:"side"`
+	PricePoint      string `bson:"pricepoint"`
+	RemainingAmount string `bson:"remainingAmount"`
+}
+
+// AuditSnapshotRecord is the database representation of an AuditSnapshot
+type AuditSnapshotRecord struct {
+	ID         bson.ObjectId               `bson:"_id"`
+	PairName   string                      `bson:"pairName"`
+	BaseToken  string                      `bson:"baseToken"`
+	QuoteToken string                      `bson:"quoteToken"`
+	Sequence   int64                       `bson:"sequence"`
+	Timestamp  time.Time                   `bson:"timestamp"`
+	Orders     []*AuditSnapshotOrderRecord `bson:"orders"`
+	Hash       string                      `bson:"hash"`
+	Signer     string                      `bson:"signer"`
+	Signature  *Signature                  `bson:"signature,omitempty"`
+}
+
+// ComputeHash returns the hash of the canonical encoding of the snapshot: the pair tokens, the
+// sequence, the timestamp in milliseconds and the number of orders, followed by the hash, the side
+// (0 for bids, 1 for asks), the pricepoint and the remaining amount of each order, every value but
+// the token addresses being encoded as a 32 bytes word.
+func (s *AuditSnapshot) ComputeHash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write(s.BaseToken.Bytes())
+	sha.Write(s.QuoteToken.Bytes())
+	sha.Write(common.BigToHash(new(big.Int).SetUint64(s.Sequence)).Bytes())
+	sha.Write(common.BigToHash(big.NewInt(s.Timestamp.UnixNano() / int64(time.Millisecond))).Bytes())
+	sha.Write(common.BigToHash(big.NewInt(int64(len(s.Orders)))).Bytes())
+
+	for _, o := range s.Orders {
+		side := big.NewInt(0)
+		if o.Side == OrderSideSell {
+			side = big.NewInt(1)
+		}
+
+		sha.Write(o.Hash.Bytes())
+		sha.Write(common.BigToHash(side).Bytes())
+		sha.Write(common.BigToHash(o.PricePoint).Bytes())
+		sha.Write(common.BigToHash(o.RemainingAmount).Bytes())
+	}
+
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// Sign computes the hash of the snapshot, then signs it and sets the signer and the signature
+func (s *AuditSnapshot) Sign(w Signer) error {
+	h := s.ComputeHash()
+	sig, err := w.SignHash(h)
+	if err != nil {
+		return err
+	}
+
+	s.Hash = h
+	s.Signer = w.SignerAddress()
+	s.Signature = sig
+	return nil
+}
+
+// Verify computes the hash of the snapshot again and returns an error if it does not correspond
+// to the stored hash or if the hash was not signed by the signer of the snapshot
+func (s *AuditSnapshot) Verify() error {
+	if s.Signature == nil {
+		return errors.New("Snapshot signature is missing")
+	}
+
+	if s.Hash != s.ComputeHash() {
+		return errors.New("Snapshot hash does not correspond to its content")
+	}
+
+	return s.Signature.Verify(s.Hash, s.Signer)
+}
+
+// MarshalJSON returns the json encoded snapshot. Pricepoints and amounts are encoded as decimal
+// strings.
+func (s *AuditSnapshot) MarshalJSON() ([]byte, error) {
+	orders := []map[string]interface{}{}
+	for _, o := range s.Orders {
+		orders = append(orders, map[string]interface{}{
+			"hash":            o.Hash.Hex(),
+			"side":            o.Side,
+			"pricepoint":      o.PricePoint.String(),
+			"remainingAmount": o.RemainingAmount.String(),
+		})
+	}
+
+	snapshot := map[string]interface{}{
+		"pairName":   s.PairName,
+		"baseToken":  s.BaseToken.Hex(),
+		"quoteToken": s.QuoteToken.Hex(),
+		"sequence":   s.Sequence,
+		"timestamp":  s.Timestamp.Format(time.RFC3339Nano),
+		"orders":     orders,
+		"hash":       s.Hash.Hex(),
+		"signer":     s.Signer.Hex(),
+		"signature":  s.Signature,
+	}
+
+	if s.ID.Valid() {
+		snapshot["id"] = s.ID.Hex()
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// GetBSON implements bson.Getter
+func (s *AuditSnapshot) GetBSON() (interface{}, error) {
+	orders := []*AuditSnapshotOrderRecord{}
+	for _, o := range s.Orders {
+		orders = append(orders, &AuditSnapshotOrderRecord{
+			Hash:            o.Hash.Hex(),
+			Side:            string(o.Side),
+			PricePoint:      o.PricePoint.String(),
+			RemainingAmount: o.RemainingAmount.String(),
+		})
+	}
+
+	record := AuditSnapshotRecord{
+		ID:         s.ID,
+		PairName:   s.PairName,
+		BaseToken:  s.BaseToken.Hex(),
+		QuoteToken: s.QuoteToken.Hex(),
+		Sequence:   int64(s.Sequence),
+		Timestamp:  s.Timestamp,
+		Orders:     orders,
+		Hash:       s.Hash.Hex(),
+		Signer:     s.Signer.Hex(),
+		Signature:  s.Signature,
+	}
+
+	return record, nil
+}
+
+// SetBSON implements bson.Setter
+func (s *AuditSnapshot) SetBSON(raw bson.Raw) error {
+	decoded := &AuditSnapshotRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	s.ID = decoded.ID
+	s.PairName = decoded.PairName
+	s.BaseToken = common.HexToAddress(decoded.BaseToken)
+	s.QuoteToken = common.HexToAddress(decoded.QuoteToken)
+	s.Sequence = uint64(decoded.Sequence)
+	s.Timestamp = decoded.Timestamp
+	s.Hash = common.HexToHash(decoded.Hash)
+	s.Signer = common.HexToAddress(decoded.Signer)
+	s.Signature = decoded.Signature
+
+	s.Orders = []*AuditSnapshotOrder{}
+	for _, o := range decoded.Orders {
+		s.Orders = append(s.Orders, &AuditSnapshotOrder{
+			Hash:            common.HexToHash(o.Hash),
+			Side:            OrderSide(o.Side),
+			PricePoint:      math.ToBigInt(o.PricePoint),
+			RemainingAmount: math.ToBigInt(o.RemainingAmount),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-test/deep"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func newTestAuditSnapshot() *AuditSnapshot {
+	return &AuditSnapshot{
+		PairName:   "ZRX/WETH",
+		BaseToken:  common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5"),
+		QuoteToken: common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47"),
+		Sequence:   42,
+		Timestamp:  time.Unix(1405544146, 0),
+		Orders: []*AuditSnapshotOrder{
+			{
+				Hash:            common.HexToHash("0xa9a89346cc62330626c5853b74493a1f8e933db582c444bf2288bd6a211586ee"),
+				Side:            OrderSideBuy,
+				PricePoint:      big.NewInt(100),
+				RemainingAmount: big.NewInt(1e18),
+			},
+			{
+				Hash:            common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+				Side:            OrderSideSell,
+				PricePoint:      big.NewInt(110),
+				RemainingAmount: big.NewInt(5e17),
+			},
+		},
+	}
+}
+
+func TestAuditSnapshotBSON(t *testing.T) {
+	expected := newTestAuditSnapshot()
+	expected.ID = bson.ObjectIdHex("537f700b537461b70c5f0000")
+	err := expected.Sign(NewWallet())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := bson.Marshal(expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	decoded := &AuditSnapshot{}
+	err = bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if diff := deep.Equal(expected, decoded); diff != nil {
+		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, decoded)
+	}
+
+	if err := decoded.Verify(); err != nil {
+		t.Errorf("Expected decoded snapshot to be valid but got: %v", err)
+	}
+}
+
+func TestVerifyAuditSnapshot(t *testing.T) {
+	operator := NewWallet()
+
+	s := newTestAuditSnapshot()
+	if err := s.Verify(); err == nil {
+		t.Error("Expected unsigned snapshot to be rejected")
+	}
+
+	err := s.Sign(operator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Signer != operator.Address {
+		t.Errorf("Expected signer to be %v but got %v", operator.Address.Hex(), s.Signer.Hex())
+	}
+
+	if err := s.Verify(); err != nil {
+		t.Errorf("Expected snapshot to be valid but got: %v", err)
+	}
+
+	// a modified order is detected
+	s.Orders[1].RemainingAmount = big.NewInt(4e17)
+	if err := s.Verify(); err == nil {
+		t.Error("Expected snapshot with a modified order to be rejected")
+	}
+
+	// a removed order is detected
+	s = newTestAuditSnapshot()
+	s.Sign(operator)
+	s.Orders = s.Orders[:1]
+	if err := s.Verify(); err == nil {
+		t.Error("Expected snapshot with a removed order to be rejected")
+	}
+
+	// a signature of another account is rejected
+	s = newTestAuditSnapshot()
+	s.Sign(operator)
+	s.Signer = NewWallet().Address
+	if err := s.Verify(); err == nil {
+		t.Error("Expected snapshot attributed to another account to be rejected")
+	}
+
+	// the timestamp is hashed to the millisecond
+	s = newTestAuditSnapshot()
+	s.Sign(operator)
+	s.Timestamp = s.Timestamp.Add(time.Microsecond)
+	if err := s.Verify(); err != nil {
+		t.Errorf("Expected snapshot to be valid but got: %v", err)
+	}
+
+	s.Timestamp = s.Timestamp.Add(time.Millisecond)
+	if err := s.Verify(); err == nil {
+		t.Error("Expected snapshot with a modified timestamp to be rejected")
+	}
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Reasons of the balance changes of an account
+const (
+	// BalanceChangeSync records the update of a balance from the token contract
+	BalanceChangeSync = "SYNC"
+	// BalanceChangeLock records the amount locked for a new order
+	BalanceChangeLock = "LOCK"
+	// BalanceChangeUnlock records the amount released when an order is cancelled or expires
+	BalanceChangeUnlock = "UNLOCK"
+	// BalanceChangeFill records the amounts sold and bought when an order is filled
+	BalanceChangeFill = "FILL"
+)
+
+// BalanceChange records the reason why the balance of a token of an account moved. Delta is the
+// change of the token balance and LockedDelta the change of the locked balance, so that the sum
+// of the deltas of all the changes of a token corresponds to the current balances of the account.
+type BalanceChange struct {
+	ID          bson.ObjectId  `json:"id" bson:"_id"`
+	Address     common.Address `json:"address" bson:"address"`
+	Token       common.Address `json:"token" bson:"token"`
+	Delta       *big.Int       `json:"delta" bson:"delta"`
+	LockedDelta *big.Int       `json:"lockedDelta" bson:"lockedDelta"`
+	Reason      string         `json:"reason" bson:"reason"`
+	OrderHash   common.Hash    `json:"orderHash" bson:"orderHash"`
+	TradeHash   common.Hash    `json:"tradeHash" bson:"tradeHash"`
+	Timestamp   time.Time      `json:"timestamp" bson:"timestamp"`
+}
+
+// BalanceChangeRecord is the database representation of a BalanceChange
+type BalanceChangeRecord struct {
+	ID          bson.ObjectId `json:"id" bson:"_id"`
+	Address     string        `json:"address" bson:"address"`
+	Token       string        `json:"token" bson:"token"`
+	Delta       string        `json:"delta" bson:"delta"`
+	LockedDelta string        `json:"lockedDelta" bson:"lockedDelta"`
+	Reason      string        `json:"reason" bson:"reason"`
+	OrderHash   string        `json:"orderHash,omitempty" bson:"orderHash,omitempty"`
+	TradeHash   string        `json:"tradeHash,omitempty" bson:"tradeHash,omitempty"`
+	Timestamp   time.Time     `json:"timestamp" bson:"timestamp"`
+}
+
+// newBalanceChange returns a balance change of the account balances of a token
+func newBalanceChange(a *Account, token common.Address, delta, lockedDelta *big.Int, reason string) *BalanceChange {
+	return &BalanceChange{
+		Address:     a.Address,
+		Token:       token,
+		Delta:       delta,
+		LockedDelta: lockedDelta,
+		Reason:      reason,
+		Timestamp:   time.Now(),
+	}
+}
+
+// MarshalJSON returns the json encoded balance change. Deltas are encoded as decimal strings and
+// the order and trade hashes are omitted when the change is not related to an order or a trade.
+func (c *BalanceChange) MarshalJSON() ([]byte, error) {
+	change := map[string]interface{}{
+		"address":     c.Address.Hex(),
+		"token":       c.Token.Hex(),
+		"delta":       c.Delta.String(),
+		"lockedDelta": c.LockedDelta.String(),
+		"reason":      c.Reason,
+		"timestamp":   c.Timestamp.Format(time.RFC3339Nano),
+	}
+
+	if c.ID.Valid() {
+		change["id"] = c.ID.Hex()
+	}
+
+	if c.OrderHash != (common.Hash{}) {
+		change["orderHash"] = c.OrderHash.Hex()
+	}
+
+	if c.TradeHash != (common.Hash{}) {
+		change["tradeHash"] = c.TradeHash.Hex()
+	}
+
+	return json.Marshal(change)
+}
+
+// GetBSON implements bson.Getter
+func (c *BalanceChange) GetBSON() (interface{}, error) {
+	record := BalanceChangeRecord{
+		ID:          c.ID,
+		Address:     c.Address.Hex(),
+		Token:       c.Token.Hex(),
+		Delta:       c.Delta.String(),
+		LockedDelta: c.LockedDelta.String(),
+		Reason:      c.Reason,
+		Timestamp:   c.Timestamp,
+	}
+
+	if c.OrderHash != (common.Hash{}) {
+		record.OrderHash = c.OrderHash.Hex()
+	}
+
+	if c.TradeHash != (common.Hash{}) {
+		record.TradeHash = c.TradeHash.Hex()
+	}
+
+	return record, nil
+}
+
+// SetBSON implements bson.Setter
+func (c *BalanceChange) SetBSON(raw bson.Raw) error {
+	decoded := &BalanceChangeRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	c.ID = decoded.ID
+	c.Address = common.HexToAddress(decoded.Address)
+	c.Token = common.HexToAddress(decoded.Token)
+	c.Delta = math.ToBigInt(decoded.Delta)
+	c.LockedDelta = math.ToBigInt(decoded.LockedDelta)
+	c.Reason = decoded.Reason
+	c.Timestamp = decoded.Timestamp
+
+	if decoded.OrderHash != "" {
+		c.OrderHash = common.HexToHash(decoded.OrderHash)
+	}
+
+	if decoded.TradeHash != "" {
+		c.TradeHash = common.HexToHash(decoded.TradeHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-test/deep"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestBalanceChangeBSON(t *testing.T) {
+	expected := &BalanceChange{
+		ID:          bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		Address:     common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47"),
+		Token:       common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5"),
+		Delta:       big.NewInt(-300),
+		LockedDelta: big.NewInt(-300),
+		Reason:      BalanceChangeFill,
+		OrderHash:   common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		TradeHash:   common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Timestamp:   time.Unix(1405544146, 0),
+	}
+
+	data, err := bson.Marshal(expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	decoded := &BalanceChange{}
+	err = bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if diff := deep.Equal(expected, decoded); diff != nil {
+		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, decoded)
+	}
+}
+
+func TestAccountBalanceChanges(t *testing.T) {
+	address := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+	token := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
+	account := &Account{Address: address}
+
+	change := account.SetBalanceAndAllowance(token, big.NewInt(1000), big.NewInt(1000))
+	assert.Equal(t, BalanceChangeSync, change.Reason)
+	assert.Equal(t, address, change.Address)
+	assert.Equal(t, token, change.Token)
+	assert.Equal(t, "1000", change.Delta.String())
+	assert.Equal(t, "0", change.LockedDelta.String())
+
+	// an unchanged balance is not recorded
+	assert.Nil(t, account.SetBalanceAndAllowance(token, big.NewInt(1000), big.NewInt(500)))
+
+	change, _ = account.Lock(token, big.NewInt(400))
+	assert.Equal(t, BalanceChangeLock, change.Reason)
+	assert.Equal(t, "0", change.Delta.String())
+	assert.Equal(t, "400", change.LockedDelta.String())
+
+	change, _ = account.Unlock(token, big.NewInt(100))
+	assert.Equal(t, BalanceChangeUnlock, change.Reason)
+	assert.Equal(t, "-100", change.LockedDelta.String())
+
+	change, _ = account.SpendLocked(token, big.NewInt(300))
+	assert.Equal(t, BalanceChangeFill, change.Reason)
+	assert.Equal(t, "-300", change.Delta.String())
+	assert.Equal(t, "-300", change.LockedDelta.String())
+
+	change, _ = account.Credit(token, big.NewInt(50))
+	assert.Equal(t, BalanceChangeFill, change.Reason)
+	assert.Equal(t, "50", change.Delta.String())
+	assert.Equal(t, "0", change.LockedDelta.String())
+
+	// failed mutations do not return any change
+	change, err := account.Lock(token, big.NewInt(1e6))
+	assert.NotNil(t, err)
+	assert.Nil(t, change)
+}
+
+// TestBalanceChangesConsistency applies random mutations to an account and checks that the sum of the
+// recorded deltas of each token always equals the account balances
+func TestBalanceChangesConsistency(t *testing.T) {
+	tokens := []common.Address{
+		common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5"),
+		common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+	}
+
+	account := &Account{Address: common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")}
+	balances := map[common.Address]*big.Int{}
+	locked := map[common.Address]*big.Int{}
+	for _, token := range tokens {
+		balances[token] = big.NewInt(0)
+		locked[token] = big.NewInt(0)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		token := tokens[r.Intn(len(tokens))]
+		amount := big.NewInt(r.Int63n(1000))
+
+		var change *BalanceChange
+		switch r.Intn(5) {
+		case 0:
+			change = account.SetBalanceAndAllowance(token, amount, math.Mul(amount, big.NewInt(2)))
+		case 1:
+			change, _ = account.Lock(token, amount)
+		case 2:
+			change, _ = account.Unlock(token, amount)
+		case 3:
+			change, _ = account.SpendLocked(token, amount)
+		case 4:
+			change, _ = account.Credit(token, amount)
+		}
+
+		if change != nil {
+			balances[token] = math.Add(balances[token], change.Delta)
+			locked[token] = math.Add(locked[token], change.LockedDelta)
+		}
+
+		for _, token := range tokens {
+			tb, ok := account.TokenBalances[token]
+			if !ok {
+				continue
+			}
+
+			if balances[token].Cmp(tb.Balance) != 0 || locked[token].Cmp(tb.LockedBalance) != 0 {
+				t.Fatalf("Ledger (%v, %v) does not match the balances (%v, %v) after %v mutations",
+					balances[token], locked[token], tb.Balance, tb.LockedBalance, i+1)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Integer amounts (token amounts, fees, nonces, expiries) are always encoded as decimal
+// strings, both in json payloads and in database records. Json numbers are accepted on
+// input but are not emitted since javascript clients lose precision past 2^53.
+
+// encodeBigInt returns the decimal string representation of n. A nil value is encoded
+// as an empty string.
+func encodeBigInt(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
+
+// decodeBigInt parses a decimal string into a big.Int. An empty string decodes to nil.
+// Records written before amounts were standardized may hold "<nil>" for unset values.
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" || s == "<nil>" {
+		return nil, nil
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid integer: %v", s)
+	}
+
+	return n, nil
+}
+
+// parseBigInt parses a json value holding either a decimal string or a number into a big.Int
+func parseBigInt(v interface{}) (*big.Int, error) {
+	switch n := v.(type) {
+	case string:
+		return decodeBigInt(n)
+	case json.Number:
+		return decodeBigInt(n.String())
+	case float64:
+		f := big.NewFloat(n)
+		if !f.IsInt() {
+			return nil, fmt.Errorf("Invalid integer: %v", n)
+		}
+
+		i, _ := f.Int(nil)
+		return i, nil
+	default:
+		return nil, fmt.Errorf("Invalid integer: %v", v)
+	}
+}
+
+// unmarshalJSONObject decodes a json object into a map. Numbers are kept as json.Number
+// so that large integers do not lose precision.
+func unmarshalJSONObject(b []byte) (map[string]interface{}, error) {
+	decoded := map[string]interface{}{}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	err := d.Decode(&decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBigInt(t *testing.T) {
+	// 2^64 + 1 does not fit in an int64 nor in a float64 mantissa
+	expected, _ := new(big.Int).SetString("18446744073709551617", 10)
+
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"decimal string", "18446744073709551617"},
+		{"json number", json.Number("18446744073709551617")},
+	}
+
+	for _, test := range tests {
+		n, err := parseBigInt(test.value)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+
+		assert.Equal(t, expected.String(), n.String(), test.name)
+	}
+
+	n, err := parseBigInt(float64(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, "1000", n.String())
+
+	_, err = parseBigInt(1.5)
+	assert.NotNil(t, err)
+
+	_, err = parseBigInt("0x10")
+	assert.NotNil(t, err)
+
+	_, err = parseBigInt(true)
+	assert.NotNil(t, err)
+
+	n, err = decodeBigInt("")
+	assert.Nil(t, err)
+	assert.Nil(t, n)
+	assert.Equal(t, "", encodeBigInt(nil))
+}
+
+func TestUnmarshalJSONObjectKeepsPrecision(t *testing.T) {
+	decoded, err := unmarshalJSONObject([]byte(`{"amount": 18446744073709551617}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := parseBigInt(decoded["amount"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "18446744073709551617", n.String())
+}
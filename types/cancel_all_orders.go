@@ -0,0 +1,150 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+)
+
+// CancelAllOrders is a signed message used by a maker to cancel all of its open orders at
+// once, or only its open orders of a given pair when PairName is set. The Timestamp
+// (in seconds) must be recent for the message to be accepted, which prevents old
+// messages from being replayed. The nonce distinguishes messages sent during the same second.
+type CancelAllOrders struct {
+	Maker     Address    `json:"maker"`
+	PairName  string     `json:"pairName,omitempty"`
+	Timestamp int64      `json:"timestamp"`
+	Nonce     *big.Int   `json:"nonce"`
+	Hash      Hash       `json:"hash"`
+	Signature *Signature `json:"signature"`
+}
+
+// MarshalJSON returns the json encoded byte array representing the CancelAllOrders struct
+func (ca *CancelAllOrders) MarshalJSON() ([]byte, error) {
+	cancelAll := map[string]interface{}{
+		"maker":     ca.Maker,
+		"timestamp": ca.Timestamp,
+		"hash":      ca.Hash,
+	}
+
+	if ca.PairName != "" {
+		cancelAll["pairName"] = ca.PairName
+	}
+
+	if ca.Nonce != nil {
+		cancelAll["nonce"] = ca.Nonce.String()
+	}
+
+	if ca.Signature != nil {
+		cancelAll["signature"] = map[string]interface{}{
+			"V": ca.Signature.V,
+			"R": ca.Signature.R,
+			"S": ca.Signature.S,
+		}
+	}
+
+	return json.Marshal(cancelAll)
+}
+
+// UnmarshalJSON creates a CancelAllOrders object from a json byte string
+func (ca *CancelAllOrders) UnmarshalJSON(b []byte) error {
+	parsed, err := unmarshalJSONObject(b)
+	if err != nil {
+		return err
+	}
+
+	if parsed["maker"] == nil {
+		return errors.New("Maker is missing")
+	}
+	ca.Maker = HexToAddress(parsed["maker"].(string))
+
+	if parsed["hash"] == nil {
+		return errors.New("Hash is missing")
+	}
+	ca.Hash = HexToHash(parsed["hash"].(string))
+
+	if parsed["pairName"] != nil {
+		ca.PairName = parsed["pairName"].(string)
+	}
+
+	if parsed["timestamp"] != nil {
+		timestamp, err := parseBigInt(parsed["timestamp"])
+		if err != nil {
+			return fmt.Errorf("timestamp: %v", err)
+		}
+
+		ca.Timestamp = timestamp.Int64()
+	}
+
+	if parsed["nonce"] != nil {
+		ca.Nonce, err = parseBigInt(parsed["nonce"])
+		if err != nil {
+			return fmt.Errorf("nonce: %v", err)
+		}
+	}
+
+	ca.Signature, err = decodeSignature(parsed["signature"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks that the maker, timestamp, nonce and signature are set and that the
+// hash corresponds to the message content
+func (ca *CancelAllOrders) Validate() error {
+	if ca.Maker == (Address{}) {
+		return errors.New("Maker is missing")
+	}
+
+	if ca.Timestamp <= 0 {
+		return errors.New("Timestamp is missing")
+	}
+
+	if ca.Nonce == nil {
+		return errors.New("Nonce is missing")
+	}
+
+	if ca.Signature == nil {
+		return errors.New("Signature is missing")
+	}
+
+	if ca.Hash != ca.ComputeHash() {
+		return errors.New("Invalid cancel all orders hash")
+	}
+
+	return nil
+}
+
+// IsRecent returns true if the message timestamp is less than window away from now
+func (ca *CancelAllOrders) IsRecent(now time.Time, window time.Duration) bool {
+	age := now.Sub(time.Unix(ca.Timestamp, 0))
+	return age <= window && age >= -window
+}
+
+// VerifySignature returns an error if the message was not signed by its maker
+func (ca *CancelAllOrders) VerifySignature() error {
+	return ca.Signature.Verify(ca.Hash, ca.Maker)
+}
+
+// ComputeHash computes the hash of a cancel all orders message. The hash commits to the
+// maker address, the pair name (empty for all pairs), the timestamp and the nonce.
+func (ca *CancelAllOrders) ComputeHash() Hash {
+	nonce := Hash{}
+	if ca.Nonce != nil {
+		nonce = BigToHash(ca.Nonce)
+	}
+
+	sha := sha3.NewKeccak256()
+	sha.Write(ca.Maker.Bytes())
+	sha.Write([]byte(ca.PairName))
+	sha.Write(BigToHash(big.NewInt(ca.Timestamp)).Bytes())
+	sha.Write(nonce.Bytes())
+	return BytesToHash(sha.Sum(nil))
+}
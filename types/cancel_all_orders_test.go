@@ -0,0 +1,102 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-test/deep"
+)
+
+func TestCancelAllOrdersJSON(t *testing.T) {
+	expected := &CancelAllOrders{
+		Maker:     common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		PairName:  "ZRX/WETH",
+		Timestamp: 1405544146,
+		Nonce:     big.NewInt(1000),
+		Hash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+	}
+
+	encoded, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Error encoding cancel all orders message: %v", err)
+	}
+
+	ca := &CancelAllOrders{}
+	err = json.Unmarshal(encoded, &ca)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	if diff := deep.Equal(expected, ca); diff != nil {
+		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, ca)
+	}
+}
+
+func TestSignCancelAll(t *testing.T) {
+	maker := NewWallet()
+
+	ca := &CancelAllOrders{Timestamp: time.Now().Unix(), Nonce: big.NewInt(1)}
+	err := maker.SignCancelAll(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ca.Maker != maker.Address {
+		t.Errorf("Expected maker to be %v but got %v", maker.Address.Hex(), ca.Maker.Hex())
+	}
+
+	if err := ca.Validate(); err != nil {
+		t.Errorf("Expected cancel all orders message to be valid but got: %v", err)
+	}
+
+	if err := ca.VerifySignature(); err != nil {
+		t.Errorf("Expected signature to correspond to the maker but got: %v", err)
+	}
+
+	// a message signed by another account for the maker is rejected
+	other := NewWallet()
+	sig, err := other.SignHash(ca.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca.Signature = sig
+	if err := ca.VerifySignature(); err == nil {
+		t.Error("Expected signature by another account to be rejected")
+	}
+
+	ca.PairName = "ZRX/WETH"
+	if err := ca.Validate(); err == nil {
+		t.Error("Expected cancel all orders message with a modified pair name to be invalid")
+	}
+}
+
+func TestCancelAllOrdersIsRecent(t *testing.T) {
+	now := time.Unix(1405544146, 0)
+
+	testCases := []struct {
+		timestamp int64
+		expected  bool
+	}{
+		{now.Unix(), true},
+		{now.Unix() - 30, true},
+		{now.Unix() - 31, false},
+		{now.Unix() + 30, true},
+		{now.Unix() + 31, false},
+	}
+
+	for _, tc := range testCases {
+		ca := &CancelAllOrders{Timestamp: tc.timestamp}
+		if ca.IsRecent(now, 30*time.Second) != tc.expected {
+			t.Errorf("Expected IsRecent to be %v for timestamp %v", tc.expected, tc.timestamp)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package types
+
+// Change operations
+const (
+	ChangeInsert = "insert"
+	ChangeUpdate = "update"
+)
+
+// ChangeEvent is an insert or an update of an order or a trade observed in the database, whichever
+// process wrote it. Exactly one of Order and Trade is set, holding the document as stored after the
+// change.
+type ChangeEvent struct {
+	Operation string
+	Order     *Order
+	Trade     *Trade
+}
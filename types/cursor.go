@@ -0,0 +1,46 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrInvalidCursor is returned for pagination cursors that were not returned by the API
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// Cursor is the position of the last record of a page in a listing sorted from the most recent
+// record, by creation time and then by id. The next page starts after the cursor, so that records
+// created while a listing is paginated are neither skipped nor repeated. Cursors are sent to the
+// clients as opaque strings (see Encode).
+type Cursor struct {
+	CreatedAt time.Time
+	ID        bson.ObjectId
+}
+
+// Encode returns the cursor as an url-safe string. The creation time is encoded in milliseconds,
+// the precision at which it is stored.
+func (c *Cursor) Encode() string {
+	b := make([]byte, 8, 8+len(c.ID))
+	binary.BigEndian.PutUint64(b, uint64(c.CreatedAt.UnixNano()/int64(time.Millisecond)))
+	b = append(b, c.ID...)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decodes a cursor encoded by Cursor.Encode. ErrInvalidCursor is returned for any
+// other string.
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != 20 {
+		return nil, ErrInvalidCursor
+	}
+
+	ms := int64(binary.BigEndian.Uint64(b[:8]))
+	return &Cursor{
+		CreatedAt: time.Unix(0, ms*int64(time.Millisecond)),
+		ID:        bson.ObjectId(b[8:]),
+	}, nil
+}
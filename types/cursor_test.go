@@ -0,0 +1,30 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestCursor(t *testing.T) {
+	c := &Cursor{
+		CreatedAt: time.Unix(1405544146, 123*int64(time.Millisecond)),
+		ID:        bson.ObjectIdHex("537f700b537461b70c5f0000"),
+	}
+
+	decoded, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, c.ID, decoded.ID)
+
+	_, err = DecodeCursor("invalid")
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, err = DecodeCursor("")
+	assert.Equal(t, ErrInvalidCursor, err)
+}
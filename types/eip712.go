@@ -0,0 +1,111 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+)
+
+// Signature schemes that can be used to sign an order. Orders signed with the
+// personal sign scheme (default) sign the keccak hash of the order prefixed with the
+// "Ethereum Signed Message" string. Orders signed with the EIP712 scheme sign the
+// typed data hash of the order (https://eips.ethereum.org/EIPS/eip-712)
+const (
+	PersonalSignScheme = "PERSONAL_SIGN"
+	EIP712SignScheme   = "EIP712"
+)
+
+// ParseSignatureScheme returns the signature scheme corresponding to s regardless of its case. An
+// empty string corresponds to the personal sign scheme.
+func ParseSignatureScheme(s string) (string, error) {
+	scheme := strings.ToUpper(s)
+	switch scheme {
+	case "", PersonalSignScheme, EIP712SignScheme:
+		return scheme, nil
+	default:
+		return "", fmt.Errorf("Invalid signature scheme: %v", s)
+	}
+}
+
+// parseSignatureScheme parses a decoded json value into a signature scheme
+func parseSignatureScheme(v interface{}) (string, error) {
+	s, err := parseString(v)
+	if err != nil {
+		return "", err
+	}
+
+	return ParseSignatureScheme(s)
+}
+
+var (
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte(
+		"EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)",
+	))
+
+	eip712OrderTypeHash = crypto.Keccak256Hash([]byte(
+		"Order(address exchangeAddress,address userAddress,address sellToken,address buyToken," +
+			"uint256 sellAmount,uint256 buyAmount,uint256 makeFee,uint256 takeFee,uint256 expires,uint256 nonce)",
+	))
+)
+
+// TypedDataDomain is the EIP712 domain that binds a typed data signature to
+// a given exchange contract and chain
+type TypedDataDomain struct {
+	Name              string
+	Version           string
+	ChainID           *big.Int
+	VerifyingContract common.Address
+}
+
+// NewTypedDataDomain returns the default exchange EIP712 domain for the given
+// exchange contract address and chain ID
+func NewTypedDataDomain(exchange common.Address, chainID *big.Int) TypedDataDomain {
+	return TypedDataDomain{
+		Name:              "AMP Exchange",
+		Version:           "1",
+		ChainID:           chainID,
+		VerifyingContract: exchange,
+	}
+}
+
+// Hash returns the EIP712 domain separator
+func (d TypedDataDomain) Hash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write(eip712DomainTypeHash.Bytes())
+	sha.Write(crypto.Keccak256([]byte(d.Name)))
+	sha.Write(crypto.Keccak256([]byte(d.Version)))
+	sha.Write(common.BigToHash(d.ChainID).Bytes())
+	sha.Write(common.BytesToHash(d.VerifyingContract.Bytes()).Bytes())
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// ComputeEIP712StructHash returns the EIP712 struct hash of the order
+func (o *Order) ComputeEIP712StructHash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write(eip712OrderTypeHash.Bytes())
+	sha.Write(common.BytesToHash(o.ExchangeAddress.Bytes()).Bytes())
+	sha.Write(common.BytesToHash(o.UserAddress.Bytes()).Bytes())
+	sha.Write(common.BytesToHash(o.SellToken.Bytes()).Bytes())
+	sha.Write(common.BytesToHash(o.BuyToken.Bytes()).Bytes())
+	sha.Write(common.BigToHash(o.SellAmount).Bytes())
+	sha.Write(common.BigToHash(o.BuyAmount).Bytes())
+	sha.Write(common.BigToHash(o.MakeFee).Bytes())
+	sha.Write(common.BigToHash(o.TakeFee).Bytes())
+	sha.Write(common.BigToHash(o.Expires).Bytes())
+	sha.Write(common.BigToHash(o.Nonce).Bytes())
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// ComputeEIP712Hash returns the EIP712 typed data hash of the order for the given
+// domain. This is the hash that is signed by eth_signTypedData
+func (o *Order) ComputeEIP712Hash(domain TypedDataDomain) common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write([]byte("\x19\x01"))
+	sha.Write(domain.Hash().Bytes())
+	sha.Write(o.ComputeEIP712StructHash().Bytes())
+	return common.BytesToHash(sha.Sum(nil))
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedDataDomainHash(t *testing.T) {
+	// domain separator of the reference EIP712 "Ether Mail" example
+	domain := TypedDataDomain{
+		Name:              "Ether Mail",
+		Version:           "1",
+		ChainID:           big.NewInt(1),
+		VerifyingContract: common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"),
+	}
+
+	expected := common.HexToHash("0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f")
+	assert.Equal(t, expected, domain.Hash())
+}
+
+func TestSignOrderEIP712(t *testing.T) {
+	w, err := NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exchange := common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485")
+	domain := NewTypedDataDomain(exchange, big.NewInt(1))
+
+	o := &Order{
+		ExchangeAddress: exchange,
+		UserAddress:     w.Address,
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		MakeFee:         big.NewInt(50),
+		TakeFee:         big.NewInt(50),
+		Expires:         big.NewInt(10000),
+		Nonce:           big.NewInt(1000),
+	}
+
+	// expected values computed with eth_signTypedData_v4
+	expectedHash := common.HexToHash("0x163be7d92329e70924b8b84a7db665c01fbbd1fbeff626fd77d00b823bf05491")
+	expectedSignature := &Signature{
+		V: 27,
+		R: common.HexToHash("0x05abefa5b3c944f779aadd8e254f83e97acc4f8d7033f4fca678bf7cb6504ebf"),
+		S: common.HexToHash("0x6952640ed3dfa0768c79de89a7080c00444d887538c6b76f43b80d200f13405d"),
+	}
+
+	assert.Equal(t, expectedHash, o.ComputeEIP712Hash(domain))
+
+	err = w.SignOrderEIP712(o, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, EIP712SignScheme, o.SignatureScheme)
+	assert.Equal(t, o.ComputeHash(), o.Hash)
+
+	// orders of unknown schemes are not verified as personal sign orders
+	unknown := *o
+	unknown.SignatureScheme = "ETH_SIGN"
+	_, err = unknown.VerifySignature()
+	assert.Error(t, err)
+	assert.Equal(t, expectedSignature, o.Signature)
+
+	signer, err := o.Signature.RecoverRaw(expectedHash)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, w.Address, signer)
+}
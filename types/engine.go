@@ -1,6 +1,87 @@
 package types
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Reasons of the cancellation of the unfilled amount of an order by the engine
+const (
+	// CancelReasonIOC is used for the amount of IOC orders that could not be matched immediately
+	CancelReasonIOC = "IOC_UNFILLED"
+	// CancelReasonFOK is used for FOK orders that could not be filled entirely by the orderbook
+	CancelReasonFOK = "FOK_INSUFFICIENT_DEPTH"
+	// CancelReasonInsufficientFunds is used for resting orders that the balance or the allowance
+	// of their maker can no longer cover
+	CancelReasonInsufficientFunds = "INSUFFICIENT_FUNDS"
+	// CancelReasonCircuitBreaker is used for the amount of orders whose matching was halted by the
+	// circuit breaker of the pair because it moved the price too far
+	CancelReasonCircuitBreaker = "CIRCUIT_BREAKER"
+	// CancelReasonAuction is used for IOC and FOK orders received during the opening auction of
+	// their pair, when orders are not matched
+	CancelReasonAuction = "AUCTION"
+)
+
+// Execution price policies, the pricepoint at which an incoming order trades with a resting order
+// it crosses
+const (
+	// ExecutionPriceMaker trades at the pricepoint of the resting order, the price improvement
+	// going to the incoming order
+	ExecutionPriceMaker = "MAKER_PRICE"
+	// ExecutionPriceMidpoint trades halfway between the pricepoints of the two orders, rounded in
+	// favor of the incoming order
+	ExecutionPriceMidpoint = "MIDPOINT"
+)
+
+// Reasons of the rejection of an order by the engine
+const (
+	// RejectReasonPostOnly is used for post-only orders that would have been matched
+	RejectReasonPostOnly = "POST_ONLY_WOULD_CROSS"
+	// RejectReasonEngineBusy is used for orders submitted while the order queue of their orderbook
+	// was full
+	RejectReasonEngineBusy = "ENGINE_BUSY"
+)
+
+// ErrOrderNotResting is returned for queries about orders that are not resting in the orderbook,
+// eg. because they were filled or cancelled
+var ErrOrderNotResting = errors.New("Order is not resting in the orderbook")
+
+// ErrOrderBookNotEmpty is returned when the opening auction of a pair is started while its
+// orderbook already holds orders
+var ErrOrderBookNotEmpty = errors.New("Orderbook is not empty")
+
+// ErrReduceAmountNotLower is returned when an order is reduced to an amount that is not lower than
+// its unfilled amount. Increasing an order requires a new order.
+var ErrReduceAmountNotLower = errors.New("New amount must be lower than the unfilled amount of the order")
+
+// ErrEngineBusy is returned when new orders are submitted while the order queue of their orderbook
+// is full. The orders are not matched and can be submitted again later.
+var ErrEngineBusy = errors.New("Engine is busy")
+
+// ErrAuctionClosing is returned when an order is previewed after the end of the opening auction of
+// its pair but before the auction is closed by the next new order
+var ErrAuctionClosing = errors.New("Opening auction is closing")
+
+// OrderPosition is the position of a resting order in the queue of its price level, 1 for the
+// first order of the level, and the amount displayed by the orders ahead of it. It is advisory:
+// the queue changes as soon as the orderbook does.
+type OrderPosition struct {
+	Position    int      `json:"position"`
+	AmountAhead *big.Int `json:"amountAhead"`
+}
+
+// ToAPI returns the API representation of the position, with the amount ahead of the order as a
+// decimal string
+func (p *OrderPosition) ToAPI() map[string]interface{} {
+	return map[string]interface{}{
+		"position":    p.Position,
+		"amountAhead": p.AmountAhead.String(),
+	}
+}
 
 type OrderTradePair struct {
 	Order *Order
@@ -13,4 +94,120 @@ type EngineResponse struct {
 	Order          *Order            `json:"order,omitempty"`
 	RemainingOrder *Order            `json:"remainingOrder,omitempty"`
 	Matches        []*OrderTradePair `json:"matches,omitempty"`
+
+	// CancelledAmount is the unfilled amount of an IOC or FOK order cancelled by the engine
+	// instead of being added to the orderbook
+	CancelledAmount *big.Int `json:"cancelledAmount,omitempty"`
+	CancelReason    string   `json:"cancelReason,omitempty"`
+
+	// RejectReason is the reason why the order was rejected by the engine without being matched
+	RejectReason string `json:"rejectReason,omitempty"`
+
+	// Position is the position of the order in its price level when it rests in the orderbook
+	Position *OrderPosition `json:"position,omitempty"`
+}
+
+// Clone returns a deep copy of the engine response, including its orders and trades
+func (res *EngineResponse) Clone() *EngineResponse {
+	c := *res
+	if res.Order != nil {
+		c.Order = res.Order.Clone()
+	}
+
+	if res.RemainingOrder != nil {
+		c.RemainingOrder = res.RemainingOrder.Clone()
+	}
+
+	if res.CancelledAmount != nil {
+		c.CancelledAmount = new(big.Int).Set(res.CancelledAmount)
+	}
+
+	if res.Position != nil {
+		c.Position = &OrderPosition{res.Position.Position, new(big.Int).Set(res.Position.AmountAhead)}
+	}
+
+	if res.Matches != nil {
+		c.Matches = make([]*OrderTradePair, len(res.Matches))
+		for i, m := range res.Matches {
+			c.Matches[i] = &OrderTradePair{}
+			if m.Order != nil {
+				c.Matches[i].Order = m.Order.Clone()
+			}
+
+			if m.Trade != nil {
+				c.Matches[i].Trade = m.Trade.Clone()
+			}
+		}
+	}
+
+	return &c
+}
+
+// EngineStats holds the metrics of the orderbooks of the engine keyed by pair name
+type EngineStats struct {
+	Pairs map[string]*PairStats `json:"pairs"`
+}
+
+// PairStats holds the metrics of the orderbook of a pair. The depths are the total remaining
+// amounts of the orders of each side of the orderbook and the level counts the number of price
+// levels of each side. QueueLength is the number of orders and other commands waiting to be run
+// by the orderbook, CancelQueueLength the number of cancellations, and Rejects counts the orders
+// rejected by the engine by reason.
+type PairStats struct {
+	PairName          string
+	BidDepth          *big.Int
+	AskDepth          *big.Int
+	BidLevels         int
+	AskLevels         int
+	QueueLength       int
+	CancelQueueLength int
+	MatchLatency      *LatencyHistogram
+	Rejects           map[string]uint64
+}
+
+// LatencyHistogram is a cumulative histogram of durations. The count of a bucket is the number
+// of durations lower or equal to its upper bound, the durations above the last bound are only
+// included in the total count.
+type LatencyHistogram struct {
+	Buckets []LatencyBucket
+	Count   uint64
+	Sum     time.Duration
+}
+
+// LatencyBucket is a bucket of a latency histogram
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// MarshalJSON returns the json encoded pair metrics. Amounts are encoded as decimal strings.
+func (s *PairStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"pairName":          s.PairName,
+		"bidDepth":          encodeBigInt(s.BidDepth),
+		"askDepth":          encodeBigInt(s.AskDepth),
+		"bidLevels":         s.BidLevels,
+		"askLevels":         s.AskLevels,
+		"queueLength":       s.QueueLength,
+		"cancelQueueLength": s.CancelQueueLength,
+		"matchLatency":      s.MatchLatency,
+		"rejects":           s.Rejects,
+	})
+}
+
+// MarshalJSON returns the json encoded histogram. Durations are encoded in seconds.
+func (h *LatencyHistogram) MarshalJSON() ([]byte, error) {
+	buckets := []map[string]interface{}{}
+	for _, b := range h.Buckets {
+		buckets = append(buckets, map[string]interface{}{
+			"upperBound": b.UpperBound.Seconds(),
+			"count":      b.Count,
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"buckets": buckets,
+		"count":   h.Count,
+		"sum":     h.Sum.Seconds(),
+	})
 }
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEngineResponse() *EngineResponse {
+	taker := newValidTestOrder()
+	taker.Amount = big.NewInt(100)
+	taker.FilledAmount = big.NewInt(0)
+	taker.PricePoint = big.NewInt(1000)
+
+	maker := newValidTestOrder()
+	maker.Amount = big.NewInt(100)
+	maker.FilledAmount = big.NewInt(0)
+	maker.PricePoint = big.NewInt(1000)
+
+	trade := &Trade{
+		Maker:      maker.UserAddress,
+		Taker:      taker.UserAddress,
+		BaseToken:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		Amount:     big.NewInt(10),
+		PricePoint: big.NewInt(1000),
+		TradeNonce: big.NewInt(0),
+		Signature:  &Signature{},
+	}
+
+	return &EngineResponse{
+		Status:  "PARTIAL",
+		Order:   taker,
+		Matches: []*OrderTradePair{{maker, trade}},
+	}
+}
+
+func TestEngineResponseClone(t *testing.T) {
+	res := newTestEngineResponse()
+
+	c := res.Clone()
+	assert.Equal(t, res, c)
+	assert.False(t, res.Order == c.Order)
+	assert.False(t, res.Matches[0].Order == c.Matches[0].Order)
+	assert.False(t, res.Matches[0].Trade == c.Matches[0].Trade)
+	assert.Nil(t, c.RemainingOrder)
+}
+
+// TestEngineResponseCloneConcurrentMarshal updates the filled amounts and statuses of the matched
+// orders the way the engine does while the clones are being serialized. It is meant to be run
+// with the race detector (go test -race).
+func TestEngineResponseCloneConcurrentMarshal(t *testing.T) {
+	res := newTestEngineResponse()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		c := res.Clone()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := json.Marshal(c)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+
+		for _, m := range res.Matches {
+			m.Order.FilledAmount.Add(m.Order.FilledAmount, m.Trade.Amount)
+			m.Order.Status = OrderStatusPartialFilled
+			m.Trade.Amount.Add(m.Trade.Amount, big.NewInt(1))
+		}
+
+		res.Order.FilledAmount.Add(res.Order.FilledAmount, big.NewInt(10))
+		res.Order.Status = OrderStatusPartialFilled
+	}
+
+	wg.Wait()
+	assert.Equal(t, big.NewInt(145), res.Matches[0].Order.FilledAmount)
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// feeRateDenominator is the number of basis points in 100%
+var feeRateDenominator = big.NewInt(10000)
+
+// FeeSchedule holds the trading fees of a pair. Fee rates are expressed in basis points
+// of the traded amount. MinMakeFee and MinTakeFee are the smallest fees an order can declare.
+type FeeSchedule struct {
+	MakeFeeRate int64
+	TakeFeeRate int64
+	MinMakeFee  *big.Int
+	MinTakeFee  *big.Int
+}
+
+// ComputeFees returns the fees charged to the maker and the taker of a trade matched against
+// the order o. Fees are rounded up so that rounding always favors the exchange, but they never
+// exceed the traded amount nor the fees declared (and signed) in the order.
+func ComputeFees(o *Order, t *Trade, schedule FeeSchedule) (makerFee, takerFee *big.Int) {
+	makerFee = computeFee(t.Amount, schedule.MakeFeeRate, o.MakeFee)
+	takerFee = computeFee(t.Amount, schedule.TakeFeeRate, o.TakeFee)
+	return makerFee, takerFee
+}
+
+func computeFee(amount *big.Int, rate int64, declared *big.Int) *big.Int {
+	if amount == nil || amount.Sign() <= 0 || rate <= 0 {
+		return big.NewInt(0)
+	}
+
+	// ceil(amount * rate / 10000)
+	fee := math.DivideRoundUp(math.Mul(amount, big.NewInt(rate)), feeRateDenominator)
+
+	if fee.Cmp(amount) > 0 {
+		fee = new(big.Int).Set(amount)
+	}
+
+	if declared != nil && fee.Cmp(declared) > 0 {
+		fee = new(big.Int).Set(declared)
+	}
+
+	return fee
+}
@@ -0,0 +1,59 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeFees(t *testing.T) {
+	schedule := FeeSchedule{MakeFeeRate: 10, TakeFeeRate: 25}
+	o := &Order{MakeFee: big.NewInt(1e18), TakeFee: big.NewInt(1e18)}
+
+	testCases := []struct {
+		name     string
+		amount   *big.Int
+		makerFee *big.Int
+		takerFee *big.Int
+	}{
+		{"exact fees", big.NewInt(1e6), big.NewInt(1000), big.NewInt(2500)},
+		{"fees rounded up", big.NewInt(1001), big.NewInt(2), big.NewInt(3)},
+		{"tiny fill", big.NewInt(1), big.NewInt(1), big.NewInt(1)},
+		{"empty fill", big.NewInt(0), big.NewInt(0), big.NewInt(0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			makerFee, takerFee := ComputeFees(o, &Trade{Amount: tc.amount}, schedule)
+			assert.Equal(t, tc.makerFee, makerFee)
+			assert.Equal(t, tc.takerFee, takerFee)
+		})
+	}
+}
+
+func TestComputeFeesCappedAtTradedAmount(t *testing.T) {
+	schedule := FeeSchedule{MakeFeeRate: 20000, TakeFeeRate: 10000}
+	o := &Order{MakeFee: big.NewInt(1e18), TakeFee: big.NewInt(1e18)}
+
+	makerFee, takerFee := ComputeFees(o, &Trade{Amount: big.NewInt(3)}, schedule)
+	assert.Equal(t, big.NewInt(3), makerFee)
+	assert.Equal(t, big.NewInt(3), takerFee)
+}
+
+func TestComputeFeesCappedAtDeclaredFees(t *testing.T) {
+	schedule := FeeSchedule{MakeFeeRate: 10, TakeFeeRate: 25}
+	o := &Order{MakeFee: big.NewInt(0), TakeFee: big.NewInt(100)}
+
+	makerFee, takerFee := ComputeFees(o, &Trade{Amount: big.NewInt(1e6)}, schedule)
+	assert.Equal(t, big.NewInt(0), makerFee)
+	assert.Equal(t, big.NewInt(100), takerFee)
+}
+
+func TestComputeFeesWithoutRates(t *testing.T) {
+	o := &Order{MakeFee: big.NewInt(50), TakeFee: big.NewInt(50)}
+
+	makerFee, takerFee := ComputeFees(o, &Trade{Amount: big.NewInt(1)}, FeeSchedule{})
+	assert.Equal(t, big.NewInt(0), makerFee)
+	assert.Equal(t, big.NewInt(0), takerFee)
+}
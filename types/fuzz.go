@@ -0,0 +1,26 @@
+//go:build gofuzz
+// +build gofuzz
+
+package types
+
+import "encoding/json"
+
+// Fuzz is the entry point for go-fuzz (https://github.com/dvyukov/go-fuzz). It checks that
+// decoding arbitrary order payloads never panics and that decoded orders can be re-encoded.
+//
+//	go-fuzz-build github.com/Proofsuite/amp-matching-engine/types
+//	go-fuzz -bin=types-fuzz.zip -workdir=fuzz
+func Fuzz(data []byte) int {
+	o := &Order{}
+	err := json.Unmarshal(data, o)
+	if err != nil {
+		return 0
+	}
+
+	_, err = json.Marshal(o)
+	if err != nil {
+		panic(err)
+	}
+
+	return 1
+}
@@ -0,0 +1,176 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// The expected hashes below are the keccak256 hashes of the order and trade fields packed as in
+// the exchange smart contract. They must never be updated to make a failing test pass: a change
+// in the packing makes every executeTrade transaction revert with an invalid signature.
+
+func bigIntFromString(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+var goldenOrders = []struct {
+	name  string
+	order *Order
+	hash  string
+}{
+	{
+		"small amounts",
+		&Order{
+			ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+			UserAddress:     common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+			BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+			SellAmount:      big.NewInt(100),
+			BuyAmount:       big.NewInt(1000),
+			MakeFee:         big.NewInt(50),
+			TakeFee:         big.NewInt(50),
+			Expires:         big.NewInt(1541514187),
+			Nonce:           big.NewInt(1000),
+		},
+		"0x2d747818f77a7eee44f6fd4252fd204206016dc40122f3efcd4316eee7f61851",
+	},
+	{
+		"large amounts without fees and expiry",
+		&Order{
+			ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+			UserAddress:     common.HexToAddress("0x28074f8d0fd78629cd59290cac185611a8d60109"),
+			SellToken:       common.HexToAddress("0x2034842261b82651885751fc293bba7ba5398156"),
+			BuyToken:        common.HexToAddress("0x276e16ada4b107332afd776691a7fbbaede168ef"),
+			SellAmount:      bigIntFromString("1000000000000000000000000"),
+			BuyAmount:       bigIntFromString("25000000000000000000000"),
+			MakeFee:         big.NewInt(0),
+			TakeFee:         big.NewInt(0),
+			Expires:         big.NewInt(0),
+			Nonce:           bigIntFromString("9007199254740993"),
+		},
+		"0x36f317656a07c8facbcf0d34ddacfec2167edd8f7b856db2082b1b44c3802b68",
+	},
+}
+
+var goldenTrades = []struct {
+	name  string
+	trade *Trade
+	hash  string
+}{
+	{
+		"small amount",
+		&Trade{
+			OrderHash:  common.HexToHash("0x2d747818f77a7eee44f6fd4252fd204206016dc40122f3efcd4316eee7f61851"),
+			Taker:      common.HexToAddress("0x28074f8d0fd78629cd59290cac185611a8d60109"),
+			Amount:     big.NewInt(100),
+			TradeNonce: big.NewInt(0),
+		},
+		"0xf1057f1cf494557368574a410520e2e85797046935f2d61d76a33c1a60b489fa",
+	},
+	{
+		"large amount",
+		&Trade{
+			OrderHash:  common.HexToHash("0x36f317656a07c8facbcf0d34ddacfec2167edd8f7b856db2082b1b44c3802b68"),
+			Taker:      common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			Amount:     bigIntFromString("1000000000000000000000000"),
+			TradeNonce: big.NewInt(42),
+		},
+		"0xea717757e63c0f30f30ca5f2618da9f0fadfa9f4ee6f6bda089ec85165c18cf2",
+	},
+	{
+		"small amount with fees",
+		&Trade{
+			OrderHash:   common.HexToHash("0x2d747818f77a7eee44f6fd4252fd204206016dc40122f3efcd4316eee7f61851"),
+			Taker:       common.HexToAddress("0x28074f8d0fd78629cd59290cac185611a8d60109"),
+			Amount:      big.NewInt(100),
+			TradeNonce:  big.NewInt(0),
+			MakeFee:     big.NewInt(50),
+			TakeFee:     big.NewInt(25),
+			HashVersion: TradeHashV1,
+		},
+		"0x7cd23e4f1780dff2f28ac2fce76ca5efaf343152caba669c876ab29cbf758445",
+	},
+	{
+		"large amount without fees",
+		&Trade{
+			OrderHash:   common.HexToHash("0x36f317656a07c8facbcf0d34ddacfec2167edd8f7b856db2082b1b44c3802b68"),
+			Taker:       common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			Amount:      bigIntFromString("1000000000000000000000000"),
+			TradeNonce:  big.NewInt(42),
+			HashVersion: TradeHashV1,
+		},
+		"0xa4902467539af2feffd842401541e2bf455d84baea47b6bb710879d83e6d9b13",
+	},
+	{
+		"large amount with large fees",
+		&Trade{
+			OrderHash:   common.HexToHash("0x36f317656a07c8facbcf0d34ddacfec2167edd8f7b856db2082b1b44c3802b68"),
+			Taker:       common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			Amount:      bigIntFromString("1000000000000000000000000"),
+			TradeNonce:  big.NewInt(42),
+			MakeFee:     bigIntFromString("2500000000000000000000"),
+			TakeFee:     bigIntFromString("2500000000000000000000"),
+			HashVersion: TradeHashV1,
+		},
+		"0x76a44ecdc2bee71a5848bbdb177b636e0cddb063ae26d028f70801a440c4cc28",
+	},
+}
+
+func TestOrderComputeHashGolden(t *testing.T) {
+	for _, tc := range goldenOrders {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.hash, tc.order.ComputeHash().Hex())
+		})
+	}
+}
+
+func TestTradeComputeHashGolden(t *testing.T) {
+	for _, tc := range goldenTrades {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.hash, tc.trade.ComputeHash().Hex())
+		})
+	}
+}
+
+func TestOrderEncodedBytes(t *testing.T) {
+	expected := "0x" +
+		"ae55690d4b079460e6ac28aaa58c9ec7b73a7485" +
+		"7a9f3cd060ab180f36c17fe6bdf9974f577d77aa" +
+		"12459c951127e0c374ff9105dda097662a027093" +
+		"e41d2489571d322189246dafa5ebde1f4699f498" +
+		"0000000000000000000000000000000000000000000000000000000000000064" +
+		"00000000000000000000000000000000000000000000000000000000000003e8" +
+		"0000000000000000000000000000000000000000000000000000000000000032" +
+		"0000000000000000000000000000000000000000000000000000000000000032" +
+		"000000000000000000000000000000000000000000000000000000005be1a3cb" +
+		"00000000000000000000000000000000000000000000000000000000000003e8"
+
+	assert.Equal(t, expected, hexutil.Encode(goldenOrders[0].order.EncodedBytes()))
+}
+
+func TestTradeEncodedBytes(t *testing.T) {
+	expected := "0x" +
+		"2d747818f77a7eee44f6fd4252fd204206016dc40122f3efcd4316eee7f61851" +
+		"28074f8d0fd78629cd59290cac185611a8d60109" +
+		"0000000000000000000000000000000000000000000000000000000000000064" +
+		"0000000000000000000000000000000000000000000000000000000000000000"
+
+	assert.Equal(t, expected, hexutil.Encode(goldenTrades[0].trade.EncodedBytes()))
+}
+
+func TestTradeEncodedBytesWithFees(t *testing.T) {
+	expected := "0x" +
+		"2d747818f77a7eee44f6fd4252fd204206016dc40122f3efcd4316eee7f61851" +
+		"28074f8d0fd78629cd59290cac185611a8d60109" +
+		"0000000000000000000000000000000000000000000000000000000000000064" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000032" +
+		"0000000000000000000000000000000000000000000000000000000000000019"
+
+	assert.Equal(t, expected, hexutil.Encode(goldenTrades[2].trade.EncodedBytes()))
+}
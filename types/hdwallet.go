@@ -0,0 +1,163 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// hardenedKeyStart is the index of the first hardened child key (BIP-32)
+const hardenedKeyStart = 0x80000000
+
+// DefaultDerivationPath is the BIP-44 ethereum derivation path used by MetaMask and Ganache.
+// The last (non-hardened) component is the account index.
+var DefaultDerivationPath = []uint32{
+	hardenedKeyStart + 44,
+	hardenedKeyStart + 60,
+	hardenedKeyStart + 0,
+	0,
+}
+
+// NewWalletsFromMnemonic returns the first count wallets derived from the given BIP-39 mnemonic
+// along the m/44'/60'/0'/0/i path
+func NewWalletsFromMnemonic(mnemonic string, count int) ([]*Wallet, error) {
+	if count <= 0 {
+		return nil, errors.New("Wallet count should be positive")
+	}
+
+	return NewWalletsFromMnemonicRange(mnemonic, 0, count-1)
+}
+
+// NewWalletsFromMnemonicRange returns the wallets derived from the given BIP-39 mnemonic
+// along the m/44'/60'/0'/0/i path for each index i between start and end (included)
+func NewWalletsFromMnemonicRange(mnemonic string, start, end int) ([]*Wallet, error) {
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("Invalid derivation index range: %v-%v", start, end)
+	}
+
+	seed, err := mnemonicToSeed(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	master, chainCode := newMasterKey(seed)
+	account, accountChainCode, err := deriveKeyPath(master, chainCode, DefaultDerivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := []*Wallet{}
+	for i := start; i <= end; i++ {
+		key, _, err := deriveChildKey(account, accountChainCode, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+
+		privateKey, err := crypto.ToECDSA(paddedBytes(key))
+		if err != nil {
+			return nil, err
+		}
+
+		wallets = append(wallets, &Wallet{
+			Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+			PrivateKey: privateKey,
+		})
+	}
+
+	return wallets, nil
+}
+
+// mnemonicToSeed computes the BIP-39 seed of a mnemonic (with an empty passphrase).
+// The mnemonic checksum is not verified.
+func mnemonicToSeed(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("Mnemonic should contain 12, 15, 18, 21 or 24 words but got %v", len(words))
+	}
+
+	normalized := strings.Join(words, " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"), 2048, 64, sha512.New), nil
+}
+
+// newMasterKey returns the BIP-32 master private key and chain code of a seed
+func newMasterKey(seed []byte) (*big.Int, []byte) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	I := mac.Sum(nil)
+
+	return new(big.Int).SetBytes(I[:32]), I[32:]
+}
+
+func deriveKeyPath(key *big.Int, chainCode []byte, path []uint32) (*big.Int, []byte, error) {
+	var err error
+
+	for _, index := range path {
+		key, chainCode, err = deriveChildKey(key, chainCode, index)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return key, chainCode, nil
+}
+
+// deriveChildKey computes the BIP-32 child private key of a parent private key
+func deriveChildKey(key *big.Int, chainCode []byte, index uint32) (*big.Int, []byte, error) {
+	data := []byte{}
+	if index >= hardenedKeyStart {
+		data = append(data, 0)
+		data = append(data, paddedBytes(key)...)
+	} else {
+		data = append(data, compressedPubkey(key)...)
+	}
+
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	data = append(data, indexBytes...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	I := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(I[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, nil, errors.New("Invalid derived key")
+	}
+
+	child := new(big.Int).Add(il, key)
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, errors.New("Invalid derived key")
+	}
+
+	return child, I[32:], nil
+}
+
+func compressedPubkey(key *big.Int) []byte {
+	x, y := crypto.S256().ScalarBaseMult(paddedBytes(key))
+
+	prefix := byte(0x02)
+	if y.Bit(0) == 1 {
+		prefix = 0x03
+	}
+
+	return append([]byte{prefix}, paddedBytes(x)...)
+}
+
+func paddedBytes(n *big.Int) []byte {
+	b := make([]byte, 32)
+	nb := n.Bytes()
+	copy(b[32-len(nb):], nb)
+	return b
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// Ganache deterministic accounts (ganache-cli -d)
+func TestNewWalletsFromMnemonic(t *testing.T) {
+	mnemonic := "myth like bonus scare over problem client lizard pioneer submit female collect"
+
+	expected := []struct {
+		address    string
+		privateKey string
+	}{
+		{"0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1", "4f3edf983ac636a65a842ce7c78d9aa706d3b113bce9c46f30d7d21715b23b1d"},
+		{"0xFFcf8FDEE72ac11b5c542428B35EEF5769C409f0", "6cbed15c793ce57650b9877cf6fa156fbef513c4e6134f022a85b1ffdd59b2a1"},
+		{"0x22d491Bde2303f2f43325b2108D26f1eAbA1e32b", "6370fd033278c143179d81c5526140625662b8daa446c22ee2d73db3707e620c"},
+	}
+
+	wallets, err := NewWalletsFromMnemonic(mnemonic, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, len(wallets))
+	for i, w := range wallets {
+		assert.Equal(t, common.HexToAddress(expected[i].address), w.Address)
+		assert.Equal(t, expected[i].privateKey, hex.EncodeToString(w.PrivateKey.D.Bytes()))
+		assert.Nil(t, w.Validate())
+	}
+
+	wallets, err = NewWalletsFromMnemonicRange(mnemonic, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(wallets))
+	assert.Equal(t, common.HexToAddress(expected[1].address), wallets[0].Address)
+	assert.Equal(t, common.HexToAddress(expected[2].address), wallets[1].Address)
+}
+
+func TestNewWalletsFromMnemonicErrors(t *testing.T) {
+	mnemonic := "myth like bonus scare over problem client lizard pioneer submit female collect"
+
+	_, err := NewWalletsFromMnemonic(mnemonic, 0)
+	assert.Error(t, err)
+
+	_, err = NewWalletsFromMnemonicRange(mnemonic, 2, 1)
+	assert.Error(t, err)
+
+	_, err = NewWalletsFromMnemonic("myth like bonus scare", 1)
+	assert.Error(t, err)
+}
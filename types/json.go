@@ -0,0 +1,77 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// parseString parses a json value holding a string
+func parseString(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("Invalid string: %v", v)
+	}
+
+	return s, nil
+}
+
+// parseBool parses a json value holding a boolean
+func parseBool(v interface{}) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("Invalid boolean: %v", v)
+	}
+
+	return b, nil
+}
+
+// parseTime parses a json value holding a RFC3339 timestamp
+func parseTime(v interface{}) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Invalid time: %v", v)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time: %v", s)
+	}
+
+	return t, nil
+}
+
+// parseObjectID parses a json value holding a hex encoded mongo object id
+func parseObjectID(v interface{}) (bson.ObjectId, error) {
+	s, ok := v.(string)
+	if !ok || !bson.IsObjectIdHex(s) {
+		return "", fmt.Errorf("Invalid id: %v", v)
+	}
+
+	return bson.ObjectIdHex(s), nil
+}
+
+// parseHash parses a json value holding a hex encoded 32 bytes hash. Unlike common.HexToHash,
+// values that are not exactly 32 bytes of hex are rejected instead of being truncated or padded.
+func parseHash(v interface{}) (common.Hash, error) {
+	s, ok := v.(string)
+	if !ok {
+		return common.Hash{}, fmt.Errorf("Invalid hash: %v", v)
+	}
+
+	h := s
+	if strings.HasPrefix(h, "0x") || strings.HasPrefix(h, "0X") {
+		h = h[2:]
+	}
+
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("Invalid hash: %v", s)
+	}
+
+	return common.BytesToHash(b), nil
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+// PreviewFill is a trade that a previewed order would make with a resting order of the orderbook.
+// The resting order is not disclosed.
+type PreviewFill struct {
+	PricePoint *big.Int
+	Amount     *big.Int
+	Fee        *big.Int
+}
+
+// MatchPreview is the execution of an order as previewed by the engine against the current state
+// of the orderbook, without the order being signed nor the orderbook being changed. Status is the
+// status of the engine response the order would get, eg. FULL, PARTIAL or NOMATCH. Rests is true
+// if the unfilled amount of the order would be added to the orderbook. AveragePricePoint is the
+// average pricepoint of the fills weighted by their amounts, rounded down, and is nil if the
+// order would not be matched. Sequence is the sequence of the orderbook the order was previewed
+// against.
+type MatchPreview struct {
+	PairName          string
+	Status            string
+	Fills             []*PreviewFill
+	FilledAmount      *big.Int
+	AveragePricePoint *big.Int
+	RemainingAmount   *big.Int
+	Rests             bool
+	CancelReason      string
+	RejectReason      string
+	Sequence          uint64
+}
+
+// MarshalJSON returns the json encoded preview. Pricepoints and amounts are encoded as decimal
+// strings.
+func (p *MatchPreview) MarshalJSON() ([]byte, error) {
+	fills := []map[string]interface{}{}
+	for _, f := range p.Fills {
+		fills = append(fills, map[string]interface{}{
+			"pricepoint": f.PricePoint.String(),
+			"amount":     f.Amount.String(),
+			"fee":        f.Fee.String(),
+		})
+	}
+
+	preview := map[string]interface{}{
+		"pairName":        p.PairName,
+		"status":          p.Status,
+		"fills":           fills,
+		"filledAmount":    p.FilledAmount.String(),
+		"remainingAmount": p.RemainingAmount.String(),
+		"rests":           p.Rests,
+		"sequence":        p.Sequence,
+	}
+
+	if p.AveragePricePoint != nil {
+		preview["averagePricePoint"] = p.AveragePricePoint.String()
+	}
+
+	if p.CancelReason != "" {
+		preview["cancelReason"] = p.CancelReason
+	}
+
+	if p.RejectReason != "" {
+		preview["rejectReason"] = p.RejectReason
+	}
+
+	return json.Marshal(preview)
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MatchResult holds the writes of a match of a taker order against one or more maker orders:
+// the taker and maker orders with the amounts filled by the match, and the trades between them.
+// It is recorded before its writes are applied and removed once they are all applied, so that a
+// match interrupted by a crash can be completed (see daos.MatchDao).
+type MatchResult struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	Taker     *Order        `json:"taker" bson:"taker"`
+	Makers    []*Order      `json:"makers" bson:"makers"`
+	Trades    []*Trade      `json:"trades" bson:"trades"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+}
+
+// NewMatchResult returns the match result of an engine response whose trades were signed by the
+// taker
+func NewMatchResult(res *EngineResponse, trades []*Trade) *MatchResult {
+	makers := []*Order{}
+	for _, m := range res.Matches {
+		makers = append(makers, m.Order)
+	}
+
+	return &MatchResult{
+		Taker:  res.Order,
+		Makers: makers,
+		Trades: trades,
+	}
+}
@@ -4,27 +4,53 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/mgo.v2/bson"
-	"math/big"
 )
 
-// Tick is the format in which mongo aggregate pipeline returns data when queried for OHLCV data
+// TickUnits are the units in which the duration of a tick can be expressed
+var TickUnits = []string{"sec", "min", "hour", "day", "week", "month", "year"}
+
+// tickUnitSeconds is the length of the fixed-length tick units. Months and years do
+// not have a fixed length and are bucketed by calendar month and year.
+var tickUnitSeconds = map[string]int64{
+	"sec":  1,
+	"min":  60,
+	"hour": 60 * 60,
+	"day":  24 * 60 * 60,
+	"week": 7 * 24 * 60 * 60,
+}
+
+// weekOffset is the number of seconds between the unix epoch (a thursday) and the first
+// monday following it, so that weekly ticks start on mondays like ISO weeks.
+const weekOffset = 4 * 24 * 60 * 60
+
+// Tick is an OHLCV candle of a pair. The timestamp is the start of the tick interval in
+// milliseconds and the interval length is the duration multiplied by the unit. Intervals
+// without any trade have no tick. The prices are pricepoints, the volume is in base token
+// units and the quote volume in quote token units.
 type Tick struct {
-	ID    TickID   `json:"_id,omitempty" bson:"_id"`
-	C     *big.Int `json:"c" bson:"c"`
-	Count *big.Int `json:"count" bson:"count"`
-	H     *big.Int `json:"h" bson:"h"`
-	L     *big.Int `json:"l" bson:"l"`
-	O     *big.Int `json:"o" bson:"o"`
-	Ts    int64    `json:"ts" bson:"ts"`
-	V     *big.Int `json:"v" bson:"v"`
+	Pair        PairID   `json:"pair" bson:"pair"`
+	Open        *big.Int `json:"open" bson:"open"`
+	High        *big.Int `json:"high" bson:"high"`
+	Low         *big.Int `json:"low" bson:"low"`
+	Close       *big.Int `json:"close" bson:"close"`
+	Volume      *big.Int `json:"volume" bson:"volume"`
+	QuoteVolume *big.Int `json:"quoteVolume" bson:"quoteVolume"`
+	Count       *big.Int `json:"count" bson:"count"`
+	Timestamp   int64    `json:"timestamp" bson:"timestamp"`
+	Duration    int64    `json:"duration" bson:"duration"`
+	Unit        string   `json:"unit" bson:"unit"`
 }
 
-// TickID is the subdocument for aggregate grouping for OHLCV data
-type TickID struct {
-	Pair       string         `json:"pair" bson:"pair"`
+// PairID is the subdocument for aggregate grouping for OHLCV data
+type PairID struct {
+	PairName   string         `json:"pairName" bson:"pairName"`
 	BaseToken  common.Address `json:"baseToken" bson:"baseToken"`
 	QuoteToken common.Address `json:"quoteToken" bson:"quoteToken"`
 }
@@ -37,196 +63,280 @@ type TickRequest struct {
 	Units    string       `json:"units"`
 }
 
-// MarshalJSON returns the json encoded byte array representing the trade struct
-func (t *Tick) MarshalJSON() ([]byte, error) {
+// ValidateTickInterval checks that the unit is a known tick unit and that the duration is positive
+func ValidateTickInterval(duration int64, unit string) error {
+	if duration <= 0 {
+		return fmt.Errorf("Invalid duration: %v", duration)
+	}
 
-	tick := map[string]interface{}{
-		"_id": map[string]interface{}{
-			"pair":       t.ID.Pair,
-			"baseToken":  t.ID.BaseToken.Hex(),
-			"quoteToken": t.ID.BaseToken.Hex(),
-		},
-		"ts": t.Ts,
-		"o":  t.O.String(),
-		"h":  t.H.String(),
-		"l":  t.L.String(),
-		"c":  t.C.String(),
-		"v":          t.V.String(),
-		"count": t.Count.String(),
-	}
-	tab, err := json.Marshal(tick)
-	return tab, err
+	for _, u := range TickUnits {
+		if u == unit {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Invalid unit: %v", unit)
 }
 
-// UnmarshalJSON creates a trade object from a json byte string
-func (t *Tick) UnmarshalJSON(b []byte) error {
-	tick := map[string]interface{}{}
-	err := json.Unmarshal(b, &tick)
+// TickStart returns the start of the tick interval of the given duration and unit containing t.
+// Fixed-length intervals are aligned on the unix epoch (on the first monday following it for
+// weeks), month and year intervals are aligned on january 1970. All intervals are in UTC.
+func TickStart(t time.Time, duration int64, unit string) time.Time {
+	t = t.UTC()
 
-	if err != nil {
-		return err
-	}
-	fmt.Print(tick)
-	t.ID = TickID{}
-	if tick["_id"] != nil {
-		id := tick["_id"].(map[string]interface{})
-		if id["quoteToken"] == nil {
-			return errors.New("Quote token is not set")
-		} else {
-			t.ID.QuoteToken = common.HexToAddress(id["quoteToken"].(string))
-		}
+	switch unit {
+	case "month":
+		months := int64(t.Year()-1970)*12 + int64(t.Month()-1)
+		months -= mod(months, duration)
+		return time.Date(1970+int(months/12), time.Month(months%12+1), 1, 0, 0, 0, 0, time.UTC)
 
-		if id["baseToken"] == nil {
-			return errors.New("Base token is not set")
-		} else {
-			t.ID.BaseToken = common.HexToAddress(id["baseToken"].(string))
-		}
+	case "year":
+		years := int64(t.Year() - 1970)
+		years -= mod(years, duration)
+		return time.Date(1970+int(years), 1, 1, 0, 0, 0, 0, time.UTC)
 
-		if id["pair"] == nil {
-			return errors.New("Pair is not set")
-		} else {
-			t.ID.Pair = id["pair"].(string)
+	default:
+		offset := int64(0)
+		if unit == "week" {
+			offset = weekOffset
 		}
-	}
 
-	if tick["ts"] == nil {
-		return errors.New("ts is not set")
-	} else {
-		t.Ts = int64(tick["ts"].(float64))
+		interval := int64(TickLength(duration, unit) / time.Second)
+		ts := t.Unix()
+		return time.Unix(ts-mod(ts-offset, interval), 0).UTC()
 	}
-	t.O = new(big.Int)
-	t.H = new(big.Int)
-	t.L = new(big.Int)
-	t.C = new(big.Int)
-	t.V = new(big.Int)
-	t.Count = new(big.Int)
+}
 
-	if tick["o"] != nil {
-		t.O.UnmarshalJSON([]byte(fmt.Sprintf("%v", tick["o"])))
-	}
-	if tick["h"] != nil {
-		t.H.UnmarshalJSON([]byte(fmt.Sprintf("%v", tick["h"])))
-	}
-	if tick["l"] != nil {
-		t.L.UnmarshalJSON([]byte(fmt.Sprintf("%v", tick["l"])))
-	}
-	if tick["c"] != nil {
-		t.C.UnmarshalJSON([]byte(fmt.Sprintf("%v", tick["c"])))
-	}
-	if tick["v"] != nil {
-		t.V.UnmarshalJSON([]byte(fmt.Sprintf("%v", tick["v"])))
+// TickLength returns the length of tick intervals of the given duration and unit. Month and
+// year intervals do not have a fixed length and have a zero length.
+func TickLength(duration int64, unit string) time.Duration {
+	return time.Duration(duration*tickUnitSeconds[unit]) * time.Second
+}
+
+// ComputeTicks buckets trades into ticks of the given duration and unit in memory, like the OHLCV
+// aggregate pipeline of the trade DAO. The trades must be sorted by creation time. The ticks are
+// sorted by timestamp and then by pair, and their quote volume is the sum of the amounts of the
+// trades multiplied by their pricepoints (see Tick.SetBSON).
+func ComputeTicks(trades []*Trade, duration int64, unit string) []*Tick {
+	type key struct {
+		pair      PairID
+		timestamp int64
 	}
-	if tick["count"] != nil {
-		t.Count.UnmarshalJSON([]byte(fmt.Sprintf("%v", tick["count"])))
+
+	ticks := []*Tick{}
+	byKey := map[key]*Tick{}
+	for _, tr := range trades {
+		k := key{
+			pair:      PairID{PairName: tr.PairName, BaseToken: tr.BaseToken, QuoteToken: tr.QuoteToken},
+			timestamp: TickStart(tr.CreatedAt, duration, unit).Unix() * 1000,
+		}
+
+		t, ok := byKey[k]
+		if !ok {
+			t = &Tick{
+				Pair:        k.pair,
+				Open:        tr.PricePoint,
+				High:        tr.PricePoint,
+				Low:         tr.PricePoint,
+				Volume:      big.NewInt(0),
+				QuoteVolume: big.NewInt(0),
+				Count:       big.NewInt(0),
+				Timestamp:   k.timestamp,
+				Duration:    duration,
+				Unit:        unit,
+			}
+
+			byKey[k] = t
+			ticks = append(ticks, t)
+		}
+
+		t.High = math.Max(t.High, tr.PricePoint)
+		t.Low = math.Min(t.Low, tr.PricePoint)
+		t.Close = tr.PricePoint
+		t.Volume = math.Add(t.Volume, tr.Amount)
+		t.QuoteVolume = math.Add(t.QuoteVolume, math.Mul(tr.Amount, tr.PricePoint))
+		t.Count = math.Add(t.Count, big.NewInt(1))
 	}
-	return nil
+
+	sort.SliceStable(ticks, func(i, j int) bool {
+		a, b := ticks[i], ticks[j]
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp < b.Timestamp
+		}
+
+		if a.Pair.BaseToken != b.Pair.BaseToken {
+			return a.Pair.BaseToken.Hex() < b.Pair.BaseToken.Hex()
+		}
+
+		return a.Pair.QuoteToken.Hex() < b.Pair.QuoteToken.Hex()
+	})
+
+	return ticks
 }
 
-func (t *Tick) GetBSON() (interface{}, error) {
-	fmt.Println("CAME HERE.. hopefully can be done")
-	type TID struct {
-		Pair       string `json:"pair" bson:"pair"`
-		BaseToken  string `json:"baseToken" bson:"baseToken"`
-		QuoteToken string `json:"quoteToken" bson:"quoteToken"`
+// mod returns the non-negative remainder of a divided by b
+func mod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
 	}
 
-	count, err := bson.ParseDecimal128(t.Count.String())
-	if err != nil {
-		return nil, err
+	return m
+}
+
+// MarshalJSON returns the json encoded tick. Prices, volumes and count are encoded as decimal strings.
+func (t *Tick) MarshalJSON() ([]byte, error) {
+	tick := map[string]interface{}{
+		"pair": map[string]interface{}{
+			"pairName":   t.Pair.PairName,
+			"baseToken":  t.Pair.BaseToken.Hex(),
+			"quoteToken": t.Pair.QuoteToken.Hex(),
+		},
+		"timestamp":   t.Timestamp,
+		"duration":    t.Duration,
+		"unit":        t.Unit,
+		"open":        encodeBigInt(t.Open),
+		"high":        encodeBigInt(t.High),
+		"low":         encodeBigInt(t.Low),
+		"close":       encodeBigInt(t.Close),
+		"volume":      encodeBigInt(t.Volume),
+		"quoteVolume": encodeBigInt(t.QuoteVolume),
+		"count":       encodeBigInt(t.Count),
 	}
-	o, err := bson.ParseDecimal128(t.O.String())
+
+	return json.Marshal(tick)
+}
+
+// UnmarshalJSON creates a tick from a json byte string
+func (t *Tick) UnmarshalJSON(b []byte) error {
+	decoded := struct {
+		Pair *struct {
+			PairName   string `json:"pairName"`
+			BaseToken  string `json:"baseToken"`
+			QuoteToken string `json:"quoteToken"`
+		} `json:"pair"`
+		Timestamp   *int64 `json:"timestamp"`
+		Duration    int64  `json:"duration"`
+		Unit        string `json:"unit"`
+		Open        string `json:"open"`
+		High        string `json:"high"`
+		Low         string `json:"low"`
+		Close       string `json:"close"`
+		Volume      string `json:"volume"`
+		QuoteVolume string `json:"quoteVolume"`
+		Count       string `json:"count"`
+	}{}
+
+	err := json.Unmarshal(b, &decoded)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	h, err := bson.ParseDecimal128(t.H.String())
-	if err != nil {
-		return nil, err
+
+	if decoded.Pair == nil {
+		return errors.New("Pair is not set")
 	}
-	l, err := bson.ParseDecimal128(t.L.String())
-	if err != nil {
-		return nil, err
+
+	if decoded.Timestamp == nil {
+		return errors.New("Timestamp is not set")
 	}
-	c, err := bson.ParseDecimal128(t.C.String())
-	if err != nil {
-		return nil, err
+
+	t.Pair = PairID{
+		PairName:   decoded.Pair.PairName,
+		BaseToken:  common.HexToAddress(decoded.Pair.BaseToken),
+		QuoteToken: common.HexToAddress(decoded.Pair.QuoteToken),
 	}
-	v, err := bson.ParseDecimal128(t.V.String())
-	if err != nil {
-		return nil, err
-	}
-
-	return struct {
-		ID    TID             `json:"_id,omitempty" bson:"_id"`
-		Count bson.Decimal128 `json:"count" bson:"count"`
-		O     bson.Decimal128 `json:"o" bson:"o"`
-		H     bson.Decimal128 `json:"h" bson:"h"`
-		L     bson.Decimal128 `json:"l" bson:"l"`
-		C     bson.Decimal128 `json:"c" bson:"c"`
-		V     bson.Decimal128 `json:"v" bson:"v"`
-		Ts    int64           `json:"ts" bson:"ts"`
+
+	t.Timestamp = *decoded.Timestamp
+	t.Duration = decoded.Duration
+	t.Unit = decoded.Unit
+
+	fields := map[string]struct {
+		value string
+		dest  **big.Int
 	}{
-		ID: TID{
-			t.ID.Pair,
-			t.ID.BaseToken.Hex(),
-			t.ID.QuoteToken.Hex(),
-		},
+		"open":        {decoded.Open, &t.Open},
+		"high":        {decoded.High, &t.High},
+		"low":         {decoded.Low, &t.Low},
+		"close":       {decoded.Close, &t.Close},
+		"volume":      {decoded.Volume, &t.Volume},
+		"quoteVolume": {decoded.QuoteVolume, &t.QuoteVolume},
+		"count":       {decoded.Count, &t.Count},
+	}
+
+	for k, f := range fields {
+		*f.dest = big.NewInt(0)
+		if f.value == "" {
+			continue
+		}
+
+		n, ok := new(big.Int).SetString(f.value, 10)
+		if !ok {
+			return fmt.Errorf("Invalid %v: %v", k, f.value)
+		}
+
+		*f.dest = n
+	}
+
+	return nil
+}
+
+// tickRecord is the format in which the mongo aggregate pipeline returns OHLCV data
+type tickRecord struct {
+	ID struct {
+		PairName   string `bson:"pairName"`
+		BaseToken  string `bson:"baseToken"`
+		QuoteToken string `bson:"quoteToken"`
+		Timestamp  int64  `bson:"timestamp"`
+	} `bson:"_id"`
+	Count  bson.Decimal128 `bson:"count"`
+	Open   bson.Decimal128 `bson:"open"`
+	High   bson.Decimal128 `bson:"high"`
+	Low    bson.Decimal128 `bson:"low"`
+	Close  bson.Decimal128 `bson:"close"`
+	Volume bson.Decimal128 `bson:"volume"`
 
-		O:     o,
-		H:     h,
-		L:     l,
-		C:     c,
-		V:     v,
-		Count: count,
-		Ts:    t.Ts,
-	}, nil
+	// PriceVolume is the sum of the amounts of the trades multiplied by their pricepoints
+	PriceVolume bson.Decimal128 `bson:"priceVolume"`
 }
 
+// SetBSON decodes a tick returned by the OHLCV aggregate pipeline. The duration and unit
+// of the tick are not part of the aggregate result and are set by the caller. The quote
+// volume is set to the sum of the amounts of the trades multiplied by their pricepoints,
+// which the caller converts to quote token units with the decimals of the pair (see
+// Pair.QuoteAmount).
 func (t *Tick) SetBSON(raw bson.Raw) error {
-	type TID struct {
-		Pair       string `json:"pair" bson:"pair"`
-		BaseToken  string `json:"baseToken" bson:"baseToken"`
-		QuoteToken string `json:"quoteToken" bson:"quoteToken"`
-	}
-	decoded := new(struct {
-		ID    TID             `json:"_id,omitempty" bson:"_id"`
-		Count bson.Decimal128 `json:"count" bson:"count"`
-		O     bson.Decimal128 `json:"o" bson:"o"`
-		H     bson.Decimal128 `json:"h" bson:"h"`
-		L     bson.Decimal128 `json:"l" bson:"l"`
-		C     bson.Decimal128 `json:"c" bson:"c"`
-		V     bson.Decimal128 `json:"v" bson:"v"`
-		Ts    int64           `json:"ts" bson:"ts"`
-	})
+	decoded := &tickRecord{}
 
 	err := raw.Unmarshal(decoded)
 	if err != nil {
 		return err
 	}
-	t.ID = TickID{
-		Pair:       decoded.ID.Pair,
+
+	t.Pair = PairID{
+		PairName:   decoded.ID.PairName,
 		BaseToken:  common.HexToAddress(decoded.ID.BaseToken),
 		QuoteToken: common.HexToAddress(decoded.ID.QuoteToken),
 	}
-	t.Count = new(big.Int)
-	t.C = new(big.Int)
-	t.H = new(big.Int)
-	t.L = new(big.Int)
-	t.O = new(big.Int)
-	t.V = new(big.Int)
-
-	count := decoded.Count.String()
-	o := decoded.O.String()
-	h := decoded.H.String()
-	l := decoded.L.String()
-	c := decoded.C.String()
-	v := decoded.V.String()
-	t.Count = math.ToBigInt(count)
-	t.C = math.ToBigInt(c)
-	t.H = math.ToBigInt(h)
-	t.L = math.ToBigInt(l)
-	t.O = math.ToBigInt(o)
-	t.V = math.ToBigInt(v)
-
-	t.Ts = decoded.Ts
+
+	t.Timestamp = decoded.ID.Timestamp
+	t.Count = math.ToBigInt(decoded.Count.String())
+	t.Open = math.ToBigInt(decoded.Open.String())
+	t.High = math.ToBigInt(decoded.High.String())
+	t.Low = math.ToBigInt(decoded.Low.String())
+	t.Close = math.ToBigInt(decoded.Close.String())
+	t.Volume = math.ToBigInt(decoded.Volume.String())
+	t.QuoteVolume = decimalToBigInt(decoded.PriceVolume)
 	return nil
 }
+
+// decimalToBigInt returns the integer part of a decimal. Unlike the sums of amounts, the sums
+// of amounts multiplied by pricepoints can exceed the 34 digits of a decimal and be returned
+// with an exponent by the aggregate pipeline.
+func decimalToBigInt(d bson.Decimal128) *big.Int {
+	r, ok := new(big.Rat).SetString(d.String())
+	if !ok {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Quo(r.Num(), r.Denom())
+}
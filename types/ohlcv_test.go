@@ -0,0 +1,168 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestTickStart(t *testing.T) {
+	testCases := []struct {
+		time     string
+		duration int64
+		unit     string
+		expected string
+	}{
+		{"2018-08-21T08:00:14Z", 15, "sec", "2018-08-21T08:00:00Z"},
+		{"2018-08-21T08:00:15Z", 15, "sec", "2018-08-21T08:00:15Z"},
+		{"2018-08-21T08:19:59Z", 7, "min", "2018-08-21T08:13:00Z"},
+		{"2018-08-21T08:29:59Z", 90, "min", "2018-08-21T07:30:00Z"},
+		{"2018-08-21T23:59:59Z", 6, "hour", "2018-08-21T18:00:00Z"},
+		{"2018-08-22T00:00:00Z", 6, "hour", "2018-08-22T00:00:00Z"},
+		{"2018-08-21T08:00:00Z", 1, "day", "2018-08-21T00:00:00Z"},
+		{"2018-08-21T08:00:00Z", 1, "week", "2018-08-20T00:00:00Z"},
+		{"2018-08-19T23:59:59Z", 1, "week", "2018-08-13T00:00:00Z"},
+		{"2017-12-31T23:59:59Z", 1, "week", "2017-12-25T00:00:00Z"},
+		{"2018-01-01T00:00:00Z", 1, "week", "2018-01-01T00:00:00Z"},
+		{"2018-01-31T23:59:59Z", 1, "month", "2018-01-01T00:00:00Z"},
+		{"2018-02-01T00:00:00Z", 1, "month", "2018-02-01T00:00:00Z"},
+		{"2018-02-28T23:59:59Z", 1, "month", "2018-02-01T00:00:00Z"},
+		{"2016-02-29T12:00:00Z", 1, "month", "2016-02-01T00:00:00Z"},
+		{"2018-03-31T23:59:59Z", 3, "month", "2018-01-01T00:00:00Z"},
+		{"2018-04-01T00:00:00Z", 3, "month", "2018-04-01T00:00:00Z"},
+		{"2017-12-31T23:59:59Z", 3, "month", "2017-10-01T00:00:00Z"},
+		{"2017-12-31T23:59:59Z", 9, "month", "2017-04-01T00:00:00Z"},
+		{"2018-01-01T00:00:00Z", 9, "month", "2018-01-01T00:00:00Z"},
+		{"2018-06-30T23:59:59Z", 1, "year", "2018-01-01T00:00:00Z"},
+		{"2018-06-30T23:59:59Z", 4, "year", "2018-01-01T00:00:00Z"},
+		{"2017-06-30T23:59:59Z", 4, "year", "2014-01-01T00:00:00Z"},
+	}
+
+	for _, tc := range testCases {
+		ts, _ := time.Parse(time.RFC3339, tc.time)
+		expected, _ := time.Parse(time.RFC3339, tc.expected)
+
+		start := TickStart(ts, tc.duration, tc.unit)
+		assert.Equal(t, expected.Unix(), start.Unix(), "%v %v %v", tc.time, tc.duration, tc.unit)
+	}
+}
+
+func TestTickStartIgnoresLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+9", 9*60*60)
+	ts := time.Date(2018, 2, 1, 8, 0, 0, 0, loc)
+
+	start := TickStart(ts, 1, "month")
+	assert.Equal(t, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), start)
+}
+
+func TestValidateTickInterval(t *testing.T) {
+	assert.NoError(t, ValidateTickInterval(7, "min"))
+	assert.NoError(t, ValidateTickInterval(9, "month"))
+	assert.Error(t, ValidateTickInterval(0, "min"))
+	assert.Error(t, ValidateTickInterval(-1, "hour"))
+	assert.Error(t, ValidateTickInterval(1, "yr"))
+}
+
+func TestTickJSON(t *testing.T) {
+	tick := &Tick{
+		Pair: PairID{
+			PairName:   "ZRX/WETH",
+			BaseToken:  common.HexToAddress("0x2034842261b82651885751fc293bba7ba5398156"),
+			QuoteToken: common.HexToAddress("0x276e16ada4b107332afd776691a7fbbaede168ef"),
+		},
+		Open:        big.NewInt(100),
+		High:        big.NewInt(120),
+		Low:         big.NewInt(90),
+		Close:       big.NewInt(110),
+		Volume:      big.NewInt(1000),
+		QuoteVolume: big.NewInt(110000),
+		Count:       big.NewInt(3),
+		Timestamp:   1534809600000,
+		Duration:    1,
+		Unit:        "day",
+	}
+
+	encoded, err := json.Marshal(tick)
+	if err != nil {
+		t.Error(err)
+	}
+
+	decoded := &Tick{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, tick, decoded)
+}
+
+func TestDecimalToBigInt(t *testing.T) {
+	tests := map[string]string{
+		"125772":      "125772",
+		"1.25772E+40": "12577200000000000000000000000000000000000",
+		"1256.9":      "1256",
+		"-0":          "0",
+	}
+
+	for d, expected := range tests {
+		decimal, err := bson.ParseDecimal128(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, expected, decimalToBigInt(decimal).String(), d)
+	}
+}
+
+func TestComputeTicks(t *testing.T) {
+	zrx := PairID{"ZRX/WETH", common.HexToAddress("0x1"), common.HexToAddress("0x3")}
+	dai := PairID{"DAI/WETH", common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+	start := time.Date(2018, 9, 3, 9, 0, 0, 0, time.UTC)
+
+	trade := func(p PairID, offset time.Duration, pricepoint, amount int64) *Trade {
+		return &Trade{
+			PairName:   p.PairName,
+			BaseToken:  p.BaseToken,
+			QuoteToken: p.QuoteToken,
+			PricePoint: big.NewInt(pricepoint),
+			Amount:     big.NewInt(amount),
+			CreatedAt:  start.Add(offset),
+		}
+	}
+
+	trades := []*Trade{
+		trade(dai, 0, 100, 1),
+		trade(zrx, 0, 10, 5),
+		trade(dai, 10*time.Minute, 120, 2),
+		trade(dai, 20*time.Minute, 90, 3),
+		trade(dai, 59*time.Minute, 110, 4),
+		trade(zrx, 2*time.Hour, 11, 5),
+	}
+
+	ticks := ComputeTicks(trades, 1, "hour")
+	if !assert.Equal(t, 3, len(ticks)) {
+		return
+	}
+
+	// ticks starting at the same time are sorted by pair
+	assert.Equal(t, zrx, ticks[0].Pair)
+	assert.Equal(t, dai, ticks[1].Pair)
+	assert.Equal(t, start.Unix()*1000, ticks[1].Timestamp)
+	assert.Equal(t, big.NewInt(100), ticks[1].Open)
+	assert.Equal(t, big.NewInt(120), ticks[1].High)
+	assert.Equal(t, big.NewInt(90), ticks[1].Low)
+	assert.Equal(t, big.NewInt(110), ticks[1].Close)
+	assert.Equal(t, big.NewInt(10), ticks[1].Volume)
+	assert.Equal(t, big.NewInt(100+240+270+440), ticks[1].QuoteVolume)
+	assert.Equal(t, big.NewInt(4), ticks[1].Count)
+	assert.Equal(t, int64(1), ticks[1].Duration)
+	assert.Equal(t, "hour", ticks[1].Unit)
+	assert.Equal(t, start.Add(2*time.Hour).Unix()*1000, ticks[2].Timestamp)
+
+	assert.Equal(t, []*Tick{}, ComputeTicks(nil, 1, "hour"))
+}
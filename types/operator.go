@@ -1,10 +1,22 @@
 package types
 
+// Error IDs of the TRADE_ERROR operator messages of trades whose failure is not reported by the
+// exchange contract: their transaction reverted or it was not mined in time
+const (
+	TxErrorReverted = -1
+	TxErrorTimeout  = -2
+)
+
 type OperatorMessage struct {
 	MessageType string
 	Order       *Order
 	Trade       *Trade
 	ErrID       int
+
+	// Matches are the trades of a taker order against several maker orders, in the order in
+	// which they were matched. They are sent as a single message so that they are executed in
+	// that order.
+	Matches []*OrderTradePair
 }
 
 type PendingTradeMessage struct {
@@ -11,11 +11,23 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"gopkg.in/mgo.v2/bson"
 )
 
+// trailingPercentBase is the PERCENT trailing offset corresponding to 100%
+var trailingPercentBase = big.NewInt(10000)
+
+// ErrOrderAlreadyExists is returned when storing an order whose hash is already stored
+var ErrOrderAlreadyExists = errors.New("Order already exists")
+
+// DuplicateOrderHash lists the orders stored with the same hash, along with their statuses
+type DuplicateOrderHash struct {
+	Hash     common.Hash     `json:"hash"`
+	IDs      []bson.ObjectId `json:"ids"`
+	Statuses []string        `json:"statuses"`
+}
+
 // Order contains the data related to an order sent by the user
 type Order struct {
 	ID              bson.ObjectId  `json:"id" bson:"_id"`
@@ -28,10 +40,20 @@ type Order struct {
 	BuyAmount       *big.Int       `json:"buyAmount" bson:"buyAmount"`
 	SellAmount      *big.Int       `json:"sellAmount" bson:"sellAmount"`
 	Status          string         `json:"status" bson:"status"`
-	Side            string         `json:"side" bson:"side"`
+	Side            OrderSide      `json:"side" bson:"side"`
+	Type            OrderType      `json:"type" bson:"type"`
+	TimeInForce     TimeInForce    `json:"timeInForce" bson:"timeInForce"`
+	PostOnly        bool           `json:"postOnly" bson:"postOnly"`
+	Hidden          bool           `json:"hidden" bson:"hidden"`
 	Hash            common.Hash    `json:"hash" bson:"hash"`
 	Signature       *Signature     `json:"signature,omitempty" bson:"signature"`
+	SignatureScheme string         `json:"signatureScheme,omitempty" bson:"signatureScheme"`
 	PricePoint      *big.Int       `json:"pricepoint" bson:"pricepoint"`
+	StopPricepoint  *big.Int       `json:"stopPricepoint,omitempty" bson:"stopPricepoint"`
+	TrailingOffset  *big.Int       `json:"trailingOffset,omitempty" bson:"trailingOffset"`
+	TrailingType    TrailingType   `json:"trailingType,omitempty" bson:"trailingType"`
+	Watermark       *big.Int       `json:"watermark,omitempty" bson:"watermark"`
+	DisplayAmount   *big.Int       `json:"displayAmount,omitempty" bson:"displayAmount"`
 	Amount          *big.Int       `json:"amount" bson:"amount"`
 	FilledAmount    *big.Int       `json:"filledAmount" bson:"filledAmount"`
 	Nonce           *big.Int       `json:"nonce" bson:"nonce"`
@@ -44,83 +66,159 @@ type Order struct {
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
+// Validate checks that all the order fields are set and consistent. If the order is invalid,
+// the returned error is a ValidationErrors listing each invalid field.
 func (o *Order) Validate() error {
-	// err := validation.ValidateStruct(o,
-	// 	validation.Field(o.ExchangeAddress, validation.Required),
-	// 	validation.Field(o.UserAddress, validation.Required),
-	// 	validation.Field(o.SellToken, validation.Required),
-	// 	validation.Field(o.BuyToken, validation.Required),
-	// 	validation.Field(o.MakeFee, validation.Required),
-	// 	validation.Field(o.TakeFee, validation.Required),
-	// 	validation.Field(o.Nonce, validation.Required),
-	// 	validation.Field(o.Expires, validation.Required),
-	// 	validation.Field(o.SellAmount, validation.Required),
-	// 	validation.Field(o.UserAddress, validation.Required),
-	// 	validation.Field(o.Signature, validation.Required),
-	// )
-
-	// if err != nil {
-	// 	return err
-	// }
+	errs := ValidationErrors{}
 
-	if o.ExchangeAddress != common.HexToAddress(app.Config.Ethereum["exchange_address"]) {
-		return errors.New("Incorrect exchange address")
+	if o.UserAddress == (common.Address{}) {
+		errs.Add("userAddress", "Maker address is missing")
 	}
 
-	if math.IsSmallerThan(o.BuyAmount, big.NewInt(0)) {
-		return errors.New("Buy amount should be positive")
+	if !app.Config.IsAllowedExchangeAddress(o.ExchangeAddress) {
+		errs.Add("exchangeAddress", "Incorrect exchange address")
 	}
 
-	if math.IsSmallerThan(o.SellAmount, big.NewInt(0)) {
-		return errors.New("Sell amount should be positive")
+	if o.BuyToken == (common.Address{}) {
+		errs.Add("buyToken", "Buy token address is missing")
 	}
 
-	if math.IsSmallerThan(o.Nonce, big.NewInt(0)) {
-		return errors.New("Nonce should be positive")
+	if o.SellToken == (common.Address{}) {
+		errs.Add("sellToken", "Sell token address is missing")
+	} else if o.SellToken == o.BuyToken {
+		errs.Add("sellToken", "Buy and sell tokens should be different")
 	}
 
-	return nil
+	if o.BuyAmount == nil || o.BuyAmount.Sign() <= 0 {
+		errs.Add("buyAmount", "Buy amount should be positive")
+	}
+
+	if o.SellAmount == nil || o.SellAmount.Sign() <= 0 {
+		errs.Add("sellAmount", "Sell amount should be positive")
+	}
+
+	if o.MakeFee == nil || o.MakeFee.Sign() < 0 {
+		errs.Add("makeFee", "Make fee should not be negative")
+	}
+
+	if o.TakeFee == nil || o.TakeFee.Sign() < 0 {
+		errs.Add("takeFee", "Take fee should not be negative")
+	}
+
+	if o.Expires == nil {
+		errs.Add("expires", "Expiry is missing")
+	} else if o.IsExpired(time.Now()) {
+		errs.Add("expires", "Order has expired")
+	}
+
+	if o.Nonce == nil {
+		errs.Add("nonce", "Nonce is missing")
+	} else if o.Nonce.Sign() < 0 {
+		errs.Add("nonce", "Nonce should be positive")
+	}
+
+	if o.Signature == nil {
+		errs.Add("signature", "Signature is missing")
+	}
+
+	if o.StopPricepoint != nil && o.StopPricepoint.Sign() <= 0 {
+		errs.Add("stopPricepoint", "Stop pricepoint should be positive")
+	}
+
+	// trailing stops move their stop pricepoint, which must be set initially, with the last price
+	if o.TrailingOffset != nil && o.TrailingOffset.Sign() <= 0 {
+		errs.Add("trailingOffset", "Trailing offset should be positive")
+	} else if o.TrailingOffset != nil && o.StopPricepoint == nil {
+		errs.Add("trailingOffset", "Trailing stop orders require a stop pricepoint")
+	} else if o.TrailingOffset != nil && o.TrailingType == TrailingPercent && o.TrailingOffset.Cmp(trailingPercentBase) >= 0 {
+		errs.Add("trailingOffset", "Trailing percentage should be lower than 100%")
+	} else if o.TrailingOffset == nil && o.TrailingType != "" {
+		errs.Add("trailingType", "Trailing type requires a trailing offset")
+	}
+
+	// the hidden amount of iceberg orders is only revealed while they rest in the orderbook
+	if o.DisplayAmount != nil && o.DisplayAmount.Sign() <= 0 {
+		errs.Add("displayAmount", "Display amount should be positive")
+	} else if o.DisplayAmount != nil && (o.TimeInForce == TimeInForceIOC || o.TimeInForce == TimeInForceFOK) {
+		errs.Add("displayAmount", "Iceberg orders cannot be immediate-or-cancel or fill-or-kill")
+	}
+
+	// hidden orders never show any amount in the orderbook, and only matter while they rest in it
+	if o.Hidden && o.DisplayAmount != nil {
+		errs.Add("hidden", "Hidden orders cannot be iceberg orders")
+	} else if o.Hidden && (o.TimeInForce == TimeInForceIOC || o.TimeInForce == TimeInForceFOK) {
+		errs.Add("hidden", "Hidden orders cannot be immediate-or-cancel or fill-or-kill")
+	}
+
+	// post-only orders rest in the orderbook, which immediate orders never do
+	if o.PostOnly && (o.TimeInForce == TimeInForceIOC || o.TimeInForce == TimeInForceFOK) {
+		errs.Add("postOnly", "Post-only orders cannot be immediate-or-cancel or fill-or-kill")
+	}
+
+	// the engine only matches limit orders
+	if o.Type == OrderTypeMarket {
+		errs.Add("type", "Market orders are not supported")
+	}
+
+	return errs.Err()
+}
+
+// EncodedBytes returns the tightly packed order fields hashed by the exchange smart contract.
+// The field order must match the contract exactly, otherwise every order signature is rejected
+// on-chain.
+func (o *Order) EncodedBytes() []byte {
+	b := make([]byte, 0, 4*common.AddressLength+6*common.HashLength)
+	b = append(b, o.ExchangeAddress.Bytes()...)
+	b = append(b, o.UserAddress.Bytes()...)
+	b = append(b, o.SellToken.Bytes()...)
+	b = append(b, o.BuyToken.Bytes()...)
+	b = append(b, common.BigToHash(o.SellAmount).Bytes()...)
+	b = append(b, common.BigToHash(o.BuyAmount).Bytes()...)
+	b = append(b, common.BigToHash(o.MakeFee).Bytes()...)
+	b = append(b, common.BigToHash(o.TakeFee).Bytes()...)
+	b = append(b, common.BigToHash(o.Expires).Bytes()...)
+	b = append(b, common.BigToHash(o.Nonce).Bytes()...)
+	return b
 }
 
 // ComputeHash calculates the orderRequest hash
 func (o *Order) ComputeHash() common.Hash {
 	sha := sha3.NewKeccak256()
-	sha.Write(o.ExchangeAddress.Bytes())
-	sha.Write(o.UserAddress.Bytes())
-	sha.Write(o.SellToken.Bytes())
-	sha.Write(o.BuyToken.Bytes())
-	sha.Write(common.BigToHash(o.SellAmount).Bytes())
-	sha.Write(common.BigToHash(o.BuyAmount).Bytes())
-	sha.Write(common.BigToHash(o.MakeFee).Bytes())
-	sha.Write(common.BigToHash(o.TakeFee).Bytes())
-	sha.Write(common.BigToHash(o.Expires).Bytes())
-	sha.Write(common.BigToHash(o.Nonce).Bytes())
+	sha.Write(o.EncodedBytes())
 	return common.BytesToHash(sha.Sum(nil))
 }
 
-// VerifySignature checks that the orderRequest signature corresponds to the address in the userAddress field
+// VerifySignature checks that the orderRequest signature corresponds to the address in the userAddress field.
+// The signed message depends on the order signature scheme (personal sign by default or EIP712)
 func (o *Order) VerifySignature() (bool, error) {
 	o.Hash = o.ComputeHash()
-	message := crypto.Keccak256(
-		[]byte("\x19Ethereum Signed Message:\n32"),
-		o.Hash.Bytes(),
-	)
 
-	address, err := o.Signature.Verify(common.BytesToHash(message))
-	if err != nil {
-		return false, err
-	}
+	switch o.SignatureScheme {
+	case EIP712SignScheme:
+		chainID := math.ToBigInt(app.Config.Ethereum["chain_id"])
+		address, err := o.Signature.RecoverRaw(o.ComputeEIP712Hash(NewTypedDataDomain(o.ExchangeAddress, chainID)))
+		if err != nil {
+			return false, err
+		}
 
-	if address != o.UserAddress {
-		return false, errors.New("Recovered address is incorrect")
+		if address != o.UserAddress {
+			return false, errors.New("Recovered address is incorrect")
+		}
+	case "", PersonalSignScheme:
+		err := o.Signature.Verify(o.Hash, o.UserAddress)
+		if err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("Invalid signature scheme: %v", o.SignatureScheme)
 	}
 
 	return true, nil
 }
 
 // Sign first calculates the order hash, then computes a signature of this hash
-// with the given wallet
-func (o *Order) Sign(w *Wallet) error {
+// with the given signer
+func (o *Order) Sign(w Signer) error {
 	hash := o.ComputeHash()
 	sig, err := w.SignHash(hash)
 	if err != nil {
@@ -138,23 +236,139 @@ func (o *Order) Process(p *Pair) error {
 	}
 
 	if o.BuyToken == p.BaseTokenAddress {
-		o.Side = "BUY"
+		o.Side = OrderSideBuy
 		o.Amount = o.BuyAmount
-		o.PricePoint = math.Div(math.Mul(o.SellAmount, p.PriceMultiplier), o.BuyAmount)
+		o.PricePoint = p.PricePoint(o.BuyAmount, o.SellAmount)
 	} else if o.BuyToken == p.QuoteTokenAddress {
-		o.Side = "SELL"
+		o.Side = OrderSideSell
 		o.Amount = o.SellAmount
-		o.PricePoint = math.Div(math.Mul(o.BuyAmount, p.PriceMultiplier), o.SellAmount)
+		o.PricePoint = p.PricePoint(o.SellAmount, o.BuyAmount)
 	} else {
 		return errors.New("Could not determine o side")
 	}
 
+	if o.Type == "" {
+		o.Type = OrderTypeLimit
+	}
+
+	if o.TimeInForce == "" {
+		o.TimeInForce = TimeInForceGTC
+	}
+
 	o.BaseToken = p.BaseTokenAddress
 	o.QuoteToken = p.QuoteTokenAddress
 	o.PairName = p.Name()
 	return nil
 }
 
+// signedAmount returns the amount (in base token) of the order signed by its maker. It is the
+// amount of the order unless the order was reduced (see OrderReduce).
+func (o *Order) signedAmount() *big.Int {
+	if o.Side == OrderSideBuy && o.BuyAmount != nil {
+		return o.BuyAmount
+	}
+
+	if o.Side == OrderSideSell && o.SellAmount != nil {
+		return o.SellAmount
+	}
+
+	return o.Amount
+}
+
+// IsReduced returns true if the amount of the order was reduced below its signed amount
+func (o *Order) IsReduced() bool {
+	return o.Amount != nil && o.Amount.Cmp(o.signedAmount()) < 0
+}
+
+// SellAmountFor returns the amount of sell token corresponding to an amount (in base token) of the order
+func (o *Order) SellAmountFor(amount *big.Int) *big.Int {
+	signed := o.signedAmount()
+	if signed == nil || signed.Sign() == 0 {
+		return big.NewInt(0)
+	}
+
+	return math.Div(math.Mul(o.SellAmount, amount), signed)
+}
+
+// BuyAmountFor returns the amount of buy token corresponding to an amount (in base token) of the order
+func (o *Order) BuyAmountFor(amount *big.Int) *big.Int {
+	signed := o.signedAmount()
+	if signed == nil || signed.Sign() == 0 {
+		return big.NewInt(0)
+	}
+
+	return math.Div(math.Mul(o.BuyAmount, amount), signed)
+}
+
+// FillAmounts returns the amounts of sell and buy token of a fill of the order, given the amount
+// of the order filled before it. The amounts are the difference between the amounts of the order
+// filled after and before the fill, rounded down, so that the rounding remainder of a fill is
+// carried over to the next fills: the fills of a filled order sum up exactly to its sell and buy
+// amounts, or to the sell and buy amounts of its reduced amount if it was reduced.
+func (o *Order) FillAmounts(filledBefore, amount *big.Int) (sell, buy *big.Int) {
+	if filledBefore == nil {
+		filledBefore = big.NewInt(0)
+	}
+
+	filledAfter := math.Add(filledBefore, amount)
+	sell = math.Sub(o.SellAmountFor(filledAfter), o.SellAmountFor(filledBefore))
+	buy = math.Sub(o.BuyAmountFor(filledAfter), o.BuyAmountFor(filledBefore))
+	return sell, buy
+}
+
+// RemainingSellAmount returns the amount of sell token that has not been filled yet. The sell
+// amount of a reduced order is the part of its signed sell amount that was not reduced.
+func (o *Order) RemainingSellAmount() *big.Int {
+	sell := o.SellAmount
+	if o.IsReduced() {
+		sell = o.SellAmountFor(o.Amount)
+	}
+
+	if o.FilledAmount == nil {
+		return sell
+	}
+
+	return math.Sub(sell, o.SellAmountFor(o.FilledAmount))
+}
+
+// DustAmount returns the unfilled amount of a filled order. The engine fills the orders left with
+// less than the pair minimum amount and cancels their unfilled (dust) amount.
+func (o *Order) DustAmount() *big.Int {
+	if o.Status != OrderStatusFilled || o.Amount == nil || o.FilledAmount == nil {
+		return big.NewInt(0)
+	}
+
+	return math.Sub(o.Amount, o.FilledAmount)
+}
+
+// Clone returns a deep copy of the order. The engine keeps updating the amounts and status of
+// the orders it matches, so orders handed to other goroutines should be cloned first.
+func (o *Order) Clone() *Order {
+	c := *o
+	for _, n := range c.bigIntFields() {
+		if *n != nil {
+			*n = new(big.Int).Set(*n)
+		}
+	}
+
+	if o.Signature != nil {
+		sig := *o.Signature
+		c.Signature = &sig
+	}
+
+	return &c
+}
+
+// IsExpired returns true if the order expiry timestamp is before the given time.
+// Orders with a zero expiry never expire.
+func (o *Order) IsExpired(now time.Time) bool {
+	if o.Expires == nil || o.Expires.Sign() == 0 {
+		return false
+	}
+
+	return o.Expires.Cmp(big.NewInt(now.Unix())) < 0
+}
+
 func (o *Order) PairCode() (string, error) {
 	if o.PairName == "" {
 		return "", errors.New("Pair name is required")
@@ -163,6 +377,78 @@ func (o *Order) PairCode() (string, error) {
 	return o.PairName + "::" + o.BaseToken.Hex() + "::" + o.QuoteToken.Hex(), nil
 }
 
+// IsStop returns true if the order is a stop-limit order, i.e. an order that only enters the
+// orderbook once the last traded price has crossed its stop pricepoint
+func (o *Order) IsStop() bool {
+	return o.StopPricepoint != nil
+}
+
+// IsStopTriggered returns true if a trade at the given pricepoint activates the stop order.
+// Buy stops are triggered when the price rises to their stop pricepoint and sell stops when
+// the price falls to their stop pricepoint.
+func (o *Order) IsStopTriggered(pricePoint *big.Int) bool {
+	if !o.IsStop() || pricePoint == nil {
+		return false
+	}
+
+	if o.Side == OrderSideBuy {
+		return pricePoint.Cmp(o.StopPricepoint) >= 0
+	}
+
+	return pricePoint.Cmp(o.StopPricepoint) <= 0
+}
+
+// IsTrailingStop returns true if the order is a trailing stop order, i.e. a stop order whose stop
+// pricepoint follows the price at a fixed offset
+func (o *Order) IsTrailingStop() bool {
+	return o.IsStop() && o.TrailingOffset != nil
+}
+
+// TrailingStopPricepoint returns the stop pricepoint trailing the given pricepoint by the offset
+// of the order: below it for sell orders, which are triggered when the price falls back from
+// its high, and above it for buy orders, which are triggered when the price rises back from its
+// low. Percentage offsets are rounded down.
+func (o *Order) TrailingStopPricepoint(pricePoint *big.Int) *big.Int {
+	offset := o.TrailingOffset
+	if o.TrailingType == TrailingPercent {
+		offset = math.Div(math.Mul(pricePoint, o.TrailingOffset), trailingPercentBase)
+	}
+
+	if o.Side == OrderSideBuy {
+		return math.Add(pricePoint, offset)
+	}
+
+	return math.Sub(pricePoint, offset)
+}
+
+// IsIceberg returns true if the orderbook only shows a slice of DisplayAmount of the order
+func (o *Order) IsIceberg() bool {
+	return o.DisplayAmount != nil && o.DisplayAmount.Sign() > 0 && o.Amount != nil && o.DisplayAmount.Cmp(o.Amount) < 0
+}
+
+// DisplayedAmount returns the unfilled amount of the order shown in the orderbook. The amount of
+// an iceberg order is cut into consecutive slices of DisplayAmount and only the unfilled part of
+// its current slice is shown, its next slice being revealed once the current slice is filled.
+// Hidden orders show no amount and the unfilled amount of other orders is entirely shown.
+func (o *Order) DisplayedAmount() *big.Int {
+	if o.Hidden {
+		return big.NewInt(0)
+	}
+
+	filled := o.FilledAmount
+	if filled == nil {
+		filled = big.NewInt(0)
+	}
+
+	remaining := math.Sub(o.Amount, filled)
+	if !o.IsIceberg() {
+		return remaining
+	}
+
+	slice := math.Sub(o.DisplayAmount, new(big.Int).Mod(filled, o.DisplayAmount))
+	return math.Min(slice, remaining)
+}
+
 // GetKVPrefix returns the key value store(redis) prefix to be used
 // by matching engine correspondind to a particular order.
 func (o *Order) GetKVPrefix() string {
@@ -173,29 +459,24 @@ func (o *Order) GetKVPrefix() string {
 // orderbook price point key
 // orderbook list key corresponding to order price.
 func (o *Order) GetOBKeys() (ss, list string) {
-	var k string
-	if o.Side == "BUY" {
-		k = "BUY"
-	} else if o.Side == "SELL" {
-		k = "SELL"
-	}
-
+	k := string(o.Side)
 	ss = o.GetKVPrefix() + "::" + k
 	list = o.GetKVPrefix() + "::" + k + "::" + utils.UintToPaddedString(o.PricePoint.Int64())
 	return
 }
 
+// GetStopKeys returns the keys of the stop order index of the order side and of the
+// serialized stop order
+func (o *Order) GetStopKeys() (index, order string) {
+	index = o.GetKVPrefix() + "::" + string(o.Side) + "::stops"
+	order = index + "::" + o.Hash.Hex() + "::stop"
+	return
+}
+
 // GetOBMatchKey returns the orderbook price point key
 // aginst which the order needs to be matched
 func (o *Order) GetOBMatchKey() (ss string) {
-	var k string
-	if o.Side == "BUY" {
-		k = "SELL"
-	} else if o.Side == "SELL" {
-		k = "BUY"
-	}
-
-	ss = o.GetKVPrefix() + "::" + k
+	ss = o.GetKVPrefix() + "::" + string(o.Side.Opposite())
 	return
 }
 
@@ -204,20 +485,9 @@ func (o *Order) GetOBMatchKey() (ss string) {
 // MarshalJSON implements the json.Marshal interface
 func (o *Order) MarshalJSON() ([]byte, error) {
 	order := map[string]interface{}{
-		"exchangeAddress": o.ExchangeAddress,
-		"userAddress":     o.UserAddress,
-		"buyToken":        o.BuyToken,
-		"sellToken":       o.SellToken,
-		"baseToken":       o.BaseToken,
-		"quoteToken":      o.QuoteToken,
-		"side":            o.Side,
-		"status":          o.Status,
-		"pairName":        o.PairName,
-		"buyAmount":       o.BuyAmount.String(),
-		"sellAmount":      o.SellAmount.String(),
-		"makeFee":         o.MakeFee.String(),
-		"takeFee":         o.TakeFee.String(),
-		"expires":         o.Expires.String(),
+		"side":     o.Side,
+		"status":   o.Status,
+		"pairName": o.PairName,
 		// NOTE: Currently removing this to simplify public API, might reinclude
 		// later. An alternative would be to create additional simplified type
 		"createdAt": o.CreatedAt.Format(time.RFC3339Nano),
@@ -230,26 +500,20 @@ func (o *Order) MarshalJSON() ([]byte, error) {
 	// 	order["id"] = o.ID
 	// }
 
-	if o.Amount != nil {
-		order["amount"] = o.Amount.String()
-	}
-
-	if o.FilledAmount != nil {
-		order["filledAmount"] = o.FilledAmount.String()
+	for k, a := range o.addressFields() {
+		order[k] = a.Hex()
 	}
 
-	if o.PricePoint != nil {
-		order["pricepoint"] = o.PricePoint.String()
+	for k, n := range o.bigIntFields() {
+		if *n != nil {
+			order[k] = (*n).String()
+		}
 	}
 
 	if o.Hash.Hex() != "" {
 		order["hash"] = o.Hash.Hex()
 	}
 
-	if o.Nonce != nil {
-		order["nonce"] = o.Nonce.String()
-	}
-
 	if o.Signature != nil {
 		order["signature"] = map[string]interface{}{
 			"V": o.Signature.V,
@@ -258,141 +522,165 @@ func (o *Order) MarshalJSON() ([]byte, error) {
 		}
 	}
 
-	return json.Marshal(order)
-}
-
-func (o *Order) UnmarshalJSON(b []byte) error {
-	order := map[string]interface{}{}
-
-	err := json.Unmarshal(b, &order)
-	if err != nil {
-		return err
-	}
-
-	if order["id"] != nil && bson.IsObjectIdHex(order["id"].(string)) {
-		o.ID = bson.ObjectIdHex(order["id"].(string))
-	}
-
-	if order["pairName"] != nil {
-		o.PairName = order["pairName"].(string)
-	}
-
-	if order["exchangeAddress"] != nil {
-		o.ExchangeAddress = common.HexToAddress(order["exchangeAddress"].(string))
+	if o.SignatureScheme != "" {
+		order["signatureScheme"] = o.SignatureScheme
 	}
 
-	if order["userAddress"] != nil {
-		o.UserAddress = common.HexToAddress(order["userAddress"].(string))
+	if o.Type != "" {
+		order["type"] = o.Type
 	}
 
-	if order["buyToken"] != nil {
-		o.BuyToken = common.HexToAddress(order["buyToken"].(string))
+	if o.TimeInForce != "" {
+		order["timeInForce"] = o.TimeInForce
 	}
 
-	if order["sellToken"] != nil {
-		o.SellToken = common.HexToAddress(order["sellToken"].(string))
+	if o.PostOnly {
+		order["postOnly"] = true
 	}
 
-	if order["baseToken"] != nil {
-		o.BaseToken = common.HexToAddress(order["baseToken"].(string))
+	if o.Hidden {
+		order["hidden"] = true
 	}
 
-	if order["quoteToken"] != nil {
-		o.QuoteToken = common.HexToAddress(order["quoteToken"].(string))
+	if o.TrailingType != "" {
+		order["trailingType"] = o.TrailingType
 	}
 
-	if order["pricepoint"] != nil {
-		o.PricePoint = math.ToBigInt(order["pricepoint"].(string))
-	}
-
-	if order["amount"] != nil {
-		o.Amount = math.ToBigInt(order["amount"].(string))
-	}
-
-	if order["filledAmount"] != nil {
-		o.FilledAmount = math.ToBigInt(order["filledAmount"].(string))
-	}
-
-	if order["buyAmount"] != nil {
-		o.BuyAmount = math.ToBigInt(order["buyAmount"].(string))
-	}
-
-	if order["sellAmount"] != nil {
-		o.SellAmount = math.ToBigInt(order["sellAmount"].(string))
-	}
-
-	if order["expires"] != nil {
-		o.Expires = math.ToBigInt(order["expires"].(string))
-	}
-
-	if order["nonce"] != nil {
-		o.Nonce = math.ToBigInt(order["nonce"].(string))
-	}
+	return json.Marshal(order)
+}
 
-	if order["makeFee"] != nil {
-		o.MakeFee = math.ToBigInt(order["makeFee"].(string))
+// UnmarshalJSON creates an order object from a json byte string. Integer fields
+// can be either decimal strings or json numbers. Unknown fields and malformed values
+// are rejected with a ValidationErrors listing every invalid field.
+func (o *Order) UnmarshalJSON(b []byte) error {
+	order, err := unmarshalJSONObject(b)
+	if err != nil {
+		return err
 	}
 
-	if order["takeFee"] != nil {
-		o.TakeFee = math.ToBigInt(order["takeFee"].(string))
+	errs := ValidationErrors{}
+	addresses := o.addressFields()
+	bigInts := o.bigIntFields()
+	stringFields := map[string]*string{
+		"pairName": &o.PairName,
+		"status":   &o.Status,
 	}
 
-	if order["hash"] != nil {
-		o.Hash = common.HexToHash(order["hash"].(string))
+	timeFields := map[string]*time.Time{
+		"createdAt": &o.CreatedAt,
+		"updatedAt": &o.UpdatedAt,
 	}
 
-	if order["side"] != nil {
-		o.Side = order["side"].(string)
-	}
+	for k, v := range order {
+		if v == nil {
+			continue
+		}
 
-	if order["status"] != nil {
-		o.Status = order["status"].(string)
-	}
+		switch {
+		case addresses[k] != nil:
+			*addresses[k], err = parseAddress(v)
+		case bigInts[k] != nil:
+			*bigInts[k], err = parseBigInt(v)
+		case stringFields[k] != nil:
+			*stringFields[k], err = parseString(v)
+		case timeFields[k] != nil:
+			*timeFields[k], err = parseTime(v)
+		case k == "side":
+			o.Side, err = parseOrderSide(v)
+		case k == "type":
+			o.Type, err = parseOrderType(v)
+		case k == "timeInForce":
+			o.TimeInForce, err = parseTimeInForce(v)
+		case k == "trailingType":
+			o.TrailingType, err = parseTrailingType(v)
+		case k == "signatureScheme":
+			o.SignatureScheme, err = parseSignatureScheme(v)
+		case k == "postOnly":
+			o.PostOnly, err = parseBool(v)
+		case k == "hidden":
+			o.Hidden, err = parseBool(v)
+		case k == "id":
+			o.ID, err = parseObjectID(v)
+		case k == "hash":
+			o.Hash, err = parseHash(v)
+		case k == "signature":
+			o.Signature, err = decodeSignature(v)
+		default:
+			err = errors.New("Unknown field")
+		}
 
-	if order["signature"] != nil {
-		signature := order["signature"].(map[string]interface{})
-		o.Signature = &Signature{
-			V: byte(signature["V"].(float64)),
-			R: common.HexToHash(signature["R"].(string)),
-			S: common.HexToHash(signature["S"].(string)),
+		if err != nil {
+			errs.Add(k, err.Error())
 		}
 	}
 
-	if order["createdAt"] != nil {
-		t, _ := time.Parse(time.RFC3339Nano, order["createdAt"].(string))
-		o.CreatedAt = t
-	}
+	errs.Sort()
+	return errs.Err()
+}
 
-	if order["updatedAt"] != nil {
-		t, _ := time.Parse(time.RFC3339Nano, order["updatedAt"].(string))
-		o.UpdatedAt = t
+// addressFields returns the address fields of the order indexed by their json key
+func (o *Order) addressFields() map[string]*common.Address {
+	return map[string]*common.Address{
+		"exchangeAddress": &o.ExchangeAddress,
+		"userAddress":     &o.UserAddress,
+		"buyToken":        &o.BuyToken,
+		"sellToken":       &o.SellToken,
+		"baseToken":       &o.BaseToken,
+		"quoteToken":      &o.QuoteToken,
 	}
+}
 
-	return nil
+// bigIntFields returns the integer fields of the order indexed by their json key
+func (o *Order) bigIntFields() map[string]**big.Int {
+	return map[string]**big.Int{
+		"buyAmount":      &o.BuyAmount,
+		"sellAmount":     &o.SellAmount,
+		"makeFee":        &o.MakeFee,
+		"takeFee":        &o.TakeFee,
+		"expires":        &o.Expires,
+		"nonce":          &o.Nonce,
+		"pricepoint":     &o.PricePoint,
+		"stopPricepoint": &o.StopPricepoint,
+		"trailingOffset": &o.TrailingOffset,
+		"watermark":      &o.Watermark,
+		"displayAmount":  &o.DisplayAmount,
+		"amount":         &o.Amount,
+		"filledAmount":   &o.FilledAmount,
+	}
 }
 
 // OrderRecord is the object that will be saved in the database
 type OrderRecord struct {
-	ID              bson.ObjectId    `json:"id" bson:"_id"`
-	UserAddress     string           `json:"userAddress" bson:"userAddress"`
-	ExchangeAddress string           `json:"exchangeAddress" bson:"exchangeAddress"`
-	BuyToken        string           `json:"buyToken" bson:"buyToken"`
-	SellToken       string           `json:"sellToken" bson:"sellToken"`
-	BaseToken       string           `json:"baseToken" bson:"baseToken"`
-	QuoteToken      string           `json:"quoteToken" bson:"quoteToken"`
-	BuyAmount       string           `json:"buyAmount" bson:"buyAmount"`
-	SellAmount      string           `json:"sellAmount" bson:"sellAmount"`
-	Status          string           `json:"status" bson:"status"`
-	Side            string           `json:"side" bson:"side"`
-	Hash            string           `json:"hash" bson:"hash"`
-	PricePoint      string           `json:"pricepoint" bson:"pricepoint"`
-	Amount          string           `json:"amount" bson:"amount"`
-	FilledAmount    string           `json:"filledAmount" bson:"filledAmount"`
-	Nonce           string           `json:"nonce" bson:"nonce"`
-	Expires         string           `json:"expires" bson:"expires"`
-	MakeFee         string           `json:"makeFee" bson:"makeFee"`
-	TakeFee         string           `json:"takeFee" bson:"takeFee"`
-	Signature       *SignatureRecord `json:"signature,omitempty" bson:"signature"`
+	ID              bson.ObjectId `json:"id" bson:"_id"`
+	UserAddress     string        `json:"userAddress" bson:"userAddress"`
+	ExchangeAddress string        `json:"exchangeAddress" bson:"exchangeAddress"`
+	BuyToken        string        `json:"buyToken" bson:"buyToken"`
+	SellToken       string        `json:"sellToken" bson:"sellToken"`
+	BaseToken       string        `json:"baseToken" bson:"baseToken"`
+	QuoteToken      string        `json:"quoteToken" bson:"quoteToken"`
+	BuyAmount       string        `json:"buyAmount" bson:"buyAmount"`
+	SellAmount      string        `json:"sellAmount" bson:"sellAmount"`
+	Status          string        `json:"status" bson:"status"`
+	Side            string        `json:"side" bson:"side"`
+	Type            string        `json:"type,omitempty" bson:"type,omitempty"`
+	TimeInForce     string        `json:"timeInForce,omitempty" bson:"timeInForce,omitempty"`
+	PostOnly        bool          `json:"postOnly,omitempty" bson:"postOnly,omitempty"`
+	Hidden          bool          `json:"hidden,omitempty" bson:"hidden,omitempty"`
+	Hash            string        `json:"hash" bson:"hash"`
+	PricePoint      string        `json:"pricepoint" bson:"pricepoint"`
+	StopPricepoint  string        `json:"stopPricepoint,omitempty" bson:"stopPricepoint,omitempty"`
+	TrailingOffset  string        `json:"trailingOffset,omitempty" bson:"trailingOffset,omitempty"`
+	TrailingType    string        `json:"trailingType,omitempty" bson:"trailingType,omitempty"`
+	Watermark       string        `json:"watermark,omitempty" bson:"watermark,omitempty"`
+	DisplayAmount   string        `json:"displayAmount,omitempty" bson:"displayAmount,omitempty"`
+	Amount          string        `json:"amount" bson:"amount"`
+	FilledAmount    string        `json:"filledAmount" bson:"filledAmount"`
+	Nonce           string        `json:"nonce" bson:"nonce"`
+	Expires         string        `json:"expires" bson:"expires"`
+	MakeFee         string        `json:"makeFee" bson:"makeFee"`
+	TakeFee         string        `json:"takeFee" bson:"takeFee"`
+	Signature       *Signature    `json:"signature,omitempty" bson:"signature"`
+	SignatureScheme string        `json:"signatureScheme,omitempty" bson:"signatureScheme"`
 
 	PairName  string    `json:"pairName" bson:"pairName"`
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
@@ -409,67 +697,71 @@ func (o *Order) GetBSON() (interface{}, error) {
 		SellToken:       o.SellToken.Hex(),
 		BaseToken:       o.BaseToken.Hex(),
 		QuoteToken:      o.QuoteToken.Hex(),
-		BuyAmount:       o.BuyAmount.String(),
-		SellAmount:      o.SellAmount.String(),
+		BuyAmount:       encodeBigInt(o.BuyAmount),
+		SellAmount:      encodeBigInt(o.SellAmount),
 		Status:          o.Status,
-		Side:            o.Side,
+		Side:            string(o.Side),
+		Type:            string(o.Type),
+		TimeInForce:     string(o.TimeInForce),
+		PostOnly:        o.PostOnly,
+		Hidden:          o.Hidden,
 		Hash:            o.Hash.Hex(),
-		Nonce:           o.Nonce.String(),
-		Expires:         o.Expires.String(),
-		MakeFee:         o.MakeFee.String(),
-		TakeFee:         o.TakeFee.String(),
+		Nonce:           encodeBigInt(o.Nonce),
+		Expires:         encodeBigInt(o.Expires),
+		MakeFee:         encodeBigInt(o.MakeFee),
+		TakeFee:         encodeBigInt(o.TakeFee),
+		Signature:       o.Signature,
+		SignatureScheme: o.SignatureScheme,
+		PricePoint:      encodeBigInt(o.PricePoint),
+		StopPricepoint:  encodeBigInt(o.StopPricepoint),
+		TrailingOffset:  encodeBigInt(o.TrailingOffset),
+		TrailingType:    string(o.TrailingType),
+		Watermark:       encodeBigInt(o.Watermark),
+		DisplayAmount:   encodeBigInt(o.DisplayAmount),
+		Amount:          encodeBigInt(o.Amount),
+		FilledAmount:    encodeBigInt(o.FilledAmount),
 		CreatedAt:       o.CreatedAt,
 		UpdatedAt:       o.UpdatedAt,
 	}
 
-	if o.PricePoint != nil {
-		or.PricePoint = o.PricePoint.String()
-	}
-
-	if o.Amount != nil {
-		or.Amount = o.Amount.String()
-	}
-
-	if o.FilledAmount != nil {
-		or.FilledAmount = o.FilledAmount.String()
-	}
-
-	if o.Signature != nil {
-		or.Signature = &SignatureRecord{
-			V: o.Signature.V,
-			R: o.Signature.R.Hex(),
-			S: o.Signature.S.Hex(),
-		}
-	}
-
 	return or, nil
 }
 
 func (o *Order) SetBSON(raw bson.Raw) error {
 	decoded := new(struct {
-		ID              bson.ObjectId    `json:"id,omitempty" bson:"_id"`
-		PairName        string           `json:"pairName" bson:"pairName"`
-		ExchangeAddress string           `json:"exchangeAddress" bson:"exchangeAddress"`
-		UserAddress     string           `json:"userAddress" bson:"userAddress"`
-		BuyToken        string           `json:"buyToken" bson:"buyToken"`
-		SellToken       string           `json:"sellToken" bson:"sellToken"`
-		BaseToken       string           `json:"baseToken" bson:"baseToken"`
-		QuoteToken      string           `json:"quoteToken" bson:"quoteToken"`
-		BuyAmount       string           `json:"buyAmount" bson:"buyAmount"`
-		SellAmount      string           `json:"sellAmount" bson:"sellAmount"`
-		Status          string           `json:"status" bson:"status"`
-		Side            string           `json:"side" bson:"side"`
-		Hash            string           `json:"hash" bson:"hash"`
-		PricePoint      string           `json:"pricepoint" bson:"pricepoint"`
-		Amount          string           `json:"amount" bson:"amount"`
-		FilledAmount    string           `json:"filledAmount" bson:"filledAmount"`
-		Nonce           string           `json:"nonce" bson:"nonce"`
-		Expires         string           `json:"expires" bson:"expires"`
-		MakeFee         string           `json:"makeFee" bson:"makeFee"`
-		TakeFee         string           `json:"takeFee" bson:"takeFee"`
-		Signature       *SignatureRecord `json:"signature" bson:"signature"`
-		CreatedAt       time.Time        `json:"createdAt" bson:"createdAt"`
-		UpdatedAt       time.Time        `json:"updatedAt" bson:"updatedAt"`
+		ID              bson.ObjectId `json:"id,omitempty" bson:"_id"`
+		PairName        string        `json:"pairName" bson:"pairName"`
+		ExchangeAddress string        `json:"exchangeAddress" bson:"exchangeAddress"`
+		UserAddress     string        `json:"userAddress" bson:"userAddress"`
+		BuyToken        string        `json:"buyToken" bson:"buyToken"`
+		SellToken       string        `json:"sellToken" bson:"sellToken"`
+		BaseToken       string        `json:"baseToken" bson:"baseToken"`
+		QuoteToken      string        `json:"quoteToken" bson:"quoteToken"`
+		BuyAmount       string        `json:"buyAmount" bson:"buyAmount"`
+		SellAmount      string        `json:"sellAmount" bson:"sellAmount"`
+		Status          string        `json:"status" bson:"status"`
+		Side            string        `json:"side" bson:"side"`
+		Type            string        `json:"type" bson:"type"`
+		TimeInForce     string        `json:"timeInForce" bson:"timeInForce"`
+		PostOnly        bool          `json:"postOnly" bson:"postOnly"`
+		Hidden          bool          `json:"hidden" bson:"hidden"`
+		Hash            string        `json:"hash" bson:"hash"`
+		PricePoint      string        `json:"pricepoint" bson:"pricepoint"`
+		StopPricepoint  string        `json:"stopPricepoint" bson:"stopPricepoint"`
+		TrailingOffset  string        `json:"trailingOffset" bson:"trailingOffset"`
+		TrailingType    string        `json:"trailingType" bson:"trailingType"`
+		Watermark       string        `json:"watermark" bson:"watermark"`
+		DisplayAmount   string        `json:"displayAmount" bson:"displayAmount"`
+		Amount          string        `json:"amount" bson:"amount"`
+		FilledAmount    string        `json:"filledAmount" bson:"filledAmount"`
+		Nonce           string        `json:"nonce" bson:"nonce"`
+		Expires         string        `json:"expires" bson:"expires"`
+		MakeFee         string        `json:"makeFee" bson:"makeFee"`
+		TakeFee         string        `json:"takeFee" bson:"takeFee"`
+		Signature       *Signature    `json:"signature" bson:"signature"`
+		SignatureScheme string        `json:"signatureScheme" bson:"signatureScheme"`
+		CreatedAt       time.Time     `json:"createdAt" bson:"createdAt"`
+		UpdatedAt       time.Time     `json:"updatedAt" bson:"updatedAt"`
 	})
 
 	err := raw.Unmarshal(decoded)
@@ -487,37 +779,45 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 	o.BaseToken = common.HexToAddress(decoded.BaseToken)
 	o.QuoteToken = common.HexToAddress(decoded.QuoteToken)
 
-	o.BuyAmount = math.ToBigInt(decoded.BuyAmount)
-	o.SellAmount = math.ToBigInt(decoded.SellAmount)
-	o.FilledAmount = math.ToBigInt(decoded.FilledAmount)
-
-	o.Nonce = math.ToBigInt(decoded.Nonce)
-	o.Expires = math.ToBigInt(decoded.Expires)
-	o.MakeFee = math.ToBigInt(decoded.MakeFee)
-	o.TakeFee = math.ToBigInt(decoded.TakeFee)
 	o.Status = decoded.Status
-	o.Side = decoded.Side
+	o.Side = OrderSide(decoded.Side)
+	o.Type = OrderType(decoded.Type)
+	o.TimeInForce = TimeInForce(decoded.TimeInForce)
+	o.TrailingType = TrailingType(decoded.TrailingType)
+	o.PostOnly = decoded.PostOnly
+	o.Hidden = decoded.Hidden
 	o.Hash = common.HexToHash(decoded.Hash)
-
-	if decoded.Amount != "" {
-		o.Amount = math.ToBigInt(decoded.Amount)
-	}
-
-	if decoded.FilledAmount != "" {
-		o.FilledAmount = math.ToBigInt(decoded.FilledAmount)
+	o.SignatureScheme = decoded.SignatureScheme
+
+	records := map[string]string{
+		"buyAmount":      decoded.BuyAmount,
+		"sellAmount":     decoded.SellAmount,
+		"makeFee":        decoded.MakeFee,
+		"takeFee":        decoded.TakeFee,
+		"expires":        decoded.Expires,
+		"nonce":          decoded.Nonce,
+		"pricepoint":     decoded.PricePoint,
+		"stopPricepoint": decoded.StopPricepoint,
+		"trailingOffset": decoded.TrailingOffset,
+		"watermark":      decoded.Watermark,
+		"displayAmount":  decoded.DisplayAmount,
+		"amount":         decoded.Amount,
+		"filledAmount":   decoded.FilledAmount,
+	}
+
+	for k, n := range o.bigIntFields() {
+		*n, err = decodeBigInt(records[k])
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
 	}
 
-	if decoded.PricePoint != "" {
-		o.PricePoint = math.ToBigInt(decoded.PricePoint)
+	if o.FilledAmount == nil {
+		o.FilledAmount = big.NewInt(0)
 	}
 
-	if decoded.Signature != nil {
-		o.Signature = &Signature{
-			V: byte(decoded.Signature.V),
-			R: common.HexToHash(decoded.Signature.R),
-			S: common.HexToHash(decoded.Signature.S),
-		}
-	}
+	o.Signature = decoded.Signature
 
 	o.CreatedAt = decoded.CreatedAt
 	o.UpdatedAt = decoded.UpdatedAt
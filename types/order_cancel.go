@@ -4,21 +4,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	. "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"gopkg.in/mgo.v2/bson"
 )
 
 // OrderCancel is a group of params used for canceling an order previously
 // sent to the matching engine. The OrderId and OrderHash must correspond to the
 // same order. To be valid and be able to be processed by the matching engine,
 // the OrderCancel must include a signature by the Maker of the order corresponding
-// to the OrderHash.
+// to the OrderHash. The nonce prevents a cancel message from being replayed.
 type OrderCancel struct {
-	OrderHash Hash       `json:"orderHash"`
-	Hash      Hash       `json:"hash"`
-	Signature *Signature `json:"signature"`
+	ID        bson.ObjectId `json:"id,omitempty" bson:"_id"`
+	OrderHash Hash          `json:"orderHash"`
+	PairName  string        `json:"pairName"`
+	Nonce     *big.Int      `json:"nonce"`
+	Hash      Hash          `json:"hash"`
+	Signature *Signature    `json:"signature"`
+	CreatedAt time.Time     `json:"createdAt"`
 }
 
 // NewOrderCancel returns a new empty OrderCancel object
@@ -35,26 +42,35 @@ func (oc *OrderCancel) MarshalJSON() ([]byte, error) {
 	orderCancel := map[string]interface{}{
 		"orderHash": oc.OrderHash,
 		"hash":      oc.Hash,
-		"signature": map[string]interface{}{
+	}
+
+	if oc.PairName != "" {
+		orderCancel["pairName"] = oc.PairName
+	}
+
+	if oc.Nonce != nil {
+		orderCancel["nonce"] = oc.Nonce.String()
+	}
+
+	if oc.Signature != nil {
+		orderCancel["signature"] = map[string]interface{}{
 			"V": oc.Signature.V,
 			"R": oc.Signature.R,
 			"S": oc.Signature.S,
-		},
+		}
 	}
 
 	return json.Marshal(orderCancel)
 }
 
 func (oc *OrderCancel) String() string {
-	return fmt.Sprintf("\nOrderCancel:\nOrderHash: %x\nHash: %x\nSignature.V: %x\nSignature.R: %x\nSignature.S: %x\n\n",
-		oc.OrderHash, oc.Hash, oc.Signature.V, oc.Signature.R, oc.Signature.S)
+	return fmt.Sprintf("\nOrderCancel:\nOrderHash: %x\nPairName: %v\nNonce: %v\nHash: %x\nSignature.V: %x\nSignature.R: %x\nSignature.S: %x\n\n",
+		oc.OrderHash, oc.PairName, oc.Nonce, oc.Hash, oc.Signature.V, oc.Signature.R, oc.Signature.S)
 }
 
 // UnmarshalJSON creates an OrderCancel object from a json byte string
 func (oc *OrderCancel) UnmarshalJSON(b []byte) error {
-	parsed := map[string]interface{}{}
-
-	err := json.Unmarshal(b, &parsed)
+	parsed, err := unmarshalJSONObject(b)
 	if err != nil {
 		return err
 	}
@@ -69,11 +85,90 @@ func (oc *OrderCancel) UnmarshalJSON(b []byte) error {
 	}
 	oc.Hash = HexToHash(parsed["hash"].(string))
 
-	sig := parsed["signature"].(map[string]interface{})
-	oc.Signature = &Signature{
-		V: byte(sig["V"].(float64)),
-		R: HexToHash(sig["R"].(string)),
-		S: HexToHash(sig["S"].(string)),
+	if parsed["pairName"] != nil {
+		oc.PairName = parsed["pairName"].(string)
+	}
+
+	if parsed["nonce"] != nil {
+		oc.Nonce, err = parseBigInt(parsed["nonce"])
+		if err != nil {
+			return fmt.Errorf("nonce: %v", err)
+		}
+	}
+
+	oc.Signature, err = decodeSignature(parsed["signature"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OrderCancelRecord is the database representation of an OrderCancel
+type OrderCancelRecord struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	OrderHash string        `json:"orderHash" bson:"orderHash"`
+	PairName  string        `json:"pairName" bson:"pairName"`
+	Nonce     string        `json:"nonce" bson:"nonce"`
+	Hash      string        `json:"hash" bson:"hash"`
+	Signature *Signature    `json:"signature" bson:"signature"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+}
+
+func (oc *OrderCancel) GetBSON() (interface{}, error) {
+	ocr := OrderCancelRecord{
+		ID:        oc.ID,
+		OrderHash: oc.OrderHash.Hex(),
+		PairName:  oc.PairName,
+		Hash:      oc.Hash.Hex(),
+		CreatedAt: oc.CreatedAt,
+	}
+
+	if oc.Nonce != nil {
+		ocr.Nonce = oc.Nonce.String()
+	}
+
+	ocr.Signature = oc.Signature
+
+	return ocr, nil
+}
+
+func (oc *OrderCancel) SetBSON(raw bson.Raw) error {
+	decoded := &OrderCancelRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	oc.ID = decoded.ID
+	oc.OrderHash = HexToHash(decoded.OrderHash)
+	oc.PairName = decoded.PairName
+	oc.Hash = HexToHash(decoded.Hash)
+	oc.CreatedAt = decoded.CreatedAt
+
+	if decoded.Nonce != "" {
+		oc.Nonce = math.ToBigInt(decoded.Nonce)
+	}
+
+	oc.Signature = decoded.Signature
+	return nil
+}
+
+// Validate checks that the order cancel nonce and signature are set and that the
+// hash corresponds to the order hash and nonce
+func (oc *OrderCancel) Validate() error {
+	if oc.Nonce == nil {
+		return errors.New("Nonce is missing")
+	}
+
+	if oc.Signature == nil {
+		return errors.New("Signature is missing")
+	}
+
+	if oc.Hash != oc.ComputeHash() {
+		return errors.New("Invalid order cancel hash")
 	}
 
 	return nil
@@ -82,32 +177,30 @@ func (oc *OrderCancel) UnmarshalJSON(b []byte) error {
 // VerifySignature returns a true value if the OrderCancel object signature
 // corresponds to the Maker of the given order
 func (oc *OrderCancel) VerifySignature(o *Order) (bool, error) {
-	message := crypto.Keccak256(
-		[]byte("\x19Ethereum Signed Message:\n32"),
-		oc.Hash.Bytes(),
-	)
-
-	address, err := oc.Signature.Verify(BytesToHash(message))
+	err := oc.Signature.Verify(oc.Hash, o.UserAddress)
 	if err != nil {
 		return false, err
 	}
 
-	if address != o.UserAddress {
-		return false, errors.New("Recovered address is incorrect")
-	}
-
 	return true, nil
 }
 
-// ComputeHash computes the hash of an order cancel message
+// ComputeHash computes the hash of an order cancel message. The hash commits
+// to the hash of the cancelled order and to the cancel nonce.
 func (oc *OrderCancel) ComputeHash() Hash {
+	nonce := Hash{}
+	if oc.Nonce != nil {
+		nonce = BigToHash(oc.Nonce)
+	}
+
 	sha := sha3.NewKeccak256()
 	sha.Write(oc.OrderHash.Bytes())
+	sha.Write(nonce.Bytes())
 	return BytesToHash(sha.Sum(nil))
 }
 
 // Sign first computes the order cancel hash, then signs and sets the signature
-func (oc *OrderCancel) Sign(w *Wallet) error {
+func (oc *OrderCancel) Sign(w Signer) error {
 	h := oc.ComputeHash()
 	sig, err := w.SignHash(h)
 	if err != nil {
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-test/deep"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestOrderCancelJSON(t *testing.T) {
+	expected := &OrderCancel{
+		OrderHash: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		PairName:  "ZRX/WETH",
+		Nonce:     big.NewInt(1000),
+		Hash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+	}
+
+	encoded, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Error encoding order cancel: %v", err)
+	}
+
+	oc := &OrderCancel{}
+	err = json.Unmarshal(encoded, &oc)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	if diff := deep.Equal(expected, oc); diff != nil {
+		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, oc)
+	}
+}
+
+func TestOrderCancelBSON(t *testing.T) {
+	expected := &OrderCancel{
+		ID:        bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		OrderHash: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		PairName:  "ZRX/WETH",
+		Nonce:     big.NewInt(1000),
+		Hash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+		CreatedAt: time.Unix(1405544146, 0),
+	}
+
+	data, err := bson.Marshal(expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	decoded := &OrderCancel{}
+	err = bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if diff := deep.Equal(expected, decoded); diff != nil {
+		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, decoded)
+	}
+}
+
+func TestSignOrderCancel(t *testing.T) {
+	maker := NewWallet()
+	o := &Order{UserAddress: maker.Address, Hash: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff")}
+
+	oc := &OrderCancel{OrderHash: o.Hash, PairName: "ZRX/WETH", Nonce: big.NewInt(1)}
+	err := maker.SignOrderCancel(oc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := oc.Validate(); err != nil {
+		t.Errorf("Expected order cancel to be valid but got: %v", err)
+	}
+
+	if _, err := oc.VerifySignature(o); err != nil {
+		t.Errorf("Expected signature to correspond to the order maker but got: %v", err)
+	}
+
+	other := NewWallet()
+	err = other.SignOrderCancel(oc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := oc.VerifySignature(o); err == nil {
+		t.Error("Expected signature by another account to be rejected")
+	}
+
+	oc.Nonce = big.NewInt(2)
+	if err := oc.Validate(); err == nil {
+		t.Error("Expected order cancel with a modified nonce to be invalid")
+	}
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Fields the orders of an account can be sorted by
+const (
+	OrderSortCreatedAt = "createdAt"
+	OrderSortUpdatedAt = "updatedAt"
+)
+
+// OrderQuery filters and sorts the orders of an account. Statuses, PairName, Side, From and To are
+// optional filters, From being inclusive and To exclusive. Orders are sorted by SortBy, their
+// creation time by default, the most recent first unless Ascending is set. Pages of up to Limit
+// orders are selected either by Offset or, for orders sorted by creation time, by Cursor (see
+// Cursor).
+type OrderQuery struct {
+	Statuses  []string
+	PairName  string
+	Side      OrderSide
+	From      time.Time
+	To        time.Time
+	SortBy    string
+	Ascending bool
+	Offset    int
+	Cursor    string
+	Limit     int
+}
+
+// Validate checks that the statuses, the side and the sort field of the query are valid and that
+// its page is selected either by offset or by cursor
+func (q *OrderQuery) Validate() error {
+	// statuses are stored in uppercase
+	for _, s := range q.Statuses {
+		status, err := ParseOrderStatus(s)
+		if err != nil || status != s {
+			return fmt.Errorf("Invalid order status: %v", s)
+		}
+	}
+
+	if q.Side != "" && q.Side != OrderSideBuy && q.Side != OrderSideSell {
+		return fmt.Errorf("Invalid order side: %v", q.Side)
+	}
+
+	switch q.SortBy {
+	case "", OrderSortCreatedAt, OrderSortUpdatedAt:
+	default:
+		return fmt.Errorf("Invalid sort field: %v", q.SortBy)
+	}
+
+	if q.Limit <= 0 {
+		return errors.New("Invalid limit")
+	}
+
+	if q.Offset < 0 {
+		return errors.New("Invalid offset")
+	}
+
+	if q.Cursor != "" && q.Offset != 0 {
+		return errors.New("Offset and cursor can not be used together")
+	}
+
+	if q.Cursor != "" && q.SortField() != OrderSortCreatedAt {
+		return errors.New("Cursors can only be used with orders sorted by createdAt")
+	}
+
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return errors.New("Invalid time range")
+	}
+
+	return nil
+}
+
+// SortField returns the field the orders are sorted by
+func (q *OrderQuery) SortField() string {
+	if q.SortBy == "" {
+		return OrderSortCreatedAt
+	}
+
+	return q.SortBy
+}
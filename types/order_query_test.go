@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderQueryValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		query OrderQuery
+		valid bool
+	}{
+		{OrderQuery{Limit: 10}, true},
+		{OrderQuery{Limit: 10, Statuses: []string{OrderStatusOpen, OrderStatusFilled}, Side: OrderSideBuy}, true},
+		{OrderQuery{Limit: 10, SortBy: OrderSortUpdatedAt, Offset: 20}, true},
+		{OrderQuery{Limit: 10, Cursor: "cursor", Ascending: true}, true},
+		{OrderQuery{Limit: 10, From: now.Add(-time.Hour), To: now}, true},
+		{OrderQuery{}, false},
+		{OrderQuery{Limit: 10, Statuses: []string{"open"}}, false},
+		{OrderQuery{Limit: 10, Statuses: []string{"UNKNOWN"}}, false},
+		{OrderQuery{Limit: 10, Side: "BOTH"}, false},
+		{OrderQuery{Limit: 10, SortBy: "pricepoint"}, false},
+		{OrderQuery{Limit: 10, Offset: -1}, false},
+		{OrderQuery{Limit: 10, Cursor: "cursor", Offset: 10}, false},
+		{OrderQuery{Limit: 10, Cursor: "cursor", SortBy: OrderSortUpdatedAt}, false},
+		{OrderQuery{Limit: 10, From: now, To: now}, false},
+	}
+
+	for _, test := range tests {
+		err := test.query.Validate()
+		if test.valid {
+			assert.Nil(t, err, "%+v", test.query)
+		} else {
+			assert.NotNil(t, err, "%+v", test.query)
+		}
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	status, err := ParseOrderStatus("partial_filled")
+	assert.Nil(t, err)
+	assert.Equal(t, OrderStatusPartialFilled, status)
+
+	_, err = ParseOrderStatus("UNKNOWN")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	. "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+)
+
+// OrderReduce is a signed message used by a maker to reduce the unfilled amount of one of its
+// orders to NewAmount without losing the position of the order in its price level, which a cancel
+// and replace would. NewAmount must be lower than the unfilled amount of the order: increasing an
+// order requires a new order. The message hash commits to the hash of the order, the new amount
+// and the nonce, and must be signed by the maker of the order. Since the unfilled amount of an
+// order only decreases, a replayed message is always rejected.
+type OrderReduce struct {
+	OrderHash Hash       `json:"orderHash"`
+	NewAmount *big.Int   `json:"newAmount"`
+	Nonce     *big.Int   `json:"nonce"`
+	Hash      Hash       `json:"hash"`
+	Signature *Signature `json:"signature"`
+}
+
+// MarshalJSON returns the json encoded byte array representing the OrderReduce struct
+func (or *OrderReduce) MarshalJSON() ([]byte, error) {
+	orderReduce := map[string]interface{}{
+		"orderHash": or.OrderHash,
+		"hash":      or.Hash,
+	}
+
+	if or.NewAmount != nil {
+		orderReduce["newAmount"] = or.NewAmount.String()
+	}
+
+	if or.Nonce != nil {
+		orderReduce["nonce"] = or.Nonce.String()
+	}
+
+	if or.Signature != nil {
+		orderReduce["signature"] = map[string]interface{}{
+			"V": or.Signature.V,
+			"R": or.Signature.R,
+			"S": or.Signature.S,
+		}
+	}
+
+	return json.Marshal(orderReduce)
+}
+
+// UnmarshalJSON creates an OrderReduce object from a json byte string
+func (or *OrderReduce) UnmarshalJSON(b []byte) error {
+	parsed, err := unmarshalJSONObject(b)
+	if err != nil {
+		return err
+	}
+
+	if parsed["orderHash"] == nil {
+		return errors.New("Order Hash is missing")
+	}
+
+	or.OrderHash, err = parseHash(parsed["orderHash"])
+	if err != nil {
+		return fmt.Errorf("orderHash: %v", err)
+	}
+
+	if parsed["hash"] == nil {
+		return errors.New("Hash is missing")
+	}
+
+	or.Hash, err = parseHash(parsed["hash"])
+	if err != nil {
+		return fmt.Errorf("hash: %v", err)
+	}
+
+	if parsed["newAmount"] != nil {
+		or.NewAmount, err = parseBigInt(parsed["newAmount"])
+		if err != nil {
+			return fmt.Errorf("newAmount: %v", err)
+		}
+	}
+
+	if parsed["nonce"] != nil {
+		or.Nonce, err = parseBigInt(parsed["nonce"])
+		if err != nil {
+			return fmt.Errorf("nonce: %v", err)
+		}
+	}
+
+	or.Signature, err = decodeSignature(parsed["signature"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks that the order hash, the new amount, the nonce and the signature are set, that
+// the new amount is positive and that the hash corresponds to the message content
+func (or *OrderReduce) Validate() error {
+	if or.OrderHash == (Hash{}) {
+		return errors.New("Order Hash is missing")
+	}
+
+	if or.NewAmount == nil {
+		return errors.New("New amount is missing")
+	}
+
+	if or.NewAmount.Sign() <= 0 {
+		return errors.New("New amount must be positive")
+	}
+
+	if or.Nonce == nil {
+		return errors.New("Nonce is missing")
+	}
+
+	if or.Signature == nil {
+		return errors.New("Signature is missing")
+	}
+
+	if or.Hash != or.ComputeHash() {
+		return errors.New("Invalid order reduce hash")
+	}
+
+	return nil
+}
+
+// VerifySignature returns an error if the message was not signed by the maker of the order
+func (or *OrderReduce) VerifySignature(o *Order) error {
+	return or.Signature.Verify(or.Hash, o.UserAddress)
+}
+
+// ComputeHash computes the hash of an order reduce message. The hash commits to the hash of the
+// reduced order, to the new amount and to the nonce.
+func (or *OrderReduce) ComputeHash() Hash {
+	amount := Hash{}
+	if or.NewAmount != nil {
+		amount = BigToHash(or.NewAmount)
+	}
+
+	nonce := Hash{}
+	if or.Nonce != nil {
+		nonce = BigToHash(or.Nonce)
+	}
+
+	sha := sha3.NewKeccak256()
+	sha.Write(or.OrderHash.Bytes())
+	sha.Write(amount.Bytes())
+	sha.Write(nonce.Bytes())
+	return BytesToHash(sha.Sum(nil))
+}
+
+// Sign first computes the order reduce hash, then signs and sets the signature
+func (or *OrderReduce) Sign(w Signer) error {
+	h := or.ComputeHash()
+	sig, err := w.SignHash(h)
+	if err != nil {
+		return err
+	}
+
+	or.Hash = h
+	or.Signature = sig
+	return nil
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderReduceJSON(t *testing.T) {
+	expected := &OrderReduce{
+		OrderHash: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		NewAmount: big.NewInt(500),
+		Nonce:     big.NewInt(1001),
+		Hash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+	}
+
+	encoded, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Error encoding order reduce: %v", err)
+	}
+
+	or := &OrderReduce{}
+	err = json.Unmarshal(encoded, &or)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	assert.Equal(t, expected, or)
+}
+
+func TestSignOrderReduce(t *testing.T) {
+	maker := NewWallet()
+
+	o := newValidTestOrder()
+	o.UserAddress = maker.Address
+	maker.SignOrder(o)
+
+	or := &OrderReduce{OrderHash: o.Hash, NewAmount: big.NewInt(500), Nonce: big.NewInt(1)}
+	err := or.Sign(maker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := or.Validate(); err != nil {
+		t.Errorf("Expected order reduce to be valid but got: %v", err)
+	}
+
+	if err := or.VerifySignature(o); err != nil {
+		t.Errorf("Expected signature to correspond to the maker but got: %v", err)
+	}
+
+	// a message signed by another account is rejected
+	other := NewWallet()
+	sig, err := other.SignHash(or.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := or.Signature
+	or.Signature = sig
+	if err := or.VerifySignature(o); err == nil {
+		t.Error("Expected signature by another account to be rejected")
+	}
+
+	// the message can not be reused for another amount
+	or.Signature = signed
+	or.NewAmount = big.NewInt(100)
+	if err := or.Validate(); err == nil {
+		t.Error("Expected order reduce with a modified amount to be invalid")
+	}
+
+	// the new amount must be positive
+	or.NewAmount = big.NewInt(0)
+	or.Sign(maker)
+	if err := or.Validate(); err == nil {
+		t.Error("Expected order reduce to a zero amount to be invalid")
+	}
+}
+
+func TestReducedOrderAmounts(t *testing.T) {
+	o := &Order{
+		Side:         OrderSideBuy,
+		Amount:       big.NewInt(10),
+		BuyAmount:    big.NewInt(10),
+		SellAmount:   big.NewInt(1000),
+		FilledAmount: big.NewInt(2),
+	}
+
+	assert.False(t, o.IsReduced())
+	assert.Equal(t, big.NewInt(800), o.RemainingSellAmount())
+
+	// the fills of a reduced order are still priced by its signed amounts
+	o.Amount = big.NewInt(6)
+	assert.True(t, o.IsReduced())
+	assert.Equal(t, big.NewInt(400), o.RemainingSellAmount())
+	assert.Equal(t, big.NewInt(100), o.SellAmountFor(big.NewInt(1)))
+
+	sell, buy := o.FillAmounts(o.FilledAmount, big.NewInt(4))
+	assert.Equal(t, big.NewInt(400), sell)
+	assert.Equal(t, big.NewInt(4), buy)
+}
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	. "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+)
+
+// OrderReplace is a signed message used by a maker to atomically cancel one of its orders and
+// replace it with a new order, for example to move a quote to a new price. The replacement is
+// a regular signed order that goes through the same checks as new orders, including the
+// nonce check which prevents the message from being replayed. The message hash commits to
+// the hashes of both orders and must be signed by the maker of the replaced order.
+type OrderReplace struct {
+	OrderHash Hash       `json:"orderHash"`
+	Order     *Order     `json:"order"`
+	Hash      Hash       `json:"hash"`
+	Signature *Signature `json:"signature"`
+}
+
+// MarshalJSON returns the json encoded byte array representing the OrderReplace struct
+func (or *OrderReplace) MarshalJSON() ([]byte, error) {
+	orderReplace := map[string]interface{}{
+		"orderHash": or.OrderHash,
+		"hash":      or.Hash,
+	}
+
+	if or.Order != nil {
+		orderReplace["order"] = or.Order
+	}
+
+	if or.Signature != nil {
+		orderReplace["signature"] = map[string]interface{}{
+			"V": or.Signature.V,
+			"R": or.Signature.R,
+			"S": or.Signature.S,
+		}
+	}
+
+	return json.Marshal(orderReplace)
+}
+
+// UnmarshalJSON creates an OrderReplace object from a json byte string
+func (or *OrderReplace) UnmarshalJSON(b []byte) error {
+	parsed, err := unmarshalJSONObject(b)
+	if err != nil {
+		return err
+	}
+
+	if parsed["orderHash"] == nil {
+		return errors.New("Order Hash is missing")
+	}
+
+	or.OrderHash, err = parseHash(parsed["orderHash"])
+	if err != nil {
+		return fmt.Errorf("orderHash: %v", err)
+	}
+
+	if parsed["hash"] == nil {
+		return errors.New("Hash is missing")
+	}
+
+	or.Hash, err = parseHash(parsed["hash"])
+	if err != nil {
+		return fmt.Errorf("hash: %v", err)
+	}
+
+	if parsed["order"] == nil {
+		return errors.New("Order is missing")
+	}
+
+	encoded, err := json.Marshal(parsed["order"])
+	if err != nil {
+		return err
+	}
+
+	or.Order = &Order{}
+	err = json.Unmarshal(encoded, or.Order)
+	if err != nil {
+		return err
+	}
+
+	or.Signature, err = decodeSignature(parsed["signature"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks that the replaced order hash, the replacement order and the signature are
+// set and that the hash corresponds to the message content. The replacement order itself is
+// validated as a new order.
+func (or *OrderReplace) Validate() error {
+	if or.OrderHash == (Hash{}) {
+		return errors.New("Order Hash is missing")
+	}
+
+	if or.Order == nil {
+		return errors.New("Order is missing")
+	}
+
+	if or.Order.Hash == or.OrderHash {
+		return errors.New("Replacement order must be a new order")
+	}
+
+	if or.Signature == nil {
+		return errors.New("Signature is missing")
+	}
+
+	if or.Hash != or.ComputeHash() {
+		return errors.New("Invalid order replace hash")
+	}
+
+	return nil
+}
+
+// VerifySignature returns an error if the message was not signed by the maker of the
+// replaced order
+func (or *OrderReplace) VerifySignature(o *Order) error {
+	return or.Signature.Verify(or.Hash, o.UserAddress)
+}
+
+// ComputeHash computes the hash of an order replace message. The hash commits to the hash of
+// the replaced order and to the hash of the replacement order.
+func (or *OrderReplace) ComputeHash() Hash {
+	replacement := Hash{}
+	if or.Order != nil {
+		replacement = or.Order.Hash
+	}
+
+	sha := sha3.NewKeccak256()
+	sha.Write(or.OrderHash.Bytes())
+	sha.Write(replacement.Bytes())
+	return BytesToHash(sha.Sum(nil))
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderReplaceJSON(t *testing.T) {
+	expected := &OrderReplace{
+		OrderHash: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		Order:     newValidTestOrder(),
+		Hash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+	}
+
+	encoded, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Error encoding order replace: %v", err)
+	}
+
+	or := &OrderReplace{}
+	err = json.Unmarshal(encoded, &or)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	assert.Equal(t, expected.OrderHash, or.OrderHash)
+	assert.Equal(t, expected.Hash, or.Hash)
+	assert.Equal(t, expected.Signature, or.Signature)
+	assert.Equal(t, expected.Order.Nonce, or.Order.Nonce)
+	assert.Equal(t, expected.Order.BuyAmount, or.Order.BuyAmount)
+	assert.Equal(t, expected.Order.UserAddress, or.Order.UserAddress)
+}
+
+func TestSignOrderReplace(t *testing.T) {
+	maker := NewWallet()
+
+	replaced := newValidTestOrder()
+	replaced.UserAddress = maker.Address
+	maker.SignOrder(replaced)
+
+	replacement := newValidTestOrder()
+	replacement.UserAddress = maker.Address
+	replacement.Nonce.SetInt64(1001)
+
+	or := &OrderReplace{OrderHash: replaced.Hash, Order: replacement}
+	err := maker.SignOrderReplace(or)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := or.Validate(); err != nil {
+		t.Errorf("Expected order replace to be valid but got: %v", err)
+	}
+
+	if err := or.VerifySignature(replaced); err != nil {
+		t.Errorf("Expected signature to correspond to the maker but got: %v", err)
+	}
+
+	// the replacement order is signed along with the message
+	if ok, err := replacement.VerifySignature(); !ok || err != nil {
+		t.Errorf("Expected replacement order signature to be valid but got: %v", err)
+	}
+
+	// a message signed by another account is rejected
+	other := NewWallet()
+	sig, err := other.SignHash(or.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	or.Signature = sig
+	if err := or.VerifySignature(replaced); err == nil {
+		t.Error("Expected signature by another account to be rejected")
+	}
+
+	// the message can not be reused for another replacement order
+	or.Order.Nonce.SetInt64(1002)
+	maker.SignOrder(or.Order)
+	if err := or.Validate(); err == nil {
+		t.Error("Expected order replace with a modified replacement order to be invalid")
+	}
+}
@@ -0,0 +1,219 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OrderSide is the side of an order or trade. It is encoded in json as an uppercase string.
+type OrderSide string
+
+// Order sides
+const (
+	OrderSideBuy  OrderSide = "BUY"
+	OrderSideSell OrderSide = "SELL"
+)
+
+// OrderType is the type of an order. It is encoded in json as an uppercase string. Orders
+// without type are limit orders.
+type OrderType string
+
+// Order types
+const (
+	OrderTypeLimit  OrderType = "LO"
+	OrderTypeMarket OrderType = "MO"
+)
+
+// TimeInForce defines how long an order stays in the orderbook. It is encoded in json as an
+// uppercase string. Orders without time in force are good till cancelled.
+type TimeInForce string
+
+// Time in force options. IOC orders are matched against the orderbook and their unfilled
+// amount is cancelled instead of resting in the orderbook. FOK orders are only matched if
+// they can be filled entirely and are cancelled otherwise.
+const (
+	TimeInForceGTC TimeInForce = "GTC"
+	TimeInForceIOC TimeInForce = "IOC"
+	TimeInForceFOK TimeInForce = "FOK"
+)
+
+// TrailingType defines how the trailing offset of a trailing stop order is expressed. It is
+// encoded in json as an uppercase string. Trailing stops without trailing type have an absolute
+// offset.
+type TrailingType string
+
+// Trailing types. ABSOLUTE offsets are expressed in pricepoint units and PERCENT offsets in
+// hundredths of a percent of the pricepoint (basis points).
+const (
+	TrailingAbsolute TrailingType = "ABSOLUTE"
+	TrailingPercent  TrailingType = "PERCENT"
+)
+
+// ParseOrderSide returns the order side corresponding to s regardless of its case. An empty
+// string corresponds to an unset side.
+func ParseOrderSide(s string) (OrderSide, error) {
+	side := OrderSide(strings.ToUpper(s))
+	switch side {
+	case "", OrderSideBuy, OrderSideSell:
+		return side, nil
+	default:
+		return "", fmt.Errorf("Invalid order side: %v", s)
+	}
+}
+
+// Opposite returns the side of the orders that can be matched against an order of side s
+func (s OrderSide) Opposite() OrderSide {
+	switch s {
+	case OrderSideBuy:
+		return OrderSideSell
+	case OrderSideSell:
+		return OrderSideBuy
+	default:
+		return ""
+	}
+}
+
+// MarshalJSON returns the side as an uppercase json string
+func (s OrderSide) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(string(s)))
+}
+
+// UnmarshalJSON decodes a side regardless of its case and rejects unknown sides
+func (s *OrderSide) UnmarshalJSON(b []byte) error {
+	var str string
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return err
+	}
+
+	*s, err = ParseOrderSide(str)
+	return err
+}
+
+// ParseOrderType returns the order type corresponding to s regardless of its case. An empty
+// string corresponds to an unset type.
+func ParseOrderType(s string) (OrderType, error) {
+	t := OrderType(strings.ToUpper(s))
+	switch t {
+	case "", OrderTypeLimit, OrderTypeMarket:
+		return t, nil
+	default:
+		return "", fmt.Errorf("Invalid order type: %v", s)
+	}
+}
+
+// MarshalJSON returns the type as an uppercase json string
+func (t OrderType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(string(t)))
+}
+
+// UnmarshalJSON decodes an order type regardless of its case and rejects unknown types
+func (t *OrderType) UnmarshalJSON(b []byte) error {
+	var str string
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return err
+	}
+
+	*t, err = ParseOrderType(str)
+	return err
+}
+
+// ParseTimeInForce returns the time in force corresponding to s regardless of its case. An
+// empty string corresponds to an unset time in force.
+func ParseTimeInForce(s string) (TimeInForce, error) {
+	tif := TimeInForce(strings.ToUpper(s))
+	switch tif {
+	case "", TimeInForceGTC, TimeInForceIOC, TimeInForceFOK:
+		return tif, nil
+	default:
+		return "", fmt.Errorf("Invalid time in force: %v", s)
+	}
+}
+
+// MarshalJSON returns the time in force as an uppercase json string
+func (tif TimeInForce) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(string(tif)))
+}
+
+// UnmarshalJSON decodes a time in force regardless of its case and rejects unknown values
+func (tif *TimeInForce) UnmarshalJSON(b []byte) error {
+	var str string
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return err
+	}
+
+	*tif, err = ParseTimeInForce(str)
+	return err
+}
+
+// ParseTrailingType returns the trailing type corresponding to s regardless of its case. An
+// empty string corresponds to an unset trailing type.
+func ParseTrailingType(s string) (TrailingType, error) {
+	t := TrailingType(strings.ToUpper(s))
+	switch t {
+	case "", TrailingAbsolute, TrailingPercent:
+		return t, nil
+	default:
+		return "", fmt.Errorf("Invalid trailing type: %v", s)
+	}
+}
+
+// MarshalJSON returns the trailing type as an uppercase json string
+func (t TrailingType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(string(t)))
+}
+
+// UnmarshalJSON decodes a trailing type regardless of its case and rejects unknown values
+func (t *TrailingType) UnmarshalJSON(b []byte) error {
+	var str string
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return err
+	}
+
+	*t, err = ParseTrailingType(str)
+	return err
+}
+
+// parseOrderSide parses a decoded json value into an order side
+func parseOrderSide(v interface{}) (OrderSide, error) {
+	s, err := parseString(v)
+	if err != nil {
+		return "", err
+	}
+
+	return ParseOrderSide(s)
+}
+
+// parseOrderType parses a decoded json value into an order type
+func parseOrderType(v interface{}) (OrderType, error) {
+	s, err := parseString(v)
+	if err != nil {
+		return "", err
+	}
+
+	return ParseOrderType(s)
+}
+
+// parseTimeInForce parses a decoded json value into a time in force
+func parseTimeInForce(v interface{}) (TimeInForce, error) {
+	s, err := parseString(v)
+	if err != nil {
+		return "", err
+	}
+
+	return ParseTimeInForce(s)
+}
+
+// parseTrailingType parses a decoded json value into a trailing type
+func parseTrailingType(v interface{}) (TrailingType, error) {
+	s, err := parseString(v)
+	if err != nil {
+		return "", err
+	}
+
+	return ParseTrailingType(s)
+}
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrderSide(t *testing.T) {
+	valid := map[string]OrderSide{
+		"BUY":  OrderSideBuy,
+		"buy":  OrderSideBuy,
+		"Sell": OrderSideSell,
+		"":     "",
+	}
+
+	for s, expected := range valid {
+		side, err := ParseOrderSide(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, side, s)
+	}
+
+	for _, s := range []string{"BID", "buy ", "LO"} {
+		_, err := ParseOrderSide(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestOrderSideOpposite(t *testing.T) {
+	assert.Equal(t, OrderSideSell, OrderSideBuy.Opposite())
+	assert.Equal(t, OrderSideBuy, OrderSideSell.Opposite())
+	assert.Equal(t, OrderSide(""), OrderSide("").Opposite())
+}
+
+func TestOrderSideJSON(t *testing.T) {
+	encoded, err := json.Marshal(OrderSideBuy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `"BUY"`, string(encoded))
+
+	var side OrderSide
+	assert.Nil(t, json.Unmarshal([]byte(`"sell"`), &side))
+	assert.Equal(t, OrderSideSell, side)
+
+	assert.Error(t, json.Unmarshal([]byte(`"HOLD"`), &side))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &side))
+}
+
+func TestOrderTypeJSON(t *testing.T) {
+	encoded, err := json.Marshal(OrderTypeMarket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `"MO"`, string(encoded))
+
+	var orderType OrderType
+	assert.Nil(t, json.Unmarshal([]byte(`"lo"`), &orderType))
+	assert.Equal(t, OrderTypeLimit, orderType)
+
+	assert.Error(t, json.Unmarshal([]byte(`"STOP"`), &orderType))
+}
+
+func TestTimeInForceJSON(t *testing.T) {
+	encoded, err := json.Marshal(TimeInForceFOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `"FOK"`, string(encoded))
+
+	var tif TimeInForce
+	assert.Nil(t, json.Unmarshal([]byte(`"ioc"`), &tif))
+	assert.Equal(t, TimeInForceIOC, tif)
+
+	assert.Error(t, json.Unmarshal([]byte(`"GTD"`), &tif))
+}
+
+func TestOrderUnmarshalJSONSide(t *testing.T) {
+	o := &Order{}
+	err := json.Unmarshal([]byte(`{"side": "buy", "type": "lo"}`), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, OrderSideBuy, o.Side)
+	assert.Equal(t, OrderTypeLimit, o.Type)
+
+	err = json.Unmarshal([]byte(`{"side": "bid", "type": "stop"}`), &Order{})
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+
+	assert.Equal(t, []string{"side", "type"}, []string{errs[0].Field, errs[1].Field})
+}
+
+func TestTrailingTypeJSON(t *testing.T) {
+	encoded, err := json.Marshal(TrailingPercent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `"PERCENT"`, string(encoded))
+
+	var trailingType TrailingType
+	assert.Nil(t, json.Unmarshal([]byte(`"absolute"`), &trailingType))
+	assert.Equal(t, TrailingAbsolute, trailingType)
+
+	assert.Error(t, json.Unmarshal([]byte(`"RELATIVE"`), &trailingType))
+}
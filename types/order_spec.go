@@ -0,0 +1,217 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// OrderSpec is the representation of an order returned by the HTTP and websocket API.
+// Only the fields of OrderSpec are exposed, so that fields added to Order are never returned
+// to API callers unless they are explicitly added here. Public specs describe the orders of
+// any user. Private specs are only sent to the maker of the order and additionally include
+// the order bookkeeping fields (ID, FilledAmount and UpdatedAt), and the position of the order in
+// its price level when it is known.
+// The public specs of iceberg orders do not reveal their size: their amount is the amount
+// displayed in the orderbook and their buy and sell amounts are omitted. Only private specs
+// include their display amount. Hidden orders are left out of the orderbook feeds: their specs
+// are only returned by the queries of the orders of their maker and sent to their maker.
+type OrderSpec struct {
+	Hash            common.Hash
+	UserAddress     common.Address
+	ExchangeAddress common.Address
+	BuyToken        common.Address
+	SellToken       common.Address
+	BaseToken       common.Address
+	QuoteToken      common.Address
+	BuyAmount       *big.Int
+	SellAmount      *big.Int
+	Side            OrderSide
+	Type            OrderType
+	TimeInForce     TimeInForce
+	PostOnly        bool
+	Hidden          bool
+	Status          string
+	PricePoint      *big.Int
+	StopPricepoint  *big.Int
+	TrailingOffset  *big.Int
+	TrailingType    TrailingType
+	Watermark       *big.Int
+	Amount          *big.Int
+	Nonce           *big.Int
+	Expires         *big.Int
+	MakeFee         *big.Int
+	TakeFee         *big.Int
+	PairName        string
+	Signature       *Signature
+	SignatureScheme string
+	CreatedAt       time.Time
+
+	// private fields, only set in the specs sent to the maker of the order
+	ID            *bson.ObjectId
+	FilledAmount  *big.Int
+	DisplayAmount *big.Int
+	UpdatedAt     *time.Time
+	Position      *OrderPosition
+}
+
+// ToAPI returns the public API representation of the order
+func (o *Order) ToAPI() *OrderSpec {
+	spec := o.toSpec()
+	if o.IsIceberg() {
+		spec.Amount = o.DisplayedAmount()
+		spec.BuyAmount = nil
+		spec.SellAmount = nil
+	}
+
+	return spec
+}
+
+// toSpec returns the API representation of all the public fields of the order
+func (o *Order) toSpec() *OrderSpec {
+	return &OrderSpec{
+		Hash:            o.Hash,
+		UserAddress:     o.UserAddress,
+		ExchangeAddress: o.ExchangeAddress,
+		BuyToken:        o.BuyToken,
+		SellToken:       o.SellToken,
+		BaseToken:       o.BaseToken,
+		QuoteToken:      o.QuoteToken,
+		BuyAmount:       o.BuyAmount,
+		SellAmount:      o.SellAmount,
+		Side:            o.Side,
+		Type:            o.Type,
+		TimeInForce:     o.TimeInForce,
+		PostOnly:        o.PostOnly,
+		Hidden:          o.Hidden,
+		Status:          o.Status,
+		PricePoint:      o.PricePoint,
+		StopPricepoint:  o.StopPricepoint,
+		TrailingOffset:  o.TrailingOffset,
+		TrailingType:    o.TrailingType,
+		Watermark:       o.Watermark,
+		Amount:          o.Amount,
+		Nonce:           o.Nonce,
+		Expires:         o.Expires,
+		MakeFee:         o.MakeFee,
+		TakeFee:         o.TakeFee,
+		PairName:        o.PairName,
+		Signature:       o.Signature,
+		SignatureScheme: o.SignatureScheme,
+		CreatedAt:       o.CreatedAt,
+	}
+}
+
+// ToPrivateAPI returns the API representation of the order sent to its maker
+func (o *Order) ToPrivateAPI() *OrderSpec {
+	spec := o.toSpec()
+	if o.ID.Valid() {
+		id := o.ID
+		spec.ID = &id
+	}
+
+	updatedAt := o.UpdatedAt
+	spec.FilledAmount = o.FilledAmount
+	spec.DisplayAmount = o.DisplayAmount
+	spec.UpdatedAt = &updatedAt
+	return spec
+}
+
+// OrdersToAPI returns the public API representation of a list of orders
+func OrdersToAPI(orders []*Order) []*OrderSpec {
+	specs := []*OrderSpec{}
+	for _, o := range orders {
+		specs = append(specs, o.ToAPI())
+	}
+
+	return specs
+}
+
+// MarshalJSON returns the json encoded order spec. Integers are encoded as decimal strings
+// and unset integers are omitted.
+func (s *OrderSpec) MarshalJSON() ([]byte, error) {
+	spec := map[string]interface{}{
+		"hash":            s.Hash.Hex(),
+		"userAddress":     s.UserAddress.Hex(),
+		"exchangeAddress": s.ExchangeAddress.Hex(),
+		"buyToken":        s.BuyToken.Hex(),
+		"sellToken":       s.SellToken.Hex(),
+		"baseToken":       s.BaseToken.Hex(),
+		"quoteToken":      s.QuoteToken.Hex(),
+		"side":            s.Side,
+		"status":          s.Status,
+		"pairName":        s.PairName,
+		"createdAt":       s.CreatedAt.Format(time.RFC3339Nano),
+	}
+
+	bigInts := map[string]*big.Int{
+		"buyAmount":      s.BuyAmount,
+		"sellAmount":     s.SellAmount,
+		"pricepoint":     s.PricePoint,
+		"stopPricepoint": s.StopPricepoint,
+		"trailingOffset": s.TrailingOffset,
+		"watermark":      s.Watermark,
+		"amount":         s.Amount,
+		"nonce":          s.Nonce,
+		"expires":        s.Expires,
+		"makeFee":        s.MakeFee,
+		"takeFee":        s.TakeFee,
+		"filledAmount":   s.FilledAmount,
+		"displayAmount":  s.DisplayAmount,
+	}
+
+	for k, n := range bigInts {
+		if n != nil {
+			spec[k] = n.String()
+		}
+	}
+
+	if s.Type != "" {
+		spec["type"] = s.Type
+	}
+
+	if s.TimeInForce != "" {
+		spec["timeInForce"] = s.TimeInForce
+	}
+
+	if s.PostOnly {
+		spec["postOnly"] = true
+	}
+
+	if s.TrailingType != "" {
+		spec["trailingType"] = s.TrailingType
+	}
+
+	if s.Hidden {
+		spec["hidden"] = true
+	}
+
+	if s.Signature != nil {
+		spec["signature"] = map[string]interface{}{
+			"V": s.Signature.V,
+			"R": s.Signature.R,
+			"S": s.Signature.S,
+		}
+	}
+
+	if s.SignatureScheme != "" {
+		spec["signatureScheme"] = s.SignatureScheme
+	}
+
+	if s.ID != nil {
+		spec["id"] = s.ID.Hex()
+	}
+
+	if s.UpdatedAt != nil {
+		spec["updatedAt"] = s.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	if s.Position != nil {
+		spec["position"] = s.Position.ToAPI()
+	}
+
+	return json.Marshal(spec)
+}
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// publicOrderFields are the order fields exposed to any API caller
+var publicOrderFields = []string{
+	"UserAddress", "ExchangeAddress", "BuyToken", "SellToken", "BaseToken", "QuoteToken",
+	"BuyAmount", "SellAmount", "Status", "Side", "Type", "TimeInForce", "PostOnly", "Hidden", "Hash",
+	"Signature", "SignatureScheme", "PricePoint", "StopPricepoint", "TrailingOffset", "TrailingType", "Watermark", "Amount", "Nonce", "Expires",
+	"MakeFee", "TakeFee", "PairName", "CreatedAt",
+}
+
+// privateOrderFields are the order fields only exposed to the maker of the order
+var privateOrderFields = []string{"ID", "FilledAmount", "DisplayAmount", "UpdatedAt"}
+
+// orderJSONKeys returns the sorted json keys of the given order fields
+func orderJSONKeys(t *testing.T, fields ...string) []string {
+	keys := []string{}
+	for _, name := range fields {
+		f, ok := reflect.TypeOf(Order{}).FieldByName(name)
+		if !ok {
+			t.Fatalf("Order has no field %v", name)
+		}
+
+		keys = append(keys, strings.Split(f.Tag.Get("json"), ",")[0])
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// specJSONKeys returns the sorted json keys of an encoded order spec
+func specJSONKeys(t *testing.T, spec *OrderSpec) []string {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := map[string]interface{}{}
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{}
+	for k := range decoded {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func TestOrderSpecFieldsWhitelisted(t *testing.T) {
+	whitelisted := map[string]bool{}
+	for _, f := range append(publicOrderFields, privateOrderFields...) {
+		whitelisted[f] = true
+	}
+
+	typ := reflect.TypeOf(Order{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		if !whitelisted[name] {
+			t.Errorf("Order field %v must be added to the public or private order fields and to OrderSpec", name)
+		}
+	}
+}
+
+func TestOrderToAPI(t *testing.T) {
+	o := &Order{
+		ID:              bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		UserAddress:     common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BaseToken:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		Status:          OrderStatusOpen,
+		Side:            OrderSideBuy,
+		Type:            OrderTypeLimit,
+		TimeInForce:     TimeInForceGTC,
+		PostOnly:        true,
+		Hidden:          true,
+		Hash:            common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+		SignatureScheme: EIP712SignScheme,
+		PricePoint:      big.NewInt(10000000),
+		StopPricepoint:  big.NewInt(9000000),
+		TrailingOffset:  big.NewInt(500),
+		TrailingType:    TrailingPercent,
+		Watermark:       big.NewInt(9500000),
+		Amount:          big.NewInt(1000),
+		FilledAmount:    big.NewInt(100),
+		DisplayAmount:   big.NewInt(1000),
+		Nonce:           big.NewInt(1),
+		Expires:         big.NewInt(10000),
+		MakeFee:         big.NewInt(50),
+		TakeFee:         big.NewInt(50),
+		PairName:        "ZRX/WETH",
+		CreatedAt:       time.Unix(1405544146, 0),
+		UpdatedAt:       time.Unix(1405544146, 0),
+	}
+
+	assert.Equal(t, orderJSONKeys(t, publicOrderFields...), specJSONKeys(t, o.ToAPI()))
+	assert.Equal(t, orderJSONKeys(t, append(publicOrderFields, privateOrderFields...)...), specJSONKeys(t, o.ToPrivateAPI()))
+
+	// the api representation of an order can be decoded back into an order
+	b, _ := json.Marshal(o.ToPrivateAPI())
+	decoded := &Order{}
+	err := json.Unmarshal(b, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.Hash, decoded.Hash)
+	assert.Equal(t, o.ID, decoded.ID)
+	assert.Equal(t, o.FilledAmount, decoded.FilledAmount)
+}
+
+func TestIcebergOrderToAPI(t *testing.T) {
+	o := &Order{
+		BuyAmount:     big.NewInt(1000),
+		SellAmount:    big.NewInt(100),
+		Side:          OrderSideBuy,
+		Amount:        big.NewInt(1000),
+		FilledAmount:  big.NewInt(350),
+		DisplayAmount: big.NewInt(300),
+	}
+
+	// the public spec only shows the unfilled amount of the current slice
+	spec := o.ToAPI()
+	assert.Equal(t, "250", spec.Amount.String())
+	assert.Nil(t, spec.BuyAmount)
+	assert.Nil(t, spec.SellAmount)
+	assert.Nil(t, spec.DisplayAmount)
+
+	private := o.ToPrivateAPI()
+	assert.Equal(t, "1000", private.Amount.String())
+	assert.Equal(t, "1000", private.BuyAmount.String())
+	assert.Equal(t, "300", private.DisplayAmount.String())
+}
+
+func TestOrderSpecPosition(t *testing.T) {
+	o := &Order{Hash: common.HexToHash("0x1"), Amount: big.NewInt(1000)}
+
+	spec := o.ToPrivateAPI()
+	spec.Position = &OrderPosition{Position: 3, AmountAhead: big.NewInt(2000)}
+
+	b, _ := json.Marshal(spec)
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]interface{}{"position": float64(3), "amountAhead": "2000"}, decoded["position"])
+}
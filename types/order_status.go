@@ -0,0 +1,146 @@
+package types
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+)
+
+// Order statuses. REPLACED is used for orders that were partially matched when received by
+// the engine and replaced by their remaining order. INVALID is used for orders that were
+// rejected by the exchange smart contract. STOP is used for stop orders waiting for their
+// stop pricepoint to be crossed. REJECTED is used for post-only orders refused by the engine
+// because they would have been matched.
+const (
+	OrderStatusNew           = "NEW"
+	OrderStatusStop          = "STOP"
+	OrderStatusOpen          = "OPEN"
+	OrderStatusPartialFilled = "PARTIAL_FILLED"
+	OrderStatusFilled        = "FILLED"
+	OrderStatusReplaced      = "REPLACED"
+	OrderStatusCancelled     = "CANCELLED"
+	OrderStatusExpired       = "EXPIRED"
+	OrderStatusInvalid       = "INVALID"
+	OrderStatusRejected      = "REJECTED"
+	OrderStatusError         = "ERROR"
+)
+
+// orderStatuses lists the valid order statuses
+var orderStatuses = []string{
+	OrderStatusNew,
+	OrderStatusStop,
+	OrderStatusOpen,
+	OrderStatusPartialFilled,
+	OrderStatusFilled,
+	OrderStatusReplaced,
+	OrderStatusCancelled,
+	OrderStatusExpired,
+	OrderStatusInvalid,
+	OrderStatusRejected,
+	OrderStatusError,
+}
+
+// ParseOrderStatus returns the order status corresponding to s regardless of its case. Unknown
+// statuses are rejected.
+func ParseOrderStatus(s string) (string, error) {
+	status := strings.ToUpper(s)
+	for _, st := range orderStatuses {
+		if st == status {
+			return status, nil
+		}
+	}
+
+	return "", fmt.Errorf("Invalid order status: %v", s)
+}
+
+// orderStatusTransitions lists the statuses an order can move to from a given status.
+// Filled orders move back to open or partially filled when one of their trades fails to settle
+// and its amount is given back to them, and replaced orders can only move to an error status. Open orders can be rejected since triggered stop orders are open when they
+// reach the engine. Cancelled, expired, invalid, rejected and errored orders can not be updated
+// anymore.
+var orderStatusTransitions = map[string][]string{
+	OrderStatusNew: {
+		OrderStatusStop,
+		OrderStatusOpen,
+		OrderStatusPartialFilled,
+		OrderStatusFilled,
+		OrderStatusReplaced,
+		OrderStatusCancelled,
+		OrderStatusExpired,
+		OrderStatusInvalid,
+		OrderStatusRejected,
+		OrderStatusError,
+	},
+	OrderStatusOpen: {
+		OrderStatusPartialFilled,
+		OrderStatusFilled,
+		OrderStatusReplaced,
+		OrderStatusCancelled,
+		OrderStatusExpired,
+		OrderStatusInvalid,
+		OrderStatusRejected,
+		OrderStatusError,
+	},
+	OrderStatusStop: {
+		OrderStatusOpen,
+		OrderStatusCancelled,
+		OrderStatusExpired,
+		OrderStatusError,
+	},
+	OrderStatusPartialFilled: {
+		OrderStatusOpen,
+		OrderStatusFilled,
+		OrderStatusCancelled,
+		OrderStatusExpired,
+		OrderStatusInvalid,
+		OrderStatusError,
+	},
+	OrderStatusFilled: {
+		OrderStatusOpen,
+		OrderStatusPartialFilled,
+		OrderStatusInvalid,
+		OrderStatusError,
+	},
+	OrderStatusReplaced: {
+		OrderStatusInvalid,
+		OrderStatusError,
+	},
+}
+
+// illegalOrderStatusTransitions counts the rejected status transitions. It is published on
+// the /debug/vars endpoint and is mostly caused by engine and operator messages racing.
+var illegalOrderStatusTransitions = expvar.NewInt("illegal_order_status_transitions")
+
+// CanTransitionOrderStatus returns true if an order can move from the status 'from' to the
+// status 'to'. Orders without status are considered new and updating an order to its
+// current status is always allowed.
+func CanTransitionOrderStatus(from, to string) bool {
+	if from == "" {
+		from = OrderStatusNew
+	}
+
+	if from == to {
+		return true
+	}
+
+	for _, s := range orderStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionTo updates the order status. An error is returned and the order is left
+// unchanged if the transition from the current status is not legal.
+func (o *Order) TransitionTo(status string) error {
+	if !CanTransitionOrderStatus(o.Status, status) {
+		illegalOrderStatusTransitions.Add(1)
+		logger.Warning("Illegal order status transition", o.Hash.Hex(), o.Status, status)
+		return fmt.Errorf("Order status can not change from %v to %v", o.Status, status)
+	}
+
+	o.Status = status
+	return nil
+}
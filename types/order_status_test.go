@@ -0,0 +1,57 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderTransitionTo(t *testing.T) {
+	tests := []struct {
+		from  string
+		to    string
+		legal bool
+	}{
+		{"", OrderStatusOpen, true},
+		{OrderStatusNew, OrderStatusOpen, true},
+		{OrderStatusOpen, OrderStatusPartialFilled, true},
+		{OrderStatusOpen, OrderStatusFilled, true},
+		{OrderStatusOpen, OrderStatusCancelled, true},
+		{OrderStatusOpen, OrderStatusExpired, true},
+		{OrderStatusPartialFilled, OrderStatusPartialFilled, true},
+		{OrderStatusPartialFilled, OrderStatusOpen, true},
+		{OrderStatusPartialFilled, OrderStatusFilled, true},
+		{OrderStatusFilled, OrderStatusError, true},
+		{OrderStatusFilled, OrderStatusOpen, true},
+		{OrderStatusFilled, OrderStatusPartialFilled, true},
+		{OrderStatusNew, OrderStatusStop, true},
+		{OrderStatusStop, OrderStatusOpen, true},
+		{OrderStatusStop, OrderStatusCancelled, true},
+		{OrderStatusStop, OrderStatusFilled, false},
+		{OrderStatusOpen, OrderStatusStop, false},
+		{OrderStatusNew, OrderStatusRejected, true},
+		{OrderStatusOpen, OrderStatusRejected, true},
+		{OrderStatusRejected, OrderStatusOpen, false},
+		{OrderStatusFilled, OrderStatusCancelled, false},
+		{OrderStatusCancelled, OrderStatusOpen, false},
+		{OrderStatusCancelled, OrderStatusFilled, false},
+		{OrderStatusExpired, OrderStatusOpen, false},
+		{OrderStatusError, OrderStatusOpen, false},
+	}
+
+	for _, test := range tests {
+		o := &Order{Status: test.from}
+		before := illegalOrderStatusTransitions.Value()
+
+		err := o.TransitionTo(test.to)
+		if test.legal {
+			assert.Nil(t, err, "%v -> %v", test.from, test.to)
+			assert.Equal(t, test.to, o.Status)
+			assert.Equal(t, before, illegalOrderStatusTransitions.Value())
+		} else {
+			assert.NotNil(t, err, "%v -> %v", test.from, test.to)
+			assert.Equal(t, test.from, o.Status)
+			assert.Equal(t, before+1, illegalOrderStatusTransitions.Value())
+		}
+	}
+}
@@ -4,15 +4,317 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"testing"
+	"testing/quick"
 	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-test/deep"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
 )
 
+func newValidTestOrder() *Order {
+	return &Order{
+		UserAddress:     common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		MakeFee:         big.NewInt(50),
+		TakeFee:         big.NewInt(50),
+		Expires:         big.NewInt(time.Now().Add(time.Hour).Unix()),
+		Nonce:           big.NewInt(1000),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+	}
+}
+
+func TestOrderValidate(t *testing.T) {
+	app.Config.Ethereum = map[string]string{"exchange_address": "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"}
+	app.Config.ExchangeAddresses = []string{"0x5d0e9f8d3f66bcb133e1f97aaa44937be5a48920"}
+	defer func() { app.Config.ExchangeAddresses = nil }()
+
+	tests := []struct {
+		name   string
+		modify func(o *Order)
+		fields []string
+	}{
+		{"valid order", func(o *Order) {}, nil},
+		{"zero buy amount", func(o *Order) { o.BuyAmount = big.NewInt(0) }, []string{"buyAmount"}},
+		{"missing buy amount", func(o *Order) { o.BuyAmount = nil }, []string{"buyAmount"}},
+		{"negative sell amount", func(o *Order) { o.SellAmount = big.NewInt(-1) }, []string{"sellAmount"}},
+		{"missing buy token", func(o *Order) { o.BuyToken = common.Address{} }, []string{"buyToken"}},
+		{"missing sell token", func(o *Order) { o.SellToken = common.Address{} }, []string{"sellToken"}},
+		{"identical tokens", func(o *Order) { o.SellToken = o.BuyToken }, []string{"sellToken"}},
+		{"missing maker", func(o *Order) { o.UserAddress = common.Address{} }, []string{"userAddress"}},
+		{"wrong exchange", func(o *Order) { o.ExchangeAddress = common.HexToAddress("0x1") }, []string{"exchangeAddress"}},
+		{"secondary exchange", func(o *Order) { o.ExchangeAddress = common.HexToAddress("0x5d0e9f8d3f66bcb133e1f97aaa44937be5a48920") }, nil},
+		{"negative make fee", func(o *Order) { o.MakeFee = big.NewInt(-1) }, []string{"makeFee"}},
+		{"missing take fee", func(o *Order) { o.TakeFee = nil }, []string{"takeFee"}},
+		{"expired", func(o *Order) { o.Expires = big.NewInt(time.Now().Add(-time.Hour).Unix()) }, []string{"expires"}},
+		{"missing expiry", func(o *Order) { o.Expires = nil }, []string{"expires"}},
+		{"never expires", func(o *Order) { o.Expires = big.NewInt(0) }, nil},
+		{"missing nonce", func(o *Order) { o.Nonce = nil }, []string{"nonce"}},
+		{"missing signature", func(o *Order) { o.Signature = nil }, []string{"signature"}},
+		{"limit order", func(o *Order) { o.Type = OrderTypeLimit }, nil},
+		{"market order", func(o *Order) { o.Type = OrderTypeMarket }, []string{"type"}},
+		{"trailing stop order", func(o *Order) { o.StopPricepoint, o.TrailingOffset = big.NewInt(900), big.NewInt(50) }, nil},
+		{"trailing stop without stop pricepoint", func(o *Order) { o.TrailingOffset = big.NewInt(50) }, []string{"trailingOffset"}},
+		{"trailing type without offset", func(o *Order) { o.TrailingType = TrailingPercent }, []string{"trailingType"}},
+		{
+			"trailing percentage of 100%",
+			func(o *Order) {
+				o.StopPricepoint = big.NewInt(900)
+				o.TrailingOffset = big.NewInt(10000)
+				o.TrailingType = TrailingPercent
+			},
+			[]string{"trailingOffset"},
+		},
+		{"iceberg order", func(o *Order) { o.DisplayAmount = big.NewInt(10) }, nil},
+		{"zero display amount", func(o *Order) { o.DisplayAmount = big.NewInt(0) }, []string{"displayAmount"}},
+		{
+			"immediate-or-cancel iceberg order",
+			func(o *Order) {
+				o.DisplayAmount = big.NewInt(10)
+				o.TimeInForce = TimeInForceIOC
+			},
+			[]string{"displayAmount"},
+		},
+		{"hidden order", func(o *Order) { o.Hidden = true }, nil},
+		{
+			"hidden iceberg order",
+			func(o *Order) {
+				o.Hidden = true
+				o.DisplayAmount = big.NewInt(10)
+			},
+			[]string{"hidden"},
+		},
+		{
+			"fill-or-kill hidden order",
+			func(o *Order) {
+				o.Hidden = true
+				o.TimeInForce = TimeInForceFOK
+			},
+			[]string{"hidden"},
+		},
+		{
+			"multiple errors",
+			func(o *Order) {
+				o.BuyAmount = big.NewInt(0)
+				o.SellAmount = big.NewInt(0)
+				o.Signature = nil
+			},
+			[]string{"buyAmount", "sellAmount", "signature"},
+		},
+	}
+
+	for _, test := range tests {
+		o := newValidTestOrder()
+		test.modify(o)
+
+		err := o.Validate()
+		if test.fields == nil {
+			assert.Nil(t, err, test.name)
+			continue
+		}
+
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			t.Errorf("%v: expected validation errors but got %v", test.name, err)
+			continue
+		}
+
+		fields := []string{}
+		for _, e := range errs {
+			fields = append(fields, e.Field)
+		}
+
+		assert.Equal(t, test.fields, fields, test.name)
+	}
+}
+
+func TestOrderIsExpired(t *testing.T) {
+	now := time.Now()
+	o := newValidTestOrder()
+
+	o.Expires = big.NewInt(now.Add(time.Hour).Unix())
+	assert.False(t, o.IsExpired(now))
+	assert.True(t, o.IsExpired(now.Add(2*time.Hour)))
+
+	o.Expires = big.NewInt(0)
+	assert.False(t, o.IsExpired(now.Add(1000*time.Hour)))
+}
+
+func TestOrderIsStopTriggered(t *testing.T) {
+	o := newValidTestOrder()
+	assert.False(t, o.IsStopTriggered(big.NewInt(1000)))
+
+	o.StopPricepoint = big.NewInt(1000)
+	o.Side = OrderSideBuy
+	assert.False(t, o.IsStopTriggered(big.NewInt(999)))
+	assert.True(t, o.IsStopTriggered(big.NewInt(1000)))
+	assert.True(t, o.IsStopTriggered(big.NewInt(1001)))
+
+	o.Side = OrderSideSell
+	assert.True(t, o.IsStopTriggered(big.NewInt(999)))
+	assert.True(t, o.IsStopTriggered(big.NewInt(1000)))
+	assert.False(t, o.IsStopTriggered(big.NewInt(1001)))
+}
+
+func TestOrderTrailingStopPricepoint(t *testing.T) {
+	o := newValidTestOrder()
+	o.StopPricepoint = big.NewInt(900)
+	assert.False(t, o.IsTrailingStop())
+
+	o.TrailingOffset = big.NewInt(50)
+	assert.True(t, o.IsTrailingStop())
+
+	o.Side = OrderSideSell
+	assert.Equal(t, int64(950), o.TrailingStopPricepoint(big.NewInt(1000)).Int64())
+
+	o.Side = OrderSideBuy
+	assert.Equal(t, int64(1050), o.TrailingStopPricepoint(big.NewInt(1000)).Int64())
+
+	// percentage offsets are expressed in basis points and rounded down
+	o.TrailingType = TrailingPercent
+	o.TrailingOffset = big.NewInt(250)
+	assert.Equal(t, int64(1025), o.TrailingStopPricepoint(big.NewInt(1000)).Int64())
+
+	o.Side = OrderSideSell
+	assert.Equal(t, int64(976), o.TrailingStopPricepoint(big.NewInt(1001)).Int64())
+}
+
+func TestOrderDustAmount(t *testing.T) {
+	o := newValidTestOrder()
+	o.Amount = big.NewInt(100)
+	o.FilledAmount = big.NewInt(97)
+
+	o.Status = OrderStatusPartialFilled
+	assert.Equal(t, int64(0), o.DustAmount().Int64())
+
+	o.Status = OrderStatusFilled
+	assert.Equal(t, int64(3), o.DustAmount().Int64())
+}
+
+func TestOrderDisplayedAmount(t *testing.T) {
+	o := newValidTestOrder()
+	o.Amount = big.NewInt(100)
+	o.FilledAmount = big.NewInt(45)
+	assert.Equal(t, int64(55), o.DisplayedAmount().Int64())
+
+	// iceberg orders show the unfilled part of their current slice
+	o.DisplayAmount = big.NewInt(30)
+	assert.True(t, o.IsIceberg())
+	assert.Equal(t, int64(15), o.DisplayedAmount().Int64())
+
+	o.FilledAmount = big.NewInt(60)
+	assert.Equal(t, int64(30), o.DisplayedAmount().Int64())
+
+	// the last slice is smaller than the display amount
+	o.FilledAmount = big.NewInt(90)
+	assert.Equal(t, int64(10), o.DisplayedAmount().Int64())
+
+	// an order displaying its whole amount is not an iceberg order
+	o.DisplayAmount = big.NewInt(100)
+	assert.False(t, o.IsIceberg())
+	assert.Equal(t, int64(10), o.DisplayedAmount().Int64())
+}
+
+func TestOrderFillAmounts(t *testing.T) {
+	o := &Order{Amount: big.NewInt(3), SellAmount: big.NewInt(100), BuyAmount: big.NewInt(3)}
+
+	// the rounding remainder of a fill is carried over to the next fills
+	sells := []int64{}
+	filled := big.NewInt(0)
+	for i := 0; i < 3; i++ {
+		sell, buy := o.FillAmounts(filled, big.NewInt(1))
+		assert.Equal(t, big.NewInt(1), buy)
+		sells = append(sells, sell.Int64())
+		filled = math.Add(filled, big.NewInt(1))
+	}
+
+	assert.Equal(t, []int64{33, 33, 34}, sells)
+
+	sell, _ := o.FillAmounts(nil, big.NewInt(2))
+	assert.Equal(t, big.NewInt(66), sell)
+}
+
+// TestOrderFillAmountsConservation fills random orders with random sequences of fills and checks
+// that the fills never exceed the order amounts and sum up exactly to them once the order is filled
+func TestOrderFillAmountsConservation(t *testing.T) {
+	conservation := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		o := &Order{
+			Amount:     new(big.Int).Rand(r, big.NewInt(1e18)),
+			SellAmount: new(big.Int).Rand(r, big.NewInt(1e18)),
+			BuyAmount:  new(big.Int).Rand(r, big.NewInt(1e18)),
+		}
+
+		o.Amount.Add(o.Amount, big.NewInt(1))
+
+		filled := big.NewInt(0)
+		sold := big.NewInt(0)
+		bought := big.NewInt(0)
+		for filled.Cmp(o.Amount) < 0 {
+			amount := new(big.Int).Rand(r, math.Sub(o.Amount, filled))
+			amount.Add(amount, big.NewInt(1))
+
+			sell, buy := o.FillAmounts(filled, amount)
+			filled = math.Add(filled, amount)
+			sold = math.Add(sold, sell)
+			bought = math.Add(bought, buy)
+
+			if sold.Cmp(o.SellAmount) > 0 || bought.Cmp(o.BuyAmount) > 0 {
+				return false
+			}
+		}
+
+		return sold.Cmp(o.SellAmount) == 0 && bought.Cmp(o.BuyAmount) == 0
+	}
+
+	config := &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(conservation, config); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderClone(t *testing.T) {
+	o := newValidTestOrder()
+	o.Amount = big.NewInt(100)
+	o.FilledAmount = big.NewInt(0)
+
+	c := o.Clone()
+	assert.Equal(t, o, c)
+
+	o.FilledAmount.Add(o.FilledAmount, big.NewInt(10))
+	o.Signature.V = 27
+	o.Status = OrderStatusFilled
+
+	assert.Equal(t, big.NewInt(0), c.FilledAmount)
+	assert.Equal(t, byte(28), c.Signature.V)
+	assert.Equal(t, "", c.Status)
+}
+
+func TestValidationErrorsJSON(t *testing.T) {
+	errs := ValidationErrors{}
+	errs.Add("buyAmount", "Buy amount should be positive")
+	errs.Add("signature", "Signature is missing")
+
+	b, err := json.Marshal(errs)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"field":"buyAmount","reason":"Buy amount should be positive"},{"field":"signature","reason":"Signature is missing"}]`, string(b))
+	assert.Equal(t, "buyAmount: Buy amount should be positive; signature: Signature is missing", errs.Error())
+	assert.Nil(t, ValidationErrors{}.Err())
+}
+
 func TestOrderMarshal(t *testing.T) {
 
 	o := &Order{
@@ -25,7 +327,7 @@ func TestOrderMarshal(t *testing.T) {
 		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
 		BuyAmount:       big.NewInt(1000),
 		SellAmount:      big.NewInt(100),
-		Price:           big.NewInt(1000),
+		PricePoint:      big.NewInt(1000),
 		Amount:          big.NewInt(1000),
 		FilledAmount:    big.NewInt(100),
 		Status:          "OPEN",
@@ -55,7 +357,7 @@ func TestOrderMarshal(t *testing.T) {
 		"quoteToken":      "0x12459c951127e0c374ff9105dda097662a027093",
 		"buyAmount":       "1000",
 		"sellAmount":      "100",
-		"price":           "1000",
+		"pricepoint":      "1000",
 		"amount":          "1000",
 		"filledAmount":    "100",
 		"status":          "OPEN",
@@ -103,7 +405,7 @@ func TestOrderUnmarshal(t *testing.T) {
 		BuyAmount:       big.NewInt(1000),
 		SellAmount:      big.NewInt(100),
 		Amount:          big.NewInt(100),
-		Price:           big.NewInt(100),
+		PricePoint:      big.NewInt(100),
 		FilledAmount:    big.NewInt(1000),
 		Status:          "OPEN",
 		Side:            "BUY",
@@ -132,7 +434,7 @@ func TestOrderUnmarshal(t *testing.T) {
 			"buyAmount":"1000",
 			"sellAmount":"100",
 			"amount": "100",
-			"price": "100",
+			"pricepoint": "100",
 			"filledAmount": "1000",
 			"status": "OPEN",
 			"side": "BUY",
@@ -171,7 +473,7 @@ func TestOrderBSON(t *testing.T) {
 		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
 		BuyAmount:       big.NewInt(1000),
 		SellAmount:      big.NewInt(100),
-		Price:           big.NewInt(1000),
+		PricePoint:      big.NewInt(1000),
 		Amount:          big.NewInt(1000),
 		FilledAmount:    big.NewInt(100),
 		Status:          "OPEN",
@@ -204,6 +506,173 @@ func TestOrderBSON(t *testing.T) {
 	assert.Equal(t, decoded, order)
 }
 
+func TestOrderLargeAmounts(t *testing.T) {
+	large, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	o := newValidTestOrder()
+	o.ID = bson.NewObjectId()
+	o.BuyAmount = large
+	o.SellAmount = math.Add(large, big.NewInt(1))
+	o.Amount = large
+	o.FilledAmount = big.NewInt(0)
+	o.PricePoint = big.NewInt(1000)
+	o.Nonce = math.Add(large, big.NewInt(2))
+
+	encoded, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// amounts are always encoded as decimal strings
+	assert.Equal(t, "123456789012345678901234567890", raw["buyAmount"])
+	assert.Equal(t, "123456789012345678901234567891", raw["sellAmount"])
+	assert.Equal(t, "123456789012345678901234567892", raw["nonce"])
+	assert.Equal(t, o.Expires.String(), raw["expires"])
+
+	decoded := &Order{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.BuyAmount.String(), decoded.BuyAmount.String())
+	assert.Equal(t, o.SellAmount.String(), decoded.SellAmount.String())
+	assert.Equal(t, o.Nonce.String(), decoded.Nonce.String())
+	assert.Equal(t, o.Amount.String(), decoded.Amount.String())
+
+	// json numbers are accepted on input without losing precision
+	payload := `{"buyAmount": 123456789012345678901234567890, "sellAmount": "18446744073709551617", "nonce": 10}`
+	decoded = &Order{}
+	err = json.Unmarshal([]byte(payload), decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "123456789012345678901234567890", decoded.BuyAmount.String())
+	assert.Equal(t, "18446744073709551617", decoded.SellAmount.String())
+	assert.Equal(t, "10", decoded.Nonce.String())
+
+	err = json.Unmarshal([]byte(`{"buyAmount": 1.5}`), &Order{})
+	assert.NotNil(t, err)
+
+	data, err := bson.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := bson.M{}
+	err = bson.Unmarshal(data, &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "123456789012345678901234567890", record["buyAmount"])
+
+	fromDB := &Order{}
+	err = bson.Unmarshal(data, fromDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.BuyAmount.String(), fromDB.BuyAmount.String())
+	assert.Equal(t, o.SellAmount.String(), fromDB.SellAmount.String())
+	assert.Equal(t, o.Nonce.String(), fromDB.Nonce.String())
+}
+
+func TestOrderUnmarshalJSONInvalidFields(t *testing.T) {
+	payload := `{
+		"userAddress": "0x14d281013d8ee8ccfa0eca87524e5b3cfa6152bz",
+		"buyToken": "0xe41d2489571d322189246dafa5ebde1f4699f498",
+		"buyAmount": "0x3e8",
+		"sellAmount": "100",
+		"nonce": true,
+		"hash": "0xb9070a2d",
+		"side": 1,
+		"price": "1.5"
+	}`
+
+	err := json.Unmarshal([]byte(payload), &Order{})
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+
+	fields := []string{}
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+
+	assert.Equal(t, []string{"buyAmount", "hash", "nonce", "price", "side", "userAddress"}, fields)
+}
+
+func TestOrderUnmarshalJSONRoundTrip(t *testing.T) {
+	o := newValidTestOrder()
+	o.Hash = o.ComputeHash()
+	o.Signature = &Signature{
+		V: 28,
+		R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+		S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+	}
+
+	encoded, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Order{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.Hash, decoded.Hash)
+	assert.Equal(t, o.Signature, decoded.Signature)
+}
+
+// Malformed payloads found while fuzzing the order decoder must return an error instead of panicking
+func TestOrderUnmarshalJSONMalformed(t *testing.T) {
+	payloads := []string{
+		``,
+		`null`,
+		`[]`,
+		`"order"`,
+		`{"id": 1}`,
+		`{"id": "zz"}`,
+		`{"pairName": {}}`,
+		`{"status": []}`,
+		`{"createdAt": 12}`,
+		`{"createdAt": "yesterday"}`,
+		`{"hash": 1}`,
+		`{"hash": "0xzz"}`,
+		`{"signature": 1}`,
+		`{"signature": {}}`,
+		`{"signature": {"V": "28"}}`,
+		`{"signature": {"V": 28, "R": 1, "S": 2}}`,
+		`{"signature": {"V": 28, "R": "0x01", "S": "0x02"}}`,
+		`{"signature": "0x01"}`,
+		`{"buyAmount": {}}`,
+		`{"buyAmount": 1e400}`,
+		`{"userAddress": []}`,
+		`{"signatureScheme": 1}`,
+		`{"signatureScheme": "ETH_SIGN"}`,
+	}
+
+	for _, p := range payloads {
+		assert.NotPanics(t, func() {
+			err := json.Unmarshal([]byte(p), &Order{})
+			if p != `null` {
+				assert.Error(t, err, p)
+			}
+		}, p)
+	}
+}
+
 // func TestAccountBSON(t *testing.T) {
 // 	assert := assert.New(t)
 
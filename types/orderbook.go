@@ -0,0 +1,254 @@
+package types
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// PriceLevel is the total remaining amount of the orders resting at a given pricepoint.
+// Price is the pricepoint formatted with the precision of the pair, if known.
+type PriceLevel struct {
+	PricePoint *big.Int
+	Amount     *big.Int
+	Price      string
+}
+
+// OrderBookChecksumDepth is the number of price levels of each side of an orderbook covered by
+// its checksum
+const OrderBookChecksumDepth = 25
+
+// OrderBook holds the bids and asks of a pair aggregated by pricepoint. Bids are sorted by
+// decreasing pricepoint and asks by increasing pricepoint. The sequence number increases with
+// every orderbook update published for the pair. The checksum of the orderbook of the engine
+// (see ComputeChecksum) is set by the engine, grouped orderbooks have none.
+type OrderBook struct {
+	PairName string
+	Sequence uint64
+	Bids     []*PriceLevel
+	Asks     []*PriceLevel
+	Checksum *uint32
+}
+
+// NewOrderBookFromOrders aggregates the displayed amounts of orders with the same side and
+// pricepoint into price levels
+func NewOrderBookFromOrders(orders []*Order) *OrderBook {
+	ob := &OrderBook{Bids: []*PriceLevel{}, Asks: []*PriceLevel{}}
+	bids := map[string]*PriceLevel{}
+	asks := map[string]*PriceLevel{}
+
+	for _, o := range orders {
+		if o.PricePoint == nil || o.Amount == nil {
+			continue
+		}
+
+		if ob.PairName == "" {
+			ob.PairName = o.PairName
+		}
+
+		levels := asks
+		if o.Side == OrderSideBuy {
+			levels = bids
+		}
+
+		key := o.PricePoint.String()
+		if levels[key] == nil {
+			levels[key] = &PriceLevel{PricePoint: new(big.Int).Set(o.PricePoint), Amount: big.NewInt(0)}
+			if o.Side == OrderSideBuy {
+				ob.Bids = append(ob.Bids, levels[key])
+			} else {
+				ob.Asks = append(ob.Asks, levels[key])
+			}
+		}
+
+		levels[key].Amount = math.Add(levels[key].Amount, o.DisplayedAmount())
+	}
+
+	ob.Sort()
+	return ob
+}
+
+// OrderBookChecksum is the checksum of the orderbook of a pair at a given sequence
+type OrderBookChecksum struct {
+	PairName string `json:"pairName"`
+	Sequence uint64 `json:"sequence"`
+	Checksum uint32 `json:"checksum"`
+}
+
+// ComputeChecksum returns the CRC32 (IEEE) checksum of the best OrderBookChecksumDepth price
+// levels of each side of the orderbook, whose levels must be sorted. The checksummed string lists
+// the bids then the asks, best priced first, each level being its decimal pricepoint and amount
+// joined by ':'. The levels of a side are joined by ',' and the sides by '|', eg.
+// "1000:5,990:2|1010:3". The checksum of an empty orderbook is the checksum of "|".
+func (ob *OrderBook) ComputeChecksum() uint32 {
+	sides := []string{}
+	for _, levels := range [][]*PriceLevel{ob.Bids, ob.Asks} {
+		entries := []string{}
+		for i, l := range levels {
+			if i == OrderBookChecksumDepth {
+				break
+			}
+
+			entries = append(entries, l.PricePoint.String()+":"+l.Amount.String())
+		}
+
+		sides = append(sides, strings.Join(entries, ","))
+	}
+
+	return crc32.ChecksumIEEE([]byte(strings.Join(sides, "|")))
+}
+
+// Sort orders the bids by decreasing pricepoint and the asks by increasing pricepoint
+func (ob *OrderBook) Sort() {
+	sort.Slice(ob.Bids, func(i, j int) bool {
+		return ob.Bids[i].PricePoint.Cmp(ob.Bids[j].PricePoint) > 0
+	})
+
+	sort.Slice(ob.Asks, func(i, j int) bool {
+		return ob.Asks[i].PricePoint.Cmp(ob.Asks[j].PricePoint) < 0
+	})
+}
+
+// Truncate keeps at most depth price levels on each side of the orderbook. A non-positive
+// depth leaves the orderbook unchanged.
+func (ob *OrderBook) Truncate(depth int) {
+	if depth <= 0 {
+		return
+	}
+
+	if len(ob.Bids) > depth {
+		ob.Bids = ob.Bids[:depth]
+	}
+
+	if len(ob.Asks) > depth {
+		ob.Asks = ob.Asks[:depth]
+	}
+}
+
+// Group aggregates the price levels of the orderbook to multiples of tick, summing their
+// amounts. Bids are rounded down and asks up, so that the grouped spread is never tighter than
+// the actual spread. The levels must be sorted.
+func (ob *OrderBook) Group(tick *big.Int) {
+	ob.Bids = groupLevels(ob.Bids, tick, OrderSideBuy)
+	ob.Asks = groupLevels(ob.Asks, tick, OrderSideSell)
+	ob.Checksum = nil
+}
+
+// groupLevels aggregates sorted price levels to multiples of tick. Rounding preserves the order
+// of the levels, so the levels of a group are next to each other.
+func groupLevels(levels []*PriceLevel, tick *big.Int, side OrderSide) []*PriceLevel {
+	grouped := []*PriceLevel{}
+	for _, l := range levels {
+		pp := GroupPricePoint(l.PricePoint, tick, side)
+
+		last := len(grouped) - 1
+		if last >= 0 && grouped[last].PricePoint.Cmp(pp) == 0 {
+			grouped[last].Amount = math.Add(grouped[last].Amount, l.Amount)
+			continue
+		}
+
+		grouped = append(grouped, &PriceLevel{PricePoint: pp, Amount: new(big.Int).Set(l.Amount)})
+	}
+
+	return grouped
+}
+
+// GroupPricePoint rounds a pricepoint to a multiple of tick, down for bids and up for asks
+func GroupPricePoint(pp, tick *big.Int, side OrderSide) *big.Int {
+	q, r := new(big.Int).QuoRem(pp, tick, new(big.Int))
+	if r.Sign() != 0 && side == OrderSideSell {
+		q = math.Add(q, big.NewInt(1))
+	}
+
+	return math.Mul(q, tick)
+}
+
+// FormatPrices sets the price of each level using the precision of the pair
+func (ob *OrderBook) FormatPrices(p *Pair) {
+	for _, l := range ob.Bids {
+		l.Price = p.FormatPricePoint(l.PricePoint)
+	}
+
+	for _, l := range ob.Asks {
+		l.Price = p.FormatPricePoint(l.PricePoint)
+	}
+}
+
+// MarshalJSON returns the json encoded price level. Pricepoints and amounts are encoded
+// as decimal strings.
+func (l *PriceLevel) MarshalJSON() ([]byte, error) {
+	level := map[string]interface{}{
+		"pricepoint": encodeBigInt(l.PricePoint),
+		"amount":     encodeBigInt(l.Amount),
+	}
+
+	if l.Price != "" {
+		level["price"] = l.Price
+	}
+
+	return json.Marshal(level)
+}
+
+// MarshalJSON returns the json encoded orderbook
+func (ob *OrderBook) MarshalJSON() ([]byte, error) {
+	bids := ob.Bids
+	if bids == nil {
+		bids = []*PriceLevel{}
+	}
+
+	asks := ob.Asks
+	if asks == nil {
+		asks = []*PriceLevel{}
+	}
+
+	book := map[string]interface{}{
+		"pairName": ob.PairName,
+		"sequence": ob.Sequence,
+		"bids":     bids,
+		"asks":     asks,
+	}
+
+	if ob.Checksum != nil {
+		book["checksum"] = *ob.Checksum
+	}
+
+	return json.Marshal(book)
+}
+
+// OrderBookDiff is the new total amount of a price level after a change of the orderbook. The
+// sequence increases by one with every change of the orderbook of the pair, so that clients
+// applying diffs on top of a snapshot can detect the diffs they missed. A zero amount means
+// that the price level was removed. The checksum of the diffs of the engine is the checksum of
+// the orderbook once the diff is applied (see OrderBook.ComputeChecksum), grouped diffs have none.
+type OrderBookDiff struct {
+	Sequence   uint64
+	Side       OrderSide
+	PricePoint *big.Int
+	Amount     *big.Int
+	Price      string
+	Checksum   *uint32
+}
+
+// MarshalJSON returns the json encoded orderbook diff
+func (d *OrderBookDiff) MarshalJSON() ([]byte, error) {
+	diff := map[string]interface{}{
+		"sequence":   d.Sequence,
+		"side":       d.Side,
+		"pricepoint": encodeBigInt(d.PricePoint),
+		"newAmount":  encodeBigInt(d.Amount),
+	}
+
+	if d.Price != "" {
+		diff["price"] = d.Price
+	}
+
+	if d.Checksum != nil {
+		diff["checksum"] = *d.Checksum
+	}
+
+	return json.Marshal(diff)
+}
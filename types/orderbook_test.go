@@ -0,0 +1,145 @@
+package types
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBookOrder(side OrderSide, pricepoint, amount, filledAmount int64) *Order {
+	return &Order{
+		PairName:     "ZRX/WETH",
+		Side:         side,
+		PricePoint:   big.NewInt(pricepoint),
+		Amount:       big.NewInt(amount),
+		FilledAmount: big.NewInt(filledAmount),
+	}
+}
+
+func TestNewOrderBookFromOrders(t *testing.T) {
+	orders := []*Order{
+		newTestBookOrder("BUY", 100, 10, 0),
+		newTestBookOrder("BUY", 102, 10, 4),
+		newTestBookOrder("BUY", 100, 5, 0),
+		newTestBookOrder("SELL", 105, 7, 0),
+		newTestBookOrder("SELL", 103, 3, 1),
+		newTestBookOrder("SELL", 105, 1, 0),
+	}
+
+	ob := NewOrderBookFromOrders(orders)
+
+	assert.Equal(t, "ZRX/WETH", ob.PairName)
+	assert.Equal(t, []*PriceLevel{
+		{PricePoint: big.NewInt(102), Amount: big.NewInt(6)},
+		{PricePoint: big.NewInt(100), Amount: big.NewInt(15)},
+	}, ob.Bids)
+
+	assert.Equal(t, []*PriceLevel{
+		{PricePoint: big.NewInt(103), Amount: big.NewInt(2)},
+		{PricePoint: big.NewInt(105), Amount: big.NewInt(8)},
+	}, ob.Asks)
+}
+
+func TestOrderBookTruncate(t *testing.T) {
+	orders := []*Order{
+		newTestBookOrder("BUY", 100, 1, 0),
+		newTestBookOrder("BUY", 101, 1, 0),
+		newTestBookOrder("BUY", 102, 1, 0),
+		newTestBookOrder("SELL", 103, 1, 0),
+	}
+
+	ob := NewOrderBookFromOrders(orders)
+	ob.Truncate(2)
+
+	assert.Equal(t, 2, len(ob.Bids))
+	assert.Equal(t, big.NewInt(102), ob.Bids[0].PricePoint)
+	assert.Equal(t, big.NewInt(101), ob.Bids[1].PricePoint)
+	assert.Equal(t, 1, len(ob.Asks))
+
+	ob.Truncate(0)
+	assert.Equal(t, 2, len(ob.Bids))
+}
+
+func TestOrderBookGroup(t *testing.T) {
+	orders := []*Order{
+		newTestBookOrder("BUY", 1099, 1, 0),
+		newTestBookOrder("BUY", 1050, 2, 0),
+		newTestBookOrder("BUY", 1000, 4, 0),
+		newTestBookOrder("BUY", 990, 8, 0),
+		newTestBookOrder("SELL", 1101, 1, 0),
+		newTestBookOrder("SELL", 1150, 2, 0),
+		newTestBookOrder("SELL", 1200, 4, 0),
+	}
+
+	ob := NewOrderBookFromOrders(orders)
+	ob.Group(big.NewInt(100))
+
+	// the best bid is rounded down and the best ask up
+	assert.Equal(t, []*PriceLevel{
+		{PricePoint: big.NewInt(1000), Amount: big.NewInt(7)},
+		{PricePoint: big.NewInt(900), Amount: big.NewInt(8)},
+	}, ob.Bids)
+
+	assert.Equal(t, []*PriceLevel{
+		{PricePoint: big.NewInt(1200), Amount: big.NewInt(7)},
+	}, ob.Asks)
+}
+
+func TestOrderBookJSON(t *testing.T) {
+	ob := NewOrderBookFromOrders([]*Order{newTestBookOrder("SELL", 1230000, 1000, 0)})
+	ob.Sequence = 3
+	ob.FormatPrices(&Pair{PriceMultiplier: big.NewInt(1e6), PriceDecimals: 2})
+
+	encoded, err := json.Marshal(ob)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := `{"asks":[{"amount":"1000","price":"1.23","pricepoint":"1230000"}],"bids":[],"pairName":"ZRX/WETH","sequence":3}`
+	assert.JSONEq(t, expected, string(encoded))
+}
+
+func TestOrderBookDiffJSON(t *testing.T) {
+	d := &OrderBookDiff{
+		Sequence:   4,
+		Side:       OrderSideBuy,
+		PricePoint: big.NewInt(1230000),
+		Amount:     big.NewInt(0),
+		Price:      "1.23",
+	}
+
+	encoded, err := json.Marshal(d)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := `{"newAmount":"0","price":"1.23","pricepoint":"1230000","sequence":4,"side":"BUY"}`
+	assert.JSONEq(t, expected, string(encoded))
+}
+
+func TestOrderBookChecksum(t *testing.T) {
+	ob := &OrderBook{
+		Bids: []*PriceLevel{
+			{PricePoint: big.NewInt(1000), Amount: big.NewInt(5)},
+			{PricePoint: big.NewInt(990), Amount: big.NewInt(2)},
+		},
+		Asks: []*PriceLevel{
+			{PricePoint: big.NewInt(1010), Amount: big.NewInt(3)},
+		},
+	}
+
+	assert.Equal(t, crc32.ChecksumIEEE([]byte("1000:5,990:2|1010:3")), ob.ComputeChecksum())
+	assert.Equal(t, crc32.ChecksumIEEE([]byte("|")), (&OrderBook{}).ComputeChecksum())
+
+	// only the first levels of each side are included
+	deep := &OrderBook{}
+	for i := 0; i < OrderBookChecksumDepth+5; i++ {
+		deep.Asks = append(deep.Asks, &PriceLevel{PricePoint: big.NewInt(int64(1000 + i)), Amount: big.NewInt(1)})
+	}
+
+	truncated := &OrderBook{Asks: deep.Asks[:OrderBookChecksumDepth]}
+	assert.Equal(t, truncated.ComputeChecksum(), deep.ComputeChecksum())
+}
@@ -2,9 +2,13 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -23,16 +27,57 @@ type Pair struct {
 	QuoteTokenAddress common.Address `json:"quoteTokenAddress" bson:"quoteTokenAddress"`
 	QuoteTokenDecimal int            `json:"quoteTokenDecimal" bson:"quoteTokenDecimal"`
 
+	// PriceMultiplier is the pricepoint multiplier: a pricepoint is the price, in quote tokens per base token
+	// whatever the decimals of the tokens, multiplied by PriceMultiplier (see PricePoint).
+	// PriceDecimals is the number of decimals of prices, ie. the tick size is PriceMultiplier / 10^PriceDecimals
+	// pricepoints. MinAmount is the minimum order amount in base token units. MinNotional is the
+	// minimum order value, the amount of an order at its pricepoint, in quote token units (see QuoteAmount).
 	PriceMultiplier *big.Int `json:"priceMultiplier" bson:"priceMultiplier"`
+	PriceDecimals   int      `json:"priceDecimals" bson:"priceDecimals"`
+	MinAmount       *big.Int `json:"minAmount" bson:"minAmount"`
+	MinNotional     *big.Int `json:"minNotional,omitempty" bson:"minNotional,omitempty"`
 
-	Active  bool     `json:"active" bson:"active"`
-	MakeFee *big.Int `json:"makeFee" bson:"makeFee"`
-	TakeFee *big.Int `json:"takeFee" bson:"takeFee"`
+	// Active is false for delisted pairs: new orders are refused but existing orders can still be cancelled.
+	Active bool `json:"active" bson:"active"`
+
+	// Paused is true for pairs paused by an admin: new orders are refused until the pair is
+	// resumed but existing orders can still be cancelled.
+	Paused bool `json:"paused" bson:"paused"`
+
+	// AuctionEndsAt is the end of the opening auction of the pair, if any: until then, orders are
+	// collected without being matched. AuctionReferencePricePoint breaks the ties between clearing
+	// pricepoints when the pair has not traded yet.
+	AuctionEndsAt              *time.Time `json:"auctionEndsAt,omitempty" bson:"auctionEndsAt,omitempty"`
+	AuctionReferencePricePoint *big.Int   `json:"auctionReferencePricePoint,omitempty" bson:"auctionReferencePricePoint,omitempty"`
+
+	// MakeFee and TakeFee are the minimum fees orders should declare. MakeFeeRate and TakeFeeRate
+	// are the fees charged on trades in basis points of the traded amount.
+	MakeFee     *big.Int `json:"makeFee" bson:"makeFee"`
+	TakeFee     *big.Int `json:"takeFee" bson:"takeFee"`
+	MakeFeeRate int64    `json:"makeFeeRate" bson:"makeFeeRate"`
+	TakeFeeRate int64    `json:"takeFeeRate" bson:"takeFeeRate"`
 
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
+// PairStatus is the trading status of a pair. A listed pair is halted by its circuit breaker for a
+// cooldown period once an order moved its price too far: new orders are refused until HaltedUntil
+// but open orders can still be cancelled. A paused pair refuses new orders until it is resumed by an
+// admin. During the opening auction of a pair, orders are collected without being matched until
+// AuctionEndsAt.
+type PairStatus struct {
+	PairName      string     `json:"pairName"`
+	BaseToken     string     `json:"baseToken"`
+	QuoteToken    string     `json:"quoteToken"`
+	Active        bool       `json:"active"`
+	Paused        bool       `json:"paused"`
+	Halted        bool       `json:"halted"`
+	HaltedUntil   *time.Time `json:"haltedUntil,omitempty"`
+	Auction       bool       `json:"auction"`
+	AuctionEndsAt *time.Time `json:"auctionEndsAt,omitempty"`
+}
+
 type PairSubDoc struct {
 	Name       string         `json:"name" bson:"name"`
 	BaseToken  common.Address `json:"baseToken" bson:"baseToken"`
@@ -49,11 +94,20 @@ type PairRecord struct {
 	QuoteTokenAddress string    `json:"quoteTokenAddress" bson:"quoteTokenAddress"`
 	QuoteTokenDecimal int       `json:"quoteTokenDecimal" bson:"quoteTokenDecimal"`
 	Active            bool      `json:"active" bson:"active"`
+	Paused            bool      `json:"paused" bson:"paused"`
 	PriceMultiplier   string    `json:"priceMultiplier" bson:"priceMultiplier"`
+	PriceDecimals     int       `json:"priceDecimals" bson:"priceDecimals"`
+	MinAmount         string    `json:"minAmount" bson:"minAmount"`
+	MinNotional       string    `json:"minNotional,omitempty" bson:"minNotional,omitempty"`
 	MakeFee           string    `json:"makeFee" bson:"makeFee"`
 	TakeFee           string    `json:"takeFee" bson:"takeFee"`
+	MakeFeeRate       int64     `json:"makeFeeRate" bson:"makeFeeRate"`
+	TakeFeeRate       int64     `json:"takeFeeRate" bson:"takeFeeRate"`
 	CreatedAt         time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt         time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	AuctionEndsAt              *time.Time `json:"auctionEndsAt,omitempty" bson:"auctionEndsAt,omitempty"`
+	AuctionReferencePricePoint string     `json:"auctionReferencePricePoint,omitempty" bson:"auctionReferencePricePoint,omitempty"`
 }
 
 func (p *Pair) Code() string {
@@ -66,6 +120,65 @@ func (p *Pair) Name() string {
 	return name
 }
 
+// Status returns the status of a pair that is not halted by its circuit breaker
+func (p *Pair) Status() *PairStatus {
+	return &PairStatus{
+		PairName:   p.Name(),
+		BaseToken:  p.BaseTokenAddress.Hex(),
+		QuoteToken: p.QuoteTokenAddress.Hex(),
+		Active:     p.Active,
+		Paused:     p.Paused,
+	}
+}
+
+// MarshalJSON implements the json.Marshal interface. Token addresses are encoded with
+// their EIP-55 checksum.
+func (p Pair) MarshalJSON() ([]byte, error) {
+	type pair Pair
+	return json.Marshal(struct {
+		pair
+		BaseTokenAddress  string `json:"baseTokenAddress"`
+		QuoteTokenAddress string `json:"quoteTokenAddress"`
+	}{pair(p), p.BaseTokenAddress.Hex(), p.QuoteTokenAddress.Hex()})
+}
+
+// UnmarshalJSON implements the json.Unmarshal interface. Mixed-case token addresses
+// are rejected if their EIP-55 checksum is invalid.
+func (p *Pair) UnmarshalJSON(b []byte) error {
+	type pair Pair
+	decoded := struct {
+		*pair
+		BaseTokenAddress  string `json:"baseTokenAddress"`
+		QuoteTokenAddress string `json:"quoteTokenAddress"`
+	}{pair: (*pair)(p)}
+
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return err
+	}
+
+	addresses := map[string]struct {
+		value string
+		dest  *common.Address
+	}{
+		"baseTokenAddress":  {decoded.BaseTokenAddress, &p.BaseTokenAddress},
+		"quoteTokenAddress": {decoded.QuoteTokenAddress, &p.QuoteTokenAddress},
+	}
+
+	for k, a := range addresses {
+		if a.value == "" {
+			continue
+		}
+
+		*a.dest, err = utils.ParseAddress(a.value)
+		if err != nil {
+			return fmt.Errorf("%v: %v", k, err)
+		}
+	}
+
+	return nil
+}
+
 func (p *Pair) SetBSON(raw bson.Raw) error {
 	decoded := &PairRecord{}
 
@@ -89,9 +202,31 @@ func (p *Pair) SetBSON(raw bson.Raw) error {
 	p.QuoteTokenAddress = common.HexToAddress(decoded.QuoteTokenAddress)
 	p.QuoteTokenDecimal = decoded.QuoteTokenDecimal
 	p.Active = decoded.Active
+	p.Paused = decoded.Paused
+	p.AuctionEndsAt = decoded.AuctionEndsAt
+	p.AuctionReferencePricePoint = nil
+	if decoded.AuctionReferencePricePoint != "" {
+		p.AuctionReferencePricePoint = math.ToBigInt(decoded.AuctionReferencePricePoint)
+	}
 	p.PriceMultiplier = priceMultiplier
+	p.MinNotional = nil
+	if decoded.MinNotional != "" {
+		p.MinNotional = math.ToBigInt(decoded.MinNotional)
+	}
 	p.MakeFee = makeFee
 	p.TakeFee = takeFee
+	p.MakeFeeRate = decoded.MakeFeeRate
+	p.TakeFeeRate = decoded.TakeFeeRate
+
+	// pairs created before the minimum amount and price decimals were introduced
+	// are given the default values
+	if decoded.MinAmount != "" {
+		p.MinAmount = math.ToBigInt(decoded.MinAmount)
+		p.PriceDecimals = decoded.PriceDecimals
+	} else {
+		p.MinAmount = nil
+		p.ApplyDefaults()
+	}
 
 	p.CreatedAt = decoded.CreatedAt
 	p.UpdatedAt = decoded.UpdatedAt
@@ -99,6 +234,21 @@ func (p *Pair) SetBSON(raw bson.Raw) error {
 }
 
 func (p *Pair) GetBSON() (interface{}, error) {
+	minAmount := ""
+	if p.MinAmount != nil {
+		minAmount = p.MinAmount.String()
+	}
+
+	minNotional := ""
+	if p.MinNotional != nil {
+		minNotional = p.MinNotional.String()
+	}
+
+	auctionReference := ""
+	if p.AuctionReferencePricePoint != nil {
+		auctionReference = p.AuctionReferencePricePoint.String()
+	}
+
 	return &PairRecord{
 		ID: p.ID,
 
@@ -109,11 +259,20 @@ func (p *Pair) GetBSON() (interface{}, error) {
 		QuoteTokenAddress: p.QuoteTokenAddress.Hex(),
 		QuoteTokenDecimal: p.QuoteTokenDecimal,
 		PriceMultiplier:   p.PriceMultiplier.String(),
+		PriceDecimals:     p.PriceDecimals,
+		MinAmount:         minAmount,
+		MinNotional:       minNotional,
 		Active:            p.Active,
+		Paused:            p.Paused,
 		MakeFee:           p.MakeFee.String(),
 		TakeFee:           p.TakeFee.String(),
+		MakeFeeRate:       p.MakeFeeRate,
+		TakeFeeRate:       p.TakeFeeRate,
 		CreatedAt:         p.CreatedAt,
 		UpdatedAt:         p.UpdatedAt,
+
+		AuctionEndsAt:              p.AuctionEndsAt,
+		AuctionReferencePricePoint: auctionReference,
 	}, nil
 }
 
@@ -128,6 +287,201 @@ func (p Pair) Validate() error {
 	)
 }
 
+// ApplyDefaults sets the minimum amount and price decimals if the minimum amount is not set. By default
+// the minimum amount is 1 and the tick size is 1 pricepoint which corresponds to the behavior of pairs created
+// before these parameters existed.
+func (p *Pair) ApplyDefaults() {
+	if p.MinAmount != nil {
+		return
+	}
+
+	p.MinAmount = big.NewInt(1)
+	p.PriceDecimals = 0
+	if p.PriceMultiplier != nil && p.PriceMultiplier.Sign() > 0 {
+		p.PriceDecimals = len(p.PriceMultiplier.String()) - 1
+	}
+}
+
+// TickSize returns the minimum pricepoint increment of the pair
+func (p *Pair) TickSize() *big.Int {
+	if p.PriceMultiplier == nil {
+		return big.NewInt(1)
+	}
+
+	tick := math.Div(p.PriceMultiplier, math.Exp(big.NewInt(10), big.NewInt(int64(p.PriceDecimals))))
+	if tick.Sign() <= 0 {
+		return big.NewInt(1)
+	}
+
+	return tick
+}
+
+// GroupingTick returns the pricepoint increment of the price levels grouped to the given number
+// of price decimals, or nil if the grouping is finer than the precision of the pair
+func (p *Pair) GroupingTick(decimals int) *big.Int {
+	if decimals < 0 || decimals > p.PriceDecimals {
+		return nil
+	}
+
+	return math.Mul(p.TickSize(), math.Exp(big.NewInt(10), big.NewInt(int64(p.PriceDecimals-decimals))))
+}
+
+// FeeSchedule returns the fee rates and minimum fees of the pair
+func (p *Pair) FeeSchedule() FeeSchedule {
+	return FeeSchedule{
+		MakeFeeRate: p.MakeFeeRate,
+		TakeFeeRate: p.TakeFeeRate,
+		MinMakeFee:  p.MakeFee,
+		MinTakeFee:  p.TakeFee,
+	}
+}
+
+// ValidateOrder checks that the amount and the display amount of an order are above the pair
+// minimum amount, that its pricepoint is aligned to the pair tick size and that it declares at
+// least the pair minimum fees. The order should be processed first.
+func (p *Pair) ValidateOrder(o *Order) error {
+	errs := ValidationErrors{}
+
+	if p.MinAmount != nil && (o.Amount == nil || o.Amount.Cmp(p.MinAmount) < 0) {
+		errs.Add("amount", fmt.Sprintf("Amount should be at least %v", p.MinAmount))
+	}
+
+	if p.MinAmount != nil && o.DisplayAmount != nil && o.DisplayAmount.Cmp(p.MinAmount) < 0 {
+		errs.Add("displayAmount", fmt.Sprintf("Display amount should be at least %v", p.MinAmount))
+	}
+
+	if o.PricePoint == nil || o.PricePoint.Sign() <= 0 {
+		errs.Add("pricepoint", "Price should be positive")
+	} else if new(big.Int).Mod(o.PricePoint, p.TickSize()).Sign() != 0 {
+		errs.Add("pricepoint", fmt.Sprintf("Price should have at most %v decimals", p.PriceDecimals))
+	}
+
+	fees := p.FeeSchedule()
+	if fees.MinMakeFee != nil && (o.MakeFee == nil || o.MakeFee.Cmp(fees.MinMakeFee) < 0) {
+		errs.Add("makeFee", fmt.Sprintf("Make fee should be at least %v", fees.MinMakeFee))
+	}
+
+	if fees.MinTakeFee != nil && (o.TakeFee == nil || o.TakeFee.Cmp(fees.MinTakeFee) < 0) {
+		errs.Add("takeFee", fmt.Sprintf("Take fee should be at least %v", fees.MinTakeFee))
+	}
+
+	return errs.Err()
+}
+
+// MinNotionalError is returned for orders whose notional value is below the pair minimum notional.
+// Both values are in quote token units.
+type MinNotionalError struct {
+	Notional    *big.Int
+	MinNotional *big.Int
+}
+
+func (e *MinNotionalError) Error() string {
+	return fmt.Sprintf("Order notional %v is below the minimum notional %v", e.Notional, e.MinNotional)
+}
+
+// IsBelowMinNotional returns true if the value of an amount of base token at a pricepoint is below
+// the pair minimum notional
+func (p *Pair) IsBelowMinNotional(amount, pricePoint *big.Int) bool {
+	if p.MinNotional == nil || p.MinNotional.Sign() <= 0 {
+		return false
+	}
+
+	return p.QuoteAmount(amount, pricePoint).Cmp(p.MinNotional) < 0
+}
+
+// ValidateNotional returns a *MinNotionalError if the notional value of an order, its amount at its
+// pricepoint, is below the pair minimum notional. The order should be processed first.
+func (p *Pair) ValidateNotional(o *Order) error {
+	if o.Amount == nil || o.PricePoint == nil || !p.IsBelowMinNotional(o.Amount, o.PricePoint) {
+		return nil
+	}
+
+	return &MinNotionalError{
+		Notional:    p.QuoteAmount(o.Amount, o.PricePoint),
+		MinNotional: p.MinNotional,
+	}
+}
+
+// PricepointFromHumanPrice converts a price expressed in quote tokens per base token (eg. "0.0021")
+// to a pricepoint. Prices that can not be represented exactly by a pricepoint are rejected.
+func (p *Pair) PricepointFromHumanPrice(price string) (*big.Int, error) {
+	if price == "" || strings.Trim(price, "0123456789.") != "" || strings.Count(price, ".") > 1 {
+		return nil, fmt.Errorf("Invalid price: %v", price)
+	}
+
+	r, ok := new(big.Rat).SetString(price)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("Invalid price: %v", price)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(p.multiplier()))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("Price %v is more precise than the pair pricepoints", price)
+	}
+
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// PricePoint returns the pricepoint of an exchange of amounts of base and quote token, both in
+// the smallest units of their token. Amounts are scaled by the decimals of their token so that
+// pricepoints do not depend on them:
+// pricepoint = quoteAmount * PriceMultiplier * 10^BaseTokenDecimal / (baseAmount * 10^QuoteTokenDecimal)
+// The pricepoint is rounded down.
+func (p *Pair) PricePoint(baseAmount, quoteAmount *big.Int) *big.Int {
+	baseUnit, quoteUnit := p.units()
+	num := math.Mul(math.Mul(quoteAmount, p.multiplier()), baseUnit)
+	return math.Div(num, math.Mul(baseAmount, quoteUnit))
+}
+
+// QuoteAmount returns the amount of quote token, in its smallest units, exchanged for an amount of
+// base token at a pricepoint. It is the inverse of PricePoint and is rounded down.
+func (p *Pair) QuoteAmount(baseAmount, pricePoint *big.Int) *big.Int {
+	baseUnit, quoteUnit := p.units()
+	num := math.Mul(math.Mul(baseAmount, pricePoint), quoteUnit)
+	return math.Div(num, math.Mul(p.multiplier(), baseUnit))
+}
+
+// units returns one base token and one quote token in the smallest units of each token
+func (p *Pair) units() (base, quote *big.Int) {
+	base = math.Exp(big.NewInt(10), big.NewInt(int64(p.BaseTokenDecimal)))
+	quote = math.Exp(big.NewInt(10), big.NewInt(int64(p.QuoteTokenDecimal)))
+	return base, quote
+}
+
+// multiplier returns the pricepoint multiplier of the pair, 1 if it is not set
+func (p *Pair) multiplier() *big.Int {
+	if p.PriceMultiplier == nil || p.PriceMultiplier.Sign() <= 0 {
+		return big.NewInt(1)
+	}
+
+	return p.PriceMultiplier
+}
+
+// FormatPricePoint converts a pricepoint to a decimal price string rounded to the pair price decimals
+func (p *Pair) FormatPricePoint(pp *big.Int) string {
+	if p.PriceMultiplier == nil || p.PriceMultiplier.Sign() == 0 {
+		return pp.String()
+	}
+
+	unit := math.Exp(big.NewInt(10), big.NewInt(int64(p.PriceDecimals)))
+	scaled, rem := new(big.Int).QuoRem(math.Mul(pp, unit), p.PriceMultiplier, new(big.Int))
+	if math.Mul(rem, big.NewInt(2)).Cmp(p.PriceMultiplier) >= 0 {
+		scaled = math.Add(scaled, big.NewInt(1))
+	}
+
+	if p.PriceDecimals == 0 {
+		return scaled.String()
+	}
+
+	digits := scaled.String()
+	if len(digits) <= p.PriceDecimals {
+		digits = strings.Repeat("0", p.PriceDecimals-len(digits)+1) + digits
+	}
+
+	i := len(digits) - p.PriceDecimals
+	return digits[:i] + "." + digits[i:]
+}
+
 // GetOrderBookKeys returns the orderbook price point keys for corresponding pair
 // It is used to fetch the orderbook from redis of a pair
 func (p *Pair) GetOrderBookKeys() (sell, buy string) {
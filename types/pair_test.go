@@ -1,9 +1,11 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
@@ -11,7 +13,7 @@ import (
 
 func ComparePair(t *testing.T, a, b *Pair) {
 	assert.Equal(t, a.ID, b.ID)
-	assert.Equal(t, a.Name, b.Name)
+	assert.Equal(t, a.Name(), b.Name())
 	assert.Equal(t, a.BaseTokenSymbol, b.BaseTokenSymbol)
 	assert.Equal(t, a.BaseTokenAddress, b.BaseTokenAddress)
 	assert.Equal(t, a.QuoteTokenSymbol, b.QuoteTokenSymbol)
@@ -19,17 +21,23 @@ func ComparePair(t *testing.T, a, b *Pair) {
 	assert.Equal(t, a.Active, b.Active)
 	assert.Equal(t, a.MakeFee, b.MakeFee)
 	assert.Equal(t, a.TakeFee, b.TakeFee)
+	assert.Equal(t, a.MinAmount, b.MinAmount)
+	assert.Equal(t, a.MinNotional, b.MinNotional)
+	assert.Equal(t, a.PriceDecimals, b.PriceDecimals)
 }
 
 func TestPairBSON(t *testing.T) {
 	pair := &Pair{
 		ID:                bson.NewObjectId(),
-		Name:              "REQ",
 		BaseTokenSymbol:   "REQ",
 		BaseTokenAddress:  common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5"),
 		QuoteTokenSymbol:  "WETH",
 		QuoteTokenAddress: common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
 		Active:            true,
+		PriceMultiplier:   big.NewInt(1e6),
+		PriceDecimals:     4,
+		MinAmount:         big.NewInt(1e15),
+		MinNotional:       big.NewInt(1e16),
 		MakeFee:           big.NewInt(10000),
 		TakeFee:           big.NewInt(10000),
 	}
@@ -46,3 +54,224 @@ func TestPairBSON(t *testing.T) {
 
 	ComparePair(t, pair, decoded)
 }
+
+func TestPairBSONDefaults(t *testing.T) {
+	record := bson.M{
+		"_id":               bson.NewObjectId(),
+		"baseTokenSymbol":   "REQ",
+		"baseTokenAddress":  "0xcf7389dc6c63637598402907d5431160ec8972a5",
+		"quoteTokenSymbol":  "WETH",
+		"quoteTokenAddress": "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa",
+		"priceMultiplier":   "1000000",
+		"makeFee":           "0",
+		"takeFee":           "0",
+	}
+
+	data, err := bson.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Pair{}
+	if err := bson.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "1", decoded.MinAmount.String())
+	assert.Equal(t, 6, decoded.PriceDecimals)
+	assert.Equal(t, "1", decoded.TickSize().String())
+}
+
+func TestPairValidateOrder(t *testing.T) {
+	pair := &Pair{
+		PriceMultiplier: big.NewInt(1e6),
+		PriceDecimals:   2,
+		MinAmount:       big.NewInt(1000),
+	}
+
+	o := &Order{Amount: big.NewInt(1000), PricePoint: big.NewInt(1230000)}
+	assert.Nil(t, pair.ValidateOrder(o))
+
+	o = &Order{Amount: big.NewInt(999), PricePoint: big.NewInt(1230000)}
+	assert.Equal(t, ValidationErrors{{"amount", "Amount should be at least 1000"}}, pair.ValidateOrder(o))
+
+	o = &Order{Amount: big.NewInt(1000), PricePoint: big.NewInt(1234567)}
+	assert.Equal(t, ValidationErrors{{"pricepoint", "Price should have at most 2 decimals"}}, pair.ValidateOrder(o))
+
+	o = &Order{Amount: big.NewInt(1000), PricePoint: big.NewInt(0)}
+	assert.Equal(t, ValidationErrors{{"pricepoint", "Price should be positive"}}, pair.ValidateOrder(o))
+}
+
+func TestPairValidateNotional(t *testing.T) {
+	// the quote token has 6 decimals, a pricepoint of 1e6 is a price of 1 quote token
+	pair := &Pair{
+		BaseTokenDecimal:  18,
+		QuoteTokenDecimal: 6,
+		PriceMultiplier:   big.NewInt(1e6),
+		MinAmount:         big.NewInt(1),
+		MinNotional:       big.NewInt(10e6),
+	}
+
+	o := &Order{Amount: math.ToBigInt("10000000000000000000"), PricePoint: big.NewInt(1e6)}
+	assert.Nil(t, pair.ValidateNotional(o))
+
+	o = &Order{Amount: math.ToBigInt("9000000000000000000"), PricePoint: big.NewInt(1e6)}
+	expected := &MinNotionalError{Notional: big.NewInt(9e6), MinNotional: big.NewInt(10e6)}
+	assert.Equal(t, expected, pair.ValidateNotional(o))
+
+	// a large amount at a microscopic price is rejected
+	o = &Order{Amount: math.ToBigInt("1000000000000000000000000"), PricePoint: big.NewInt(1)}
+	expected = &MinNotionalError{Notional: big.NewInt(1e6), MinNotional: big.NewInt(10e6)}
+	assert.Equal(t, expected, pair.ValidateNotional(o))
+
+	pair.MinNotional = nil
+	assert.Nil(t, pair.ValidateNotional(o))
+}
+
+func TestPairValidateOrderFees(t *testing.T) {
+	pair := &Pair{
+		PriceMultiplier: big.NewInt(1e6),
+		MinAmount:       big.NewInt(1),
+		MakeFee:         big.NewInt(10),
+		TakeFee:         big.NewInt(20),
+	}
+
+	o := &Order{Amount: big.NewInt(1000), PricePoint: big.NewInt(1e6), MakeFee: big.NewInt(10), TakeFee: big.NewInt(20)}
+	assert.Nil(t, pair.ValidateOrder(o))
+
+	o = &Order{Amount: big.NewInt(1000), PricePoint: big.NewInt(1e6), MakeFee: big.NewInt(9), TakeFee: big.NewInt(19)}
+	expected := ValidationErrors{
+		{"makeFee", "Make fee should be at least 10"},
+		{"takeFee", "Take fee should be at least 20"},
+	}
+	assert.Equal(t, expected, pair.ValidateOrder(o))
+}
+
+func TestPairFormatPricePoint(t *testing.T) {
+	pair := &Pair{PriceMultiplier: big.NewInt(1e6), PriceDecimals: 2}
+
+	assert.Equal(t, "1.23", pair.FormatPricePoint(big.NewInt(1234567)))
+	assert.Equal(t, "1.24", pair.FormatPricePoint(big.NewInt(1235000)))
+	assert.Equal(t, "0.05", pair.FormatPricePoint(big.NewInt(50000)))
+	assert.Equal(t, "12.00", pair.FormatPricePoint(big.NewInt(12000000)))
+
+	pair.PriceDecimals = 0
+	assert.Equal(t, "2", pair.FormatPricePoint(big.NewInt(1500000)))
+}
+
+func TestPairGroupingTick(t *testing.T) {
+	pair := &Pair{PriceMultiplier: big.NewInt(1e6), PriceDecimals: 4}
+
+	assert.Equal(t, big.NewInt(100), pair.GroupingTick(4))
+	assert.Equal(t, big.NewInt(1000), pair.GroupingTick(3))
+	assert.Equal(t, big.NewInt(1e6), pair.GroupingTick(0))
+	assert.Nil(t, pair.GroupingTick(5))
+	assert.Nil(t, pair.GroupingTick(-1))
+}
+
+func TestPairPricepointFromHumanPrice(t *testing.T) {
+	pair := &Pair{PriceMultiplier: big.NewInt(1e6), BaseTokenDecimal: 18, QuoteTokenDecimal: 18}
+
+	pp, err := pair.PricepointFromHumanPrice("0.0021")
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(2100), pp)
+
+	_, err = pair.PricepointFromHumanPrice("0.00000012")
+	assert.Error(t, err)
+
+	_, err = pair.PricepointFromHumanPrice("-1")
+	assert.Error(t, err)
+
+	_, err = pair.PricepointFromHumanPrice("1/3")
+	assert.Error(t, err)
+
+	// pricepoints do not depend on the decimals of the tokens
+	pair = &Pair{PriceMultiplier: big.NewInt(1e6), BaseTokenDecimal: 18, QuoteTokenDecimal: 6}
+
+	pp, err = pair.PricepointFromHumanPrice("1.5")
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1500000), pp)
+}
+
+func TestPairPricePointDecimals(t *testing.T) {
+	tests := []struct {
+		name          string
+		baseDecimals  int
+		quoteDecimals int
+		price         string
+		pricePoint    string
+		baseAmount    string
+		quoteAmount   string
+	}{
+		// 100 USDC for 0.05 WETH
+		{"6/18", 6, 18, "0.0005", "500", "100000000", "50000000000000000"},
+		// 0.2 WBTC for 6.1 WETH
+		{"8/18", 8, 18, "30.5", "30500000", "20000000", "6100000000000000000"},
+		// 1.5 WETH for 3000.375 USDC
+		{"18/6", 18, 6, "2000.25", "2000250000", "1500000000000000000", "3000375000"},
+		{"18/18", 18, 18, "0.0021", "2100", "1000000000000000000", "2100000000000000"},
+	}
+
+	for _, test := range tests {
+		pair := &Pair{
+			BaseTokenAddress:  common.HexToAddress("0x1"),
+			BaseTokenDecimal:  test.baseDecimals,
+			QuoteTokenAddress: common.HexToAddress("0x2"),
+			QuoteTokenDecimal: test.quoteDecimals,
+			PriceMultiplier:   big.NewInt(1e6),
+			PriceDecimals:     6,
+		}
+
+		pricePoint := math.ToBigInt(test.pricePoint)
+		baseAmount := math.ToBigInt(test.baseAmount)
+		quoteAmount := math.ToBigInt(test.quoteAmount)
+
+		pp, err := pair.PricepointFromHumanPrice(test.price)
+		assert.Nil(t, err, test.name)
+		assert.Equal(t, pricePoint, pp, test.name)
+
+		assert.Equal(t, pricePoint, pair.PricePoint(baseAmount, quoteAmount), test.name)
+		assert.Equal(t, quoteAmount, pair.QuoteAmount(baseAmount, pricePoint), test.name)
+		assert.Equal(t, pair.FormatPricePoint(pricePoint), pair.FormatPricePoint(pp), test.name)
+
+		buy := &Order{BuyToken: pair.BaseTokenAddress, BuyAmount: baseAmount, SellAmount: quoteAmount}
+		assert.Nil(t, buy.Process(pair), test.name)
+		assert.Equal(t, pricePoint, buy.PricePoint, test.name)
+		assert.Equal(t, baseAmount, buy.Amount, test.name)
+
+		sell := &Order{BuyToken: pair.QuoteTokenAddress, BuyAmount: quoteAmount, SellAmount: baseAmount}
+		assert.Nil(t, sell.Process(pair), test.name)
+		assert.Equal(t, pricePoint, sell.PricePoint, test.name)
+		assert.Equal(t, baseAmount, sell.Amount, test.name)
+	}
+}
+
+func TestPairJSONAddressChecksum(t *testing.T) {
+	p := &Pair{
+		BaseTokenSymbol:   "ZRX",
+		BaseTokenAddress:  common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"),
+		QuoteTokenSymbol:  "WETH",
+		QuoteTokenAddress: common.HexToAddress("0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"),
+	}
+
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Contains(t, string(encoded), "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	assert.Contains(t, string(encoded), "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359")
+
+	decoded := &Pair{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, p.BaseTokenAddress, decoded.BaseTokenAddress)
+	assert.Equal(t, p.QuoteTokenAddress, decoded.QuoteTokenAddress)
+
+	invalid := []byte(`{"baseTokenAddress": "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}`)
+	err = json.Unmarshal(invalid, &Pair{})
+	assert.Error(t, err)
+}
@@ -2,12 +2,10 @@ package types
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"math/big"
 
-	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/go-ozzo/ozzo-validation"
@@ -17,6 +15,7 @@ import (
 // user is populated
 type NewOrderPayload struct {
 	PairName        string         `json:"pairName"`
+	Type            OrderType      `json:"type"`
 	ExchangeAddress common.Address `json:"exchangeAddress"`
 	UserAddress     common.Address `json:"userAddress"`
 	BuyToken        common.Address `json:"buyToken"`
@@ -38,12 +37,6 @@ func (p NewOrderPayload) MarshalJSON() ([]byte, error) {
 		"userAddress":     p.UserAddress,
 		"buyToken":        p.BuyToken,
 		"sellToken":       p.SellToken,
-		"buyAmount":       p.BuyAmount.String(),
-		"sellAmount":      p.SellAmount.String(),
-		"takeFee":         p.TakeFee.String(),
-		"makeFee":         p.MakeFee.String(),
-		"expires":         p.Expires.String(),
-		"nonce":           p.Nonce.String(),
 		"signature": map[string]interface{}{
 			"V": p.Signature.V,
 			"R": p.Signature.R,
@@ -52,13 +45,21 @@ func (p NewOrderPayload) MarshalJSON() ([]byte, error) {
 		"hash": p.Hash,
 	}
 
+	if p.Type != "" {
+		encoded["type"] = p.Type
+	}
+
+	for k, n := range p.bigIntFields() {
+		if *n != nil {
+			encoded[k] = (*n).String()
+		}
+	}
+
 	return json.Marshal(encoded)
 }
 
 func (p *NewOrderPayload) UnmarshalJSON(b []byte) error {
-	decoded := map[string]interface{}{}
-
-	err := json.Unmarshal(b, &decoded)
+	decoded, err := unmarshalJSONObject(b)
 	if err != nil {
 		return err
 	}
@@ -67,6 +68,13 @@ func (p *NewOrderPayload) UnmarshalJSON(b []byte) error {
 		p.PairName = decoded["pairName"].(string)
 	}
 
+	if decoded["type"] != nil {
+		p.Type, err = parseOrderType(decoded["type"])
+		if err != nil {
+			return fmt.Errorf("type: %v", err)
+		}
+	}
+
 	if decoded["userAddress"] != nil {
 		p.UserAddress = common.HexToAddress(decoded["userAddress"].(string))
 	}
@@ -83,36 +91,21 @@ func (p *NewOrderPayload) UnmarshalJSON(b []byte) error {
 		p.SellToken = common.HexToAddress(decoded["sellToken"].(string))
 	}
 
-	if decoded["buyAmount"] != nil {
-		p.BuyAmount = math.ToBigInt(decoded["buyAmount"].(string))
-	}
-
-	if decoded["sellAmount"] != nil {
-		p.BuyAmount = math.ToBigInt(decoded["sellAmount"].(string))
-	}
-
-	if decoded["expires"] != nil {
-		p.BuyAmount = math.ToBigInt(decoded["expires"].(string))
-	}
-
-	if decoded["nonce"] != nil {
-		p.BuyAmount = math.ToBigInt(decoded["nonce"].(string))
-	}
-
-	if decoded["makeFee"] != nil {
-		p.BuyAmount = math.ToBigInt(decoded["makeFee"].(string))
-	}
+	for k, n := range p.bigIntFields() {
+		if decoded[k] == nil {
+			continue
+		}
 
-	if decoded["takeFee"] != nil {
-		p.BuyAmount = math.ToBigInt(decoded["takeFee"].(string))
+		*n, err = parseBigInt(decoded[k])
+		if err != nil {
+			return fmt.Errorf("%v: %v", k, err)
+		}
 	}
 
 	if decoded["signature"] != nil {
-		signature := decoded["signature"].(map[string]interface{})
-		p.Signature = &Signature{
-			V: byte(signature["V"].(float64)),
-			R: common.HexToHash(signature["R"].(string)),
-			S: common.HexToHash(signature["S"].(string)),
+		p.Signature, err = decodeSignature(decoded["signature"])
+		if err != nil {
+			return err
 		}
 	}
 
@@ -122,6 +115,18 @@ func (p *NewOrderPayload) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// bigIntFields returns the integer fields of the payload indexed by their json key
+func (p *NewOrderPayload) bigIntFields() map[string]**big.Int {
+	return map[string]**big.Int{
+		"buyAmount":  &p.BuyAmount,
+		"sellAmount": &p.SellAmount,
+		"makeFee":    &p.MakeFee,
+		"takeFee":    &p.TakeFee,
+		"expires":    &p.Expires,
+		"nonce":      &p.Nonce,
+	}
+}
+
 // Validate validates the NewOrderPayload fields.
 func (p NewOrderPayload) Validate() error {
 	return validation.ValidateStruct(&p,
@@ -141,6 +146,7 @@ func (p *NewOrderPayload) ToOrder() (o *Order, err error) {
 	}
 
 	o = &Order{
+		Type:        p.Type,
 		MakeFee:     p.MakeFee,
 		TakeFee:     p.TakeFee,
 		UserAddress: p.UserAddress,
@@ -174,19 +180,10 @@ func (p *NewOrderPayload) ComputeHash() common.Hash {
 // VerifySignature checks that the orderRequest signature corresponds to the address in the userAddress field
 func (p *NewOrderPayload) VerifySignature() (bool, error) {
 	p.Hash = p.ComputeHash()
-	message := crypto.Keccak256(
-		[]byte("\x19Ethereum Signed Message:\n32"),
-		p.Hash.Bytes(),
-	)
-
-	address, err := p.Signature.Verify(common.BytesToHash(message))
+	err := p.Signature.Verify(p.Hash, p.UserAddress)
 	if err != nil {
 		return false, err
 	}
 
-	if address != p.UserAddress {
-		return false, errors.New("Recovered address is incorrect")
-	}
-
 	return true, nil
 }
@@ -0,0 +1,158 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+)
+
+// SessionAuth is a signed message authenticating a websocket session as an address. When
+// CancelOnDisconnect is set, the orders placed through the session are cancelled once the
+// connection is lost, unless the address authenticates a new session within the grace period.
+// The Timestamp (in seconds) must be recent for the message to be accepted, which prevents old
+// messages from being replayed. The nonce distinguishes messages sent during the same second.
+type SessionAuth struct {
+	Address            Address    `json:"address"`
+	CancelOnDisconnect bool       `json:"cancelOnDisconnect"`
+	Timestamp          int64      `json:"timestamp"`
+	Nonce              *big.Int   `json:"nonce"`
+	Hash               Hash       `json:"hash"`
+	Signature          *Signature `json:"signature"`
+}
+
+// MarshalJSON returns the json encoded byte array representing the SessionAuth struct
+func (sa *SessionAuth) MarshalJSON() ([]byte, error) {
+	auth := map[string]interface{}{
+		"address":            sa.Address,
+		"cancelOnDisconnect": sa.CancelOnDisconnect,
+		"timestamp":          sa.Timestamp,
+		"hash":               sa.Hash,
+	}
+
+	if sa.Nonce != nil {
+		auth["nonce"] = sa.Nonce.String()
+	}
+
+	if sa.Signature != nil {
+		auth["signature"] = map[string]interface{}{
+			"V": sa.Signature.V,
+			"R": sa.Signature.R,
+			"S": sa.Signature.S,
+		}
+	}
+
+	return json.Marshal(auth)
+}
+
+// UnmarshalJSON creates a SessionAuth object from a json byte string
+func (sa *SessionAuth) UnmarshalJSON(b []byte) error {
+	parsed, err := unmarshalJSONObject(b)
+	if err != nil {
+		return err
+	}
+
+	if parsed["address"] == nil {
+		return errors.New("Address is missing")
+	}
+	sa.Address = HexToAddress(parsed["address"].(string))
+
+	if parsed["hash"] == nil {
+		return errors.New("Hash is missing")
+	}
+	sa.Hash = HexToHash(parsed["hash"].(string))
+
+	if parsed["cancelOnDisconnect"] != nil {
+		cancel, ok := parsed["cancelOnDisconnect"].(bool)
+		if !ok {
+			return errors.New("cancelOnDisconnect: expected a boolean")
+		}
+
+		sa.CancelOnDisconnect = cancel
+	}
+
+	if parsed["timestamp"] != nil {
+		timestamp, err := parseBigInt(parsed["timestamp"])
+		if err != nil {
+			return fmt.Errorf("timestamp: %v", err)
+		}
+
+		sa.Timestamp = timestamp.Int64()
+	}
+
+	if parsed["nonce"] != nil {
+		sa.Nonce, err = parseBigInt(parsed["nonce"])
+		if err != nil {
+			return fmt.Errorf("nonce: %v", err)
+		}
+	}
+
+	sa.Signature, err = decodeSignature(parsed["signature"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks that the address, timestamp, nonce and signature are set and that the
+// hash corresponds to the message content
+func (sa *SessionAuth) Validate() error {
+	if sa.Address == (Address{}) {
+		return errors.New("Address is missing")
+	}
+
+	if sa.Timestamp <= 0 {
+		return errors.New("Timestamp is missing")
+	}
+
+	if sa.Nonce == nil {
+		return errors.New("Nonce is missing")
+	}
+
+	if sa.Signature == nil {
+		return errors.New("Signature is missing")
+	}
+
+	if sa.Hash != sa.ComputeHash() {
+		return errors.New("Invalid session authentication hash")
+	}
+
+	return nil
+}
+
+// IsRecent returns true if the message timestamp is less than window away from now
+func (sa *SessionAuth) IsRecent(now time.Time, window time.Duration) bool {
+	age := now.Sub(time.Unix(sa.Timestamp, 0))
+	return age <= window && age >= -window
+}
+
+// VerifySignature returns an error if the message was not signed by its address
+func (sa *SessionAuth) VerifySignature() error {
+	return sa.Signature.Verify(sa.Hash, sa.Address)
+}
+
+// ComputeHash computes the hash of a session authentication message. The hash commits to the
+// address, the cancel on disconnect flag, the timestamp and the nonce.
+func (sa *SessionAuth) ComputeHash() Hash {
+	nonce := Hash{}
+	if sa.Nonce != nil {
+		nonce = BigToHash(sa.Nonce)
+	}
+
+	cancel := byte(0)
+	if sa.CancelOnDisconnect {
+		cancel = 1
+	}
+
+	sha := sha3.NewKeccak256()
+	sha.Write(sa.Address.Bytes())
+	sha.Write([]byte{cancel})
+	sha.Write(BigToHash(big.NewInt(sa.Timestamp)).Bytes())
+	sha.Write(nonce.Bytes())
+	return BytesToHash(sha.Sum(nil))
+}
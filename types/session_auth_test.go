@@ -0,0 +1,69 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-test/deep"
+)
+
+func TestSessionAuthJSON(t *testing.T) {
+	expected := &SessionAuth{
+		Address:            common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		CancelOnDisconnect: true,
+		Timestamp:          1405544146,
+		Nonce:              big.NewInt(1000),
+		Hash:               common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Signature: &Signature{
+			V: 28,
+			R: common.HexToHash("0x10b30eb0072a4f0a38b6fca0b731cba15eb2e1702845d97c1230b53a839bcb85"),
+			S: common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		},
+	}
+
+	encoded, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Error encoding session authentication message: %v", err)
+	}
+
+	sa := &SessionAuth{}
+	err = json.Unmarshal(encoded, &sa)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	if diff := deep.Equal(expected, sa); diff != nil {
+		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, sa)
+	}
+}
+
+func TestSignSessionAuth(t *testing.T) {
+	w := NewWallet()
+
+	sa := &SessionAuth{CancelOnDisconnect: true, Timestamp: time.Now().Unix(), Nonce: big.NewInt(1)}
+	err := w.SignSessionAuth(sa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sa.Address != w.Address {
+		t.Errorf("Expected address to be %v but got %v", w.Address.Hex(), sa.Address.Hex())
+	}
+
+	if err := sa.Validate(); err != nil {
+		t.Errorf("Expected session authentication message to be valid but got: %v", err)
+	}
+
+	if err := sa.VerifySignature(); err != nil {
+		t.Errorf("Expected signature to correspond to the address but got: %v", err)
+	}
+
+	// the cancel on disconnect flag cannot be changed without signing the message again
+	sa.CancelOnDisconnect = false
+	if err := sa.Validate(); err == nil {
+		t.Error("Expected session authentication message with a modified flag to be invalid")
+	}
+}
@@ -2,10 +2,15 @@ package types
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"gopkg.in/mgo.v2/bson"
 )
 
 // Signature struct
@@ -15,25 +20,203 @@ type Signature struct {
 	S common.Hash
 }
 
+// SignatureRecord is the database representation of a signature
 type SignatureRecord struct {
-	V byte   `json:"V" bson:"V"`
+	V int    `json:"V" bson:"V"`
 	R string `json:"R" bson:"R"`
 	S string `json:"S" bson:"S"`
 }
 
-// NewSignature function decodes []byte to Signature type
+// NewSignature decodes a 65 bytes compact signature (R || S || V) to a Signature. Both 0/1 and 27/28
+// recovery ids are accepted.
 func NewSignature(b []byte) (*Signature, error) {
-	if len(b) != 64 {
-		return nil, errors.New("Signature length should be 64 bytes")
+	if len(b) != 65 {
+		return nil, errors.New("Signature length should be 65 bytes")
+	}
+
+	v := b[64]
+	if v < 27 {
+		v += 27
+	}
+
+	if v != 27 && v != 28 {
+		return nil, errors.New("Invalid signature recovery id")
 	}
 
 	return &Signature{
 		R: common.BytesToHash(b[0:32]),
-		S: common.BytesToHash(b[32:65]),
-		V: b[64] + 27,
+		S: common.BytesToHash(b[32:64]),
+		V: v,
+	}, nil
+}
+
+// Bytes returns the 65 bytes compact form (R || S || V) of the signature with a 27/28 recovery id
+func (s *Signature) Bytes() []byte {
+	v := s.V
+	if v < 27 {
+		v += 27
+	}
+
+	b := append([]byte{}, s.R.Bytes()...)
+	b = append(b, s.S.Bytes()...)
+	return append(b, v)
+}
+
+// MarshalText encodes the signature as a 0x prefixed hex string of its compact form
+func (s *Signature) MarshalText() ([]byte, error) {
+	return []byte(hexutil.Encode(s.Bytes())), nil
+}
+
+// UnmarshalText decodes a 0x prefixed hex string of the compact form of a signature
+func (s *Signature) UnmarshalText(text []byte) error {
+	b, err := hexutil.Decode(string(text))
+	if err != nil {
+		return fmt.Errorf("Invalid signature: %v", err)
+	}
+
+	sig, err := NewSignature(b)
+	if err != nil {
+		return err
+	}
+
+	*s = *sig
+	return nil
+}
+
+// UnmarshalJSON decodes a signature either in the compact hex form or in the {"V", "R", "S"} object form
+func (s *Signature) UnmarshalJSON(b []byte) error {
+	var decoded interface{}
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return err
+	}
+
+	sig, err := decodeSignature(decoded)
+	if err != nil {
+		return err
+	}
+
+	*s = *sig
+	return nil
+}
+
+// decodeSignature decodes a signature parsed from json in either the compact hex form
+// or the {"V", "R", "S"} object form
+func decodeSignature(decoded interface{}) (*Signature, error) {
+	switch sig := decoded.(type) {
+	case string:
+		s := &Signature{}
+		err := s.UnmarshalText([]byte(sig))
+		if err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	case map[string]interface{}:
+		var v float64
+		switch n := sig["V"].(type) {
+		case float64:
+			v = n
+		case json.Number:
+			f, err := n.Float64()
+			if err != nil {
+				return nil, errors.New("Invalid signature: V is not a number")
+			}
+
+			v = f
+		default:
+			return nil, errors.New("Invalid signature: V is missing")
+		}
+
+		if sig["R"] == nil {
+			return nil, errors.New("Invalid signature: R is missing")
+		}
+
+		r, err := parseHash(sig["R"])
+		if err != nil {
+			return nil, errors.New("Invalid signature: R is not a 32 bytes hex value")
+		}
+
+		if sig["S"] == nil {
+			return nil, errors.New("Invalid signature: S is missing")
+		}
+
+		s, err := parseHash(sig["S"])
+		if err != nil {
+			return nil, errors.New("Invalid signature: S is not a 32 bytes hex value")
+		}
+
+		if v < 27 {
+			v += 27
+		}
+
+		if v != 27 && v != 28 {
+			return nil, errors.New("Invalid signature recovery id")
+		}
+
+		return &Signature{
+			V: byte(v),
+			R: r,
+			S: s,
+		}, nil
+	default:
+		return nil, errors.New("Invalid signature format")
+	}
+}
+
+// GetBSON implements bson.Getter. R and S are stored as hex strings and V as an integer. A nil
+// signature is stored as null.
+func (s *Signature) GetBSON() (interface{}, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	return SignatureRecord{
+		V: int(s.V),
+		R: s.R.Hex(),
+		S: s.S.Hex(),
 	}, nil
 }
 
+// SetBSON implements bson.Setter. Signatures stored before GetBSON was implemented used the
+// default struct encoding ({v, r, s} with R and S as binary) and are still decoded. A null
+// signature is decoded as nil.
+func (s *Signature) SetBSON(raw bson.Raw) error {
+	if raw.Kind == 0x0A {
+		return bson.SetZero
+	}
+
+	decoded := &struct {
+		V       int    `bson:"V"`
+		R       string `bson:"R"`
+		S       string `bson:"S"`
+		LegacyV int    `bson:"v"`
+		LegacyR []byte `bson:"r"`
+		LegacyS []byte `bson:"s"`
+	}{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	switch {
+	case decoded.R != "" && decoded.S != "":
+		s.V = byte(decoded.V)
+		s.R = common.HexToHash(decoded.R)
+		s.S = common.HexToHash(decoded.S)
+	case len(decoded.LegacyR) == common.HashLength && len(decoded.LegacyS) == common.HashLength:
+		s.V = byte(decoded.LegacyV)
+		s.R = common.BytesToHash(decoded.LegacyR)
+		s.S = common.BytesToHash(decoded.LegacyS)
+	default:
+		return errors.New("Invalid signature record")
+	}
+
+	return nil
+}
+
 // MarshalSignature marshals the signature struct to []byte
 func (s *Signature) MarshalSignature() ([]byte, error) {
 	sigBytes1 := s.R.Bytes()
@@ -47,23 +230,71 @@ func (s *Signature) MarshalSignature() ([]byte, error) {
 	return sigBytes, nil
 }
 
-// Verify returns the address that corresponds to the given signature and signed message
-func (s *Signature) Verify(hash common.Hash) (common.Address, error) {
+// secp256k1HalfN is half the order of the secp256k1 curve. Signatures with a S value greater
+// than half the curve order are malleable and rejected.
+var secp256k1HalfN = new(big.Int).Div(crypto.S256().Params().N, big.NewInt(2))
+
+// RecoverRaw returns the address that signed the given hash (without any prefix). Both 0/1 and 27/28
+// recovery ids are accepted.
+func (s *Signature) RecoverRaw(hash common.Hash) (common.Address, error) {
+	if s == nil {
+		return common.Address{}, errors.New("Signature is missing")
+	}
+
+	v := s.V
+	if v >= 27 {
+		v -= 27
+	}
+
+	if v > 1 {
+		return common.Address{}, errors.New("Invalid signature recovery id")
+	}
+
+	if new(big.Int).SetBytes(s.S.Bytes()).Cmp(secp256k1HalfN) > 0 {
+		return common.Address{}, errors.New("Invalid signature: malleable S value")
+	}
+
+	sigBytes := append([]byte{}, s.R.Bytes()...)
+	sigBytes = append(sigBytes, s.S.Bytes()...)
+	sigBytes = append(sigBytes, v)
 
-	hashBytes := hash.Bytes()
-	sigBytes, err := s.MarshalSignature()
+	pubKey, err := crypto.SigToPub(hash.Bytes(), sigBytes)
 	if err != nil {
 		return common.Address{}, err
 	}
 
-	pubKey, err := crypto.SigToPub(hashBytes, sigBytes)
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// Recover returns the address that signed the given hash with the "Ethereum Signed Message" prefix
+// (as signed by Wallet.SignHash or web3 personal_sign)
+func (s *Signature) Recover(hash common.Hash) (common.Address, error) {
+	message := crypto.Keccak256(
+		[]byte("\x19Ethereum Signed Message:\n32"),
+		hash.Bytes(),
+	)
+
+	return s.RecoverRaw(common.BytesToHash(message))
+}
+
+// Verify returns an error if the given hash (with the "Ethereum Signed Message" prefix)
+// was not signed by the given signer
+func (s *Signature) Verify(hash common.Hash, signer common.Address) error {
+	address, err := s.Recover(hash)
 	if err != nil {
-		return common.Address{}, err
+		return err
 	}
-	address := crypto.PubkeyToAddress(*pubKey)
-	return address, nil
+
+	if address != signer {
+		return errors.New("Recovered address is incorrect")
+	}
+
+	return nil
 }
 
+// signedMessagePrefix is prepended to hashes signed with the "Ethereum Signed Message" scheme
+var signedMessagePrefix = []byte("\x19Ethereum Signed Message:\n32")
+
 // Sign calculates the EDCSA signature corresponding of a hashed message from a given private key
 func Sign(hash common.Hash, privKey *ecdsa.PrivateKey) (*Signature, error) {
 	sigBytes, err := crypto.Sign(hash.Bytes(), privKey)
@@ -83,10 +314,7 @@ func Sign(hash common.Hash, privKey *ecdsa.PrivateKey) (*Signature, error) {
 // SignHash also calculates the EDCSA signature of a message but adds an "Ethereum Signed Message" prefix
 // https://github.com/ethereum/EIPs/issues/191
 func SignHash(hash common.Hash, privKey *ecdsa.PrivateKey) (*Signature, error) {
-	message := crypto.Keccak256(
-		[]byte("\x19Ethereum Signed Message:\n32"),
-		hash.Bytes(),
-	)
+	message := crypto.Keccak256(signedMessagePrefix, hash.Bytes())
 
 	sigBytes, err := crypto.Sign(message, privKey)
 	if err != nil {
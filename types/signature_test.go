@@ -0,0 +1,241 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestSignatureRecover(t *testing.T) {
+	w := NewWallet()
+	hash := common.HexToHash("0xb855f4c28160c01034986b68694be4ed6364a5612f898e9b8e6e2ff711ed41f2")
+
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address, err := sig.Recover(hash)
+	assert.Nil(t, err)
+	assert.Equal(t, w.Address, address)
+	assert.Nil(t, sig.Verify(hash, w.Address))
+	assert.Error(t, sig.Verify(hash, NewWallet().Address))
+	assert.Error(t, sig.Verify(common.HexToHash("0x01"), w.Address))
+
+	// 0/1 recovery ids
+	sig.V -= 27
+	assert.Nil(t, sig.Verify(hash, w.Address))
+
+	sig.V = 2
+	assert.Error(t, sig.Verify(hash, w.Address))
+
+	var missing *Signature
+	assert.Error(t, missing.Verify(hash, w.Address))
+}
+
+// Signature returned by web3.eth.personal.sign(hash, address) for the test wallet
+func TestSignatureRecoverPersonalSign(t *testing.T) {
+	address := common.HexToAddress("0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47")
+	hash := crypto.Keccak256Hash([]byte("amp personal_sign test"))
+
+	sig := &Signature{
+		R: common.HexToHash("0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7"),
+		S: common.HexToHash("0x582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba"),
+		V: 28,
+	}
+
+	recovered, err := sig.Recover(hash)
+	assert.Nil(t, err)
+	assert.Equal(t, address, recovered)
+
+	w, err := NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := w.SignHash(hash)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, sig)
+}
+
+func TestSignatureRecoverMalleable(t *testing.T) {
+	hash := crypto.Keccak256Hash([]byte("amp personal_sign test"))
+
+	// same signature as above with S replaced by N - S and the recovery id flipped
+	sig := &Signature{
+		R: common.HexToHash("0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7"),
+		S: common.HexToHash("0xa7d4ce8b9a70672116c58b56018fb2086e3d0ba0c56161add2dd8d3340306887"),
+		V: 27,
+	}
+
+	_, err := sig.Recover(hash)
+	assert.Error(t, err)
+}
+
+func TestSignatureCompactEncoding(t *testing.T) {
+	compact := "0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba1c"
+	expected := &Signature{
+		R: common.HexToHash("0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7"),
+		S: common.HexToHash("0x582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba"),
+		V: 28,
+	}
+
+	sig := &Signature{}
+	err := sig.UnmarshalText([]byte(compact))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, sig)
+
+	text, err := sig.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, compact, string(text))
+
+	decoded, err := NewSignature(sig.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, sig, decoded)
+
+	// 0/1 recovery ids are normalized to 27/28
+	b := sig.Bytes()
+	b[64] = 1
+	decoded, err = NewSignature(b)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, decoded)
+
+	decoded.V = 1
+	assert.Equal(t, compact, hexutil.Encode(decoded.Bytes()))
+
+	b[64] = 29
+	_, err = NewSignature(b)
+	assert.Error(t, err)
+
+	invalid := []string{
+		"",
+		"0x",
+		compact[:len(compact)-2],
+		compact + "00",
+		"0xzz" + compact[4:],
+	}
+
+	for _, s := range invalid {
+		err = (&Signature{}).UnmarshalText([]byte(s))
+		assert.Error(t, err, "Expected an error for signature %q", s)
+	}
+}
+
+func TestSignatureJSON(t *testing.T) {
+	compact := "0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba1c"
+	expected := &Signature{
+		R: common.HexToHash("0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7"),
+		S: common.HexToHash("0x582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba"),
+		V: 28,
+	}
+
+	b, err := json.Marshal(expected)
+	assert.Nil(t, err)
+	assert.Equal(t, `"`+compact+`"`, string(b))
+
+	inputs := []string{
+		`"` + compact + `"`,
+		`{"V":28,"R":"0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7","S":"0x582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba"}`,
+		`{"V":1,"R":"0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7","S":"0x582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba"}`,
+	}
+
+	for _, input := range inputs {
+		sig := &Signature{}
+		err := json.Unmarshal([]byte(input), sig)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, sig)
+	}
+
+	err = json.Unmarshal([]byte(`{"V":28}`), &Signature{})
+	assert.Error(t, err)
+
+	err = json.Unmarshal([]byte(`123`), &Signature{})
+	assert.Error(t, err)
+}
+
+func TestOrderUnmarshalCompactSignature(t *testing.T) {
+	compact := "0xb6ea11ee649af1c6a56dfe41ca4ae7368c563a9dd2b529ddf78bd551aa2825e7582b3174658f98dee93a74a9fe704df64c71d145e9e73e8decf4d1599005d8ba1c"
+
+	o := &Order{}
+	err := json.Unmarshal([]byte(`{"signature":"`+compact+`"}`), o)
+	assert.Nil(t, err)
+	assert.Equal(t, byte(28), o.Signature.V)
+
+	err = json.Unmarshal([]byte(`{"signature":"0x1234"}`), &Order{})
+	assert.Error(t, err)
+}
+
+func TestSignatureBSON(t *testing.T) {
+	w := NewWallet()
+	hash := common.HexToHash("0xb855f4c28160c01034986b68694be4ed6364a5612f898e9b8e6e2ff711ed41f2")
+
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := bson.Marshal(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := &SignatureRecord{}
+	err = bson.Unmarshal(encoded, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &SignatureRecord{int(sig.V), sig.R.Hex(), sig.S.Hex()}, record)
+
+	decoded := &Signature{}
+	err = bson.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, sig, decoded)
+	assert.Nil(t, decoded.Verify(hash, w.Address))
+}
+
+func TestSignatureBSONLegacyLayout(t *testing.T) {
+	w := NewWallet()
+	hash := common.HexToHash("0xb855f4c28160c01034986b68694be4ed6364a5612f898e9b8e6e2ff711ed41f2")
+
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// default struct encoding used before signatures implemented bson.Getter
+	legacy := struct {
+		V byte
+		R [32]byte
+		S [32]byte
+	}{sig.V, sig.R, sig.S}
+
+	encoded, err := bson.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Signature{}
+	err = bson.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, sig, decoded)
+	assert.Nil(t, decoded.Verify(hash, w.Address))
+
+	encoded, err = bson.Marshal(bson.M{"V": 27})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, bson.Unmarshal(encoded, &Signature{}))
+}
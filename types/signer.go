@@ -0,0 +1,150 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer is implemented by any object that can sign hashes on behalf of an ethereum account.
+// Sign computes a raw ECDSA signature of the hash (used for transactions and typed data)
+// while SignHash adds the "Ethereum Signed Message" prefix before signing (used for orders,
+// trades and order cancels).
+type Signer interface {
+	Sign(h common.Hash) (*Signature, error)
+	SignHash(h common.Hash) (*Signature, error)
+	SignerAddress() common.Address
+}
+
+// NewTransactor returns transaction options that sign transactions with the given signer
+func NewTransactor(s Signer) *bind.TransactOpts {
+	from := s.SignerAddress()
+
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(signer eth.Signer, address common.Address, tx *eth.Transaction) (*eth.Transaction, error) {
+			if address != from {
+				return nil, errors.New("Not authorized to sign this account")
+			}
+
+			sig, err := s.Sign(signer.Hash(tx))
+			if err != nil {
+				return nil, err
+			}
+
+			sigBytes, err := sig.MarshalSignature()
+			if err != nil {
+				return nil, err
+			}
+
+			return tx.WithSignature(signer, sigBytes)
+		},
+	}
+}
+
+// RemoteSigner forwards hashes to an external signing service (for example an HSM backed
+// signing server) so that private keys never have to be held in memory by the engine.
+// The signing service is expected to accept a POST request with a {"address", "hash"} json body
+// and to respond with a {"V", "R", "S"} json signature of the raw hash.
+type RemoteSigner struct {
+	URL        string
+	Address    common.Address
+	Retries    int
+	RetryDelay time.Duration
+	client     *http.Client
+}
+
+// NewRemoteSigner returns a remote signer for the given account. Requests to the signing
+// service time out after the given timeout and are retried up to 3 times.
+func NewRemoteSigner(url string, address common.Address, timeout time.Duration) *RemoteSigner {
+	return &RemoteSigner{
+		URL:        url,
+		Address:    address,
+		Retries:    3,
+		RetryDelay: 500 * time.Millisecond,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+// SignerAddress returns the address of the account of the remote signer
+func (s *RemoteSigner) SignerAddress() common.Address {
+	return s.Address
+}
+
+// Sign requests a signature of the raw hash from the remote signing service
+func (s *RemoteSigner) Sign(h common.Hash) (*Signature, error) {
+	var err error
+
+	for i := 0; i <= s.Retries; i++ {
+		if i > 0 {
+			time.Sleep(s.RetryDelay)
+		}
+
+		var sig *Signature
+		sig, err = s.requestSignature(h)
+		if err == nil {
+			return sig, nil
+		}
+
+		logger.Warning("Remote signing request failed: ", err)
+	}
+
+	return nil, fmt.Errorf("Remote signing failed after %d attempts: %v", s.Retries+1, err)
+}
+
+// SignHash adds the "Ethereum Signed Message" prefix to the hash and requests a signature
+// from the remote signing service
+func (s *RemoteSigner) SignHash(h common.Hash) (*Signature, error) {
+	message := crypto.Keccak256(
+		[]byte("\x19Ethereum Signed Message:\n32"),
+		h.Bytes(),
+	)
+
+	return s.Sign(common.BytesToHash(message))
+}
+
+func (s *RemoteSigner) requestSignature(h common.Hash) (*Signature, error) {
+	body, err := json.Marshal(map[string]string{
+		"address": s.Address.Hex(),
+		"hash":    h.Hex(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Signing service responded with status %v", res.StatusCode)
+	}
+
+	sig := &Signature{}
+	err = json.NewDecoder(res.Body).Decode(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := sig.RecoverRaw(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != s.Address {
+		return nil, errors.New("Signing service returned a signature from an incorrect account")
+	}
+
+	return sig, nil
+}
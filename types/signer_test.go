@@ -0,0 +1,170 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSigningService signs the hashes it receives with a local wallet and records them
+type fakeSigningService struct {
+	wallet   *Wallet
+	failures int
+	requests []common.Hash
+	mutex    sync.Mutex
+}
+
+func (f *fakeSigningService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	req := map[string]string{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h := common.HexToHash(req["hash"])
+	f.requests = append(f.requests, h)
+
+	sig, err := f.wallet.Sign(h)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(sig)
+}
+
+func newTestRemoteSigner(f *fakeSigningService) (*RemoteSigner, *httptest.Server) {
+	server := httptest.NewServer(f)
+	s := NewRemoteSigner(server.URL, f.wallet.Address, time.Second)
+	s.RetryDelay = time.Millisecond
+	return s, server
+}
+
+func TestRemoteSignerSignOrder(t *testing.T) {
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
+	w, err := NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeSigningService{wallet: w}
+
+	s, server := newTestRemoteSigner(f)
+	defer server.Close()
+
+	o := &Order{
+		UserAddress:     w.Address,
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0x4bc89ac6f1c55ea645294f3fed949813a768ac6d"),
+		SellToken:       common.HexToAddress("0xd27a76b3a44e4d2e2b8a4a6d2a8e1e07f8e1b9d0"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		MakeFee:         big.NewInt(50),
+		TakeFee:         big.NewInt(50),
+		Expires:         big.NewInt(10000),
+		Nonce:           big.NewInt(1000),
+	}
+
+	err = o.Sign(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, err := o.VerifySignature()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.True(t, valid)
+	assert.Equal(t, 1, len(f.requests))
+
+	expected := &Order{}
+	*expected = *o
+	w.SignOrder(expected)
+
+	assert.Equal(t, expected.Signature, o.Signature)
+}
+
+func TestRemoteSignerRetries(t *testing.T) {
+	w := NewWallet()
+	f := &fakeSigningService{wallet: w, failures: 2}
+
+	s, server := newTestRemoteSigner(f)
+	defer server.Close()
+
+	h := common.HexToHash("0x163be7d92329e70924b8b84a7db665c01fbbd1fbeff626fd77d00b823bf05491")
+	sig, err := s.Sign(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, _ := sig.RecoverRaw(h)
+	assert.Equal(t, w.Address, signer)
+	assert.Equal(t, []common.Hash{h}, f.requests)
+
+	f.failures = s.Retries + 1
+	_, err = s.Sign(h)
+	assert.Error(t, err)
+}
+
+func TestRemoteSignerWrongAccount(t *testing.T) {
+	f := &fakeSigningService{wallet: NewWallet()}
+
+	s, server := newTestRemoteSigner(f)
+	defer server.Close()
+
+	// the signing service signs with a different account than the one requested
+	s.Address = NewWallet().Address
+	s.Retries = 0
+
+	_, err := s.Sign(common.HexToHash("0x01"))
+	assert.Error(t, err)
+}
+
+func TestNewTransactor(t *testing.T) {
+	w := NewWallet()
+	f := &fakeSigningService{wallet: w}
+
+	s, server := newTestRemoteSigner(f)
+	defer server.Close()
+
+	signer := eth.HomesteadSigner{}
+	tx := eth.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(1), 21000, big.NewInt(1), nil)
+
+	opts := NewTransactor(s)
+	assert.Equal(t, w.Address, opts.From)
+
+	signed, err := opts.Signer(signer, opts.From, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := eth.Sender(signer, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w.Address, from)
+	assert.Equal(t, []common.Hash{signer.Hash(tx)}, f.requests)
+
+	_, err = opts.Signer(signer, common.HexToAddress("0x02"), tx)
+	assert.Error(t, err)
+}
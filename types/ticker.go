@@ -0,0 +1,25 @@
+package types
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Ticker holds the best pricepoints of the orderbook of a pair and its last trade. Mid is the
+// average of the best bid and ask, rounded down, and Spread their difference: both are nil while a
+// side of the orderbook is empty. Sequence is the sequence of the orderbook at the time of the
+// ticker, so that the tickers of a pair can be ordered.
+type Ticker struct {
+	PairName       string         `json:"pairName"`
+	BaseToken      common.Address `json:"baseToken"`
+	QuoteToken     common.Address `json:"quoteToken"`
+	BestBid        *big.Int       `json:"bestBid"`
+	BestAsk        *big.Int       `json:"bestAsk"`
+	Mid            *big.Int       `json:"mid"`
+	Spread         *big.Int       `json:"spread"`
+	LastTradePrice *big.Int       `json:"lastTradePrice"`
+	LastTradeTime  *time.Time     `json:"lastTradeTime"`
+	Sequence       uint64         `json:"sequence"`
+}
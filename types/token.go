@@ -2,8 +2,10 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-ozzo/ozzo-validation"
 	"gopkg.in/mgo.v2/bson"
@@ -55,6 +57,40 @@ func (t Token) Validate() error {
 	)
 }
 
+// MarshalJSON implements the json.Marshal interface. The contract address is encoded
+// with its EIP-55 checksum.
+func (t Token) MarshalJSON() ([]byte, error) {
+	type token Token
+	return json.Marshal(struct {
+		token
+		ContractAddress string `json:"contractAddress"`
+	}{token(t), t.ContractAddress.Hex()})
+}
+
+// UnmarshalJSON implements the json.Unmarshal interface. Mixed-case contract addresses
+// are rejected if their EIP-55 checksum is invalid.
+func (t *Token) UnmarshalJSON(b []byte) error {
+	type token Token
+	decoded := struct {
+		*token
+		ContractAddress string `json:"contractAddress"`
+	}{token: (*token)(t)}
+
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return err
+	}
+
+	if decoded.ContractAddress != "" {
+		t.ContractAddress, err = utils.ParseAddress(decoded.ContractAddress)
+		if err != nil {
+			return fmt.Errorf("contractAddress: %v", err)
+		}
+	}
+
+	return nil
+}
+
 // GetBSON implements bson.Getter
 func (t *Token) GetBSON() (interface{}, error) {
 
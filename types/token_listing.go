@@ -0,0 +1,222 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Token listing request statuses
+const (
+	TokenListingPending  = "PENDING"
+	TokenListingApproved = "APPROVED"
+	TokenListingRejected = "REJECTED"
+)
+
+// TokenListingRequest is a request by a user to list a token. Requests are created as pending
+// and are approved or rejected by an admin wallet. Approved requests create the corresponding
+// token while rejected requests hold the reason of the rejection.
+type TokenListingRequest struct {
+	ID              bson.ObjectId  `json:"id" bson:"_id"`
+	ContractAddress common.Address `json:"contractAddress" bson:"contractAddress"`
+	Symbol          string         `json:"symbol" bson:"symbol"`
+	Decimal         int            `json:"decimal" bson:"decimal"`
+	Status          string         `json:"status" bson:"status"`
+	Reason          string         `json:"reason,omitempty" bson:"reason"`
+	ReviewedBy      common.Address `json:"reviewedBy,omitempty" bson:"reviewedBy"`
+	CreatedAt       time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt" bson:"updatedAt"`
+}
+
+// TokenListingRequestRecord is the struct which is stored in db
+type TokenListingRequestRecord struct {
+	ID              bson.ObjectId `bson:"_id"`
+	ContractAddress string        `bson:"contractAddress"`
+	Symbol          string        `bson:"symbol"`
+	Decimal         int           `bson:"decimal"`
+	Status          string        `bson:"status"`
+	Reason          string        `bson:"reason"`
+	ReviewedBy      string        `bson:"reviewedBy,omitempty"`
+	CreatedAt       time.Time     `bson:"createdAt"`
+	UpdatedAt       time.Time     `bson:"updatedAt"`
+}
+
+// Validate checks that the request holds a token address and a proposed symbol
+func (r *TokenListingRequest) Validate() error {
+	errs := ValidationErrors{}
+
+	if r.ContractAddress == (common.Address{}) {
+		errs.Add("contractAddress", "Address is required")
+	}
+
+	if strings.TrimSpace(r.Symbol) == "" {
+		errs.Add("symbol", "Symbol is required")
+	}
+
+	return errs.Err()
+}
+
+// MarshalJSON returns the json encoded request. The contract address is encoded with its
+// EIP-55 checksum and the reviewer is only included once the request has been reviewed.
+func (r *TokenListingRequest) MarshalJSON() ([]byte, error) {
+	request := map[string]interface{}{
+		"id":              r.ID,
+		"contractAddress": r.ContractAddress.Hex(),
+		"symbol":          r.Symbol,
+		"decimal":         r.Decimal,
+		"status":          r.Status,
+		"createdAt":       r.CreatedAt,
+		"updatedAt":       r.UpdatedAt,
+	}
+
+	if r.Reason != "" {
+		request["reason"] = r.Reason
+	}
+
+	if r.ReviewedBy != (common.Address{}) {
+		request["reviewedBy"] = r.ReviewedBy.Hex()
+	}
+
+	return json.Marshal(request)
+}
+
+// UnmarshalJSON decodes a listing request as submitted by a user. Only the contract address
+// and the proposed symbol are read, the other fields are set by the matching engine.
+func (r *TokenListingRequest) UnmarshalJSON(b []byte) error {
+	decoded := struct {
+		ContractAddress string `json:"contractAddress"`
+		Symbol          string `json:"symbol"`
+	}{}
+
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return err
+	}
+
+	if decoded.ContractAddress != "" {
+		r.ContractAddress, err = utils.ParseAddress(decoded.ContractAddress)
+		if err != nil {
+			return fmt.Errorf("contractAddress: %v", err)
+		}
+	}
+
+	r.Symbol = strings.TrimSpace(decoded.Symbol)
+	return nil
+}
+
+// GetBSON implements bson.Getter
+func (r *TokenListingRequest) GetBSON() (interface{}, error) {
+	record := TokenListingRequestRecord{
+		ID:              r.ID,
+		ContractAddress: r.ContractAddress.Hex(),
+		Symbol:          r.Symbol,
+		Decimal:         r.Decimal,
+		Status:          r.Status,
+		Reason:          r.Reason,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+
+	if r.ReviewedBy != (common.Address{}) {
+		record.ReviewedBy = r.ReviewedBy.Hex()
+	}
+
+	return record, nil
+}
+
+// SetBSON implements bson.Setter
+func (r *TokenListingRequest) SetBSON(raw bson.Raw) error {
+	decoded := &TokenListingRequestRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	r.ID = decoded.ID
+	r.ContractAddress = common.HexToAddress(decoded.ContractAddress)
+	r.Symbol = decoded.Symbol
+	r.Decimal = decoded.Decimal
+	r.Status = decoded.Status
+	r.Reason = decoded.Reason
+	if common.IsHexAddress(decoded.ReviewedBy) {
+		r.ReviewedBy = common.HexToAddress(decoded.ReviewedBy)
+	}
+
+	r.CreatedAt = decoded.CreatedAt
+	r.UpdatedAt = decoded.UpdatedAt
+	return nil
+}
+
+// TokenListingReview is the decision of an admin on a token listing request. The admin signs
+// the hash of the request id, the decision and the reason with the admin wallet.
+type TokenListingReview struct {
+	RequestID bson.ObjectId  `json:"-"`
+	Status    string         `json:"-"`
+	Admin     common.Address `json:"admin"`
+	Reason    string         `json:"reason"`
+	Signature *Signature     `json:"signature"`
+}
+
+// ComputeHash returns the hash signed by the admin reviewing a listing request
+func (r *TokenListingReview) ComputeHash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write([]byte(r.RequestID.Hex()))
+	sha.Write([]byte(r.Status))
+	sha.Write([]byte(r.Reason))
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// Sign signs the review with the given admin wallet
+func (r *TokenListingReview) Sign(w *Wallet) error {
+	sig, err := w.SignHash(r.ComputeHash())
+	if err != nil {
+		return err
+	}
+
+	r.Admin = w.Address
+	r.Signature = sig
+	return nil
+}
+
+// Verify returns an error if the review was not signed by the admin address it holds
+func (r *TokenListingReview) Verify() error {
+	if r.Signature == nil {
+		return errors.New("Review signature is missing")
+	}
+
+	return r.Signature.Verify(r.ComputeHash(), r.Admin)
+}
+
+// UnmarshalJSON decodes the admin address, the reason and the signature of a review. The
+// request id and the decision are taken from the endpoint the review is sent to.
+func (r *TokenListingReview) UnmarshalJSON(b []byte) error {
+	decoded := struct {
+		Admin     string     `json:"admin"`
+		Reason    string     `json:"reason"`
+		Signature *Signature `json:"signature"`
+	}{}
+
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return err
+	}
+
+	if decoded.Admin != "" {
+		r.Admin, err = utils.ParseAddress(decoded.Admin)
+		if err != nil {
+			return fmt.Errorf("admin: %v", err)
+		}
+	}
+
+	r.Reason = decoded.Reason
+	r.Signature = decoded.Signature
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
@@ -14,6 +15,17 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
+// Trade hash versions. Trades signed before the fees were part of the trade hash are
+// still verified with the legacy version until they are settled.
+const (
+	// TradeHashV0 is the legacy trade hash which does not commit to the trade fees
+	TradeHashV0 = 0
+	// TradeHashV1 includes the make and take fees of the trade
+	TradeHashV1 = 1
+	// CurrentTradeHashVersion is the hash version of new trades
+	CurrentTradeHashVersion = TradeHashV1
+)
+
 // Trade struct holds arguments corresponding to a "Taker Order"
 // To be valid an accept by the matching engine (and ultimately the exchange smart-contract),
 // the trade signature must be made from the trader Maker account
@@ -33,41 +45,58 @@ type Trade struct {
 	CreatedAt      time.Time      `json:"createdAt" bson:"createdAt" redis:"createdAt"`
 	UpdatedAt      time.Time      `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
 	PricePoint     *big.Int       `json:"pricepoint" bson:"pricepoint"`
-	Side           string         `json:"side" bson:"side"`
+	Side           OrderSide      `json:"side" bson:"side"`
 	Status         string         `json:"status" bson:"status"`
 	Amount         *big.Int       `json:"amount" bson:"amount"`
+	MakeFee        *big.Int       `json:"makeFee" bson:"makeFee"`
+	TakeFee        *big.Int       `json:"takeFee" bson:"takeFee"`
+	HashVersion    int            `json:"hashVersion" bson:"hashVersion"`
+
+	// MakerFilledBefore and TakerFilledBefore are the amounts of the maker and taker orders filled
+	// before the trade. They place the trade among the fills of each order so that the token
+	// amounts of the fills are computed without losing rounding remainders (see Order.FillAmounts).
+	MakerFilledBefore *big.Int `json:"makerFilledBefore" bson:"makerFilledBefore"`
+	TakerFilledBefore *big.Int `json:"takerFilledBefore" bson:"takerFilledBefore"`
 }
 
 type TradeRecord struct {
-	ID             bson.ObjectId    `json:"id" bson:"_id"`
-	Taker          string           `json:"taker" bson:"taker"`
-	Maker          string           `json:"maker" bson:"maker"`
-	BaseToken      string           `json:"baseToken" bson:"baseToken"`
-	QuoteToken     string           `json:"quoteToken" bson:"quoteToken"`
-	OrderHash      string           `json:"orderHash" bson:"orderHash"`
-	TakerOrderHash string           `json:"takerOrderHash" bson:"takerOrderHash"`
-	Hash           string           `json:"hash" bson:"hash"`
-	TxHash         string           `json:"txHash" bson:"txHash"`
-	PairName       string           `json:"pairName" bson:"pairName"`
-	TradeNonce     string           `json:"tradeNonce" bson:"tradeNonce"`
-	Signature      *SignatureRecord `json:"signature" bson:"signature"`
-	CreatedAt      time.Time        `json:"createdAt" bson:"createdAt"`
-	UpdatedAt      time.Time        `json:"updatedAt" bson:"updatedAt"`
-	PricePoint     string           `json:"pricepoint" bson:"pricepoint"`
-	Side           string           `json:"side" bson:"side"`
-	Amount         string           `json:"amount" bson:"amount"`
+	ID             bson.ObjectId `json:"id" bson:"_id"`
+	Taker          string        `json:"taker" bson:"taker"`
+	Maker          string        `json:"maker" bson:"maker"`
+	BaseToken      string        `json:"baseToken" bson:"baseToken"`
+	QuoteToken     string        `json:"quoteToken" bson:"quoteToken"`
+	OrderHash      string        `json:"orderHash" bson:"orderHash"`
+	TakerOrderHash string        `json:"takerOrderHash" bson:"takerOrderHash"`
+	Hash           string        `json:"hash" bson:"hash"`
+	TxHash         string        `json:"txHash" bson:"txHash"`
+	PairName       string        `json:"pairName" bson:"pairName"`
+	TradeNonce     string        `json:"tradeNonce" bson:"tradeNonce"`
+	Signature      *Signature    `json:"signature" bson:"signature"`
+	CreatedAt      time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt" bson:"updatedAt"`
+	PricePoint     string        `json:"pricepoint" bson:"pricepoint"`
+	Side           string        `json:"side" bson:"side"`
+	Amount         string        `json:"amount" bson:"amount"`
+	MakeFee        string        `json:"makeFee" bson:"makeFee"`
+	TakeFee        string        `json:"takeFee" bson:"takeFee"`
+	HashVersion    int           `json:"hashVersion" bson:"hashVersion"`
+	Status         string        `json:"status" bson:"status"`
+
+	MakerFilledBefore string `json:"makerFilledBefore" bson:"makerFilledBefore"`
+	TakerFilledBefore string `json:"takerFilledBefore" bson:"takerFilledBefore"`
 }
 
 // NewTrade returns a new unsigned trade corresponding to an Order, amount and taker address
 func NewTrade(o *Order, amount *big.Int, price *big.Int, taker common.Address) *Trade {
 	t := &Trade{
-		OrderHash:  o.Hash,
-		PairName:   o.PairName,
-		Amount:     amount,
-		TradeNonce: big.NewInt(0),
-		Side:       o.Side,
-		Taker:      taker,
-		Signature:  &Signature{},
+		OrderHash:   o.Hash,
+		PairName:    o.PairName,
+		Amount:      amount,
+		TradeNonce:  big.NewInt(0),
+		Side:        o.Side,
+		Taker:       taker,
+		Signature:   &Signature{},
+		HashVersion: CurrentTradeHashVersion,
 	}
 
 	return t
@@ -76,17 +105,21 @@ func NewTrade(o *Order, amount *big.Int, price *big.Int, taker common.Address) *
 // MarshalJSON returns the json encoded byte array representing the trade struct
 func (t *Trade) MarshalJSON() ([]byte, error) {
 	trade := map[string]interface{}{
-		"taker":          t.Taker,
-		"maker":          t.Maker,
+		"taker":          t.Taker.Hex(),
+		"maker":          t.Maker.Hex(),
 		"orderHash":      t.OrderHash,
 		"takerOrderHash": t.TakerOrderHash,
 		"side":           t.Side,
 		"hash":           t.Hash,
 		"txHash":         t.TxHash,
 		"pairName":       t.PairName,
-		"tradeNonce":     t.TradeNonce.String(),
-		"pricepoint":     t.PricePoint.String(),
-		"amount":         t.Amount.String(),
+		"hashVersion":    t.HashVersion,
+	}
+
+	for k, n := range t.bigIntFields() {
+		if *n != nil {
+			trade[k] = (*n).String()
+		}
 	}
 
 	if (t.BaseToken != common.Address{}) {
@@ -124,9 +157,7 @@ func (t *Trade) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON creates a trade object from a json byte string
 func (t *Trade) UnmarshalJSON(b []byte) error {
-	trade := map[string]interface{}{}
-
-	err := json.Unmarshal(b, &trade)
+	trade, err := unmarshalJSONObject(b)
 	if err != nil {
 		return err
 	}
@@ -143,28 +174,22 @@ func (t *Trade) UnmarshalJSON(b []byte) error {
 		t.Hash = common.HexToHash(trade["hash"].(string))
 	}
 
-	if trade["quoteToken"] == nil {
-		return errors.New("Quote token is not set")
-	} else {
-		t.QuoteToken = common.HexToAddress(trade["quoteToken"].(string))
-	}
-
-	if trade["baseToken"] == nil {
-		return errors.New("Base token is not set")
-	} else {
-		t.BaseToken = common.HexToAddress(trade["baseToken"].(string))
+	addresses := map[string]*common.Address{
+		"baseToken":  &t.BaseToken,
+		"quoteToken": &t.QuoteToken,
+		"maker":      &t.Maker,
+		"taker":      &t.Taker,
 	}
 
-	if trade["maker"] == nil {
-		return errors.New("Maker is not set")
-	} else {
-		t.Taker = common.HexToAddress(trade["taker"].(string))
-	}
+	for k, a := range addresses {
+		if trade[k] == nil {
+			return fmt.Errorf("%v is not set", k)
+		}
 
-	if trade["taker"] == nil {
-		return errors.New("Taker is not set")
-	} else {
-		t.Maker = common.HexToAddress(trade["maker"].(string))
+		*a, err = parseAddress(trade[k])
+		if err != nil {
+			return fmt.Errorf("%v: %v", k, err)
+		}
 	}
 
 	if trade["id"] != nil && bson.IsObjectIdHex(trade["id"].(string)) {
@@ -184,35 +209,63 @@ func (t *Trade) UnmarshalJSON(b []byte) error {
 	}
 
 	if trade["side"] != nil {
-		t.Side = trade["side"].(string)
+		t.Side, err = parseOrderSide(trade["side"])
+		if err != nil {
+			return fmt.Errorf("side: %v", err)
+		}
 	}
 
-	if trade["pricepoint"] != nil {
-		t.PricePoint = math.ToBigInt(fmt.Sprintf("%v", trade["pricepoint"]))
-	}
+	for k, n := range t.bigIntFields() {
+		if trade[k] == nil {
+			continue
+		}
 
-	if trade["amount"] != nil {
-		t.Amount = new(big.Int)
-		t.Amount.UnmarshalJSON([]byte(fmt.Sprintf("%v", trade["amount"])))
+		*n, err = parseBigInt(trade[k])
+		if err != nil {
+			return fmt.Errorf("%v: %v", k, err)
+		}
 	}
 
-	if trade["tradeNonce"] != nil {
-		t.TradeNonce = new(big.Int)
-		t.TradeNonce.UnmarshalJSON([]byte(fmt.Sprintf("%v", trade["tradeNonce"])))
+	// trades encoded before the hash version was introduced use the legacy hash
+	t.HashVersion = TradeHashV0
+	if trade["hashVersion"] != nil {
+		version, ok := trade["hashVersion"].(json.Number)
+		if !ok {
+			return fmt.Errorf("Invalid hashVersion: %v", trade["hashVersion"])
+		}
+
+		v, err := strconv.Atoi(version.String())
+		if err != nil {
+			return fmt.Errorf("Invalid hashVersion: %v", version)
+		}
+
+		t.HashVersion = v
 	}
 
 	if trade["signature"] != nil {
-		signature := trade["signature"].(map[string]interface{})
-		t.Signature = &Signature{
-			V: byte(signature["V"].(float64)),
-			R: common.HexToHash(signature["R"].(string)),
-			S: common.HexToHash(signature["S"].(string)),
+		t.Signature, err = decodeSignature(trade["signature"])
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// bigIntFields returns the integer fields of the trade indexed by their json key
+func (t *Trade) bigIntFields() map[string]**big.Int {
+	return map[string]**big.Int{
+		"tradeNonce": &t.TradeNonce,
+		"pricepoint": &t.PricePoint,
+		"amount":     &t.Amount,
+		"makeFee":    &t.MakeFee,
+		"takeFee":    &t.TakeFee,
+
+		"makerFilledBefore": &t.MakerFilledBefore,
+		"takerFilledBefore": &t.TakerFilledBefore,
+	}
+}
+
 func (t *Trade) GetBSON() (interface{}, error) {
 	tr := TradeRecord{
 		ID:             t.ID,
@@ -222,23 +275,23 @@ func (t *Trade) GetBSON() (interface{}, error) {
 		BaseToken:      t.BaseToken.Hex(),
 		QuoteToken:     t.QuoteToken.Hex(),
 		OrderHash:      t.OrderHash.Hex(),
-		TradeNonce:     t.TradeNonce.String(),
+		TradeNonce:     encodeBigInt(t.TradeNonce),
 		Hash:           t.Hash.Hex(),
 		TxHash:         t.TxHash.Hex(),
 		TakerOrderHash: t.TakerOrderHash.Hex(),
 		CreatedAt:      t.CreatedAt,
 		UpdatedAt:      t.UpdatedAt,
-		PricePoint:     t.PricePoint.String(),
-		Side:           t.Side,
-		Amount:         t.Amount.String(),
-	}
+		PricePoint:     encodeBigInt(t.PricePoint),
+		Side:           string(t.Side),
+		Amount:         encodeBigInt(t.Amount),
+		MakeFee:        encodeBigInt(t.MakeFee),
+		TakeFee:        encodeBigInt(t.TakeFee),
+		Signature:      t.Signature,
+		HashVersion:    t.HashVersion,
+		Status:         t.Status,
 
-	if t.Signature != nil {
-		tr.Signature = &SignatureRecord{
-			V: t.Signature.V,
-			R: t.Signature.R.Hex(),
-			S: t.Signature.S.Hex(),
-		}
+		MakerFilledBefore: encodeBigInt(t.MakerFilledBefore),
+		TakerFilledBefore: encodeBigInt(t.TakerFilledBefore),
 	}
 
 	return tr, nil
@@ -246,23 +299,30 @@ func (t *Trade) GetBSON() (interface{}, error) {
 
 func (t *Trade) SetBSON(raw bson.Raw) error {
 	decoded := new(struct {
-		ID             bson.ObjectId    `json:"id,omitempty" bson:"_id"`
-		PairName       string           `json:"pairName" bson:"pairName"`
-		Taker          string           `json:"taker" bson:"taker"`
-		Maker          string           `json:"maker" bson:"maker"`
-		BaseToken      string           `json:"baseToken" bson:"baseToken"`
-		QuoteToken     string           `json:"quoteToken" bson:"quoteToken"`
-		OrderHash      string           `json:"orderHash" bson:"orderHash"`
-		TakerOrderHash string           `json:"takerOrderHash" bson:"takerOrderHash"`
-		Hash           string           `json:"hash" bson:"hash"`
-		TxHash         string           `json:"txHash" bson:"txHash"`
-		TradeNonce     string           `json:"tradeNonce" bson:"tradeNonce"`
-		Signature      *SignatureRecord `json:"signature" bson:"signature"`
-		CreatedAt      time.Time        `json:"createdAt" bson:"createdAt" redis:"createdAt"`
-		UpdatedAt      time.Time        `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
-		PricePoint     string           `json:"pricepoint" bson:"pricepoint"`
-		Side           string           `json:"side" bson:"side"`
-		Amount         string           `json:"amount" bson:"amount"`
+		ID             bson.ObjectId `json:"id,omitempty" bson:"_id"`
+		PairName       string        `json:"pairName" bson:"pairName"`
+		Taker          string        `json:"taker" bson:"taker"`
+		Maker          string        `json:"maker" bson:"maker"`
+		BaseToken      string        `json:"baseToken" bson:"baseToken"`
+		QuoteToken     string        `json:"quoteToken" bson:"quoteToken"`
+		OrderHash      string        `json:"orderHash" bson:"orderHash"`
+		TakerOrderHash string        `json:"takerOrderHash" bson:"takerOrderHash"`
+		Hash           string        `json:"hash" bson:"hash"`
+		TxHash         string        `json:"txHash" bson:"txHash"`
+		TradeNonce     string        `json:"tradeNonce" bson:"tradeNonce"`
+		Signature      *Signature    `json:"signature" bson:"signature"`
+		CreatedAt      time.Time     `json:"createdAt" bson:"createdAt" redis:"createdAt"`
+		UpdatedAt      time.Time     `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
+		PricePoint     string        `json:"pricepoint" bson:"pricepoint"`
+		Side           string        `json:"side" bson:"side"`
+		Amount         string        `json:"amount" bson:"amount"`
+		MakeFee        string        `json:"makeFee" bson:"makeFee"`
+		TakeFee        string        `json:"takeFee" bson:"takeFee"`
+		HashVersion    int           `json:"hashVersion" bson:"hashVersion"`
+		Status         string        `json:"status" bson:"status"`
+
+		MakerFilledBefore string `json:"makerFilledBefore" bson:"makerFilledBefore"`
+		TakerFilledBefore string `json:"takerFilledBefore" bson:"takerFilledBefore"`
 	})
 
 	err := raw.Unmarshal(decoded)
@@ -281,55 +341,168 @@ func (t *Trade) SetBSON(raw bson.Raw) error {
 	t.Hash = common.HexToHash(decoded.Hash)
 	t.TxHash = common.HexToHash(decoded.TxHash)
 
-	t.TradeNonce = math.ToBigInt(decoded.TradeNonce)
-	t.Amount = math.ToBigInt(decoded.Amount)
-	t.PricePoint = math.ToBigInt(decoded.PricePoint)
-	t.Side = decoded.Side
+	t.Side = OrderSide(decoded.Side)
+	t.HashVersion = decoded.HashVersion
+	t.Status = decoded.Status
 
-	if decoded.Signature != nil {
-		t.Signature = &Signature{
-			V: byte(decoded.Signature.V),
-			R: common.HexToHash(decoded.Signature.R),
-			S: common.HexToHash(decoded.Signature.S),
+	records := map[string]string{
+		"tradeNonce": decoded.TradeNonce,
+		"pricepoint": decoded.PricePoint,
+		"amount":     decoded.Amount,
+		"makeFee":    decoded.MakeFee,
+		"takeFee":    decoded.TakeFee,
+
+		"makerFilledBefore": decoded.MakerFilledBefore,
+		"takerFilledBefore": decoded.TakerFilledBefore,
+	}
+
+	for k, n := range t.bigIntFields() {
+		*n, err = decodeBigInt(records[k])
+		if err != nil {
+			logger.Error(err)
+			return err
 		}
 	}
 
+	t.Signature = decoded.Signature
+
 	t.CreatedAt = decoded.CreatedAt
 	t.UpdatedAt = decoded.UpdatedAt
 	return nil
 }
 
-// ComputeHash returns hashes the trade
+// Clone returns a deep copy of the trade
+func (t *Trade) Clone() *Trade {
+	c := *t
+	for _, n := range c.bigIntFields() {
+		if *n != nil {
+			*n = new(big.Int).Set(*n)
+		}
+	}
+
+	if t.Signature != nil {
+		sig := *t.Signature
+		c.Signature = &sig
+	}
+
+	return &c
+}
+
+// EncodedBytes returns the tightly packed trade fields hashed by the exchange smart contract.
+// The make and take fees are appended from hash version 1 on, unset fees being packed as zero.
+// Legacy trades do not commit to the fees.
+func (t *Trade) EncodedBytes() []byte {
+	b := make([]byte, 0, common.HashLength+common.AddressLength+4*common.HashLength)
+	b = append(b, t.OrderHash.Bytes()...)
+	b = append(b, t.Taker.Bytes()...)
+	b = append(b, common.BigToHash(t.Amount).Bytes()...)
+	b = append(b, common.BigToHash(t.TradeNonce).Bytes()...)
+
+	if t.HashVersion >= TradeHashV1 {
+		for _, fee := range []*big.Int{t.MakeFee, t.TakeFee} {
+			if fee == nil {
+				fee = big.NewInt(0)
+			}
+
+			b = append(b, common.BigToHash(fee).Bytes()...)
+		}
+	}
+
+	return b
+}
+
+// ComputeHash returns hashes the trade with the trade hash version
 // The OrderHash, Amount, Taker and TradeNonce attributes must be
 // set before attempting to compute the trade hash
 func (t *Trade) ComputeHash() common.Hash {
 	sha := sha3.NewKeccak256()
-
-	sha.Write(t.OrderHash.Bytes())
-	sha.Write(t.Taker.Bytes())
-	sha.Write(common.BigToHash(t.Amount).Bytes())
-	sha.Write(common.BigToHash(t.TradeNonce).Bytes())
+	sha.Write(t.EncodedBytes())
 	return common.BytesToHash(sha.Sum(nil))
 }
 
-// VerifySignature verifies that the trade is correct and corresponds
-// to the trade Taker address
+// VerifySignature verifies that the trade hash corresponds to the trade content and that
+// the trade signature corresponds to the trade Taker address
 func (t *Trade) VerifySignature() (bool, error) {
-	address, err := t.Signature.Verify(t.Hash)
+	if t.Hash != t.ComputeHash() {
+		return false, errors.New("Trade hash does not match trade content")
+	}
+
+	err := t.Signature.Verify(t.Hash, t.Taker)
 	if err != nil {
 		return false, err
 	}
 
-	if address != t.Taker {
-		return false, errors.New("Recovered address is incorrect")
+	return true, nil
+}
+
+// Validate checks that the trade amount is positive and that the trade is signed by the taker.
+// If the trade is invalid, the returned error is a ValidationErrors listing each invalid field.
+func (t *Trade) Validate() error {
+	errs := ValidationErrors{}
+
+	if t.OrderHash == (common.Hash{}) {
+		errs.Add("orderHash", "Order hash is missing")
 	}
 
-	return true, nil
+	if t.Taker == (common.Address{}) {
+		errs.Add("taker", "Taker address is missing")
+	}
+
+	if t.Amount == nil || t.Amount.Sign() <= 0 {
+		errs.Add("amount", "Amount should be positive")
+	}
+
+	if t.TradeNonce == nil {
+		errs.Add("tradeNonce", "Trade nonce is missing")
+	}
+
+	if t.HashVersion < TradeHashV0 || t.HashVersion > CurrentTradeHashVersion {
+		errs.Add("hashVersion", "Unknown trade hash version")
+		return errs.Err()
+	}
+
+	// the hash can only be computed once the amount and nonce are set
+	if t.Amount == nil || t.TradeNonce == nil {
+		return errs.Err()
+	}
+
+	if t.Signature == nil {
+		errs.Add("signature", "Signature is missing")
+	} else if t.Hash != t.ComputeHash() {
+		errs.Add("hash", "Trade hash does not match trade content")
+	} else if _, err := t.VerifySignature(); err != nil {
+		errs.Add("signature", "Signature does not correspond to the taker")
+	}
+
+	return errs.Err()
+}
+
+// ValidateFill checks that the trade refers to the given order and that the trade amount does not
+// exceed the remaining fillable amount of the order
+func (t *Trade) ValidateFill(o *Order) error {
+	errs := ValidationErrors{}
+
+	if t.OrderHash != o.Hash {
+		errs.Add("orderHash", "Trade does not refer to this order")
+	}
+
+	if t.Amount != nil && o.Amount != nil {
+		fillable := o.Amount
+		if o.FilledAmount != nil {
+			fillable = math.Sub(o.Amount, o.FilledAmount)
+		}
+
+		if t.Amount.Cmp(fillable) > 0 {
+			errs.Add("amount", "Amount exceeds the remaining fillable amount of the order")
+		}
+	}
+
+	return errs.Err()
 }
 
 // Sign calculates ands sets the trade hash and signature with the
-// given wallet
-func (t *Trade) Sign(w *Wallet) error {
+// given signer
+func (t *Trade) Sign(w Signer) error {
 	hash := t.ComputeHash()
 	signature, err := w.SignHash(hash)
 	if err != nil {
@@ -362,12 +535,9 @@ func NewUnsignedTrade(o *Order, taker common.Address, amount *big.Int) (Trade, e
 	t.Taker = taker
 	t.Amount = amount
 	t.PairName = o.PairName
+	t.HashVersion = CurrentTradeHashVersion
 
-	if o.Side == "BUY" {
-		t.Side = "SELL"
-	} else if o.Side == "SELL" {
-		t.Side = "BUY"
-	}
+	t.Side = o.Side.Opposite()
 
 	return t, nil
 }
@@ -379,19 +549,16 @@ func NewUnsignedTrade1(maker *Order, taker *Order, amount *big.Int) (Trade, erro
 	t.Taker = taker.UserAddress
 	t.BaseToken = maker.BaseToken
 	t.QuoteToken = maker.QuoteToken
-	t.PricePoint = taker.PricePoint
+	t.PricePoint = maker.PricePoint
 	t.OrderHash = maker.Hash
 	t.TakerOrderHash = taker.Hash
 	t.PairName = maker.PairName
+	t.HashVersion = CurrentTradeHashVersion
 
 	//TODO compute from taker amount and maker amount
 	t.Amount = amount
 
-	if maker.Side == "BUY" {
-		t.Side = "SELL"
-	} else if maker.Side == "SELL" {
-		t.Side = "BUY"
-	}
+	t.Side = maker.Side.Opposite()
 
 	return t, nil
 }
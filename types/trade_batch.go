@@ -0,0 +1,55 @@
+package types
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrDuplicateTrade is the error of a trade of a batch that was not inserted because a trade
+// with the same hash already exists
+var ErrDuplicateTrade = errors.New("Trade already exists")
+
+// TradeError describes why a trade of a batch was not inserted
+type TradeError struct {
+	Trade *Trade
+	Err   error
+}
+
+// TradeBatchErrors is returned when one or more trades of a batch could not be inserted. The
+// other trades of the batch are inserted.
+type TradeBatchErrors []TradeError
+
+// Add appends a trade error
+func (errs *TradeBatchErrors) Add(t *Trade, err error) {
+	*errs = append(*errs, TradeError{Trade: t, Err: err})
+}
+
+// Failed returns true if the trade was not inserted
+func (errs TradeBatchErrors) Failed(t *Trade) bool {
+	for _, e := range errs {
+		if e.Trade == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Error returns all the trade errors as a single string
+func (errs TradeBatchErrors) Error() string {
+	messages := []string{}
+	for _, e := range errs {
+		messages = append(messages, e.Trade.Hash.Hex()+": "+e.Err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Err returns the trade errors or nil if there are none
+func (errs TradeBatchErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Trade export formats. CSV exports start with a header row (see TradeExportHeader) and JSON
+// exports hold one json object per line, keyed by the columns of the header.
+const (
+	TradeExportCSV  = "csv"
+	TradeExportJSON = "json"
+)
+
+// ErrInvalidExportFormat is returned for export formats other than TradeExportCSV and
+// TradeExportJSON
+var ErrInvalidExportFormat = errors.New("Invalid export format")
+
+// TradeExportHeader lists the columns of the trade exports
+var TradeExportHeader = []string{"pair", "side", "price", "amount", "fee", "txHash", "timestamp"}
+
+// TradeIterator walks over the trades returned by a query one at a time, so that the trades are
+// never all held in memory. Next decodes the next trade into t and returns false once there is
+// no trade left or on error. Close must always be called and returns the error that stopped
+// the iteration, if any.
+type TradeIterator interface {
+	Next(t *Trade) bool
+	Close() error
+}
+
+// ExportRecord returns the fields of a trade of the account addr in the order of
+// TradeExportHeader. The side and the fee are the ones of the account: the side of the taker
+// order and the take fee if the account is the taker of the trade, and the opposite side and the
+// make fee otherwise. The price is the pricepoint of the trade and the amounts are in base units
+// of their token. The timestamp is the RFC3339 UTC creation time of the trade and the tx hash is
+// empty for trades not sent to the exchange contract yet.
+func (t *Trade) ExportRecord(addr common.Address) []string {
+	side, fee := t.Side.Opposite(), t.MakeFee
+	if t.Taker == addr {
+		side, fee = t.Side, t.TakeFee
+	}
+
+	txHash := ""
+	if t.TxHash != (common.Hash{}) {
+		txHash = t.TxHash.Hex()
+	}
+
+	return []string{
+		t.PairName,
+		string(side),
+		exportInt(t.PricePoint),
+		exportInt(t.Amount),
+		exportInt(fee),
+		txHash,
+		t.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// exportInt returns the decimal representation of n, or an empty string if n is not set
+func exportInt(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
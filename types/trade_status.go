@@ -0,0 +1,82 @@
+package types
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// Trade statuses. A trade is PENDING from the time it is matched until its settlement transaction
+// is confirmed (SUCCESS) or fails (FAILED): the transaction reverted, the exchange contract
+// reported an error, or the transaction was not mined in time. ERROR is used for trades rolled back
+// before being sent to the exchange contract, eg. when the taker did not sign them. ORDER_PENDING
+// is the legacy status of trades whose transaction was sent and trades created before statuses
+// were set at matching have no status: both are pending.
+const (
+	TradeStatusPending      = "PENDING"
+	TradeStatusOrderPending = "ORDER_PENDING"
+	TradeStatusSuccess      = "SUCCESS"
+	TradeStatusFailed       = "FAILED"
+	TradeStatusInvalid      = "INVALID"
+	TradeStatusError        = "ERROR"
+)
+
+// TradePendingStatuses lists the statuses of the trades that are still being settled
+var TradePendingStatuses = []string{"", TradeStatusPending, TradeStatusOrderPending}
+
+// tradeStatusTransitions lists the statuses a pending trade can move to. Settled, failed, invalid
+// and errored trades can not be updated anymore, so that the amount of a failed trade is given
+// back to its orders only once.
+var tradeStatusTransitions = []string{
+	TradeStatusPending,
+	TradeStatusOrderPending,
+	TradeStatusSuccess,
+	TradeStatusFailed,
+	TradeStatusInvalid,
+	TradeStatusError,
+}
+
+// illegalTradeStatusTransitions counts the rejected trade status transitions. It is published on
+// the /debug/vars endpoint and is mostly caused by the late receipt of a trade transaction that
+// was considered failed.
+var illegalTradeStatusTransitions = expvar.NewInt("illegal_trade_status_transitions")
+
+// IsPending returns true if the trade is still being settled
+func (t *Trade) IsPending() bool {
+	for _, s := range TradePendingStatuses {
+		if t.Status == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanTransitionTradeStatus returns true if a trade can move from the status 'from' to the status
+// 'to'. Updating a pending trade to its current status is always allowed.
+func CanTransitionTradeStatus(from, to string) bool {
+	t := &Trade{Status: from}
+	if !t.IsPending() {
+		return false
+	}
+
+	for _, s := range tradeStatusTransitions {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionTo updates the trade status. An error is returned and the trade is left unchanged if
+// the transition from the current status is not legal.
+func (t *Trade) TransitionTo(status string) error {
+	if !CanTransitionTradeStatus(t.Status, status) {
+		illegalTradeStatusTransitions.Add(1)
+		logger.Warning("Illegal trade status transition", t.Hash.Hex(), t.Status, status)
+		return fmt.Errorf("Trade status can not change from %v to %v", t.Status, status)
+	}
+
+	t.Status = status
+	return nil
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTradeTransitionTo(t *testing.T) {
+	tests := []struct {
+		from  string
+		to    string
+		legal bool
+	}{
+		{"", TradeStatusPending, true},
+		{"", TradeStatusFailed, true},
+		{TradeStatusPending, TradeStatusPending, true},
+		{TradeStatusPending, TradeStatusSuccess, true},
+		{TradeStatusPending, TradeStatusFailed, true},
+		{TradeStatusPending, TradeStatusError, true},
+		{TradeStatusOrderPending, TradeStatusSuccess, true},
+		{TradeStatusOrderPending, TradeStatusFailed, true},
+		{TradeStatusPending, "UNKNOWN", false},
+		{TradeStatusSuccess, TradeStatusFailed, false},
+		{TradeStatusFailed, TradeStatusFailed, false},
+		{TradeStatusFailed, TradeStatusSuccess, false},
+		{TradeStatusError, TradeStatusPending, false},
+		{TradeStatusInvalid, TradeStatusFailed, false},
+	}
+
+	for _, test := range tests {
+		tr := &Trade{Status: test.from}
+		before := illegalTradeStatusTransitions.Value()
+
+		err := tr.TransitionTo(test.to)
+		if test.legal {
+			assert.Nil(t, err, "%v -> %v", test.from, test.to)
+			assert.Equal(t, test.to, tr.Status)
+			assert.Equal(t, before, illegalTradeStatusTransitions.Value())
+		} else {
+			assert.NotNil(t, err, "%v -> %v", test.from, test.to)
+			assert.Equal(t, test.from, tr.Status)
+			assert.Equal(t, before+1, illegalTradeStatusTransitions.Value())
+		}
+	}
+}
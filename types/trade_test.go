@@ -31,6 +31,9 @@ func TestTradeJSON(t *testing.T) {
 		PricePoint: big.NewInt(10000),
 		Side:       "BUY",
 		Amount:     big.NewInt(100),
+
+		MakerFilledBefore: big.NewInt(50),
+		TakerFilledBefore: big.NewInt(0),
 	}
 
 	encoded, err := json.Marshal(expected)
@@ -44,6 +47,9 @@ func TestTradeJSON(t *testing.T) {
 		t.Errorf("Could not unmarshal payload: %v", err)
 	}
 
+	// the ids are not part of the json encoding of the trades
+	expected.ID = ""
+
 	if diff := deep.Equal(expected, trade); diff != nil {
 		t.Errorf("Expected: \n%+v\nGot: \n%+v\n\n", expected, trade)
 	}
@@ -68,8 +74,12 @@ func TestTradeBSON(t *testing.T) {
 		PricePoint: big.NewInt(10000),
 		Side:       "BUY",
 		Amount:     big.NewInt(100),
+		Status:     TradeStatusPending,
 		CreatedAt:  time.Unix(1405544146, 0),
 		UpdatedAt:  time.Unix(1405544146, 0),
+
+		MakerFilledBefore: big.NewInt(50),
+		TakerFilledBefore: big.NewInt(0),
 	}
 
 	data, err := bson.Marshal(expected)
@@ -84,3 +94,245 @@ func TestTradeBSON(t *testing.T) {
 
 	assert.Equal(t, decoded, expected)
 }
+
+func TestUnsignedTradeBSON(t *testing.T) {
+	trade := &Trade{
+		ID:         bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		Maker:      common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Taker:      common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		Hash:       common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		PricePoint: big.NewInt(10000),
+		Amount:     big.NewInt(100),
+	}
+
+	data, err := bson.Marshal(trade)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := bson.M{}
+	if err := bson.Unmarshal(data, raw); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, raw["signature"])
+
+	decoded := &Trade{}
+	if err := bson.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, decoded.Signature)
+	assert.Equal(t, trade.Hash, decoded.Hash)
+	assert.Equal(t, trade.Amount, decoded.Amount)
+}
+
+func TestTradeLargeAmounts(t *testing.T) {
+	large, _ := new(big.Int).SetString("98765432109876543210987654321", 10)
+
+	trade := &Trade{
+		ID:         bson.NewObjectId(),
+		Maker:      common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Taker:      common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BaseToken:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		Hash:       common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		OrderHash:  common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		TradeNonce: large,
+		PricePoint: big.NewInt(10000),
+		Amount:     large,
+	}
+
+	encoded, err := json.Marshal(trade)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "98765432109876543210987654321", raw["amount"])
+	assert.Equal(t, "98765432109876543210987654321", raw["tradeNonce"])
+
+	decoded := &Trade{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, large.String(), decoded.Amount.String())
+	assert.Equal(t, large.String(), decoded.TradeNonce.String())
+
+	// json numbers are accepted on input
+	raw["amount"] = json.Number("18446744073709551617")
+	raw["tradeNonce"] = json.Number("18446744073709551618")
+	encoded, err = json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded = &Trade{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "18446744073709551617", decoded.Amount.String())
+	assert.Equal(t, "18446744073709551618", decoded.TradeNonce.String())
+
+	data, err := bson.Marshal(trade)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromDB := &Trade{}
+	err = bson.Unmarshal(data, fromDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, large.String(), fromDB.Amount.String())
+	assert.Equal(t, large.String(), fromDB.TradeNonce.String())
+}
+
+func newValidTestTrade(taker *Wallet) *Trade {
+	t := &Trade{
+		Maker:      common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Taker:      taker.Address,
+		OrderHash:  common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		PairName:   "ZRX/WETH",
+		TradeNonce: big.NewInt(1),
+		Amount:     big.NewInt(100),
+	}
+
+	t.Sign(taker)
+	return t
+}
+
+func TestTradeValidate(t *testing.T) {
+	taker := NewWallet()
+
+	tr := newValidTestTrade(taker)
+	assert.Nil(t, tr.Validate())
+
+	ok, err := tr.VerifySignature()
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	// stale hash: the trade was modified after being signed
+	tr = newValidTestTrade(taker)
+	tr.Amount = big.NewInt(1000)
+	assert.NotNil(t, tr.Validate())
+
+	ok, err = tr.VerifySignature()
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+
+	// the hash was recomputed after the modification but the signature was not
+	tr.Hash = tr.ComputeHash()
+	err = tr.Validate()
+	assert.Equal(t, ValidationErrors{{"signature", "Signature does not correspond to the taker"}}, err)
+
+	// signed by another account than the taker
+	tr = newValidTestTrade(taker)
+	tr.Sign(NewWallet())
+	assert.NotNil(t, tr.Validate())
+
+	tr = newValidTestTrade(taker)
+	tr.Amount = big.NewInt(0)
+	tr.Sign(taker)
+	assert.Equal(t, ValidationErrors{{"amount", "Amount should be positive"}}, tr.Validate())
+}
+
+func TestTradeHashVersions(t *testing.T) {
+	taker := NewWallet()
+
+	// legacy trades do not commit to the fees
+	legacy := newValidTestTrade(taker)
+	legacy.TakeFee = big.NewInt(1000)
+	assert.Nil(t, legacy.Validate())
+
+	tr := newValidTestTrade(taker)
+	tr.HashVersion = TradeHashV1
+	tr.MakeFee = big.NewInt(10)
+	tr.TakeFee = big.NewInt(20)
+	tr.Sign(taker)
+	assert.Nil(t, tr.Validate())
+	assert.NotEqual(t, legacy.Hash, tr.Hash)
+
+	// the take fee can not be changed once the trade is signed
+	tr.TakeFee = big.NewInt(1000)
+	assert.Equal(t, ValidationErrors{{"hash", "Trade hash does not match trade content"}}, tr.Validate())
+
+	tr.HashVersion = CurrentTradeHashVersion + 1
+	assert.Equal(t, ValidationErrors{{"hashVersion", "Unknown trade hash version"}}, tr.Validate())
+}
+
+func TestTradeHashVersionJSON(t *testing.T) {
+	tr := newValidTestTrade(NewWallet())
+	tr.BaseToken = common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	tr.QuoteToken = common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093")
+	tr.HashVersion = TradeHashV1
+
+	encoded, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Trade{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, TradeHashV1, decoded.HashVersion)
+
+	// trades queued before the hash version was introduced are decoded as legacy trades
+	raw := map[string]interface{}{}
+	json.Unmarshal(encoded, &raw)
+	delete(raw, "hashVersion")
+	encoded, _ = json.Marshal(raw)
+
+	decoded = &Trade{HashVersion: TradeHashV1}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, TradeHashV0, decoded.HashVersion)
+}
+
+func TestTradeValidateFill(t *testing.T) {
+	tr := newValidTestTrade(NewWallet())
+
+	o := &Order{
+		Hash:         tr.OrderHash,
+		Amount:       big.NewInt(150),
+		FilledAmount: big.NewInt(50),
+	}
+
+	assert.Nil(t, tr.ValidateFill(o))
+
+	o.FilledAmount = big.NewInt(51)
+	assert.Equal(t, ValidationErrors{{"amount", "Amount exceeds the remaining fillable amount of the order"}}, tr.ValidateFill(o))
+
+	o.FilledAmount = big.NewInt(0)
+	o.Hash = common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	assert.Equal(t, ValidationErrors{{"orderHash", "Trade does not refer to this order"}}, tr.ValidateFill(o))
+}
+
+func TestTradeClone(t *testing.T) {
+	tr := newValidTestTrade(NewWallet())
+
+	c := tr.Clone()
+	assert.Equal(t, tr, c)
+
+	tr.Amount.Add(tr.Amount, big.NewInt(1))
+	tr.Signature.R = common.Hash{}
+
+	assert.Equal(t, big.NewInt(100), c.Amount)
+	assert.NotEqual(t, common.Hash{}, c.Signature.R)
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldError describes why a field of a payload is invalid
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationErrors is returned when one or more fields of a payload are invalid.
+// It is marshalled to a json array of {field, reason} entries.
+type ValidationErrors []FieldError
+
+// Add appends a field error
+func (errs *ValidationErrors) Add(field, reason string) {
+	*errs = append(*errs, FieldError{Field: field, Reason: reason})
+}
+
+// Sort orders the field errors by field name
+func (errs ValidationErrors) Sort() {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Field < errs[j].Field
+	})
+}
+
+// Error returns all the field errors as a single string
+func (errs ValidationErrors) Error() string {
+	messages := []string{}
+	for _, e := range errs {
+		messages = append(messages, e.Field+": "+e.Reason)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Err returns the validation errors or nil if there are none
+func (errs ValidationErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
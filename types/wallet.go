@@ -4,14 +4,25 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pborman/uuid"
 	"gopkg.in/mgo.v2/bson"
 )
 
-// Wallet holds both the address and the private key of an ethereum account
+// ErrNoPrivateKey is returned when signing with a watch-only wallet
+var ErrNoPrivateKey = errors.New("Wallet private key is not set")
+
+// Wallet holds both the address and the private key of an ethereum account. Watch-only
+// wallets only hold an address and can not sign.
 type Wallet struct {
 	ID         bson.ObjectId
 	Address    common.Address
@@ -31,18 +42,61 @@ func NewWallet() *Wallet {
 	}
 }
 
+// NewWatchOnlyWallet returns a wallet object holding an address without private key
+func NewWatchOnlyWallet(addr common.Address) *Wallet {
+	return &Wallet{Address: addr}
+}
+
 // NewWalletFromPrivateKey returns a new wallet object corresponding
-// to a given private key
-func NewWalletFromPrivateKey(key string) *Wallet {
+// to a given hex encoded private key (with or without 0x prefix)
+func NewWalletFromPrivateKey(key string) (*Wallet, error) {
+	if strings.HasPrefix(key, "0x") || strings.HasPrefix(key, "0X") {
+		key = key[2:]
+	}
+
+	if len(key) != 64 {
+		return nil, fmt.Errorf("Private key should be 64 hex characters long but got %v", len(key))
+	}
+
 	privateKey, err := crypto.HexToECDSA(key)
 	if err != nil {
-		logger.Error(err)
+		return nil, fmt.Errorf("Invalid private key: %v", err)
 	}
 
 	return &Wallet{
 		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
 		PrivateKey: privateKey,
+	}, nil
+}
+
+// NewWalletFromKeystore returns a new wallet object corresponding to the
+// private key encrypted in the given keystore (V3) json file
+func NewWalletFromKeystore(keyjson []byte, passphrase string) (*Wallet, error) {
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decrypt keystore: %v", err)
 	}
+
+	return &Wallet{
+		Address:    key.Address,
+		PrivateKey: key.PrivateKey,
+	}, nil
+}
+
+// ExportKeystore encrypts the wallet private key with the given passphrase
+// and returns it as a keystore (V3) json file
+func (w *Wallet) ExportKeystore(passphrase string) ([]byte, error) {
+	if w.IsWatchOnly() {
+		return nil, ErrNoPrivateKey
+	}
+
+	key := &keystore.Key{
+		Id:         uuid.NewRandom(),
+		Address:    w.Address,
+		PrivateKey: w.PrivateKey,
+	}
+
+	return keystore.EncryptKey(key, passphrase, keystore.StandardScryptN, keystore.StandardScryptP)
 }
 
 // GetAddress returns the wallet address
@@ -50,29 +104,89 @@ func (w *Wallet) GetAddress() string {
 	return w.Address.Hex()
 }
 
-// GetPrivateKey returns the wallet private key
+// GetPrivateKey returns the hex encoded wallet private key or an empty string for watch-only wallets
 func (w *Wallet) GetPrivateKey() string {
+	if w.IsWatchOnly() {
+		return ""
+	}
+
 	return hex.EncodeToString(w.PrivateKey.D.Bytes())
 }
 
+// IsAdmin returns true if the wallet is allowed to manage the tokens and pairs of the exchange
+func (w *Wallet) IsAdmin() bool {
+	return w != nil && w.Admin
+}
+
+// IsOperator returns true if the wallet is allowed to operate the exchange
+func (w *Wallet) IsOperator() bool {
+	return w != nil && w.Operator
+}
+
+// IsWatchOnly returns true if the wallet does not hold a private key
+func (w *Wallet) IsWatchOnly() bool {
+	return w.PrivateKey == nil || w.PrivateKey.D == nil
+}
+
+// Validate checks that the wallet holds a private key corresponding to the wallet address
 func (w *Wallet) Validate() error {
+	if w.IsWatchOnly() {
+		return ErrNoPrivateKey
+	}
+
+	if crypto.PubkeyToAddress(w.PrivateKey.PublicKey) != w.Address {
+		return errors.New("Wallet address does not match private key")
+	}
+
 	return nil
 }
 
+// WalletRecord is the database representation of a wallet. Private keys are encrypted with AES-GCM.
+// Records written before private keys were encrypted hold the key in plain hex in the PrivateKey field.
 type WalletRecord struct {
-	ID         bson.ObjectId `json:"id,omitempty" bson:"_id"`
-	Address    string        `json:"address" bson:"address"`
-	PrivateKey string        `json:"privateKey" bson:"privateKey"`
-	Admin      bool          `json:"admin" bson:"admin"`
-	Operator   bool          `json:"operator" bson:"operator"`
+	ID                  bson.ObjectId `json:"id,omitempty" bson:"_id"`
+	Address             string        `json:"address" bson:"address"`
+	PrivateKey          string        `json:"privateKey,omitempty" bson:"privateKey,omitempty"`
+	EncryptedPrivateKey string        `json:"encryptedPrivateKey,omitempty" bson:"encryptedPrivateKey,omitempty"`
+	Nonce               string        `json:"nonce,omitempty" bson:"nonce,omitempty"`
+	KeyVersion          int           `json:"keyVersion,omitempty" bson:"keyVersion,omitempty"`
+	Admin               bool          `json:"admin" bson:"admin"`
+	Operator            bool          `json:"operator" bson:"operator"`
 }
 
 func (w *Wallet) GetBSON() (interface{}, error) {
+	if w.IsWatchOnly() {
+		if (w.Address == common.Address{}) {
+			return nil, errors.New("Wallet address is not set")
+		}
+
+		return WalletRecord{
+			ID:       w.ID,
+			Address:  w.Address.Hex(),
+			Admin:    w.Admin,
+			Operator: w.Operator,
+		}, nil
+	}
+
+	err := w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, nonce, version, err := encryptPrivateKey(w.PrivateKey, w.Address)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
 	return WalletRecord{
-		ID:         w.ID,
-		Address:    w.Address.Hex(),
-		PrivateKey: hex.EncodeToString(w.PrivateKey.D.Bytes()),
-		Admin:      w.Admin,
+		ID:                  w.ID,
+		Address:             w.Address.Hex(),
+		EncryptedPrivateKey: ciphertext,
+		Nonce:               nonce,
+		KeyVersion:          version,
+		Admin:               w.Admin,
+		Operator:            w.Operator,
 	}, nil
 }
 
@@ -86,7 +200,16 @@ func (w *Wallet) SetBSON(raw bson.Raw) error {
 
 	w.ID = decoded.ID
 	w.Address = common.HexToAddress(decoded.Address)
-	w.PrivateKey, err = crypto.HexToECDSA(decoded.PrivateKey)
+
+	// records of watch-only wallets do not hold any key
+	if decoded.EncryptedPrivateKey != "" {
+		w.PrivateKey, err = decryptPrivateKey(decoded.EncryptedPrivateKey, decoded.Nonce, decoded.KeyVersion, w.Address)
+	} else if decoded.PrivateKey != "" {
+		w.PrivateKey, err = crypto.HexToECDSA(decoded.PrivateKey)
+	} else {
+		w.PrivateKey = nil
+	}
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -97,26 +220,29 @@ func (w *Wallet) SetBSON(raw bson.Raw) error {
 	return nil
 }
 
-// SignHash signs a hashed message with a wallet private key
-// and returns it as a Signature object
-func (w *Wallet) SignHash(h common.Hash) (*Signature, error) {
-	message := crypto.Keccak256(
-		[]byte("\x19Ethereum Signed Message:\n32"),
-		h.Bytes(),
-	)
+// SignerAddress returns the wallet address
+func (w *Wallet) SignerAddress() common.Address {
+	return w.Address
+}
 
-	sigBytes, err := crypto.Sign(message, w.PrivateKey)
-	if err != nil {
-		return &Signature{}, err
+// Sign signs a raw hash (without the "Ethereum Signed Message" prefix) with
+// the wallet private key
+func (w *Wallet) Sign(h common.Hash) (*Signature, error) {
+	if w.IsWatchOnly() {
+		return nil, ErrNoPrivateKey
 	}
 
-	sig := &Signature{
-		R: common.BytesToHash(sigBytes[0:32]),
-		S: common.BytesToHash(sigBytes[32:64]),
-		V: sigBytes[64] + 27,
+	return Sign(h, w.PrivateKey)
+}
+
+// SignHash signs a hashed message with a wallet private key
+// and returns it as a Signature object
+func (w *Wallet) SignHash(h common.Hash) (*Signature, error) {
+	if w.IsWatchOnly() {
+		return nil, ErrNoPrivateKey
 	}
 
-	return sig, nil
+	return SignHash(h, w.PrivateKey)
 }
 
 // SignTrade signs and sets the signature of a trade with a wallet private key
@@ -145,8 +271,190 @@ func (w *Wallet) SignOrder(o *Order) error {
 	return nil
 }
 
+// SignOrders signs and sets the signature of each order. Orders are hashed and signed concurrently
+// by GOMAXPROCS workers. Signing stops at the first error, which is returned as a SignBatchError
+// holding the index of the order that could not be signed. Orders signed before the error keep
+// their signature.
+func (w *Wallet) SignOrders(orders []*Order) error {
+	return signBatch(len(orders), func(i int) error {
+		return w.SignOrder(orders[i])
+	})
+}
+
+// SignTrades signs and sets the signature of each trade. Trades are signed the same way as
+// orders in SignOrders.
+func (w *Wallet) SignTrades(trades []*Trade) error {
+	return signBatch(len(trades), func(i int) error {
+		return w.SignTrade(trades[i])
+	})
+}
+
+// SignBatchError is returned when an item of a batch could not be signed
+type SignBatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *SignBatchError) Error() string {
+	return fmt.Sprintf("Could not sign item %v: %v", e.Index, e.Err)
+}
+
+// signBatch calls sign for each index in [0, n) from a pool of GOMAXPROCS workers. Workers stop
+// picking new indexes as soon as one call fails. If several calls fail, the error with the
+// lowest index is returned.
+func signBatch(n int, sign func(i int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+
+	var next int64 = -1
+	var failed int32
+	errs := make([]error, n)
+
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for k := 0; k < workers; k++ {
+		go func() {
+			defer wg.Done()
+
+			for atomic.LoadInt32(&failed) == 0 {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+
+				err := sign(i)
+				if err != nil {
+					errs[i] = err
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return &SignBatchError{Index: i, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// SignOrderCancel signs and sets the signature of an order cancel with a wallet private key
+func (w *Wallet) SignOrderCancel(oc *OrderCancel) error {
+	hash := oc.ComputeHash()
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		return err
+	}
+
+	oc.Hash = hash
+	oc.Signature = sig
+	return nil
+}
+
+// SignCancelAll signs and sets the signature of a cancel all orders message. The maker of the
+// message is set to the wallet address.
+func (w *Wallet) SignCancelAll(ca *CancelAllOrders) error {
+	ca.Maker = w.Address
+	hash := ca.ComputeHash()
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		return err
+	}
+
+	ca.Hash = hash
+	ca.Signature = sig
+	return nil
+}
+
+// SignSessionAuth signs and sets the signature of a session authentication message. The address
+// of the message is set to the wallet address.
+func (w *Wallet) SignSessionAuth(sa *SessionAuth) error {
+	sa.Address = w.Address
+	hash := sa.ComputeHash()
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		return err
+	}
+
+	sa.Hash = hash
+	sa.Signature = sig
+	return nil
+}
+
+// SignOrderReplace signs the replacement order of an order replace message, then signs and sets
+// the signature of the message with a wallet private key
+func (w *Wallet) SignOrderReplace(or *OrderReplace) error {
+	err := w.SignOrder(or.Order)
+	if err != nil {
+		return err
+	}
+
+	hash := or.ComputeHash()
+	sig, err := w.SignHash(hash)
+	if err != nil {
+		return err
+	}
+
+	or.Hash = hash
+	or.Signature = sig
+	return nil
+}
+
+// SignOrderEIP712 signs and sets the signature of an order with the EIP712 typed data
+// scheme. The order hash is still computed with the default order hashing function.
+func (w *Wallet) SignOrderEIP712(o *Order, domain TypedDataDomain) error {
+	sig, err := w.Sign(o.ComputeEIP712Hash(domain))
+	if err != nil {
+		return err
+	}
+
+	o.Hash = o.ComputeHash()
+	o.Signature = sig
+	o.SignatureScheme = EIP712SignScheme
+	return nil
+}
+
+// String returns a representation of the wallet in which the private key is redacted, so
+// that wallets can safely be formatted in logs
+func (w Wallet) String() string {
+	return fmt.Sprintf("Wallet{ID: %v, Address: %v, Admin: %v, Operator: %v, PrivateKey: ***}",
+		w.ID.Hex(), w.Address.Hex(), w.Admin, w.Operator)
+}
+
+// Print prints the wallet with its private key redacted
 func (w *Wallet) Print() {
-	b, err := json.MarshalIndent(w, "", "  ")
+	b, err := json.MarshalIndent(map[string]interface{}{
+		"id":         w.ID.Hex(),
+		"address":    w.Address.Hex(),
+		"admin":      w.Admin,
+		"operator":   w.Operator,
+		"privateKey": "***",
+	}, "", "  ")
+
+	if err != nil {
+		fmt.Println("Error: ", err)
+	}
+
+	fmt.Print(string(b))
+}
+
+// PrintWithSecrets prints the wallet including its hex encoded private key.
+// It should only be used for debugging, never in code paths that write to logs.
+func (w *Wallet) PrintWithSecrets() {
+	b, err := json.MarshalIndent(map[string]interface{}{
+		"id":         w.ID.Hex(),
+		"address":    w.Address.Hex(),
+		"admin":      w.Admin,
+		"operator":   w.Operator,
+		"privateKey": w.GetPrivateKey(),
+	}, "", "  ")
+
 	if err != nil {
 		fmt.Println("Error: ", err)
 	}
@@ -0,0 +1,226 @@
+package types
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Environment variables holding the hex encoded 32 bytes AES key used to encrypt wallet private
+// keys before they are persisted and the version of this key (defaults to 1). The keys of previous
+// versions, needed to decrypt the records not migrated yet, are set as WALLET_ENCRYPTION_KEY_V<n>
+// where n is the version of the key.
+const (
+	WalletEncryptionKeyEnv         = "WALLET_ENCRYPTION_KEY"
+	WalletEncryptionKeyVersionEnv  = "WALLET_ENCRYPTION_KEY_VERSION"
+	WalletEncryptionKeyPreviousEnv = "WALLET_ENCRYPTION_KEY_V"
+)
+
+var walletKeys = &walletKeyring{keys: make(map[int][]byte)}
+
+// walletKeyring holds the wallet encryption keys indexed by version. New records are always
+// encrypted with the current key, older keys are only kept to decrypt existing records.
+type walletKeyring struct {
+	sync.RWMutex
+	keys    map[int][]byte
+	current int
+}
+
+// SetWalletEncryptionKey registers a 32 bytes AES key used to encrypt and decrypt wallet private keys
+// and makes it the current encryption key. Keys previously registered with a different version can still
+// be used to decrypt records that were encrypted with them.
+func SetWalletEncryptionKey(version int, key []byte) error {
+	err := addWalletEncryptionKey(version, key)
+	if err != nil {
+		return err
+	}
+
+	walletKeys.Lock()
+	defer walletKeys.Unlock()
+
+	walletKeys.current = version
+	return nil
+}
+
+// addWalletEncryptionKey registers a 32 bytes AES key used to decrypt the wallet private keys
+// encrypted with the given version, without changing the current encryption key
+func addWalletEncryptionKey(version int, key []byte) error {
+	if version <= 0 {
+		return errors.New("Wallet encryption key version should be positive")
+	}
+
+	if len(key) != 32 {
+		return errors.New("Wallet encryption key should be 32 bytes long")
+	}
+
+	walletKeys.Lock()
+	defer walletKeys.Unlock()
+
+	walletKeys.keys[version] = key
+	return nil
+}
+
+// CurrentWalletEncryptionKeyVersion returns the version of the key currently used to encrypt wallet
+// private keys
+func CurrentWalletEncryptionKeyVersion() (int, error) {
+	_, version, err := getWalletEncryptionKey(0)
+	return version, err
+}
+
+// getWalletEncryptionKey returns the key of the given version (or the current key if version is 0).
+// If no key was registered, the key is loaded from the environment.
+func getWalletEncryptionKey(version int) ([]byte, int, error) {
+	walletKeys.RLock()
+	empty := len(walletKeys.keys) == 0
+	walletKeys.RUnlock()
+
+	if empty {
+		err := LoadWalletEncryptionKeys()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	walletKeys.RLock()
+	defer walletKeys.RUnlock()
+
+	if version == 0 {
+		version = walletKeys.current
+	}
+
+	key, ok := walletKeys.keys[version]
+	if !ok {
+		return nil, 0, fmt.Errorf("Wallet encryption key version %v is not available", version)
+	}
+
+	return key, version, nil
+}
+
+// LoadWalletEncryptionKeys loads the current wallet encryption key and the keys of the previous
+// versions from the environment. An error is returned if the current key is not set, so that a
+// server missing it fails on startup instead of on the first wallet it stores.
+func LoadWalletEncryptionKeys() error {
+	encoded := os.Getenv(WalletEncryptionKeyEnv)
+	if encoded == "" {
+		return fmt.Errorf("Wallet encryption key is not set: %v must hold the hex encoded 32 bytes key used to encrypt the wallet private keys", WalletEncryptionKeyEnv)
+	}
+
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("Invalid wallet encryption key (%v): %v", WalletEncryptionKeyEnv, err)
+	}
+
+	version := 1
+	if v := os.Getenv(WalletEncryptionKeyVersionEnv); v != "" {
+		version, err = strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			return fmt.Errorf("Invalid wallet encryption key version: %v", v)
+		}
+	}
+
+	for _, env := range os.Environ() {
+		kv := strings.SplitN(env, "=", 2)
+		if !strings.HasPrefix(kv[0], WalletEncryptionKeyPreviousEnv) || kv[0] == WalletEncryptionKeyVersionEnv {
+			continue
+		}
+
+		v, err := strconv.Atoi(strings.TrimPrefix(kv[0], WalletEncryptionKeyPreviousEnv))
+		if err != nil || v <= 0 {
+			return fmt.Errorf("Invalid wallet encryption key version: %v", kv[0])
+		}
+
+		previous, err := hex.DecodeString(kv[1])
+		if err != nil {
+			return fmt.Errorf("Invalid wallet encryption key (%v): %v", kv[0], err)
+		}
+
+		if v == version && string(previous) != string(key) {
+			return fmt.Errorf("%v differs from the current wallet encryption key of the same version", kv[0])
+		}
+
+		err = addWalletEncryptionKey(v, previous)
+		if err != nil {
+			return fmt.Errorf("%v: %v", kv[0], err)
+		}
+	}
+
+	return SetWalletEncryptionKey(version, key)
+}
+
+// encryptPrivateKey encrypts a private key with AES-GCM using the current wallet encryption key.
+// The wallet address is used as additional data so that an encrypted key can not be moved to another record.
+func encryptPrivateKey(privateKey *ecdsa.PrivateKey, address common.Address) (string, string, int, error) {
+	key, version, err := getWalletEncryptionKey(0)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	gcm, err := newWalletCipher(key)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, crypto.FromECDSA(privateKey), address.Bytes())
+	return hex.EncodeToString(ciphertext), hex.EncodeToString(nonce), version, nil
+}
+
+// decryptPrivateKey decrypts a private key encrypted by encryptPrivateKey
+func decryptPrivateKey(ciphertext, nonce string, version int, address common.Address) (*ecdsa.PrivateKey, error) {
+	key, _, err := getWalletEncryptionKey(version)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newWalletCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := hex.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := hex.DecodeString(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(n) != gcm.NonceSize() {
+		return nil, errors.New("Invalid wallet private key nonce")
+	}
+
+	plaintext, err := gcm.Open(nil, n, c, address.Bytes())
+	if err != nil {
+		return nil, errors.New("Could not decrypt wallet private key")
+	}
+
+	return crypto.ToECDSA(plaintext)
+}
+
+func newWalletCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
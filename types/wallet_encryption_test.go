@@ -0,0 +1,190 @@
+package types
+
+import (
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var testWalletEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func encodeTestWallet(t *testing.T) (*Wallet, WalletRecord) {
+	w, err := NewWalletFromPrivateKey("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.ID = bson.NewObjectId()
+	w.Operator = true
+
+	err = SetWalletEncryptionKey(1, testWalletEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := bson.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := WalletRecord{}
+	err = bson.Unmarshal(data, &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w, record
+}
+
+func TestWalletBSONEncryption(t *testing.T) {
+	w, record := encodeTestWallet(t)
+
+	assert.Equal(t, "", record.PrivateKey)
+	assert.NotEqual(t, "", record.EncryptedPrivateKey)
+	assert.NotEqual(t, "", record.Nonce)
+	assert.Equal(t, 1, record.KeyVersion)
+
+	data, _ := bson.Marshal(record)
+	decoded := &Wallet{}
+	err := bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w, decoded)
+}
+
+func TestWalletBSONKeyRotation(t *testing.T) {
+	w, record := encodeTestWallet(t)
+
+	err := SetWalletEncryptionKey(2, []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer SetWalletEncryptionKey(1, testWalletEncryptionKey)
+
+	// records encrypted with a previous key can still be decoded
+	data, _ := bson.Marshal(record)
+	decoded := &Wallet{}
+	err = bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w.PrivateKey, decoded.PrivateKey)
+
+	version, err := CurrentWalletEncryptionKeyVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, version)
+}
+
+func TestWalletBSONWrongKey(t *testing.T) {
+	_, record := encodeTestWallet(t)
+
+	err := SetWalletEncryptionKey(1, []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer SetWalletEncryptionKey(1, testWalletEncryptionKey)
+
+	data, _ := bson.Marshal(record)
+	err = bson.Unmarshal(data, &Wallet{})
+	assert.Error(t, err)
+
+	// unknown key version
+	record.KeyVersion = 5
+	data, _ = bson.Marshal(record)
+	err = bson.Unmarshal(data, &Wallet{})
+	assert.Error(t, err)
+}
+
+func TestWalletBSONTruncatedCiphertext(t *testing.T) {
+	_, record := encodeTestWallet(t)
+
+	truncated := record
+	truncated.EncryptedPrivateKey = record.EncryptedPrivateKey[:len(record.EncryptedPrivateKey)-2]
+	data, _ := bson.Marshal(truncated)
+	err := bson.Unmarshal(data, &Wallet{})
+	assert.Error(t, err)
+
+	truncated = record
+	truncated.Nonce = record.Nonce[:len(record.Nonce)-2]
+	data, _ = bson.Marshal(truncated)
+	err = bson.Unmarshal(data, &Wallet{})
+	assert.Error(t, err)
+
+	// the ciphertext is bound to the wallet address
+	moved := record
+	moved.Address = NewWallet().Address.Hex()
+	data, _ = bson.Marshal(moved)
+	err = bson.Unmarshal(data, &Wallet{})
+	assert.Error(t, err)
+}
+
+func TestWalletBSONLegacyPlaintext(t *testing.T) {
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
+	legacy := bson.M{
+		"_id":        bson.NewObjectId(),
+		"address":    "0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47",
+		"privateKey": key,
+		"admin":      true,
+		"operator":   false,
+	}
+
+	data, _ := bson.Marshal(legacy)
+	decoded := &Wallet{}
+	err := bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47", decoded.Address.Hex())
+	assert.Equal(t, key, hex.EncodeToString(decoded.PrivateKey.D.Bytes()))
+	assert.True(t, decoded.Admin)
+}
+
+func TestLoadWalletEncryptionKeys(t *testing.T) {
+	_, record := encodeTestWallet(t)
+
+	keys := walletKeys
+	defer func() { walletKeys = keys }()
+
+	env := map[string]string{
+		WalletEncryptionKeyEnv:               hex.EncodeToString([]byte("fedcba9876543210fedcba9876543210")),
+		WalletEncryptionKeyVersionEnv:        "2",
+		WalletEncryptionKeyPreviousEnv + "1": hex.EncodeToString(testWalletEncryptionKey),
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	// records encrypted with a previous key can be decoded with the keys of the environment
+	walletKeys = &walletKeyring{keys: make(map[int][]byte)}
+	data, _ := bson.Marshal(record)
+	err := bson.Unmarshal(data, &Wallet{})
+	assert.Nil(t, err)
+
+	version, err := CurrentWalletEncryptionKeyVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, version)
+
+	// a previous key of the current version must be the current key
+	os.Setenv(WalletEncryptionKeyPreviousEnv+"2", hex.EncodeToString(testWalletEncryptionKey))
+	walletKeys = &walletKeyring{keys: make(map[int][]byte)}
+	assert.Error(t, LoadWalletEncryptionKeys())
+	os.Unsetenv(WalletEncryptionKeyPreviousEnv + "2")
+
+	// the current key is required
+	os.Unsetenv(WalletEncryptionKeyEnv)
+	walletKeys = &walletKeyring{keys: make(map[int][]byte)}
+	err = LoadWalletEncryptionKeys()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), WalletEncryptionKeyEnv)
+}
@@ -2,9 +2,12 @@ package types
 
 import (
 	"encoding/hex"
+	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -30,16 +33,83 @@ func TestNewWallet(t *testing.T) {
 func TestNewWalletFromPrivateKey(t *testing.T) {
 	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
 
-	wallet := NewWalletFromPrivateKey(key)
+	wallet, err := NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	if address := wallet.GetAddress(); address != "0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47" {
 		t.Error("Expected address to equal 0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47 but got: ", address)
 	}
+
+	prefixed, err := NewWalletFromPrivateKey("0x" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, wallet.Address, prefixed.Address)
+	assert.Nil(t, prefixed.Validate())
+
+	invalidKeys := []string{
+		"",
+		"0x",
+		key[:62],
+		key + "00",
+		"zz78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	for _, k := range invalidKeys {
+		w, err := NewWalletFromPrivateKey(k)
+		assert.Error(t, err, "Expected an error for key %q", k)
+		assert.Nil(t, w)
+	}
+}
+
+func TestWalletValidate(t *testing.T) {
+	w := NewWallet()
+	assert.Nil(t, w.Validate())
+
+	w.Address = NewWallet().Address
+	assert.Error(t, w.Validate())
+
+	assert.Error(t, (&Wallet{Address: w.Address}).Validate())
+}
+
+func TestWalletStringRedactsPrivateKey(t *testing.T) {
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
+	w, err := NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.ID = bson.NewObjectId()
+	w.Operator = true
+
+	outputs := []string{
+		w.String(),
+		fmt.Sprintf("%v", w),
+		fmt.Sprintf("%+v", *w),
+		fmt.Sprintf("%s", []Wallet{*w}),
+	}
+
+	for _, out := range outputs {
+		assert.NotContains(t, out, key)
+		assert.NotContains(t, out, w.PrivateKey.D.String())
+		assert.Contains(t, out, w.Address.Hex())
+		assert.Contains(t, out, "***")
+	}
 }
 
 func TestBSON(t *testing.T) {
 	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
-	w := NewWalletFromPrivateKey(key)
+	w, err := NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	w.ID = bson.NewObjectId()
+	SetWalletEncryptionKey(1, testWalletEncryptionKey)
 
 	data, err := bson.Marshal(w)
 	if err != nil {
@@ -70,3 +140,213 @@ func TestBSON(t *testing.T) {
 		"Private key should be encoded and decoded correctly",
 	)
 }
+
+func TestBSONRoles(t *testing.T) {
+	SetWalletEncryptionKey(1, testWalletEncryptionKey)
+
+	w := NewWallet()
+	w.ID = bson.NewObjectId()
+	w.Operator = true
+
+	watchOnly := NewWatchOnlyWallet(NewWallet().Address)
+	watchOnly.ID = bson.NewObjectId()
+	watchOnly.Admin = true
+
+	for _, wallet := range []*Wallet{w, watchOnly} {
+		data, err := bson.Marshal(wallet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded := &Wallet{}
+		err = bson.Unmarshal(data, decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, wallet.Admin, decoded.IsAdmin())
+		assert.Equal(t, wallet.Operator, decoded.IsOperator())
+	}
+}
+
+func TestWatchOnlyWallet(t *testing.T) {
+	address := common.HexToAddress("0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47")
+	w := NewWatchOnlyWallet(address)
+
+	assert.True(t, w.IsWatchOnly())
+	assert.Equal(t, "", w.GetPrivateKey())
+	assert.Equal(t, ErrNoPrivateKey, w.Validate())
+
+	_, err := w.SignHash(common.HexToHash("0x01"))
+	assert.Equal(t, ErrNoPrivateKey, err)
+
+	_, err = w.Sign(common.HexToHash("0x01"))
+	assert.Equal(t, ErrNoPrivateKey, err)
+
+	assert.Equal(t, ErrNoPrivateKey, w.SignOrder(newValidTestOrder()))
+	assert.Equal(t, ErrNoPrivateKey, w.SignTrade(&Trade{Amount: big.NewInt(1), TradeNonce: big.NewInt(0)}))
+
+	_, err = w.ExportKeystore("passphrase")
+	assert.Equal(t, ErrNoPrivateKey, err)
+}
+
+func TestWatchOnlyWalletBSON(t *testing.T) {
+	w := NewWatchOnlyWallet(common.HexToAddress("0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47"))
+	w.ID = bson.NewObjectId()
+	w.Admin = true
+
+	data, err := bson.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Wallet{}
+	err = bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w, decoded)
+	assert.True(t, decoded.IsWatchOnly())
+}
+
+func TestWalletKeystore(t *testing.T) {
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
+	w, err := NewWalletFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyjson, err := w.ExportKeystore("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := NewWalletFromKeystore(keyjson, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w.Address, decoded.Address)
+
+	hash := common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	sig1, err := w.SignHash(hash)
+	if err != nil {
+		t.Error(err)
+	}
+
+	sig2, err := decoded.SignHash(hash)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, sig1, sig2)
+
+	_, err = NewWalletFromKeystore(keyjson, "wrong passphrase")
+	assert.NotNil(t, err)
+
+	_, err = NewWalletFromKeystore([]byte("{not json"), "passphrase")
+	assert.NotNil(t, err)
+}
+
+func newTestOrders(n int) []*Order {
+	orders := make([]*Order, n)
+	for i := range orders {
+		orders[i] = newValidTestOrder()
+		orders[i].Nonce = big.NewInt(int64(i))
+	}
+
+	return orders
+}
+
+func TestWalletSignOrders(t *testing.T) {
+	w := NewWallet()
+	orders := newTestOrders(100)
+
+	err := w.SignOrders(orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, o := range orders {
+		assert.Equal(t, o.ComputeHash(), o.Hash, "order %v", i)
+
+		assert.Nil(t, o.Signature.Verify(o.Hash, w.Address), "order %v", i)
+	}
+}
+
+func TestWalletSignTrades(t *testing.T) {
+	w := NewWallet()
+	trades := []*Trade{}
+	for i := 0; i < 100; i++ {
+		trades = append(trades, &Trade{Amount: big.NewInt(int64(i + 1)), TradeNonce: big.NewInt(int64(i))})
+	}
+
+	err := w.SignTrades(trades)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tr := range trades {
+		assert.Equal(t, tr.ComputeHash(), tr.Hash, "trade %v", i)
+		assert.NotNil(t, tr.Signature, "trade %v", i)
+	}
+}
+
+func TestWalletSignOrdersWatchOnly(t *testing.T) {
+	w := NewWatchOnlyWallet(common.HexToAddress("0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47"))
+
+	err := w.SignOrders(newTestOrders(10))
+	assert.Equal(t, &SignBatchError{Index: 0, Err: ErrNoPrivateKey}, err)
+}
+
+func TestSignBatchFailFast(t *testing.T) {
+	failure := fmt.Errorf("failure")
+	signed := make([]bool, 1000)
+
+	err := signBatch(len(signed), func(i int) error {
+		if i == 500 {
+			return failure
+		}
+
+		signed[i] = true
+		return nil
+	})
+
+	assert.Equal(t, &SignBatchError{Index: 500, Err: failure}, err)
+
+	// items are picked in order so every item before the failing one has been signed
+	for i := 0; i < 500; i++ {
+		assert.True(t, signed[i], "item %v", i)
+	}
+
+	assert.Nil(t, signBatch(0, func(i int) error { return failure }))
+}
+
+func BenchmarkSignOrdersSequential(b *testing.B) {
+	w := NewWallet()
+	orders := newTestOrders(1000)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, o := range orders {
+			err := w.SignOrder(o)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSignOrdersBatch(b *testing.B) {
+	w := NewWallet()
+	orders := newTestOrders(1000)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		err := w.SignOrders(orders)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
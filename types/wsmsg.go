@@ -15,6 +15,7 @@ const (
 	SUBSCRIBE   SubscriptionEvent = "subscribe"
 	UNSUBSCRIBE SubscriptionEvent = "unsubscribe"
 	Fetch       SubscriptionEvent = "fetch"
+	REPLAY      SubscriptionEvent = "replay"
 )
 
 const TradeChannel = "trades"
@@ -46,6 +47,14 @@ type Params struct {
 	Duration int64  `json:"duration"`
 	Units    string `json:"units"`
 	TickID   string `json:"tickID"`
+
+	// Sequence is the sequence of the last orderbook diff received by the client, the diffs
+	// following it are sent back by the replay event
+	Sequence uint64 `json:"sequence"`
+
+	// GroupingDecimals aggregates the price levels of an orderbook subscription to the given
+	// number of price decimals
+	GroupingDecimals *int `json:"groupingDecimals"`
 }
 
 type SignaturePayload struct {
@@ -53,6 +62,78 @@ type SignaturePayload struct {
 	Matches []*OrderTradePair `json:"matches"`
 }
 
+// UnfilledCancelPayload is sent to the maker of an IOC or FOK order when the engine cancels the
+// unfilled amount of the order. It states how much of the order was filled and why the rest was
+// cancelled.
+type UnfilledCancelPayload struct {
+	Order           *OrderSpec
+	FilledAmount    *big.Int
+	CancelledAmount *big.Int
+	Reason          string
+}
+
+// NewUnfilledCancelPayload returns the payload describing the amount of the order of an engine
+// response cancelled by the engine
+func NewUnfilledCancelPayload(res *EngineResponse) *UnfilledCancelPayload {
+	return &UnfilledCancelPayload{
+		Order:           res.Order.ToPrivateAPI(),
+		FilledAmount:    res.Order.FilledAmount,
+		CancelledAmount: res.CancelledAmount,
+		Reason:          res.CancelReason,
+	}
+}
+
+// MarshalJSON returns the json encoded payload. Amounts are encoded as decimal strings.
+func (p *UnfilledCancelPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"order":           p.Order,
+		"filledAmount":    encodeBigInt(p.FilledAmount),
+		"cancelledAmount": encodeBigInt(p.CancelledAmount),
+		"reason":          p.Reason,
+	})
+}
+
+// FillReportPayload is sent to the maker of a taker order once the trades of the order against
+// the orders of the orderbook have been sent to the operator. It reports all the trades of the
+// order at once, in the order in which they were matched.
+type FillReportPayload struct {
+	Order           *OrderSpec
+	Trades          []*Trade
+	FilledAmount    *big.Int
+	RemainingAmount *big.Int
+}
+
+// NewFillReportPayload returns the fill report of a taker order and of its matches
+func NewFillReportPayload(o *Order, matches []*OrderTradePair) *FillReportPayload {
+	p := &FillReportPayload{
+		Order:        o.ToPrivateAPI(),
+		Trades:       []*Trade{},
+		FilledAmount: big.NewInt(0),
+	}
+
+	for _, m := range matches {
+		p.Trades = append(p.Trades, m.Trade)
+		p.FilledAmount = new(big.Int).Add(p.FilledAmount, m.Trade.Amount)
+	}
+
+	p.RemainingAmount = new(big.Int).Set(o.Amount)
+	if o.FilledAmount != nil {
+		p.RemainingAmount.Sub(p.RemainingAmount, o.FilledAmount)
+	}
+
+	return p
+}
+
+// MarshalJSON returns the json encoded payload. Amounts are encoded as decimal strings.
+func (p *FillReportPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"order":           p.Order,
+		"trades":          p.Trades,
+		"filledAmount":    encodeBigInt(p.FilledAmount),
+		"remainingAmount": encodeBigInt(p.RemainingAmount),
+	})
+}
+
 func NewOrderWebsocketMessage(o *Order) *WebSocketMessage {
 	return &WebSocketMessage{
 		Channel: "orders",
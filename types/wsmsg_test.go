@@ -6,7 +6,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-test/deep"
 	"github.com/stretchr/testify/assert"
@@ -89,8 +88,11 @@ func TestWebSocketMessageJSON(t *testing.T) {
 		t.Errorf("Error decoding order; %v", err)
 	}
 
-	testutils.Compare(t, msg, decoded)
-	testutils.CompareStructs(t, order, decodedOrder)
+	// the ids and update times of the orders are not part of their json encoding
+	Compare(t, msg, decoded)
+	order.ID = ""
+	order.UpdatedAt = time.Time{}
+	CompareStructs(t, order, decodedOrder)
 }
 
 func TestOrderCancelWebSocketMessageJSON(t *testing.T) {
@@ -170,8 +172,8 @@ func TestNewOrderCancelWebSocketMessage(t *testing.T) {
 		},
 	}
 
-	testutils.Compare(t, expected, msg)
-	testutils.CompareStructs(t, expected, msg)
+	Compare(t, expected, msg)
+	CompareStructs(t, expected, msg)
 }
 
 func TestNewWebsocketMessage(t *testing.T) {
@@ -216,6 +218,42 @@ func TestNewWebsocketMessage(t *testing.T) {
 		},
 	}
 
-	testutils.Compare(t, expected, msg)
-	testutils.CompareStructs(t, expected, msg)
+	Compare(t, expected, msg)
+	CompareStructs(t, expected, msg)
+}
+
+func TestFillReportPayload(t *testing.T) {
+	o := &Order{
+		Hash:         common.HexToHash("0x1"),
+		Amount:       big.NewInt(1000),
+		FilledAmount: big.NewInt(300),
+		Status:       "PARTIAL_FILLED",
+	}
+
+	matches := []*OrderTradePair{
+		{&Order{Hash: common.HexToHash("0x2")}, &Trade{OrderHash: common.HexToHash("0x2"), Amount: big.NewInt(100)}},
+		{&Order{Hash: common.HexToHash("0x3")}, &Trade{OrderHash: common.HexToHash("0x3"), Amount: big.NewInt(200)}},
+	}
+
+	p := NewFillReportPayload(o, matches)
+	assert.Equal(t, big.NewInt(300), p.FilledAmount)
+	assert.Equal(t, big.NewInt(700), p.RemainingAmount)
+	assert.Equal(t, 2, len(p.Trades))
+	assert.Equal(t, common.HexToHash("0x2"), p.Trades[0].OrderHash)
+	assert.Equal(t, common.HexToHash("0x3"), p.Trades[1].OrderHash)
+
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "300", decoded["filledAmount"])
+	assert.Equal(t, "700", decoded["remainingAmount"])
+	assert.Equal(t, 2, len(decoded["trades"].([]interface{})))
 }
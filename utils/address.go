@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ParseAddress parses a hex encoded address. All-lowercase and all-uppercase addresses
+// are accepted while mixed-case addresses must carry a valid EIP-55 checksum, so that a
+// typo in a checksummed address is rejected instead of silently referring to another account.
+func ParseAddress(s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("Invalid address: %v", s)
+	}
+
+	hex := s
+	if strings.HasPrefix(hex, "0x") || strings.HasPrefix(hex, "0X") {
+		hex = hex[2:]
+	}
+
+	a := common.HexToAddress(hex)
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+		return a, nil
+	}
+
+	if a.Hex()[2:] != hex {
+		return common.Address{}, fmt.Errorf("Invalid address checksum: %v", s)
+	}
+
+	return a, nil
+}
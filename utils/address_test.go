@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	// EIP-55 test vectors
+	valid := []string{
+		// all caps
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		// all lower
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+		// normal
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+
+	for _, s := range valid {
+		a, err := ParseAddress(s)
+		if err != nil {
+			t.Errorf("Expected %v to be valid but got: %v", s, err)
+			continue
+		}
+
+		assert.True(t, strings.EqualFold(s, a.Hex()), s)
+	}
+
+	// addresses are always emitted in their checksummed form
+	a, err := ParseAddress("0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359")
+	assert.Nil(t, err)
+	assert.Equal(t, "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", a.Hex())
+
+	a, err = ParseAddress("5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	assert.Nil(t, err)
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", a.Hex())
+
+	invalid := []string{
+		// checksums with a single flipped letter case
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+		"0xfb6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6Fb",
+		"0xd1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+		// not addresses
+		"",
+		"0x",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAedaa",
+		"0xZZAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	}
+
+	for _, s := range invalid {
+		_, err := ParseAddress(s)
+		assert.NotNil(t, err, s)
+	}
+}
@@ -38,10 +38,20 @@ func GetOHLCVChannelID(bt, qt common.Address, unit string, duration int64) strin
 	return fmt.Sprintf("%s::%d::%s", pair, duration, unit)
 }
 
+func GetTickerChannelID(bt, qt common.Address) string {
+	return strings.ToLower(fmt.Sprintf("%s::%s", bt.Hex(), qt.Hex()))
+}
+
 func GetOrderBookChannelID(bt, qt common.Address) string {
 	return strings.ToLower(fmt.Sprintf("%s::%s", bt.Hex(), qt.Hex()))
 }
 
+// GetGroupedOrderBookChannelID returns the channel of the orderbook of a pair grouped to the given
+// number of price decimals
+func GetGroupedOrderBookChannelID(bt, qt common.Address, decimals int) string {
+	return fmt.Sprintf("%s::%d", GetOrderBookChannelID(bt, qt), decimals)
+}
+
 func PrintJSON(x interface{}) {
 	b, err := json.MarshalIndent(x, "", "  ")
 	if err != nil {
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ParseHash parses a hex encoded 32 bytes hash, with or without its 0x prefix
+func ParseHash(s string) (common.Hash, error) {
+	h := s
+	if strings.HasPrefix(h, "0x") || strings.HasPrefix(h, "0X") {
+		h = h[2:]
+	}
+
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("Invalid hash: %v", s)
+	}
+
+	return common.BytesToHash(b), nil
+}
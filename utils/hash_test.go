@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHash(t *testing.T) {
+	hex := "a9a89346cc62330626c5853b74493a1f8e933db582c444bf2288bd6a211586ee"
+	expected := common.HexToHash(hex)
+
+	for _, s := range []string{hex, "0x" + hex} {
+		h, err := ParseHash(s)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, h)
+	}
+
+	invalid := []string{"", "0x", "0x1234", "0x" + hex + "00", "0x" + hex[:63] + "g"}
+	for _, s := range invalid {
+		_, err := ParseHash(s)
+		assert.NotNil(t, err, s)
+	}
+}
@@ -18,6 +18,10 @@ func Sub(x, y *big.Int) *big.Int {
 	return big.NewInt(0).Sub(x, y)
 }
 
+func Exp(x, y *big.Int) *big.Int {
+	return big.NewInt(0).Exp(x, y, nil)
+}
+
 func Neg(x *big.Int) *big.Int {
 	return big.NewInt(0).Neg(x)
 }
@@ -36,6 +40,14 @@ func Max(a, b *big.Int) *big.Int {
 	}
 }
 
+func Min(a, b *big.Int) *big.Int {
+	if a.Cmp(b) == -1 {
+		return a
+	} else {
+		return b
+	}
+}
+
 func IsZero(x *big.Int) bool {
 	if x.Cmp(big.NewInt(0)) == 0 {
 		return true
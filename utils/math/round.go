@@ -0,0 +1,52 @@
+package math
+
+import "math/big"
+
+// DivideRoundDown returns x / y rounded towards negative infinity
+func DivideRoundDown(x, y *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() != 0 && r.Sign() != y.Sign() {
+		q.Sub(q, big.NewInt(1))
+	}
+
+	return q
+}
+
+// DivideRoundUp returns x / y rounded towards positive infinity
+func DivideRoundUp(x, y *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() != 0 && r.Sign() == y.Sign() {
+		q.Add(q, big.NewInt(1))
+	}
+
+	return q
+}
+
+// SplitProportionally splits total into shares proportional to the given non-negative weights.
+// Every share but the last one is rounded down and the last share absorbs the remainder, so
+// that the shares always sum up to total. If the weights sum up to zero, the last share
+// receives the whole total.
+func SplitProportionally(total *big.Int, weights []*big.Int) []*big.Int {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	sum := big.NewInt(0)
+	for _, w := range weights {
+		sum.Add(sum, w)
+	}
+
+	shares := make([]*big.Int, len(weights))
+	allocated := big.NewInt(0)
+	for i, w := range weights[:len(weights)-1] {
+		shares[i] = big.NewInt(0)
+		if sum.Sign() > 0 {
+			shares[i] = DivideRoundDown(Mul(total, w), sum)
+		}
+
+		allocated.Add(allocated, shares[i])
+	}
+
+	shares[len(weights)-1] = Sub(total, allocated)
+	return shares
+}
@@ -0,0 +1,94 @@
+package math
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDivideRound(t *testing.T) {
+	testCases := []struct {
+		x, y     int64
+		down, up int64
+	}{
+		{10, 5, 2, 2},
+		{11, 5, 2, 3},
+		{14, 5, 2, 3},
+		{0, 5, 0, 0},
+		{-11, 5, -3, -2},
+		{11, -5, -3, -2},
+		{-11, -5, 2, 3},
+	}
+
+	for _, tc := range testCases {
+		x, y := big.NewInt(tc.x), big.NewInt(tc.y)
+		assert.Equal(t, tc.down, DivideRoundDown(x, y).Int64(), "%v / %v", tc.x, tc.y)
+		assert.Equal(t, tc.up, DivideRoundUp(x, y).Int64(), "%v / %v", tc.x, tc.y)
+	}
+}
+
+func TestDivideRoundBounds(t *testing.T) {
+	bounds := func(a, b []byte) bool {
+		x := new(big.Int).SetBytes(a)
+		y := new(big.Int).SetBytes(b)
+		if y.Sign() == 0 {
+			return true
+		}
+
+		down := DivideRoundDown(x, y)
+		up := DivideRoundUp(x, y)
+
+		// down * y <= x <= up * y and the two results differ by at most one
+		return Mul(down, y).Cmp(x) <= 0 &&
+			Mul(up, y).Cmp(x) >= 0 &&
+			Sub(up, down).Cmp(big.NewInt(1)) <= 0
+	}
+
+	config := &quick.Config{MaxCount: 1000, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(bounds, config); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSplitProportionally(t *testing.T) {
+	shares := SplitProportionally(big.NewInt(100), []*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1)})
+	assert.Equal(t, []*big.Int{big.NewInt(33), big.NewInt(33), big.NewInt(34)}, shares)
+
+	shares = SplitProportionally(big.NewInt(10), []*big.Int{big.NewInt(0), big.NewInt(0)})
+	assert.Equal(t, []*big.Int{big.NewInt(0), big.NewInt(10)}, shares)
+
+	assert.Nil(t, SplitProportionally(big.NewInt(10), nil))
+}
+
+func TestSplitProportionallyConservation(t *testing.T) {
+	conservation := func(total []byte, weights []uint32) bool {
+		if len(weights) == 0 {
+			return true
+		}
+
+		n := new(big.Int).SetBytes(total)
+		w := make([]*big.Int, len(weights))
+		for i := range weights {
+			w[i] = big.NewInt(int64(weights[i]))
+		}
+
+		sum := big.NewInt(0)
+		for _, s := range SplitProportionally(n, w) {
+			if s.Sign() < 0 {
+				return false
+			}
+
+			sum = Add(sum, s)
+		}
+
+		return sum.Cmp(n) == 0
+	}
+
+	config := &quick.Config{MaxCount: 1000, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(conservation, config); err != nil {
+		t.Error(err)
+	}
+}
@@ -397,8 +397,9 @@ func (c *Client) handleOHLCVUpdate(p types.WebSocketPayload) {
 
 }
 
+// SetNonce sets an increasing order nonce (orders with an already used nonce are rejected)
 func (c *Client) SetNonce(o *types.Order) {
-	o.Nonce = big.NewInt(int64(c.NonceGenerator.Intn(1e8)))
+	o.Nonce = big.NewInt(time.Now().UnixNano())
 }
 
 func (c *Client) SetTradeNonce(t *types.Trade) {
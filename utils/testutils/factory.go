@@ -65,10 +65,18 @@ func NewOrderFactory(p *types.Pair, w *types.Wallet, exchangeAddress common.Addr
 		Wallet:         w,
 		Params:         params,
 		NonceGenerator: ng,
+		OrderNonce:     uint64(time.Now().UnixNano()),
 		// Client:         client,
 	}, nil
 }
 
+// nextOrderNonce returns an increasing order nonce as orders with a nonce lower than the last
+// nonce used by the factory account are rejected
+func (f *OrderFactory) nextOrderNonce() *big.Int {
+	f.OrderNonce++
+	return new(big.Int).SetUint64(f.OrderNonce)
+}
+
 // GetWallet returns the order factory wallet
 func (f *OrderFactory) GetWallet() *types.Wallet {
 	return f.Wallet
@@ -127,7 +135,7 @@ func (f *OrderFactory) NewOrder(buyToken common.Address, buyAmount int64, sellTo
 	o.Expires = f.Params.Expires
 	o.MakeFee = f.Params.MakeFee
 	o.TakeFee = f.Params.TakeFee
-	o.Nonce = big.NewInt(int64(f.NonceGenerator.Intn(1e18)))
+	o.Nonce = f.nextOrderNonce()
 	o.Sign(f.Wallet)
 
 	return o, nil
@@ -146,7 +154,7 @@ func (f *OrderFactory) NewLargeOrder(buyToken common.Address, buyAmount *big.Int
 	o.Expires = f.Params.Expires
 	o.MakeFee = f.Params.MakeFee
 	o.TakeFee = f.Params.TakeFee
-	o.Nonce = big.NewInt(int64(f.NonceGenerator.Intn(1e18)))
+	o.Nonce = f.nextOrderNonce()
 	o.Sign(f.Wallet)
 
 	return o, nil
@@ -178,7 +186,14 @@ func (f *OrderFactory) NewCancelOrder(o *types.Order) (*types.OrderCancel, error
 	oc := &types.OrderCancel{}
 
 	oc.OrderHash = o.Hash
-	oc.Sign(f.Wallet)
+	oc.PairName = o.PairName
+	oc.Nonce = big.NewInt(int64(f.NonceGenerator.Intn(1e18)))
+
+	err := oc.Sign(f.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
 	return oc, nil
 }
 
@@ -203,7 +218,7 @@ func (f *OrderFactory) NewBuyOrder(pricepoint int64, value float64, filled ...fl
 	o.Expires = f.Params.Expires
 	o.MakeFee = f.Params.MakeFee
 	o.TakeFee = f.Params.TakeFee
-	o.Nonce = big.NewInt(int64(f.NonceGenerator.Intn(1e8)))
+	o.Nonce = f.nextOrderNonce()
 	o.Side = "BUY"
 
 	if filled == nil {
@@ -251,7 +266,7 @@ func (f *OrderFactory) NewSellOrder(pricepoint int64, value float64, filled ...f
 	o.Expires = f.Params.Expires
 	o.MakeFee = f.Params.MakeFee
 	o.TakeFee = f.Params.TakeFee
-	o.Nonce = big.NewInt(int64(f.NonceGenerator.Intn(1e8)))
+	o.Nonce = f.nextOrderNonce()
 	o.Side = "SELL"
 
 	o.PricePoint = big.NewInt(pricepoint)
@@ -287,6 +302,7 @@ func (f *OrderFactory) NewTrade(o *types.Order, amount int64) (types.Trade, erro
 	t.TradeNonce = big.NewInt(int64(f.NonceGenerator.Intn(1e8)))
 	t.OrderHash = o.Hash
 	t.Amount = big.NewInt(amount)
+	t.HashVersion = types.CurrentTradeHashVersion
 
 	t.Sign(f.Wallet)
 	return t, nil
@@ -302,6 +318,7 @@ func (f *OrderFactory) NewPendingTrade(o *types.Order, amount int64) *types.Pend
 	t.TradeNonce = big.NewInt(int64(f.NonceGenerator.Intn(1e8)))
 	t.OrderHash = o.Hash
 	t.Amount = big.NewInt(amount)
+	t.HashVersion = types.CurrentTradeHashVersion
 
 	t.Sign(f.Wallet)
 
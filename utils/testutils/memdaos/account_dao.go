@@ -0,0 +1,299 @@
+package memdaos
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccountDao is an in-memory implementation of interfaces.AccountDao (see daos.AccountDao)
+type AccountDao struct {
+	accounts *collection
+
+	// mu serializes the balance updates of UpdateTokenBalances, like the version check of the
+	// mongo update does for daos.AccountDao
+	mu sync.Mutex
+}
+
+// NewAccountDao returns an empty AccountDao. There is a single account for an address.
+func NewAccountDao() *AccountDao {
+	return &AccountDao{accounts: newCollection([]string{"address"})}
+}
+
+// Create inserts an account
+func (dao *AccountDao) Create(a *types.Account) error {
+	a.ID = bson.NewObjectId()
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = time.Now()
+
+	return dao.accounts.insert(a)
+}
+
+// GetAll returns all the accounts
+func (dao *AccountDao) GetAll() ([]types.Account, error) {
+	res := []types.Account{}
+	dao.accounts.find(func(bson.M) bool { return true }, 0, &res)
+	return res, nil
+}
+
+// GetByID returns the account with the given id. mgo.ErrNotFound is returned if there is none.
+func (dao *AccountDao) GetByID(id bson.ObjectId) (*types.Account, error) {
+	a := &types.Account{}
+	err := dao.accounts.findID(id, a)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GetByAddress returns the account of the given address, or nil if there is none
+func (dao *AccountDao) GetByAddress(owner common.Address) (*types.Account, error) {
+	a := &types.Account{}
+	if !dao.accounts.findOne(byAddress(owner), a) {
+		return nil, nil
+	}
+
+	return a, nil
+}
+
+// GetTokenBalances returns the token balances of the account of the given address, or nil if
+// there is no such account
+func (dao *AccountDao) GetTokenBalances(owner common.Address) (map[common.Address]*types.TokenBalance, error) {
+	a, err := dao.GetByAddress(owner)
+	if a == nil || err != nil {
+		return nil, err
+	}
+
+	return a.TokenBalances, nil
+}
+
+// GetTokenBalance returns the balance of a token of the account of the given address, or nil if
+// there is no such account or balance
+func (dao *AccountDao) GetTokenBalance(owner common.Address, token common.Address) (*types.TokenBalance, error) {
+	balances, err := dao.GetTokenBalances(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return balances[token], nil
+}
+
+// setTokenBalanceFields sets fields of the balance of a token of the account of the given address,
+// like a $set update of the "tokenBalances.<token>.<field>" keys
+func (dao *AccountDao) setTokenBalanceFields(owner, token common.Address, fields bson.M) error {
+	return dao.accounts.update(byAddress(owner), func(d bson.M) {
+		balances, ok := d["tokenBalances"].(bson.M)
+		if !ok {
+			balances = bson.M{}
+			d["tokenBalances"] = balances
+		}
+
+		balance, ok := balances[token.Hex()].(bson.M)
+		if !ok {
+			balance = bson.M{}
+			balances[token.Hex()] = balance
+		}
+
+		for k, v := range fields {
+			balance[k] = v
+		}
+	})
+}
+
+// UpdateTokenBalance sets the balance, allowance, locked and pending balances of a token
+func (dao *AccountDao) UpdateTokenBalance(owner, token common.Address, tokenBalance *types.TokenBalance) error {
+	return dao.setTokenBalanceFields(owner, token, bson.M{
+		"balance":        tokenBalance.Balance.String(),
+		"allowance":      tokenBalance.Allowance.String(),
+		"lockedBalance":  tokenBalance.LockedBalance.String(),
+		"pendingBalance": tokenBalance.PendingBalance.String(),
+	})
+}
+
+// UpdateBalance sets the balance of a token
+func (dao *AccountDao) UpdateBalance(owner common.Address, token common.Address, balance *big.Int) error {
+	return dao.setTokenBalanceFields(owner, token, bson.M{"balance": balance.String()})
+}
+
+// UpdateAllowance sets the allowance of a token
+func (dao *AccountDao) UpdateAllowance(owner common.Address, token common.Address, allowance *big.Int) error {
+	return dao.setTokenBalanceFields(owner, token, bson.M{"allowance": allowance.String()})
+}
+
+// UpdateTokenBalances applies fn to the account of owner and writes the balances of the given
+// tokens. Updates are serialized so that concurrent updates are never lost. mgo.ErrNotFound is
+// returned if there is no account for owner.
+func (dao *AccountDao) UpdateTokenBalances(owner common.Address, fn func(acc *types.Account) ([]*types.BalanceChange, error), tokens ...common.Address) ([]*types.BalanceChange, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	acc, err := dao.GetByAddress(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if acc == nil {
+		return nil, mgo.ErrNotFound
+	}
+
+	changes, err := fn(acc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		tb, ok := acc.TokenBalances[token]
+		if !ok {
+			continue
+		}
+
+		err = dao.UpdateTokenBalance(owner, token, tb)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// LockBalance locks an amount of token of the account of owner. types.ErrInsufficientBalance is
+// returned if the spendable balance is lower than the amount.
+func (dao *AccountDao) LockBalance(owner, token common.Address, amount *big.Int) (*types.BalanceChange, error) {
+	changes, err := dao.UpdateTokenBalances(owner, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		lock, err := acc.Lock(token, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*types.BalanceChange{lock}, nil
+	}, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes[0], nil
+}
+
+// UnlockBalance releases an amount of token of the account of owner locked with LockBalance
+func (dao *AccountDao) UnlockBalance(owner, token common.Address, amount *big.Int) (*types.BalanceChange, error) {
+	changes, err := dao.UpdateTokenBalances(owner, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		unlock, err := acc.Unlock(token, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*types.BalanceChange{unlock}, nil
+	}, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes[0], nil
+}
+
+// TransferLocked takes the amount spent from the locked balance of the sell token and credits the
+// amount received to the balance of the buy token
+func (dao *AccountDao) TransferLocked(owner, sellToken, buyToken common.Address, spent, received *big.Int) ([]*types.BalanceChange, error) {
+	return dao.UpdateTokenBalances(owner, func(acc *types.Account) ([]*types.BalanceChange, error) {
+		debit, err := acc.SpendLocked(sellToken, spent)
+		if err != nil {
+			return nil, err
+		}
+
+		credit, err := acc.Credit(buyToken, received)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*types.BalanceChange{debit, credit}, nil
+	}, sellToken, buyToken)
+}
+
+// ConsumeOrderNonce records nonce as the last order nonce of an account if it is higher than the
+// previous one, creating the account if it does not exist. It returns false if the nonce was
+// already consumed.
+func (dao *AccountDao) ConsumeOrderNonce(owner common.Address, nonce *big.Int) (bool, error) {
+	encoded := types.EncodeOrderNonce(nonce)
+	match := func(d bson.M) bool {
+		if !byAddress(owner)(d) {
+			return false
+		}
+
+		last, ok := d["orderNonce"].(string)
+		return !ok || last < encoded
+	}
+
+	set := func(d bson.M) {
+		d["orderNonce"] = encoded
+	}
+
+	err := dao.accounts.update(match, set)
+	if err == mgo.ErrNotFound {
+		err = dao.Create(&types.Account{
+			Address:       owner,
+			TokenBalances: map[common.Address]*types.TokenBalance{},
+			OrderNonce:    nonce,
+		})
+
+		if mgo.IsDup(err) {
+			err = dao.accounts.update(match, set)
+		}
+	}
+
+	return err == nil, nil
+}
+
+// GetOrderNonce returns the last order nonce consumed by an account or nil if the account has not
+// placed any order yet
+func (dao *AccountDao) GetOrderNonce(owner common.Address) (*big.Int, error) {
+	a, err := dao.GetByAddress(owner)
+	if a == nil || err != nil {
+		return nil, err
+	}
+
+	return a.OrderNonce, nil
+}
+
+// UpdateOpenOrderLimits sets the open orders limits of an account. The account is reverted to the
+// default limits when limits is nil.
+func (dao *AccountDao) UpdateOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) error {
+	var encoded interface{}
+	if limits != nil {
+		encoded = encode(limits)
+	}
+
+	return dao.accounts.update(byAddress(owner), func(d bson.M) {
+		if encoded == nil {
+			delete(d, "openOrderLimits")
+		} else {
+			d["openOrderLimits"] = encoded
+		}
+	})
+}
+
+// UpdateBlocked sets whether an account is blocked from sending orders
+func (dao *AccountDao) UpdateBlocked(owner common.Address, blocked bool) error {
+	return dao.accounts.update(byAddress(owner), func(d bson.M) {
+		d["isBlocked"] = blocked
+	})
+}
+
+// Drop removes all the accounts
+func (dao *AccountDao) Drop() {
+	dao.accounts.drop()
+}
+
+// byAddress returns a query matching the document of the given address
+func byAddress(addr common.Address) func(bson.M) bool {
+	return func(d bson.M) bool {
+		return d["address"] == addr.Hex()
+	}
+}
@@ -0,0 +1,434 @@
+// Package memdaos provides in-memory implementations of the DAO interfaces for the unit tests of
+// the services, so that they do not need a mongo server. The DAOs keep the semantics of the mgo
+// DAOs of the daos package: documents are stored as they are encoded by mgo, unique indexes are
+// enforced and the same errors are returned when documents are missing.
+package memdaos
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// collection is an in-memory mongo collection. Documents are stored in their bson encoding, in
+// insertion order which is the natural order of mongo, so that they are copied in and out of the
+// collection and decoded by their SetBSON methods like with mgo.
+type collection struct {
+	mu     sync.RWMutex
+	docs   []bson.M
+	unique [][]string
+}
+
+// newCollection returns an empty collection. Each unique index is given as the list of its keys,
+// ids being unique as well.
+func newCollection(unique ...[]string) *collection {
+	return &collection{unique: append([][]string{{"_id"}}, unique...)}
+}
+
+// encode returns the bson document of a value
+func encode(v interface{}) bson.M {
+	b, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	doc := bson.M{}
+	err = bson.Unmarshal(b, &doc)
+	if err != nil {
+		panic(err)
+	}
+
+	return doc
+}
+
+// decode decodes documents into out, a pointer to a slice of values or of pointers to values
+func decode(docs []bson.M, out interface{}) {
+	slice := reflect.ValueOf(out).Elem()
+	elem := slice.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	if isPtr {
+		elem = elem.Elem()
+	}
+
+	res := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		v := reflect.New(elem)
+		decodeOne(doc, v.Interface())
+
+		if isPtr {
+			res = reflect.Append(res, v)
+		} else {
+			res = reflect.Append(res, v.Elem())
+		}
+	}
+
+	slice.Set(res)
+}
+
+// decodeOne decodes a document into out, a pointer to a value
+func decodeOne(doc bson.M, out interface{}) {
+	b, err := bson.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+
+	err = bson.Unmarshal(b, out)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// duplicate returns the duplicate key error of mongo if a document other than the document at
+// index skip has the same keys as doc for one of the unique indexes
+func (c *collection) duplicate(doc bson.M, skip int) error {
+	for _, keys := range c.unique {
+		for i, d := range c.docs {
+			if i == skip {
+				continue
+			}
+
+			same := true
+			for _, k := range keys {
+				if !reflect.DeepEqual(d[k], doc[k]) {
+					same = false
+					break
+				}
+			}
+
+			if same {
+				return &mgo.LastError{
+					Code: 11000,
+					Err:  fmt.Sprintf("E11000 duplicate key error index: %v", strings.Join(keys, "_")),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// insert inserts documents in the collection in order, like mgo.Collection.Insert. The insertion
+// stops at the first document that breaks a unique index.
+func (c *collection) insert(values ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range values {
+		doc := encode(v)
+		err := c.duplicate(doc, -1)
+		if err != nil {
+			return err
+		}
+
+		c.docs = append(c.docs, doc)
+	}
+
+	return nil
+}
+
+// find decodes the documents matching a query into out (see decode). Up to limit documents are
+// decoded, or all of them if limit is 0.
+func (c *collection) find(match func(bson.M) bool, limit int, out interface{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	decode(c.filter(match, limit), out)
+}
+
+// findOne decodes the first document matching a query into out. It returns false if no
+// document matches.
+func (c *collection) findOne(match func(bson.M) bool, out interface{}) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := c.filter(match, 1)
+	if len(docs) == 0 {
+		return false
+	}
+
+	decodeOne(docs[0], out)
+	return true
+}
+
+// findID decodes the document with the given id into out, like mgo.Collection.FindId.
+// mgo.ErrNotFound is returned if there is no such document.
+func (c *collection) findID(id bson.ObjectId, out interface{}) error {
+	if !c.findOne(func(d bson.M) bool { return d["_id"] == id }, out) {
+		return mgo.ErrNotFound
+	}
+
+	return nil
+}
+
+// filter returns up to limit documents matching a query, or all of them if limit is 0
+func (c *collection) filter(match func(bson.M) bool, limit int) []bson.M {
+	res := []bson.M{}
+	for _, d := range c.docs {
+		if match(d) {
+			res = append(res, d)
+		}
+
+		if limit > 0 && len(res) == limit {
+			break
+		}
+	}
+
+	return res
+}
+
+// page decodes into out a page of up to limit documents matching a query, sorted by creation time
+// and then by id, the most recent first unless ascending is set, starting after a cursor like
+// daos.Database.GetPage. The cursor of the next page is returned, and is empty if there is no
+// next page.
+func (c *collection) page(match func(bson.M) bool, cursor string, ascending bool, limit int, out interface{}) (string, error) {
+	var after *types.Cursor
+	if cursor != "" {
+		var err error
+		after, err = types.DecodeCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := c.filter(match, 0)
+	sort.SliceStable(docs, func(i, j int) bool {
+		if ascending {
+			return before(docs[i], docs[j]["createdAt"].(time.Time), docs[j]["_id"].(bson.ObjectId))
+		}
+
+		return before(docs[j], docs[i]["createdAt"].(time.Time), docs[i]["_id"].(bson.ObjectId))
+	})
+
+	res := []bson.M{}
+	for _, d := range docs {
+		if after == nil {
+			res = append(res, d)
+			continue
+		}
+
+		isBefore := before(d, after.CreatedAt, after.ID)
+		isAfter := !isBefore && !(d["_id"] == after.ID)
+		if (ascending && isAfter) || (!ascending && isBefore) {
+			res = append(res, d)
+		}
+	}
+
+	next := ""
+	if len(res) > limit {
+		res = res[:limit]
+		last := res[limit-1]
+		next = (&types.Cursor{CreatedAt: last["createdAt"].(time.Time), ID: last["_id"].(bson.ObjectId)}).Encode()
+	}
+
+	decode(res, out)
+	return next, nil
+}
+
+// sorted decodes into out up to limit documents matching a query, or all of them if limit is 0,
+// sorted by a time field and then by id, skipping the first offset documents like a query with
+// a sort and a skip
+func (c *collection) sorted(match func(bson.M) bool, field string, ascending bool, offset, limit int, out interface{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := c.filter(match, 0)
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, _ := docs[i][field].(time.Time)
+		b, _ := docs[j][field].(time.Time)
+		if ascending {
+			a, b = b, a
+			i, j = j, i
+		}
+
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+
+		return docs[i]["_id"].(bson.ObjectId) > docs[j]["_id"].(bson.ObjectId)
+	})
+
+	if offset > len(docs) {
+		offset = len(docs)
+	}
+
+	docs = docs[offset:]
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+
+	decode(docs, out)
+}
+
+// before returns true if a document was created before the given creation time, or at the same
+// time with a lower id
+func before(doc bson.M, createdAt time.Time, id bson.ObjectId) bool {
+	t := doc["createdAt"].(time.Time)
+	if !t.Equal(createdAt) {
+		return t.Before(createdAt)
+	}
+
+	return doc["_id"].(bson.ObjectId) < id
+}
+
+// update calls set on the first document matching a query, like mgo.Collection.Update with a
+// $set update. mgo.ErrNotFound is returned if no document matches.
+func (c *collection) update(match func(bson.M) bool, set func(bson.M)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, d := range c.docs {
+		if match(d) {
+			set(d)
+			return nil
+		}
+	}
+
+	return mgo.ErrNotFound
+}
+
+// replace replaces the first document matching a query with the given value, like
+// mgo.Collection.Update with a whole document. The id of the document is kept.
+// mgo.ErrNotFound is returned if no document matches.
+func (c *collection) replace(match func(bson.M) bool, v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, d := range c.docs {
+		if !match(d) {
+			continue
+		}
+
+		doc := encode(v)
+		doc["_id"] = d["_id"]
+
+		err := c.duplicate(doc, i)
+		if err != nil {
+			return err
+		}
+
+		c.docs[i] = doc
+		return nil
+	}
+
+	return mgo.ErrNotFound
+}
+
+// upsert replaces the documents with the ids of the given documents and inserts the others, like
+// an unordered bulk of upserts by id
+func (c *collection) upsert(docs ...bson.M) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, doc := range docs {
+		doc = encode(doc)
+		i := 0
+		for ; i < len(c.docs); i++ {
+			if c.docs[i]["_id"] == doc["_id"] {
+				break
+			}
+		}
+
+		err := c.duplicate(doc, i)
+		if err != nil {
+			return err
+		}
+
+		if i < len(c.docs) {
+			c.docs[i] = doc
+		} else {
+			c.docs = append(c.docs, doc)
+		}
+	}
+
+	return nil
+}
+
+// remove removes the documents matching a query, like mgo.Collection.RemoveAll. It returns the
+// number of removed documents.
+func (c *collection) remove(match func(bson.M) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	docs := []bson.M{}
+	for _, d := range c.docs {
+		if !match(d) {
+			docs = append(docs, d)
+		}
+	}
+
+	removed := len(c.docs) - len(docs)
+	c.docs = docs
+	return removed
+}
+
+// union returns a collection holding copies of the documents of the given collections. A
+// document is taken from the first collection holding its id.
+func union(cs ...*collection) *collection {
+	res := newCollection()
+	seen := map[interface{}]bool{}
+	for _, c := range cs {
+		c.mu.RLock()
+		for _, d := range c.docs {
+			if seen[d["_id"]] {
+				continue
+			}
+
+			seen[d["_id"]] = true
+			doc := bson.M{}
+			for k, v := range d {
+				doc[k] = v
+			}
+
+			res.docs = append(res.docs, doc)
+		}
+		c.mu.RUnlock()
+	}
+
+	return res
+}
+
+// drop removes all the documents of the collection
+func (c *collection) drop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.docs = nil
+}
+
+// in returns true if a field of a document is one of the given values
+func in(v interface{}, values ...string) bool {
+	for _, s := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchName returns true if a field of a document matches a case insensitive regular expression,
+// like a bson.RegEx query with the "i" option
+func matchName(v interface{}, pattern string) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(s)
+}
@@ -0,0 +1,206 @@
+package memdaos
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/testutils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var _ interfaces.OrderDao = NewOrderDao()
+var _ interfaces.TradeDao = NewTradeDao()
+var _ interfaces.PairDao = NewPairDao()
+var _ interfaces.AccountDao = NewAccountDao()
+var _ interfaces.WalletDao = NewWalletDao()
+var _ interfaces.TokenDao = NewTokenDao()
+
+func TestOrderDao(t *testing.T) {
+	dao := NewOrderDao()
+
+	o1 := testutils.GetTestOrder1()
+	o2 := testutils.GetTestOrder2()
+	dao.Create(&o1)
+	dao.Create(&o2)
+
+	// order hashes are unique
+	dup := testutils.GetTestOrder1()
+	err := dao.Create(&dup)
+	assert.Equal(t, types.ErrOrderAlreadyExists, err)
+
+	o, err := dao.GetByHash(o1.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.CompareOrder(t, &o1, o)
+
+	// the orders are copied in and out of the dao
+	o.Status = types.OrderStatusFilled
+	o, _ = dao.GetByHash(o1.Hash)
+	assert.Equal(t, types.OrderStatusOpen, o.Status)
+
+	_, err = dao.GetByID(bson.NewObjectId())
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	o, err = dao.GetByHash(common.HexToHash("0x1"))
+	assert.Nil(t, o)
+	assert.Nil(t, err)
+
+	err = dao.UpdateOrderFilledAmount(o1.Hash, o1.Amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, _ = dao.GetByHash(o1.Hash)
+	assert.Equal(t, types.OrderStatusFilled, o.Status)
+
+	// filled orders can not be cancelled, but they are reopened when their trades fail
+	err = dao.UpdateOrderStatus(o1.Hash, types.OrderStatusCancelled)
+	assert.NotNil(t, err)
+
+	err = dao.UpdateOrderStatus(o1.Hash, types.OrderStatusOpen)
+	assert.Nil(t, err)
+
+	o, _ = dao.GetByHash(o1.Hash)
+	assert.Equal(t, types.OrderStatusOpen, o.Status)
+
+	err = dao.UpdateOrderStatus(o1.Hash, types.OrderStatusFilled)
+	assert.Nil(t, err)
+
+	// the most recent orders come first
+	orders, next, err := dao.GetByUserAddress(o1.UserAddress, types.OrderQuery{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o2.Hash, orders[0].Hash)
+
+	orders, next, _ = dao.GetByUserAddress(o1.UserAddress, types.OrderQuery{Cursor: next, Limit: 1})
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o1.Hash, orders[0].Hash)
+	assert.Equal(t, "", next)
+
+	orders, _, _ = dao.GetCurrentByUserAddress(o1.UserAddress, "", 10)
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o2.Hash, orders[0].Hash)
+}
+
+func TestOrderDaoArchiveOrders(t *testing.T) {
+	dao := NewOrderDao()
+
+	o1 := testutils.GetTestOrder1()
+	o2 := testutils.GetTestOrder2()
+	o1.Status = types.OrderStatusFilled
+	dao.Create(&o1)
+	dao.Create(&o2)
+
+	n, err := dao.ArchiveOrders(time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, n)
+
+	o, _ := dao.GetByHash(o1.Hash)
+	assert.Nil(t, o)
+
+	// the archived orders are still listed
+	orders, _, _ := dao.GetHistoryByUserAddress(o1.UserAddress, "", 10)
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, o1.Hash, orders[0].Hash)
+
+	orders, _, _ = dao.GetByUserAddress(o1.UserAddress, types.OrderQuery{Limit: 10})
+	assert.Equal(t, 2, len(orders))
+
+	n, _ = dao.ArchiveOrders(time.Now().Add(time.Hour), 0)
+	assert.Equal(t, 0, n)
+}
+
+func TestAccountDao(t *testing.T) {
+	dao := NewAccountDao()
+
+	owner := common.HexToAddress("0x1")
+	token := common.HexToAddress("0x2")
+	dao.Create(&types.Account{Address: owner})
+
+	err := dao.Create(&types.Account{Address: owner})
+	assert.True(t, mgo.IsDup(err))
+
+	err = dao.UpdateBalance(owner, token, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dao.UpdateAllowance(owner, token, big.NewInt(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := dao.GetTokenBalance(owner, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, big.NewInt(100), b.Balance)
+	assert.Equal(t, big.NewInt(50), b.Allowance)
+
+	err = dao.UpdateBlocked(common.HexToAddress("0x3"), true)
+	assert.Equal(t, mgo.ErrNotFound, err)
+}
+
+func TestAccountDaoConcurrentLock(t *testing.T) {
+	dao := NewAccountDao()
+
+	owner := common.HexToAddress("0x1")
+	token := common.HexToAddress("0x2")
+	dao.Create(&types.Account{Address: owner})
+	dao.UpdateTokenBalance(owner, token, &types.TokenBalance{
+		Address:        token,
+		Balance:        big.NewInt(100),
+		Allowance:      big.NewInt(100),
+		LockedBalance:  big.NewInt(0),
+		PendingBalance: big.NewInt(0),
+	})
+
+	wg := sync.WaitGroup{}
+	locked := make(chan bool, 200)
+
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := dao.LockBalance(owner, token, big.NewInt(1))
+			locked <- err == nil
+		}()
+	}
+
+	wg.Wait()
+	close(locked)
+
+	count := 0
+	for ok := range locked {
+		if ok {
+			count++
+		}
+	}
+
+	b, err := dao.GetTokenBalance(owner, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 100, count)
+	assert.Equal(t, "100", b.LockedBalance.String())
+
+	_, err = dao.LockBalance(common.HexToAddress("0x3"), token, big.NewInt(1))
+	assert.Equal(t, mgo.ErrNotFound, err)
+}
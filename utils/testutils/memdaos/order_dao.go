@@ -0,0 +1,523 @@
+package memdaos
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// archiveBatchSize is the number of orders moved to the archive at once, like
+// daos.OrderArchiveBatchSize
+const archiveBatchSize = 1000
+
+// deleteBatchSize is the number of orders removed at once, like daos.OrderDeleteBatchSize
+const deleteBatchSize = 1000
+
+// OrderDao is an in-memory implementation of interfaces.OrderDao (see daos.OrderDao)
+type OrderDao struct {
+	orders  *collection
+	archive *collection
+}
+
+// NewOrderDao returns an empty OrderDao. Order hashes are unique.
+func NewOrderDao() *OrderDao {
+	return &OrderDao{newCollection([]string{"hash"}), newCollection()}
+}
+
+// listed returns the orders listed by the listings of orders, the archived orders included. An
+// order found in both collections is taken from the orders collection.
+func (dao *OrderDao) listed() *collection {
+	return union(dao.orders, dao.archive)
+}
+
+// ArchiveOrders moves the filled, cancelled and expired orders last updated before the given time
+// to the archive, in batches of archiveBatchSize orders and up to the given number of batches, or
+// until none is left if batches is 0. Returns the number of archived orders.
+func (dao *OrderDao) ArchiveOrders(before time.Time, batches int) (int, error) {
+	match := func(d bson.M) bool {
+		return in(d["status"], types.OrderStatusFilled, types.OrderStatusCancelled, types.OrderStatusExpired) &&
+			d["updatedAt"].(time.Time).Before(before)
+	}
+
+	archived := 0
+	for i := 0; batches == 0 || i < batches; i++ {
+		docs := []bson.M{}
+		dao.orders.sorted(match, "updatedAt", true, 0, archiveBatchSize, &docs)
+		if len(docs) == 0 {
+			break
+		}
+
+		err := dao.archive.upsert(docs...)
+		if err != nil {
+			return archived, err
+		}
+
+		ids := []interface{}{}
+		for _, d := range docs {
+			ids = append(ids, d["_id"])
+		}
+
+		dao.orders.remove(func(d bson.M) bool {
+			for _, id := range ids {
+				if d["_id"] == id {
+					return match(d)
+				}
+			}
+
+			return false
+		})
+
+		archived += len(docs)
+	}
+
+	return archived, nil
+}
+
+// DeleteOrdersBefore removes the orders of the given final status last updated strictly before the
+// given time, in batches of deleteBatchSize orders and up to the given number of batches, or until
+// none is left if batches is 0. Archived orders are kept.
+func (dao *OrderDao) DeleteOrdersBefore(status string, before time.Time, batches int) (int, error) {
+	if !in(status, types.OrderStatusFilled, types.OrderStatusReplaced, types.OrderStatusCancelled,
+		types.OrderStatusExpired, types.OrderStatusInvalid, types.OrderStatusRejected, types.OrderStatusError) {
+		return 0, errors.New("Only the orders in a final status can be deleted")
+	}
+
+	match := func(d bson.M) bool {
+		return d["status"] == status && d["updatedAt"].(time.Time).Before(before)
+	}
+
+	deleted := 0
+	for i := 0; batches == 0 || i < batches; i++ {
+		docs := []bson.M{}
+		dao.orders.sorted(match, "updatedAt", true, 0, deleteBatchSize, &docs)
+		if len(docs) == 0 {
+			break
+		}
+
+		ids := []interface{}{}
+		for _, d := range docs {
+			ids = append(ids, d["_id"])
+		}
+
+		dao.orders.remove(func(d bson.M) bool {
+			for _, id := range ids {
+				if d["_id"] == id {
+					return match(d)
+				}
+			}
+
+			return false
+		})
+
+		deleted += len(docs)
+	}
+
+	return deleted, nil
+}
+
+// Create inserts an order
+func (dao *OrderDao) Create(o *types.Order) error {
+	o.ID = bson.NewObjectId()
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = time.Now()
+
+	if o.Status == "" {
+		o.Status = types.OrderStatusOpen
+	}
+
+	err := dao.orders.insert(o)
+	if mgo.IsDup(err) {
+		return types.ErrOrderAlreadyExists
+	}
+
+	return err
+}
+
+// UpsertByHash updates the updateable fields of the order with the hash of o if it can move to
+// the status of o, or inserts o if no order has its hash
+func (dao *OrderDao) UpsertByHash(o *types.Order) error {
+	stored, err := dao.GetByHash(o.Hash)
+	if err != nil {
+		return err
+	}
+
+	if stored == nil {
+		return dao.Create(o)
+	}
+
+	if o.Status == "" {
+		o.Status = types.OrderStatusOpen
+	}
+
+	o.ID, o.CreatedAt = stored.ID, stored.CreatedAt
+	return dao.UpdateByHash(o.Hash, o)
+}
+
+// Update replaces the order with the given id
+func (dao *OrderDao) Update(id bson.ObjectId, o *types.Order) error {
+	o.UpdatedAt = time.Now()
+	return dao.orders.replace(func(d bson.M) bool { return d["_id"] == id }, o)
+}
+
+// UpdateAllByHash replaces the order with the given hash if it can move to the status of o
+func (dao *OrderDao) UpdateAllByHash(hash common.Hash, o *types.Order) error {
+	o.UpdatedAt = time.Now()
+
+	match, err := dao.statusTransitionQuery(hash, o.Status)
+	if err != nil {
+		return err
+	}
+
+	return dao.orders.replace(match, o)
+}
+
+// UpdateByHash updates the updateable fields of the order with the given hash if it can move to
+// the status of o
+func (dao *OrderDao) UpdateByHash(hash common.Hash, o *types.Order) error {
+	o.UpdatedAt = time.Now()
+
+	match, err := dao.statusTransitionQuery(hash, o.Status)
+	if err != nil {
+		return err
+	}
+
+	return dao.orders.update(match, func(d bson.M) {
+		d["buyAmount"] = o.BuyAmount.String()
+		d["sellAmount"] = o.SellAmount.String()
+		d["pricepoint"] = o.PricePoint.String()
+		d["amount"] = o.Amount.String()
+		d["status"] = o.Status
+		d["filledAmount"] = o.FilledAmount.String()
+		d["makeFee"] = o.MakeFee.String()
+		d["takeFee"] = o.TakeFee.String()
+		d["updatedAt"] = o.UpdatedAt
+	})
+}
+
+// UpdateOrderStatus updates the status of an order. Illegal status transitions are rejected.
+func (dao *OrderDao) UpdateOrderStatus(hash common.Hash, status string) error {
+	match, err := dao.statusTransitionQuery(hash, status)
+	if err != nil {
+		return err
+	}
+
+	return dao.orders.update(match, func(d bson.M) {
+		d["status"] = status
+	})
+}
+
+// UpdateOrderAmount sets the amount of an open or partially filled order
+func (dao *OrderDao) UpdateOrderAmount(hash common.Hash, amount *big.Int) error {
+	match := func(d bson.M) bool {
+		return d["hash"] == hash.Hex() && in(d["status"], types.OrderStatusOpen, types.OrderStatusPartialFilled)
+	}
+
+	return dao.orders.update(match, func(d bson.M) {
+		d["amount"] = amount.String()
+		d["updatedAt"] = time.Now()
+	})
+}
+
+// UpdateOrderFilledAmount adds value to the filled amount of an order and updates its status
+func (dao *OrderDao) UpdateOrderFilledAmount(hash common.Hash, value *big.Int) error {
+	o, err := dao.GetByHash(hash)
+	if err != nil {
+		return err
+	}
+
+	if o == nil {
+		return errors.New("Order not found")
+	}
+
+	status := ""
+	filledAmount := math.Add(o.FilledAmount, value)
+
+	if math.IsEqualOrSmallerThan(filledAmount, big.NewInt(0)) {
+		filledAmount = big.NewInt(0)
+		status = types.OrderStatusOpen
+	} else if math.IsEqualOrGreaterThan(filledAmount, o.Amount) {
+		filledAmount = o.Amount
+		status = types.OrderStatusFilled
+	} else {
+		status = types.OrderStatusPartialFilled
+	}
+
+	current := o.Status
+	err = o.TransitionTo(status)
+	if err != nil {
+		return err
+	}
+
+	match := func(d bson.M) bool { return d["hash"] == hash.Hex() && d["status"] == current }
+	return dao.orders.update(match, func(d bson.M) {
+		d["status"] = status
+		d["filledAmount"] = filledAmount.String()
+	})
+}
+
+// statusTransitionQuery checks that the order with the given hash can move to the given status
+// and returns a query matching the order only if its status has not changed since
+func (dao *OrderDao) statusTransitionQuery(hash common.Hash, status string) (func(bson.M) bool, error) {
+	o, err := dao.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if o == nil {
+		return nil, errors.New("Order not found")
+	}
+
+	current := o.Status
+	err = o.TransitionTo(status)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(d bson.M) bool { return d["hash"] == hash.Hex() && d["status"] == current }, nil
+}
+
+// GetByID returns the order with the given id. mgo.ErrNotFound is returned if there is none.
+func (dao *OrderDao) GetByID(id bson.ObjectId) (*types.Order, error) {
+	o := &types.Order{}
+	err := dao.orders.findID(id, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// GetByHash returns the order with the given hash, or nil if there is none
+func (dao *OrderDao) GetByHash(hash common.Hash) (*types.Order, error) {
+	o := &types.Order{}
+	if !dao.orders.findOne(func(d bson.M) bool { return d["hash"] == hash.Hex() }, o) {
+		return nil, nil
+	}
+
+	return o, nil
+}
+
+// GetByHashes returns the orders with the given hashes
+func (dao *OrderDao) GetByHashes(hashes []common.Hash) ([]*types.Order, error) {
+	hexes := []string{}
+	for _, h := range hashes {
+		hexes = append(hexes, h.Hex())
+	}
+
+	res := []*types.Order{}
+	dao.orders.find(func(d bson.M) bool { return in(d["hash"], hexes...) }, 0, &res)
+	return res, nil
+}
+
+// GetByUserAddress returns a page of the orders of an account matching a query, along with the
+// cursor of the next page which is empty for the queries paginated by offset
+func (dao *OrderDao) GetByUserAddress(addr common.Address, query types.OrderQuery) ([]*types.Order, string, error) {
+	err := query.Validate()
+	if err != nil {
+		return nil, "", err
+	}
+
+	match := func(d bson.M) bool {
+		if d["userAddress"] != addr.Hex() {
+			return false
+		}
+
+		if len(query.Statuses) > 0 && !in(d["status"], query.Statuses...) {
+			return false
+		}
+
+		if query.PairName != "" && d["pairName"] != query.PairName {
+			return false
+		}
+
+		if query.Side != "" && d["side"] != string(query.Side) {
+			return false
+		}
+
+		createdAt, _ := d["createdAt"].(time.Time)
+		if !query.From.IsZero() && createdAt.Before(query.From) {
+			return false
+		}
+
+		return query.To.IsZero() || createdAt.Before(query.To)
+	}
+
+	listed := dao.listed()
+	if query.SortField() == types.OrderSortCreatedAt && query.Offset == 0 {
+		res := []*types.Order{}
+		next, err := listed.page(match, query.Cursor, query.Ascending, query.Limit, &res)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return res, next, nil
+	}
+
+	res := []*types.Order{}
+	listed.sorted(match, query.SortField(), query.Ascending, query.Offset, query.Limit, &res)
+	return res, "", nil
+}
+
+// GetCurrentByUserAddress returns a page of the open/partial orders of an account, the most recent
+// first, along with the cursor of the next page
+func (dao *OrderDao) GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	match := func(d bson.M) bool {
+		return d["userAddress"] == addr.Hex() && in(d["status"], types.OrderStatusOpen, types.OrderStatusPartialFilled)
+	}
+
+	return dao.getPage(match, cursor, limit)
+}
+
+// GetHistoryByUserAddress returns a page of the orders of an account which are not in
+// open/partial status, the most recent first and archived orders included, along with the cursor
+// of the next page
+func (dao *OrderDao) GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	match := func(d bson.M) bool {
+		return d["userAddress"] == addr.Hex() && !in(d["status"], types.OrderStatusOpen, types.OrderStatusPartialFilled)
+	}
+
+	return getPage(dao.listed(), match, cursor, limit)
+}
+
+func (dao *OrderDao) getPage(match func(bson.M) bool, cursor string, limit int) ([]*types.Order, string, error) {
+	return getPage(dao.orders, match, cursor, limit)
+}
+
+// getPage returns a page of the orders of a collection, the most recent first, along with the
+// cursor of the next page
+func getPage(c *collection, match func(bson.M) bool, cursor string, limit int) ([]*types.Order, string, error) {
+	res := []*types.Order{}
+	next, err := c.page(match, cursor, false, limit, &res)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return res, next, nil
+}
+
+// GetOpenOrderCounts returns the number of open orders of an account by pair name. Stop orders
+// that are not triggered yet are counted as open orders.
+func (dao *OrderDao) GetOpenOrderCounts(addr common.Address) (map[string]int, error) {
+	res := []*types.Order{}
+	dao.orders.find(func(d bson.M) bool {
+		return d["userAddress"] == addr.Hex() &&
+			in(d["status"], types.OrderStatusStop, types.OrderStatusOpen, types.OrderStatusPartialFilled)
+	}, 0, &res)
+
+	counts := map[string]int{}
+	for _, o := range res {
+		counts[o.PairName]++
+	}
+
+	return counts, nil
+}
+
+// GetUserLockedBalance returns the amount of a token locked by the open orders of an account
+func (dao *OrderDao) GetUserLockedBalance(account common.Address, token common.Address) (*big.Int, error) {
+	res := []*types.Order{}
+	dao.orders.find(func(d bson.M) bool {
+		return d["userAddress"] == account.Hex() && d["sellToken"] == token.Hex() &&
+			in(d["status"], types.OrderStatusOpen, types.OrderStatusPartialFilled)
+	}, 0, &res)
+
+	locked := big.NewInt(0)
+	for _, o := range res {
+		locked = math.Add(locked, o.RemainingSellAmount())
+	}
+
+	return locked, nil
+}
+
+// pending returns a query matching the open and partially filled orders of a pair
+func pending(p *types.Pair) func(bson.M) bool {
+	return func(d bson.M) bool {
+		return d["baseToken"] == p.BaseTokenAddress.Hex() && d["quoteToken"] == p.QuoteTokenAddress.Hex() &&
+			in(d["status"], types.OrderStatusOpen, types.OrderStatusPartialFilled)
+	}
+}
+
+// GetRawOrderBook returns the open orders of a pair sorted by pricepoint. Like with mongo, the
+// pricepoints are sorted as the strings they are stored as.
+func (dao *OrderDao) GetRawOrderBook(p *types.Pair) ([]*types.Order, error) {
+	dao.orders.mu.RLock()
+	docs := dao.orders.filter(pending(p), 0)
+	dao.orders.mu.RUnlock()
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i]["pricepoint"].(string) < docs[j]["pricepoint"].(string)
+	})
+
+	res := []*types.Order{}
+	decode(docs, &res)
+	return res, nil
+}
+
+// GetOrderBook returns the unfilled amounts of the open orders of a pair by pricepoint, for each
+// side, sorted by pricepoint like GetRawOrderBook
+func (dao *OrderDao) GetOrderBook(p *types.Pair) ([]map[string]string, []map[string]string, error) {
+	orders, err := dao.GetRawOrderBook(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bids := []map[string]string{}
+	asks := []map[string]string{}
+	levels := map[string]map[string]*big.Int{"BUY": {}, "SELL": {}}
+	for _, o := range orders {
+		pp := o.PricePoint.String()
+		amount := math.Sub(o.Amount, o.FilledAmount)
+
+		side := string(o.Side)
+		if levels[side] == nil {
+			continue
+		}
+
+		if levels[side][pp] == nil {
+			levels[side][pp] = big.NewInt(0)
+			level := map[string]string{"pricepoint": pp}
+			if side == "BUY" {
+				bids = append(bids, level)
+			} else {
+				asks = append(asks, level)
+			}
+		}
+
+		levels[side][pp] = math.Add(levels[side][pp], amount)
+	}
+
+	for _, l := range bids {
+		l["amount"] = levels["BUY"][l["pricepoint"]].String()
+	}
+
+	for _, l := range asks {
+		l["amount"] = levels["SELL"][l["pricepoint"]].String()
+	}
+
+	return bids, asks, nil
+}
+
+// GetOrderBookPricePoint returns the unfilled amount of the first open order of a pair at the given
+// pricepoint, or nil if there is none
+func (dao *OrderDao) GetOrderBookPricePoint(p *types.Pair, pp *big.Int) (*big.Int, error) {
+	match := pending(p)
+	o := &types.Order{}
+	if !dao.orders.findOne(func(d bson.M) bool { return match(d) && d["pricepoint"] == pp.String() }, o) {
+		return nil, nil
+	}
+
+	return math.Sub(o.Amount, o.FilledAmount), nil
+}
+
+// Drop removes all the orders, archived orders included
+func (dao *OrderDao) Drop() error {
+	dao.orders.drop()
+	dao.archive.drop()
+	return nil
+}
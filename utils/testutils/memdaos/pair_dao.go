@@ -0,0 +1,150 @@
+package memdaos
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// PairDao is an in-memory implementation of interfaces.PairDao (see daos.PairDao)
+type PairDao struct {
+	pairs *collection
+}
+
+// NewPairDao returns an empty PairDao. There is a single pair for a base token and a quote token.
+func NewPairDao() *PairDao {
+	return &PairDao{newCollection([]string{"baseTokenAddress", "quoteTokenAddress"})}
+}
+
+// Create inserts a pair
+func (dao *PairDao) Create(p *types.Pair) error {
+	p.ID = bson.NewObjectId()
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+
+	return dao.pairs.insert(p)
+}
+
+// GetAll returns all the pairs
+func (dao *PairDao) GetAll() ([]types.Pair, error) {
+	res := []types.Pair{}
+	dao.pairs.find(func(bson.M) bool { return true }, 0, &res)
+	return res, nil
+}
+
+// GetActive returns the pairs that are currently listed
+func (dao *PairDao) GetActive() ([]types.Pair, error) {
+	res := []types.Pair{}
+	dao.pairs.find(func(d bson.M) bool { return d["active"] == true }, 0, &res)
+	return res, nil
+}
+
+// GetByID returns the pair with the given id. mgo.ErrNotFound is returned if there is none.
+func (dao *PairDao) GetByID(id bson.ObjectId) (*types.Pair, error) {
+	p := &types.Pair{}
+	err := dao.pairs.findID(id, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetByName returns the first pair whose name field matches name, case insensitively
+func (dao *PairDao) GetByName(name string) (*types.Pair, error) {
+	p := &types.Pair{}
+	if !dao.pairs.findOne(func(d bson.M) bool { return matchName(d["name"], name) }, p) {
+		return nil, errors.New("Pair not found")
+	}
+
+	return p, nil
+}
+
+// GetByTokenSymbols returns the pair of the given base and quote token symbols
+func (dao *PairDao) GetByTokenSymbols(baseTokenSymbol, quoteTokenSymbol string) (*types.Pair, error) {
+	match := func(d bson.M) bool {
+		return d["baseTokenSymbol"] == baseTokenSymbol && d["quoteTokenSymbol"] == quoteTokenSymbol
+	}
+
+	p := &types.Pair{}
+	if !dao.pairs.findOne(match, p) {
+		return nil, errors.New("No pair found")
+	}
+
+	return p, nil
+}
+
+// GetByTokenAddress returns the pair of the given base and quote token addresses
+func (dao *PairDao) GetByTokenAddress(baseToken, quoteToken common.Address) (*types.Pair, error) {
+	p := &types.Pair{}
+	if !dao.pairs.findOne(byTokens(baseToken, quoteToken), p) {
+		return nil, errors.New("Pair not found")
+	}
+
+	return p, nil
+}
+
+// GetByBuySellTokenAddress returns the pair of the given buy and sell tokens, whichever is the
+// base token
+func (dao *PairDao) GetByBuySellTokenAddress(buyToken, sellToken common.Address) (*types.Pair, error) {
+	buySell := byTokens(buyToken, sellToken)
+	sellBuy := byTokens(sellToken, buyToken)
+
+	p := &types.Pair{}
+	if !dao.pairs.findOne(func(d bson.M) bool { return buySell(d) || sellBuy(d) }, p) {
+		return nil, errors.New("Pair not found")
+	}
+
+	return p, nil
+}
+
+// UpdatePaused pauses or resumes the pair of the given tokens
+func (dao *PairDao) UpdatePaused(baseToken, quoteToken common.Address, paused bool) error {
+	return dao.pairs.update(byTokens(baseToken, quoteToken), func(d bson.M) {
+		d["paused"] = paused
+		d["updatedAt"] = time.Now()
+	})
+}
+
+// UpdateAuction saves the end and the reference pricepoint of the opening auction of the pair of
+// the given tokens
+func (dao *PairDao) UpdateAuction(baseToken, quoteToken common.Address, endsAt *time.Time, reference *big.Int) error {
+	ref := ""
+	if reference != nil {
+		ref = reference.String()
+	}
+
+	return dao.pairs.update(byTokens(baseToken, quoteToken), func(d bson.M) {
+		d["auctionEndsAt"] = nil
+		if endsAt != nil {
+			d["auctionEndsAt"] = *endsAt
+		}
+
+		d["auctionReferencePricePoint"] = ref
+		d["updatedAt"] = time.Now()
+	})
+}
+
+// UpdateActive lists or delists the pair of the given tokens
+func (dao *PairDao) UpdateActive(baseToken, quoteToken common.Address, active bool) error {
+	return dao.pairs.update(byTokens(baseToken, quoteToken), func(d bson.M) {
+		d["active"] = active
+		d["updatedAt"] = time.Now()
+	})
+}
+
+// Drop removes all the pairs
+func (dao *PairDao) Drop() {
+	dao.pairs.drop()
+}
+
+// byTokens returns a query matching the pair of the given base and quote tokens
+func byTokens(baseToken, quoteToken common.Address) func(bson.M) bool {
+	return func(d bson.M) bool {
+		return d["baseTokenAddress"] == baseToken.Hex() && d["quoteTokenAddress"] == quoteToken.Hex()
+	}
+}
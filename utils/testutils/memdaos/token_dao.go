@@ -0,0 +1,81 @@
+package memdaos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// TokenDao is an in-memory implementation of interfaces.TokenDao (see daos.TokenDao)
+type TokenDao struct {
+	tokens *collection
+}
+
+// NewTokenDao returns an empty TokenDao. There is a single token for a contract address.
+func NewTokenDao() *TokenDao {
+	return &TokenDao{newCollection([]string{"contractAddress"})}
+}
+
+// Create inserts a token
+func (dao *TokenDao) Create(t *types.Token) error {
+	err := t.Validate()
+	if err != nil {
+		return err
+	}
+
+	t.ID = bson.NewObjectId()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+
+	return dao.tokens.insert(t)
+}
+
+// GetAll returns all the tokens
+func (dao *TokenDao) GetAll() ([]types.Token, error) {
+	res := []types.Token{}
+	dao.tokens.find(func(bson.M) bool { return true }, 0, &res)
+	return res, nil
+}
+
+// GetQuoteTokens returns the quote tokens
+func (dao *TokenDao) GetQuoteTokens() ([]types.Token, error) {
+	res := []types.Token{}
+	dao.tokens.find(func(d bson.M) bool { return d["quote"] == true }, 0, &res)
+	return res, nil
+}
+
+// GetBaseTokens returns the tokens that are not quote tokens
+func (dao *TokenDao) GetBaseTokens() ([]types.Token, error) {
+	res := []types.Token{}
+	dao.tokens.find(func(d bson.M) bool { return d["quote"] == false }, 0, &res)
+	return res, nil
+}
+
+// GetByID returns the token with the given id. mgo.ErrNotFound is returned if there is none.
+func (dao *TokenDao) GetByID(id bson.ObjectId) (*types.Token, error) {
+	t := &types.Token{}
+	err := dao.tokens.findID(id, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// GetByAddress returns the token of the given contract address, or nil if there is none
+func (dao *TokenDao) GetByAddress(addr common.Address) (*types.Token, error) {
+	t := &types.Token{}
+	if !dao.tokens.findOne(func(d bson.M) bool { return d["contractAddress"] == addr.Hex() }, t) {
+		return nil, nil
+	}
+
+	return t, nil
+}
+
+// Drop removes all the tokens
+func (dao *TokenDao) Drop() error {
+	dao.tokens.drop()
+	return nil
+}
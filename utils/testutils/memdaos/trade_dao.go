@@ -0,0 +1,260 @@
+package memdaos
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// TradeDao is an in-memory implementation of interfaces.TradeDao (see daos.TradeDao)
+type TradeDao struct {
+	trades *collection
+}
+
+// NewTradeDao returns an empty TradeDao
+func NewTradeDao() *TradeDao {
+	return &TradeDao{newCollection()}
+}
+
+// Create inserts trades
+func (dao *TradeDao) Create(trades ...*types.Trade) error {
+	docs := []interface{}{}
+	for _, t := range trades {
+		t.ID = bson.NewObjectId()
+		t.CreatedAt = time.Now()
+		t.UpdatedAt = time.Now()
+		docs = append(docs, t)
+	}
+
+	return dao.trades.insert(docs...)
+}
+
+// CreateBatch inserts the trades whose hash is not stored yet, and returns a
+// types.TradeBatchErrors listing the other ones
+func (dao *TradeDao) CreateBatch(trades []*types.Trade) error {
+	errs := types.TradeBatchErrors{}
+	for _, t := range trades {
+		if stored, _ := dao.GetByHash(t.Hash); stored != nil {
+			errs.Add(t, types.ErrDuplicateTrade)
+			continue
+		}
+
+		err := dao.Create(t)
+		if err != nil {
+			errs.Add(t, err)
+		}
+	}
+
+	return errs.Err()
+}
+
+// Update replaces the trade with the id of t
+func (dao *TradeDao) Update(t *types.Trade) error {
+	t.UpdatedAt = time.Now()
+	return dao.trades.replace(func(d bson.M) bool { return d["_id"] == t.ID }, t)
+}
+
+// UpdateByHash updates the updateable fields of the trade with the given hash
+func (dao *TradeDao) UpdateByHash(hash common.Hash, t *types.Trade) error {
+	t.UpdatedAt = time.Now()
+	signature := encode(bson.M{"signature": t.Signature})["signature"]
+
+	return dao.trades.update(func(d bson.M) bool { return d["hash"] == hash.Hex() }, func(d bson.M) {
+		d["pricepoint"] = t.PricePoint.String()
+		d["tradeNonce"] = t.TradeNonce.String()
+		d["txHash"] = t.TxHash.String()
+		d["takerOrderHash"] = t.TakerOrderHash.String()
+		d["signature"] = signature
+		d["updatedAt"] = t.UpdatedAt
+	})
+}
+
+// GetAll returns all the trades
+func (dao *TradeDao) GetAll() ([]types.Trade, error) {
+	res := []types.Trade{}
+	dao.trades.find(func(bson.M) bool { return true }, 0, &res)
+	return res, nil
+}
+
+// GetOHLCV computes in memory the OHLCV ticks of the trades created between from (inclusive) and
+// to (exclusive), of the given pairs or of all the pairs if none is given (see types.ComputeTicks)
+func (dao *TradeDao) GetOHLCV(pairs []types.PairSubDoc, duration int64, unit string, from, to time.Time) ([]*types.Tick, error) {
+	match := func(d bson.M) bool {
+		createdAt := d["createdAt"].(time.Time)
+		if createdAt.Before(from) || !createdAt.Before(to) {
+			return false
+		}
+
+		if len(pairs) == 0 {
+			return true
+		}
+
+		for _, p := range pairs {
+			if d["baseToken"] == p.BaseToken.Hex() && d["quoteToken"] == p.QuoteToken.Hex() {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	res := []*types.Trade{}
+	dao.trades.sorted(match, "createdAt", true, 0, 0, &res)
+	return types.ComputeTicks(res, duration, unit), nil
+}
+
+// GetByPairName returns the trades whose pair name matches name, case insensitively
+func (dao *TradeDao) GetByPairName(name string) ([]*types.Trade, error) {
+	res := []*types.Trade{}
+	dao.trades.find(func(d bson.M) bool { return matchName(d["pairName"], name) }, 0, &res)
+	return res, nil
+}
+
+// GetByHash returns the first trade with the given hash, or nil if there is none
+func (dao *TradeDao) GetByHash(hash common.Hash) (*types.Trade, error) {
+	t := &types.Trade{}
+	if !dao.trades.findOne(func(d bson.M) bool { return d["hash"] == hash.Hex() }, t) {
+		return nil, nil
+	}
+
+	return t, nil
+}
+
+// GetByOrderHash returns the trades of the order with the given hash, as maker or taker, sorted by
+// creation time
+func (dao *TradeDao) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
+	return dao.GetByOrderHashes([]common.Hash{hash})
+}
+
+// GetByOrderHashes returns the trades of the orders with the given hashes, as maker or taker,
+// sorted by creation time
+func (dao *TradeDao) GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error) {
+	hexes := []string{}
+	for _, h := range hashes {
+		hexes = append(hexes, h.Hex())
+	}
+
+	res := []*types.Trade{}
+	match := func(d bson.M) bool {
+		return in(d["orderHash"], hexes...) || in(d["takerOrderHash"], hexes...)
+	}
+
+	dao.trades.sorted(match, "createdAt", true, 0, 0, &res)
+	return res, nil
+}
+
+// GetByPairAddress returns a page of the trades of a pair, the most recent first, along with the
+// cursor of the next page
+func (dao *TradeDao) GetByPairAddress(baseToken, quoteToken common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	match := func(d bson.M) bool {
+		return d["baseToken"] == baseToken.Hex() && d["quoteToken"] == quoteToken.Hex()
+	}
+
+	return dao.getPage(match, cursor, limit)
+}
+
+// GetByUserAddress returns a page of the trades of an account, as a maker or as a taker, the most
+// recent first, along with the cursor of the next page
+func (dao *TradeDao) GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	match := func(d bson.M) bool {
+		return d["maker"] == addr.Hex() || d["taker"] == addr.Hex()
+	}
+
+	return dao.getPage(match, cursor, limit)
+}
+
+func (dao *TradeDao) getPage(match func(bson.M) bool, cursor string, limit int) ([]*types.Trade, string, error) {
+	res := []*types.Trade{}
+	next, err := dao.trades.page(match, cursor, false, limit, &res)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return res, next, nil
+}
+
+// GetPendingByPairAddress returns the trades of a pair that are still being settled
+func (dao *TradeDao) GetPendingByPairAddress(baseToken, quoteToken common.Address) ([]*types.Trade, error) {
+	res := []*types.Trade{}
+	dao.trades.find(func(d bson.M) bool {
+		return d["baseToken"] == baseToken.Hex() && d["quoteToken"] == quoteToken.Hex() &&
+			in(d["status"], types.TradePendingStatuses...)
+	}, 0, &res)
+
+	return res, nil
+}
+
+// tradeIterator iterates over trades sorted in memory
+type tradeIterator struct {
+	trades []*types.Trade
+}
+
+// Next copies the next trade into t
+func (it *tradeIterator) Next(t *types.Trade) bool {
+	if len(it.trades) == 0 {
+		return false
+	}
+
+	*t, it.trades = *it.trades[0], it.trades[1:]
+	return true
+}
+
+// Close stops the iteration
+func (it *tradeIterator) Close() error {
+	it.trades = nil
+	return nil
+}
+
+// StreamByAddress returns an iterator over the trades of an account, as a maker or as a taker,
+// created between from (inclusive) and to (exclusive), by creation time. A zero from or to
+// leaves the range open on that side. The trades are all loaded in memory.
+func (dao *TradeDao) StreamByAddress(addr common.Address, from, to time.Time) types.TradeIterator {
+	match := func(d bson.M) bool {
+		createdAt := d["createdAt"].(time.Time)
+		if !from.IsZero() && createdAt.Before(from) || !to.IsZero() && !createdAt.Before(to) {
+			return false
+		}
+
+		return d["maker"] == addr.Hex() || d["taker"] == addr.Hex()
+	}
+
+	res := []*types.Trade{}
+	dao.trades.sorted(match, "createdAt", true, 0, 0, &res)
+	return &tradeIterator{res}
+}
+
+// UpdateTradeStatus moves the trade with the given hash to the given status, if the trade can move
+// to the status and its status did not change concurrently
+func (dao *TradeDao) UpdateTradeStatus(hash common.Hash, status string) error {
+	t, err := dao.GetByHash(hash)
+	if err != nil {
+		return err
+	}
+
+	if t == nil {
+		return errors.New("Trade not found")
+	}
+
+	current := t.Status
+	err = t.TransitionTo(status)
+	if err != nil {
+		return err
+	}
+
+	// the trades created before statuses were set have no status field
+	match := func(d bson.M) bool {
+		return d["hash"] == hash.Hex() && (d["status"] == current || current == "" && d["status"] == nil)
+	}
+
+	return dao.trades.update(match, func(d bson.M) {
+		d["status"] = status
+	})
+}
+
+// Drop removes all the trades
+func (dao *TradeDao) Drop() {
+	dao.trades.drop()
+}
@@ -0,0 +1,78 @@
+package memdaos
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// WalletDao is an in-memory implementation of interfaces.WalletDao (see daos.WalletDao). Private
+// keys are encrypted like in mongo, which requires the wallet encryption key to be configured.
+type WalletDao struct {
+	wallets *collection
+}
+
+// NewWalletDao returns an empty WalletDao
+func NewWalletDao() *WalletDao {
+	return &WalletDao{newCollection()}
+}
+
+// Create inserts a wallet. Wallets without a private key are rejected.
+func (dao *WalletDao) Create(w *types.Wallet) error {
+	err := w.Validate()
+	if err != nil {
+		return err
+	}
+
+	w.ID = bson.NewObjectId()
+	return dao.wallets.insert(w)
+}
+
+// GetAll returns all the wallets
+func (dao *WalletDao) GetAll() ([]types.Wallet, error) {
+	res := []types.Wallet{}
+	dao.wallets.find(func(bson.M) bool { return true }, 0, &res)
+	return res, nil
+}
+
+// GetByID returns the wallet with the given id. mgo.ErrNotFound is returned if there is none.
+func (dao *WalletDao) GetByID(id bson.ObjectId) (*types.Wallet, error) {
+	w := &types.Wallet{}
+	err := dao.wallets.findID(id, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// GetByAddress returns the wallet of the given address, or nil if there is none
+func (dao *WalletDao) GetByAddress(addr common.Address) (*types.Wallet, error) {
+	w := &types.Wallet{}
+	if !dao.wallets.findOne(byAddress(addr), w) {
+		return nil, nil
+	}
+
+	return w, nil
+}
+
+// GetDefaultAdminWallet returns the first admin wallet, or nil if there is none
+func (dao *WalletDao) GetDefaultAdminWallet() (*types.Wallet, error) {
+	w := &types.Wallet{}
+	if !dao.wallets.findOne(func(d bson.M) bool { return d["admin"] == true }, w) {
+		return nil, nil
+	}
+
+	return w, nil
+}
+
+// GetOperatorWallets returns the operator wallets. Like with mongo, a single wallet is returned.
+func (dao *WalletDao) GetOperatorWallets() ([]*types.Wallet, error) {
+	res := []*types.Wallet{}
+	dao.wallets.find(func(d bson.M) bool { return d["operator"] == true }, 1, &res)
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res, nil
+}
@@ -14,6 +14,27 @@ type AccountDao struct {
 	mock.Mock
 }
 
+// ConsumeOrderNonce provides a mock function with given fields: owner, nonce
+func (_m *AccountDao) ConsumeOrderNonce(owner common.Address, nonce *big.Int) (bool, error) {
+	ret := _m.Called(owner, nonce)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(common.Address, *big.Int) bool); ok {
+		r0 = rf(owner, nonce)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, *big.Int) error); ok {
+		r1 = rf(owner, nonce)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: account
 func (_m *AccountDao) Create(account *types.Account) error {
 	ret := _m.Called(account)
@@ -102,6 +123,29 @@ func (_m *AccountDao) GetByID(id bson.ObjectId) (*types.Account, error) {
 	return r0, r1
 }
 
+// GetOrderNonce provides a mock function with given fields: owner
+func (_m *AccountDao) GetOrderNonce(owner common.Address) (*big.Int, error) {
+	ret := _m.Called(owner)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(common.Address) *big.Int); ok {
+		r0 = rf(owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
+		r1 = rf(owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTokenBalance provides a mock function with given fields: owner, token
 func (_m *AccountDao) GetTokenBalance(owner common.Address, token common.Address) (*types.TokenBalance, error) {
 	ret := _m.Called(owner, token)
@@ -148,6 +192,75 @@ func (_m *AccountDao) GetTokenBalances(owner common.Address) (map[common.Address
 	return r0, r1
 }
 
+// LockBalance provides a mock function with given fields: owner, token, amount
+func (_m *AccountDao) LockBalance(owner common.Address, token common.Address, amount *big.Int) (*types.BalanceChange, error) {
+	ret := _m.Called(owner, token, amount)
+
+	var r0 *types.BalanceChange
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, *big.Int) *types.BalanceChange); ok {
+		r0 = rf(owner, token, amount)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.BalanceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, *big.Int) error); ok {
+		r1 = rf(owner, token, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransferLocked provides a mock function with given fields: owner, sellToken, buyToken, spent, received
+func (_m *AccountDao) TransferLocked(owner common.Address, sellToken common.Address, buyToken common.Address, spent *big.Int, received *big.Int) ([]*types.BalanceChange, error) {
+	ret := _m.Called(owner, sellToken, buyToken, spent, received)
+
+	var r0 []*types.BalanceChange
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, common.Address, *big.Int, *big.Int) []*types.BalanceChange); ok {
+		r0 = rf(owner, sellToken, buyToken, spent, received)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.BalanceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, common.Address, *big.Int, *big.Int) error); ok {
+		r1 = rf(owner, sellToken, buyToken, spent, received)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnlockBalance provides a mock function with given fields: owner, token, amount
+func (_m *AccountDao) UnlockBalance(owner common.Address, token common.Address, amount *big.Int) (*types.BalanceChange, error) {
+	ret := _m.Called(owner, token, amount)
+
+	var r0 *types.BalanceChange
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, *big.Int) *types.BalanceChange); ok {
+		r0 = rf(owner, token, amount)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.BalanceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, *big.Int) error); ok {
+		r1 = rf(owner, token, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateAllowance provides a mock function with given fields: owner, token, allowance
 func (_m *AccountDao) UpdateAllowance(owner common.Address, token common.Address, allowance *big.Int) error {
 	ret := _m.Called(owner, token, allowance)
@@ -176,6 +289,34 @@ func (_m *AccountDao) UpdateBalance(owner common.Address, token common.Address,
 	return r0
 }
 
+// UpdateBlocked provides a mock function with given fields: owner, blocked
+func (_m *AccountDao) UpdateBlocked(owner common.Address, blocked bool) error {
+	ret := _m.Called(owner, blocked)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Address, bool) error); ok {
+		r0 = rf(owner, blocked)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOpenOrderLimits provides a mock function with given fields: owner, limits
+func (_m *AccountDao) UpdateOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) error {
+	ret := _m.Called(owner, limits)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Address, *types.OpenOrderLimits) error); ok {
+		r0 = rf(owner, limits)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateTokenBalance provides a mock function with given fields: owner, token, tokenBalance
 func (_m *AccountDao) UpdateTokenBalance(owner common.Address, token common.Address, tokenBalance *types.TokenBalance) error {
 	ret := _m.Called(owner, token, tokenBalance)
@@ -189,3 +330,33 @@ func (_m *AccountDao) UpdateTokenBalance(owner common.Address, token common.Addr
 
 	return r0
 }
+
+// UpdateTokenBalances provides a mock function with given fields: owner, fn, tokens
+func (_m *AccountDao) UpdateTokenBalances(owner common.Address, fn func(*types.Account) ([]*types.BalanceChange, error), tokens ...common.Address) ([]*types.BalanceChange, error) {
+	_va := make([]interface{}, len(tokens))
+	for _i := range tokens {
+		_va[_i] = tokens[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, owner, fn)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []*types.BalanceChange
+	if rf, ok := ret.Get(0).(func(common.Address, func(*types.Account) ([]*types.BalanceChange, error), ...common.Address) []*types.BalanceChange); ok {
+		r0 = rf(owner, fn, tokens...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.BalanceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, func(*types.Account) ([]*types.BalanceChange, error), ...common.Address) error); ok {
+		r1 = rf(owner, fn, tokens...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
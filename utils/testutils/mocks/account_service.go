@@ -2,6 +2,7 @@
 
 package mocks
 
+import big "math/big"
 import bson "gopkg.in/mgo.v2/bson"
 import common "github.com/ethereum/go-ethereum/common"
 import mock "github.com/stretchr/testify/mock"
@@ -96,6 +97,75 @@ func (_m *AccountService) GetByID(id bson.ObjectId) (*types.Account, error) {
 	return r0, r1
 }
 
+// GetLedger provides a mock function with given fields: owner, offset, limit
+func (_m *AccountService) GetLedger(owner common.Address, offset int, limit int) ([]*types.BalanceChange, error) {
+	ret := _m.Called(owner, offset, limit)
+
+	var r0 []*types.BalanceChange
+	if rf, ok := ret.Get(0).(func(common.Address, int, int) []*types.BalanceChange); ok {
+		r0 = rf(owner, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.BalanceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, int, int) error); ok {
+		r1 = rf(owner, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNextOrderNonce provides a mock function with given fields: owner
+func (_m *AccountService) GetNextOrderNonce(owner common.Address) (*big.Int, error) {
+	ret := _m.Called(owner)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(common.Address) *big.Int); ok {
+		r0 = rf(owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
+		r1 = rf(owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOpenOrderCounts provides a mock function with given fields: owner
+func (_m *AccountService) GetOpenOrderCounts(owner common.Address) (*types.OpenOrderCounts, error) {
+	ret := _m.Called(owner)
+
+	var r0 *types.OpenOrderCounts
+	if rf, ok := ret.Get(0).(func(common.Address) *types.OpenOrderCounts); ok {
+		r0 = rf(owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.OpenOrderCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
+		r1 = rf(owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTokenBalance provides a mock function with given fields: owner, token
 func (_m *AccountService) GetTokenBalance(owner common.Address, token common.Address) (*types.TokenBalance, error) {
 	ret := _m.Called(owner, token)
@@ -141,3 +211,26 @@ func (_m *AccountService) GetTokenBalances(owner common.Address) (map[common.Add
 
 	return r0, r1
 }
+
+// SetOpenOrderLimits provides a mock function with given fields: owner, limits
+func (_m *AccountService) SetOpenOrderLimits(owner common.Address, limits *types.OpenOrderLimits) (*types.OpenOrderCounts, error) {
+	ret := _m.Called(owner, limits)
+
+	var r0 *types.OpenOrderCounts
+	if rf, ok := ret.Get(0).(func(common.Address, *types.OpenOrderLimits) *types.OpenOrderCounts); ok {
+		r0 = rf(owner, limits)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.OpenOrderCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, *types.OpenOrderLimits) error); ok {
+		r1 = rf(owner, limits)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import big "math/big"
+import common "github.com/ethereum/go-ethereum/common"
 
 import mock "github.com/stretchr/testify/mock"
 import rabbitmq "github.com/Proofsuite/amp-matching-engine/rabbitmq"
@@ -13,6 +14,52 @@ type Engine struct {
 	mock.Mock
 }
 
+// AuditSnapshots provides a mock function with given fields:
+func (_m *Engine) AuditSnapshots() ([]*types.AuditSnapshot, error) {
+	ret := _m.Called()
+
+	var r0 []*types.AuditSnapshot
+	if rf, ok := ret.Get(0).(func() []*types.AuditSnapshot); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.AuditSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelAllOrders provides a mock function with given fields: maker, pairName
+func (_m *Engine) CancelAllOrders(maker common.Address, pairName string) ([]*types.EngineResponse, error) {
+	ret := _m.Called(maker, pairName)
+
+	var r0 []*types.EngineResponse
+	if rf, ok := ret.Get(0).(func(common.Address, string) []*types.EngineResponse); ok {
+		r0 = rf(maker, pairName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.EngineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, string) error); ok {
+		r1 = rf(maker, pairName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CancelOrder provides a mock function with given fields: order
 func (_m *Engine) CancelOrder(order *types.Order) (*types.EngineResponse, error) {
 	ret := _m.Called(order)
@@ -36,6 +83,52 @@ func (_m *Engine) CancelOrder(order *types.Order) (*types.EngineResponse, error)
 	return r0, r1
 }
 
+// CancelOrdersByHash provides a mock function with given fields: addr, hashes
+func (_m *Engine) CancelOrdersByHash(addr common.Address, hashes []common.Hash) ([]*types.Order, error) {
+	ret := _m.Called(addr, hashes)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address, []common.Hash) []*types.Order); ok {
+		r0 = rf(addr, hashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, []common.Hash) error); ok {
+		r1 = rf(addr, hashes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrdersForAddress provides a mock function with given fields: addr, p
+func (_m *Engine) CancelOrdersForAddress(addr common.Address, p *types.Pair) ([]*types.Order, error) {
+	ret := _m.Called(addr, p)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address, *types.Pair) []*types.Order); ok {
+		r0 = rf(addr, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, *types.Pair) error); ok {
+		r1 = rf(addr, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CancelTrades provides a mock function with given fields: orders, amount
 func (_m *Engine) CancelTrades(orders []*types.Order, amount []*big.Int) error {
 	ret := _m.Called(orders, amount)
@@ -84,31 +177,50 @@ func (_m *Engine) DeleteOrders(orders ...types.Order) error {
 	return r0
 }
 
-// GetOrderBook provides a mock function with given fields: pair
-func (_m *Engine) GetOrderBook(pair *types.Pair) ([]*map[string]float64, []*map[string]float64, error) {
-	ret := _m.Called(pair)
+// GetOrderBook provides a mock function with given fields: p
+func (_m *Engine) GetOrderBook(p *types.Pair) (*types.OrderBook, error) {
+	ret := _m.Called(p)
 
-	var r0 []*map[string]float64
-	if rf, ok := ret.Get(0).(func(*types.Pair) []*map[string]float64); ok {
-		r0 = rf(pair)
+	var r0 *types.OrderBook
+	if rf, ok := ret.Get(0).(func(*types.Pair) *types.OrderBook); ok {
+		r0 = rf(p)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*map[string]float64)
+			r0 = ret.Get(0).(*types.OrderBook)
 		}
 	}
 
-	var r1 []*map[string]float64
-	if rf, ok := ret.Get(1).(func(*types.Pair) []*map[string]float64); ok {
-		r1 = rf(pair)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
 	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).([]*map[string]float64)
-		}
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderBookChecksum provides a mock function with given fields: p
+func (_m *Engine) GetOrderBookChecksum(p *types.Pair) (uint64, uint32, error) {
+	ret := _m.Called(p)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(*types.Pair) uint64); ok {
+		r0 = rf(p)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 uint32
+	if rf, ok := ret.Get(1).(func(*types.Pair) uint32); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Get(1).(uint32)
 	}
 
 	var r2 error
 	if rf, ok := ret.Get(2).(func(*types.Pair) error); ok {
-		r2 = rf(pair)
+		r2 = rf(p)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -116,6 +228,52 @@ func (_m *Engine) GetOrderBook(pair *types.Pair) ([]*map[string]float64, []*map[
 	return r0, r1, r2
 }
 
+// Ticker provides a mock function with given fields: p
+func (_m *Engine) Ticker(p *types.Pair) (*types.Ticker, error) {
+	ret := _m.Called(p)
+
+	var r0 *types.Ticker
+	if rf, ok := ret.Get(0).(func(*types.Pair) *types.Ticker); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Ticker)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderBookDiffs provides a mock function with given fields: p, from
+func (_m *Engine) GetOrderBookDiffs(p *types.Pair, from uint64) ([]*types.OrderBookDiff, error) {
+	ret := _m.Called(p, from)
+
+	var r0 []*types.OrderBookDiff
+	if rf, ok := ret.Get(0).(func(*types.Pair, uint64) []*types.OrderBookDiff); ok {
+		r0 = rf(p, from)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.OrderBookDiff)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair, uint64) error); ok {
+		r1 = rf(p, from)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRawOrderBook provides a mock function with given fields: pair
 func (_m *Engine) GetRawOrderBook(pair *types.Pair) ([][]types.Order, error) {
 	ret := _m.Called(pair)
@@ -153,6 +311,52 @@ func (_m *Engine) HandleOrders(msg *rabbitmq.Message) error {
 	return r0
 }
 
+// NewOrders provides a mock function with given fields: orders
+func (_m *Engine) NewOrders(orders []*types.Order) ([]*types.EngineResponse, error) {
+	ret := _m.Called(orders)
+
+	var r0 []*types.EngineResponse
+	if rf, ok := ret.Get(0).(func([]*types.Order) []*types.EngineResponse); ok {
+		r0 = rf(orders)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.EngineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]*types.Order) error); ok {
+		r1 = rf(orders)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PausePair provides a mock function with given fields: p
+func (_m *Engine) PausePair(p *types.Pair) (*types.PairStatus, error) {
+	ret := _m.Called(p)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(*types.Pair) *types.PairStatus); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RecoverOrders provides a mock function with given fields: orders
 func (_m *Engine) RecoverOrders(orders []*types.OrderTradePair) error {
 	ret := _m.Called(orders)
@@ -166,3 +370,224 @@ func (_m *Engine) RecoverOrders(orders []*types.OrderTradePair) error {
 
 	return r0
 }
+
+// ReduceOrder provides a mock function with given fields: o, amount
+func (_m *Engine) ReduceOrder(o *types.Order, amount *big.Int) (*types.EngineResponse, error) {
+	ret := _m.Called(o, amount)
+
+	var r0 *types.EngineResponse
+	if rf, ok := ret.Get(0).(func(*types.Order, *big.Int) *types.EngineResponse); ok {
+		r0 = rf(o, amount)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.EngineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Order, *big.Int) error); ok {
+		r1 = rf(o, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceOrder provides a mock function with given fields: old, o, hashID
+func (_m *Engine) ReplaceOrder(old *types.Order, o *types.Order, hashID common.Hash) (*types.EngineResponse, error) {
+	ret := _m.Called(old, o, hashID)
+
+	var r0 *types.EngineResponse
+	if rf, ok := ret.Get(0).(func(*types.Order, *types.Order, common.Hash) *types.EngineResponse); ok {
+		r0 = rf(old, o, hashID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.EngineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Order, *types.Order, common.Hash) error); ok {
+		r1 = rf(old, o, hashID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreFailedTrade provides a mock function with given fields: orders, amount
+func (_m *Engine) RestoreFailedTrade(orders []*types.Order, amount *big.Int) ([]*types.Order, error) {
+	ret := _m.Called(orders, amount)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func([]*types.Order, *big.Int) []*types.Order); ok {
+		r0 = rf(orders, amount)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]*types.Order, *big.Int) error); ok {
+		r1 = rf(orders, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SimulateOrder provides a mock function with given fields: o
+func (_m *Engine) SimulateOrder(o *types.Order) (*types.MatchPreview, error) {
+	ret := _m.Called(o)
+
+	var r0 *types.MatchPreview
+	if rf, ok := ret.Get(0).(func(*types.Order) *types.MatchPreview); ok {
+		r0 = rf(o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.MatchPreview)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Order) error); ok {
+		r1 = rf(o)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stats provides a mock function with given fields:
+func (_m *Engine) Stats() *types.EngineStats {
+	ret := _m.Called()
+
+	var r0 *types.EngineStats
+	if rf, ok := ret.Get(0).(func() *types.EngineStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.EngineStats)
+		}
+	}
+
+	return r0
+}
+
+// StartAuction provides a mock function with given fields: p
+func (_m *Engine) StartAuction(p *types.Pair) (*types.PairStatus, error) {
+	ret := _m.Called(p)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(*types.Pair) *types.PairStatus); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderPosition provides a mock function with given fields: h
+func (_m *Engine) GetOrderPosition(h common.Hash) (int, *big.Int, error) {
+	ret := _m.Called(h)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(common.Hash) int); ok {
+		r0 = rf(h)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 *big.Int
+	if rf, ok := ret.Get(1).(func(common.Hash) *big.Int); ok {
+		r1 = rf(h)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*big.Int)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Hash) error); ok {
+		r2 = rf(h)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetPairStatus provides a mock function with given fields: p
+func (_m *Engine) GetPairStatus(p *types.Pair) (*types.PairStatus, error) {
+	ret := _m.Called(p)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(*types.Pair) *types.PairStatus); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResumePair provides a mock function with given fields: p
+func (_m *Engine) ResumePair(p *types.Pair) (*types.PairStatus, error) {
+	ret := _m.Called(p)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(*types.Pair) *types.PairStatus); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePair provides a mock function with given fields: p
+func (_m *Engine) UpdatePair(p *types.Pair) error {
+	ret := _m.Called(p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*types.Pair) error); ok {
+		r0 = rf(p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -7,6 +7,7 @@ import bson "gopkg.in/mgo.v2/bson"
 import common "github.com/ethereum/go-ethereum/common"
 
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import types "github.com/Proofsuite/amp-matching-engine/types"
 
 // OrderDao is an autogenerated mock type for the OrderDao type
@@ -14,6 +15,27 @@ type OrderDao struct {
 	mock.Mock
 }
 
+// ArchiveOrders provides a mock function with given fields: before, batches
+func (_m *OrderDao) ArchiveOrders(before time.Time, batches int) (int, error) {
+	ret := _m.Called(before, batches)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time, int) int); ok {
+		r0 = rf(before, batches)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, int) error); ok {
+		r1 = rf(before, batches)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: o
 func (_m *OrderDao) Create(o *types.Order) error {
 	ret := _m.Called(o)
@@ -28,6 +50,27 @@ func (_m *OrderDao) Create(o *types.Order) error {
 	return r0
 }
 
+// DeleteOrdersBefore provides a mock function with given fields: status, before, batches
+func (_m *OrderDao) DeleteOrdersBefore(status string, before time.Time, batches int) (int, error) {
+	ret := _m.Called(status, before, batches)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) int); ok {
+		r0 = rf(status, before, batches)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time, int) error); ok {
+		r1 = rf(status, before, batches)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Drop provides a mock function with given fields:
 func (_m *OrderDao) Drop() error {
 	ret := _m.Called()
@@ -111,19 +154,109 @@ func (_m *OrderDao) GetByID(id bson.ObjectId) (*types.Order, error) {
 	return r0, r1
 }
 
-// GetByUserAddress provides a mock function with given fields: addr
-func (_m *OrderDao) GetByUserAddress(addr common.Address) ([]*types.Order, error) {
-	ret := _m.Called(addr)
+// GetByUserAddress provides a mock function with given fields: addr, q
+func (_m *OrderDao) GetByUserAddress(addr common.Address, q types.OrderQuery) ([]*types.Order, string, error) {
+	ret := _m.Called(addr, q)
 
 	var r0 []*types.Order
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(common.Address, types.OrderQuery) []*types.Order); ok {
+		r0 = rf(addr, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, types.OrderQuery) string); ok {
+		r1 = rf(addr, q)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, types.OrderQuery) error); ok {
+		r2 = rf(addr, q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetCurrentByUserAddress provides a mock function with given fields: addr, cursor, limit
+func (_m *OrderDao) GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	ret := _m.Called(addr, cursor, limit)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address, string, int) []*types.Order); ok {
+		r0 = rf(addr, cursor, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Order)
 		}
 	}
 
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, string, int) string); ok {
+		r1 = rf(addr, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, string, int) error); ok {
+		r2 = rf(addr, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetHistoryByUserAddress provides a mock function with given fields: addr, cursor, limit
+func (_m *OrderDao) GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	ret := _m.Called(addr, cursor, limit)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address, string, int) []*types.Order); ok {
+		r0 = rf(addr, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, string, int) string); ok {
+		r1 = rf(addr, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, string, int) error); ok {
+		r2 = rf(addr, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetOpenOrderCounts provides a mock function with given fields: addr
+func (_m *OrderDao) GetOpenOrderCounts(addr common.Address) (map[string]int, error) {
+	ret := _m.Called(addr)
+
+	var r0 map[string]int
+	if rf, ok := ret.Get(0).(func(common.Address) map[string]int); ok {
+		r0 = rf(addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
 		r1 = rf(addr)
@@ -134,22 +267,54 @@ func (_m *OrderDao) GetByUserAddress(addr common.Address) ([]*types.Order, error
 	return r0, r1
 }
 
-// GetCurrentByUserAddress provides a mock function with given fields: addr
-func (_m *OrderDao) GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error) {
-	ret := _m.Called(addr)
+// GetOrderBook provides a mock function with given fields: _a0
+func (_m *OrderDao) GetOrderBook(_a0 *types.Pair) ([]map[string]string, []map[string]string, error) {
+	ret := _m.Called(_a0)
 
-	var r0 []*types.Order
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
-		r0 = rf(addr)
+	var r0 []map[string]string
+	if rf, ok := ret.Get(0).(func(*types.Pair) []map[string]string); ok {
+		r0 = rf(_a0)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*types.Order)
+			r0 = ret.Get(0).([]map[string]string)
+		}
+	}
+
+	var r1 []map[string]string
+	if rf, ok := ret.Get(1).(func(*types.Pair) []map[string]string); ok {
+		r1 = rf(_a0)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]map[string]string)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*types.Pair) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetOrderBookPricePoint provides a mock function with given fields: p, pp
+func (_m *OrderDao) GetOrderBookPricePoint(p *types.Pair, pp *big.Int) (*big.Int, error) {
+	ret := _m.Called(p, pp)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(*types.Pair, *big.Int) *big.Int); ok {
+		r0 = rf(p, pp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	if rf, ok := ret.Get(1).(func(*types.Pair, *big.Int) error); ok {
+		r1 = rf(p, pp)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -157,13 +322,13 @@ func (_m *OrderDao) GetCurrentByUserAddress(addr common.Address) ([]*types.Order
 	return r0, r1
 }
 
-// GetHistoryByUserAddress provides a mock function with given fields: addr
-func (_m *OrderDao) GetHistoryByUserAddress(addr common.Address) ([]*types.Order, error) {
-	ret := _m.Called(addr)
+// GetRawOrderBook provides a mock function with given fields: _a0
+func (_m *OrderDao) GetRawOrderBook(_a0 *types.Pair) ([]*types.Order, error) {
+	ret := _m.Called(_a0)
 
 	var r0 []*types.Order
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(*types.Pair) []*types.Order); ok {
+		r0 = rf(_a0)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Order)
@@ -171,8 +336,8 @@ func (_m *OrderDao) GetHistoryByUserAddress(addr common.Address) ([]*types.Order
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -259,6 +424,20 @@ func (_m *OrderDao) UpdateOrderFilledAmount(hash common.Hash, value *big.Int) er
 	return r0
 }
 
+// UpdateOrderAmount provides a mock function with given fields: hash, amount
+func (_m *OrderDao) UpdateOrderAmount(hash common.Hash, amount *big.Int) error {
+	ret := _m.Called(hash, amount)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Hash, *big.Int) error); ok {
+		r0 = rf(hash, amount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateOrderStatus provides a mock function with given fields: hash, status
 func (_m *OrderDao) UpdateOrderStatus(hash common.Hash, status string) error {
 	ret := _m.Called(hash, status)
@@ -272,3 +451,17 @@ func (_m *OrderDao) UpdateOrderStatus(hash common.Hash, status string) error {
 
 	return r0
 }
+
+// UpsertByHash provides a mock function with given fields: o
+func (_m *OrderDao) UpsertByHash(o *types.Order) error {
+	ret := _m.Called(o)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*types.Order) error); ok {
+		r0 = rf(o)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -13,6 +13,43 @@ type OrderService struct {
 	mock.Mock
 }
 
+// AuthenticateSession provides a mock function with given fields: sa
+func (_m *OrderService) AuthenticateSession(sa *types.SessionAuth) error {
+	ret := _m.Called(sa)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*types.SessionAuth) error); ok {
+		r0 = rf(sa)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelAllOrders provides a mock function with given fields: ca
+func (_m *OrderService) CancelAllOrders(ca *types.CancelAllOrders) ([]*types.Order, error) {
+	ret := _m.Called(ca)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(*types.CancelAllOrders) []*types.Order); ok {
+		r0 = rf(ca)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.CancelAllOrders) error); ok {
+		r1 = rf(ca)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CancelOrder provides a mock function with given fields: oc
 func (_m *OrderService) CancelOrder(oc *types.OrderCancel) error {
 	ret := _m.Called(oc)
@@ -27,6 +64,52 @@ func (_m *OrderService) CancelOrder(oc *types.OrderCancel) error {
 	return r0
 }
 
+// CancelOrdersByHash provides a mock function with given fields: addr, hashes
+func (_m *OrderService) CancelOrdersByHash(addr common.Address, hashes []common.Hash) ([]*types.Order, error) {
+	ret := _m.Called(addr, hashes)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address, []common.Hash) []*types.Order); ok {
+		r0 = rf(addr, hashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, []common.Hash) error); ok {
+		r1 = rf(addr, hashes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrdersForAddress provides a mock function with given fields: addr, p
+func (_m *OrderService) CancelOrdersForAddress(addr common.Address, p *types.Pair) ([]*types.Order, error) {
+	ret := _m.Called(addr, p)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address, *types.Pair) []*types.Order); ok {
+		r0 = rf(addr, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, *types.Pair) error); ok {
+		r1 = rf(addr, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CancelTrades provides a mock function with given fields: trades
 func (_m *OrderService) CancelTrades(trades []*types.Trade) error {
 	ret := _m.Called(trades)
@@ -41,6 +124,29 @@ func (_m *OrderService) CancelTrades(trades []*types.Trade) error {
 	return r0
 }
 
+// FreezeAccount provides a mock function with given fields: addr
+func (_m *OrderService) FreezeAccount(addr common.Address) ([]*types.Order, error) {
+	ret := _m.Called(addr)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
+		r0 = rf(addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
+		r1 = rf(addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByHash provides a mock function with given fields: hash
 func (_m *OrderService) GetByHash(hash common.Hash) (*types.Order, error) {
 	ret := _m.Called(hash)
@@ -87,73 +193,94 @@ func (_m *OrderService) GetByID(id bson.ObjectId) (*types.Order, error) {
 	return r0, r1
 }
 
-// GetByUserAddress provides a mock function with given fields: addr
-func (_m *OrderService) GetByUserAddress(addr common.Address) ([]*types.Order, error) {
-	ret := _m.Called(addr)
+// GetByUserAddress provides a mock function with given fields: addr, q
+func (_m *OrderService) GetByUserAddress(addr common.Address, q types.OrderQuery) ([]*types.Order, string, error) {
+	ret := _m.Called(addr, q)
 
 	var r0 []*types.Order
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(common.Address, types.OrderQuery) []*types.Order); ok {
+		r0 = rf(addr, q)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Order)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, types.OrderQuery) string); ok {
+		r1 = rf(addr, q)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(string)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, types.OrderQuery) error); ok {
+		r2 = rf(addr, q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// GetCurrentByUserAddress provides a mock function with given fields: addr
-func (_m *OrderService) GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error) {
-	ret := _m.Called(addr)
+// GetCurrentByUserAddress provides a mock function with given fields: addr, cursor, limit
+func (_m *OrderService) GetCurrentByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	ret := _m.Called(addr, cursor, limit)
 
 	var r0 []*types.Order
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(common.Address, string, int) []*types.Order); ok {
+		r0 = rf(addr, cursor, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Order)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, string, int) string); ok {
+		r1 = rf(addr, cursor, limit)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(string)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, string, int) error); ok {
+		r2 = rf(addr, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// GetHistoryByUserAddress provides a mock function with given fields: addr
-func (_m *OrderService) GetHistoryByUserAddress(addr common.Address) ([]*types.Order, error) {
-	ret := _m.Called(addr)
+// GetHistoryByUserAddress provides a mock function with given fields: addr, cursor, limit
+func (_m *OrderService) GetHistoryByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Order, string, error) {
+	ret := _m.Called(addr, cursor, limit)
 
 	var r0 []*types.Order
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Order); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(common.Address, string, int) []*types.Order); ok {
+		r0 = rf(addr, cursor, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Order)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, string, int) string); ok {
+		r1 = rf(addr, cursor, limit)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(string)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, string, int) error); ok {
+		r2 = rf(addr, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // HandleEngineResponse provides a mock function with given fields: res
@@ -184,6 +311,29 @@ func (_m *OrderService) NewOrder(o *types.Order) error {
 	return r0
 }
 
+// NewOrders provides a mock function with given fields: orders
+func (_m *OrderService) NewOrders(orders []*types.Order) ([]*types.EngineResponse, error) {
+	ret := _m.Called(orders)
+
+	var r0 []*types.EngineResponse
+	if rf, ok := ret.Get(0).(func([]*types.Order) []*types.EngineResponse); ok {
+		r0 = rf(orders)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.EngineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]*types.Order) error); ok {
+		r1 = rf(orders)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RelayOrderUpdate provides a mock function with given fields: res
 func (_m *OrderService) RelayOrderUpdate(res *types.EngineResponse) {
 	_m.Called(res)
@@ -199,6 +349,52 @@ func (_m *OrderService) RelayUpdateOverSocket(res *types.EngineResponse) {
 	_m.Called(res)
 }
 
+// ReduceOrder provides a mock function with given fields: or
+func (_m *OrderService) ReduceOrder(or *types.OrderReduce) (*types.Order, error) {
+	ret := _m.Called(or)
+
+	var r0 *types.Order
+	if rf, ok := ret.Get(0).(func(*types.OrderReduce) *types.Order); ok {
+		r0 = rf(or)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.OrderReduce) error); ok {
+		r1 = rf(or)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceOrder provides a mock function with given fields: or
+func (_m *OrderService) ReplaceOrder(or *types.OrderReplace) (*types.Order, error) {
+	ret := _m.Called(or)
+
+	var r0 *types.Order
+	if rf, ok := ret.Get(0).(func(*types.OrderReplace) *types.Order); ok {
+		r0 = rf(or)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.OrderReplace) error); ok {
+		r1 = rf(or)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Rollback provides a mock function with given fields: res
 func (_m *OrderService) Rollback(res *types.EngineResponse) *types.EngineResponse {
 	ret := _m.Called(res)
@@ -242,3 +438,26 @@ func (_m *OrderService) RollbackTrade(o *types.Order, t *types.Trade) error {
 
 	return r0
 }
+
+// SimulateOrder provides a mock function with given fields: o
+func (_m *OrderService) SimulateOrder(o *types.Order) (*types.MatchPreview, error) {
+	ret := _m.Called(o)
+
+	var r0 *types.MatchPreview
+	if rf, ok := ret.Get(0).(func(*types.Order) *types.MatchPreview); ok {
+		r0 = rf(o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.MatchPreview)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Order) error); ok {
+		r1 = rf(o)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	types "github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/ws"
 	common "github.com/ethereum/go-ethereum/common"
 	mock "github.com/stretchr/testify/mock"
@@ -13,16 +14,78 @@ type OrderBookService struct {
 	mock.Mock
 }
 
+// GetEngineStats provides a mock function with given fields:
+func (_m *OrderBookService) GetEngineStats() *types.EngineStats {
+	ret := _m.Called()
+
+	var r0 *types.EngineStats
+	if rf, ok := ret.Get(0).(func() *types.EngineStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.EngineStats)
+		}
+	}
+
+	return r0
+}
+
+// GetGroupedOrderBook provides a mock function with given fields: bt, qt, decimals
+func (_m *OrderBookService) GetGroupedOrderBook(bt common.Address, qt common.Address, decimals int) (*types.OrderBook, error) {
+	ret := _m.Called(bt, qt, decimals)
+
+	var r0 *types.OrderBook
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, int) *types.OrderBook); ok {
+		r0 = rf(bt, qt, decimals)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.OrderBook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, int) error); ok {
+		r1 = rf(bt, qt, decimals)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrderBook provides a mock function with given fields: bt, qt
-func (_m *OrderBookService) GetOrderBook(bt common.Address, qt common.Address) (map[string]interface{}, error) {
+func (_m *OrderBookService) GetOrderBook(bt common.Address, qt common.Address) (*types.OrderBook, error) {
+	ret := _m.Called(bt, qt)
+
+	var r0 *types.OrderBook
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) *types.OrderBook); ok {
+		r0 = rf(bt, qt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.OrderBook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
+		r1 = rf(bt, qt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderBookChecksum provides a mock function with given fields: bt, qt
+func (_m *OrderBookService) GetOrderBookChecksum(bt common.Address, qt common.Address) (*types.OrderBookChecksum, error) {
 	ret := _m.Called(bt, qt)
 
-	var r0 map[string]interface{}
-	if rf, ok := ret.Get(0).(func(common.Address, common.Address) map[string]interface{}); ok {
+	var r0 *types.OrderBookChecksum
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) *types.OrderBookChecksum); ok {
 		r0 = rf(bt, qt)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string]interface{})
+			r0 = ret.Get(0).(*types.OrderBookChecksum)
 		}
 	}
 
@@ -36,11 +99,26 @@ func (_m *OrderBookService) GetOrderBook(bt common.Address, qt common.Address) (
 	return r0, r1
 }
 
+// ReplayOrderBookDiffs provides a mock function with given fields: conn, bt, qt, from
+func (_m *OrderBookService) ReplayOrderBookDiffs(conn *ws.Conn, bt common.Address, qt common.Address, from uint64) {
+	_m.Called(conn, bt, qt, from)
+}
+
 // Subscribe provides a mock function with given fields: conn, bt, qt
 func (_m *OrderBookService) Subscribe(conn *ws.Conn, bt common.Address, qt common.Address) {
 	_m.Called(conn, bt, qt)
 }
 
+// SubscribeGroupedOrderBook provides a mock function with given fields: conn, bt, qt, decimals
+func (_m *OrderBookService) SubscribeGroupedOrderBook(conn *ws.Conn, bt common.Address, qt common.Address, decimals int) {
+	_m.Called(conn, bt, qt, decimals)
+}
+
+// UnSubscribeGroupedOrderBook provides a mock function with given fields: conn, bt, qt, decimals
+func (_m *OrderBookService) UnSubscribeGroupedOrderBook(conn *ws.Conn, bt common.Address, qt common.Address, decimals int) {
+	_m.Called(conn, bt, qt, decimals)
+}
+
 // Unsubscribe provides a mock function with given fields: conn, bt, qt
 func (_m *OrderBookService) Unsubscribe(conn *ws.Conn, bt common.Address, qt common.Address) {
 	_m.Called(conn, bt, qt)
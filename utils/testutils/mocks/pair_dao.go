@@ -2,10 +2,12 @@
 
 package mocks
 
+import big "math/big"
 import bson "gopkg.in/mgo.v2/bson"
 import common "github.com/ethereum/go-ethereum/common"
 
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import types "github.com/Proofsuite/amp-matching-engine/types"
 
 // PairDao is an autogenerated mock type for the PairDao type
@@ -50,6 +52,29 @@ func (_m *PairDao) GetAll() ([]types.Pair, error) {
 	return r0, r1
 }
 
+// GetActive provides a mock function with given fields:
+func (_m *PairDao) GetActive() ([]types.Pair, error) {
+	ret := _m.Called()
+
+	var r0 []types.Pair
+	if rf, ok := ret.Get(0).(func() []types.Pair); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Pair)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByBuySellTokenAddress provides a mock function with given fields: buyToken, sellToken
 func (_m *PairDao) GetByBuySellTokenAddress(buyToken common.Address, sellToken common.Address) (*types.Pair, error) {
 	ret := _m.Called(buyToken, sellToken)
@@ -164,3 +189,45 @@ func (_m *PairDao) GetByTokenSymbols(baseTokenSymbol string, quoteTokenSymbol st
 
 	return r0, r1
 }
+
+// UpdateActive provides a mock function with given fields: baseToken, quoteToken, active
+func (_m *PairDao) UpdateActive(baseToken common.Address, quoteToken common.Address, active bool) error {
+	ret := _m.Called(baseToken, quoteToken, active)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, bool) error); ok {
+		r0 = rf(baseToken, quoteToken, active)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAuction provides a mock function with given fields: baseToken, quoteToken, endsAt, reference
+func (_m *PairDao) UpdateAuction(baseToken common.Address, quoteToken common.Address, endsAt *time.Time, reference *big.Int) error {
+	ret := _m.Called(baseToken, quoteToken, endsAt, reference)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, *time.Time, *big.Int) error); ok {
+		r0 = rf(baseToken, quoteToken, endsAt, reference)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePaused provides a mock function with given fields: baseToken, quoteToken, paused
+func (_m *PairDao) UpdatePaused(baseToken common.Address, quoteToken common.Address, paused bool) error {
+	ret := _m.Called(baseToken, quoteToken, paused)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, bool) error); ok {
+		r0 = rf(baseToken, quoteToken, paused)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
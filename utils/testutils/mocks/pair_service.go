@@ -2,11 +2,14 @@
 
 package mocks
 
+import big "math/big"
 import bson "gopkg.in/mgo.v2/bson"
 import common "github.com/ethereum/go-ethereum/common"
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 
 import types "github.com/Proofsuite/amp-matching-engine/types"
+import ws "github.com/Proofsuite/amp-matching-engine/ws"
 
 // PairService is an autogenerated mock type for the PairService type
 type PairService struct {
@@ -50,6 +53,29 @@ func (_m *PairService) GetAll() ([]types.Pair, error) {
 	return r0, r1
 }
 
+// GetActive provides a mock function with given fields:
+func (_m *PairService) GetActive() ([]types.Pair, error) {
+	ret := _m.Called()
+
+	var r0 []types.Pair
+	if rf, ok := ret.Get(0).(func() []types.Pair); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Pair)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: id
 func (_m *PairService) GetByID(id bson.ObjectId) (*types.Pair, error) {
 	ret := _m.Called(id)
@@ -95,3 +121,161 @@ func (_m *PairService) GetByTokenAddress(bt common.Address, qt common.Address) (
 
 	return r0, r1
 }
+
+// Pause provides a mock function with given fields: bt, qt
+func (_m *PairService) Pause(bt common.Address, qt common.Address) (*types.PairStatus, error) {
+	ret := _m.Called(bt, qt)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) *types.PairStatus); ok {
+		r0 = rf(bt, qt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
+		r1 = rf(bt, qt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetActive provides a mock function with given fields: bt, qt, active
+func (_m *PairService) SetActive(bt common.Address, qt common.Address, active bool) (*types.Pair, error) {
+	ret := _m.Called(bt, qt, active)
+
+	var r0 *types.Pair
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, bool) *types.Pair); ok {
+		r0 = rf(bt, qt, active)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Pair)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, bool) error); ok {
+		r1 = rf(bt, qt, active)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartAuction provides a mock function with given fields: bt, qt, duration, reference
+func (_m *PairService) StartAuction(bt common.Address, qt common.Address, duration time.Duration, reference *big.Int) (*types.PairStatus, error) {
+	ret := _m.Called(bt, qt, duration, reference)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, time.Duration, *big.Int) *types.PairStatus); ok {
+		r0 = rf(bt, qt, duration, reference)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, time.Duration, *big.Int) error); ok {
+		r1 = rf(bt, qt, duration, reference)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStatus provides a mock function with given fields: bt, qt
+func (_m *PairService) GetStatus(bt common.Address, qt common.Address) (*types.PairStatus, error) {
+	ret := _m.Called(bt, qt)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) *types.PairStatus); ok {
+		r0 = rf(bt, qt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
+		r1 = rf(bt, qt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Resume provides a mock function with given fields: bt, qt
+func (_m *PairService) Resume(bt common.Address, qt common.Address) (*types.PairStatus, error) {
+	ret := _m.Called(bt, qt)
+
+	var r0 *types.PairStatus
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) *types.PairStatus); ok {
+		r0 = rf(bt, qt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.PairStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
+		r1 = rf(bt, qt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BroadcastPairStatus provides a mock function with given fields: s
+func (_m *PairService) BroadcastPairStatus(s *types.PairStatus) {
+	_m.Called(s)
+}
+
+// GetTicker provides a mock function with given fields: bt, qt
+func (_m *PairService) GetTicker(bt common.Address, qt common.Address) (*types.Ticker, error) {
+	ret := _m.Called(bt, qt)
+
+	var r0 *types.Ticker
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) *types.Ticker); ok {
+		r0 = rf(bt, qt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Ticker)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
+		r1 = rf(bt, qt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeTicker provides a mock function with given fields: conn, bt, qt
+func (_m *PairService) SubscribeTicker(conn *ws.Conn, bt common.Address, qt common.Address) {
+	_m.Called(conn, bt, qt)
+}
+
+// UnsubscribeTicker provides a mock function with given fields: conn, bt, qt
+func (_m *PairService) UnsubscribeTicker(conn *ws.Conn, bt common.Address, qt common.Address) {
+	_m.Called(conn, bt, qt)
+}
+
+// BroadcastTicker provides a mock function with given fields: t
+func (_m *PairService) BroadcastTicker(t *types.Ticker) {
+	_m.Called(t)
+}
@@ -126,6 +126,34 @@ func (_m *EthereumProvider) GetPendingNonceAt(a common.Address) (uint64, error)
 	return r0, r1
 }
 
+// TokenMetadata provides a mock function with given fields: token
+func (_m *EthereumProvider) TokenMetadata(token common.Address) (string, uint8, error) {
+	ret := _m.Called(token)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(common.Address) string); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 uint8
+	if rf, ok := ret.Get(1).(func(common.Address) uint8); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Get(1).(uint8)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address) error); ok {
+		r2 = rf(token)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // WaitMined provides a mock function with given fields: hash
 func (_m *EthereumProvider) WaitMined(hash common.Hash) (*types.Receipt, error) {
 	ret := _m.Called(hash)
@@ -13,13 +13,13 @@ type TokenService struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: token
-func (_m *TokenService) Create(token *types.Token) error {
-	ret := _m.Called(token)
+// Create provides a mock function with given fields: token, force
+func (_m *TokenService) Create(token *types.Token, force bool) error {
+	ret := _m.Called(token, force)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*types.Token) error); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(*types.Token, bool) error); ok {
+		r0 = rf(token, force)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -141,3 +141,31 @@ func (_m *TokenService) GetQuoteTokens() ([]types.Token, error) {
 
 	return r0, r1
 }
+
+// ResolveTokenMetadata provides a mock function with given fields: addr
+func (_m *TokenService) ResolveTokenMetadata(addr common.Address) (string, uint8, error) {
+	ret := _m.Called(addr)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(common.Address) string); ok {
+		r0 = rf(addr)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 uint8
+	if rf, ok := ret.Get(1).(func(common.Address) uint8); ok {
+		r1 = rf(addr)
+	} else {
+		r1 = ret.Get(1).(uint8)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address) error); ok {
+		r2 = rf(addr)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
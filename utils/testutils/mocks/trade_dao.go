@@ -2,10 +2,10 @@
 
 package mocks
 
-import bson "gopkg.in/mgo.v2/bson"
 import common "github.com/ethereum/go-ethereum/common"
 
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import types "github.com/Proofsuite/amp-matching-engine/types"
 
 // TradeDao is an autogenerated mock type for the TradeDao type
@@ -13,29 +13,6 @@ type TradeDao struct {
 	mock.Mock
 }
 
-// Aggregate provides a mock function with given fields: q
-func (_m *TradeDao) Aggregate(q []bson.M) ([]*types.Tick, error) {
-	ret := _m.Called(q)
-
-	var r0 []*types.Tick
-	if rf, ok := ret.Get(0).(func([]bson.M) []*types.Tick); ok {
-		r0 = rf(q)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*types.Tick)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func([]bson.M) error); ok {
-		r1 = rf(q)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
-
 // Create provides a mock function with given fields: o
 func (_m *TradeDao) Create(o ...*types.Trade) error {
 	_va := make([]interface{}, len(o))
@@ -56,6 +33,20 @@ func (_m *TradeDao) Create(o ...*types.Trade) error {
 	return r0
 }
 
+// CreateBatch provides a mock function with given fields: trades
+func (_m *TradeDao) CreateBatch(trades []*types.Trade) error {
+	ret := _m.Called(trades)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]*types.Trade) error); ok {
+		r0 = rf(trades)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Drop provides a mock function with given fields:
 func (_m *TradeDao) Drop() {
 	_m.Called()
@@ -130,13 +121,13 @@ func (_m *TradeDao) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
 	return r0, r1
 }
 
-// GetByPairAddress provides a mock function with given fields: baseToken, quoteToken
-func (_m *TradeDao) GetByPairAddress(baseToken common.Address, quoteToken common.Address) ([]*types.Trade, error) {
-	ret := _m.Called(baseToken, quoteToken)
+// GetByOrderHashes provides a mock function with given fields: hashes
+func (_m *TradeDao) GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error) {
+	ret := _m.Called(hashes)
 
 	var r0 []*types.Trade
-	if rf, ok := ret.Get(0).(func(common.Address, common.Address) []*types.Trade); ok {
-		r0 = rf(baseToken, quoteToken)
+	if rf, ok := ret.Get(0).(func([]common.Hash) []*types.Trade); ok {
+		r0 = rf(hashes)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Trade)
@@ -144,8 +135,8 @@ func (_m *TradeDao) GetByPairAddress(baseToken common.Address, quoteToken common
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
-		r1 = rf(baseToken, quoteToken)
+	if rf, ok := ret.Get(1).(func([]common.Hash) error); ok {
+		r1 = rf(hashes)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -153,6 +144,36 @@ func (_m *TradeDao) GetByPairAddress(baseToken common.Address, quoteToken common
 	return r0, r1
 }
 
+// GetByPairAddress provides a mock function with given fields: baseToken, quoteToken, cursor, limit
+func (_m *TradeDao) GetByPairAddress(baseToken common.Address, quoteToken common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	ret := _m.Called(baseToken, quoteToken, cursor, limit)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, string, int) []*types.Trade); ok {
+		r0 = rf(baseToken, quoteToken, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, string, int) string); ok {
+		r1 = rf(baseToken, quoteToken, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, common.Address, string, int) error); ok {
+		r2 = rf(baseToken, quoteToken, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetByPairName provides a mock function with given fields: name
 func (_m *TradeDao) GetByPairName(name string) ([]*types.Trade, error) {
 	ret := _m.Called(name)
@@ -176,13 +197,66 @@ func (_m *TradeDao) GetByPairName(name string) ([]*types.Trade, error) {
 	return r0, r1
 }
 
-// GetByUserAddress provides a mock function with given fields: addr
-func (_m *TradeDao) GetByUserAddress(addr common.Address) ([]*types.Trade, error) {
-	ret := _m.Called(addr)
+// GetByUserAddress provides a mock function with given fields: addr, cursor, limit
+func (_m *TradeDao) GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	ret := _m.Called(addr, cursor, limit)
 
 	var r0 []*types.Trade
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Trade); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(common.Address, string, int) []*types.Trade); ok {
+		r0 = rf(addr, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, string, int) string); ok {
+		r1 = rf(addr, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, string, int) error); ok {
+		r2 = rf(addr, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetOHLCV provides a mock function with given fields: pairs, duration, unit, from, to
+func (_m *TradeDao) GetOHLCV(pairs []types.PairSubDoc, duration int64, unit string, from time.Time, to time.Time) ([]*types.Tick, error) {
+	ret := _m.Called(pairs, duration, unit, from, to)
+
+	var r0 []*types.Tick
+	if rf, ok := ret.Get(0).(func([]types.PairSubDoc, int64, string, time.Time, time.Time) []*types.Tick); ok {
+		r0 = rf(pairs, duration, unit, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Tick)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]types.PairSubDoc, int64, string, time.Time, time.Time) error); ok {
+		r1 = rf(pairs, duration, unit, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingByPairAddress provides a mock function with given fields: baseToken, quoteToken
+func (_m *TradeDao) GetPendingByPairAddress(baseToken common.Address, quoteToken common.Address) ([]*types.Trade, error) {
+	ret := _m.Called(baseToken, quoteToken)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address) []*types.Trade); ok {
+		r0 = rf(baseToken, quoteToken)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Trade)
@@ -190,8 +264,8 @@ func (_m *TradeDao) GetByUserAddress(addr common.Address) ([]*types.Trade, error
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
+		r1 = rf(baseToken, quoteToken)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -199,6 +273,22 @@ func (_m *TradeDao) GetByUserAddress(addr common.Address) ([]*types.Trade, error
 	return r0, r1
 }
 
+// StreamByAddress provides a mock function with given fields: addr, from, to
+func (_m *TradeDao) StreamByAddress(addr common.Address, from time.Time, to time.Time) types.TradeIterator {
+	ret := _m.Called(addr, from, to)
+
+	var r0 types.TradeIterator
+	if rf, ok := ret.Get(0).(func(common.Address, time.Time, time.Time) types.TradeIterator); ok {
+		r0 = rf(addr, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(types.TradeIterator)
+		}
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: t
 func (_m *TradeDao) Update(t *types.Trade) error {
 	ret := _m.Called(t)
@@ -4,7 +4,9 @@ package mocks
 
 import common "github.com/ethereum/go-ethereum/common"
 
+import io "io"
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import types "github.com/Proofsuite/amp-matching-engine/types"
 import ws "github.com/Proofsuite/amp-matching-engine/ws"
 
@@ -13,6 +15,20 @@ type TradeService struct {
 	mock.Mock
 }
 
+// ExportByUserAddress provides a mock function with given fields: w, addr, from, to, format
+func (_m *TradeService) ExportByUserAddress(w io.Writer, addr common.Address, from time.Time, to time.Time, format string) error {
+	ret := _m.Called(w, addr, from, to, format)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(io.Writer, common.Address, time.Time, time.Time, string) error); ok {
+		r0 = rf(w, addr, from, to, format)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetByHash provides a mock function with given fields: hash
 func (_m *TradeService) GetByHash(hash common.Hash) (*types.Trade, error) {
 	ret := _m.Called(hash)
@@ -59,13 +75,13 @@ func (_m *TradeService) GetByOrderHash(hash common.Hash) ([]*types.Trade, error)
 	return r0, r1
 }
 
-// GetByPairAddress provides a mock function with given fields: bt, qt
-func (_m *TradeService) GetByPairAddress(bt common.Address, qt common.Address) ([]*types.Trade, error) {
-	ret := _m.Called(bt, qt)
+// GetByOrderHashes provides a mock function with given fields: hashes
+func (_m *TradeService) GetByOrderHashes(hashes []common.Hash) ([]*types.Trade, error) {
+	ret := _m.Called(hashes)
 
 	var r0 []*types.Trade
-	if rf, ok := ret.Get(0).(func(common.Address, common.Address) []*types.Trade); ok {
-		r0 = rf(bt, qt)
+	if rf, ok := ret.Get(0).(func([]common.Hash) []*types.Trade); ok {
+		r0 = rf(hashes)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Trade)
@@ -73,8 +89,8 @@ func (_m *TradeService) GetByPairAddress(bt common.Address, qt common.Address) (
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address, common.Address) error); ok {
-		r1 = rf(bt, qt)
+	if rf, ok := ret.Get(1).(func([]common.Hash) error); ok {
+		r1 = rf(hashes)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -82,6 +98,36 @@ func (_m *TradeService) GetByPairAddress(bt common.Address, qt common.Address) (
 	return r0, r1
 }
 
+// GetByPairAddress provides a mock function with given fields: bt, qt, cursor, limit
+func (_m *TradeService) GetByPairAddress(bt common.Address, qt common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	ret := _m.Called(bt, qt, cursor, limit)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(common.Address, common.Address, string, int) []*types.Trade); ok {
+		r0 = rf(bt, qt, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, common.Address, string, int) string); ok {
+		r1 = rf(bt, qt, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, common.Address, string, int) error); ok {
+		r2 = rf(bt, qt, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetByPairName provides a mock function with given fields: p
 func (_m *TradeService) GetByPairName(p string) ([]*types.Trade, error) {
 	ret := _m.Called(p)
@@ -105,27 +151,34 @@ func (_m *TradeService) GetByPairName(p string) ([]*types.Trade, error) {
 	return r0, r1
 }
 
-// GetByUserAddress provides a mock function with given fields: addr
-func (_m *TradeService) GetByUserAddress(addr common.Address) ([]*types.Trade, error) {
-	ret := _m.Called(addr)
+// GetByUserAddress provides a mock function with given fields: addr, cursor, limit
+func (_m *TradeService) GetByUserAddress(addr common.Address, cursor string, limit int) ([]*types.Trade, string, error) {
+	ret := _m.Called(addr, cursor, limit)
 
 	var r0 []*types.Trade
-	if rf, ok := ret.Get(0).(func(common.Address) []*types.Trade); ok {
-		r0 = rf(addr)
+	if rf, ok := ret.Get(0).(func(common.Address, string, int) []*types.Trade); ok {
+		r0 = rf(addr, cursor, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*types.Trade)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Address) error); ok {
-		r1 = rf(addr)
+	var r1 string
+	if rf, ok := ret.Get(1).(func(common.Address, string, int) string); ok {
+		r1 = rf(addr, cursor, limit)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(string)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(common.Address, string, int) error); ok {
+		r2 = rf(addr, cursor, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetTrades provides a mock function with given fields: bt, qt
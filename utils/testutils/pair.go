@@ -16,5 +16,6 @@ func GetZRXWETHTestPair() *types.Pair {
 		PriceMultiplier:   big.NewInt(1e6),
 		QuoteTokenAddress: common.HexToAddress("0x276e16ada4b107332afd776691a7fbbaede168ef"),
 		QuoteTokenDecimal: 18,
+		Active:            true,
 	}
 }
@@ -1,7 +1,9 @@
 package units
 
 import (
+	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
 )
@@ -9,3 +11,80 @@ import (
 func Ethers(value int64) *big.Int {
 	return math.Mul(big.NewInt(1e18), big.NewInt(value))
 }
+
+// ToBaseUnit converts a decimal amount expressed in token units (eg. "1.5") to the corresponding
+// amount of base units of a token with the given number of decimals. Amounts with more fractional
+// digits than the token decimals are rejected instead of being truncated.
+func ToBaseUnit(amount string, decimals uint8) (*big.Int, error) {
+	parts := strings.Split(amount, ".")
+	if len(parts) > 2 || amount == "" || amount == "." {
+		return nil, fmt.Errorf("Invalid amount: %v", amount)
+	}
+
+	for _, p := range parts {
+		if strings.Trim(p, "0123456789") != "" {
+			return nil, fmt.Errorf("Invalid amount: %v", amount)
+		}
+	}
+
+	fraction := ""
+	if len(parts) == 2 {
+		fraction = strings.TrimRight(parts[1], "0")
+	}
+
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("Amount %v has more than %v decimals", amount, decimals)
+	}
+
+	digits := parts[0] + fraction + strings.Repeat("0", int(decimals)-len(fraction))
+	n, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid amount: %v", amount)
+	}
+
+	return n, nil
+}
+
+// FromBaseUnit converts an amount of base units of a token with the given number of decimals to
+// a decimal string with displayDecimals fractional digits. The amount is rounded half up when
+// displayDecimals is lower than the token decimals. A negative displayDecimals displays the
+// amount with the full token precision.
+func FromBaseUnit(n *big.Int, decimals uint8, displayDecimals int) string {
+	if n == nil {
+		n = big.NewInt(0)
+	}
+
+	if displayDecimals < 0 {
+		displayDecimals = int(decimals)
+	}
+
+	scaled := new(big.Int).Abs(n)
+	if displayDecimals < int(decimals) {
+		unit := math.Exp(big.NewInt(10), big.NewInt(int64(int(decimals)-displayDecimals)))
+		q, r := new(big.Int).QuoRem(scaled, unit, new(big.Int))
+		if math.Mul(r, big.NewInt(2)).Cmp(unit) >= 0 {
+			q = math.Add(q, big.NewInt(1))
+		}
+
+		scaled = q
+	} else {
+		scaled = math.Mul(scaled, math.Exp(big.NewInt(10), big.NewInt(int64(displayDecimals-int(decimals)))))
+	}
+
+	sign := ""
+	if n.Sign() < 0 && scaled.Sign() != 0 {
+		sign = "-"
+	}
+
+	digits := scaled.String()
+	if displayDecimals == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= displayDecimals {
+		digits = strings.Repeat("0", displayDecimals-len(digits)+1) + digits
+	}
+
+	i := len(digits) - displayDecimals
+	return sign + digits[:i] + "." + digits[i:]
+}
@@ -0,0 +1,102 @@
+package units
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToBaseUnit(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		decimals uint8
+		expected string
+	}{
+		{"1.5", 18, "1500000000000000000"},
+		{"0.0021", 18, "2100000000000000"},
+		{"12", 6, "12000000"},
+		{".5", 1, "5"},
+		{"1.", 2, "100"},
+		{"1.500", 1, "15"},
+		{"0", 18, "0"},
+		{"123456789.123456789", 9, "123456789123456789"},
+	}
+
+	for _, tc := range testCases {
+		n, err := ToBaseUnit(tc.amount, tc.decimals)
+		if err != nil {
+			t.Errorf("%v: %v", tc.amount, err)
+			continue
+		}
+
+		assert.Equal(t, tc.expected, n.String(), tc.amount)
+	}
+}
+
+func TestToBaseUnitInvalid(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		decimals uint8
+	}{
+		{"", 18},
+		{".", 18},
+		{"abc", 18},
+		{"1.2.3", 18},
+		{"-1", 18},
+		{"1e18", 18},
+		{"1.234", 2},
+		{"0.1", 0},
+	}
+
+	for _, tc := range testCases {
+		_, err := ToBaseUnit(tc.amount, tc.decimals)
+		assert.Error(t, err, tc.amount)
+	}
+}
+
+func TestFromBaseUnit(t *testing.T) {
+	testCases := []struct {
+		n               *big.Int
+		decimals        uint8
+		displayDecimals int
+		expected        string
+	}{
+		{big.NewInt(1500000000000000000), 18, 2, "1.50"},
+		{big.NewInt(2100000000000000), 18, -1, "0.002100000000000000"},
+		{big.NewInt(2150000000000000), 18, 3, "0.002"},
+		{big.NewInt(2500000000000000), 18, 3, "0.003"},
+		{big.NewInt(12000000), 6, 0, "12"},
+		{big.NewInt(5), 1, 3, "0.500"},
+		{big.NewInt(-15), 1, 1, "-1.5"},
+		{big.NewInt(-1), 2, 0, "0"},
+		{nil, 18, 2, "0.00"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, FromBaseUnit(tc.n, tc.decimals, tc.displayDecimals))
+	}
+}
+
+func TestBaseUnitRoundTrip(t *testing.T) {
+	roundTrip := func(b []byte, decimals uint8) bool {
+		decimals = decimals % 40
+		n := new(big.Int).SetBytes(b)
+
+		s := FromBaseUnit(n, decimals, int(decimals))
+		decoded, err := ToBaseUnit(s, decimals)
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+
+		return decoded.Cmp(n) == 0
+	}
+
+	config := &quick.Config{MaxCount: 1000, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(roundTrip, config); err != nil {
+		t.Error(err)
+	}
+}
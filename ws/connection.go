@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
@@ -19,6 +20,7 @@ const (
 	LiteOrderBookChannel = "order_book_lite"
 	OrderChannel         = "orders"
 	OHLCVChannel         = "ohlcv"
+	TickerChannel        = "ticker"
 )
 
 var logger = utils.Logger
@@ -31,9 +33,20 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// PingInterval is the interval between two pings sent to each websocket connection. Connections
+// that do not answer within two intervals are considered lost. 0 disables the heartbeat.
+var PingInterval time.Duration
+
 type Conn struct {
 	*websocket.Conn
 	mu sync.Mutex
+
+	// closed is closed once the connection is closed or lost
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// session is the authenticated session of the connection, if any (see Authenticate)
+	session *session
 }
 
 var connectionUnsubscribtions map[*Conn][]func(*Conn)
@@ -49,8 +62,12 @@ func ConnectionEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	conn := &Conn{c, sync.Mutex{}}
+	conn := NewConnection(c)
 	initConnection(conn)
+	conn.SetCloseHandler(wsCloseHandler(conn))
+	if PingInterval > 0 {
+		startHeartbeat(conn)
+	}
 
 	go func() {
 		// Recover in case of any panic in websocket. So that the app doesn't crash ===
@@ -72,6 +89,11 @@ func ConnectionEndpoint(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				logger.Error(err)
 				conn.Close()
+
+				// connections lost without a close message, or whose heartbeat timed out, are
+				// unsubscribed like closed connections
+				unsubscribeConnection(conn)
+				return
 			}
 
 			if messageType != 1 {
@@ -85,8 +107,6 @@ func ConnectionEndpoint(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			conn.SetCloseHandler(wsCloseHandler(conn))
-
 			if socketChannels[msg.Channel] == nil {
 				SendMessage(conn, msg.Channel, "ERROR", "INVALID_CHANNEL")
 			}
@@ -97,7 +117,37 @@ func ConnectionEndpoint(w http.ResponseWriter, r *http.Request) {
 }
 
 func NewConnection(conn *websocket.Conn) *Conn {
-	return &Conn{conn, sync.Mutex{}}
+	return &Conn{Conn: conn, closed: make(chan struct{})}
+}
+
+// startHeartbeat pings the connection every PingInterval until it is closed. The read deadline
+// of the connection is pushed back whenever a pong is received, so that reading from a
+// connection that stopped answering fails.
+func startHeartbeat(conn *Conn) {
+	timeout := 2 * PingInterval
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	go func() {
+		ticker := time.NewTicker(PingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-conn.closed:
+				return
+			case <-ticker.C:
+			}
+
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(PingInterval))
+			if err != nil {
+				logger.Error(err)
+				return
+			}
+		}
+	}()
 }
 
 // initConnection initializes connection in connectionUnsubscribtions map
@@ -156,11 +206,20 @@ func RegisterConnectionUnsubscribeHandler(conn *Conn, fn func(*Conn)) {
 // connection in a separate go routine
 func wsCloseHandler(conn *Conn) func(code int, text string) error {
 	return func(code int, text string) error {
+		unsubscribeConnection(conn)
+		return nil
+	}
+}
+
+// unsubscribeConnection triggers the UnsubscribeHandler associated with a closed or lost
+// connection, once, each in a separate go routine
+func unsubscribeConnection(conn *Conn) {
+	conn.closeOnce.Do(func() {
+		close(conn.closed)
 		for _, unsub := range connectionUnsubscribtions[conn] {
 			go unsub(conn)
 		}
-		return nil
-	}
+	})
 }
 
 // SendMessage constructs the message with proper structure to be sent over websocket
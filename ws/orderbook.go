@@ -2,6 +2,7 @@ package ws
 
 import (
 	"errors"
+	"strings"
 )
 
 var orderbook *OrderBookSocket
@@ -66,6 +67,41 @@ func (s *OrderBookSocket) BroadcastMessage(channelID string, p interface{}) erro
 	return nil
 }
 
+// BroadcastDiffMessage streams orderbook diffs to all the subscriptions subscribed to the pair
+func (s *OrderBookSocket) BroadcastDiffMessage(channelID string, p interface{}) {
+	for conn, status := range s.subscriptions[channelID] {
+		if status {
+			s.SendDiffMessage(conn, p)
+		}
+	}
+}
+
+// BroadcastErrorMessage sends an error message to all the subscriptions subscribed to the channel
+func (s *OrderBookSocket) BroadcastErrorMessage(channelID string, p interface{}) {
+	for conn, status := range s.subscriptions[channelID] {
+		if status {
+			s.SendErrorMessage(conn, p)
+		}
+	}
+}
+
+// BroadcastMarketStatus sends a MARKET_STATUS message to all the subscriptions subscribed to the pair
+// when the pair is listed or delisted, including the subscriptions to the grouped orderbook channels
+// of the pair
+func (s *OrderBookSocket) BroadcastMarketStatus(channelID string, p interface{}) {
+	for id, subscriptions := range s.subscriptions {
+		if id != channelID && !strings.HasPrefix(id, channelID+"::") {
+			continue
+		}
+
+		for conn, status := range subscriptions {
+			if status {
+				s.SendMessage(conn, "MARKET_STATUS", p)
+			}
+		}
+	}
+}
+
 // SendMessage sends a message on the orderbook channel
 func (s *OrderBookSocket) SendMessage(conn *Conn, msgType string, data interface{}) {
 	SendMessage(conn, LiteOrderBookChannel, msgType, data)
@@ -81,6 +117,11 @@ func (s *OrderBookSocket) SendInitMessage(conn *Conn, data interface{}) {
 	s.SendMessage(conn, "INIT", data)
 }
 
+// SendDiffMessage sends DIFF message on orderbookchannel with orderbook diffs
+func (s *OrderBookSocket) SendDiffMessage(conn *Conn, data interface{}) {
+	s.SendMessage(conn, "DIFF", data)
+}
+
 // SendUpdateMessage sends UPDATE message on orderbookchannel as new data is created
 func (s *OrderBookSocket) SendUpdateMessage(conn *Conn, data interface{}) {
 	s.SendMessage(conn, "UPDATE", data)
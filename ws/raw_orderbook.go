@@ -66,6 +66,16 @@ func (s *RawOrderBookSocket) BroadcastMessage(channelID string, p interface{}) e
 	return nil
 }
 
+// BroadcastMarketStatus sends a MARKET_STATUS message to all the subscriptions subscribed to the pair
+// when the pair is listed or delisted
+func (s *RawOrderBookSocket) BroadcastMarketStatus(channelID string, p interface{}) {
+	for conn, status := range s.subscriptions[channelID] {
+		if status {
+			s.SendMessage(conn, "MARKET_STATUS", p)
+		}
+	}
+}
+
 // SendMessage sends a message on the orderbook channel
 func (s *RawOrderBookSocket) SendMessage(conn *Conn, msgType string, data interface{}) {
 	SendMessage(conn, RawOrderBookChannel, msgType, data)
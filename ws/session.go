@@ -0,0 +1,129 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DisconnectGracePeriod is the time after which the orders of a lost cancel on disconnect session
+// are cancelled. Authenticating a new session for the same address within this period aborts
+// the cancellation.
+var DisconnectGracePeriod = 5 * time.Second
+
+// session is an authenticated websocket session. The orders placed through a cancel on
+// disconnect session are tracked so that only these orders are cancelled once it is lost.
+type session struct {
+	address            common.Address
+	cancelOnDisconnect bool
+	orders             map[common.Hash]bool
+}
+
+// pendingCancellation holds the orders of a lost cancel on disconnect session until the end of
+// the grace period
+type pendingCancellation struct {
+	orders map[common.Hash]bool
+	timer  *time.Timer
+}
+
+var sessionsMutex sync.Mutex
+var pendingCancellations = map[common.Address]*pendingCancellation{}
+
+// Authenticate sets the session of a connection once its address has been authenticated. When
+// cancelOnDisconnect is set, the orders tracked by the session (see TrackSessionOrder) are passed
+// to cancel once the connection is closed or lost and the grace period is over. A pending
+// cancellation of the orders of an earlier session of the address is aborted, the orders being
+// tracked by the new session if it is also a cancel on disconnect session.
+func Authenticate(conn *Conn, addr common.Address, cancelOnDisconnect bool, cancel func(addr common.Address, hashes []common.Hash)) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	// the unsubscribe handler closes whichever session the connection has when it is closed
+	registered := conn.session != nil
+	if registered && conn.session.address != addr {
+		closeSession(conn, cancel)
+	}
+
+	s := &session{address: addr, cancelOnDisconnect: cancelOnDisconnect, orders: map[common.Hash]bool{}}
+	if conn.session != nil && conn.session.cancelOnDisconnect && cancelOnDisconnect {
+		s.orders = conn.session.orders
+	}
+
+	if p := pendingCancellations[addr]; p != nil {
+		p.timer.Stop()
+		delete(pendingCancellations, addr)
+		logger.Infof("Cancellation of the orders of %v aborted", addr.Hex())
+
+		if cancelOnDisconnect {
+			for h := range p.orders {
+				s.orders[h] = true
+			}
+		}
+	}
+
+	conn.session = s
+	if !registered {
+		RegisterConnectionUnsubscribeHandler(conn, func(conn *Conn) {
+			sessionsMutex.Lock()
+			defer sessionsMutex.Unlock()
+
+			closeSession(conn, cancel)
+		})
+	}
+}
+
+// TrackSessionOrder adds an order of an address to the orders of the session of a connection, if
+// the connection is a cancel on disconnect session of this address
+func TrackSessionOrder(conn *Conn, addr common.Address, h common.Hash) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	s := conn.session
+	if s == nil || !s.cancelOnDisconnect || s.address != addr {
+		return
+	}
+
+	s.orders[h] = true
+}
+
+// closeSession schedules the cancellation of the orders of the session of a connection at the
+// end of the grace period, if it is a cancel on disconnect session. The orders of the other lost
+// sessions of the address are cancelled along with them. sessionsMutex must be held.
+func closeSession(conn *Conn, cancel func(addr common.Address, hashes []common.Hash)) {
+	s := conn.session
+	conn.session = nil
+	if s == nil || !s.cancelOnDisconnect || len(s.orders) == 0 {
+		return
+	}
+
+	addr := s.address
+	p := &pendingCancellation{orders: s.orders}
+	if previous := pendingCancellations[addr]; previous != nil {
+		previous.timer.Stop()
+		for h := range previous.orders {
+			p.orders[h] = true
+		}
+	}
+
+	pendingCancellations[addr] = p
+
+	p.timer = time.AfterFunc(DisconnectGracePeriod, func() {
+		sessionsMutex.Lock()
+		if pendingCancellations[addr] != p {
+			sessionsMutex.Unlock()
+			return
+		}
+
+		delete(pendingCancellations, addr)
+		sessionsMutex.Unlock()
+
+		hashes := []common.Hash{}
+		for h := range p.orders {
+			hashes = append(hashes, h)
+		}
+
+		logger.Infof("Cancelling the %v orders of the lost session of %v", len(hashes), addr.Hex())
+		cancel(addr, hashes)
+	})
+}
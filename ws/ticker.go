@@ -0,0 +1,72 @@
+package ws
+
+var tickerSocket *TickerSocket
+
+// TickerSocket holds the map of connections subscribed to the ticker of pairs
+type TickerSocket struct {
+	subscriptions map[string]map[*Conn]bool
+}
+
+// GetTickerSocket returns the singleton instance of TickerSocket
+func GetTickerSocket() *TickerSocket {
+	if tickerSocket == nil {
+		tickerSocket = &TickerSocket{make(map[string]map[*Conn]bool)}
+	}
+
+	return tickerSocket
+}
+
+// Subscribe registers a new websocket connection to the ticker updates of a pair
+func (s *TickerSocket) Subscribe(channelID string, conn *Conn) error {
+	if s.subscriptions[channelID] == nil {
+		s.subscriptions[channelID] = make(map[*Conn]bool)
+	}
+
+	s.subscriptions[channelID][conn] = true
+	return nil
+}
+
+// Unsubscribe removes a websocket connection from the ticker updates of a pair
+func (s *TickerSocket) Unsubscribe(channelID string, conn *Conn) {
+	if s.subscriptions[channelID][conn] {
+		s.subscriptions[channelID][conn] = false
+		delete(s.subscriptions[channelID], conn)
+	}
+}
+
+// UnsubscribeHandler unsubscribes a connection from a certain ticker channel id
+func (s *TickerSocket) UnsubscribeHandler(channelID string) func(conn *Conn) {
+	return func(conn *Conn) {
+		s.Unsubscribe(channelID, conn)
+	}
+}
+
+// BroadcastMessage broadcasts a ticker update to the connections subscribed to the ticker of a
+// pair. The updates are sent in the order they are broadcast.
+func (s *TickerSocket) BroadcastMessage(channelID string, p interface{}) {
+	for conn, active := range s.subscriptions[channelID] {
+		if active {
+			s.SendUpdateMessage(conn, p)
+		}
+	}
+}
+
+// SendMessage sends a websocket message on the ticker channel
+func (s *TickerSocket) SendMessage(conn *Conn, msgType string, p interface{}) {
+	SendMessage(conn, TickerChannel, msgType, p)
+}
+
+// SendErrorMessage sends an error message on the ticker channel
+func (s *TickerSocket) SendErrorMessage(conn *Conn, p interface{}) {
+	s.SendMessage(conn, "ERROR", p)
+}
+
+// SendInitMessage sends the current ticker of a pair on the ticker channel at subscription
+func (s *TickerSocket) SendInitMessage(conn *Conn, p interface{}) {
+	s.SendMessage(conn, "INIT", p)
+}
+
+// SendUpdateMessage sends a ticker update on the ticker channel
+func (s *TickerSocket) SendUpdateMessage(conn *Conn, p interface{}) {
+	s.SendMessage(conn, "UPDATE", p)
+}